@@ -11,9 +11,12 @@ import (
 
 	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/config"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/middleware"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/http/router"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/logger"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/repository"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/blob"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/service"
 )
@@ -30,6 +33,11 @@ func run() error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	if cfg.InviteLogin != "" {
+		fmt.Println(service.InviteCode(cfg.InviteKey, cfg.InviteLogin))
+		return nil
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer stop()
 
@@ -54,16 +62,99 @@ func run() error {
 		return fmt.Errorf("failed to load jwt ppublic key: %w", err)
 	}
 
+	// Пустой путь - шифрование тел запросов выключено, ключ nil
+	payloadKey, err := crypto.LoadPrivateKey(cfg.PayloadPriv)
+	if err != nil {
+		return fmt.Errorf("failed to load payload private key: %w", err)
+	}
+
 	userRepository := repository.NewUser(db)
-	authService := service.NewAuth(userRepository, logger, jwtPublicKey, jwtPrivateKey, cfg.TokenTTL)
+	sessionRepository := repository.NewSession(db)
+	tokenRepository := repository.NewRefreshToken(db)
+	totpRepository := repository.NewTOTP(db)
+	authService := service.NewAuth(
+		userRepository,
+		sessionRepository,
+		tokenRepository,
+		totpRepository,
+		logger,
+		jwtPublicKey,
+		jwtPrivateKey,
+		cfg.TokenTTL,
+		cfg.RefreshTokenTTL,
+	)
+	authService.SetLockout(repository.NewLoginAttempt(db), entity.LockoutPolicy{
+		Threshold: cfg.LoginLockThreshold,
+		Base:      cfg.LoginLockBase,
+		Max:       cfg.LoginLockMax,
+		Reset:     cfg.LoginLockReset,
+	})
+	authService.SetHashLimit(cfg.HashConcurrency)
+	authService.SetInviteKey(cfg.InviteKey)
+	go authService.RunProfiles(ctx, cfg.AuthProfilesInterval)
+	go authService.RunLoginAttemptsGC(ctx, cfg.LoginLockGCInterval)
+
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to init blob store: %w", err)
+	}
+	blobCollector := service.NewBlobCollector(logger, repository.NewBlob(db), blobStore, cfg.BlobGCGrace, cfg.UploadTTL)
+	go blobCollector.Run(ctx, cfg.BlobGCInterval)
+
+	quota := entity.Quota{MaxBytes: cfg.QuotaBytes, MaxSecrets: cfg.QuotaSecrets}
+
+	secretRepository := repository.NewSecret(db, blobStore, cfg.BlobThreshold)
+	secretService := service.NewSecret(logger, secretRepository, quota)
 
-	secretRepository := repository.NewSecret(db)
-	secretService := service.NewSecret(logger, secretRepository)
+	uploadRepository := repository.NewUpload(db, blobStore)
+	uploadService := service.NewUpload(logger, uploadRepository, quota)
 
-	router := router.NewRouter(cfg, logger, authService, secretService)
+	sessionService := service.NewSession(logger, sessionRepository)
+
+	twoFactorService := service.NewTwoFactor(logger, userRepository, totpRepository)
+
+	recoveryService := service.NewRecovery(authService, repository.NewRecovery(db), secretRepository)
+
+	ipLimit := middleware.NewRateLimiter(cfg.AuthRateIP, cfg.AuthRateMaxKeys, cfg.AuthBodyMaxSize)
+	go ipLimit.Run(ctx, cfg.AuthRateGCInterval)
+	loginLimit := middleware.NewRateLimiter(cfg.AuthRateLogin, cfg.AuthRateMaxKeys, cfg.AuthBodyMaxSize)
+	go loginLimit.Run(ctx, cfg.AuthRateGCInterval)
+
+	router := router.NewRouter(
+		cfg,
+		logger,
+		authService,
+		secretService,
+		uploadService,
+		sessionService,
+		twoFactorService,
+		recoveryService,
+		payloadKey,
+		ipLimit,
+		loginLimit,
+	)
 	return sevreHTTPS(ctx, cfg, logger, router)
 }
 
+// blobStore хранилище данных файлов, используется репозиториями и сборщиком мусора.
+type blobStore interface {
+	repository.BlobStore
+	service.BlobStore
+}
+
+func newBlobStore(cfg *config.Config) (blobStore, error) {
+	if cfg.BlobStore == "s3" {
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}), nil
+	}
+	return blob.NewFileStore(cfg.BlobDir)
+}
+
 func sevreHTTPS(ctx context.Context, cfg *config.Config, logger *logger.Logger, router http.Handler) error {
 	srv := &http.Server{Addr: cfg.HTTPSaddr, Handler: router}
 	errChan := make(chan error, 1)
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+DROP INDEX IF EXISTS idx_secret_tombstones_user_id_revision;
+DROP TABLE IF EXISTS secret_tombstones;
+DROP INDEX IF EXISTS idx_secrets_user_id_revision;
+ALTER TABLE secrets DROP COLUMN IF EXISTS revision;
+DROP SEQUENCE IF EXISTS secret_revision_seq;
+COMMIT;
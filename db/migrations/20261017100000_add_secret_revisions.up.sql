@@ -0,0 +1,23 @@
+BEGIN TRANSACTION;
+
+-- Общий для всех пользователей счетчик ревизий, растет при каждом изменении секретов
+CREATE SEQUENCE IF NOT EXISTS secret_revision_seq;
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS revision BIGINT NOT NULL DEFAULT nextval('secret_revision_seq');
+
+CREATE INDEX IF NOT EXISTS idx_secrets_user_id_revision ON secrets(user_id, revision);
+
+CREATE TABLE IF NOT EXISTS secret_tombstones (
+    secret_id BIGINT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    revision BIGINT NOT NULL DEFAULT nextval('secret_revision_seq'),
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_secret_tombstones_user_id_revision ON secret_tombstones(user_id, revision);
+
+COMMENT ON TABLE secret_tombstones IS 'Stores ids of deleted secrets for the change feed.';
+COMMENT ON COLUMN secrets.revision IS 'revision of the last change';
+COMMENT ON COLUMN secret_tombstones.revision IS 'revision of the deletion';
+
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN TRANSACTION;
+ALTER TABLE secrets DROP COLUMN IF EXISTS version;
+COMMIT;
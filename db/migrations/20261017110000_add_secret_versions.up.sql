@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN secrets.version IS 'version counter, incremented on every update';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+ALTER TABLE secrets DROP COLUMN IF EXISTS chunks;
+ALTER TABLE secrets DROP COLUMN IF EXISTS upload_id;
+DROP TABLE IF EXISTS secret_chunks;
+DROP TABLE IF EXISTS secret_uploads;
+COMMIT;
@@ -0,0 +1,33 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS secret_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    -- Секрет, данные которого заменяются, NULL для нового секрета
+    secret_id BIGINT,
+    version BIGINT,
+    data_type secret_data_type NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    meta_data JSONB NOT NULL DEFAULT '[]'::jsonb,
+    encrypted_key VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    committed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS secret_chunks (
+    upload_id UUID NOT NULL REFERENCES secret_uploads(id) ON DELETE CASCADE,
+    n INTEGER NOT NULL,
+    data BYTEA NOT NULL,
+    PRIMARY KEY (upload_id, n)
+);
+
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS upload_id UUID REFERENCES secret_uploads(id),
+    ADD COLUMN IF NOT EXISTS chunks INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON TABLE secret_uploads IS 'Stores sessions of chunked secret uploads.';
+COMMENT ON TABLE secret_chunks IS 'Stores encrypted chunks of uploaded secrets.';
+COMMENT ON COLUMN secrets.upload_id IS 'upload with secret data, NULL if data is in encrypted_data';
+COMMENT ON COLUMN secrets.chunks IS 'number of data chunks';
+
+COMMIT;
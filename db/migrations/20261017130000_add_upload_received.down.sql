@@ -0,0 +1,3 @@
+BEGIN TRANSACTION;
+ALTER TABLE secret_uploads DROP COLUMN IF EXISTS received;
+COMMIT;
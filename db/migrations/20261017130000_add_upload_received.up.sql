@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE secret_uploads ADD COLUMN IF NOT EXISTS received INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN secret_uploads.received IS 'number of chunks received in sequence from the first one, upload resumes from this chunk';
+
+COMMIT;
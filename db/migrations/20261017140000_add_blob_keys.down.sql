@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+-- Части, данные которых только в хранилище, вернуть в БД нельзя
+DELETE FROM secret_chunks WHERE data IS NULL;
+ALTER TABLE secret_chunks ALTER COLUMN data SET NOT NULL;
+DROP INDEX IF EXISTS idx_secret_chunks_blob_key;
+DROP INDEX IF EXISTS idx_secrets_blob_key;
+ALTER TABLE secret_chunks DROP COLUMN IF EXISTS blob_key;
+ALTER TABLE secrets DROP COLUMN IF EXISTS blob_key;
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS blob_key TEXT;
+ALTER TABLE secret_chunks ADD COLUMN IF NOT EXISTS blob_key TEXT;
+ALTER TABLE secret_chunks ALTER COLUMN data DROP NOT NULL;
+
+-- По ключам сборщик мусора ищет объекты хранилища, на которые нет ссылок
+CREATE UNIQUE INDEX IF NOT EXISTS idx_secrets_blob_key ON secrets(blob_key) WHERE blob_key IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_secret_chunks_blob_key ON secret_chunks(blob_key) WHERE blob_key IS NOT NULL;
+
+COMMENT ON COLUMN secrets.blob_key IS 'key of encrypted data in blob store, NULL if data is in encrypted_data';
+COMMENT ON COLUMN secret_chunks.blob_key IS 'key of encrypted chunk in blob store, NULL if chunk is in data';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+DROP TRIGGER IF EXISTS secrets_user_usage ON secrets;
+DROP FUNCTION IF EXISTS update_user_usage();
+ALTER TABLE users DROP COLUMN IF EXISTS used_bytes, DROP COLUMN IF EXISTS secret_count;
+ALTER TABLE secret_chunks DROP COLUMN IF EXISTS size;
+ALTER TABLE secrets DROP COLUMN IF EXISTS size;
+COMMIT;
@@ -0,0 +1,41 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE secret_chunks ADD COLUMN IF NOT EXISTS size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS used_bytes BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS secret_count INTEGER NOT NULL DEFAULT 0;
+
+-- Размер данных, хранящихся в БД, известен, размер данных в хранилище остается 0
+UPDATE secret_chunks SET size = octet_length(data) WHERE data IS NOT NULL;
+UPDATE secrets s SET size = COALESCE(octet_length(s.encrypted_data), 0)
+    + COALESCE((SELECT SUM(c.size) FROM secret_chunks c WHERE c.upload_id = s.upload_id), 0);
+UPDATE users u SET
+    used_bytes = COALESCE((SELECT SUM(s.size) FROM secrets s WHERE s.user_id = u.id), 0),
+    secret_count = (SELECT COUNT(*) FROM secrets s WHERE s.user_id = u.id);
+
+-- Счетчики использования обновляются при любом изменении секретов
+CREATE OR REPLACE FUNCTION update_user_usage() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE users SET used_bytes = used_bytes - OLD.size, secret_count = secret_count - 1
+            WHERE id = OLD.user_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE users SET used_bytes = used_bytes + NEW.size, secret_count = secret_count + 1
+            WHERE id = NEW.user_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER secrets_user_usage
+    AFTER INSERT OR DELETE OR UPDATE OF size, user_id ON secrets
+    FOR EACH ROW EXECUTE FUNCTION update_user_usage();
+
+COMMENT ON COLUMN secrets.size IS 'size of encrypted data in bytes';
+COMMENT ON COLUMN secret_chunks.size IS 'size of encrypted chunk in bytes';
+COMMENT ON COLUMN users.used_bytes IS 'total size of encrypted data of user secrets';
+COMMENT ON COLUMN users.secret_count IS 'number of user secrets';
+
+COMMIT;
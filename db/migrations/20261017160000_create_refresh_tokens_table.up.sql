@@ -0,0 +1,21 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    hash VARCHAR(64) NOT NULL UNIQUE,
+    family UUID NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+COMMENT ON TABLE refresh_tokens IS 'Stores refresh tokens for issuing new access tokens.';
+COMMENT ON COLUMN refresh_tokens.hash IS 'SHA-256 hash of the token, the token itself is not stored';
+COMMENT ON COLUMN refresh_tokens.family IS 'chain of tokens issued by rotation starting from one login';
+COMMENT ON COLUMN refresh_tokens.revoked_at IS 'time of rotation or revocation, NULL for an active token';
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_session_id;
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS refresh_tokens_session_id_fkey;
+ALTER TABLE refresh_tokens RENAME COLUMN session_id TO family;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family);
+COMMENT ON COLUMN refresh_tokens.family IS 'chain of tokens issued by rotation starting from one login';
+DROP TABLE IF EXISTS sessions;
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    device VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+-- Токены, выданные до появления сессий, ни к одной сессии не относятся
+DELETE FROM refresh_tokens;
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+ALTER TABLE refresh_tokens RENAME COLUMN family TO session_id;
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_session_id_fkey
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+
+COMMENT ON TABLE sessions IS 'Stores user sessions, a session is created on login and removed on logout or revocation.';
+COMMENT ON COLUMN sessions.device IS 'device name reported by the client on login';
+COMMENT ON COLUMN sessions.ip IS 'client IP address of the last request';
+COMMENT ON COLUMN sessions.expires_at IS 'expiration time of the last refresh token of the session';
+COMMENT ON COLUMN refresh_tokens.session_id IS 'session the token was issued for';
+
+COMMIT;
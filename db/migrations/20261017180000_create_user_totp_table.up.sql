@@ -0,0 +1,26 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (user_id, hash)
+);
+
+COMMENT ON TABLE user_totp IS 'Stores TOTP secrets of users with two-factor authentication.';
+COMMENT ON COLUMN user_totp.secret IS 'base32 encoded TOTP secret';
+COMMENT ON COLUMN user_totp.enabled IS 'false until the user confirms the secret with a valid code';
+COMMENT ON COLUMN user_totp.last_step IS 'time step of the last accepted code, codes of this and earlier steps are rejected';
+COMMENT ON TABLE recovery_codes IS 'Stores one-time recovery codes for login without TOTP device.';
+COMMENT ON COLUMN recovery_codes.hash IS 'SHA-256 hash of the recovery code';
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN TRANSACTION;
+
+-- Удалить значение из перечисления нельзя, поэтому тип пересоздается без него
+DELETE FROM secret_uploads WHERE data_type = 'otp';
+DELETE FROM secrets WHERE data_type = 'otp';
+
+ALTER TYPE secret_data_type RENAME TO secret_data_type_old;
+CREATE TYPE secret_data_type AS ENUM ('credentials', 'card', 'file', 'text');
+ALTER TABLE secrets
+    ALTER COLUMN data_type TYPE secret_data_type USING data_type::text::secret_data_type;
+ALTER TABLE secret_uploads
+    ALTER COLUMN data_type TYPE secret_data_type USING data_type::text::secret_data_type;
+DROP TYPE secret_data_type_old;
+
+COMMIT;
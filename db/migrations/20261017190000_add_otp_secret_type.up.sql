@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+-- Новое значение перечисления нельзя использовать в той же транзакции,
+-- поэтому миграция только добавляет его
+ALTER TYPE secret_data_type ADD VALUE IF NOT EXISTS 'otp';
+
+COMMIT;
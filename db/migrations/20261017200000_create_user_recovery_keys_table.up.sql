@@ -0,0 +1,16 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS user_recovery_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    master_key VARCHAR(128) NOT NULL,
+    recovery_key VARCHAR(128) NOT NULL,
+    auth_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE user_recovery_keys IS 'Stores wrapped keys for password reset with a recovery key.';
+COMMENT ON COLUMN user_recovery_keys.master_key IS 'master key encrypted with a key derived from the recovery key, base64';
+COMMENT ON COLUMN user_recovery_keys.recovery_key IS 'recovery key encrypted with the master key, base64, used to rewrap master_key on password change';
+COMMENT ON COLUMN user_recovery_keys.auth_hash IS 'SHA-256 hash of the auth key derived from the recovery key';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+-- Ключи данных, зашифрованные ключом аккаунта, без него не расшифровать
+ALTER TABLE secret_uploads DROP COLUMN IF EXISTS key_version;
+ALTER TABLE secrets DROP COLUMN IF EXISTS key_version;
+ALTER TABLE users DROP COLUMN IF EXISTS account_key;
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS account_key VARCHAR(128);
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS key_version SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE secret_uploads ADD COLUMN IF NOT EXISTS key_version SMALLINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN users.account_key IS 'account key encrypted with the master key, base64, NULL until the client migrates the account';
+COMMENT ON COLUMN secrets.key_version IS 'key wrapping the DEK: 0 - master key, 1 - account key';
+COMMENT ON COLUMN secret_uploads.key_version IS 'key wrapping the DEK: 0 - master key, 1 - account key';
+COMMENT ON COLUMN user_recovery_keys.master_key IS 'master key (account key after migration) encrypted with a key derived from the recovery key, base64';
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+ALTER TABLE users DROP COLUMN IF EXISTS encr_kdf_threads;
+ALTER TABLE users DROP COLUMN IF EXISTS encr_kdf_memory;
+ALTER TABLE users DROP COLUMN IF EXISTS encr_kdf_time;
+COMMIT;
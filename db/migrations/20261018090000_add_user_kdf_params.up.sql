@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS encr_kdf_time INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS encr_kdf_memory INTEGER NOT NULL DEFAULT 65536;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS encr_kdf_threads SMALLINT NOT NULL DEFAULT 4;
+
+COMMENT ON COLUMN users.encr_kdf_time IS 'Argon2id iterations used by the client to derive the master key';
+COMMENT ON COLUMN users.encr_kdf_memory IS 'Argon2id memory in KB used by the client to derive the master key';
+COMMENT ON COLUMN users.encr_kdf_threads IS 'Argon2id parallelism used by the client to derive the master key';
+
+COMMIT;
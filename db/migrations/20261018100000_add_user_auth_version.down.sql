@@ -0,0 +1,3 @@
+BEGIN TRANSACTION;
+ALTER TABLE users DROP COLUMN IF EXISTS auth_version;
+COMMIT;
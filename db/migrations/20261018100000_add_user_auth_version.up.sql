@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_version SMALLINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN users.auth_version IS 'What hash stores: 0 - password hash, 1 - hash of the auth key derived by the client from the master key';
+
+COMMIT;
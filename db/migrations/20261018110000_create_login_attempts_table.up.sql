@@ -0,0 +1,15 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    login VARCHAR(64) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE login_attempts IS 'Stores failed login attempts, including attempts for logins that do not exist.';
+COMMENT ON COLUMN login_attempts.failures IS 'number of consecutive failed attempts, reset by a successful login';
+COMMENT ON COLUMN login_attempts.locked_until IS 'login attempts are rejected until this time';
+COMMENT ON COLUMN login_attempts.updated_at IS 'time of the last failed attempt, the counter restarts after a long pause';
+
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN TRANSACTION;
+ALTER TABLE secret_uploads DROP COLUMN IF EXISTS uid;
+ALTER TABLE secrets DROP COLUMN IF EXISTS uid;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS uid UUID;
+ALTER TABLE secret_uploads ADD COLUMN IF NOT EXISTS uid UUID;
+
+COMMENT ON COLUMN secrets.uid IS 'client generated secret id, encrypted data is bound to it, NULL for secrets created before';
+COMMENT ON COLUMN secret_uploads.uid IS 'client generated secret id, chunks are bound to it';
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS login_attempts_updated_at_idx;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+CREATE INDEX IF NOT EXISTS login_attempts_updated_at_idx ON login_attempts (updated_at);
+
+COMMIT;
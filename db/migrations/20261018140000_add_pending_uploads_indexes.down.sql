@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_secret_uploads_created_at_pending;
+DROP INDEX IF EXISTS idx_secret_uploads_user_id_pending;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+CREATE INDEX IF NOT EXISTS idx_secret_uploads_user_id_pending ON secret_uploads(user_id) WHERE committed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_secret_uploads_created_at_pending ON secret_uploads(created_at) WHERE committed_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+
+ALTER INDEX IF EXISTS idx_login_attempts_updated_at RENAME TO login_attempts_updated_at_idx;
+DELETE FROM login_attempts WHERE network <> '';
+ALTER TABLE login_attempts DROP CONSTRAINT IF EXISTS login_attempts_pkey;
+ALTER TABLE login_attempts DROP COLUMN IF EXISTS network;
+ALTER TABLE login_attempts ADD PRIMARY KEY (login);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE login_attempts ADD COLUMN IF NOT EXISTS network VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE login_attempts DROP CONSTRAINT IF EXISTS login_attempts_pkey;
+ALTER TABLE login_attempts ADD PRIMARY KEY (login, network);
+ALTER INDEX IF EXISTS login_attempts_updated_at_idx RENAME TO idx_login_attempts_updated_at;
+
+COMMENT ON COLUMN login_attempts.network IS 'client network the attempts come from, empty if the client IP is unknown';
+
+COMMIT;
@@ -29,6 +29,12 @@ var credentialsCmd = &cobra.Command{
 	RunE:  addCredentials,
 }
 
+var cardCmd = &cobra.Command{
+	Use:   "card",
+	Short: "Adds bank card data to the system",
+	RunE:  addCard,
+}
+
 var fileCmd = &cobra.Command{
 	Use:   "file <file_path>",
 	Short: "Adds a file to the system",
@@ -37,6 +43,12 @@ var fileCmd = &cobra.Command{
 	},
 }
 
+var otpKeyCmd = &cobra.Command{
+	Use:   "otp",
+	Short: "Adds one-time password generator (TOTP/HOTP) to the system",
+	RunE:  addOTP,
+}
+
 var textCmd = &cobra.Command{
 	Use:   "text",
 	Short: "Adds text data to the system",
@@ -68,59 +80,139 @@ func addCredentials(cmd *cobra.Command, args []string) error {
 		data,
 	)
 	if err != nil {
+		if savedLocally(cmd.OutOrStdout(), err) {
+			return nil
+		}
+		return fmt.Errorf("failed to send data to server: %w", err)
+	}
+	return nil
+}
+
+func addCard(cmd *cobra.Command, args []string) error {
+	name, err := prompt.SecretName()
+	if err != nil {
+		return err
+	}
+
+	card, err := prompt.Card()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(card)
+	if err != nil {
+		return fmt.Errorf("failed to encode card to json: %w", err)
+	}
+
+	err = secretService.Upload(
+		dto.SecretRequest{
+			Name:     name,
+			DataType: dto.SecretTypeCard,
+			Meta:     []dto.MetaData{},
+		},
+		data,
+	)
+	if err != nil {
+		if savedLocally(cmd.OutOrStdout(), err) {
+			return nil
+		}
 		return fmt.Errorf("failed to send data to server: %w", err)
 	}
 	return nil
 }
 
 func addFile(out io.Writer, path string) error {
-	// Проверяем доступность и размер файла
+	meta, err := fileMeta(path)
+	if err != nil {
+		return err
+	}
+
+	name, err := prompt.SecretName()
+	if err != nil {
+		return err
+	}
+
+	// Файл читается и шифруется по частям, целиком в память не загружается.
+	// Если предыдущая загрузка этого файла прервалась, она продолжится.
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	fmt.Fprintln(out, "sending the file to the server")
+	err = secretService.UploadFile(
+		dto.SecretRequest{
+			Name:     name,
+			DataType: dto.SecretTypeFile,
+			Meta:     meta,
+		},
+		file,
+		showProgress("sending"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send data to server: %w", err)
+	}
+	return nil
+}
+
+// fileMeta проверяет доступность и размер файла и формирует для него метаданные.
+func fileMeta(path string) ([]dto.MetaData, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
 	fileName := info.Name()
 	if info.IsDir() {
-		return fmt.Errorf("failed add file: the file \"%s\" is directory", info.Name())
+		return nil, fmt.Errorf("failed add file: the file \"%s\" is directory", info.Name())
 	}
 
 	size := info.Size()
 	if size > cfg.FileMaxSize {
-		return fmt.Errorf("file size (%d bytes) exceeds the limit of %d bytes", size, cfg.FileMaxSize)
+		return nil, fmt.Errorf("file size (%d bytes) exceeds the limit of %d bytes", size, cfg.FileMaxSize)
 	}
 
-	// Формируем метаданные
 	meta := []dto.MetaData{}
 	meta = append(meta, dto.MetaData{Name: MetaFileName, Value: fileName})
 
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute file path: %w", err)
+		return nil, fmt.Errorf("failed to get absolute file path: %w", err)
 	}
 	meta = append(meta, dto.MetaData{Name: MetaFilePath, Value: filepath.Dir(absPath)})
 
+	return meta, nil
+}
+
+func addOTP(cmd *cobra.Command, args []string) error {
 	name, err := prompt.SecretName()
 	if err != nil {
 		return err
 	}
 
-	// Читаем файл и отправляем в зашифрованном виде на сервер
-	data, err := os.ReadFile(path)
+	key, err := prompt.OTPKey()
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return err
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode otp to json: %w", err)
 	}
 
-	fmt.Fprintln(out, "sending the file to the server")
 	err = secretService.Upload(
 		dto.SecretRequest{
 			Name:     name,
-			DataType: dto.SecretTypeFile,
-			Meta:     meta,
+			DataType: dto.SecretTypeOTP,
+			Meta:     []dto.MetaData{},
 		},
 		data,
 	)
 	if err != nil {
+		if savedLocally(cmd.OutOrStdout(), err) {
+			return nil
+		}
 		return fmt.Errorf("failed to send data to server: %w", err)
 	}
 	return nil
@@ -146,6 +238,9 @@ func addText(cmd *cobra.Command, args []string) error {
 		[]byte(text),
 	)
 	if err != nil {
+		if savedLocally(cmd.OutOrStdout(), err) {
+			return nil
+		}
 		return fmt.Errorf("failed to send data to server: %w", err)
 	}
 
@@ -154,7 +249,9 @@ func addText(cmd *cobra.Command, args []string) error {
 
 func init() {
 	addCmd.AddCommand(credentialsCmd)
+	addCmd.AddCommand(cardCmd)
 	addCmd.AddCommand(fileCmd)
 	addCmd.AddCommand(textCmd)
+	addCmd.AddCommand(otpKeyCmd)
 	rootCmd.AddCommand(addCmd)
 }
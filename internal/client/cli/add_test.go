@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -124,6 +125,113 @@ func Test_addCredentials(t *testing.T) {
 	}
 }
 
+func Test_addCard(t *testing.T) {
+	card := dto.Card{Number: "4111111111111111", Holder: "IVAN IVANOV", Expiry: "12/99", CVV: "123"}
+	data, err := json.Marshal(card)
+	require.Nil(t, err, "Card json encode")
+
+	tests := []struct {
+		name    string
+		pSetup  func(t *testing.T) Prompt
+		sSetup  func(t *testing.T) SecretService
+		wantErr string
+	}{
+		{
+			name: "success",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					SecretName().Return("name", nil)
+				prompt.EXPECT().
+					Card().Return(card, nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Upload(
+						dto.SecretRequest{
+							Name:     "name",
+							DataType: dto.SecretTypeCard,
+							Meta:     []dto.MetaData{},
+						},
+						data,
+					).
+					Return(nil)
+				return service
+			},
+		},
+		{
+			name: "invalid_name",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					SecretName().Return("", fmt.Errorf("invalid name"))
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			wantErr: "invalid name",
+		},
+		{
+			name: "invalid_card",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					SecretName().Return("name", nil)
+				prompt.EXPECT().
+					Card().Return(dto.Card{}, fmt.Errorf("invalid card number checksum"))
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			wantErr: "invalid card number checksum",
+		},
+		{
+			name: "failed_to_send",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					SecretName().Return("name", nil)
+				prompt.EXPECT().
+					Card().Return(card, nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Upload(gomock.All(), gomock.All()).
+					Return(fmt.Errorf("authorization failed"))
+				return service
+			},
+			wantErr: "failed to send data to server: authorization failed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prompt = test.pSetup(t)
+			secretService = test.sSetup(t)
+
+			err := addCard(&cobra.Command{}, []string{})
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Add card error")
+		})
+	}
+}
+
 func Test_addFile(t *testing.T) {
 	tmpdir := t.TempDir()
 
@@ -163,15 +271,21 @@ func Test_addFile(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				service := mocks.NewMockSecretService(ctrl)
 				service.EXPECT().
-					Upload(
+					UploadFile(
 						dto.SecretRequest{
 							Name:     "name",
 							DataType: dto.SecretTypeFile,
 							Meta:     meta,
 						},
-						[]byte("file data"),
+						gomock.Any(),
+						gomock.Any(),
 					).
-					Return(nil)
+					DoAndReturn(func(_ dto.SecretRequest, file *os.File, _ func(done, total uint32)) error {
+						data, err := io.ReadAll(file)
+						require.Nil(t, err, "Read file")
+						assert.Equal(t, []byte("file data"), data, "File data")
+						return nil
+					})
 				return service
 			},
 		},
@@ -252,7 +366,7 @@ func Test_addFile(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				service := mocks.NewMockSecretService(ctrl)
 				service.EXPECT().
-					Upload(gomock.All(), gomock.All()).
+					UploadFile(gomock.All(), gomock.All(), gomock.All()).
 					Return(fmt.Errorf("sending error"))
 				return service
 			},
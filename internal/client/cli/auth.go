@@ -1,15 +1,29 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"io"
+
 	"github.com/spf13/cobra"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/http"
 )
 
 var registerCmd = &cobra.Command{
 	Use:   "register",
 	Short: "Register a new user in the GophKeeper system",
-	RunE:  register,
+	Long: "Registers a new user. With --recovery-key also creates a recovery key, " +
+		"which resets a forgotten password without losing data with the recover command.",
+	RunE: register,
 }
 
+// withRecoveryKey создать ключ восстановления при регистрации
+var withRecoveryKey bool
+
+// inviteCode код приглашения, если сервер регистрирует по приглашениям
+var inviteCode string
+
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Login the GophKeeper system",
@@ -21,6 +35,17 @@ func login(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+
+	err = authService.Login(cr)
+	if !errors.Is(err, http.ErrOTPRequired) {
+		return err
+	}
+
+	// у пользователя включена двухфакторная аутентификация, запрашиваем код
+	cr.OTP, err = prompt.OTP()
+	if err != nil {
+		return err
+	}
 	return authService.Login(cr)
 }
 
@@ -29,10 +54,36 @@ func register(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	return authService.Register(cr)
+	cr.Invite = inviteCode
+	if err := authService.Register(cr); err != nil {
+		return err
+	}
+
+	if withRecoveryKey {
+		return setupRecovery(cmd.OutOrStdout(), cr.Password)
+	}
+	return nil
+}
+
+// setupRecovery создает ключ восстановления, подтверждая его паролем, и показывает его код единственный раз.
+func setupRecovery(out io.Writer, password string) error {
+	code, err := authService.SetupRecovery(password)
+	if err != nil {
+		return fmt.Errorf("user registered, but failed to create recovery key: %w", err)
+	}
+
+	fmt.Fprintln(out, "Recovery key, resets a forgotten password with the recover command:")
+	fmt.Fprintln(out, code)
+	fmt.Fprintln(out, "Store it in a safe place, it will not be shown again.")
+	fmt.Fprintln(out, "Anyone with this key and your login can reset your password.")
+
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(registerCmd)
 	rootCmd.AddCommand(loginCmd)
+
+	registerCmd.Flags().BoolVar(&withRecoveryKey, "recovery-key", false, "Create a recovery key for a forgotten password")
+	registerCmd.Flags().StringVar(&inviteCode, "invite", "", "Invite code, if the server registers by invitation only")
 }
@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
 	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/http"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/cobra"
@@ -51,6 +53,49 @@ func Test_login(t *testing.T) {
 			},
 			wantErr: "some err",
 		},
+		{
+			name: "success_2fa",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					Credentials().Return(dto.Credentials{Login: "test13", Password: "password13"}, nil)
+				prompt.EXPECT().OTP().Return("123456", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				gomock.InOrder(
+					service.EXPECT().
+						Login(dto.Credentials{Login: "test13", Password: "password13"}).
+						Return(fmt.Errorf("%w: %w", http.ErrLoginFailed, http.ErrOTPRequired)),
+					service.EXPECT().
+						Login(dto.Credentials{Login: "test13", Password: "password13", OTP: "123456"}).
+						Return(nil),
+				)
+				return service
+			},
+		},
+		{
+			name: "empty_2fa_code",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					Credentials().Return(dto.Credentials{}, nil)
+				prompt.EXPECT().OTP().Return("", fmt.Errorf("code can not be empty"))
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Login(gomock.All()).Return(http.ErrOTPRequired)
+				return service
+			},
+			wantErr: "code can not be empty",
+		},
 	}
 
 	for _, test := range tests {
@@ -70,10 +115,13 @@ func Test_login(t *testing.T) {
 
 func Test_Register(t *testing.T) {
 	tests := []struct {
-		name    string
-		pSetup  func(t *testing.T) Prompt
-		sSetup  func(t *testing.T) AuthService
-		wantErr string
+		name        string
+		recoveryKey bool
+		invite      string
+		pSetup      func(t *testing.T) Prompt
+		sSetup      func(t *testing.T) AuthService
+		wantOutput  string
+		wantErr     string
 	}{
 		{
 			name: "success",
@@ -93,6 +141,57 @@ func Test_Register(t *testing.T) {
 				return service
 			},
 		},
+		{
+			name:        "success_with_recovery_key",
+			recoveryKey: true,
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RegisterCredentials().Return(dto.Credentials{}, nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				service := mocks.NewMockAuthService(gomock.NewController(t))
+				service.EXPECT().Register(gomock.Any()).Return(nil)
+				service.EXPECT().SetupRecovery(gomock.Any()).Return("ABCDE-FGHIJ", nil)
+				return service
+			},
+			wantOutput: "Recovery key, resets a forgotten password with the recover command:\n" +
+				"ABCDE-FGHIJ\n" +
+				"Store it in a safe place, it will not be shown again.\n" +
+				"Anyone with this key and your login can reset your password.\n",
+		},
+		{
+			name:   "invite_code",
+			invite: "abcd-efgh-ijkl-mnop",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RegisterCredentials().Return(dto.Credentials{Login: "user"}, nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				service := mocks.NewMockAuthService(gomock.NewController(t))
+				service.EXPECT().
+					Register(dto.Credentials{Login: "user", Invite: "abcd-efgh-ijkl-mnop"}).
+					Return(nil)
+				return service
+			},
+		},
+		{
+			name:        "recovery_key_error",
+			recoveryKey: true,
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RegisterCredentials().Return(dto.Credentials{}, nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				service := mocks.NewMockAuthService(gomock.NewController(t))
+				service.EXPECT().Register(gomock.Any()).Return(nil)
+				service.EXPECT().SetupRecovery(gomock.Any()).Return("", fmt.Errorf("some err"))
+				return service
+			},
+			wantErr: "user registered, but failed to create recovery key: some err",
+		},
 		{
 			name: "invalid_credentials",
 			pSetup: func(t *testing.T) Prompt {
@@ -115,13 +214,21 @@ func Test_Register(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			prompt = test.pSetup(t)
 			authService = test.sSetup(t)
+			withRecoveryKey = test.recoveryKey
+			inviteCode = test.invite
+			defer func() { withRecoveryKey, inviteCode = false, "" }()
+
+			var out bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&out)
 
-			err := register(&cobra.Command{}, []string{})
+			err := register(cmd, []string{})
 			var gotErr string
 			if err != nil {
 				gotErr = err.Error()
 			}
 			assert.Equal(t, test.wantErr, gotErr, "Register error")
+			assert.Equal(t, test.wantOutput, out.String(), "Register output")
 		})
 	}
 }
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var keep string
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "List unresolved synchronization conflicts",
+	Long: "Displays secrets whose local changes could not be sent on sync " +
+		"because they were changed or deleted on another client.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listConflicts(os.Stdout)
+	},
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <id>",
+	Short: "Resolve synchronization conflict by secret ID",
+	Long: "Resolves conflict keeping local version (local), server version (remote) " +
+		"or both versions, local one is saved as a new secret (both).",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return resolveConflict(os.Stdout, args[0])
+	},
+}
+
+func listConflicts(out io.Writer) error {
+	list, err := secretService.Conflicts()
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(out, "no conflicts")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tType\tLocalName\tRemoteName")
+	for _, item := range list {
+		remoteName := item.RemoteName
+		if item.RemoteDeleted {
+			remoteName = "<deleted>"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", item.ID, item.DataType, item.LocalName, remoteName)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func resolveConflict(out io.Writer, argID string) error {
+	id, err := strconv.ParseUint(argID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("id must be a number")
+	}
+
+	if err := secretService.ResolveConflict(id, keep); err != nil {
+		return fmt.Errorf("failed to resolve conflict: %w", err)
+	}
+
+	fmt.Fprintf(out, "conflict for secret %d resolved, run \"sync\" to get the result\n", id)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+	conflictsCmd.AddCommand(resolveCmd)
+
+	resolveCmd.Flags().StringVarP(&keep, "keep", "k", "", "Version to keep: local, remote or both")
+	resolveCmd.MarkFlagRequired("keep")
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_listConflicts(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SecretService
+		want  want
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Conflicts().
+					Return([]service.Conflict{
+						{ID: 13, DataType: "text", LocalName: "local", RemoteName: "remote"},
+						{ID: 14, DataType: "file", LocalName: "file", RemoteDeleted: true},
+					}, nil)
+				return secretService
+			},
+			want: want{
+				output: "ID   Type   LocalName   RemoteName\n" +
+					"13   text   local       remote\n" +
+					"14   file   file        <deleted>\n",
+			},
+		},
+		{
+			name: "no_conflicts",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Conflicts().
+					Return([]service.Conflict{}, nil)
+				return secretService
+			},
+			want: want{
+				output: "no conflicts\n",
+			},
+		},
+		{
+			name: "failed_to_get",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Conflicts().
+					Return(nil, fmt.Errorf("authorization failed"))
+				return secretService
+			},
+			want: want{
+				err: "authorization failed",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secretService = test.setup(t)
+
+			out := new(bytes.Buffer)
+			err := listConflicts(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Conflicts error")
+			assert.Equal(t, test.want.output, out.String(), "Conflicts output")
+		})
+	}
+}
+
+func Test_resolveConflict(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name     string
+		secretID string
+		keep     string
+		setup    func(t *testing.T) SecretService
+		want     want
+	}{
+		{
+			name:     "success",
+			secretID: "13",
+			keep:     service.KeepBoth,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					ResolveConflict(uint64(13), service.KeepBoth).
+					Return(nil)
+				return secretService
+			},
+			want: want{
+				output: "conflict for secret 13 resolved, run \"sync\" to get the result\n",
+			},
+		},
+		{
+			name:     "failed_to_resolve",
+			secretID: "13",
+			keep:     "mine",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					ResolveConflict(uint64(13), "mine").
+					Return(service.ErrConflictInvalidKeep)
+				return secretService
+			},
+			want: want{
+				err: "failed to resolve conflict: keep must be one of: local, remote, both",
+			},
+		},
+		{
+			name:     "invalid_id",
+			secretID: "text_id",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				err: "id must be a number",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secretService = test.setup(t)
+			keep = test.keep
+
+			out := new(bytes.Buffer)
+			err := resolveConflict(out, test.secretID)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Resolve error")
+			assert.Equal(t, test.want.output, out.String(), "Resolve output")
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var force bool
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete secret from system by ID",
+	Long:  "Deletes secret from the server after confirmation.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return deleteSecret(os.Stdout, args[0])
+	},
+}
+
+func deleteSecret(out io.Writer, argID string) error {
+	id, err := strconv.ParseUint(argID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("id must be a number")
+	}
+
+	if !force && !prompt.ConfirmDelete(id) {
+		fmt.Fprintln(out, "deletion canceled")
+		return nil
+	}
+
+	if err := secretService.Delete(id); err != nil {
+		if savedLocally(out, err) {
+			return nil
+		}
+		return err
+	}
+
+	fmt.Fprintf(out, "secret %d deleted\n", id)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().BoolVarP(&force, "force", "f", false, "Delete without confirmation")
+}
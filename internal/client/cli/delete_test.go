@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deleteSecret(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name     string
+		secretID string
+		force    bool
+		pSetup   func(t *testing.T) Prompt
+		sSetup   func(t *testing.T) SecretService
+		want     want
+	}{
+		{
+			name:     "success",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					ConfirmDelete(uint64(13)).Return(true)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Delete(uint64(13)).Return(nil)
+				return service
+			},
+			want: want{
+				output: "secret 13 deleted\n",
+			},
+		},
+		{
+			name:     "success_force",
+			secretID: "13",
+			force:    true,
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockPrompt(ctrl)
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Delete(uint64(13)).Return(nil)
+				return service
+			},
+			want: want{
+				output: "secret 13 deleted\n",
+			},
+		},
+		{
+			name:     "canceled",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					ConfirmDelete(uint64(13)).Return(false)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				output: "deletion canceled\n",
+			},
+		},
+		{
+			name:     "failed_to_delete",
+			secretID: "13",
+			force:    true,
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockPrompt(ctrl)
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Delete(uint64(13)).Return(fmt.Errorf("failed to delete secret: not found"))
+				return service
+			},
+			want: want{
+				err: "failed to delete secret: not found",
+			},
+		},
+		{
+			name:     "invalid_id",
+			secretID: "text_id",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockPrompt(ctrl)
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				err: "id must be a number",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prompt = test.pSetup(t)
+			secretService = test.sSetup(t)
+			force = test.force
+
+			out := new(bytes.Buffer)
+			err := deleteSecret(out, test.secretID)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Delete error")
+			assert.Equal(t, test.want.output, out.String(), "Delete output")
+		})
+	}
+}
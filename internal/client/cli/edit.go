@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+var editFilePath string
+
+var editCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Edit secret in system by ID",
+	Long: "Changes name and data of the secret. " +
+		"For file type new content is taken from the file passed with --file flag.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editSecret(os.Stdout, args[0])
+	},
+}
+
+func editSecret(out io.Writer, argID string) error {
+	id, err := strconv.ParseUint(argID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("id must be a number")
+	}
+
+	data, info, err := secretService.GetSecretAndInfo(id)
+	if err != nil {
+		return err
+	}
+
+	name, err := prompt.EditSecretName(info.Name)
+	if err != nil {
+		return err
+	}
+
+	meta := info.Meta
+	if meta == nil {
+		meta = []dto.MetaData{}
+	}
+
+	switch {
+	case info.DataType == dto.SecretTypeFile:
+		// содержимое файла меняется только если передан новый файл,
+		// иначе данные не отправляются и на сервере остаются прежними
+		if editFilePath != "" {
+			return editFile(out, id, name, info.Version)
+		}
+		data = nil
+	case prompt.EditData():
+		data, err = promptSecretData(info.DataType)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = secretService.Update(
+		id,
+		dto.SecretUpdateRequest{
+			Name:    name,
+			Meta:    meta,
+			Version: info.Version,
+		},
+		data,
+	)
+	if err != nil {
+		if savedLocally(out, err) {
+			return nil
+		}
+		return fmt.Errorf("failed to send data to server: %w", err)
+	}
+
+	fmt.Fprintf(out, "secret %d updated\n", id)
+	return nil
+}
+
+// editFile заменяет содержимое файлового секрета файлом editFilePath, отправляя его по частям.
+func editFile(out io.Writer, id uint64, name string, version uint64) error {
+	meta, err := fileMeta(editFilePath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(editFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	err = secretService.UpdateFile(
+		id,
+		dto.SecretUpdateRequest{
+			Name:    name,
+			Meta:    meta,
+			Version: version,
+		},
+		file,
+		showProgress("sending"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send data to server: %w", err)
+	}
+
+	fmt.Fprintf(out, "secret %d updated\n", id)
+	return nil
+}
+
+// promptSecretData запрашивает у пользователя новые данные секрета в зависимости от его типа.
+func promptSecretData(dataType string) ([]byte, error) {
+	switch dataType {
+	case dto.SecretTypeCredentials:
+		credentials, err := prompt.Credentials()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode credentials to json: %w", err)
+		}
+		return data, nil
+	case dto.SecretTypeCard:
+		card, err := prompt.Card()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(card)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode card to json: %w", err)
+		}
+		return data, nil
+	case dto.SecretTypeOTP:
+		key, err := prompt.OTPKey()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode otp to json: %w", err)
+		}
+		return data, nil
+	case dto.SecretTypeText:
+		text, err := prompt.Text()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(text), nil
+	}
+
+	return nil, fmt.Errorf("unsuported secret type: %s", dataType)
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().StringVar(&editFilePath, "file", "", "Path to new file content for file secret")
+}
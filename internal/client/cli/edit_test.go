@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/config"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_editSecret(t *testing.T) {
+	version := uint64(3)
+	cr := dto.Credentials{Login: "user", Password: "password"}
+	crData, err := json.Marshal(cr)
+	require.Nil(t, err, "Credentials encode to json")
+
+	tmpdir := t.TempDir()
+	newFilePath := filepath.Join(tmpdir, "new.bin")
+	err = os.WriteFile(newFilePath, []byte("new file data"), 0600)
+	require.Nil(t, err, "File creating")
+
+	fileMeta := []dto.MetaData{
+		{Name: MetaFileName, Value: "old.bin"},
+		{Name: MetaFilePath, Value: "/tmp"},
+	}
+
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name     string
+		secretID string
+		filePath string
+		pSetup   func(t *testing.T) Prompt
+		sSetup   func(t *testing.T) SecretService
+		want     want
+	}{
+		{
+			name:     "success_change_data",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					EditSecretName("name").Return("new name", nil)
+				prompt.EXPECT().
+					EditData().Return(true)
+				prompt.EXPECT().
+					Credentials().Return(cr, nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						[]byte("{}"),
+						dto.SecretInfo{Name: "name", DataType: dto.SecretTypeCredentials, Version: version},
+						nil,
+					)
+				service.EXPECT().
+					Update(
+						uint64(13),
+						dto.SecretUpdateRequest{Name: "new name", Meta: []dto.MetaData{}, Version: version},
+						crData,
+					).
+					Return(nil)
+				return service
+			},
+			want: want{
+				output: "secret 13 updated\n",
+			},
+		},
+		{
+			name:     "success_keep_data",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					EditSecretName("name").Return("new name", nil)
+				prompt.EXPECT().
+					EditData().Return(false)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						[]byte("text"),
+						dto.SecretInfo{Name: "name", DataType: dto.SecretTypeText, Version: version},
+						nil,
+					)
+				service.EXPECT().
+					Update(
+						uint64(13),
+						dto.SecretUpdateRequest{Name: "new name", Meta: []dto.MetaData{}, Version: version},
+						[]byte("text"),
+					).
+					Return(nil)
+				return service
+			},
+			want: want{
+				output: "secret 13 updated\n",
+			},
+		},
+		{
+			name:     "success_new_file",
+			secretID: "13",
+			filePath: newFilePath,
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					EditSecretName("name").Return("name", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						[]byte("old file data"),
+						dto.SecretInfo{Name: "name", DataType: dto.SecretTypeFile, Meta: fileMeta, Version: version},
+						nil,
+					)
+				service.EXPECT().
+					UpdateFile(
+						uint64(13),
+						dto.SecretUpdateRequest{
+							Name: "name",
+							Meta: []dto.MetaData{
+								{Name: MetaFileName, Value: "new.bin"},
+								{Name: MetaFilePath, Value: tmpdir},
+							},
+							Version: version,
+						},
+						gomock.Any(),
+						gomock.Any(),
+					).
+					DoAndReturn(func(_ uint64, _ dto.SecretUpdateRequest, file *os.File, _ func(done, total uint32)) error {
+						data, err := io.ReadAll(file)
+						require.Nil(t, err, "Read file")
+						assert.Equal(t, []byte("new file data"), data, "File data")
+						return nil
+					})
+				return service
+			},
+			want: want{
+				output: "secret 13 updated\n",
+			},
+		},
+		{
+			name:     "success_keep_file",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					EditSecretName("name").Return("new name", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						nil,
+						dto.SecretInfo{Name: "name", DataType: dto.SecretTypeFile, Meta: fileMeta, Version: version, Chunks: 2},
+						nil,
+					)
+				service.EXPECT().
+					Update(
+						uint64(13),
+						dto.SecretUpdateRequest{Name: "new name", Meta: fileMeta, Version: version},
+						nil,
+					).
+					Return(nil)
+				return service
+			},
+			want: want{
+				output: "secret 13 updated\n",
+			},
+		},
+		{
+			name:     "conflict",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					EditSecretName("name").Return("name", nil)
+				prompt.EXPECT().
+					EditData().Return(false)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						[]byte("text"),
+						dto.SecretInfo{Name: "name", DataType: dto.SecretTypeText, Version: version},
+						nil,
+					)
+				service.EXPECT().
+					Update(gomock.All(), gomock.All(), gomock.All()).
+					Return(fmt.Errorf("failed to update secret: secret was modified by another client"))
+				return service
+			},
+			want: want{
+				err: "failed to send data to server: " +
+					"failed to update secret: secret was modified by another client",
+			},
+		},
+		{
+			name:     "unsupported_type",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().
+					EditSecretName("name").Return("name", nil)
+				prompt.EXPECT().
+					EditData().Return(true)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						[]byte("data"),
+						dto.SecretInfo{Name: "name", DataType: "unknown"},
+						nil,
+					)
+				return service
+			},
+			want: want{
+				err: "unsuported secret type: unknown",
+			},
+		},
+		{
+			name:     "failed_to_get",
+			secretID: "13",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockPrompt(ctrl)
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(nil, dto.SecretInfo{}, fmt.Errorf("failed to get secret"))
+				return service
+			},
+			want: want{
+				err: "failed to get secret",
+			},
+		},
+		{
+			name:     "invalid_id",
+			secretID: "text_id",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockPrompt(ctrl)
+			},
+			sSetup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				err: "id must be a number",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg = &config.Config{FileMaxSize: 1024}
+			prompt = test.pSetup(t)
+			secretService = test.sSetup(t)
+			editFilePath = test.filePath
+
+			out := new(bytes.Buffer)
+			err := editSecret(out, test.secretID)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Edit error")
+			assert.Equal(t, test.want.output, out.String(), "Edit output")
+		})
+	}
+}
@@ -2,18 +2,24 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 )
 
-var filePath string
+var (
+	filePath string
+	reveal   bool
+)
 
 var getCmd = &cobra.Command{
 	Use:   "get <id>",
@@ -39,10 +45,14 @@ func get(out io.Writer, argID string) error {
 	switch info.DataType {
 	case dto.SecretTypeCredentials:
 		return outputCredentials(out, secret, info)
+	case dto.SecretTypeCard:
+		return outputCard(out, secret, info)
 	case dto.SecretTypeFile:
 		return saveFile(secret, info)
 	case dto.SecretTypeText:
 		return outputText(out, secret, info)
+	case dto.SecretTypeOTP:
+		return outputOTP(out, secret, info, time.Now())
 	}
 
 	return fmt.Errorf("unsuported secret type: %s", info.DataType)
@@ -65,6 +75,31 @@ func outputCredentials(out io.Writer, secret []byte, info dto.SecretInfo) error
 	return nil
 }
 
+func outputCard(out io.Writer, secret []byte, info dto.SecretInfo) error {
+	var card dto.Card
+	err := json.Unmarshal(secret, &card)
+	if err != nil {
+		return fmt.Errorf("failed decode secret json: %w", err)
+	}
+
+	// без флага --reveal номер карты и CVV не выводятся целиком
+	number, cvv := card.MaskedNumber(), "***"
+	if reveal {
+		number, cvv = card.Number, card.CVV
+	}
+
+	fmt.Fprintln(out, info.Name)
+	fmt.Fprintln(out, "--------------------------------")
+	fmt.Fprintf(out, "number: %s\n", number)
+	fmt.Fprintf(out, "holder: %s\n", card.Holder)
+	fmt.Fprintf(out, "expiry: %s\n", card.Expiry)
+	fmt.Fprintf(out, "cvv:    %s\n", cvv)
+	fmt.Fprintln(out, "--------------------------------")
+	fmt.Fprintln(out, "created:", info.Created.Format("2006-01-02 15:04:05"))
+
+	return nil
+}
+
 func saveFile(secret []byte, info dto.SecretInfo) error {
 	// если не задан путь для вывода файла, товыводим его в текущую директорию
 	if filePath == "" {
@@ -111,6 +146,11 @@ func saveFile(secret []byte, info dto.SecretInfo) error {
 		}
 	}
 
+	// данные, загруженные по частям, скачиваются потоком
+	if info.Chunks > 0 {
+		return downloadFile(info, filePath)
+	}
+
 	err = os.WriteFile(filePath, secret, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to to save file: %w", err)
@@ -119,6 +159,37 @@ func saveFile(secret []byte, info dto.SecretInfo) error {
 	return nil
 }
 
+// downloadFile скачивает данные секрета во временный файл рядом с path
+// и после успешной расшифровки всех частей переименовывает его в path.
+// Временный файл привязан к версии секрета, поэтому прерванное скачивание
+// продолжается при повторном запуске команды, если секрет не изменился.
+func downloadFile(info dto.SecretInfo, path string) error {
+	partPath := fmt.Sprintf("%s.%d-%d.part", path, info.ID, info.Version)
+
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to to save file: %w", err)
+	}
+
+	err = secretService.DownloadFile(info.ID, file, showProgress("receiving"))
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to to save file: %w", closeErr)
+	}
+	if err != nil {
+		// поврежденные данные продолжать скачивать нельзя
+		if errors.Is(err, service.ErrSecretDecryptionFailed) {
+			os.Remove(partPath)
+		}
+		return err
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		return fmt.Errorf("failed to to save file: %w", err)
+	}
+
+	return nil
+}
+
 func outputText(out io.Writer, secret []byte, info dto.SecretInfo) error {
 	fmt.Fprintln(out, info.Name)
 	fmt.Fprintln(out, "--------------------------------")
@@ -133,4 +204,5 @@ func init() {
 	rootCmd.AddCommand(getCmd)
 
 	getCmd.Flags().StringVarP(&filePath, "out", "o", "", "Path to save file")
+	getCmd.Flags().BoolVarP(&reveal, "reveal", "r", false, "Show full card number and CVV")
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,9 @@ func Test_get(t *testing.T) {
 	cr := dto.Credentials{Login: "user", Password: "password"}
 	crData, err := json.Marshal(cr)
 	require.Nil(t, err, "Credentials encode to json")
+	card := dto.Card{Number: "4111111111111111", Holder: "IVAN IVANOV", Expiry: "12/99", CVV: "123"}
+	cardData, err := json.Marshal(card)
+	require.Nil(t, err, "Card encode to json")
 
 	type want struct {
 		output string
@@ -28,6 +32,7 @@ func Test_get(t *testing.T) {
 	tests := []struct {
 		name     string
 		filePath string
+		reveal   bool
 		secretID string
 		setup    func(t *testing.T) SecretService
 		want     want
@@ -82,6 +87,88 @@ func Test_get(t *testing.T) {
 					"looking for beginning of value",
 			},
 		},
+		{
+			name:     "success_card_masked",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						cardData,
+						dto.SecretInfo{
+							Name:     "name",
+							DataType: dto.SecretTypeCard,
+						},
+						nil,
+					)
+				return service
+			},
+			want: want{
+				output: "name\n" +
+					"--------------------------------\n" +
+					"number: ************1111\n" +
+					"holder: IVAN IVANOV\n" +
+					"expiry: 12/99\n" +
+					"cvv:    ***\n" +
+					"--------------------------------\n" +
+					"created: 0001-01-01 00:00:00\n",
+			},
+		},
+		{
+			name:     "success_card_revealed",
+			secretID: "13",
+			reveal:   true,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						cardData,
+						dto.SecretInfo{
+							Name:     "name",
+							DataType: dto.SecretTypeCard,
+						},
+						nil,
+					)
+				return service
+			},
+			want: want{
+				output: "name\n" +
+					"--------------------------------\n" +
+					"number: 4111111111111111\n" +
+					"holder: IVAN IVANOV\n" +
+					"expiry: 12/99\n" +
+					"cvv:    123\n" +
+					"--------------------------------\n" +
+					"created: 0001-01-01 00:00:00\n",
+			},
+		},
+		{
+			name:     "card_invalid_json",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(
+						[]byte("inalid json"),
+						dto.SecretInfo{
+							Name:     "name",
+							DataType: dto.SecretTypeCard,
+						},
+						nil,
+					)
+				return service
+			},
+			want: want{
+				err: "failed decode secret json: invalid character 'i' " +
+					"looking for beginning of value",
+			},
+		},
 		{
 			name:     "success_text",
 			secretID: "13",
@@ -186,6 +273,7 @@ func Test_get(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			secretService = test.setup(t)
 			filePath = test.filePath
+			reveal = test.reveal
 
 			out := new(bytes.Buffer)
 			err := get(out, test.secretID)
@@ -347,3 +435,90 @@ func Test_saveFile(t *testing.T) {
 		})
 	}
 }
+
+func Test_downloadFile(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "file.bin")
+	partPath := path + ".13-3.part"
+	info := dto.SecretInfo{ID: 13, Version: 3, Chunks: 2}
+
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T) SecretService
+		wantErr  string
+		wantFile string
+		wantPart bool
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					DownloadFile(uint64(13), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ uint64, file *os.File, _ func(done, total uint32)) error {
+						assert.Equal(t, partPath, file.Name(), "Temporary file")
+						_, err := file.Write([]byte("chunked content"))
+						return err
+					})
+				return service
+			},
+			wantFile: "chunked content",
+		},
+		{
+			name: "interrupted",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					DownloadFile(uint64(13), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ uint64, file *os.File, _ func(done, total uint32)) error {
+						_, err := file.Write([]byte("partial"))
+						require.Nil(t, err, "Write partial content")
+						return fmt.Errorf("connection refused")
+					})
+				return service
+			},
+			wantErr:  "connection refused",
+			wantPart: true,
+		},
+		{
+			name: "decryption_failed",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secret := mocks.NewMockSecretService(ctrl)
+				secret.EXPECT().
+					DownloadFile(uint64(13), gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("%w: bad chunk", service.ErrSecretDecryptionFailed))
+				return secret
+			},
+			wantErr: "failed to decrypt secret: bad chunk",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Remove(path)
+			os.Remove(partPath)
+			secretService = test.setup(t)
+
+			err := downloadFile(info, path)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Download file error")
+
+			_, err = os.Stat(partPath)
+			assert.Equal(t, test.wantPart, err == nil, "Temporary file must stay only for resume")
+
+			content, err := os.ReadFile(path)
+			if test.wantFile == "" {
+				assert.True(t, os.IsNotExist(err), "File must not be created")
+			} else {
+				require.Nil(t, err, "Read saved file")
+				assert.Equal(t, test.wantFile, string(content), "Saved file content")
+			}
+		})
+	}
+}
@@ -48,6 +48,34 @@ func (mr *MockAuthServiceMockRecorder) Login(cr interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), cr)
 }
 
+// Logout mocks base method.
+func (m *MockAuthService) Logout() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthServiceMockRecorder) Logout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthService)(nil).Logout))
+}
+
+// Recover mocks base method.
+func (m *MockAuthService) Recover(login, code, newPassword, otp string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recover", login, code, newPassword, otp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Recover indicates an expected call of Recover.
+func (mr *MockAuthServiceMockRecorder) Recover(login, code, newPassword, otp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockAuthService)(nil).Recover), login, code, newPassword, otp)
+}
+
 // Register mocks base method.
 func (m *MockAuthService) Register(cr dto.Credentials) error {
 	m.ctrl.T.Helper()
@@ -61,3 +89,91 @@ func (mr *MockAuthServiceMockRecorder) Register(cr interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthService)(nil).Register), cr)
 }
+
+// RevokeSession mocks base method.
+func (m *MockAuthService) RevokeSession(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthServiceMockRecorder) RevokeSession(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthService)(nil).RevokeSession), id)
+}
+
+// Sessions mocks base method.
+func (m *MockAuthService) Sessions() ([]dto.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sessions")
+	ret0, _ := ret[0].([]dto.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sessions indicates an expected call of Sessions.
+func (mr *MockAuthServiceMockRecorder) Sessions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockAuthService)(nil).Sessions))
+}
+
+// SetupRecovery mocks base method.
+func (m *MockAuthService) SetupRecovery(password string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetupRecovery", password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetupRecovery indicates an expected call of SetupRecovery.
+func (mr *MockAuthServiceMockRecorder) SetupRecovery(password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetupRecovery", reflect.TypeOf((*MockAuthService)(nil).SetupRecovery), password)
+}
+
+// TwoFactorDisable mocks base method.
+func (m *MockAuthService) TwoFactorDisable(code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactorDisable", code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TwoFactorDisable indicates an expected call of TwoFactorDisable.
+func (mr *MockAuthServiceMockRecorder) TwoFactorDisable(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactorDisable", reflect.TypeOf((*MockAuthService)(nil).TwoFactorDisable), code)
+}
+
+// TwoFactorEnable mocks base method.
+func (m *MockAuthService) TwoFactorEnable(code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactorEnable", code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TwoFactorEnable indicates an expected call of TwoFactorEnable.
+func (mr *MockAuthServiceMockRecorder) TwoFactorEnable(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactorEnable", reflect.TypeOf((*MockAuthService)(nil).TwoFactorEnable), code)
+}
+
+// TwoFactorSetup mocks base method.
+func (m *MockAuthService) TwoFactorSetup() (dto.TOTPSetup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactorSetup")
+	ret0, _ := ret[0].(dto.TOTPSetup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TwoFactorSetup indicates an expected call of TwoFactorSetup.
+func (mr *MockAuthServiceMockRecorder) TwoFactorSetup() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactorSetup", reflect.TypeOf((*MockAuthService)(nil).TwoFactorSetup))
+}
@@ -34,6 +34,35 @@ func (m *MockPrompt) EXPECT() *MockPromptMockRecorder {
 	return m.recorder
 }
 
+// Card mocks base method.
+func (m *MockPrompt) Card() (dto.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Card")
+	ret0, _ := ret[0].(dto.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Card indicates an expected call of Card.
+func (mr *MockPromptMockRecorder) Card() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Card", reflect.TypeOf((*MockPrompt)(nil).Card))
+}
+
+// ConfirmDelete mocks base method.
+func (m *MockPrompt) ConfirmDelete(id uint64) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmDelete", id)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ConfirmDelete indicates an expected call of ConfirmDelete.
+func (mr *MockPromptMockRecorder) ConfirmDelete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmDelete", reflect.TypeOf((*MockPrompt)(nil).ConfirmDelete), id)
+}
+
 // Credentials mocks base method.
 func (m *MockPrompt) Credentials() (dto.Credentials, error) {
 	m.ctrl.T.Helper()
@@ -49,6 +78,80 @@ func (mr *MockPromptMockRecorder) Credentials() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Credentials", reflect.TypeOf((*MockPrompt)(nil).Credentials))
 }
 
+// EditData mocks base method.
+func (m *MockPrompt) EditData() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditData")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// EditData indicates an expected call of EditData.
+func (mr *MockPromptMockRecorder) EditData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditData", reflect.TypeOf((*MockPrompt)(nil).EditData))
+}
+
+// EditSecretName mocks base method.
+func (m *MockPrompt) EditSecretName(current string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditSecretName", current)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EditSecretName indicates an expected call of EditSecretName.
+func (mr *MockPromptMockRecorder) EditSecretName(current interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditSecretName", reflect.TypeOf((*MockPrompt)(nil).EditSecretName), current)
+}
+
+// NewPassword mocks base method.
+func (m *MockPrompt) NewPassword() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewPassword")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewPassword indicates an expected call of NewPassword.
+func (mr *MockPromptMockRecorder) NewPassword() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewPassword", reflect.TypeOf((*MockPrompt)(nil).NewPassword))
+}
+
+// OTP mocks base method.
+func (m *MockPrompt) OTP() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OTP")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OTP indicates an expected call of OTP.
+func (mr *MockPromptMockRecorder) OTP() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OTP", reflect.TypeOf((*MockPrompt)(nil).OTP))
+}
+
+// OTPKey mocks base method.
+func (m *MockPrompt) OTPKey() (dto.OTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OTPKey")
+	ret0, _ := ret[0].(dto.OTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OTPKey indicates an expected call of OTPKey.
+func (mr *MockPromptMockRecorder) OTPKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OTPKey", reflect.TypeOf((*MockPrompt)(nil).OTPKey))
+}
+
 // Overwrite mocks base method.
 func (m *MockPrompt) Overwrite(fileName string) bool {
 	m.ctrl.T.Helper()
@@ -63,6 +166,38 @@ func (mr *MockPromptMockRecorder) Overwrite(fileName interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Overwrite", reflect.TypeOf((*MockPrompt)(nil).Overwrite), fileName)
 }
 
+// PasswordChange mocks base method.
+func (m *MockPrompt) PasswordChange() (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PasswordChange")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PasswordChange indicates an expected call of PasswordChange.
+func (mr *MockPromptMockRecorder) PasswordChange() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PasswordChange", reflect.TypeOf((*MockPrompt)(nil).PasswordChange))
+}
+
+// RecoveryKey mocks base method.
+func (m *MockPrompt) RecoveryKey() (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryKey")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecoveryKey indicates an expected call of RecoveryKey.
+func (mr *MockPromptMockRecorder) RecoveryKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryKey", reflect.TypeOf((*MockPrompt)(nil).RecoveryKey))
+}
+
 // RegisterCredentials mocks base method.
 func (m *MockPrompt) RegisterCredentials() (dto.Credentials, error) {
 	m.ctrl.T.Helper()
@@ -5,8 +5,10 @@
 package mocks
 
 import (
+	os "os"
 	reflect "reflect"
 
+	service "github.com/EshkinKot1980/GophKeeper/internal/client/service"
 	dto "github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,6 +36,63 @@ func (m *MockSecretService) EXPECT() *MockSecretServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockSecretService) ChangePassword(password, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", password, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockSecretServiceMockRecorder) ChangePassword(password, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockSecretService)(nil).ChangePassword), password, newPassword)
+}
+
+// Conflicts mocks base method.
+func (m *MockSecretService) Conflicts() ([]service.Conflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Conflicts")
+	ret0, _ := ret[0].([]service.Conflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Conflicts indicates an expected call of Conflicts.
+func (mr *MockSecretServiceMockRecorder) Conflicts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Conflicts", reflect.TypeOf((*MockSecretService)(nil).Conflicts))
+}
+
+// Delete mocks base method.
+func (m *MockSecretService) Delete(id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSecretServiceMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSecretService)(nil).Delete), id)
+}
+
+// DownloadFile mocks base method.
+func (m *MockSecretService) DownloadFile(id uint64, file *os.File, progress service.ProgressFunc) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadFile", id, file, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadFile indicates an expected call of DownloadFile.
+func (mr *MockSecretServiceMockRecorder) DownloadFile(id, file, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockSecretService)(nil).DownloadFile), id, file, progress)
+}
+
 // GetSecretAndInfo mocks base method.
 func (m *MockSecretService) GetSecretAndInfo(id uint64) ([]byte, dto.SecretInfo, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +124,63 @@ func (mr *MockSecretServiceMockRecorder) InfoList() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InfoList", reflect.TypeOf((*MockSecretService)(nil).InfoList))
 }
 
+// ResolveConflict mocks base method.
+func (m *MockSecretService) ResolveConflict(id uint64, keep string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveConflict", id, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveConflict indicates an expected call of ResolveConflict.
+func (mr *MockSecretServiceMockRecorder) ResolveConflict(id, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveConflict", reflect.TypeOf((*MockSecretService)(nil).ResolveConflict), id, keep)
+}
+
+// Sync mocks base method.
+func (m *MockSecretService) Sync() (service.SyncResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sync")
+	ret0, _ := ret[0].(service.SyncResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sync indicates an expected call of Sync.
+func (mr *MockSecretServiceMockRecorder) Sync() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockSecretService)(nil).Sync))
+}
+
+// Update mocks base method.
+func (m *MockSecretService) Update(id uint64, secret dto.SecretUpdateRequest, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", id, secret, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSecretServiceMockRecorder) Update(id, secret, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSecretService)(nil).Update), id, secret, data)
+}
+
+// UpdateFile mocks base method.
+func (m *MockSecretService) UpdateFile(id uint64, secret dto.SecretUpdateRequest, file *os.File, progress service.ProgressFunc) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFile", id, secret, file, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFile indicates an expected call of UpdateFile.
+func (mr *MockSecretServiceMockRecorder) UpdateFile(id, secret, file, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFile", reflect.TypeOf((*MockSecretService)(nil).UpdateFile), id, secret, file, progress)
+}
+
 // Upload mocks base method.
 func (m *MockSecretService) Upload(secret dto.SecretRequest, data []byte) error {
 	m.ctrl.T.Helper()
@@ -78,3 +194,32 @@ func (mr *MockSecretServiceMockRecorder) Upload(secret, data interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockSecretService)(nil).Upload), secret, data)
 }
+
+// UploadFile mocks base method.
+func (m *MockSecretService) UploadFile(secret dto.SecretRequest, file *os.File, progress service.ProgressFunc) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadFile", secret, file, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadFile indicates an expected call of UploadFile.
+func (mr *MockSecretServiceMockRecorder) UploadFile(secret, file, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockSecretService)(nil).UploadFile), secret, file, progress)
+}
+
+// Usage mocks base method.
+func (m *MockSecretService) Usage() (dto.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage")
+	ret0, _ := ret[0].(dto.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockSecretServiceMockRecorder) Usage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockSecretService)(nil).Usage))
+}
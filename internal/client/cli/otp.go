@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+var otpWatch bool
+
+var otpCmd = &cobra.Command{
+	Use:   "otp <id>",
+	Short: "Show current one-time password by secret ID",
+	Long: "Generates current code of the otp secret. " +
+		"With --watch flag TOTP code is refreshed every second until interrupted.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var ticks <-chan time.Time
+		if otpWatch {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			ticks = otpTicks(ctx, time.Second)
+		}
+		return showOTP(os.Stdout, args[0], time.Now(), ticks)
+	},
+}
+
+// showOTP выводит текущий код секрета типа otp,
+// если передан канал ticks, код TOTP обновляется на каждом тике до закрытия канала.
+func showOTP(out io.Writer, argID string, now time.Time, ticks <-chan time.Time) error {
+	id, err := strconv.ParseUint(argID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("id must be a number")
+	}
+
+	secret, info, err := secretService.GetSecretAndInfo(id)
+	if err != nil {
+		return err
+	}
+	if info.DataType != dto.SecretTypeOTP {
+		return fmt.Errorf("secret %d is not otp", id)
+	}
+
+	key, err := decodeOTP(secret)
+	if err != nil {
+		return err
+	}
+
+	if ticks != nil {
+		if key.Type != dto.OTPTypeTOTP {
+			return fmt.Errorf("only totp code can be watched")
+		}
+		return watchOTP(out, key, ticks)
+	}
+
+	code, err := key.Code(now)
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	if key.Type == dto.OTPTypeHOTP {
+		fmt.Fprintln(out, code)
+		return nextHOTPCounter(out, key, info)
+	}
+
+	fmt.Fprintf(out, "%s (valid for %s)\n", code, key.Remaining(now))
+	return nil
+}
+
+// watchOTP перезаписывает строку с кодом TOTP на каждом тике.
+func watchOTP(out io.Writer, key dto.OTP, ticks <-chan time.Time) error {
+	for now := range ticks {
+		code, err := key.Code(now)
+		if err != nil {
+			return fmt.Errorf("failed to generate code: %w", err)
+		}
+		fmt.Fprintf(out, "\r%s (valid for %2ds)", code, int(key.Remaining(now).Seconds()))
+	}
+	fmt.Fprintln(out)
+
+	return nil
+}
+
+// otpTicks отдает текущее время сразу и далее с интервалом interval, пока не отменен ctx.
+func otpTicks(ctx context.Context, interval time.Duration) <-chan time.Time {
+	ticks := make(chan time.Time)
+
+	go func() {
+		defer close(ticks)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		now := time.Now()
+		for {
+			select {
+			case ticks <- now:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case now = <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ticks
+}
+
+func outputOTP(out io.Writer, secret []byte, info dto.SecretInfo, now time.Time) error {
+	key, err := decodeOTP(secret)
+	if err != nil {
+		return err
+	}
+
+	code, err := key.Code(now)
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	fmt.Fprintln(out, info.Name)
+	fmt.Fprintln(out, "--------------------------------")
+	if key.Issuer != "" {
+		fmt.Fprintf(out, "issuer:  %s\n", key.Issuer)
+	}
+	if key.Account != "" {
+		fmt.Fprintf(out, "account: %s\n", key.Account)
+	}
+	fmt.Fprintf(out, "code:    %s\n", code)
+	if key.Type == dto.OTPTypeTOTP {
+		fmt.Fprintf(out, "valid:   %s\n", key.Remaining(now))
+	} else {
+		fmt.Fprintf(out, "counter: %d\n", key.Counter)
+	}
+	fmt.Fprintln(out, "--------------------------------")
+	fmt.Fprintln(out, "created:", info.Created.Format("2006-01-02 15:04:05"))
+
+	if key.Type == dto.OTPTypeHOTP {
+		return nextHOTPCounter(out, key, info)
+	}
+
+	return nil
+}
+
+// nextHOTPCounter сохраняет увеличенный счетчик HOTP, чтобы выданный код не повторялся.
+func nextHOTPCounter(out io.Writer, key dto.OTP, info dto.SecretInfo) error {
+	key.Counter++
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode otp to json: %w", err)
+	}
+
+	meta := info.Meta
+	if meta == nil {
+		meta = []dto.MetaData{}
+	}
+
+	err = secretService.Update(
+		info.ID,
+		dto.SecretUpdateRequest{
+			Name:    info.Name,
+			Meta:    meta,
+			Version: info.Version,
+		},
+		data,
+	)
+	if err != nil {
+		if savedLocally(out, err) {
+			return nil
+		}
+		return fmt.Errorf("failed to save hotp counter: %w", err)
+	}
+
+	return nil
+}
+
+func decodeOTP(secret []byte) (dto.OTP, error) {
+	var key dto.OTP
+	if err := json.Unmarshal(secret, &key); err != nil {
+		return key, fmt.Errorf("failed decode secret json: %w", err)
+	}
+	if err := key.Validate(); err != nil {
+		return key, fmt.Errorf("invalid otp secret: %w", err)
+	}
+	return key, nil
+}
+
+func init() {
+	rootCmd.AddCommand(otpCmd)
+
+	otpCmd.Flags().BoolVarP(&otpWatch, "watch", "w", false, "Refresh TOTP code every second")
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Секрет "12345678901234567890" из приложений RFC 4226 и RFC 6238
+const testOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func Test_showOTP(t *testing.T) {
+	totp := dto.NewTOTP(testOTPSecret)
+	totp.Issuer, totp.Account = "Example", "alice"
+	totpData, err := json.Marshal(totp)
+	require.Nil(t, err, "TOTP encode to json")
+
+	hotp := dto.OTP{Type: dto.OTPTypeHOTP, Secret: testOTPSecret, Algorithm: "SHA1", Digits: 6, Counter: 1}
+	hotpData, err := json.Marshal(hotp)
+	require.Nil(t, err, "HOTP encode to json")
+	hotp.Counter++
+	nextHotpData, err := json.Marshal(hotp)
+	require.Nil(t, err, "HOTP encode to json")
+
+	totpInfo := dto.SecretInfo{ID: 13, Name: "name", DataType: dto.SecretTypeOTP, Version: 2}
+	hotpInfo := dto.SecretInfo{ID: 13, Name: "name", DataType: dto.SecretTypeOTP, Version: 2}
+
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name     string
+		secretID string
+		ticks    []time.Time
+		setup    func(t *testing.T) SecretService
+		want     want
+	}{
+		{
+			name:     "success_totp",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().GetSecretAndInfo(uint64(13)).Return(totpData, totpInfo, nil)
+				return service
+			},
+			want: want{output: "287082 (valid for 1s)\n"},
+		},
+		{
+			name:     "success_totp_watch",
+			secretID: "13",
+			ticks:    []time.Time{time.Unix(59, 0), time.Unix(60, 0)},
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().GetSecretAndInfo(uint64(13)).Return(totpData, totpInfo, nil)
+				return service
+			},
+			want: want{output: "\r287082 (valid for  1s)\r359152 (valid for 30s)\n"},
+		},
+		{
+			name:     "success_hotp",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().GetSecretAndInfo(uint64(13)).Return(hotpData, hotpInfo, nil)
+				service.EXPECT().
+					Update(
+						uint64(13),
+						dto.SecretUpdateRequest{Name: "name", Meta: []dto.MetaData{}, Version: 2},
+						nextHotpData,
+					).
+					Return(nil)
+				return service
+			},
+			want: want{output: "287082\n"},
+		},
+		{
+			name:     "hotp_saved_locally",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				srv := mocks.NewMockSecretService(ctrl)
+				srv.EXPECT().GetSecretAndInfo(uint64(13)).Return(hotpData, hotpInfo, nil)
+				srv.EXPECT().
+					Update(uint64(13), gomock.Any(), nextHotpData).
+					Return(service.ErrSavedLocally)
+				return srv
+			},
+			want: want{output: "287082\n" + service.ErrSavedLocally.Error() + "\n"},
+		},
+		{
+			name:     "hotp_watch",
+			secretID: "13",
+			ticks:    []time.Time{time.Unix(59, 0)},
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().GetSecretAndInfo(uint64(13)).Return(hotpData, hotpInfo, nil)
+				return service
+			},
+			want: want{err: "only totp code can be watched"},
+		},
+		{
+			name:     "not_otp",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return([]byte("text"), dto.SecretInfo{DataType: dto.SecretTypeText}, nil)
+				return service
+			},
+			want: want{err: "secret 13 is not otp"},
+		},
+		{
+			name:     "invalid_id",
+			secretID: "abc",
+			setup: func(t *testing.T) SecretService {
+				return mocks.NewMockSecretService(gomock.NewController(t))
+			},
+			want: want{err: "id must be a number"},
+		},
+		{
+			name:     "service_error",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					GetSecretAndInfo(uint64(13)).
+					Return(nil, dto.SecretInfo{}, fmt.Errorf("secret not found"))
+				return service
+			},
+			want: want{err: "secret not found"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secretService = test.setup(t)
+
+			var ticks chan time.Time
+			if test.ticks != nil {
+				ticks = make(chan time.Time, len(test.ticks))
+				for _, tick := range test.ticks {
+					ticks <- tick
+				}
+				close(ticks)
+			}
+
+			var out bytes.Buffer
+			err := showOTP(&out, test.secretID, time.Unix(59, 0), ticks)
+
+			if test.want.err != "" {
+				assert.EqualError(t, err, test.want.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want.output, out.String())
+		})
+	}
+}
+
+func Test_outputOTP(t *testing.T) {
+	totp := dto.NewTOTP(testOTPSecret)
+	totp.Issuer, totp.Account = "Example", "alice"
+	totpData, err := json.Marshal(totp)
+	require.Nil(t, err, "TOTP encode to json")
+
+	var out bytes.Buffer
+	err = outputOTP(&out, totpData, dto.SecretInfo{Name: "name", DataType: dto.SecretTypeOTP}, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t,
+		"name\n"+
+			"--------------------------------\n"+
+			"issuer:  Example\n"+
+			"account: alice\n"+
+			"code:    287082\n"+
+			"valid:   1s\n"+
+			"--------------------------------\n"+
+			"created: 0001-01-01 00:00:00\n",
+		out.String(),
+	)
+
+	err = outputOTP(&out, []byte(`{"type":"totp"}`), dto.SecretInfo{}, time.Unix(59, 0))
+	assert.EqualError(t, err, "invalid otp secret: otp secret can not be empty")
+}
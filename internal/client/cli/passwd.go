@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var passwdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change password",
+	Long: "Changes the password and re-encrypts keys of all secrets with the new master key. " +
+		"Other sessions are terminated, interrupted file uploads start over.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changePassword(os.Stdout)
+	},
+}
+
+func changePassword(out io.Writer) error {
+	password, newPassword, err := prompt.PasswordChange()
+	if err != nil {
+		return err
+	}
+
+	if err := secretService.ChangePassword(password, newPassword); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "password changed, other sessions are terminated")
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(passwdCmd)
+}
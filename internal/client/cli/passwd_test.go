@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_changePassword(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name   string
+		pSetup func(t *testing.T) Prompt
+		sSetup func(t *testing.T) SecretService
+		want   want
+	}{
+		{
+			name: "success",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().PasswordChange().Return("old1password", "new1password", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				service := mocks.NewMockSecretService(gomock.NewController(t))
+				service.EXPECT().ChangePassword("old1password", "new1password").Return(nil)
+				return service
+			},
+			want: want{output: "password changed, other sessions are terminated\n"},
+		},
+		{
+			name: "prompt_error",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().PasswordChange().Return("", "", fmt.Errorf("passwords do not match"))
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				return mocks.NewMockSecretService(gomock.NewController(t))
+			},
+			want: want{err: "passwords do not match"},
+		},
+		{
+			name: "service_error",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().PasswordChange().Return("old1password", "new1password", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) SecretService {
+				service := mocks.NewMockSecretService(gomock.NewController(t))
+				service.EXPECT().
+					ChangePassword("old1password", "new1password").
+					Return(fmt.Errorf("failed to change password: invalid password"))
+				return service
+			},
+			want: want{err: "failed to change password: invalid password"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prompt = test.pSetup(t)
+			secretService = test.sSetup(t)
+
+			out := new(bytes.Buffer)
+			err := changePassword(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Change password error")
+			assert.Equal(t, test.want.output, out.String(), "Change password output")
+		})
+	}
+}
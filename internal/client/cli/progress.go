@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service"
+)
+
+// progressOut вывод хода передачи файлов, не смешивается с выводом команд
+var progressOut io.Writer = os.Stderr
+
+// showProgress возвращает функцию, которая выводит ход передачи файла в progressOut.
+// Части имеют размер 1 MB, поэтому количество частей примерно равно количеству мегабайт.
+func showProgress(action string) service.ProgressFunc {
+	return func(done, total uint32) {
+		fmt.Fprintf(progressOut, "\r%s: %3d%% (%d/%d MB)", action, uint64(done)*100/uint64(total), done, total)
+		if done == total {
+			fmt.Fprintln(progressOut)
+		}
+	}
+}
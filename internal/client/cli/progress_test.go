@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_showProgress(t *testing.T) {
+	out := new(bytes.Buffer)
+	progressOut = out
+
+	progress := showProgress("sending")
+	progress(1, 4)
+	progress(4, 4)
+
+	assert.Equal(t, "\rsending:  25% (1/4 MB)\rsending: 100% (4/4 MB)\n", out.String(), "Progress output")
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/http"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Reset a forgotten password with the recovery key",
+	Long: "Resets the password with the recovery key created at registration and logs in. " +
+		"Keys of all secrets are re-encrypted with the new master key, the recovery key stays valid. " +
+		"All sessions are terminated, unsynchronized local changes on this device are lost.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return recoverAccount(os.Stdout)
+	},
+}
+
+func recoverAccount(out io.Writer) error {
+	login, code, err := prompt.RecoveryKey()
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := prompt.NewPassword()
+	if err != nil {
+		return err
+	}
+
+	err = authService.Recover(login, code, newPassword, "")
+	if errors.Is(err, http.ErrOTPRequired) {
+		// у пользователя включена двухфакторная аутентификация, запрашиваем код
+		otp, promptErr := prompt.OTP()
+		if promptErr != nil {
+			return promptErr
+		}
+		err = authService.Recover(login, code, newPassword, otp)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "password reset, other sessions are terminated")
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(recoverCmd)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recoverAccount(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name   string
+		pSetup func(t *testing.T) Prompt
+		sSetup func(t *testing.T) AuthService
+		want   want
+	}{
+		{
+			name: "success",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RecoveryKey().Return("test13", "ABCDE-FGHIJ", nil)
+				prompt.EXPECT().NewPassword().Return("new1password", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				service := mocks.NewMockAuthService(gomock.NewController(t))
+				service.EXPECT().Recover("test13", "ABCDE-FGHIJ", "new1password", "").Return(nil)
+				return service
+			},
+			want: want{output: "password reset, other sessions are terminated\n"},
+		},
+		{
+			name: "success_2fa",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RecoveryKey().Return("test13", "ABCDE-FGHIJ", nil)
+				prompt.EXPECT().NewPassword().Return("new1password", nil)
+				prompt.EXPECT().OTP().Return("123456", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				service := mocks.NewMockAuthService(gomock.NewController(t))
+				gomock.InOrder(
+					service.EXPECT().
+						Recover("test13", "ABCDE-FGHIJ", "new1password", "").
+						Return(fmt.Errorf("%w: %w", http.ErrRecoveryFailed, http.ErrOTPRequired)),
+					service.EXPECT().
+						Recover("test13", "ABCDE-FGHIJ", "new1password", "123456").
+						Return(nil),
+				)
+				return service
+			},
+			want: want{output: "password reset, other sessions are terminated\n"},
+		},
+		{
+			name: "prompt_error",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RecoveryKey().Return("test13", "ABCDE-FGHIJ", nil)
+				prompt.EXPECT().NewPassword().Return("", fmt.Errorf("passwords do not match"))
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				return mocks.NewMockAuthService(gomock.NewController(t))
+			},
+			want: want{err: "passwords do not match"},
+		},
+		{
+			name: "invalid_recovery_key",
+			pSetup: func(t *testing.T) Prompt {
+				prompt := mocks.NewMockPrompt(gomock.NewController(t))
+				prompt.EXPECT().RecoveryKey().Return("test13", "ABCDE-FGHIJ", nil)
+				prompt.EXPECT().NewPassword().Return("new1password", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				service := mocks.NewMockAuthService(gomock.NewController(t))
+				service.EXPECT().
+					Recover("test13", "ABCDE-FGHIJ", "new1password", "").
+					Return(fmt.Errorf("%w: %w", http.ErrRecoveryFailed, http.ErrInvalidRecoveryKey))
+				return service
+			},
+			want: want{err: "account recovery failed: invalid login or recovery key"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prompt = test.pSetup(t)
+			authService = test.sSetup(t)
+
+			var out bytes.Buffer
+			err := recoverAccount(&out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Recover error")
+			assert.Equal(t, test.want.output, out.String(), "Recover output")
+		})
+	}
+}
@@ -2,7 +2,9 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -21,6 +23,22 @@ type AuthService interface {
 	Register(cr dto.Credentials) error
 	//Login осуществляет вход пользователя в систему
 	Login(cr dto.Credentials) error
+	// Logout завершает текущую сессию и удаляет локальные данные пользователя
+	Logout() error
+	// Sessions получает список действующих сессий пользователя
+	Sessions() ([]dto.Session, error)
+	// RevokeSession завершает сессию пользователя по id
+	RevokeSession(id string) error
+	// TwoFactorSetup получает новый TOTP секрет для приложения аутентификатора
+	TwoFactorSetup() (dto.TOTPSetup, error)
+	// TwoFactorEnable включает двухфакторную аутентификацию, возвращает коды восстановления
+	TwoFactorEnable(code string) ([]string, error)
+	// TwoFactorDisable выключает двухфакторную аутентификацию
+	TwoFactorDisable(code string) error
+	// SetupRecovery создает ключ восстановления доступа, возвращает его печатный код
+	SetupRecovery(password string) (string, error)
+	// Recover сбрасывает пароль по коду ключа восстановления и выполняет вход
+	Recover(login, code, newPassword, otp string) error
 }
 
 // SecretService сервис для работы с секретными данными пользователя
@@ -31,20 +49,62 @@ type SecretService interface {
 	// GetSecretAndInfo получает секрет пользователя с сервера по id,
 	// возвращает расшиврованные данные в виде []byte и информацию о секрете
 	GetSecretAndInfo(id uint64) ([]byte, dto.SecretInfo, error)
+	// Update изменяет секрет пользователя на сервере по id.
+	// Принимает частино заполненный dto.SecretUpdateRequest и данные, которые нужно зашифровать,
+	// если данные равны nil, они не меняются.
+	Update(id uint64, secret dto.SecretUpdateRequest, data []byte) error
+	// UploadFile отправляет на сервер данные файла по частям, шифруя их потоково.
+	// Прерванная загрузка того же файла продолжается.
+	UploadFile(secret dto.SecretRequest, file *os.File, progress service.ProgressFunc) error
+	// UpdateFile заменяет данные секрета по id данными файла, отправляя их по частям.
+	UpdateFile(id uint64, secret dto.SecretUpdateRequest, file *os.File, progress service.ProgressFunc) error
+	// DownloadFile получает данные секрета по id с сервера, расшифровывает и пишет их в file,
+	// продолжая с места, на котором прервалось предыдущее скачивание в этот файл.
+	DownloadFile(id uint64, file *os.File, progress service.ProgressFunc) error
+	// Delete удаляет секрет пользователя на сервере по id.
+	Delete(id uint64) error
 	// InfoList получает информацию о всех секретах пользователя с сервера.
 	InfoList() ([]dto.SecretInfo, error)
+	// Usage получает с сервера занятый пользователем объем хранилища и его квоты.
+	Usage() (dto.Usage, error)
+	// Sync синхронизирует локальную копию секретов с сервером.
+	Sync() (service.SyncResult, error)
+	// Conflicts возвращает неразрешенные конфликты, найденные при синхронизации.
+	Conflicts() ([]service.Conflict, error)
+	// ResolveConflict разрешает конфликт секрета по id, keep: local, remote или both.
+	ResolveConflict(id uint64, keep string) error
+	// ChangePassword меняет пароль, перешифровывая ключ аккаунта новым мастер ключом.
+	ChangePassword(password, newPassword string) error
 }
 
 // Prompt обслуживает пользовательский ввод
 type Prompt interface {
 	// SecretName ввод названия секрета
 	SecretName() (string, error)
+	// EditSecretName ввод нового названия секрета, пустой ввод оставляет текущее
+	EditSecretName(current string) (string, error)
+	// EditData запрашивает у пользователя нужно ли изменить данные секрета
+	EditData() bool
 	// RegisterCredentials ввод учетных данных для регистрации
 	RegisterCredentials() (dto.Credentials, error)
 	// Credentials ввод учетных данных для входа или сохранения в системе
 	Credentials() (dto.Credentials, error)
+	// PasswordChange ввод текущего и нового пароля
+	PasswordChange() (password string, newPassword string, err error)
+	// NewPassword ввод нового пароля при восстановлении доступа
+	NewPassword() (string, error)
+	// RecoveryKey ввод логина и кода ключа восстановления
+	RecoveryKey() (login string, code string, err error)
+	// OTP ввод кода двухфакторной аутентификации или кода восстановления
+	OTP() (string, error)
+	// Card ввод данных банковской карты
+	Card() (dto.Card, error)
+	// OTPKey ввод otpauth:// URI или секрета генератора одноразовых кодов
+	OTPKey() (dto.OTP, error)
 	// Overwrite() запрашивает у пользователя нужно ли файл переписать
 	Overwrite(fileName string) bool
+	// ConfirmDelete запрашивает у пользователя подтверждение удаления секрета
+	ConfirmDelete(id uint64) bool
 	// Text() ввод произвольного многострочного текста
 	Text() (string, error)
 }
@@ -71,14 +131,18 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		baseURL := http.Scheme + cfg.ServerAddr + http.APIprefix
-		httpClient := http.NewClient(baseURL, cfg.AllowSelfSignedCert)
-
 		fileStorage, err := storage.NewFileSorage()
 		if err != nil {
 			return fmt.Errorf("failed init storage: %w", err)
 		}
 
+		baseURL := http.Scheme + cfg.ServerAddr + http.APIprefix
+		httpClient := http.NewClient(baseURL, cfg.AllowSelfSignedCert)
+		httpClient.SetTokenStorage(fileStorage)
+		if cfg.EncryptAuth {
+			httpClient.SetPayloadEncryption(fileStorage)
+		}
+
 		authService = service.NewAuth(httpClient, fileStorage)
 		secretService = service.NewSecret(httpClient, fileStorage)
 		prompt = utils.NewPrompt()
@@ -87,6 +151,16 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// savedLocally проверяет, сохранено ли изменение локально из-за недоступности сервера,
+// и если да, сообщает об этом пользователю.
+func savedLocally(out io.Writer, err error) bool {
+	if !errors.Is(err, service.ErrSavedLocally) {
+		return false
+	}
+	fmt.Fprintln(out, err)
+	return true
+}
+
 // Execute - точка входа для CLI
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -20,19 +20,52 @@ func TestRootCmd_Structure(t *testing.T) {
 			name: "root_subcommands",
 			cmd:  rootCmd,
 			wantSubcommand: map[string]bool{
-				"register": false,
-				"login":    false,
-				"add":      false,
-				"get":      false,
-				"list":     false,
+				"register":  false,
+				"login":     false,
+				"add":       false,
+				"get":       false,
+				"list":      false,
+				"edit":      false,
+				"delete":    false,
+				"sync":      false,
+				"conflicts": false,
+				"usage":     false,
+				"logout":    false,
+				"sessions":  false,
+				"2fa":       false,
+				"otp":       false,
+				"passwd":    false,
+				"recover":   false,
+			},
+		}, {
+			name: "2fa_subcommands",
+			cmd:  twoFactorCmd,
+			wantSubcommand: map[string]bool{
+				"enable":  false,
+				"disable": false,
+			},
+		}, {
+			name: "sessions_subcommands",
+			cmd:  sessionsCmd,
+			wantSubcommand: map[string]bool{
+				"list":   false,
+				"revoke": false,
+			},
+		}, {
+			name: "conflicts_subcommands",
+			cmd:  conflictsCmd,
+			wantSubcommand: map[string]bool{
+				"resolve": false,
 			},
 		}, {
 			name: "add_subcommands",
 			cmd:  addCmd,
 			wantSubcommand: map[string]bool{
 				"credentials": false,
+				"card":        false,
 				"file":        false,
 				"text":        false,
+				"otp":         false,
 			},
 		},
 	}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Logout the GophKeeper system",
+	Long: "Revokes the current session on the server and removes tokens, " +
+		"master key and local copy of secrets from this device.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return logout(os.Stdout)
+	},
+}
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage active sessions",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List active sessions",
+	Long:  "Displays devices logged in to the account, the current one is marked with *.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listSessions(os.Stdout)
+	},
+}
+
+var sessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke session by ID",
+	Long:  "Logs out the device with the given session ID.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return revokeSession(os.Stdout, args[0])
+	},
+}
+
+func logout(out io.Writer) error {
+	if err := authService.Logout(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "logged out")
+	return nil
+}
+
+func listSessions(out io.Writer) error {
+	list, err := authService.Sessions()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "\tID\tDevice\tIP\tLastSeen\tCreated")
+	for _, item := range list {
+		current := ""
+		if item.Current {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			current,
+			item.ID,
+			item.Device,
+			item.IP,
+			item.LastSeen.Local().Format("2006-01-02 15:04:05"),
+			item.Created.Local().Format("2006-01-02 15:04:05"),
+		)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func revokeSession(out io.Writer, id string) error {
+	if err := authService.RevokeSession(id); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	fmt.Fprintf(out, "session %s revoked\n", id)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsRevokeCmd)
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_logout(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) AuthService
+		want  want
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().Logout().Return(nil)
+				return service
+			},
+			want: want{output: "logged out\n"},
+		},
+		{
+			name: "failed",
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().Logout().Return(fmt.Errorf("failed to logout"))
+				return service
+			},
+			want: want{err: "failed to logout"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService = test.setup(t)
+
+			out := new(bytes.Buffer)
+			err := logout(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Logout error")
+			assert.Equal(t, test.want.output, out.String(), "Logout output")
+		})
+	}
+}
+
+func Test_listSessions(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local)
+	lastSeen := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	sessions := []dto.Session{
+		{ID: "a1", Device: "laptop", IP: "192.0.2.1", Created: created, LastSeen: lastSeen, Current: true},
+		{ID: "b2", Device: "phone", IP: "192.0.2.2", Created: created, LastSeen: created},
+	}
+
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) AuthService
+		want  want
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().Sessions().Return(sessions, nil)
+				return service
+			},
+			want: want{
+				output: "    ID   Device   IP          LastSeen              Created\n" +
+					"*   a1   laptop   192.0.2.1   2026-10-17 12:00:00   2026-10-01 09:30:00\n" +
+					"    b2   phone    192.0.2.2   2026-10-01 09:30:00   2026-10-01 09:30:00\n",
+			},
+		},
+		{
+			name: "failed",
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().Sessions().Return(nil, fmt.Errorf("authorization failed"))
+				return service
+			},
+			want: want{err: "authorization failed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService = test.setup(t)
+
+			out := new(bytes.Buffer)
+			err := listSessions(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "List sessions error")
+			assert.Equal(t, test.want.output, out.String(), "List sessions output")
+		})
+	}
+}
+
+func Test_revokeSession(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) AuthService
+		want  want
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().RevokeSession("b2").Return(nil)
+				return service
+			},
+			want: want{output: "session b2 revoked\n"},
+		},
+		{
+			name: "not_found",
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().RevokeSession("b2").Return(fmt.Errorf("session not found"))
+				return service
+			},
+			want: want{err: "failed to revoke session: session not found"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService = test.setup(t)
+
+			out := new(bytes.Buffer)
+			err := revokeSession(out, "b2")
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Revoke session error")
+			assert.Equal(t, test.want.output, out.String(), "Revoke session output")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Synchronize local copy of secrets with the server",
+	Long: "Sends changes made while the server was unavailable " +
+		"and downloads secrets changed on the server since the last synchronization.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return syncSecrets(os.Stdout)
+	},
+}
+
+func syncSecrets(out io.Writer) error {
+	result, err := secretService.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to synchronize: %w", err)
+	}
+
+	fmt.Fprintf(out, "sent: %d, received: %d, removed: %d\n", result.Pushed, result.Pulled, result.Removed)
+	for _, id := range result.Conflicts {
+		fmt.Fprintf(
+			out,
+			"secret %d was changed on another client, run \"conflicts resolve %d --keep local|remote|both\"\n",
+			id,
+			id,
+		)
+	}
+	for _, r := range result.Rejected {
+		if r.ID == 0 {
+			fmt.Fprintf(out, "new secret %q was rejected by the server: %s\n", r.Name, r.Reason)
+		} else {
+			fmt.Fprintf(out, "changes of secret %d were rejected by the server: %s\n", r.ID, r.Reason)
+		}
+	}
+	if len(result.Rejected) > 0 {
+		fmt.Fprintln(out, "rejected changes are kept locally and will be sent again on the next sync")
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
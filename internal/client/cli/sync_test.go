@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_syncSecrets(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SecretService
+		want  want
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Sync().
+					Return(service.SyncResult{Pushed: 2, Pulled: 3, Removed: 1}, nil)
+				return secretService
+			},
+			want: want{
+				output: "sent: 2, received: 3, removed: 1\n",
+			},
+		},
+		{
+			name: "success_with_conflicts",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Sync().
+					Return(service.SyncResult{Pulled: 1, Conflicts: []uint64{13}}, nil)
+				return secretService
+			},
+			want: want{
+				output: "sent: 0, received: 1, removed: 0\n" +
+					"secret 13 was changed on another client, run \"conflicts resolve 13 --keep local|remote|both\"\n",
+			},
+		},
+		{
+			name: "success_with_rejected",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Sync().
+					Return(service.SyncResult{Pushed: 1, Rejected: []service.RejectedChange{
+						{Name: "photo", Reason: "storage quota exceeded"},
+						{ID: 7, Name: "note", Reason: "secret rejected as invalid"},
+					}}, nil)
+				return secretService
+			},
+			want: want{
+				output: "sent: 1, received: 0, removed: 0\n" +
+					"new secret \"photo\" was rejected by the server: storage quota exceeded\n" +
+					"changes of secret 7 were rejected by the server: secret rejected as invalid\n" +
+					"rejected changes are kept locally and will be sent again on the next sync\n",
+			},
+		},
+		{
+			name: "failed_to_sync",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Sync().
+					Return(service.SyncResult{}, fmt.Errorf("authorization failed"))
+				return secretService
+			},
+			want: want{
+				err: "failed to synchronize: authorization failed",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secretService = test.setup(t)
+
+			out := new(bytes.Buffer)
+			err := syncSecrets(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Sync error")
+			assert.Equal(t, test.want.output, out.String(), "Sync output")
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/spf13/cobra"
+)
+
+var twoFactorCmd = &cobra.Command{
+	Use:   "2fa",
+	Short: "Manage two-factor authentication",
+}
+
+var twoFactorEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable two-factor authentication",
+	Long: "Shows a QR code for an authenticator app, asks for a code from the app " +
+		"and prints one-time recovery codes.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return enableTwoFactor(os.Stdout)
+	},
+}
+
+var twoFactorDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable two-factor authentication",
+	Long:  "Asks for a code from the authenticator app or a recovery code and disables two-factor authentication.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return disableTwoFactor(os.Stdout)
+	},
+}
+
+func enableTwoFactor(out io.Writer) error {
+	setup, err := authService.TwoFactorSetup()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Scan the QR code with an authenticator app or enter the secret manually.")
+	qrterminal.GenerateHalfBlock(setup.URI, qrterminal.L, out)
+	fmt.Fprintf(out, "secret: %s\nuri: %s\n", setup.Secret, setup.URI)
+
+	code, err := prompt.OTP()
+	if err != nil {
+		return err
+	}
+
+	codes, err := authService.TwoFactorEnable(code)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "2fa enabled")
+	fmt.Fprintln(out, "Recovery codes, each can be used once instead of a code from the app:")
+	for _, code := range codes {
+		fmt.Fprintln(out, code)
+	}
+	fmt.Fprintln(out, "Store them in a safe place, they will not be shown again.")
+
+	return nil
+}
+
+func disableTwoFactor(out io.Writer) error {
+	code, err := prompt.OTP()
+	if err != nil {
+		return err
+	}
+
+	if err := authService.TwoFactorDisable(code); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "2fa disabled")
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(twoFactorCmd)
+	twoFactorCmd.AddCommand(twoFactorEnableCmd)
+	twoFactorCmd.AddCommand(twoFactorDisableCmd)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_enableTwoFactor(t *testing.T) {
+	setup := dto.TOTPSetup{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/GophKeeper:test13?issuer=GophKeeper&secret=JBSWY3DPEHPK3PXP",
+	}
+
+	tests := []struct {
+		name       string
+		pSetup     func(t *testing.T) Prompt
+		sSetup     func(t *testing.T) AuthService
+		wantOutput []string
+		wantErr    string
+	}{
+		{
+			name: "success",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().OTP().Return("123456", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().TwoFactorSetup().Return(setup, nil)
+				service.EXPECT().TwoFactorEnable("123456").Return([]string{"abcde-fghij", "klmno-pqrst"}, nil)
+				return service
+			},
+			wantOutput: []string{
+				"secret: JBSWY3DPEHPK3PXP\n",
+				"uri: " + setup.URI + "\n",
+				"2fa enabled\n",
+				"abcde-fghij\nklmno-pqrst\n",
+			},
+		},
+		{
+			name: "setup_error",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockPrompt(ctrl)
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					TwoFactorSetup().
+					Return(dto.TOTPSetup{}, fmt.Errorf("failed to configure 2fa: 2fa already enabled"))
+				return service
+			},
+			wantErr: "failed to configure 2fa: 2fa already enabled",
+		},
+		{
+			name: "enable_error",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().OTP().Return("000000", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().TwoFactorSetup().Return(setup, nil)
+				service.EXPECT().
+					TwoFactorEnable("000000").
+					Return(nil, fmt.Errorf("failed to configure 2fa: invalid 2fa code"))
+				return service
+			},
+			wantErr: "failed to configure 2fa: invalid 2fa code",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prompt = test.pSetup(t)
+			authService = test.sSetup(t)
+
+			out := new(bytes.Buffer)
+			err := enableTwoFactor(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Enable 2fa error")
+			for _, want := range test.wantOutput {
+				assert.Contains(t, out.String(), want, "Enable 2fa output")
+			}
+		})
+	}
+}
+
+func Test_disableTwoFactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		pSetup     func(t *testing.T) Prompt
+		sSetup     func(t *testing.T) AuthService
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			name: "success",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().OTP().Return("abcde-fghij", nil)
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().TwoFactorDisable("abcde-fghij").Return(nil)
+				return service
+			},
+			wantOutput: "2fa disabled\n",
+		},
+		{
+			name: "empty_code",
+			pSetup: func(t *testing.T) Prompt {
+				ctrl := gomock.NewController(t)
+				prompt := mocks.NewMockPrompt(ctrl)
+				prompt.EXPECT().OTP().Return("", fmt.Errorf("code can not be empty"))
+				return prompt
+			},
+			sSetup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockAuthService(ctrl)
+			},
+			wantErr: "code can not be empty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prompt = test.pSetup(t)
+			authService = test.sSetup(t)
+
+			out := new(bytes.Buffer)
+			err := disableTwoFactor(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Disable 2fa error")
+			assert.Equal(t, test.wantOutput, out.String(), "Disable 2fa output")
+		})
+	}
+}
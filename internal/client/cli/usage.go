@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/inhies/go-bytesize"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show storage usage and quotas",
+	Long:  "Shows the number of secrets and the size of their encrypted data stored on the server.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showUsage(os.Stdout)
+	},
+}
+
+func showUsage(out io.Writer) error {
+	usage, err := secretService.Usage()
+	if err != nil {
+		return fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	maxSecrets, maxBytes := "unlimited", "unlimited"
+	if usage.MaxSecrets > 0 {
+		maxSecrets = strconv.FormatInt(usage.MaxSecrets, 10)
+	}
+	if usage.MaxBytes > 0 {
+		maxBytes = bytesize.New(float64(usage.MaxBytes)).String()
+	}
+
+	fmt.Fprintf(out, "secrets: %d of %s\n", usage.Secrets, maxSecrets)
+	fmt.Fprintf(out, "storage: %s of %s\n", bytesize.New(float64(usage.Bytes)), maxBytes)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/client/cli/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_showUsage(t *testing.T) {
+	type want struct {
+		output string
+		err    string
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SecretService
+		want  want
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Usage().
+					Return(dto.Usage{Bytes: 2048, Secrets: 3, MaxBytes: 1 << 30, MaxSecrets: 100}, nil)
+				return secretService
+			},
+			want: want{
+				output: "secrets: 3 of 100\nstorage: 2.00KB of 1.00GB\n",
+			},
+		},
+		{
+			name: "success_unlimited",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Usage().
+					Return(dto.Usage{Bytes: 100, Secrets: 1}, nil)
+				return secretService
+			},
+			want: want{
+				output: "secrets: 1 of unlimited\nstorage: 100.00B of unlimited\n",
+			},
+		},
+		{
+			name: "failed_to_get_usage",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				secretService := mocks.NewMockSecretService(ctrl)
+				secretService.EXPECT().
+					Usage().
+					Return(dto.Usage{}, fmt.Errorf("authorization failed"))
+				return secretService
+			},
+			want: want{
+				err: "failed to get usage: authorization failed",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secretService = test.setup(t)
+
+			out := new(bytes.Buffer)
+			err := showUsage(out)
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.want.err, gotErr, "Usage error")
+			assert.Equal(t, test.want.output, out.String(), "Usage output")
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"golang.org/x/term"
@@ -35,6 +36,19 @@ func (p *Prompt) SecretName() (string, error) {
 	return name, nil
 }
 
+// EditSecretName ввод нового названия секрета, пустой ввод оставляет текущее
+func (p *Prompt) EditSecretName(current string) (string, error) {
+	label := fmt.Sprintf("Enter new secret name (max %d chars) [%s]: ", dto.SecretNameMaxLen, current)
+	name := p.prompt(label)
+	if name == "" {
+		return current, nil
+	}
+	if len([]rune(name)) > dto.SecretNameMaxLen {
+		return "", fmt.Errorf("name is too long, %d max", dto.SecretNameMaxLen)
+	}
+	return name, nil
+}
+
 // RegisterCredentials ввод учетных данных для регистрации
 func (p *Prompt) RegisterCredentials() (dto.Credentials, error) {
 	cr := dto.Credentials{}
@@ -61,6 +75,86 @@ func (p *Prompt) Credentials() (dto.Credentials, error) {
 	return cr, nil
 }
 
+// PasswordChange ввод текущего и нового пароля, новый пароль вводится дважды
+func (p *Prompt) PasswordChange() (string, string, error) {
+	password := p.promptPassword("current password: ")
+	if password == "" {
+		return "", "", fmt.Errorf("password can not be empty")
+	}
+	newPassword, err := p.NewPassword()
+	if err != nil {
+		return "", "", err
+	}
+	return password, newPassword, nil
+}
+
+// NewPassword ввод нового пароля, пароль вводится дважды
+func (p *Prompt) NewPassword() (string, error) {
+	cr := dto.Credentials{Password: p.promptPassword("new password: ")}
+	if err := cr.ValidatePassword(); err != nil {
+		return "", err
+	}
+	if p.promptPassword("repeat new password: ") != cr.Password {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return cr.Password, nil
+}
+
+// RecoveryKey ввод логина и кода ключа восстановления, код вводится скрыто
+func (p *Prompt) RecoveryKey() (string, string, error) {
+	login := p.prompt("login: ")
+	if login == "" {
+		return "", "", fmt.Errorf("login can not be empty")
+	}
+	code := p.promptPassword("recovery key: ")
+	if code == "" {
+		return "", "", fmt.Errorf("recovery key can not be empty")
+	}
+	return login, code, nil
+}
+
+// OTP ввод кода двухфакторной аутентификации или кода восстановления
+func (p *Prompt) OTP() (string, error) {
+	code := p.prompt("2fa code (or recovery code): ")
+	if code == "" {
+		return "", fmt.Errorf("code can not be empty")
+	}
+	return code, nil
+}
+
+// Card ввод данных банковской карты, номер карты и CVV вводятся скрыто
+func (p *Prompt) Card() (dto.Card, error) {
+	card := dto.Card{}
+	card.Number = p.promptPassword("card number: ")
+	if err := card.ValidateNumber(); err != nil {
+		return card, err
+	}
+	card.Holder = p.prompt("card holder: ")
+	if err := card.ValidateHolder(); err != nil {
+		return card, err
+	}
+	card.Expiry = p.prompt("expiry (MM/YY): ")
+	if err := card.ValidateExpiry(time.Now()); err != nil {
+		return card, err
+	}
+	card.CVV = p.promptPassword("CVV: ")
+	if err := card.ValidateCVV(); err != nil {
+		return card, err
+	}
+	card.Number = card.NormalizedNumber()
+	return card, nil
+}
+
+// OTPKey ввод otpauth:// URI или секрета TOTP в base32, вводится скрыто
+func (p *Prompt) OTPKey() (dto.OTP, error) {
+	key := p.promptPassword("otpauth URI or base32 secret: ")
+	if strings.HasPrefix(strings.ToLower(key), dto.OTPURIScheme+"://") {
+		return dto.ParseOTPURI(key)
+	}
+	o := dto.NewTOTP(key)
+	return o, o.Validate()
+}
+
 // Text() ввод произвольного многострочного текста
 func (p *Prompt) Text() (string, error) {
 	var text strings.Builder
@@ -85,6 +179,25 @@ func (p *Prompt) Overwrite(fileName string) bool {
 	return false
 }
 
+// EditData запрашивает у пользователя нужно ли изменить данные секрета
+func (p *Prompt) EditData() bool {
+	answer := p.prompt("Change secret data y/N? [N]: ")
+	if answer == "Y" || answer == "y" {
+		return true
+	}
+	return false
+}
+
+// ConfirmDelete запрашивает у пользователя подтверждение удаления секрета
+func (p *Prompt) ConfirmDelete(id uint64) bool {
+	label := fmt.Sprintf("Delete secret %d y/N? [N]: ", id)
+	answer := p.prompt(label)
+	if answer == "Y" || answer == "y" {
+		return true
+	}
+	return false
+}
+
 func (p *Prompt) prompt(label string) string {
 	fmt.Fprint(p.out, label)
 	reader := bufio.NewReader(p.in)
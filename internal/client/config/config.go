@@ -21,6 +21,9 @@ type Config struct {
 	ServerAddr string `yaml:"https_addr" env:"SERVER_ADDR" env-default:"localhost:8443"`
 	// Разрешить ли самодписанные сертификаты для https соединения с сервером
 	AllowSelfSignedCert bool `yaml:"allow_self_signed_cert" env:"ALLOW_SELF_SIGNED_CERT" env-default:"false"`
+	// Шифровать ли тела запросов регистрации, логина, смены пароля и восстановления доступа
+	// публичным ключом сервера, ключ закрепляется при первом использовании
+	EncryptAuth bool `yaml:"encrypt_auth" env:"ENCRYPT_AUTH" env-default:"false"`
 	// Максимальный размер файла загрузки в систему в байтах
 	FileMaxSize int64
 }
@@ -28,7 +31,7 @@ type Config struct {
 // Промежуточная конфигурация, служит для преобразования пользовательского ввода типа 10MB
 // в реальное занчение конфига
 type rawConfig struct {
-	FileMaxSize string `yaml:"file_max_size" env:"FILE_MAX_SIZE" env-default:"50MB"`
+	FileMaxSize string `yaml:"file_max_size" env:"FILE_MAX_SIZE" env-default:"10GB"`
 }
 
 // Load pагружает конфигурацию из файла и переменных.
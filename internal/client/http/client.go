@@ -1,37 +1,111 @@
 package http
 
 import (
+	"crypto/rsa"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 )
 
 const (
-	Scheme       = "https://"
-	APIprefix    = "/api"
-	RegisterPath = "/register"
-	LoginPath    = "/login"
-	SecretPath   = "/secret"
-	ContentType  = "application/json"
+	Scheme         = "https://"
+	APIprefix      = "/api"
+	RegisterPath   = "/register"
+	LoginPath      = "/login"
+	PreLoginPath   = "/prelogin"
+	RefreshPath    = "/token/refresh"
+	SecretPath     = "/secret"
+	UploadPath     = SecretPath + "/upload"
+	UsagePath      = "/usage"
+	LogoutPath     = "/logout"
+	SessionPath    = "/session"
+	TwoFactorPath  = "/2fa"
+	PasswordPath   = "/password"
+	AccountKeyPath = "/account-key"
+	RecoveryPath   = "/recovery"
+	PublicKeyPath  = "/pubkey"
+	ContentType    = "application/json"
+	// Тип содержимого зашифрованной части данных
+	ChunkContentType = "application/octet-stream"
 )
 
 var (
 	ErrRegistrationFailed   = errors.New("failed to register user")
 	ErrLoginFailed          = errors.New("login failed")
+	ErrRefreshFailed        = errors.New("failed to refresh token")
 	ErrSecretSendFailed     = errors.New("failed to send secret")
 	ErrSecretRetrieveFailed = errors.New("failed to retrieve secret")
 	ErrSecretInfoListFailed = errors.New("failed to retrieve secret ifo list")
+	ErrSecretChangesFailed  = errors.New("failed to retrieve secret changes")
+	ErrSecretDeleteFailed   = errors.New("failed to delete secret")
+	ErrSecretUpdateFailed   = errors.New("failed to update secret")
+	ErrUploadFailed         = errors.New("failed to upload secret data")
+	ErrChunkRetrieveFailed  = errors.New("failed to retrieve secret data chunk")
+	ErrUsageFailed          = errors.New("failed to retrieve storage usage")
+	ErrQuotaExceeded        = errors.New("storage quota exceeded")
+	ErrSecretInvalid        = errors.New("secret rejected as invalid")
+	ErrLogoutFailed         = errors.New("failed to logout")
+	ErrSessionsFailed       = errors.New("failed to retrieve sessions")
+	ErrSessionRevokeFailed  = errors.New("failed to revoke session")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrOTPRequired          = errors.New("2fa required")
+	ErrInvalidOTP           = errors.New("invalid 2fa code")
+	ErrTwoFactorFailed      = errors.New("failed to configure 2fa")
+	ErrSecretConflict       = errors.New("secret was modified by another client")
+	ErrSecretNotFound       = errors.New("not found")
+	ErrSecretKeysFailed     = errors.New("failed to retrieve secret keys")
+	ErrPasswordChangeFailed = errors.New("failed to change password")
+	ErrInvalidPassword      = errors.New("invalid password")
+	ErrKeysMismatch         = errors.New("secrets were changed during password change, try again")
+	ErrRecoveryFailed       = errors.New("account recovery failed")
+	ErrRecoveryNotSetUp     = errors.New("recovery key not set up")
+	ErrInvalidRecoveryKey   = errors.New("invalid login or recovery key")
+	ErrAccountKeyFailed     = errors.New("failed to set up account key")
+	ErrServerKeyFailed      = errors.New("failed to get server public key")
+	ErrTooManyRequests      = errors.New("too many requests")
 )
 
+// retryMaxWait максимальное время ожидания по заголовку Retry-After ответа 429,
+// при большем ожидании запрос не повторяется, и возвращается ошибка ErrTooManyRequests.
+const retryMaxWait = 10 * time.Second
+
+// serverKeyMinSize минимальный размер публичного ключа сервера в байтах (RSA-2048).
+const serverKeyMinSize = 256
+
+// TokenStorage хранилище токенов, в которое клиент сохраняет токены, обновленные по refresh токену.
+type TokenStorage interface {
+	Token() (string, error)
+	PutToken(token string) error
+	RefreshToken() (string, error)
+	PutRefreshToken(token string) error
+	// LockTokens блокирует обновление токенов другими процессами клиента
+	// и возвращает функцию, снимающую блокировку.
+	LockTokens() (func(), error)
+}
+
+// ServerKeyStorage хранилище закрепленных публичных ключей серверов.
+// ServerKey возвращает nil, если ключ сервера addr еще не закреплен.
+type ServerKeyStorage interface {
+	ServerKey(addr string) ([]byte, error)
+	PutServerKey(addr string, key []byte) error
+}
+
 type Client struct {
-	baseURL string
-	client  *resty.Client
+	baseURL    string
+	client     *resty.Client
+	tokens     TokenStorage
+	serverKeys ServerKeyStorage
 }
 
 func NewClient(baseURL string, allowSefSignedCert bool) *Client {
@@ -50,38 +124,86 @@ func NewClient(baseURL string, allowSefSignedCert bool) *Client {
 	return &c
 }
 
+// SetTokenStorage включает обновление истекшего токена авторизации по refresh токену из хранилища s.
+func (c *Client) SetTokenStorage(s TokenStorage) {
+	c.tokens = s
+}
+
+// SetPayloadEncryption включает шифрование тел запросов, содержащих учетные данные
+// или ключ восстановления, публичным ключом сервера. Ключ запрашивается при первом использовании
+// и закрепляется в хранилище s, далее используется только закрепленный ключ.
+func (c *Client) SetPayloadEncryption(s ServerKeyStorage) {
+	c.serverKeys = s
+}
+
 // Register регистрирует пользователя в системе.
 func (c *Client) Register(cr dto.Credentials) (dto.AuthResponse, error) {
 	var authResp dto.AuthResponse
-	req := c.client.R().
-		SetResult(&authResp).
-		SetBody(cr)
+	req, err := c.payloadRequest(cr)
+	if err != nil {
+		return authResp, fmt.Errorf("%w: %w", ErrRegistrationFailed, err)
+	}
 
-	resp, err := req.Post(RegisterPath)
+	resp, err := c.send(req.SetResult(&authResp), http.MethodPost, RegisterPath)
 	if err != nil {
 		return authResp, fmt.Errorf("%w: %w", ErrRegistrationFailed, err)
 	} else if !resp.IsSuccess() {
+		if err := tooManyRequests(resp); err != nil {
+			return authResp, fmt.Errorf("%w: %w", ErrRegistrationFailed, err)
+		}
 		return authResp, fmt.Errorf("%w: %s", ErrRegistrationFailed, resp.String())
 	}
 
 	return authResp, nil
 }
 
+// PreLogin получает с сервера соль и параметры мастер ключа пользователя
+// и версию схемы аутентификации, из них клиент вычисляет ключ аутентификации для входа.
+func (c *Client) PreLogin(data dto.PreLoginRequest) (dto.PreLoginResponse, error) {
+	var params dto.PreLoginResponse
+
+	resp, err := c.send(c.client.R().SetBody(data).SetResult(&params), http.MethodPost, PreLoginPath)
+	if err != nil {
+		return params, fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	} else if !resp.IsSuccess() {
+		if err := tooManyRequests(resp); err != nil {
+			return params, fmt.Errorf("%w: %w", ErrLoginFailed, err)
+		}
+		return params, fmt.Errorf("%w: internal server error", ErrLoginFailed)
+	}
+
+	return params, nil
+}
+
 // Login осуществляет вход пользователя в систему.
+// Если у пользователя включена двухфакторная аутентификация, а код в cr не указан,
+// возвращает ошибку, содержащую ErrOTPRequired. Если сервер ограничил попытки входа
+// и повторить запрос можно не скоро, возвращает ошибку, содержащую ErrTooManyRequests.
 func (c *Client) Login(cr dto.Credentials) (dto.AuthResponse, error) {
 	var authResp dto.AuthResponse
+	req, err := c.payloadRequest(cr)
+	if err != nil {
+		return authResp, fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	}
 
-	req := c.client.R().
-		SetResult(&authResp).
-		SetBody(cr)
-
-	resp, err := req.Post(LoginPath)
+	resp, err := c.send(req.SetResult(&authResp), http.MethodPost, LoginPath)
 	if err != nil {
 		return authResp, fmt.Errorf("%w: %w", ErrLoginFailed, err)
 	} else if !resp.IsSuccess() {
+		if err := tooManyRequests(resp); err != nil {
+			return authResp, fmt.Errorf("%w: %w", ErrLoginFailed, err)
+		}
 		if resp.StatusCode() == http.StatusInternalServerError {
 			return authResp, fmt.Errorf("%w: internal server error", ErrLoginFailed)
 		}
+		if resp.StatusCode() == http.StatusUnauthorized {
+			switch strings.TrimSpace(resp.String()) {
+			case ErrOTPRequired.Error():
+				return authResp, fmt.Errorf("%w: %w", ErrLoginFailed, ErrOTPRequired)
+			case ErrInvalidOTP.Error():
+				return authResp, fmt.Errorf("%w: %w", ErrLoginFailed, ErrInvalidOTP)
+			}
+		}
 
 		return authResp, ErrLoginFailed
 	}
@@ -89,16 +211,107 @@ func (c *Client) Login(cr dto.Credentials) (dto.AuthResponse, error) {
 	return authResp, nil
 }
 
+// payloadRequest создает запрос с data в теле, если включено шифрование,
+// тело шифруется публичным ключом сервера.
+func (c *Client) payloadRequest(data any) (*resty.Request, error) {
+	req := c.client.R()
+	if c.serverKeys == nil {
+		return req.SetBody(data), nil
+	}
+
+	key, err := c.serverKey()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+	encrypted, err := crypto.EncryptWithPublicKey(body, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt request body: %w", err)
+	}
+
+	return req.SetHeader("Content-Type", dto.EncryptedContentType).SetBody(encrypted), nil
+}
+
+// serverKey возвращает закрепленный публичный ключ сервера,
+// если ключ еще не закреплен, запрашивает его у сервера и закрепляет.
+func (c *Client) serverKey() (*rsa.PublicKey, error) {
+	pemKey, err := c.serverKeys.ServerKey(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := pemKey != nil
+	if !pinned {
+		var keyResp dto.PublicKeyResponse
+		resp, err := c.client.R().SetResult(&keyResp).Get(PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrServerKeyFailed, err)
+		} else if !resp.IsSuccess() {
+			return nil, fmt.Errorf("%w: %s", ErrServerKeyFailed, http.StatusText(resp.StatusCode()))
+		}
+		pemKey = []byte(keyResp.Key)
+	}
+
+	key, err := crypto.ParsePublicKey(pemKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrServerKeyFailed, err)
+	}
+	if key.Size() < serverKeyMinSize {
+		return nil, fmt.Errorf("%w: key is too short", ErrServerKeyFailed)
+	}
+
+	if !pinned {
+		if err := c.serverKeys.PutServerKey(c.baseURL, pemKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// Refresh получает с сервера новую пару токенов по refresh токену.
+func (c *Client) Refresh(refreshToken string) (dto.TokenResponse, error) {
+	var tokens dto.TokenResponse
+	req := c.client.R().
+		SetResult(&tokens).
+		SetBody(dto.RefreshRequest{RefreshToken: refreshToken})
+
+	resp, err := c.send(req, http.MethodPost, RefreshPath)
+	if err != nil {
+		return tokens, fmt.Errorf("%w: %w", ErrRefreshFailed, err)
+	} else if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return tokens, fmt.Errorf("%w: authorization failed", ErrRefreshFailed)
+		}
+		return tokens, fmt.Errorf("%w: internal server error", ErrRefreshFailed)
+	}
+
+	return tokens, nil
+}
+
 // Upload coхраняет секрет на сервере.
+// Если превышена квота пользователя, возвращает ошибку, содержащую ErrQuotaExceeded,
+// если сервер отклонил данные секрета, возвращает ошибку, содержащую ErrSecretInvalid.
 func (c *Client) Upload(data dto.SecretRequest, token string) error {
 	req := c.client.R().
 		SetHeader("Authorization", "Bearer "+token).
 		SetBody(data)
 
-	resp, err := req.Post(SecretPath)
+	resp, err := c.execute(req, http.MethodPost, SecretPath)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrSecretSendFailed, err)
 	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrSecretSendFailed, ErrQuotaExceeded)
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+			return fmt.Errorf("%w: %w: %s", ErrSecretSendFailed, ErrSecretInvalid, resp)
+		}
+
 		text := http.StatusText(resp.StatusCode())
 		if body := resp.String(); body != "" {
 			text += ": " + body
@@ -119,7 +332,7 @@ func (c *Client) Retrieve(id uint64, token string) (dto.SecretResponse, error) {
 		SetResult(&secret)
 
 	path := fmt.Sprintf("%s/%d", SecretPath, id)
-	resp, err := req.Get(path)
+	resp, err := c.execute(req, http.MethodGet, path)
 
 	if err != nil {
 		return secret, fmt.Errorf("%w: %w", ErrSecretRetrieveFailed, err)
@@ -130,7 +343,7 @@ func (c *Client) Retrieve(id uint64, token string) (dto.SecretResponse, error) {
 		case http.StatusBadRequest:
 			return secret, fmt.Errorf("%w: %s", ErrSecretRetrieveFailed, resp)
 		case http.StatusNotFound:
-			return secret, fmt.Errorf("%w: not found", ErrSecretRetrieveFailed)
+			return secret, fmt.Errorf("%w: %w", ErrSecretRetrieveFailed, ErrSecretNotFound)
 		default:
 			return secret, fmt.Errorf("%w: internal server error", ErrSecretRetrieveFailed)
 		}
@@ -139,6 +352,65 @@ func (c *Client) Retrieve(id uint64, token string) (dto.SecretResponse, error) {
 	return secret, nil
 }
 
+// Update изменяет секрет пользователя на сервере.
+// Если секрет был изменен другим клиентом, возвращает ошибку, содержащую ErrSecretConflict,
+// если превышена квота - ErrQuotaExceeded, если сервер отклонил данные секрета - ErrSecretInvalid.
+func (c *Client) Update(id uint64, data dto.SecretUpdateRequest, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetBody(data)
+
+	path := fmt.Sprintf("%s/%d", SecretPath, id)
+	resp, err := c.execute(req, http.MethodPut, path)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretUpdateFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrSecretUpdateFailed)
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+			return fmt.Errorf("%w: %w: %s", ErrSecretUpdateFailed, ErrSecretInvalid, resp)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrSecretUpdateFailed, ErrSecretNotFound)
+		case http.StatusConflict:
+			return fmt.Errorf("%w: %w", ErrSecretUpdateFailed, ErrSecretConflict)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrSecretUpdateFailed, ErrQuotaExceeded)
+		default:
+			return fmt.Errorf("%w: internal server error", ErrSecretUpdateFailed)
+		}
+	}
+
+	return nil
+}
+
+// Delete удаляет секрет пользователя на сервере.
+func (c *Client) Delete(id uint64, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token)
+
+	path := fmt.Sprintf("%s/%d", SecretPath, id)
+	resp, err := c.execute(req, http.MethodDelete, path)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretDeleteFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrSecretDeleteFailed)
+		case http.StatusBadRequest:
+			return fmt.Errorf("%w: %s", ErrSecretDeleteFailed, resp)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrSecretDeleteFailed, ErrSecretNotFound)
+		default:
+			return fmt.Errorf("%w: internal server error", ErrSecretDeleteFailed)
+		}
+	}
+
+	return nil
+}
+
 // InfoList получает информацию о всех секретах пользователя с сервера.
 func (c *Client) InfoList(token string) ([]dto.SecretInfo, error) {
 	var list []dto.SecretInfo
@@ -147,7 +419,7 @@ func (c *Client) InfoList(token string) ([]dto.SecretInfo, error) {
 		SetHeader("Authorization", "Bearer "+token).
 		SetResult(&list)
 
-	resp, err := req.Get(SecretPath)
+	resp, err := c.execute(req, http.MethodGet, SecretPath)
 
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrSecretInfoListFailed, err)
@@ -160,3 +432,660 @@ func (c *Client) InfoList(token string) ([]dto.SecretInfo, error) {
 
 	return list, nil
 }
+
+// Usage получает с сервера занятый пользователем объем хранилища и его квоты.
+func (c *Client) Usage(token string) (dto.Usage, error) {
+	var usage dto.Usage
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&usage)
+
+	resp, err := c.execute(req, http.MethodGet, UsagePath)
+
+	if err != nil {
+		return usage, fmt.Errorf("%w: %w", ErrUsageFailed, err)
+	} else if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return usage, fmt.Errorf("%w: authorization failed", ErrUsageFailed)
+		}
+		return usage, fmt.Errorf("%w: internal server error", ErrUsageFailed)
+	}
+
+	return usage, nil
+}
+
+// Logout завершает на сервере текущую сессию пользователя.
+func (c *Client) Logout(token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token)
+
+	resp, err := c.execute(req, http.MethodPost, LogoutPath)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLogoutFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrLogoutFailed)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrLogoutFailed, ErrSessionNotFound)
+		default:
+			return fmt.Errorf("%w: internal server error", ErrLogoutFailed)
+		}
+	}
+
+	return nil
+}
+
+// Sessions получает с сервера список действующих сессий пользователя.
+func (c *Client) Sessions(token string) ([]dto.Session, error) {
+	var list []dto.Session
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&list)
+
+	resp, err := c.execute(req, http.MethodGet, SessionPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSessionsFailed, err)
+	} else if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w: authorization failed", ErrSessionsFailed)
+		}
+		return nil, fmt.Errorf("%w: internal server error", ErrSessionsFailed)
+	}
+
+	return list, nil
+}
+
+// RevokeSession завершает на сервере сессию пользователя по id.
+func (c *Client) RevokeSession(id string, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token)
+
+	path := SessionPath + "/" + url.PathEscape(id)
+	resp, err := c.execute(req, http.MethodDelete, path)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSessionRevokeFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrSessionRevokeFailed)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrSessionRevokeFailed, ErrSessionNotFound)
+		default:
+			return fmt.Errorf("%w: internal server error", ErrSessionRevokeFailed)
+		}
+	}
+
+	return nil
+}
+
+// TwoFactorSetup получает с сервера новый TOTP секрет для двухфакторной аутентификации.
+func (c *Client) TwoFactorSetup(token string) (dto.TOTPSetup, error) {
+	var setup dto.TOTPSetup
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&setup)
+
+	resp, err := c.execute(req, http.MethodPost, TwoFactorPath+"/setup")
+	if err != nil {
+		return setup, fmt.Errorf("%w: %w", ErrTwoFactorFailed, err)
+	} else if !resp.IsSuccess() {
+		return setup, twoFactorError(resp)
+	}
+
+	return setup, nil
+}
+
+// TwoFactorEnable включает двухфакторную аутентификацию по коду из приложения,
+// возвращает коды восстановления.
+func (c *Client) TwoFactorEnable(code string, token string) (dto.RecoveryCodes, error) {
+	var codes dto.RecoveryCodes
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetBody(dto.TOTPCode{Code: code}).
+		SetResult(&codes)
+
+	resp, err := c.execute(req, http.MethodPost, TwoFactorPath+"/enable")
+	if err != nil {
+		return codes, fmt.Errorf("%w: %w", ErrTwoFactorFailed, err)
+	} else if !resp.IsSuccess() {
+		return codes, twoFactorError(resp)
+	}
+
+	return codes, nil
+}
+
+// TwoFactorDisable выключает двухфакторную аутентификацию по коду из приложения или коду восстановления.
+func (c *Client) TwoFactorDisable(code string, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetBody(dto.TOTPCode{Code: code})
+
+	resp, err := c.execute(req, http.MethodPost, TwoFactorPath+"/disable")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTwoFactorFailed, err)
+	} else if !resp.IsSuccess() {
+		return twoFactorError(resp)
+	}
+
+	return nil
+}
+
+// SecretKeys получает с сервера зашифрованные ключи данных всех секретов пользователя.
+func (c *Client) SecretKeys(token string) ([]dto.SecretKey, error) {
+	var keys []dto.SecretKey
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&keys)
+
+	resp, err := c.execute(req, http.MethodGet, SecretPath+"/keys")
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecretKeysFailed, err)
+	} else if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w: authorization failed", ErrSecretKeysFailed)
+		}
+		return nil, fmt.Errorf("%w: internal server error", ErrSecretKeysFailed)
+	}
+
+	return keys, nil
+}
+
+// PasswordParams получает с сервера соль и параметры мастер ключа текущего пользователя
+// и версию схемы аутентификации, они нужны для подтверждения текущего пароля при его смене.
+func (c *Client) PasswordParams(token string) (dto.PreLoginResponse, error) {
+	var params dto.PreLoginResponse
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&params)
+
+	resp, err := c.execute(req, http.MethodGet, PasswordPath)
+	if err != nil {
+		return params, fmt.Errorf("%w: %w", ErrPasswordChangeFailed, err)
+	} else if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return params, fmt.Errorf("%w: authorization failed", ErrPasswordChangeFailed)
+		}
+		return params, fmt.Errorf("%w: internal server error", ErrPasswordChangeFailed)
+	}
+
+	return params, nil
+}
+
+// ChangePassword меняет пароль пользователя и ключи данных его секретов.
+// Если текущий пароль не подошел, возвращает ошибку, содержащую ErrInvalidPassword,
+// если секреты изменились после получения ключей, возвращает ошибку, содержащую ErrKeysMismatch.
+func (c *Client) ChangePassword(data dto.PasswordChangeRequest, token string) error {
+	req, err := c.payloadRequest(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPasswordChangeFailed, err)
+	}
+	req.SetHeader("Authorization", "Bearer "+token)
+
+	resp, err := c.execute(req, http.MethodPost, PasswordPath)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPasswordChangeFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrPasswordChangeFailed)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrPasswordChangeFailed, ErrInvalidPassword)
+		case http.StatusConflict:
+			return fmt.Errorf("%w: %w", ErrPasswordChangeFailed, ErrKeysMismatch)
+		case http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %w", ErrPasswordChangeFailed, tooManyRequests(resp))
+		case http.StatusBadRequest:
+			return fmt.Errorf("%w: %s", ErrPasswordChangeFailed, strings.TrimSpace(resp.String()))
+		default:
+			return fmt.Errorf("%w: internal server error", ErrPasswordChangeFailed)
+		}
+	}
+
+	return nil
+}
+
+// SetAccountKey сохраняет на сервере ключ аккаунта и перешифрованные им ключи данных секретов.
+func (c *Client) SetAccountKey(data dto.AccountKeyRequest, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetBody(data)
+
+	resp, err := c.execute(req, http.MethodPut, AccountKeyPath)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAccountKeyFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrAccountKeyFailed)
+		case http.StatusBadRequest, http.StatusConflict:
+			return fmt.Errorf("%w: %s", ErrAccountKeyFailed, strings.TrimSpace(resp.String()))
+		default:
+			return fmt.Errorf("%w: internal server error", ErrAccountKeyFailed)
+		}
+	}
+
+	return nil
+}
+
+// SetupRecovery сохраняет на сервере ключи восстановления доступа, заменяя предыдущие.
+func (c *Client) SetupRecovery(data dto.RecoverySetupRequest, token string) error {
+	req, err := c.payloadRequest(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	}
+	req.SetHeader("Authorization", "Bearer "+token)
+
+	resp, err := c.execute(req, http.MethodPut, RecoveryPath)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrRecoveryFailed)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrRecoveryFailed, ErrInvalidPassword)
+		case http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %w", ErrRecoveryFailed, tooManyRequests(resp))
+		case http.StatusBadRequest:
+			return fmt.Errorf("%w: %s", ErrRecoveryFailed, strings.TrimSpace(resp.String()))
+		default:
+			return fmt.Errorf("%w: internal server error", ErrRecoveryFailed)
+		}
+	}
+
+	return nil
+}
+
+// Recovery получает с сервера ключи восстановления доступа.
+// Если восстановление не настроено, возвращает ErrRecoveryNotSetUp.
+func (c *Client) Recovery(token string) (dto.RecoveryKey, error) {
+	var key dto.RecoveryKey
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&key)
+
+	resp, err := c.execute(req, http.MethodGet, RecoveryPath)
+	if err != nil {
+		return key, fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusNotFound:
+			return key, ErrRecoveryNotSetUp
+		case http.StatusUnauthorized:
+			return key, fmt.Errorf("%w: authorization failed", ErrRecoveryFailed)
+		default:
+			return key, fmt.Errorf("%w: internal server error", ErrRecoveryFailed)
+		}
+	}
+
+	return key, nil
+}
+
+// RecoveryStart получает с сервера зашифрованные мастер ключ и ключи данных секретов
+// для восстановления доступа. Если логин или ключ не подошли, возвращает ошибку,
+// содержащую ErrInvalidRecoveryKey.
+func (c *Client) RecoveryStart(data dto.RecoveryStartRequest) (dto.RecoveryStartResponse, error) {
+	var start dto.RecoveryStartResponse
+
+	req, err := c.payloadRequest(data)
+	if err != nil {
+		return start, fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	}
+	req.SetResult(&start)
+
+	resp, err := c.send(req, http.MethodPost, RecoveryPath+"/start")
+	if err != nil {
+		return start, fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	} else if !resp.IsSuccess() {
+		return start, recoveryError(resp)
+	}
+
+	return start, nil
+}
+
+// RecoveryComplete сбрасывает пароль по ключу восстановления и выполняет вход.
+// Если у пользователя включена двухфакторная аутентификация, а код не указан,
+// возвращает ошибку, содержащую ErrOTPRequired, если секреты изменились
+// после получения ключей, возвращает ошибку, содержащую ErrKeysMismatch.
+func (c *Client) RecoveryComplete(data dto.RecoveryRequest) (dto.AuthResponse, error) {
+	var authResp dto.AuthResponse
+
+	req, err := c.payloadRequest(data)
+	if err != nil {
+		return authResp, fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	}
+	req.SetResult(&authResp)
+
+	resp, err := c.send(req, http.MethodPost, RecoveryPath+"/complete")
+	if err != nil {
+		return authResp, fmt.Errorf("%w: %w", ErrRecoveryFailed, err)
+	} else if !resp.IsSuccess() {
+		return authResp, recoveryError(resp)
+	}
+
+	return authResp, nil
+}
+
+func recoveryError(resp *resty.Response) error {
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRecoveryFailed, tooManyRequests(resp))
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrRecoveryFailed, ErrInvalidRecoveryKey)
+	case http.StatusUnauthorized:
+		switch strings.TrimSpace(resp.String()) {
+		case ErrOTPRequired.Error():
+			return fmt.Errorf("%w: %w", ErrRecoveryFailed, ErrOTPRequired)
+		case ErrInvalidOTP.Error():
+			return fmt.Errorf("%w: %w", ErrRecoveryFailed, ErrInvalidOTP)
+		}
+		return fmt.Errorf("%w: authorization failed", ErrRecoveryFailed)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %w", ErrRecoveryFailed, ErrKeysMismatch)
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrRecoveryFailed, strings.TrimSpace(resp.String()))
+	default:
+		return fmt.Errorf("%w: internal server error", ErrRecoveryFailed)
+	}
+}
+
+func twoFactorError(resp *resty.Response) error {
+	switch resp.StatusCode() {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: authorization failed", ErrTwoFactorFailed)
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %w", ErrTwoFactorFailed, ErrInvalidOTP)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrTwoFactorFailed, strings.TrimSpace(resp.String()))
+	default:
+		return fmt.Errorf("%w: internal server error", ErrTwoFactorFailed)
+	}
+}
+
+// Changes получает с сервера ленту изменений секретов пользователя после ревизии since.
+func (c *Client) Changes(since uint64, token string) (dto.SecretChanges, error) {
+	var changes dto.SecretChanges
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetQueryParam("since", strconv.FormatUint(since, 10)).
+		SetResult(&changes)
+
+	resp, err := c.execute(req, http.MethodGet, SecretPath)
+
+	if err != nil {
+		return changes, fmt.Errorf("%w: %w", ErrSecretChangesFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return changes, fmt.Errorf("%w: authorization failed", ErrSecretChangesFailed)
+		case http.StatusBadRequest:
+			return changes, fmt.Errorf("%w: %s", ErrSecretChangesFailed, resp)
+		default:
+			return changes, fmt.Errorf("%w: internal server error", ErrSecretChangesFailed)
+		}
+	}
+
+	return changes, nil
+}
+
+// CreateUpload создает на сервере сессию загрузки секрета по частям.
+func (c *Client) CreateUpload(data dto.UploadRequest, token string) (dto.UploadResponse, error) {
+	var upload dto.UploadResponse
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&upload).
+		SetBody(data)
+
+	resp, err := c.execute(req, http.MethodPost, UploadPath)
+	if err != nil {
+		return upload, fmt.Errorf("%w: %w", ErrUploadFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return upload, fmt.Errorf("%w: authorization failed", ErrUploadFailed)
+		case http.StatusBadRequest:
+			return upload, fmt.Errorf("%w: %s", ErrUploadFailed, resp)
+		default:
+			return upload, fmt.Errorf("%w: internal server error", ErrUploadFailed)
+		}
+	}
+
+	return upload, nil
+}
+
+// UploadChunk отправляет на сервер зашифрованную часть n загрузки uploadID.
+// Если превышена квота пользователя, возвращает ошибку, содержащую ErrQuotaExceeded.
+func (c *Client) UploadChunk(uploadID string, n uint32, data []byte, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetHeader("Content-Type", ChunkContentType).
+		SetBody(data)
+
+	path := fmt.Sprintf("%s/%s/chunk/%d", UploadPath, uploadID, n)
+	resp, err := c.execute(req, http.MethodPut, path)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUploadFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrUploadFailed)
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+			return fmt.Errorf("%w: %s", ErrUploadFailed, resp)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrUploadFailed, ErrSecretNotFound)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrUploadFailed, ErrQuotaExceeded)
+		default:
+			return fmt.Errorf("%w: internal server error", ErrUploadFailed)
+		}
+	}
+
+	return nil
+}
+
+// UploadStatus получает с сервера состояние загрузки uploadID.
+func (c *Client) UploadStatus(uploadID string, token string) (dto.UploadStatus, error) {
+	var status dto.UploadStatus
+
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetResult(&status)
+
+	path := fmt.Sprintf("%s/%s", UploadPath, uploadID)
+	resp, err := c.execute(req, http.MethodGet, path)
+
+	if err != nil {
+		return status, fmt.Errorf("%w: %w", ErrUploadFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return status, fmt.Errorf("%w: authorization failed", ErrUploadFailed)
+		case http.StatusNotFound:
+			return status, fmt.Errorf("%w: %w", ErrUploadFailed, ErrSecretNotFound)
+		default:
+			return status, fmt.Errorf("%w: internal server error", ErrUploadFailed)
+		}
+	}
+
+	return status, nil
+}
+
+// CommitUpload завершает загрузку uploadID.
+// Если заменяемый секрет был изменен другим клиентом, возвращает ошибку, содержащую ErrSecretConflict.
+func (c *Client) CommitUpload(uploadID string, data dto.UploadCommitRequest, token string) error {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetBody(data)
+
+	path := fmt.Sprintf("%s/%s/commit", UploadPath, uploadID)
+	resp, err := c.execute(req, http.MethodPost, path)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUploadFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: authorization failed", ErrUploadFailed)
+		case http.StatusBadRequest:
+			return fmt.Errorf("%w: %s", ErrUploadFailed, resp)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrUploadFailed, ErrSecretNotFound)
+		case http.StatusConflict:
+			return fmt.Errorf("%w: %w", ErrUploadFailed, ErrSecretConflict)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", ErrUploadFailed, ErrQuotaExceeded)
+		default:
+			return fmt.Errorf("%w: internal server error", ErrUploadFailed)
+		}
+	}
+
+	return nil
+}
+
+// RetrieveChunk получает с сервера зашифрованную часть n данных секрета.
+func (c *Client) RetrieveChunk(id uint64, n uint32, token string) ([]byte, error) {
+	req := c.client.R().
+		SetHeader("Authorization", "Bearer "+token)
+
+	path := fmt.Sprintf("%s/%d/chunk/%d", SecretPath, id, n)
+	resp, err := c.execute(req, http.MethodGet, path)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChunkRetrieveFailed, err)
+	} else if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusUnauthorized:
+			return nil, fmt.Errorf("%w: authorization failed", ErrChunkRetrieveFailed)
+		case http.StatusBadRequest:
+			return nil, fmt.Errorf("%w: %s", ErrChunkRetrieveFailed, resp)
+		case http.StatusNotFound:
+			return nil, fmt.Errorf("%w: %w", ErrChunkRetrieveFailed, ErrSecretNotFound)
+		default:
+			return nil, fmt.Errorf("%w: internal server error", ErrChunkRetrieveFailed)
+		}
+	}
+
+	return resp.Body(), nil
+}
+
+// execute выполняет запрос с токеном авторизации. Если токен истек, обновляет его
+// по refresh токену из хранилища и повторяет запрос. Если обновить токен не удалось,
+// возвращает исходный ответ сервера.
+func (c *Client) execute(req *resty.Request, method, path string) (*resty.Response, error) {
+	resp, err := c.send(req, method, path)
+	if err != nil || c.tokens == nil || !tokenExpired(resp) {
+		return resp, err
+	}
+
+	expired := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	token, err := c.refreshToken(expired)
+	if err != nil {
+		return resp, nil
+	}
+
+	req.SetHeader("Authorization", "Bearer "+token)
+	return c.send(req, method, path)
+}
+
+// send выполняет запрос. Если сервер ответил 429 и разрешил повторить запрос
+// не позже чем через retryMaxWait, ждет указанное в Retry-After время и повторяет запрос один раз.
+func (c *Client) send(req *resty.Request, method, path string) (*resty.Response, error) {
+	resp, err := req.Execute(method, path)
+	if err != nil || resp.StatusCode() != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	wait, ok := retryAfter(resp)
+	if !ok || wait > retryMaxWait {
+		return resp, nil
+	}
+
+	time.Sleep(wait)
+	return req.Execute(method, path)
+}
+
+// retryAfter возвращает время ожидания из заголовка Retry-After в секундах или в формате даты HTTP.
+func retryAfter(resp *resty.Response) (time.Duration, bool) {
+	value := resp.Header().Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// tooManyRequests возвращает ошибку ErrTooManyRequests со временем ожидания из ответа 429
+// или nil, если сервер не ограничивал частоту запросов.
+func tooManyRequests(resp *resty.Response) error {
+	if resp.StatusCode() != http.StatusTooManyRequests {
+		return nil
+	}
+	if wait, ok := retryAfter(resp); ok {
+		return fmt.Errorf("%w, retry after %s", ErrTooManyRequests, wait)
+	}
+	return ErrTooManyRequests
+}
+
+// refreshToken возвращает действующий токен авторизации вместо истекшего expired.
+// Если токен в хранилище уже обновлен, например предыдущим запросом, возвращает его,
+// иначе получает новую пару токенов с сервера и сохраняет ее в хранилище.
+// Обновление выполняется под блокировкой хранилища: сервер считает повторное использование
+// refresh токена кражей и завершает сессию, поэтому два процесса клиента
+// не должны обновлять токен одновременно.
+func (c *Client) refreshToken(expired string) (string, error) {
+	if token, err := c.tokens.Token(); err == nil && token != expired {
+		return token, nil
+	}
+
+	unlock, err := c.tokens.LockTokens()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// Пока ждали блокировку, токен мог обновить другой процесс
+	if token, err := c.tokens.Token(); err == nil && token != expired {
+		return token, nil
+	}
+
+	refreshToken, err := c.tokens.RefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := c.Refresh(refreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.tokens.PutToken(tokens.Token); err != nil {
+		return "", err
+	}
+	if err := c.tokens.PutRefreshToken(tokens.RefreshToken); err != nil {
+		return "", err
+	}
+
+	return tokens.Token, nil
+}
+
+func tokenExpired(resp *resty.Response) bool {
+	return resp.StatusCode() == http.StatusUnauthorized && strings.TrimSpace(resp.String()) == "token expired"
+}
@@ -1,12 +1,15 @@
 package http
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -141,6 +144,22 @@ func TestClient_Login(t *testing.T) {
 				err: ErrLoginFailed,
 			},
 		},
+		{
+			name:     "2fa_required",
+			respCode: http.StatusUnauthorized,
+			respBody: []byte("2fa required\n"),
+			want: want{
+				err: ErrOTPRequired,
+			},
+		},
+		{
+			name:     "invalid_2fa_code",
+			respCode: http.StatusUnauthorized,
+			respBody: []byte("invalid 2fa code\n"),
+			want: want{
+				err: ErrInvalidOTP,
+			},
+		},
 		{
 			name:     "server_error",
 			respCode: http.StatusInternalServerError,
@@ -162,6 +181,8 @@ func TestClient_Login(t *testing.T) {
 
 				if test.respCode != http.StatusOK {
 					w.WriteHeader(test.respCode)
+					_, err = w.Write(test.respBody)
+					require.Nil(t, err, "Write response body")
 					return
 				}
 
@@ -190,131 +211,127 @@ func TestClient_Login(t *testing.T) {
 	}
 }
 
-func TestClient_Upload(t *testing.T) {
-	secretRequest := dto.SecretRequest{}
-	reqBody, err := json.Marshal(secretRequest)
-	require.Nil(t, err, "Secret request json encoding")
+func TestClient_LoginRetryAfter(t *testing.T) {
+	credentials := dto.Credentials{Login: "test", Password: "password13"}
+	reqBody, err := json.Marshal(credentials)
+	require.Nil(t, err, "Credentials json encoding")
 
-	secretResp := dto.SecretResponse{}
-	respBody, err := json.Marshal(secretResp)
-	require.Nil(t, err, "Auth response json encoding")
+	authResp := dto.AuthResponse{Token: "token", EncrSalt: "encryption salt"}
 
 	tests := []struct {
-		name     string
-		netError bool
-		respCode int
-		repBody  []byte
-		wantErr  error
+		name       string
+		retryAfter []string
+		wantCalls  int
+		wantErr    error
 	}{
 		{
-			name:     "succes",
-			respCode: http.StatusOK,
-			repBody:  respBody,
+			name:       "retry_succes",
+			retryAfter: []string{"1"},
+			wantCalls:  2,
 		},
 		{
-			name:     "network_error",
-			netError: true,
-			wantErr:  ErrSecretSendFailed,
+			name:       "wait_too_long",
+			retryAfter: []string{"3600"},
+			wantCalls:  1,
+			wantErr:    ErrTooManyRequests,
 		},
 		{
-			name:     "secret_upload_error",
-			respCode: http.StatusBadRequest,
-			wantErr:  ErrSecretSendFailed,
+			name:       "retry_once",
+			retryAfter: []string{"0", "0"},
+			wantCalls:  2,
+			wantErr:    ErrTooManyRequests,
+		},
+		{
+			name:       "no_retry_after",
+			retryAfter: []string{""},
+			wantCalls:  1,
+			wantErr:    ErrTooManyRequests,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			calls := 0
 			handler := func(w http.ResponseWriter, r *http.Request) {
-				assert.Equal(t, SecretPath, r.RequestURI, "Request URI")
-				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
-				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
-
 				body, err := io.ReadAll(r.Body)
-				require.Nil(t, err, "Read response body")
+				require.Nil(t, err, "Read request body")
 				assert.Equal(t, reqBody, body, "Request body")
 
-				w.WriteHeader(test.respCode)
-
-				if test.respCode != http.StatusOK {
-					_, err = w.Write([]byte("error description"))
-					require.Nil(t, err, "Write response body")
+				calls++
+				if calls <= len(test.retryAfter) {
+					if test.retryAfter[calls-1] != "" {
+						w.Header().Set("Retry-After", test.retryAfter[calls-1])
+					}
+					http.Error(w, "too many requests", http.StatusTooManyRequests)
+					return
 				}
+
+				w.Header().Set("Content-Type", ContentType)
+				require.Nil(t, json.NewEncoder(w).Encode(authResp), "Encode response")
 			}
 
 			server := httptest.NewServer(http.HandlerFunc(handler))
 			defer server.Close()
 
 			client := NewClient(server.URL, true)
-			if test.netError {
-				server.Close()
+			resp, err := client.Login(credentials)
+			assert.Equal(t, test.wantCalls, calls, "Request count")
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, ErrLoginFailed, "Login error")
+				assert.ErrorIs(t, err, test.wantErr, "Login error")
+				return
 			}
-
-			err := client.Upload(secretRequest, "token")
-			assert.ErrorIs(t, err, test.wantErr, "Upload error")
-
+			require.Nil(t, err, "Login error")
+			assert.Equal(t, authResp, resp, "Auth response")
 		})
 	}
 }
 
-func TestClient_Retrieve(t *testing.T) {
-	secretResp := dto.SecretResponse{}
-	respBody, err := json.Marshal(secretResp)
-	require.Nil(t, err, "Secret response json encoding")
+func TestClient_Refresh(t *testing.T) {
+	reqBody, err := json.Marshal(dto.RefreshRequest{RefreshToken: "refresh"})
+	require.Nil(t, err, "Refresh request json encoding")
+
+	tokens := dto.TokenResponse{Token: "token", RefreshToken: "new_refresh"}
+	respBody, err := json.Marshal(tokens)
+	require.Nil(t, err, "Token response json encoding")
 
 	type want struct {
-		secretResp dto.SecretResponse
-		err        error
+		tokens dto.TokenResponse
+		err    error
 	}
 
 	tests := []struct {
 		name     string
 		netError bool
 		respCode int
-		respBody []byte
 		want     want
 	}{
 		{
 			name:     "succes",
 			respCode: http.StatusOK,
-			respBody: respBody,
 			want: want{
-				secretResp: secretResp,
+				tokens: tokens,
 			},
 		},
 		{
 			name:     "network_error",
 			netError: true,
 			want: want{
-				err: ErrSecretRetrieveFailed,
+				err: ErrRefreshFailed,
 			},
 		},
 		{
 			name:     "unauthorized",
 			respCode: http.StatusUnauthorized,
 			want: want{
-				err: ErrSecretRetrieveFailed,
-			},
-		},
-		{
-			name:     "bad_request",
-			respCode: http.StatusBadRequest,
-			want: want{
-				err: ErrSecretRetrieveFailed,
-			},
-		},
-		{
-			name:     "not_found",
-			respCode: http.StatusNotFound,
-			want: want{
-				err: ErrSecretRetrieveFailed,
+				err: ErrRefreshFailed,
 			},
 		},
 		{
-			name:     "internal_server_error",
+			name:     "server_error",
 			respCode: http.StatusInternalServerError,
 			want: want{
-				err: ErrSecretRetrieveFailed,
+				err: ErrRefreshFailed,
 			},
 		},
 	}
@@ -322,9 +339,12 @@ func TestClient_Retrieve(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			handler := func(w http.ResponseWriter, r *http.Request) {
-				assert.Equal(t, SecretPath+"/13", r.RequestURI, "Request URI")
-				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
-				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+				assert.Equal(t, RefreshPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read response body")
+				assert.Equal(t, reqBody, body, "Request body")
 
 				if test.respCode != http.StatusOK {
 					w.WriteHeader(test.respCode)
@@ -345,78 +365,124 @@ func TestClient_Retrieve(t *testing.T) {
 				server.Close()
 			}
 
-			resp, err := client.Retrieve(13, "token")
-			assert.ErrorIs(t, err, test.want.err, "Retrieve error")
-			if err == nil {
-				assert.Equal(t, test.want.secretResp, resp, "Secret response")
+			got, err := client.Refresh("refresh")
+			assert.ErrorIs(t, err, test.want.err, "Refresh error")
+			if err != nil {
+				return
 			}
+			assert.Equal(t, test.want.tokens, got, "Token response")
 		})
 	}
 }
 
-func TestClient_InfoList(t *testing.T) {
-	infoList := []dto.SecretInfo{{}}
-	respBody, err := json.Marshal(infoList)
-	require.Nil(t, err, "Auth response json encoding")
+func TestClient_execute(t *testing.T) {
+	list := []dto.SecretInfo{{ID: 13}}
+	listBody, err := json.Marshal(list)
+	require.Nil(t, err, "Secret info list json encoding")
+
+	tokensBody, err := json.Marshal(dto.TokenResponse{Token: "new", RefreshToken: "new_refresh"})
+	require.Nil(t, err, "Token response json encoding")
 
 	type want struct {
-		list []dto.SecretInfo
-		err  error
+		list     []dto.SecretInfo
+		err      error
+		refresh  int
+		storage  testTokenStorage
+		requests []string
 	}
 
 	tests := []struct {
-		name     string
-		netError bool
-		respCode int
-		respBody []byte
-		want     want
+		name        string
+		storage     *testTokenStorage
+		expiredBody string
+		refreshCode int
+		want        want
 	}{
 		{
-			name:     "succes",
-			respCode: http.StatusOK,
-			respBody: respBody,
+			name:        "refreshed",
+			storage:     &testTokenStorage{token: "old", refreshToken: "refresh"},
+			expiredBody: "token expired",
+			refreshCode: http.StatusOK,
 			want: want{
-				list: infoList,
+				list:     list,
+				refresh:  1,
+				storage:  testTokenStorage{token: "new", refreshToken: "new_refresh"},
+				requests: []string{"Bearer old", "Bearer new"},
 			},
 		},
 		{
-			name:     "network_error",
-			netError: true,
+			name:        "already_refreshed",
+			storage:     &testTokenStorage{token: "new", refreshToken: "new_refresh"},
+			expiredBody: "token expired",
 			want: want{
-				err: ErrSecretInfoListFailed,
+				list:     list,
+				storage:  testTokenStorage{token: "new", refreshToken: "new_refresh"},
+				requests: []string{"Bearer old", "Bearer new"},
 			},
 		},
 		{
-			name:     "unauthorized",
-			respCode: http.StatusUnauthorized,
+			name: "refreshed_by_other_process",
+			storage: &testTokenStorage{
+				token:        "old",
+				refreshToken: "refresh",
+				rotated:      &testTokenStorage{token: "new", refreshToken: "new_refresh"},
+			},
+			expiredBody: "token expired",
 			want: want{
-				err: ErrSecretInfoListFailed,
+				list:     list,
+				storage:  testTokenStorage{token: "new", refreshToken: "new_refresh"},
+				requests: []string{"Bearer old", "Bearer new"},
 			},
 		},
 		{
-			name:     "internal_server_error",
-			respCode: http.StatusInternalServerError,
+			name:        "refresh_failed",
+			storage:     &testTokenStorage{token: "old", refreshToken: "refresh"},
+			expiredBody: "token expired",
+			refreshCode: http.StatusUnauthorized,
 			want: want{
-				err: ErrSecretInfoListFailed,
+				err:      ErrSecretInfoListFailed,
+				refresh:  1,
+				storage:  testTokenStorage{token: "old", refreshToken: "refresh"},
+				requests: []string{"Bearer old"},
+			},
+		},
+		{
+			name:    "invalid_token",
+			storage: &testTokenStorage{token: "old", refreshToken: "refresh"},
+			want: want{
+				err:      ErrSecretInfoListFailed,
+				storage:  testTokenStorage{token: "old", refreshToken: "refresh"},
+				requests: []string{"Bearer old"},
 			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			var refresh int
+			var requests []string
+
 			handler := func(w http.ResponseWriter, r *http.Request) {
-				assert.Equal(t, SecretPath, r.RequestURI, "Request URI")
-				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
-				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+				if r.RequestURI == RefreshPath {
+					refresh++
+					w.Header().Set("Content-Type", ContentType)
+					w.WriteHeader(test.refreshCode)
+					if test.refreshCode == http.StatusOK {
+						_, err := w.Write(tokensBody)
+						require.Nil(t, err, "Write response body")
+					}
+					return
+				}
 
-				if test.respCode != http.StatusOK {
-					w.WriteHeader(test.respCode)
+				auth := r.Header.Get("Authorization")
+				requests = append(requests, auth)
+				if auth != "Bearer new" {
+					http.Error(w, test.expiredBody, http.StatusUnauthorized)
 					return
 				}
 
 				w.Header().Set("Content-Type", ContentType)
-				w.WriteHeader(test.respCode)
-				_, err = w.Write(respBody)
+				_, err := w.Write(listBody)
 				require.Nil(t, err, "Write response body")
 			}
 
@@ -424,14 +490,2080 @@ func TestClient_InfoList(t *testing.T) {
 			defer server.Close()
 
 			client := NewClient(server.URL, true)
-			if test.netError {
-				server.Close()
+			client.SetTokenStorage(test.storage)
+
+			got, err := client.InfoList("old")
+			assert.ErrorIs(t, err, test.want.err, "InfoList error")
+			assert.Equal(t, test.want.refresh, refresh, "Refresh requests")
+			assert.Equal(t, test.want.requests, requests, "Authorization headers")
+			assert.Equal(t, test.want.storage, *test.storage, "Stored tokens")
+			if err == nil {
+				assert.Equal(t, test.want.list, got, "Secret info list")
 			}
+		})
+	}
+}
 
-			list, err := client.InfoList("token")
-			assert.ErrorIs(t, err, test.want.err, "Retrieve error")
+type testTokenStorage struct {
+	token        string
+	refreshToken string
+	// Токены, которые другой процесс сохраняет, пока этот ждет блокировку
+	rotated *testTokenStorage
+}
+
+func (s *testTokenStorage) Token() (string, error) {
+	return s.token, nil
+}
+
+func (s *testTokenStorage) PutToken(token string) error {
+	s.token = token
+	return nil
+}
+
+func (s *testTokenStorage) RefreshToken() (string, error) {
+	return s.refreshToken, nil
+}
+
+func (s *testTokenStorage) PutRefreshToken(token string) error {
+	s.refreshToken = token
+	return nil
+}
+
+func (s *testTokenStorage) LockTokens() (func(), error) {
+	if s.rotated != nil {
+		s.token, s.refreshToken, s.rotated = s.rotated.token, s.rotated.refreshToken, nil
+	}
+	return func() {}, nil
+}
+
+func TestClient_PayloadEncryption(t *testing.T) {
+	credentials := dto.Credentials{Login: "test", Password: "password13"}
+	reqBody, err := json.Marshal(credentials)
+	require.Nil(t, err, "Credentials json encoding")
+
+	authResp := dto.AuthResponse{Token: "token", EncrSalt: "encryption salt"}
+	respBody, err := json.Marshal(authResp)
+	require.Nil(t, err, "Auth response json encoding")
+
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Generate server key")
+	serverPEM, err := crypto.EncodePublicKey(&serverKey.PublicKey)
+	require.Nil(t, err, "Encode server key")
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Generate other key")
+	otherPEM, err := crypto.EncodePublicKey(&otherKey.PublicKey)
+	require.Nil(t, err, "Encode other key")
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.Nil(t, err, "Generate weak key")
+	weakPEM, err := crypto.EncodePublicKey(&weakKey.PublicKey)
+	require.Nil(t, err, "Encode weak key")
+
+	type want struct {
+		keyRequests int
+		login       bool
+		pinned      []byte
+		err         error
+	}
+
+	tests := []struct {
+		name      string
+		pinned    []byte
+		serverPEM []byte
+		want      want
+	}{
+		{
+			name:      "pin_on_first_use",
+			serverPEM: serverPEM,
+			want:      want{keyRequests: 1, login: true, pinned: serverPEM},
+		},
+		{
+			name:      "pinned",
+			pinned:    serverPEM,
+			serverPEM: otherPEM,
+			want:      want{login: true, pinned: serverPEM},
+		},
+		{
+			name:      "pinned_key_mismatch",
+			pinned:    otherPEM,
+			serverPEM: serverPEM,
+			want:      want{login: true, pinned: otherPEM, err: ErrLoginFailed},
+		},
+		{
+			name: "not_supported",
+			want: want{keyRequests: 1, err: ErrServerKeyFailed},
+		},
+		{
+			name:      "weak_key",
+			serverPEM: weakPEM,
+			want:      want{keyRequests: 1, err: ErrServerKeyFailed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				keyRequests int
+				login       bool
+			)
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if r.RequestURI == PublicKeyPath {
+					keyRequests++
+					if test.serverPEM == nil {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Type", ContentType)
+					err := json.NewEncoder(w).Encode(dto.PublicKeyResponse{Key: string(test.serverPEM)})
+					require.Nil(t, err, "Write public key")
+					return
+				}
+
+				login = true
+				assert.Equal(t, LoginPath, r.RequestURI, "Request URI")
+				assert.Equal(t, dto.EncryptedContentType, r.Header.Get("Content-Type"), "Content type")
+
+				data, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				body, err := crypto.DecryptWithPrivateKey(data, serverKey)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				assert.Equal(t, reqBody, body, "Decrypted request body")
+
+				w.Header().Set("Content-Type", ContentType)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			storage := testServerKeyStorage{}
+			if test.pinned != nil {
+				storage[server.URL] = test.pinned
+			}
+
+			client := NewClient(server.URL, true)
+			client.SetPayloadEncryption(storage)
+
+			resp, err := client.Login(credentials)
+			assert.ErrorIs(t, err, test.want.err, "Login error")
+			assert.Equal(t, test.want.keyRequests, keyRequests, "Public key requests")
+			assert.Equal(t, test.want.login, login, "Login request")
+			assert.Equal(t, test.want.pinned, storage[server.URL], "Pinned key")
 			if err == nil {
-				assert.Equal(t, test.want.list, list, "Secret info list")
+				assert.Equal(t, authResp, resp, "Auth response")
+			}
+		})
+	}
+}
+
+func TestClient_PayloadEncryptionSecrets(t *testing.T) {
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Generate server key")
+	serverPEM, err := crypto.EncodePublicKey(&serverKey.PublicKey)
+	require.Nil(t, err, "Encode server key")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		send   func(c *Client) error
+	}{
+		{
+			name:   "change_password",
+			method: http.MethodPost,
+			path:   PasswordPath,
+			body:   dto.PasswordChangeRequest{Password: "password13", NewAuthKey: "bmV3S2V5"},
+			send: func(c *Client) error {
+				return c.ChangePassword(dto.PasswordChangeRequest{Password: "password13", NewAuthKey: "bmV3S2V5"}, "token")
+			},
+		},
+		{
+			name:   "setup_recovery",
+			method: http.MethodPut,
+			path:   RecoveryPath,
+			body:   dto.RecoverySetupRequest{Password: "password13"},
+			send: func(c *Client) error {
+				return c.SetupRecovery(dto.RecoverySetupRequest{Password: "password13"}, "token")
+			},
+		},
+		{
+			name:   "recovery_start",
+			method: http.MethodPost,
+			path:   RecoveryPath + "/start",
+			body:   dto.RecoveryStartRequest{Login: "test", AuthKey: "cmVjb3Zlcnk"},
+			send: func(c *Client) error {
+				_, err := c.RecoveryStart(dto.RecoveryStartRequest{Login: "test", AuthKey: "cmVjb3Zlcnk"})
+				return err
+			},
+		},
+		{
+			name:   "recovery_complete",
+			method: http.MethodPost,
+			path:   RecoveryPath + "/complete",
+			body:   dto.RecoveryRequest{Login: "test", AuthKey: "cmVjb3Zlcnk"},
+			send: func(c *Client) error {
+				_, err := c.RecoveryComplete(dto.RecoveryRequest{Login: "test", AuthKey: "cmVjb3Zlcnk"})
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(test.body)
+			require.Nil(t, err, "Request json encoding")
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.path, r.RequestURI, "Request URI")
+				assert.Equal(t, test.method, r.Method, "Request Method")
+				assert.Equal(t, dto.EncryptedContentType, r.Header.Get("Content-Type"), "Content type")
+
+				data, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				body, err := crypto.DecryptWithPrivateKey(data, serverKey)
+				require.Nil(t, err, "Decrypt request body")
+				assert.Equal(t, reqBody, body, "Decrypted request body")
+
+				w.Header().Set("Content-Type", ContentType)
+				_, err = w.Write([]byte("{}"))
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			client.SetPayloadEncryption(testServerKeyStorage{server.URL: serverPEM})
+
+			assert.Nil(t, test.send(client), "Request error")
+		})
+	}
+}
+
+type testServerKeyStorage map[string][]byte
+
+func (s testServerKeyStorage) ServerKey(addr string) ([]byte, error) {
+	return s[addr], nil
+}
+
+func (s testServerKeyStorage) PutServerKey(addr string, key []byte) error {
+	s[addr] = key
+	return nil
+}
+
+func TestClient_Upload(t *testing.T) {
+	secretRequest := dto.SecretRequest{}
+	reqBody, err := json.Marshal(secretRequest)
+	require.Nil(t, err, "Secret request json encoding")
+
+	secretResp := dto.SecretResponse{}
+	respBody, err := json.Marshal(secretResp)
+	require.Nil(t, err, "Auth response json encoding")
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		repBody  []byte
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			repBody:  respBody,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrSecretSendFailed,
+		},
+		{
+			name:     "secret_upload_error",
+			respCode: http.StatusBadRequest,
+			wantErr:  ErrSecretInvalid,
+		},
+		{
+			name:     "quota_exceeded",
+			respCode: http.StatusForbidden,
+			wantErr:  ErrQuotaExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read response body")
+				assert.Equal(t, reqBody, body, "Request body")
+
+				w.WriteHeader(test.respCode)
+
+				if test.respCode != http.StatusOK {
+					_, err = w.Write([]byte("error description"))
+					require.Nil(t, err, "Write response body")
+				}
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.Upload(secretRequest, "token")
+			assert.ErrorIs(t, err, test.wantErr, "Upload error")
+
+		})
+	}
+}
+
+func TestClient_Retrieve(t *testing.T) {
+	secretResp := dto.SecretResponse{}
+	respBody, err := json.Marshal(secretResp)
+	require.Nil(t, err, "Secret response json encoding")
+
+	type want struct {
+		secretResp dto.SecretResponse
+		err        error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		respBody []byte
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			respBody: respBody,
+			want: want{
+				secretResp: secretResp,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrSecretRetrieveFailed,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrSecretRetrieveFailed,
+			},
+		},
+		{
+			name:     "bad_request",
+			respCode: http.StatusBadRequest,
+			want: want{
+				err: ErrSecretRetrieveFailed,
+			},
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			want: want{
+				err: ErrSecretRetrieveFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrSecretRetrieveFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath+"/13", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			resp, err := client.Retrieve(13, "token")
+			assert.ErrorIs(t, err, test.want.err, "Retrieve error")
+			if err == nil {
+				assert.Equal(t, test.want.secretResp, resp, "Secret response")
+			}
+		})
+	}
+}
+
+func TestClient_Update(t *testing.T) {
+	secret := dto.SecretUpdateRequest{Name: "name", Meta: []dto.MetaData{}}
+	reqBody, err := json.Marshal(secret)
+	require.Nil(t, err, "Secret json encoding")
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrSecretUpdateFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrSecretUpdateFailed,
+		},
+		{
+			name:     "bad_request",
+			respCode: http.StatusBadRequest,
+			wantErr:  ErrSecretInvalid,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSecretUpdateFailed,
+		},
+		{
+			name:     "conflict",
+			respCode: http.StatusConflict,
+			wantErr:  ErrSecretConflict,
+		},
+		{
+			name:     "quota_exceeded",
+			respCode: http.StatusForbidden,
+			wantErr:  ErrQuotaExceeded,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrSecretUpdateFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath+"/13", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPut, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				assert.Equal(t, reqBody, body, "Request body")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.Update(13, secret, "token")
+			assert.ErrorIs(t, err, test.wantErr, "Update error")
+		})
+	}
+}
+
+func TestClient_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrSecretDeleteFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrSecretDeleteFailed,
+		},
+		{
+			name:     "bad_request",
+			respCode: http.StatusBadRequest,
+			wantErr:  ErrSecretDeleteFailed,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSecretDeleteFailed,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrSecretDeleteFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath+"/13", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodDelete, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.Delete(13, "token")
+			assert.ErrorIs(t, err, test.wantErr, "Delete error")
+		})
+	}
+}
+
+func TestClient_InfoList(t *testing.T) {
+	infoList := []dto.SecretInfo{{}}
+	respBody, err := json.Marshal(infoList)
+	require.Nil(t, err, "Auth response json encoding")
+
+	type want struct {
+		list []dto.SecretInfo
+		err  error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		respBody []byte
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			respBody: respBody,
+			want: want{
+				list: infoList,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrSecretInfoListFailed,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrSecretInfoListFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrSecretInfoListFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			list, err := client.InfoList("token")
+			assert.ErrorIs(t, err, test.want.err, "Retrieve error")
+			if err == nil {
+				assert.Equal(t, test.want.list, list, "Secret info list")
+			}
+		})
+	}
+}
+
+func TestClient_Usage(t *testing.T) {
+	usage := dto.Usage{Bytes: 2048, Secrets: 3, MaxBytes: 1 << 20, MaxSecrets: 100}
+	respBody, err := json.Marshal(usage)
+	require.Nil(t, err, "Usage json encoding")
+
+	type want struct {
+		usage dto.Usage
+		err   error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			want: want{
+				usage: usage,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrUsageFailed,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrUsageFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrUsageFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, UsagePath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.Usage("token")
+			assert.ErrorIs(t, err, test.want.err, "Usage error")
+			if err == nil {
+				assert.Equal(t, test.want.usage, got, "Usage")
+			}
+		})
+	}
+}
+
+func TestClient_Logout(t *testing.T) {
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrLogoutFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrLogoutFailed,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSessionNotFound,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrLogoutFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, LogoutPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.Logout("token")
+			assert.ErrorIs(t, err, test.wantErr, "Logout error")
+		})
+	}
+}
+
+func TestClient_Sessions(t *testing.T) {
+	sessions := []dto.Session{{ID: "session-id", Device: "laptop", IP: "192.0.2.1", Current: true}}
+	respBody, err := json.Marshal(sessions)
+	require.Nil(t, err, "Sessions json encoding")
+
+	type want struct {
+		sessions []dto.Session
+		err      error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			want: want{
+				sessions: sessions,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrSessionsFailed,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrSessionsFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrSessionsFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SessionPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.Sessions("token")
+			assert.ErrorIs(t, err, test.want.err, "Sessions error")
+			if err == nil {
+				assert.Equal(t, test.want.sessions, got, "Sessions")
+			}
+		})
+	}
+}
+
+func TestClient_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrSessionRevokeFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrSessionRevokeFailed,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSessionNotFound,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrSessionRevokeFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SessionPath+"/session-id", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodDelete, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.RevokeSession("session-id", "token")
+			assert.ErrorIs(t, err, test.wantErr, "RevokeSession error")
+		})
+	}
+}
+
+func TestClient_TwoFactorSetup(t *testing.T) {
+	setup := dto.TOTPSetup{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/GophKeeper:test?secret=JBSWY3DPEHPK3PXP"}
+	respBody, err := json.Marshal(setup)
+	require.Nil(t, err, "TOTP setup json encoding")
+
+	type want struct {
+		setup dto.TOTPSetup
+		err   error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			want: want{
+				setup: setup,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrTwoFactorFailed,
+			},
+		},
+		{
+			name:     "already_enabled",
+			respCode: http.StatusConflict,
+			want: want{
+				err: ErrTwoFactorFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrTwoFactorFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, TwoFactorPath+"/setup", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.TwoFactorSetup("token")
+			assert.ErrorIs(t, err, test.want.err, "TwoFactorSetup error")
+			if err == nil {
+				assert.Equal(t, test.want.setup, got, "TOTP setup")
+			}
+		})
+	}
+}
+
+func TestClient_TwoFactorEnable(t *testing.T) {
+	codes := dto.RecoveryCodes{Codes: []string{"abcde-fghij"}}
+	respBody, err := json.Marshal(codes)
+	require.Nil(t, err, "Recovery codes json encoding")
+
+	type want struct {
+		codes dto.RecoveryCodes
+		err   error
+	}
+
+	tests := []struct {
+		name     string
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			want: want{
+				codes: codes,
+			},
+		},
+		{
+			name:     "invalid_code",
+			respCode: http.StatusBadRequest,
+			want: want{
+				err: ErrInvalidOTP,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrTwoFactorFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, TwoFactorPath+"/enable", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				assert.JSONEq(t, `{"code":"123456"}`, string(body), "Request body")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			got, err := client.TwoFactorEnable("123456", "token")
+			assert.ErrorIs(t, err, test.want.err, "TwoFactorEnable error")
+			if err == nil {
+				assert.Equal(t, test.want.codes, got, "Recovery codes")
+			}
+		})
+	}
+}
+
+func TestClient_TwoFactorDisable(t *testing.T) {
+	tests := []struct {
+		name     string
+		respCode int
+		respBody string
+		wantErr  error
+		wantText string
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "invalid_code",
+			respCode: http.StatusBadRequest,
+			wantErr:  ErrInvalidOTP,
+		},
+		{
+			name:     "not_enabled",
+			respCode: http.StatusConflict,
+			respBody: "2fa not enabled\n",
+			wantErr:  ErrTwoFactorFailed,
+			wantText: "failed to configure 2fa: 2fa not enabled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, TwoFactorPath+"/disable", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+
+				w.WriteHeader(test.respCode)
+				_, err := w.Write([]byte(test.respBody))
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			err := client.TwoFactorDisable("123456", "token")
+			assert.ErrorIs(t, err, test.wantErr, "TwoFactorDisable error")
+			if test.wantText != "" {
+				assert.EqualError(t, err, test.wantText, "TwoFactorDisable error text")
+			}
+		})
+	}
+}
+
+func TestClient_Changes(t *testing.T) {
+	changes := dto.SecretChanges{Revision: 42, Changed: []dto.SecretInfo{{ID: 13}}, Deleted: []uint64{7}}
+	respBody, err := json.Marshal(changes)
+	require.Nil(t, err, "Secret changes json encoding")
+
+	type want struct {
+		changes dto.SecretChanges
+		err     error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			want: want{
+				changes: changes,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrSecretChangesFailed,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrSecretChangesFailed,
+			},
+		},
+		{
+			name:     "bad_request",
+			respCode: http.StatusBadRequest,
+			want: want{
+				err: ErrSecretChangesFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrSecretChangesFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath+"?since=40", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.Changes(40, "token")
+			assert.ErrorIs(t, err, test.want.err, "Changes error")
+			if err == nil {
+				assert.Equal(t, test.want.changes, got, "Secret changes")
+			}
+		})
+	}
+}
+
+func TestClient_CreateUpload(t *testing.T) {
+	upload := dto.UploadRequest{DataType: dto.SecretTypeFile, Name: "name", Meta: []dto.MetaData{}, Key: "key"}
+	reqBody, err := json.Marshal(upload)
+	require.Nil(t, err, "Upload request json encoding")
+	respBody, err := json.Marshal(dto.UploadResponse{ID: "upload-id"})
+	require.Nil(t, err, "Upload response json encoding")
+
+	type want struct {
+		upload dto.UploadResponse
+		err    error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusCreated,
+			want: want{
+				upload: dto.UploadResponse{ID: "upload-id"},
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrUploadFailed,
+			},
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			want: want{
+				err: ErrUploadFailed,
+			},
+		},
+		{
+			name:     "bad_request",
+			respCode: http.StatusBadRequest,
+			want: want{
+				err: ErrUploadFailed,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrUploadFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, UploadPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				assert.Equal(t, reqBody, body, "Request body")
+
+				if test.respCode != http.StatusCreated {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.CreateUpload(upload, "token")
+			assert.ErrorIs(t, err, test.want.err, "Create upload error")
+			if err == nil {
+				assert.Equal(t, test.want.upload, got, "Upload response")
+			}
+		})
+	}
+}
+
+func TestClient_UploadChunk(t *testing.T) {
+	data := []byte("encrypted chunk")
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrUploadFailed,
+		},
+		{
+			name:     "too_large",
+			respCode: http.StatusRequestEntityTooLarge,
+			wantErr:  ErrUploadFailed,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSecretNotFound,
+		},
+		{
+			name:     "quota_exceeded",
+			respCode: http.StatusForbidden,
+			wantErr:  ErrQuotaExceeded,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrUploadFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, UploadPath+"/upload-id/chunk/2", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPut, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+				assert.Equal(t, ChunkContentType, r.Header.Get("Content-Type"), "Content type")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				assert.Equal(t, data, body, "Request body")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.UploadChunk("upload-id", 2, data, "token")
+			assert.ErrorIs(t, err, test.wantErr, "Upload chunk error")
+		})
+	}
+}
+
+func TestClient_UploadStatus(t *testing.T) {
+	status := dto.UploadStatus{ID: "upload-id", Received: 5}
+	respBody, err := json.Marshal(status)
+	require.Nil(t, err, "Upload status json encoding")
+
+	type want struct {
+		status dto.UploadStatus
+		err    error
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		want     want
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+			want: want{
+				status: status,
+			},
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			want: want{
+				err: ErrUploadFailed,
+			},
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			want: want{
+				err: ErrSecretNotFound,
+			},
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			want: want{
+				err: ErrUploadFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, UploadPath+"/upload-id", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.UploadStatus("upload-id", "token")
+			assert.ErrorIs(t, err, test.want.err, "Upload status error")
+			if err == nil {
+				assert.Equal(t, test.want.status, got, "Upload status")
+			}
+		})
+	}
+}
+
+func TestClient_CommitUpload(t *testing.T) {
+	commit := dto.UploadCommitRequest{Chunks: 3}
+	reqBody, err := json.Marshal(commit)
+	require.Nil(t, err, "Commit request json encoding")
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrUploadFailed,
+		},
+		{
+			name:     "incomplete",
+			respCode: http.StatusBadRequest,
+			wantErr:  ErrUploadFailed,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSecretNotFound,
+		},
+		{
+			name:     "conflict",
+			respCode: http.StatusConflict,
+			wantErr:  ErrSecretConflict,
+		},
+		{
+			name:     "quota_exceeded",
+			respCode: http.StatusForbidden,
+			wantErr:  ErrQuotaExceeded,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrUploadFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, UploadPath+"/upload-id/commit", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				body, err := io.ReadAll(r.Body)
+				require.Nil(t, err, "Read request body")
+				assert.Equal(t, reqBody, body, "Request body")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.CommitUpload("upload-id", commit, "token")
+			assert.ErrorIs(t, err, test.wantErr, "Commit upload error")
+		})
+	}
+}
+
+func TestClient_RetrieveChunk(t *testing.T) {
+	data := []byte("encrypted chunk")
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrChunkRetrieveFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrChunkRetrieveFailed,
+		},
+		{
+			name:     "not_found",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrSecretNotFound,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrChunkRetrieveFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath+"/13/chunk/2", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ChunkContentType)
+				w.WriteHeader(test.respCode)
+				_, err := w.Write(data)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.RetrieveChunk(13, 2, "token")
+			assert.ErrorIs(t, err, test.wantErr, "Retrieve chunk error")
+			if err == nil {
+				assert.Equal(t, data, got, "Chunk data")
+			}
+		})
+	}
+}
+
+func TestClient_SecretKeys(t *testing.T) {
+	keys := []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}, {ID: 3, Key: "a2V5Mw"}}
+	respBody, err := json.Marshal(keys)
+	require.Nil(t, err, "Secret keys json encoding")
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrSecretKeysFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrSecretKeysFailed,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrSecretKeysFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, SecretPath+"/keys", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+
+				w.Header().Set("Content-Type", ContentType)
+				w.WriteHeader(test.respCode)
+				_, err = w.Write(respBody)
+				require.Nil(t, err, "Write response body")
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.SecretKeys("token")
+			assert.ErrorIs(t, err, test.wantErr, "SecretKeys error")
+			if err == nil {
+				assert.Equal(t, keys, got, "Secret keys")
+			}
+		})
+	}
+}
+
+func TestClient_PreLogin(t *testing.T) {
+	params := dto.PreLoginResponse{
+		EncrSalt:    "c2FsdA",
+		KDF:         &dto.KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4},
+		AuthVersion: dto.AuthVersionKey,
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrLoginFailed,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrLoginFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, PreLoginPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+
+				var got dto.PreLoginRequest
+				err := json.NewDecoder(r.Body).Decode(&got)
+				require.Nil(t, err, "Decode request body")
+				assert.Equal(t, dto.PreLoginRequest{Login: "user"}, got, "Request body")
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.respCode)
+				if test.respCode == http.StatusOK {
+					require.Nil(t, json.NewEncoder(w).Encode(params), "Encode response")
+				}
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.PreLogin(dto.PreLoginRequest{Login: "user"})
+			assert.ErrorIs(t, err, test.wantErr, "PreLogin error")
+			if test.wantErr == nil {
+				assert.Equal(t, params, got, "PreLogin response")
+			}
+		})
+	}
+}
+
+func TestClient_PasswordParams(t *testing.T) {
+	params := dto.PreLoginResponse{EncrSalt: "c2FsdA", AuthVersion: dto.AuthVersionPassword}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrPasswordChangeFailed,
+		},
+		{
+			name:     "unauthorized",
+			respCode: http.StatusUnauthorized,
+			wantErr:  ErrPasswordChangeFailed,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrPasswordChangeFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, PasswordPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.respCode)
+				if test.respCode == http.StatusOK {
+					require.Nil(t, json.NewEncoder(w).Encode(params), "Encode response")
+				}
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.PasswordParams("token")
+			assert.ErrorIs(t, err, test.wantErr, "PasswordParams error")
+			if test.wantErr == nil {
+				assert.Equal(t, params, got, "Password params")
+			}
+		})
+	}
+}
+
+func TestClient_ChangePassword(t *testing.T) {
+	request := dto.PasswordChangeRequest{
+		AuthKey:    "b2xkS2V5",
+		NewAuthKey: "bmV3S2V5",
+		EncrSalt:   "c2FsdA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}},
+	}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrPasswordChangeFailed,
+		},
+		{
+			name:     "invalid_password",
+			respCode: http.StatusForbidden,
+			wantErr:  ErrInvalidPassword,
+		},
+		{
+			name:     "keys_mismatch",
+			respCode: http.StatusConflict,
+			wantErr:  ErrKeysMismatch,
+		},
+		{
+			name:     "bad_request",
+			respCode: http.StatusBadRequest,
+			wantErr:  ErrPasswordChangeFailed,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrPasswordChangeFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, PasswordPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				var got dto.PasswordChangeRequest
+				err := json.NewDecoder(r.Body).Decode(&got)
+				require.Nil(t, err, "Decode request body")
+				assert.Equal(t, request, got, "Request body")
+
+				w.WriteHeader(test.respCode)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			err := client.ChangePassword(request, "token")
+			assert.ErrorIs(t, err, test.wantErr, "ChangePassword error")
+		})
+	}
+}
+
+func TestClient_SetAccountKey(t *testing.T) {
+	request := dto.AccountKeyRequest{
+		AccountKey: "YWNjb3VudA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ", Version: dto.KeyVersionAccount}},
+	}
+
+	tests := []struct {
+		name     string
+		respCode int
+		respBody string
+		wantErr  string
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusNoContent,
+		},
+		{
+			name:     "already_set",
+			respCode: http.StatusConflict,
+			respBody: "account key already set",
+			wantErr:  "failed to set up account key: account key already set",
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  "failed to set up account key: internal server error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, AccountKeyPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPut, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				var got dto.AccountKeyRequest
+				err := json.NewDecoder(r.Body).Decode(&got)
+				require.Nil(t, err, "Decode request body")
+				assert.Equal(t, request, got, "Request body")
+
+				w.WriteHeader(test.respCode)
+				w.Write([]byte(test.respBody))
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			var gotErr string
+			err := NewClient(server.URL, true).SetAccountKey(request, "token")
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "SetAccountKey error")
+		})
+	}
+}
+
+func TestClient_Recovery(t *testing.T) {
+	key := dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"}
+
+	tests := []struct {
+		name     string
+		respCode int
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "not_set_up",
+			respCode: http.StatusNotFound,
+			wantErr:  ErrRecoveryNotSetUp,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrRecoveryFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, RecoveryPath, r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodGet, r.Method, "Request Method")
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"), "Authorization header")
+
+				if test.respCode != http.StatusOK {
+					w.WriteHeader(test.respCode)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(key)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			got, err := client.Recovery("token")
+			assert.ErrorIs(t, err, test.wantErr, "Recovery error")
+			if test.wantErr == nil {
+				assert.Equal(t, key, got, "Recovery key")
+			}
+		})
+	}
+}
+
+func TestClient_RecoveryComplete(t *testing.T) {
+	request := dto.RecoveryRequest{
+		Login:      "user",
+		AuthKey:    "YXV0aA",
+		NewAuthKey: "bmV3S2V5",
+		EncrSalt:   "c2FsdA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}},
+		Recovery:   &dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"},
+	}
+	authResp := dto.AuthResponse{Token: "token", RefreshToken: "refresh", EncrSalt: "c2FsdA"}
+
+	tests := []struct {
+		name     string
+		netError bool
+		respCode int
+		respBody string
+		wantErr  error
+	}{
+		{
+			name:     "succes",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "network_error",
+			netError: true,
+			wantErr:  ErrRecoveryFailed,
+		},
+		{
+			name:     "invalid_recovery_key",
+			respCode: http.StatusForbidden,
+			wantErr:  ErrInvalidRecoveryKey,
+		},
+		{
+			name:     "otp_required",
+			respCode: http.StatusUnauthorized,
+			respBody: "2fa required",
+			wantErr:  ErrOTPRequired,
+		},
+		{
+			name:     "keys_mismatch",
+			respCode: http.StatusConflict,
+			wantErr:  ErrKeysMismatch,
+		},
+		{
+			name:     "internal_server_error",
+			respCode: http.StatusInternalServerError,
+			wantErr:  ErrRecoveryFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, RecoveryPath+"/complete", r.RequestURI, "Request URI")
+				assert.Equal(t, http.MethodPost, r.Method, "Request Method")
+
+				var got dto.RecoveryRequest
+				err := json.NewDecoder(r.Body).Decode(&got)
+				require.Nil(t, err, "Decode request body")
+				assert.Equal(t, request, got, "Request body")
+
+				if test.respCode != http.StatusOK {
+					http.Error(w, test.respBody, test.respCode)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(authResp)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			client := NewClient(server.URL, true)
+			if test.netError {
+				server.Close()
+			}
+
+			got, err := client.RecoveryComplete(request)
+			assert.ErrorIs(t, err, test.wantErr, "RecoveryComplete error")
+			if test.wantErr == nil {
+				assert.Equal(t, authResp, got, "Auth response")
 			}
 		})
 	}
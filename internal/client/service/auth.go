@@ -4,6 +4,7 @@ package service
 import (
 	"encoding/base64"
 	"fmt"
+	"os"
 
 	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
@@ -13,48 +14,198 @@ import (
 type Auth struct {
 	client  Client
 	storage Storage
+	// имя устройства, под которым сервер покажет сессию
+	device string
 }
 
 func NewAuth(c Client, s Storage) *Auth {
-	return &Auth{client: c, storage: s}
+	// без имени устройства сессия все равно создается, так что ошибку игнорируем
+	device, _ := os.Hostname()
+	return &Auth{client: c, storage: s, device: device}
 }
 
-// Register регистрирует пользователя в системе
+// Register регистрирует пользователя в системе. Мастер ключ вычисляется из пароля
+// со случайной солью и параметрами по умолчанию, на сервер вместо пароля
+// отправляется выведенный из мастер ключа ключ аутентификации.
 func (a *Auth) Register(cr dto.Credentials) error {
-	resp, err := a.client.Register(cr)
+	salt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	params := crypto.DefaultKDFParams()
+	masterKey, authKey, err := deriveAuthKey(cr.Password, salt, params)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.Register(dto.Credentials{
+		Login:    cr.Login,
+		AuthKey:  authKey,
+		EncrSalt: base64.RawStdEncoding.EncodeToString(salt),
+		KDF:      kdfRequest(params),
+		Device:   a.device,
+		Invite:   cr.Invite,
+	})
 	if err != nil {
 		return err
 	}
 
-	return a.storeAuthData(cr, resp)
+	return a.storeKeys(masterKey, params, resp)
 }
 
-// Login осуществляет вход пользователя в систему
+// Login осуществляет вход пользователя в систему. Соль и параметры мастер ключа
+// запрашиваются у сервера до входа, на сервер отправляется ключ аутентификации.
+// Для аккаунта, еще не переведенного на ключ аутентификации, отправляется и пароль,
+// сервер проверяет его и переводит аккаунт на ключ аутентификации.
 func (a *Auth) Login(cr dto.Credentials) error {
+	pre, err := a.client.PreLogin(dto.PreLoginRequest{Login: cr.Login})
+	if err != nil {
+		return err
+	}
+	masterKey, params, authKey, err := deriveKeys(cr.Password, pre)
+	if err != nil {
+		return err
+	}
+
+	cr.AuthKey = authKey
+	if pre.AuthVersion != dto.AuthVersionPassword {
+		cr.Password = ""
+	}
+	cr.Device = a.device
 	resp, err := a.client.Login(cr)
 	if err != nil {
 		return err
 	}
 
-	return a.storeAuthData(cr, resp)
+	return a.storeKeys(masterKey, params, resp)
+}
+
+// Logout завершает текущую сессию на сервере и удаляет токены и ключ из локального хранилища.
+// Локальные данные удаляются, даже если сервер недоступен.
+func (a *Auth) Logout() error {
+	var logoutErr error
+	token, err := a.storage.Token()
+	if err == nil {
+		logoutErr = a.client.Logout(token)
+	}
+
+	err = a.storage.Wipe()
+	if err != nil {
+		return fmt.Errorf("failed to wipe local data: %w", err)
+	}
+
+	return logoutErr
+}
+
+// Sessions получает с сервера список действующих сессий пользователя.
+func (a *Auth) Sessions() ([]dto.Session, error) {
+	token, err := a.storage.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	return a.client.Sessions(token)
+}
+
+// RevokeSession завершает на сервере сессию пользователя по id.
+func (a *Auth) RevokeSession(id string) error {
+	token, err := a.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	return a.client.RevokeSession(id, token)
+}
+
+// TwoFactorSetup получает с сервера новый TOTP секрет для приложения аутентификатора.
+func (a *Auth) TwoFactorSetup() (dto.TOTPSetup, error) {
+	token, err := a.storage.Token()
+	if err != nil {
+		return dto.TOTPSetup{}, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	return a.client.TwoFactorSetup(token)
+}
+
+// TwoFactorEnable включает двухфакторную аутентификацию по коду из приложения,
+// возвращает коды восстановления.
+func (a *Auth) TwoFactorEnable(code string) ([]string, error) {
+	token, err := a.storage.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	resp, err := a.client.TwoFactorEnable(code, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Codes, nil
 }
 
-// storeAuthData вычисляет мастел ключ
-// и сохвраняет его вместе с токеном в локальное хранилище.
-func (a *Auth) storeAuthData(cr dto.Credentials, resp dto.AuthResponse) error {
-	salt, err := base64.RawStdEncoding.DecodeString(resp.EncrSalt)
+// TwoFactorDisable выключает двухфакторную аутентификацию по коду из приложения или коду восстановления.
+func (a *Auth) TwoFactorDisable(code string) error {
+	token, err := a.storage.Token()
 	if err != nil {
-		return fmt.Errorf("the server returned invalid data")
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
 	}
 
-	// Вычисляем  мастер ключ для шифрования данных
-	masterKey, err := crypto.DeriveKey([]byte(cr.Password), salt)
+	return a.client.TwoFactorDisable(code, token)
+}
+
+// deriveKeys вычисляет мастер ключ из пароля с солью и параметрами из ответа сервера
+// и выведенный из него ключ аутентификации, закодированный base64.
+func deriveKeys(password string, pre dto.PreLoginResponse) ([]byte, crypto.KDFParams, string, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(pre.EncrSalt)
+	if err != nil {
+		return nil, crypto.KDFParams{}, "", fmt.Errorf("the server returned invalid data")
+	}
+	params, err := kdfParams(pre.KDF)
+	if err != nil {
+		return nil, params, "", fmt.Errorf("the server returned invalid data: %w", err)
+	}
+
+	masterKey, authKey, err := deriveAuthKey(password, salt, params)
+	return masterKey, params, authKey, err
+}
+
+// deriveAuthKey вычисляет мастер ключ из пароля и выведенный из него ключ аутентификации.
+func deriveAuthKey(password string, salt []byte, params crypto.KDFParams) ([]byte, string, error) {
+	masterKey, err := crypto.DeriveKeyWithParams([]byte(password), salt, params)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to derive key")
+	}
+	authKey, err := crypto.DeriveAuthKey(masterKey)
 	if err != nil {
-		return fmt.Errorf("failed to derive key")
+		return nil, "", fmt.Errorf("failed to derive auth key: %w", err)
 	}
 
+	return masterKey, base64.RawStdEncoding.EncodeToString(authKey), nil
+}
+
+// kdfParams возвращает параметры вычисления мастер ключа из ответа сервера,
+// для аккаунтов без сохраненных параметров - параметры по умолчанию.
+func kdfParams(p *dto.KDFParams) (crypto.KDFParams, error) {
+	if p == nil {
+		return crypto.DefaultKDFParams(), nil
+	}
+
+	params := crypto.KDFParams(*p)
+	return params, params.Validate()
+}
+
+// kdfRequest преобразует параметры вычисления мастер ключа для отправки на сервер.
+func kdfRequest(params crypto.KDFParams) *dto.KDFParams {
+	p := dto.KDFParams(params)
+	return &p
+}
+
+// storeKeys сохраняет мастер ключ, ключ аккаунта и токены в локальное хранилище.
+// Если аккаунт еще не переведен на ключ аккаунта, переводит его,
+// params - параметры, с которыми вычислен мастер ключ.
+func (a *Auth) storeKeys(masterKey []byte, params crypto.KDFParams, resp dto.AuthResponse) error {
 	// сохраняем ключ в хранилище
-	err = a.storage.PutKey(masterKey)
+	err := a.storage.PutKey(masterKey)
 	if err != nil {
 		return fmt.Errorf("failed to store key")
 	}
@@ -65,5 +216,76 @@ func (a *Auth) storeAuthData(cr dto.Credentials, resp dto.AuthResponse) error {
 		return fmt.Errorf("failed to store token")
 	}
 
+	err = a.storage.PutRefreshToken(resp.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token")
+	}
+
+	var accountKey []byte
+	if resp.AccountKey != "" {
+		accountKey, err = decryptKey(masterKey, resp.AccountKey)
+		if err != nil {
+			return fmt.Errorf("%w: account key: %w", ErrSecretDecryptionFailed, err)
+		}
+	} else {
+		accountKey, err = a.migrateAccountKey(masterKey, params, resp.Token)
+		if err != nil {
+			return fmt.Errorf("failed to set up account key: %w", err)
+		}
+	}
+
+	err = a.storage.PutAccountKey(accountKey)
+	if err != nil {
+		return fmt.Errorf("failed to store account key")
+	}
+
 	return nil
 }
+
+// migrateAccountKey переводит аккаунт на ключ аккаунта: генерирует ключ аккаунта,
+// перешифровывает им ключи данных всех секретов и ключ восстановления
+// и сохраняет на сервере одним запросом вместе с ключом аккаунта, зашифрованным мастер ключом.
+// Локальная копия зашифрована мастер ключом и не меняется, ее ключи данных
+// расшифровываются по версии схемы ключей.
+func (a *Auth) migrateAccountKey(masterKey []byte, params crypto.KDFParams, token string) ([]byte, error) {
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate account key: %w", err)
+	}
+	encryptedAccountKey, err := crypto.EncryptAESWithKDF(masterKey, accountKey, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt account key: %w", err)
+	}
+
+	keys, err := a.client.SecretKeys(token)
+	if err != nil {
+		return nil, err
+	}
+	rewrap := keyRewrapper(masterKey, accountKey)
+	for i := range keys {
+		if keys[i].Key, err = rewrap(keys[i].Key); err != nil {
+			return nil, fmt.Errorf("%w: secret %d: %w", ErrSecretDecryptionFailed, keys[i].ID, err)
+		}
+		keys[i].Version = dto.KeyVersionAccount
+	}
+
+	// После перевода ключ восстановления открывает ключ аккаунта
+	recovery, err := rewrapRecovery(a.client, token, masterKey, accountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.client.SetAccountKey(
+		dto.AccountKeyRequest{
+			AccountKey: base64.RawStdEncoding.EncodeToString(encryptedAccountKey),
+			Keys:       keys,
+			Recovery:   recovery,
+		},
+		token,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return accountKey, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
 	"github.com/EshkinKot1980/GophKeeper/internal/client/service/mocks"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
@@ -17,13 +18,35 @@ import (
 func TestAuth_Register(t *testing.T) {
 	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
 		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
-	base64Salt := "EPhQ3C8pTRYDMac+aCzFTA"
-	salt, err := base64.RawStdEncoding.DecodeString(base64Salt)
-	require.Nil(t, err, "Decoding salt from base64")
-	masterKey, err := crypto.DeriveKey([]byte("password13"), salt)
-	require.Nil(t, err, "Generate masterKey from password")
+	// учетные данные, отправленные на сервер при регистрации
+	var sent dto.Credentials
+	// ключ аккаунта, отправленный на сервер при регистрации
+	var registered string
 
-	badBase64 := "EPhQ3C8pTRY&DMac+aCzFTA"
+	// register запоминает отправленные учетные данные и проверяет, что пароль не отправлен
+	register := func(resp dto.AuthResponse, err error) func(cr dto.Credentials) (dto.AuthResponse, error) {
+		return func(cr dto.Credentials) (dto.AuthResponse, error) {
+			assert.Equal(t, "test13", cr.Login, "Login")
+			assert.Empty(t, cr.Password, "Password must not be sent")
+			assert.Equal(t, "laptop", cr.Device, "Device")
+			sent = cr
+			return resp, err
+		}
+	}
+	// checkMasterKey проверяет, что мастер ключ вычислен из пароля с отправленными солью
+	// и параметрами, а отправленный ключ аутентификации выведен из него
+	checkMasterKey := func(key []byte) error {
+		salt, err := base64.RawStdEncoding.DecodeString(sent.EncrSalt)
+		require.Nil(t, err, "Decode salt")
+		assert.Equal(t, crypto.SaltLen, len(salt), "Salt length")
+		require.NotNil(t, sent.KDF, "KDF params")
+		assert.Equal(t, crypto.DefaultKDFParams(), crypto.KDFParams(*sent.KDF), "KDF params")
+		masterKey, err := crypto.DeriveKeyWithParams([]byte("password13"), salt, crypto.KDFParams(*sent.KDF))
+		require.Nil(t, err, "Generate masterKey from password")
+		assert.Equal(t, masterKey, key, "Master key")
+		assert.Equal(t, testAuthKey(t, masterKey), sent.AuthKey, "Auth key")
+		return nil
+	}
 
 	tests := []struct {
 		name    string
@@ -39,15 +62,37 @@ func TestAuth_Register(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					Register(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: base64Salt}, nil)
+					Register(gomock.Any()).
+					DoAndReturn(register(dto.AuthResponse{Token: token, RefreshToken: "refresh"}, nil))
+				// новый аккаунт сразу переводится на ключ аккаунта
+				client.EXPECT().SecretKeys(token).Return([]dto.SecretKey{}, nil)
+				client.EXPECT().Recovery(token).Return(dto.RecoveryKey{}, httpClient.ErrRecoveryNotSetUp)
+				client.EXPECT().
+					SetAccountKey(gomock.Any(), token).
+					DoAndReturn(func(data dto.AccountKeyRequest, _ string) error {
+						assert.Empty(t, data.Keys, "Secret keys")
+						assert.Nil(t, data.Recovery, "Recovery keys")
+						registered = data.AccountKey
+						return nil
+					})
 				return client
 			},
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
-				storage.EXPECT().PutKey(masterKey).Return(nil)
+				var masterKey []byte
+				storage.EXPECT().PutKey(gomock.Any()).DoAndReturn(func(key []byte) error {
+					masterKey = key
+					return checkMasterKey(key)
+				})
 				storage.EXPECT().PutToken(token).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				storage.EXPECT().PutAccountKey(gomock.Any()).DoAndReturn(func(key []byte) error {
+					stored, err := decryptKey(masterKey, registered)
+					require.Nil(t, err, "Decrypt account key with master key")
+					assert.Equal(t, stored, key, "Stored account key")
+					return nil
+				})
 				return storage
 			},
 		},
@@ -58,8 +103,8 @@ func TestAuth_Register(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					Register(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{}, fmt.Errorf("registration failed"))
+					Register(gomock.Any()).
+					DoAndReturn(register(dto.AuthResponse{}, fmt.Errorf("registration failed")))
 				return client
 			},
 			sSetup: func(t *testing.T) Storage {
@@ -69,79 +114,67 @@ func TestAuth_Register(t *testing.T) {
 			wantErr: "registration failed",
 		},
 		{
-			name: "invalid_salt",
-			cr:   dto.Credentials{Login: "test13", Password: "password13"},
-			cSetup: func(t *testing.T) Client {
-				ctrl := gomock.NewController(t)
-				client := mocks.NewMockClient(ctrl)
-				client.EXPECT().
-					Register(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: badBase64}, nil)
-				return client
-			},
-			sSetup: func(t *testing.T) Storage {
-				ctrl := gomock.NewController(t)
-				return mocks.NewMockStorage(ctrl)
-			},
-			wantErr: "the server returned invalid data",
-		},
-		{
-			name: "derive_key_error",
+			name: "store_key_error",
 			cr:   dto.Credentials{Login: "test13", Password: "password13"},
 			cSetup: func(t *testing.T) Client {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					Register(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: ""}, nil)
+					Register(gomock.Any()).
+					DoAndReturn(register(dto.AuthResponse{Token: token}, nil))
 				return client
 			},
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
-				return mocks.NewMockStorage(ctrl)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					PutKey(gomock.Any()).Return(fmt.Errorf("failed to put key"))
+				return storage
 			},
-			wantErr: "failed to derive key",
+			wantErr: "failed to store key",
 		},
 		{
-			name: "store_key_error",
+			name: "store_token_error",
 			cr:   dto.Credentials{Login: "test13", Password: "password13"},
 			cSetup: func(t *testing.T) Client {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					Register(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: base64Salt}, nil)
+					Register(gomock.Any()).
+					DoAndReturn(register(dto.AuthResponse{Token: token}, nil))
 				return client
 			},
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().PutKey(gomock.Any()).Return(nil)
 				storage.EXPECT().
-					PutKey(masterKey).Return(fmt.Errorf("failed to put key"))
+					PutToken(token).Return(fmt.Errorf("failed to put token"))
 				return storage
 			},
-			wantErr: "failed to store key",
+			wantErr: "failed to store token",
 		},
 		{
-			name: "store_token_error",
+			name: "store_refresh_token_error",
 			cr:   dto.Credentials{Login: "test13", Password: "password13"},
 			cSetup: func(t *testing.T) Client {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					Register(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: base64Salt}, nil)
+					Register(gomock.Any()).
+					DoAndReturn(register(dto.AuthResponse{Token: token, RefreshToken: "refresh"}, nil))
 				return client
 			},
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
-				storage.EXPECT().PutKey(masterKey).Return(nil)
+				storage.EXPECT().PutKey(gomock.Any()).Return(nil)
+				storage.EXPECT().PutToken(token).Return(nil)
 				storage.EXPECT().
-					PutToken(token).Return(fmt.Errorf("failed to put token"))
+					PutRefreshToken("refresh").Return(fmt.Errorf("failed to put refresh token"))
 				return storage
 			},
-			wantErr: "failed to store token",
+			wantErr: "failed to store refresh token",
 		},
 	}
 
@@ -151,6 +184,7 @@ func TestAuth_Register(t *testing.T) {
 			storage := test.sSetup(t)
 
 			authService := NewAuth(client, storage)
+			authService.device = "laptop"
 			err := authService.Register(test.cr)
 
 			var gotErr string
@@ -163,6 +197,14 @@ func TestAuth_Register(t *testing.T) {
 	}
 }
 
+// testAuthKey выводит из мастер ключа ключ аутентификации, закодированный base64.
+func testAuthKey(t *testing.T, masterKey []byte) string {
+	t.Helper()
+	key, err := crypto.DeriveAuthKey(masterKey)
+	require.Nil(t, err, "Derive auth key")
+	return base64.RawStdEncoding.EncodeToString(key)
+}
+
 func TestAuth_Login(t *testing.T) {
 	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
 		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
@@ -171,6 +213,23 @@ func TestAuth_Login(t *testing.T) {
 	require.Nil(t, err, "Decoding salt from base64")
 	masterKey, err := crypto.DeriveKey([]byte("password13"), salt)
 	require.Nil(t, err, "Generate masterKey from password")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
+	encryptedAccountKey, err := crypto.EncryptAES(masterKey, accountKey)
+	require.Nil(t, err, "Account key encryption")
+	legacy := testLegacyData(t, masterKey, []byte("legacy data"))
+	kdf := dto.KDFParams{Time: 1, Memory: crypto.ArgonMinMemory, Threads: 1}
+	kdfMasterKey, err := crypto.DeriveKeyWithParams([]byte("password13"), salt, crypto.KDFParams(kdf))
+	require.Nil(t, err, "Generate masterKey with KDF params")
+	kdfAccountKey, err := crypto.EncryptAESWithKDF(kdfMasterKey, accountKey, crypto.KDFParams(kdf))
+	require.Nil(t, err, "Account key encryption with KDF params")
+
+	authKey := testAuthKey(t, masterKey)
+	kdfAuthKey := testAuthKey(t, kdfMasterKey)
+	pre := dto.PreLoginResponse{EncrSalt: base64Salt, AuthVersion: dto.AuthVersionKey}
+
+	// ключ аккаунта, отправленный на сервер при переводе аккаунта
+	var migrated dto.AccountKeyRequest
 
 	tests := []struct {
 		name    string
@@ -185,9 +244,15 @@ func TestAuth_Login(t *testing.T) {
 			cSetup: func(t *testing.T) Client {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().PreLogin(dto.PreLoginRequest{Login: "test13"}).Return(pre, nil)
 				client.EXPECT().
-					Login(dto.Credentials{Login: "test13", Password: "password13"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: base64Salt}, nil)
+					Login(dto.Credentials{Login: "test13", AuthKey: authKey, Device: "laptop"}).
+					Return(dto.AuthResponse{
+						Token:        token,
+						RefreshToken: "refresh",
+						EncrSalt:     base64Salt,
+						AccountKey:   base64.RawStdEncoding.EncodeToString(encryptedAccountKey),
+					}, nil)
 				return client
 			},
 			sSetup: func(t *testing.T) Storage {
@@ -195,17 +260,143 @@ func TestAuth_Login(t *testing.T) {
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().PutKey(masterKey).Return(nil)
 				storage.EXPECT().PutToken(token).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				storage.EXPECT().PutAccountKey(accountKey).Return(nil)
 				return storage
 			},
 		},
+		{
+			name: "success_with_kdf_params",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					PreLogin(dto.PreLoginRequest{Login: "test13"}).
+					Return(dto.PreLoginResponse{EncrSalt: base64Salt, KDF: &kdf, AuthVersion: dto.AuthVersionKey}, nil)
+				client.EXPECT().
+					Login(dto.Credentials{Login: "test13", AuthKey: kdfAuthKey, Device: "laptop"}).
+					Return(dto.AuthResponse{
+						Token:        token,
+						RefreshToken: "refresh",
+						EncrSalt:     base64Salt,
+						KDF:          &kdf,
+						AccountKey:   base64.RawStdEncoding.EncodeToString(kdfAccountKey),
+					}, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().PutKey(kdfMasterKey).Return(nil)
+				storage.EXPECT().PutToken(token).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				storage.EXPECT().PutAccountKey(accountKey).Return(nil)
+				return storage
+			},
+		},
+		{
+			name: "invalid_kdf_params",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					PreLogin(dto.PreLoginRequest{Login: "test13"}).
+					Return(dto.PreLoginResponse{
+						EncrSalt: base64Salt,
+						KDF:      &dto.KDFParams{Time: 1, Memory: 1024, Threads: 1},
+					}, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockStorage(ctrl)
+			},
+			wantErr: "the server returned invalid data: kdf memory must be from 16384 to 1048576 KB",
+		},
+		{
+			name: "success_migration",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().PreLogin(dto.PreLoginRequest{Login: "test13"}).Return(pre, nil)
+				client.EXPECT().
+					Login(dto.Credentials{Login: "test13", AuthKey: authKey, Device: "laptop"}).
+					Return(dto.AuthResponse{Token: token, RefreshToken: "refresh", EncrSalt: base64Salt}, nil)
+				client.EXPECT().SecretKeys(token).Return([]dto.SecretKey{{ID: 1, Key: legacy.Key}}, nil)
+				client.EXPECT().Recovery(token).Return(dto.RecoveryKey{}, httpClient.ErrRecoveryNotSetUp)
+				client.EXPECT().
+					SetAccountKey(gomock.Any(), token).
+					DoAndReturn(func(data dto.AccountKeyRequest, _ string) error {
+						migrated = data
+						return nil
+					})
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().PutKey(masterKey).Return(nil)
+				storage.EXPECT().PutToken(token).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				storage.EXPECT().PutAccountKey(gomock.Any()).DoAndReturn(func(key []byte) error {
+					// ключи данных перешифрованы новым ключом аккаунта
+					stored, err := decryptKey(masterKey, migrated.AccountKey)
+					require.Nil(t, err, "Decrypt account key with master key")
+					// в заголовке записаны параметры вычисления мастер ключа
+					encrypted, err := base64.RawStdEncoding.DecodeString(migrated.AccountKey)
+					require.Nil(t, err, "Decode account key")
+					header, err := crypto.ParseHeader(encrypted)
+					require.Nil(t, err, "Parse account key header")
+					params := crypto.DefaultKDFParams()
+					assert.Equal(t, &params, header.KDF, "Account key KDF params")
+					assert.Equal(t, stored, key, "Stored account key")
+					require.Len(t, migrated.Keys, 1, "Migrated keys")
+					assert.Equal(t, dto.KeyVersionAccount, migrated.Keys[0].Version, "Migrated key version")
+					data, err := deryptData(keyring{account: key}, nil, false, &dto.EncryptedData{Key: migrated.Keys[0].Key, Data: legacy.Data, Version: dto.KeyVersionAccount})
+					require.Nil(t, err, "Decrypt data with account key")
+					assert.Equal(t, "legacy data", string(data), "Migrated data")
+					return nil
+				})
+				return storage
+			},
+		},
+		{
+			name: "migration_failed",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().PreLogin(dto.PreLoginRequest{Login: "test13"}).Return(pre, nil)
+				client.EXPECT().
+					Login(dto.Credentials{Login: "test13", AuthKey: authKey, Device: "laptop"}).
+					Return(dto.AuthResponse{Token: token, RefreshToken: "refresh", EncrSalt: base64Salt}, nil)
+				client.EXPECT().SecretKeys(token).Return([]dto.SecretKey{}, nil)
+				client.EXPECT().Recovery(token).Return(dto.RecoveryKey{}, httpClient.ErrRecoveryNotSetUp)
+				client.EXPECT().SetAccountKey(gomock.Any(), token).Return(httpClient.ErrAccountKeyFailed)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().PutKey(masterKey).Return(nil)
+				storage.EXPECT().PutToken(token).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				return storage
+			},
+			wantErr: "failed to set up account key: failed to set up account key",
+		},
 		{
 			name: "client_error",
 			cr:   dto.Credentials{Login: "test13", Password: "password13"},
 			cSetup: func(t *testing.T) Client {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().PreLogin(dto.PreLoginRequest{Login: "test13"}).Return(pre, nil)
 				client.EXPECT().
-					Login(dto.Credentials{Login: "test13", Password: "password13"}).
+					Login(dto.Credentials{Login: "test13", AuthKey: authKey, Device: "laptop"}).
 					Return(dto.AuthResponse{}, fmt.Errorf("login failed"))
 				return client
 			},
@@ -215,6 +406,87 @@ func TestAuth_Login(t *testing.T) {
 			},
 			wantErr: "login failed",
 		},
+		{
+			name: "success_legacy_password",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				// аккаунт не переведен на ключ аутентификации, пароль отправляется вместе с ключом
+				client.EXPECT().
+					PreLogin(dto.PreLoginRequest{Login: "test13"}).
+					Return(dto.PreLoginResponse{EncrSalt: base64Salt, AuthVersion: dto.AuthVersionPassword}, nil)
+				client.EXPECT().
+					Login(dto.Credentials{Login: "test13", Password: "password13", AuthKey: authKey, Device: "laptop"}).
+					Return(dto.AuthResponse{
+						Token:        token,
+						RefreshToken: "refresh",
+						EncrSalt:     base64Salt,
+						AccountKey:   base64.RawStdEncoding.EncodeToString(encryptedAccountKey),
+					}, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().PutKey(masterKey).Return(nil)
+				storage.EXPECT().PutToken(token).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				storage.EXPECT().PutAccountKey(accountKey).Return(nil)
+				return storage
+			},
+		},
+		{
+			name: "prelogin_error",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					PreLogin(dto.PreLoginRequest{Login: "test13"}).
+					Return(dto.PreLoginResponse{}, fmt.Errorf("login failed"))
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockStorage(ctrl)
+			},
+			wantErr: "login failed",
+		},
+		{
+			name: "invalid_salt",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					PreLogin(dto.PreLoginRequest{Login: "test13"}).
+					Return(dto.PreLoginResponse{EncrSalt: "EPhQ3C8pTRY&DMac+aCzFTA"}, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockStorage(ctrl)
+			},
+			wantErr: "the server returned invalid data",
+		},
+		{
+			name: "derive_key_error",
+			cr:   dto.Credentials{Login: "test13", Password: "password13"},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					PreLogin(dto.PreLoginRequest{Login: "test13"}).
+					Return(dto.PreLoginResponse{EncrSalt: ""}, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockStorage(ctrl)
+			},
+			wantErr: "failed to derive key",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -222,6 +494,7 @@ func TestAuth_Login(t *testing.T) {
 			storage := test.sSetup(t)
 
 			authService := NewAuth(client, storage)
+			authService.device = "laptop"
 			err := authService.Login(test.cr)
 
 			var gotErr string
@@ -229,7 +502,263 @@ func TestAuth_Login(t *testing.T) {
 				gotErr = err.Error()
 			}
 
-			assert.Equal(t, test.wantErr, gotErr, "Register error")
+			assert.Equal(t, test.wantErr, gotErr, "Login error")
+		})
+	}
+}
+
+func TestAuth_Logout(t *testing.T) {
+	tests := []struct {
+		name    string
+		cSetup  func(t *testing.T) Client
+		sSetup  func(t *testing.T) Storage
+		wantErr string
+	}{
+		{
+			name: "success",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().Logout("token").Return(nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				storage.EXPECT().Wipe().Return(nil)
+				return storage
+			},
+		},
+		{
+			name: "no_token",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("", fmt.Errorf("failed to get token"))
+				storage.EXPECT().Wipe().Return(nil)
+				return storage
+			},
+		},
+		{
+			name: "client_error",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().Logout("token").Return(fmt.Errorf("failed to logout"))
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				storage.EXPECT().Wipe().Return(nil)
+				return storage
+			},
+			wantErr: "failed to logout",
+		},
+		{
+			name: "wipe_error",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().Logout("token").Return(nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				storage.EXPECT().Wipe().Return(fmt.Errorf("permission denied"))
+				return storage
+			},
+			wantErr: "failed to wipe local data: permission denied",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService := NewAuth(test.cSetup(t), test.sSetup(t))
+			err := authService.Logout()
+
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Logout error")
+		})
+	}
+}
+
+func TestAuth_Sessions(t *testing.T) {
+	sessions := []dto.Session{{ID: "session-id", Device: "laptop", Current: true}}
+
+	tests := []struct {
+		name    string
+		cSetup  func(t *testing.T) Client
+		sSetup  func(t *testing.T) Storage
+		want    []dto.Session
+		wantErr error
+	}{
+		{
+			name: "success",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().Sessions("token").Return(sessions, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				return storage
+			},
+			want: sessions,
+		},
+		{
+			name: "no_token",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("", fmt.Errorf("failed to get token"))
+				return storage
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService := NewAuth(test.cSetup(t), test.sSetup(t))
+			got, err := authService.Sessions()
+
+			assert.ErrorIs(t, err, test.wantErr, "Sessions error")
+			assert.Equal(t, test.want, got, "Sessions")
+		})
+	}
+}
+
+func TestAuth_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		cSetup  func(t *testing.T) Client
+		sSetup  func(t *testing.T) Storage
+		wantErr error
+	}{
+		{
+			name: "success",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().RevokeSession("session-id", "token").Return(nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				return storage
+			},
+		},
+		{
+			name: "no_token",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("", fmt.Errorf("failed to get token"))
+				return storage
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService := NewAuth(test.cSetup(t), test.sSetup(t))
+			err := authService.RevokeSession("session-id")
+
+			assert.ErrorIs(t, err, test.wantErr, "RevokeSession error")
+		})
+	}
+}
+
+func TestAuth_TwoFactorEnable(t *testing.T) {
+	tests := []struct {
+		name    string
+		cSetup  func(t *testing.T) Client
+		sSetup  func(t *testing.T) Storage
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "success",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					TwoFactorEnable("123456", "token").
+					Return(dto.RecoveryCodes{Codes: []string{"abcde-fghij"}}, nil)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				return storage
+			},
+			want: []string{"abcde-fghij"},
+		},
+		{
+			name: "no_token",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("", fmt.Errorf("failed to get token"))
+				return storage
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+		{
+			name: "client_error",
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					TwoFactorEnable("123456", "token").
+					Return(dto.RecoveryCodes{}, ErrAuthorizationFailed)
+				return client
+			},
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().Token().Return("token", nil)
+				return storage
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService := NewAuth(test.cSetup(t), test.sSetup(t))
+			got, err := authService.TwoFactorEnable("123456")
+
+			assert.ErrorIs(t, err, test.wantErr, "TwoFactorEnable error")
+			assert.Equal(t, test.want, got, "Recovery codes")
 		})
 	}
 }
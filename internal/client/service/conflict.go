@@ -0,0 +1,197 @@
+// Пакет service содержит сервисный слой клиентской части приложения
+package service
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// Способы разрешения конфликта
+const (
+	// KeepLocal сохранить локальную версию секрета
+	KeepLocal = "local"
+	// KeepRemote сохранить версию секрета с сервера
+	KeepRemote = "remote"
+	// KeepBoth сохранить обе версии, локальная сохраняется как новый секрет
+	KeepBoth = "both"
+)
+
+// conflictCopySuffix добавляется к названию локальной версии, сохраненной как новый секрет
+const conflictCopySuffix = " (local copy)"
+
+var (
+	ErrConflictNotFound    = errors.New("conflict not found")
+	ErrConflictInvalidKeep = fmt.Errorf("keep must be one of: %s, %s, %s", KeepLocal, KeepRemote, KeepBoth)
+	// Локальная версия изменяет только название и метаданные секрета,
+	// создать из нее новый секрет нельзя
+	ErrConflictNoData = errors.New("local version does not contain secret data")
+)
+
+// conflict локальное изменение секрета, которое не удалось отправить,
+// потому что секрет изменили или удалили на другом клиенте.
+type conflict struct {
+	DataType string                  `json:"data_type"`
+	Local    dto.SecretUpdateRequest `json:"local"`
+}
+
+// Conflict информация о неразрешенном конфликте.
+type Conflict struct {
+	ID       uint64
+	DataType string
+	// Название секрета в локальной версии
+	LocalName string
+	// Название секрета на сервере
+	RemoteName string
+	// Секрет удален на другом клиенте
+	RemoteDeleted bool
+}
+
+// Conflicts возвращает неразрешенные конфликты, найденные при синхронизации.
+func (s *Secret) Conflicts() ([]Conflict, error) {
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Conflict, 0, len(v.Conflicts))
+	for id, c := range v.Conflicts {
+		remote, ok := v.Secrets[id]
+		list = append(list, Conflict{
+			ID:            id,
+			DataType:      c.DataType,
+			LocalName:     c.Local.Name,
+			RemoteName:    remote.Name,
+			RemoteDeleted: !ok,
+		})
+	}
+	slices.SortFunc(list, func(a, b Conflict) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return list, nil
+}
+
+// ResolveConflict разрешает конфликт секрета по id способом keep:
+// KeepLocal отправляет локальную версию на сервер поверх серверной,
+// KeepRemote отбрасывает локальную версию,
+// KeepBoth сохраняет локальную версию на сервере как новый секрет.
+func (s *Secret) ResolveConflict(id uint64, keep string) error {
+	if keep != KeepLocal && keep != KeepRemote && keep != KeepBoth {
+		return ErrConflictInvalidKeep
+	}
+
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := s.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return err
+	}
+
+	c, ok := v.Conflicts[id]
+	if !ok {
+		return ErrConflictNotFound
+	}
+
+	switch keep {
+	case KeepLocal:
+		err = s.keepLocal(id, c, token)
+	case KeepBoth:
+		if !c.hasData() {
+			return ErrConflictNoData
+		}
+		var secret dto.SecretRequest
+		if secret, err = c.copyRequest(keys); err == nil {
+			err = s.client.Upload(secret, token)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	delete(v.Conflicts, id)
+	return s.saveVault(keys, v)
+}
+
+// keepLocal отправляет локальную версию секрета на сервер поверх серверной.
+// Если секрет удален на другом клиенте, создает его заново.
+func (s *Secret) keepLocal(id uint64, c conflict, token string) error {
+	remote, err := s.client.Retrieve(id, token)
+	if errors.Is(err, httpClient.ErrSecretNotFound) {
+		if !c.hasData() {
+			return ErrConflictNoData
+		}
+		return s.client.Upload(c.secretRequest(c.Local.Name), token)
+	}
+	if err != nil {
+		return err
+	}
+
+	local := c.Local
+	local.Version = remote.Version
+	return s.client.Update(id, local, token)
+}
+
+// secretRequest создает запрос на сохранение локальной версии как нового секрета.
+// Секрет создается с UID локальной версии, к которому привязаны ее данные.
+func (c conflict) secretRequest(name string) dto.SecretRequest {
+	return dto.SecretRequest{
+		UID:      c.Local.UID,
+		DataType: c.DataType,
+		Name:     name,
+		Meta:     c.Local.Meta,
+		EncrData: c.Local.EncrData,
+	}
+}
+
+// copyRequest создает запрос на сохранение локальной версии как копии секрета.
+// Данные привязаны к UID и названию, поэтому перешифровываются для нового UID и названия копии.
+func (c conflict) copyRequest(keys keyring) (dto.SecretRequest, error) {
+	secret := c.secretRequest(copyName(c.Local.Name))
+
+	binding := secretBinding(c.Local.UID, c.DataType, c.Local.Name, c.Local.Meta)
+	data, err := deryptData(keys, binding, c.Local.UID != "", &c.Local.EncrData)
+	if err != nil {
+		return secret, fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+	}
+	if secret.UID, err = newSecretUID(); err != nil {
+		return secret, fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+	secret.EncrData, err = encryptData(keys, secretBinding(secret.UID, secret.DataType, secret.Name, secret.Meta), data)
+	if err != nil {
+		return secret, fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+
+	return secret, nil
+}
+
+// hasData проверяет, содержит ли локальная версия данные секрета.
+func (c conflict) hasData() bool {
+	return c.Local.EncrData.Key != ""
+}
+
+// copyName возвращает название для копии секрета, укладывающееся в dto.SecretNameMaxLen.
+func copyName(name string) string {
+	runes := []rune(name)
+	maxLen := dto.SecretNameMaxLen - len([]rune(conflictCopySuffix))
+	if len(runes) > maxLen {
+		runes = runes[:maxLen]
+	}
+	return string(runes) + conflictCopySuffix
+}
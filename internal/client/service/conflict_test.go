@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+func TestSecret_Conflicts(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+
+	storage, _ := testVaultStorage(t, masterKey, &vault{
+		Secrets: map[uint64]dto.SecretResponse{13: {ID: 13, Name: "remote"}},
+		Conflicts: map[uint64]conflict{
+			14: {DataType: dto.SecretTypeFile, Local: dto.SecretUpdateRequest{Name: "deleted"}},
+			13: {DataType: dto.SecretTypeText, Local: dto.SecretUpdateRequest{Name: "local"}},
+		},
+	})
+	client := mocks.NewMockClient(gomock.NewController(t))
+
+	list, err := NewSecret(client, storage).Conflicts()
+	require.Nil(t, err, "Conflicts error")
+	assert.Equal(t, []Conflict{
+		{ID: 13, DataType: dto.SecretTypeText, LocalName: "local", RemoteName: "remote"},
+		{ID: 14, DataType: dto.SecretTypeFile, LocalName: "deleted", RemoteDeleted: true},
+	}, list, "Conflict list")
+}
+
+func TestSecret_ResolveConflict(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+
+	keys := keyring{master: masterKey, account: testAccountKey}
+	meta := []dto.MetaData{{Name: "key", Value: "value"}}
+	encrData, err := encryptData(keys, secretBinding(testSecretUID, dto.SecretTypeText, "local", meta), []byte("data"))
+	require.Nil(t, err, "Encrypt local data")
+
+	local := dto.SecretUpdateRequest{
+		UID:      testSecretUID,
+		Name:     "local",
+		Meta:     meta,
+		EncrData: encrData,
+		Version:  2,
+	}
+	localCopy := dto.SecretRequest{
+		DataType: dto.SecretTypeText,
+		Name:     "local (local copy)",
+		Meta:     local.Meta,
+		EncrData: local.EncrData,
+	}
+	notFound := fmt.Errorf("%w: %w", httpClient.ErrSecretRetrieveFailed, httpClient.ErrSecretNotFound)
+
+	tests := []struct {
+		name         string
+		id           uint64
+		keep         string
+		cSetup       func(t *testing.T) Client
+		wantErr      error
+		wantResolved bool
+	}{
+		{
+			name: "keep_local",
+			id:   13,
+			keep: KeepLocal,
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{ID: 13, Version: 5}, nil)
+				updated := local
+				updated.Version = 5
+				client.EXPECT().Update(uint64(13), updated, testToken).Return(nil)
+				return client
+			},
+			wantResolved: true,
+		},
+		{
+			name: "keep_local_deleted_on_server",
+			id:   13,
+			keep: KeepLocal,
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{}, notFound)
+				// секрет создается заново с тем же UID, к которому привязаны данные
+				restored := localCopy
+				restored.UID = local.UID
+				restored.Name = local.Name
+				client.EXPECT().Upload(restored, testToken).Return(nil)
+				return client
+			},
+			wantResolved: true,
+		},
+		{
+			name: "keep_remote",
+			id:   13,
+			keep: KeepRemote,
+			cSetup: func(t *testing.T) Client {
+				return mocks.NewMockClient(gomock.NewController(t))
+			},
+			wantResolved: true,
+		},
+		{
+			name: "keep_both",
+			id:   13,
+			keep: KeepBoth,
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().
+					Upload(gomock.Any(), testToken).
+					DoAndReturn(func(secret dto.SecretRequest, _ string) error {
+						assert.Equal(t, localCopy.Name, secret.Name, "Copy name")
+						assert.Equal(t, localCopy.Meta, secret.Meta, "Copy meta")
+						require.Nil(t, dto.ValidateSecretUID(secret.UID), "Copy UID")
+						assert.NotEqual(t, local.UID, secret.UID, "Copy UID")
+						// данные перешифрованы для UID и названия копии
+						binding := secretBinding(secret.UID, dto.SecretTypeText, localCopy.Name, meta)
+						data, err := deryptData(keys, binding, true, &secret.EncrData)
+						require.Nil(t, err, "Decrypt copy data")
+						assert.Equal(t, "data", string(data), "Copy data")
+						return nil
+					})
+				return client
+			},
+			wantResolved: true,
+		},
+		{
+			name: "keep_both_without_data",
+			id:   15,
+			keep: KeepBoth,
+			cSetup: func(t *testing.T) Client {
+				return mocks.NewMockClient(gomock.NewController(t))
+			},
+			wantErr: ErrConflictNoData,
+		},
+		{
+			name: "server_error",
+			id:   13,
+			keep: KeepLocal,
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{ID: 13, Version: 5}, nil)
+				client.EXPECT().Update(uint64(13), gomock.All(), testToken).
+					Return(fmt.Errorf("%w: %w", httpClient.ErrSecretUpdateFailed, httpClient.ErrSecretConflict))
+				return client
+			},
+			wantErr: httpClient.ErrSecretConflict,
+		},
+		{
+			name: "conflict_not_found",
+			id:   14,
+			keep: KeepRemote,
+			cSetup: func(t *testing.T) Client {
+				return mocks.NewMockClient(gomock.NewController(t))
+			},
+			wantErr: ErrConflictNotFound,
+		},
+		{
+			name: "invalid_keep",
+			id:   13,
+			keep: "mine",
+			cSetup: func(t *testing.T) Client {
+				return mocks.NewMockClient(gomock.NewController(t))
+			},
+			wantErr: ErrConflictInvalidKeep,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage, saved := testVaultStorage(t, masterKey, &vault{
+				Conflicts: map[uint64]conflict{
+					13: {DataType: dto.SecretTypeText, Local: local},
+					15: {DataType: dto.SecretTypeFile, Local: dto.SecretUpdateRequest{Name: "meta only"}},
+				},
+			})
+
+			err := NewSecret(test.cSetup(t), storage).ResolveConflict(test.id, test.keep)
+			assert.ErrorIs(t, err, test.wantErr, "Resolve error")
+
+			_, exists := saved().Conflicts[13]
+			assert.Equal(t, !test.wantResolved, exists, "Conflict in vault")
+		})
+	}
+}
+
+func Test_copyName(t *testing.T) {
+	assert.Equal(t, "name (local copy)", copyName("name"), "Short name")
+
+	long := copyName(strings.Repeat("я", dto.SecretNameMaxLen))
+	assert.Equal(t, dto.SecretNameMaxLen, len([]rune(long)), "Long name length")
+	assert.True(t, strings.HasSuffix(long, conflictCopySuffix), "Long name suffix")
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// keyring ключи пользователя. Мастер ключ вычисляется из пароля и шифрует ключ аккаунта,
+// случайный ключ аккаунта шифрует ключи данных секретов и локальную копию секретов,
+// поэтому при смене пароля перешифровывается только ключ аккаунта.
+// Ключи данных, сохраненные до перехода на ключ аккаунта, зашифрованы мастер ключом.
+type keyring struct {
+	master  []byte
+	account []byte
+}
+
+// loadKeyring получает мастер ключ и ключ аккаунта из локального хранилища.
+func loadKeyring(s Storage) (keyring, error) {
+	var keys keyring
+
+	masterKey, err := s.Key()
+	if err != nil {
+		return keys, err
+	}
+	accountKey, err := s.AccountKey()
+	if err != nil {
+		return keys, err
+	}
+
+	keys.master, keys.account = masterKey, accountKey
+	return keys, nil
+}
+
+// wrap шифрует ключ данных ключом аккаунта, возвращает его в base64 и версию схемы ключей.
+func (k keyring) wrap(key []byte) (string, uint8, error) {
+	encryptedKey, err := crypto.EncryptAES(k.account, key)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to encrypt DEK: %w", err)
+	}
+
+	return base64.RawStdEncoding.EncodeToString(encryptedKey), dto.KeyVersionAccount, nil
+}
+
+// unwrap расшифровывает ключ данных, закодированный base64, ключом версии version.
+func (k keyring) unwrap(version uint8, encodedKey string) ([]byte, error) {
+	switch version {
+	case dto.KeyVersionMaster:
+		return decryptKey(k.master, encodedKey)
+	case dto.KeyVersionAccount:
+		return decryptKey(k.account, encodedKey)
+	default:
+		return nil, fmt.Errorf("the server returned invalid data: unsupported key version %d", version)
+	}
+}
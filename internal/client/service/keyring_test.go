@@ -0,0 +1,63 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// testLegacyData шифрует данные, как до перехода на ключ аккаунта: ключ DEK мастер ключом,
+// данные без привязки к описанию секрета.
+func testLegacyData(t *testing.T, masterKey, payload []byte) dto.EncryptedData {
+	dek, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "DEK creation")
+	data, err := crypto.EncryptAES(dek, payload)
+	require.Nil(t, err, "Legacy data encryption")
+	key, err := crypto.EncryptAES(masterKey, dek)
+	require.Nil(t, err, "Legacy DEK encryption")
+	return dto.EncryptedData{Key: base64.RawStdEncoding.EncodeToString(key), Data: data, Version: dto.KeyVersionMaster}
+}
+
+func Test_keyring_unwrap(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
+	keys := keyring{master: masterKey, account: accountKey}
+
+	dek := []byte("0123456789abcdef0123456789abcdef")
+	wrapped, version, err := keys.wrap(dek)
+	require.Nil(t, err, "Wrap DEK")
+	assert.Equal(t, dto.KeyVersionAccount, version, "Wrap version")
+
+	legacyWrapped, _, err := keyring{account: masterKey}.wrap(dek)
+	require.Nil(t, err, "Wrap legacy DEK")
+
+	tests := []struct {
+		name    string
+		version uint8
+		key     string
+		wantErr bool
+	}{
+		{name: "account_key", version: dto.KeyVersionAccount, key: wrapped},
+		{name: "master_key", version: dto.KeyVersionMaster, key: legacyWrapped},
+		{name: "wrong_version", version: dto.KeyVersionMaster, key: wrapped, wantErr: true},
+		{name: "unsupported_version", version: 7, key: wrapped, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := keys.unwrap(test.version, test.key)
+			if test.wantErr {
+				assert.NotNil(t, err, "Unwrap error")
+				return
+			}
+			require.Nil(t, err, "Unwrap error")
+			assert.Equal(t, dek, got, "Unwrapped DEK")
+		})
+	}
+}
@@ -34,6 +34,78 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockClient) ChangePassword(data dto.PasswordChangeRequest, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", data, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockClientMockRecorder) ChangePassword(data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockClient)(nil).ChangePassword), data, token)
+}
+
+// Changes mocks base method.
+func (m *MockClient) Changes(since uint64, token string) (dto.SecretChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changes", since, token)
+	ret0, _ := ret[0].(dto.SecretChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Changes indicates an expected call of Changes.
+func (mr *MockClientMockRecorder) Changes(since, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changes", reflect.TypeOf((*MockClient)(nil).Changes), since, token)
+}
+
+// CommitUpload mocks base method.
+func (m *MockClient) CommitUpload(uploadID string, data dto.UploadCommitRequest, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitUpload", uploadID, data, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitUpload indicates an expected call of CommitUpload.
+func (mr *MockClientMockRecorder) CommitUpload(uploadID, data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitUpload", reflect.TypeOf((*MockClient)(nil).CommitUpload), uploadID, data, token)
+}
+
+// CreateUpload mocks base method.
+func (m *MockClient) CreateUpload(data dto.UploadRequest, token string) (dto.UploadResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", data, token)
+	ret0, _ := ret[0].(dto.UploadResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockClientMockRecorder) CreateUpload(data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockClient)(nil).CreateUpload), data, token)
+}
+
+// Delete mocks base method.
+func (m *MockClient) Delete(id uint64, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientMockRecorder) Delete(id, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), id, token)
+}
+
 // InfoList mocks base method.
 func (m *MockClient) InfoList(token string) ([]dto.SecretInfo, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +136,95 @@ func (mr *MockClientMockRecorder) Login(cr interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockClient)(nil).Login), cr)
 }
 
+// Logout mocks base method.
+func (m *MockClient) Logout(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockClientMockRecorder) Logout(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockClient)(nil).Logout), token)
+}
+
+// PasswordParams mocks base method.
+func (m *MockClient) PasswordParams(token string) (dto.PreLoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PasswordParams", token)
+	ret0, _ := ret[0].(dto.PreLoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PasswordParams indicates an expected call of PasswordParams.
+func (mr *MockClientMockRecorder) PasswordParams(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PasswordParams", reflect.TypeOf((*MockClient)(nil).PasswordParams), token)
+}
+
+// PreLogin mocks base method.
+func (m *MockClient) PreLogin(data dto.PreLoginRequest) (dto.PreLoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreLogin", data)
+	ret0, _ := ret[0].(dto.PreLoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreLogin indicates an expected call of PreLogin.
+func (mr *MockClientMockRecorder) PreLogin(data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreLogin", reflect.TypeOf((*MockClient)(nil).PreLogin), data)
+}
+
+// Recovery mocks base method.
+func (m *MockClient) Recovery(token string) (dto.RecoveryKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recovery", token)
+	ret0, _ := ret[0].(dto.RecoveryKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recovery indicates an expected call of Recovery.
+func (mr *MockClientMockRecorder) Recovery(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recovery", reflect.TypeOf((*MockClient)(nil).Recovery), token)
+}
+
+// RecoveryComplete mocks base method.
+func (m *MockClient) RecoveryComplete(data dto.RecoveryRequest) (dto.AuthResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryComplete", data)
+	ret0, _ := ret[0].(dto.AuthResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryComplete indicates an expected call of RecoveryComplete.
+func (mr *MockClientMockRecorder) RecoveryComplete(data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryComplete", reflect.TypeOf((*MockClient)(nil).RecoveryComplete), data)
+}
+
+// RecoveryStart mocks base method.
+func (m *MockClient) RecoveryStart(data dto.RecoveryStartRequest) (dto.RecoveryStartResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryStart", data)
+	ret0, _ := ret[0].(dto.RecoveryStartResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryStart indicates an expected call of RecoveryStart.
+func (mr *MockClientMockRecorder) RecoveryStart(data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryStart", reflect.TypeOf((*MockClient)(nil).RecoveryStart), data)
+}
+
 // Register mocks base method.
 func (m *MockClient) Register(cr dto.Credentials) (dto.AuthResponse, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +255,151 @@ func (mr *MockClientMockRecorder) Retrieve(id, token interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retrieve", reflect.TypeOf((*MockClient)(nil).Retrieve), id, token)
 }
 
+// RetrieveChunk mocks base method.
+func (m *MockClient) RetrieveChunk(id uint64, n uint32, token string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveChunk", id, n, token)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveChunk indicates an expected call of RetrieveChunk.
+func (mr *MockClientMockRecorder) RetrieveChunk(id, n, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveChunk", reflect.TypeOf((*MockClient)(nil).RetrieveChunk), id, n, token)
+}
+
+// RevokeSession mocks base method.
+func (m *MockClient) RevokeSession(id, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", id, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockClientMockRecorder) RevokeSession(id, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockClient)(nil).RevokeSession), id, token)
+}
+
+// SecretKeys mocks base method.
+func (m *MockClient) SecretKeys(token string) ([]dto.SecretKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SecretKeys", token)
+	ret0, _ := ret[0].([]dto.SecretKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SecretKeys indicates an expected call of SecretKeys.
+func (mr *MockClientMockRecorder) SecretKeys(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecretKeys", reflect.TypeOf((*MockClient)(nil).SecretKeys), token)
+}
+
+// Sessions mocks base method.
+func (m *MockClient) Sessions(token string) ([]dto.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sessions", token)
+	ret0, _ := ret[0].([]dto.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sessions indicates an expected call of Sessions.
+func (mr *MockClientMockRecorder) Sessions(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockClient)(nil).Sessions), token)
+}
+
+// SetAccountKey mocks base method.
+func (m *MockClient) SetAccountKey(data dto.AccountKeyRequest, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountKey", data, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccountKey indicates an expected call of SetAccountKey.
+func (mr *MockClientMockRecorder) SetAccountKey(data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountKey", reflect.TypeOf((*MockClient)(nil).SetAccountKey), data, token)
+}
+
+// SetupRecovery mocks base method.
+func (m *MockClient) SetupRecovery(data dto.RecoverySetupRequest, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetupRecovery", data, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetupRecovery indicates an expected call of SetupRecovery.
+func (mr *MockClientMockRecorder) SetupRecovery(data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetupRecovery", reflect.TypeOf((*MockClient)(nil).SetupRecovery), data, token)
+}
+
+// TwoFactorDisable mocks base method.
+func (m *MockClient) TwoFactorDisable(code, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactorDisable", code, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TwoFactorDisable indicates an expected call of TwoFactorDisable.
+func (mr *MockClientMockRecorder) TwoFactorDisable(code, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactorDisable", reflect.TypeOf((*MockClient)(nil).TwoFactorDisable), code, token)
+}
+
+// TwoFactorEnable mocks base method.
+func (m *MockClient) TwoFactorEnable(code, token string) (dto.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactorEnable", code, token)
+	ret0, _ := ret[0].(dto.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TwoFactorEnable indicates an expected call of TwoFactorEnable.
+func (mr *MockClientMockRecorder) TwoFactorEnable(code, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactorEnable", reflect.TypeOf((*MockClient)(nil).TwoFactorEnable), code, token)
+}
+
+// TwoFactorSetup mocks base method.
+func (m *MockClient) TwoFactorSetup(token string) (dto.TOTPSetup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TwoFactorSetup", token)
+	ret0, _ := ret[0].(dto.TOTPSetup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TwoFactorSetup indicates an expected call of TwoFactorSetup.
+func (mr *MockClientMockRecorder) TwoFactorSetup(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TwoFactorSetup", reflect.TypeOf((*MockClient)(nil).TwoFactorSetup), token)
+}
+
+// Update mocks base method.
+func (m *MockClient) Update(id uint64, data dto.SecretUpdateRequest, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", id, data, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockClientMockRecorder) Update(id, data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), id, data, token)
+}
+
 // Upload mocks base method.
 func (m *MockClient) Upload(data dto.SecretRequest, token string) error {
 	m.ctrl.T.Helper()
@@ -107,3 +413,47 @@ func (mr *MockClientMockRecorder) Upload(data, token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockClient)(nil).Upload), data, token)
 }
+
+// UploadChunk mocks base method.
+func (m *MockClient) UploadChunk(uploadID string, n uint32, data []byte, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadChunk", uploadID, n, data, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadChunk indicates an expected call of UploadChunk.
+func (mr *MockClientMockRecorder) UploadChunk(uploadID, n, data, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadChunk", reflect.TypeOf((*MockClient)(nil).UploadChunk), uploadID, n, data, token)
+}
+
+// UploadStatus mocks base method.
+func (m *MockClient) UploadStatus(uploadID, token string) (dto.UploadStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadStatus", uploadID, token)
+	ret0, _ := ret[0].(dto.UploadStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadStatus indicates an expected call of UploadStatus.
+func (mr *MockClientMockRecorder) UploadStatus(uploadID, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStatus", reflect.TypeOf((*MockClient)(nil).UploadStatus), uploadID, token)
+}
+
+// Usage mocks base method.
+func (m *MockClient) Usage(token string) (dto.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", token)
+	ret0, _ := ret[0].(dto.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockClientMockRecorder) Usage(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockClient)(nil).Usage), token)
+}
@@ -33,6 +33,21 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AccountKey mocks base method.
+func (m *MockStorage) AccountKey() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountKey")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountKey indicates an expected call of AccountKey.
+func (mr *MockStorageMockRecorder) AccountKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountKey", reflect.TypeOf((*MockStorage)(nil).AccountKey))
+}
+
 // Key mocks base method.
 func (m *MockStorage) Key() ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -48,6 +63,20 @@ func (mr *MockStorageMockRecorder) Key() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Key", reflect.TypeOf((*MockStorage)(nil).Key))
 }
 
+// PutAccountKey mocks base method.
+func (m *MockStorage) PutAccountKey(key []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutAccountKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutAccountKey indicates an expected call of PutAccountKey.
+func (mr *MockStorageMockRecorder) PutAccountKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutAccountKey", reflect.TypeOf((*MockStorage)(nil).PutAccountKey), key)
+}
+
 // PutKey mocks base method.
 func (m *MockStorage) PutKey(key []byte) error {
 	m.ctrl.T.Helper()
@@ -62,6 +91,20 @@ func (mr *MockStorageMockRecorder) PutKey(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutKey", reflect.TypeOf((*MockStorage)(nil).PutKey), key)
 }
 
+// PutRefreshToken mocks base method.
+func (m *MockStorage) PutRefreshToken(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRefreshToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutRefreshToken indicates an expected call of PutRefreshToken.
+func (mr *MockStorageMockRecorder) PutRefreshToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRefreshToken", reflect.TypeOf((*MockStorage)(nil).PutRefreshToken), token)
+}
+
 // PutToken mocks base method.
 func (m *MockStorage) PutToken(token string) error {
 	m.ctrl.T.Helper()
@@ -76,6 +119,50 @@ func (mr *MockStorageMockRecorder) PutToken(token interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutToken", reflect.TypeOf((*MockStorage)(nil).PutToken), token)
 }
 
+// PutVault mocks base method.
+func (m *MockStorage) PutVault(vault []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutVault", vault)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutVault indicates an expected call of PutVault.
+func (mr *MockStorageMockRecorder) PutVault(vault interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutVault", reflect.TypeOf((*MockStorage)(nil).PutVault), vault)
+}
+
+// RefreshToken mocks base method.
+func (m *MockStorage) RefreshToken() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockStorageMockRecorder) RefreshToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockStorage)(nil).RefreshToken))
+}
+
+// SetVaultAside mocks base method.
+func (m *MockStorage) SetVaultAside() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVaultAside")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVaultAside indicates an expected call of SetVaultAside.
+func (mr *MockStorageMockRecorder) SetVaultAside() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVaultAside", reflect.TypeOf((*MockStorage)(nil).SetVaultAside))
+}
+
 // Token mocks base method.
 func (m *MockStorage) Token() (string, error) {
 	m.ctrl.T.Helper()
@@ -90,3 +177,32 @@ func (mr *MockStorageMockRecorder) Token() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockStorage)(nil).Token))
 }
+
+// Vault mocks base method.
+func (m *MockStorage) Vault() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vault")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vault indicates an expected call of Vault.
+func (mr *MockStorageMockRecorder) Vault() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vault", reflect.TypeOf((*MockStorage)(nil).Vault))
+}
+
+// Wipe mocks base method.
+func (m *MockStorage) Wipe() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wipe")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Wipe indicates an expected call of Wipe.
+func (mr *MockStorageMockRecorder) Wipe() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wipe", reflect.TypeOf((*MockStorage)(nil).Wipe))
+}
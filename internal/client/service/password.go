@@ -0,0 +1,183 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// ChangePassword меняет пароль пользователя. Из нового пароля с новой солью вычисляется
+// новый мастер ключ и новый ключ аутентификации, которым перешифровывается ключ аккаунта, ключи данных секретов
+// и ключи восстановления зашифрованы ключом аккаунта и не меняются. Ключи данных,
+// сохраненные клиентами без поддержки ключа аккаунта, перешифровываются ключом аккаунта
+// и отправляются на сервер одним запросом вместе с ключами аутентификации, сервер заменяет их в одной транзакции.
+// Локальная копия зашифрована ключом аккаунта и не перешифровывается, в ней только ключи данных
+// переводятся на ключ аккаунта. Незавершенные загрузки файлов с ключами, зашифрованными
+// мастер ключом, сервер удаляет, и они начинаются заново.
+func (s *Secret) ChangePassword(password, newPassword string) error {
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := s.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	// Текущий пароль подтверждается ключом аутентификации, выведенным из него
+	pre, err := s.client.PasswordParams(token)
+	if err != nil {
+		return err
+	}
+	_, _, authKey, err := deriveKeys(password, pre)
+	if err != nil {
+		return err
+	}
+
+	salt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	// Новый мастер ключ вычисляется с текущими параметрами по умолчанию
+	params := crypto.DefaultKDFParams()
+	newMasterKey, newAuthKey, err := deriveAuthKey(newPassword, salt, params)
+	if err != nil {
+		return err
+	}
+	encryptedAccountKey, err := crypto.EncryptAESWithKDF(newMasterKey, keys.account, params)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt account key: %w", err)
+	}
+	upgrade := keyRewrapper(keys.master, keys.account)
+
+	secretKeys, err := s.client.SecretKeys(token)
+	if err != nil {
+		return err
+	}
+	upgraded := make([]dto.SecretKey, 0)
+	for _, k := range secretKeys {
+		if k.Version != dto.KeyVersionMaster {
+			continue
+		}
+		if k.Key, err = upgrade(k.Key); err != nil {
+			return fmt.Errorf("%w: secret %d: %w", ErrSecretDecryptionFailed, k.ID, err)
+		}
+		k.Version = dto.KeyVersionAccount
+		upgraded = append(upgraded, k)
+	}
+
+	// Ключи данных в локальной копии переводятся на ключ аккаунта до запроса, чтобы ошибка
+	// не оставила в ней ключи, зашифрованные старым мастер ключом, после смены пароля на сервере.
+	// Ключ аккаунта открывает их и до смены пароля, поэтому копия сохраняется сразу
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return err
+	}
+	if err := rewrapVault(v, upgrade); err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+	}
+	if err := s.saveVault(keys, v); err != nil {
+		return err
+	}
+
+	req := dto.PasswordChangeRequest{
+		AuthKey:    authKey,
+		NewAuthKey: newAuthKey,
+		EncrSalt:   base64.RawStdEncoding.EncodeToString(salt),
+		KDF:        kdfRequest(params),
+		AccountKey: base64.RawStdEncoding.EncodeToString(encryptedAccountKey),
+		Keys:       upgraded,
+	}
+	// Аккаунт, еще не переведенный на ключ аутентификации, подтверждает смену паролем
+	if pre.AuthVersion == dto.AuthVersionPassword {
+		req.Password = password
+	}
+	err = s.client.ChangePassword(req, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.PutKey(newMasterKey); err != nil {
+		return fmt.Errorf("password changed, but failed to store key, login again: %w", err)
+	}
+
+	return nil
+}
+
+// keyRewrapper возвращает функцию, которая расшифровывает ключ данных
+// ключом oldKey и шифрует его ключом newKey.
+func keyRewrapper(oldKey, newKey []byte) func(encodedKey string) (string, error) {
+	return func(encodedKey string) (string, error) {
+		key, err := decryptKey(oldKey, encodedKey)
+		if err != nil {
+			return "", err
+		}
+
+		encryptedKey, err := crypto.EncryptAES(newKey, key)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt DEK: %w", err)
+		}
+
+		return base64.RawStdEncoding.EncodeToString(encryptedKey), nil
+	}
+}
+
+// rewrapVault перешифровывает ключом аккаунта ключи данных секретов и несинхронизированных
+// изменений в локальной копии, зашифрованные мастер ключом, и удаляет незавершенные загрузки
+// с такими ключами. Пустые ключи пропускаются: их нет у изменений без новых данных.
+func rewrapVault(v *vault, upgrade func(string) (string, error)) error {
+	rewrap := func(data *dto.EncryptedData) error {
+		if data.Key == "" || data.Version != dto.KeyVersionMaster {
+			return nil
+		}
+		key, err := upgrade(data.Key)
+		if err != nil {
+			return err
+		}
+		data.Key, data.Version = key, dto.KeyVersionAccount
+		return nil
+	}
+
+	for id, secret := range v.Secrets {
+		if err := rewrap(&secret.EncrData); err != nil {
+			return fmt.Errorf("local secret %d: %w", id, err)
+		}
+		v.Secrets[id] = secret
+	}
+
+	for i := range v.Created {
+		if err := rewrap(&v.Created[i].EncrData); err != nil {
+			return fmt.Errorf("local created secret: %w", err)
+		}
+	}
+
+	for i := range v.Rejected {
+		if err := rewrap(&v.Rejected[i].Secret.EncrData); err != nil {
+			return fmt.Errorf("local rejected secret: %w", err)
+		}
+	}
+
+	for id, secret := range v.Updated {
+		if err := rewrap(&secret.EncrData); err != nil {
+			return fmt.Errorf("local updated secret %d: %w", id, err)
+		}
+		v.Updated[id] = secret
+	}
+
+	for id, c := range v.Conflicts {
+		if err := rewrap(&c.Local.EncrData); err != nil {
+			return fmt.Errorf("local conflict %d: %w", id, err)
+		}
+		v.Conflicts[id] = c
+	}
+
+	for key, upload := range v.Uploads {
+		if upload.Request.KeyVersion == dto.KeyVersionMaster {
+			delete(v.Uploads, key)
+		}
+	}
+
+	return nil
+}
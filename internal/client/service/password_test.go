@@ -0,0 +1,239 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+func TestSecret_ChangePassword(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
+	otherKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Other master key creation")
+	keys := keyring{master: masterKey, account: accountKey}
+
+	remote, err := encryptData(keys, nil, []byte("remote data"))
+	require.Nil(t, err, "Encrypt remote data")
+	legacy := testLegacyData(t, masterKey, []byte("legacy data"))
+	foreign := testLegacyData(t, otherKey, []byte("foreign data"))
+	cached := testLegacyData(t, masterKey, []byte("cached data"))
+	rejected := testLegacyData(t, masterKey, []byte("rejected data"))
+	created, err := encryptData(keys, nil, []byte("created data"))
+	require.Nil(t, err, "Encrypt created data")
+
+	localVault := &vault{
+		Secrets: map[uint64]dto.SecretResponse{13: {ID: 13, EncrData: cached}},
+		Created: []dto.SecretRequest{{Name: "created", EncrData: created}},
+		Rejected: []rejectedSecret{
+			{Secret: dto.SecretRequest{Name: "rejected", EncrData: rejected}, Reason: "quota"},
+		},
+		Updated: map[uint64]dto.SecretUpdateRequest{13: {Name: "renamed"}},
+		Uploads: map[string]pendingUpload{
+			"file":   {ID: "upload-id", Request: dto.UploadRequest{KeyVersion: dto.KeyVersionAccount}},
+			"legacy": {ID: "legacy-upload-id"},
+		},
+	}
+	vaultData, err := json.Marshal(localVault)
+	require.Nil(t, err, "Encode vault")
+	vaultData, err = crypto.EncryptAES(masterKey, vaultData)
+	require.Nil(t, err, "Encrypt vault")
+
+	// текущий ключ аутентификации вычисляется из текущего пароля с параметрами с сервера
+	base64Salt := "EPhQ3C8pTRYDMac+aCzFTA"
+	salt, err := base64.RawStdEncoding.DecodeString(base64Salt)
+	require.Nil(t, err, "Decoding salt from base64")
+	currentMasterKey, err := crypto.DeriveKey([]byte("old1password"), salt)
+	require.Nil(t, err, "Generate current master key")
+	authKey := testAuthKey(t, currentMasterKey)
+
+	// newMasterKey вычисляет новый мастер ключ из запроса так же, как при входе
+	newMasterKey := func(t *testing.T, req dto.PasswordChangeRequest) []byte {
+		salt, err := base64.RawStdEncoding.DecodeString(req.EncrSalt)
+		require.Nil(t, err, "Decode new salt")
+		require.NotNil(t, req.KDF, "KDF params")
+		assert.Equal(t, crypto.DefaultKDFParams(), crypto.KDFParams(*req.KDF), "KDF params")
+		key, err := crypto.DeriveKeyWithParams([]byte("new1password"), salt, crypto.KDFParams(*req.KDF))
+		require.Nil(t, err, "Derive new master key")
+		assert.Equal(t, testAuthKey(t, key), req.NewAuthKey, "New auth key")
+		return key
+	}
+
+	tests := []struct {
+		name string
+		// версия схемы аутентификации аккаунта
+		authVersion uint8
+		paramsErr   error
+		keys        []dto.SecretKey
+		keysErr     error
+		// ключи, перешифрованные ключом аккаунта
+		wantUpgraded map[uint64]string
+		// отправляется ли запрос смены пароля
+		change    bool
+		changeErr error
+		wantErr   error
+	}{
+		{
+			name:         "success",
+			authVersion:  dto.AuthVersionKey,
+			keys:         []dto.SecretKey{{ID: 1, Key: remote.Key, Version: dto.KeyVersionAccount}},
+			wantUpgraded: map[uint64]string{},
+			change:       true,
+		},
+		{
+			name:         "success_legacy_password",
+			authVersion:  dto.AuthVersionPassword,
+			keys:         []dto.SecretKey{{ID: 1, Key: remote.Key, Version: dto.KeyVersionAccount}},
+			wantUpgraded: map[uint64]string{},
+			change:       true,
+		},
+		{
+			name:        "success_with_legacy_keys",
+			authVersion: dto.AuthVersionKey,
+			keys: []dto.SecretKey{
+				{ID: 1, Key: remote.Key, Version: dto.KeyVersionAccount},
+				{ID: 2, Key: legacy.Key},
+			},
+			wantUpgraded: map[uint64]string{2: "legacy data"},
+			change:       true,
+		},
+		{
+			name:      "params_failed",
+			paramsErr: httpClient.ErrPasswordChangeFailed,
+			wantErr:   httpClient.ErrPasswordChangeFailed,
+		},
+		{
+			name:    "keys_failed",
+			keysErr: httpClient.ErrSecretKeysFailed,
+			wantErr: httpClient.ErrSecretKeysFailed,
+		},
+		{
+			name:    "foreign_key",
+			keys:    []dto.SecretKey{{ID: 1, Key: remote.Key, Version: dto.KeyVersionAccount}, {ID: 2, Key: foreign.Key}},
+			wantErr: ErrSecretDecryptionFailed,
+		},
+		{
+			name:        "invalid_password",
+			authVersion: dto.AuthVersionKey,
+			keys:        []dto.SecretKey{{ID: 1, Key: remote.Key, Version: dto.KeyVersionAccount}},
+			change:      true,
+			changeErr:   fmt.Errorf("%w: %w", httpClient.ErrPasswordChangeFailed, httpClient.ErrInvalidPassword),
+			wantErr:     httpClient.ErrInvalidPassword,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				req      dto.PasswordChangeRequest
+				putKey   []byte
+				putVault []byte
+			)
+
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockStorage(ctrl)
+			storage.EXPECT().Key().Return(masterKey, nil)
+			storage.EXPECT().AccountKey().Return(accountKey, nil)
+			storage.EXPECT().Token().Return(testToken, nil)
+			storage.EXPECT().Vault().Return(vaultData, nil).AnyTimes()
+
+			client := mocks.NewMockClient(ctrl)
+			client.EXPECT().
+				PasswordParams(testToken).
+				Return(dto.PreLoginResponse{EncrSalt: base64Salt, AuthVersion: test.authVersion}, test.paramsErr)
+			if test.paramsErr == nil {
+				client.EXPECT().SecretKeys(testToken).Return(test.keys, test.keysErr)
+			}
+
+			if test.change {
+				// копия сохраняется до запроса, ключ аккаунта при смене пароля не меняется
+				storage.EXPECT().PutVault(gomock.Any()).DoAndReturn(func(vault []byte) error {
+					putVault = vault
+					return nil
+				})
+				client.EXPECT().
+					ChangePassword(gomock.Any(), testToken).
+					DoAndReturn(func(data dto.PasswordChangeRequest, _ string) error {
+						req = data
+						return test.changeErr
+					})
+			}
+			if test.wantErr == nil {
+				storage.EXPECT().PutKey(gomock.Any()).DoAndReturn(func(key []byte) error {
+					putKey = key
+					return nil
+				})
+			}
+
+			err := NewSecret(client, storage).ChangePassword("old1password", "new1password")
+			assert.ErrorIs(t, err, test.wantErr, "ChangePassword error")
+			if test.wantErr != nil {
+				return
+			}
+
+			assert.Equal(t, authKey, req.AuthKey, "Current auth key")
+			// пароль отправляется, только если аккаунт не переведен на ключ аутентификации
+			if test.authVersion == dto.AuthVersionPassword {
+				assert.Equal(t, "old1password", req.Password, "Current password")
+			} else {
+				assert.Empty(t, req.Password, "Current password")
+			}
+			assert.Nil(t, req.Recovery, "Recovery keys")
+			newKey := newMasterKey(t, req)
+			assert.Equal(t, newKey, putKey, "Stored master key")
+
+			// ключ аккаунта не меняется, он только перешифровывается новым мастер ключом
+			key, err := decryptKey(newKey, req.AccountKey)
+			require.Nil(t, err, "Decrypt account key with new master key")
+			assert.Equal(t, accountKey, key, "Account key")
+
+			newKeys := keyring{master: newKey, account: accountKey}
+			require.Len(t, req.Keys, len(test.wantUpgraded), "Upgraded keys")
+			for _, k := range req.Keys {
+				assert.Equal(t, dto.KeyVersionAccount, k.Version, "Upgraded key version")
+				data, err := deryptData(newKeys, nil, false, &dto.EncryptedData{Key: k.Key, Data: legacy.Data, Version: k.Version})
+				require.Nil(t, err, "Decrypt upgraded data with account key")
+				assert.Equal(t, test.wantUpgraded[k.ID], string(data), "Upgraded data")
+			}
+
+			// копия, сохраненная до ключа аккаунта, теперь зашифрована им
+			plainVault, err := crypto.DecryptAES(accountKey, putVault)
+			require.Nil(t, err, "Decrypt vault with account key")
+			var v vault
+			require.Nil(t, json.Unmarshal(plainVault, &v), "Decode saved vault")
+
+			secret := v.Secrets[13].EncrData
+			assert.Equal(t, dto.KeyVersionAccount, secret.Version, "Cached secret key version")
+			data, err := deryptData(newKeys, nil, false, &secret)
+			require.Nil(t, err, "Decrypt cached secret")
+			assert.Equal(t, "cached data", string(data), "Cached data")
+
+			data, err = deryptData(newKeys, nil, false, &v.Created[0].EncrData)
+			require.Nil(t, err, "Decrypt created secret")
+			assert.Equal(t, "created data", string(data), "Created data")
+
+			rejected := v.Rejected[0].Secret.EncrData
+			assert.Equal(t, dto.KeyVersionAccount, rejected.Version, "Rejected secret key version")
+			data, err = deryptData(newKeys, nil, false, &rejected)
+			require.Nil(t, err, "Decrypt rejected secret")
+			assert.Equal(t, "rejected data", string(data), "Rejected data")
+
+			assert.Equal(t, "renamed", v.Updated[13].Name, "Updated secret")
+			// загрузки с ключом, зашифрованным мастер ключом, сервер удаляет
+			assert.Len(t, v.Uploads, 1, "Pending uploads")
+			assert.Contains(t, v.Uploads, "file", "Pending upload with account key")
+		})
+	}
+}
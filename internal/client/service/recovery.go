@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// SetupRecovery генерирует ключ восстановления доступа и сохраняет на сервере ключ аккаунта,
+// зашифрованный ключом восстановления. Сервер принимает ключ только с подтверждением
+// текущим паролем, как при смене пароля. Возвращает печатный код ключа,
+// ни клиент, ни сервер его не хранят, поэтому показать его можно только один раз.
+func (a *Auth) SetupRecovery(password string) (string, error) {
+	keys, err := loadKeyring(a.storage)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := a.storage.Token()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	pre, err := a.client.PasswordParams(token)
+	if err != nil {
+		return "", err
+	}
+	_, _, authKey, err := deriveKeys(password, pre)
+	if err != nil {
+		return "", err
+	}
+
+	recoveryKey, err := crypto.GenerateRecoveryKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
+	}
+
+	key, err := wrapRecoveryKeys(recoveryKey, keys.account)
+	if err != nil {
+		return "", err
+	}
+
+	req := dto.RecoverySetupRequest{AuthKey: authKey, Recovery: key}
+	// Аккаунт, еще не переведенный на ключ аутентификации, подтверждает настройку паролем
+	if pre.AuthVersion == dto.AuthVersionPassword {
+		req.Password = password
+	}
+	if err := a.client.SetupRecovery(req, token); err != nil {
+		return "", err
+	}
+
+	return crypto.EncodeRecoveryKey(recoveryKey), nil
+}
+
+// Recover сбрасывает пароль по коду ключа восстановления и выполняет вход.
+// Ключ аккаунта расшифровывается ключом восстановления и шифруется новым мастер ключом,
+// ключи данных и ключ восстановления не меняются. Для аккаунта, не переведенного
+// на ключ аккаунта, ключом восстановления расшифровывается мастер ключ, и ключи данных
+// всех секретов перешифровываются новым мастер ключом, как при смене пароля,
+// а при входе аккаунт переводится на ключ аккаунта. Все сессии пользователя завершаются,
+// локальная копия на устройстве удаляется и загружается заново при синхронизации.
+// Если включена двухфакторная аутентификация, а otp не указан, возвращает ошибку,
+// содержащую httpClient.ErrOTPRequired.
+func (a *Auth) Recover(login, code, newPassword, otp string) error {
+	recoveryKey, err := crypto.DecodeRecoveryKey(code)
+	if err != nil {
+		return err
+	}
+	wrapKey, authKey, err := crypto.DeriveRecoveryKeys(recoveryKey)
+	if err != nil {
+		return err
+	}
+	encodedAuthKey := base64.RawStdEncoding.EncodeToString(authKey)
+
+	start, err := a.client.RecoveryStart(dto.RecoveryStartRequest{Login: login, AuthKey: encodedAuthKey})
+	if err != nil {
+		return err
+	}
+
+	salt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	params := crypto.DefaultKDFParams()
+	newMasterKey, newAuthKey, err := deriveAuthKey(newPassword, salt, params)
+	if err != nil {
+		return err
+	}
+
+	req := dto.RecoveryRequest{
+		Login:      login,
+		AuthKey:    encodedAuthKey,
+		NewAuthKey: newAuthKey,
+		EncrSalt:   base64.RawStdEncoding.EncodeToString(salt),
+		KDF:        kdfRequest(params),
+		Device:     a.device,
+		OTP:        otp,
+	}
+	if start.AccountKey != "" {
+		req.AccountKey, err = rewrapAccountKey(wrapKey, newMasterKey, params, start.AccountKey)
+	} else {
+		req.Keys, req.Recovery, err = rewrapLegacyKeys(wrapKey, recoveryKey, newMasterKey, start)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.RecoveryComplete(req)
+	if err != nil {
+		return err
+	}
+
+	// Локальная копия зашифрована старым мастер ключом
+	if err := a.storage.Wipe(); err != nil {
+		return fmt.Errorf("password reset, but failed to wipe local data: %w", err)
+	}
+
+	return a.storeKeys(newMasterKey, params, resp)
+}
+
+// rewrapAccountKey расшифровывает ключ аккаунта ключом, выведенным из ключа восстановления,
+// и шифрует его новым мастер ключом, вычисленным с параметрами params.
+func rewrapAccountKey(wrapKey, newMasterKey []byte, params crypto.KDFParams, encodedKey string) (string, error) {
+	accountKey, err := decryptKey(wrapKey, encodedKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: account key: %w", ErrSecretDecryptionFailed, err)
+	}
+
+	encryptedKey, err := crypto.EncryptAESWithKDF(newMasterKey, accountKey, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt account key: %w", err)
+	}
+
+	return base64.RawStdEncoding.EncodeToString(encryptedKey), nil
+}
+
+// rewrapLegacyKeys расшифровывает мастер ключ ключом, выведенным из ключа восстановления,
+// и перешифровывает новым мастер ключом ключи данных всех секретов и ключи восстановления.
+func rewrapLegacyKeys(
+	wrapKey, recoveryKey, newMasterKey []byte,
+	start dto.RecoveryStartResponse,
+) ([]dto.SecretKey, *dto.RecoveryKey, error) {
+	masterKey, err := decryptKey(wrapKey, start.MasterKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: master key: %w", ErrSecretDecryptionFailed, err)
+	}
+
+	rewrap := keyRewrapper(masterKey, newMasterKey)
+	for i := range start.Keys {
+		if start.Keys[i].Key, err = rewrap(start.Keys[i].Key); err != nil {
+			return nil, nil, fmt.Errorf("%w: secret %d: %w", ErrSecretDecryptionFailed, start.Keys[i].ID, err)
+		}
+	}
+
+	key, err := wrapRecoveryKeys(recoveryKey, newMasterKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	key.AuthKey = ""
+
+	return start.Keys, &key, nil
+}
+
+// wrapRecoveryKeys шифрует ключ аккаунта (или мастер ключ) ключом, выведенным из ключа
+// восстановления, а ключ восстановления им самим, чтобы перешифровать ключи восстановления
+// без кода восстановления. Возвращает их вместе с ключом аутентификации для сервера.
+func wrapRecoveryKeys(recoveryKey, masterKey []byte) (dto.RecoveryKey, error) {
+	var key dto.RecoveryKey
+
+	wrapKey, authKey, err := crypto.DeriveRecoveryKeys(recoveryKey)
+	if err != nil {
+		return key, err
+	}
+
+	encryptedMasterKey, err := crypto.EncryptAES(wrapKey, masterKey)
+	if err != nil {
+		return key, fmt.Errorf("failed to encrypt master key: %w", err)
+	}
+	encryptedRecoveryKey, err := crypto.EncryptAES(masterKey, recoveryKey)
+	if err != nil {
+		return key, fmt.Errorf("failed to encrypt recovery key: %w", err)
+	}
+
+	key.MasterKey = base64.RawStdEncoding.EncodeToString(encryptedMasterKey)
+	key.RecoveryKey = base64.RawStdEncoding.EncodeToString(encryptedRecoveryKey)
+	key.AuthKey = base64.RawStdEncoding.EncodeToString(authKey)
+	return key, nil
+}
+
+// rewrapRecovery получает с сервера ключи восстановления, зашифрованные ключом masterKey,
+// и перешифровывает их ключом newMasterKey. Если восстановление не настроено, возвращает nil.
+func rewrapRecovery(c Client, token string, masterKey, newMasterKey []byte) (*dto.RecoveryKey, error) {
+	stored, err := c.Recovery(token)
+	if err != nil {
+		if errors.Is(err, httpClient.ErrRecoveryNotSetUp) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	recoveryKey, err := decryptKey(masterKey, stored.RecoveryKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: recovery key: %w", ErrSecretDecryptionFailed, err)
+	}
+
+	key, err := wrapRecoveryKeys(recoveryKey, newMasterKey)
+	if err != nil {
+		return nil, err
+	}
+	key.AuthKey = ""
+
+	return &key, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+func TestAuth_SetupRecovery(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
+
+	// настройка подтверждается текущим ключом аутентификации, вычисленным из пароля
+	base64Salt := "EPhQ3C8pTRYDMac+aCzFTA"
+	salt, err := base64.RawStdEncoding.DecodeString(base64Salt)
+	require.Nil(t, err, "Decoding salt from base64")
+	currentMasterKey, err := crypto.DeriveKey([]byte("t1estP5assword"), salt)
+	require.Nil(t, err, "Generate current master key")
+
+	var req dto.RecoverySetupRequest
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockStorage(ctrl)
+	storage.EXPECT().Key().Return(masterKey, nil)
+	storage.EXPECT().AccountKey().Return(accountKey, nil)
+	storage.EXPECT().Token().Return(testToken, nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().
+		PasswordParams(testToken).
+		Return(dto.PreLoginResponse{EncrSalt: base64Salt, AuthVersion: dto.AuthVersionKey}, nil)
+	client.EXPECT().
+		SetupRecovery(gomock.Any(), testToken).
+		DoAndReturn(func(data dto.RecoverySetupRequest, _ string) error {
+			req = data
+			return nil
+		})
+
+	code, err := NewAuth(client, storage).SetupRecovery("t1estP5assword")
+	require.Nil(t, err, "SetupRecovery error")
+	assert.Equal(t, testAuthKey(t, currentMasterKey), req.AuthKey, "Current auth key")
+	assert.Empty(t, req.Password, "Current password")
+	sent := req.Recovery
+
+	// код открывает отправленный на сервер ключ аккаунта, а ключ аутентификации выведен из него же
+	recoveryKey, err := crypto.DecodeRecoveryKey(code)
+	require.Nil(t, err, "Decode recovery code")
+	wrapKey, authKey, err := crypto.DeriveRecoveryKeys(recoveryKey)
+	require.Nil(t, err, "Derive recovery keys")
+
+	assert.Equal(t, base64.RawStdEncoding.EncodeToString(authKey), sent.AuthKey, "Auth key")
+	key, err := decryptKey(wrapKey, sent.MasterKey)
+	require.Nil(t, err, "Decrypt account key")
+	assert.Equal(t, accountKey, key, "Account key")
+	key, err = decryptKey(accountKey, sent.RecoveryKey)
+	require.Nil(t, err, "Decrypt recovery key")
+	assert.Equal(t, recoveryKey, key, "Recovery key")
+}
+
+func TestAuth_Recover(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
+	recoveryKey, err := crypto.GenerateRecoveryKey()
+	require.Nil(t, err, "Recovery key creation")
+	otherRecoveryKey, err := crypto.GenerateRecoveryKey()
+	require.Nil(t, err, "Other recovery key creation")
+
+	stored, err := wrapRecoveryKeys(recoveryKey, accountKey)
+	require.Nil(t, err, "Wrap recovery keys")
+	legacyStored, err := wrapRecoveryKeys(recoveryKey, masterKey)
+	require.Nil(t, err, "Wrap legacy recovery keys")
+	remote := testLegacyData(t, masterKey, []byte("remote data"))
+
+	code := crypto.EncodeRecoveryKey(recoveryKey)
+	// опечатка в первом символе кода
+	typo := "A" + code[1:]
+	if code[0] == 'A' {
+		typo = "B" + code[1:]
+	}
+	otpErr := fmt.Errorf("%w: %w", httpClient.ErrRecoveryFailed, httpClient.ErrOTPRequired)
+
+	tests := []struct {
+		name string
+		code string
+		// аккаунт не переведен на ключ аккаунта
+		legacy bool
+		// ответ сервера на запрос ключей
+		startErr error
+		// отправляется ли запрос сброса пароля
+		complete    bool
+		completeErr error
+		wantErr     error
+	}{
+		{
+			name:     "success",
+			code:     code,
+			complete: true,
+		},
+		{
+			name:     "success_legacy",
+			code:     code,
+			legacy:   true,
+			complete: true,
+		},
+		{
+			name:    "typo_in_code",
+			code:    typo,
+			wantErr: crypto.ErrInvalidRecoveryKey,
+		},
+		{
+			name:     "unknown_recovery_key",
+			code:     crypto.EncodeRecoveryKey(otherRecoveryKey),
+			startErr: fmt.Errorf("%w: %w", httpClient.ErrRecoveryFailed, httpClient.ErrInvalidRecoveryKey),
+			wantErr:  httpClient.ErrInvalidRecoveryKey,
+		},
+		{
+			name:        "otp_required",
+			code:        code,
+			complete:    true,
+			completeErr: otpErr,
+			wantErr:     httpClient.ErrOTPRequired,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				req           dto.RecoveryRequest
+				migration     dto.AccountKeyRequest
+				putKey        []byte
+				putAccountKey []byte
+			)
+
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockStorage(ctrl)
+			client := mocks.NewMockClient(ctrl)
+
+			if test.wantErr != crypto.ErrInvalidRecoveryKey {
+				client.EXPECT().
+					RecoveryStart(gomock.Any()).
+					DoAndReturn(func(data dto.RecoveryStartRequest) (dto.RecoveryStartResponse, error) {
+						assert.Equal(t, "user13", data.Login, "Login")
+						if test.startErr != nil {
+							return dto.RecoveryStartResponse{}, test.startErr
+						}
+						assert.Equal(t, stored.AuthKey, data.AuthKey, "Auth key")
+						if test.legacy {
+							return dto.RecoveryStartResponse{
+								MasterKey: legacyStored.MasterKey,
+								Keys:      []dto.SecretKey{{ID: 1, Key: remote.Key}},
+							}, nil
+						}
+						return dto.RecoveryStartResponse{AccountKey: stored.MasterKey}, nil
+					})
+			}
+			if test.complete {
+				client.EXPECT().
+					RecoveryComplete(gomock.Any()).
+					DoAndReturn(func(data dto.RecoveryRequest) (dto.AuthResponse, error) {
+						req = data
+						resp := dto.AuthResponse{Token: testToken, RefreshToken: "refresh", AccountKey: data.AccountKey}
+						return resp, test.completeErr
+					})
+			}
+			if test.wantErr == nil {
+				storage.EXPECT().Wipe().Return(nil)
+				storage.EXPECT().PutKey(gomock.Any()).DoAndReturn(func(key []byte) error {
+					putKey = key
+					return nil
+				})
+				storage.EXPECT().PutToken(testToken).Return(nil)
+				storage.EXPECT().PutRefreshToken("refresh").Return(nil)
+				storage.EXPECT().PutAccountKey(gomock.Any()).DoAndReturn(func(key []byte) error {
+					putAccountKey = key
+					return nil
+				})
+			}
+			if test.legacy && test.wantErr == nil {
+				// при входе после сброса пароля аккаунт переводится на ключ аккаунта
+				client.EXPECT().SecretKeys(testToken).DoAndReturn(func(string) ([]dto.SecretKey, error) {
+					return slices.Clone(req.Keys), nil
+				})
+				client.EXPECT().Recovery(testToken).DoAndReturn(func(string) (dto.RecoveryKey, error) {
+					return *req.Recovery, nil
+				})
+				client.EXPECT().
+					SetAccountKey(gomock.Any(), testToken).
+					DoAndReturn(func(data dto.AccountKeyRequest, _ string) error {
+						migration = data
+						return nil
+					})
+			}
+
+			authService := NewAuth(client, storage)
+			authService.device = "laptop"
+
+			err := authService.Recover("user13", test.code, "new1password", "")
+			assert.ErrorIs(t, err, test.wantErr, "Recover error")
+			if test.wantErr != nil {
+				return
+			}
+
+			salt, err := base64.RawStdEncoding.DecodeString(req.EncrSalt)
+			require.Nil(t, err, "Decode new salt")
+			require.NotNil(t, req.KDF, "KDF params")
+			assert.Equal(t, crypto.DefaultKDFParams(), crypto.KDFParams(*req.KDF), "KDF params")
+			newKey, err := crypto.DeriveKeyWithParams([]byte("new1password"), salt, crypto.KDFParams(*req.KDF))
+			require.Nil(t, err, "Derive new master key")
+			assert.Equal(t, newKey, putKey, "Stored master key")
+			assert.Equal(t, testAuthKey(t, newKey), req.NewAuthKey, "New auth key")
+			assert.Equal(t, stored.AuthKey, req.AuthKey, "Auth key")
+			assert.Equal(t, "laptop", req.Device, "Device")
+			wrapKey, _, err := crypto.DeriveRecoveryKeys(recoveryKey)
+			require.Nil(t, err, "Derive recovery keys")
+
+			if !test.legacy {
+				// ключ аккаунта только перешифровывается новым мастер ключом
+				assert.Empty(t, req.Keys, "Rewrapped keys")
+				assert.Nil(t, req.Recovery, "Recovery keys")
+				key, err := decryptKey(newKey, req.AccountKey)
+				require.Nil(t, err, "Decrypt account key with new master key")
+				assert.Equal(t, accountKey, key, "Account key")
+				assert.Equal(t, accountKey, putAccountKey, "Stored account key")
+				return
+			}
+
+			require.Len(t, req.Keys, 1, "Rewrapped keys")
+			data, err := deryptData(keyring{master: newKey}, nil, false, &dto.EncryptedData{Key: req.Keys[0].Key, Data: remote.Data})
+			require.Nil(t, err, "Decrypt remote data with new master key")
+			assert.Equal(t, "remote data", string(data), "Remote data")
+
+			// тот же ключ восстановления открывает новый мастер ключ
+			key, err := decryptKey(wrapKey, req.Recovery.MasterKey)
+			require.Nil(t, err, "Decrypt new master key with recovery key")
+			assert.Equal(t, newKey, key, "Recovered master key")
+			assert.Empty(t, req.Recovery.AuthKey, "Recovery auth key")
+
+			// после перевода ключ восстановления открывает новый ключ аккаунта
+			key, err = decryptKey(newKey, migration.AccountKey)
+			require.Nil(t, err, "Decrypt account key with new master key")
+			assert.Equal(t, key, putAccountKey, "Stored account key")
+			key, err = decryptKey(wrapKey, migration.Recovery.MasterKey)
+			require.Nil(t, err, "Decrypt account key with recovery key")
+			assert.Equal(t, putAccountKey, key, "Recovered account key")
+		})
+	}
+}
@@ -2,10 +2,17 @@
 package service
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 )
@@ -14,28 +21,74 @@ var (
 	ErrAuthorizationFailed    = errors.New("authorization failed")
 	ErrSecretEncryptionFailed = errors.New("failed to encrypt secret")
 	ErrSecretDecryptionFailed = errors.New("failed to decrypt secret")
+	ErrFileTooLarge           = errors.New("file too large")
+	// Данные секрета зашифрованы для другого секрета или секрета с другим типом,
+	// названием или метаданными, либо не привязаны к секрету, хотя должны:
+	// сервер подменил данные или описание секрета
+	ErrSecretTampered = errors.New("secret data does not match its id, type, name or metadata, possible tampering")
 )
 
+// Начало associated data, к которым привязаны данные секрета
+const (
+	// Секрет создан до появления UID
+	secretBindingContext = "gophkeeper secret v1"
+	// Секрет с UID
+	secretBindingContextUID = "gophkeeper secret v2"
+)
+
+// SyncResult результат синхронизации с сервером.
+type SyncResult struct {
+	// Количество изменений, отправленных на сервер
+	Pushed int
+	// Количество секретов, полученных с сервера
+	Pulled int
+	// Количество секретов, удаленных из локальной копии
+	Removed int
+	// ID секретов, локальные изменения которых не отправлены,
+	// потому что секрет изменили или удалили на другом клиенте.
+	// Конфликты сохраняются в локальной копии до вызова ResolveConflict.
+	Conflicts []uint64
+	// Локальные изменения, которые сервер отклонил из-за превышения квоты или неверных данных.
+	// Они остаются в локальной копии и отправляются повторно при следующей синхронизации.
+	Rejected []RejectedChange
+}
+
+// RejectedChange изменение, сделанное без связи с сервером и отклоненное сервером.
+type RejectedChange struct {
+	// ID секрета, 0 для нового секрета
+	ID     uint64
+	Name   string
+	Reason string
+}
+
 // Secret сервис для работы с секретными данными пользователя
 type Secret struct {
 	client  Client
 	storage Storage
+	// Пауза перед повтором передачи при обрыве связи
+	retryDelay time.Duration
 }
 
 func NewSecret(c Client, s Storage) *Secret {
-	return &Secret{client: c, storage: s}
+	return &Secret{client: c, storage: s, retryDelay: time.Second}
 }
 
 // Upload отправляет данные на сервер.
 // Принимает частино заполненный dto.SecretRequest и данные,
-// которые нужно зашифровать.
+// которые нужно зашифровать. Если сервер недоступен, сохраняет секрет
+// в локальную копию для отправки при синхронизации и возвращает ErrSavedLocally.
 func (s *Secret) Upload(secret dto.SecretRequest, data []byte) error {
-	masterKey, err := s.storage.Key()
+	keys, err := loadKeyring(s.storage)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
 	}
 
-	secret.EncrData, err = encryptData(masterKey, data)
+	if secret.UID == "" {
+		if secret.UID, err = newSecretUID(); err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+		}
+	}
+	secret.EncrData, err = encryptData(keys, secretBinding(secret.UID, secret.DataType, secret.Name, secret.Meta), data)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
 	}
@@ -45,15 +98,24 @@ func (s *Secret) Upload(secret dto.SecretRequest, data []byte) error {
 		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
 	}
 
-	return s.client.Upload(secret, token)
+	err = s.client.Upload(secret, token)
+	if !isNetworkError(err) {
+		return err
+	}
+
+	return s.saveLocally(keys, func(v *vault) {
+		v.Created = append(v.Created, secret)
+	})
 }
 
 // GetSecretAndInfo получает секрет пользователя с сервера по id,
-// возвращает расшиврованные данные в виде []byte и  информацию о секрете
+// возвращает расшиврованные данные в виде []byte и  информацию о секрете.
+// Для секрета, загруженного по частям, данные не возвращаются (info.Chunks > 0).
+// Если сервер недоступен, берет секрет из локальной копии.
 func (s *Secret) GetSecretAndInfo(id uint64) ([]byte, dto.SecretInfo, error) {
 	var info dto.SecretInfo
 
-	masterKey, err := s.storage.Key()
+	keys, err := loadKeyring(s.storage)
 	if err != nil {
 		return nil, info, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
 	}
@@ -62,28 +124,122 @@ func (s *Secret) GetSecretAndInfo(id uint64) ([]byte, dto.SecretInfo, error) {
 		return nil, info, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
 	}
 
-	resp, err := s.client.Retrieve(id, token)
+	resp, err := s.current(id, keys, token)
 	if err != nil {
 		return nil, info, err
 	}
 
-	secret, err := deryptData(masterKey, &resp.EncrData)
+	// Данные, загруженные по частям, получают через DownloadFile
+	if resp.Chunks > 0 {
+		return nil, secretInfo(resp), nil
+	}
+
+	secret, err := decryptResponse(keys, resp)
 	if err != nil {
 		return nil, info, fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
 	}
 
-	info = dto.SecretInfo{
-		ID:       resp.ID,
-		DataType: resp.DataType,
-		Name:     resp.Name,
-		Meta:     resp.Meta,
-		Created:  resp.Created,
+	return secret, secretInfo(resp), nil
+}
+
+// Update изменяет секрет пользователя на сервере по id.
+// Принимает частино заполненный dto.SecretUpdateRequest и данные,
+// которые шифруются новым ключом DEK. Если data равно nil, данные секрета не меняются,
+// но если изменились название или метаданные, перешифровываются, потому что привязаны к ним.
+// Секрету, созданному до появления UID, присваивается UID, и данные перешифровываются.
+// Данные, загруженные по частям, перешифровываются по одной части, для этого нужна связь с сервером.
+// Если сервер недоступен, сохраняет изменение в локальную копию
+// для отправки при синхронизации и возвращает ErrSavedLocally.
+func (s *Secret) Update(id uint64, secret dto.SecretUpdateRequest, data []byte) error {
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := s.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	// Тип секрета в запросе не передается, берем его из текущей версии
+	current, err := s.current(id, keys, token)
+	if err != nil {
+		return err
+	}
+	uid := current.UID
+	if uid == "" {
+		if uid, err = newSecretUID(); err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+		}
+	}
+	binding := secretBinding(uid, current.DataType, secret.Name, secret.Meta)
+	rebind := data == nil && !bytes.Equal(binding, responseBinding(current))
+
+	if rebind && current.Chunks > 0 {
+		return s.rebindStream(id, current, secret, uid, keys, token)
+	}
+	if rebind {
+		data, err = decryptResponse(keys, current)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+		}
+	}
+
+	if data != nil {
+		secret.UID = uid
+		secret.EncrData, err = encryptData(keys, binding, data)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+		}
+	}
+
+	err = s.client.Update(id, secret, token)
+	if !isNetworkError(err) {
+		return err
+	}
+
+	return s.saveLocally(keys, func(v *vault) {
+		v.Updated[id] = secret
+		// Версию не трогаем, она нужна для проверки конфликта при синхронизации
+		if cached, ok := v.Secrets[id]; ok {
+			cached.Name = secret.Name
+			cached.Meta = secret.Meta
+			if data != nil {
+				cached.UID = secret.UID
+				cached.EncrData = secret.EncrData
+			}
+			v.Secrets[id] = cached
+		}
+	})
+}
+
+// Delete удаляет секрет пользователя на сервере по id.
+// Если сервер недоступен, удаляет секрет из локальной копии
+// и возвращает ErrSavedLocally, на сервере секрет будет удален при синхронизации.
+func (s *Secret) Delete(id uint64) error {
+	token, err := s.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	err = s.client.Delete(id, token)
+	if !isNetworkError(err) {
+		return err
 	}
 
-	return secret, info, nil
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	return s.saveLocally(keys, func(v *vault) {
+		v.Deleted = append(v.Deleted, id)
+		delete(v.Secrets, id)
+		delete(v.Updated, id)
+	})
 }
 
 // InfoList получает информацию о всех секретах пользователя с сервера.
+// Если сервер недоступен, берет информацию из локальной копии.
 func (s *Secret) InfoList() ([]dto.SecretInfo, error) {
 	token, err := s.storage.Token()
 	if err != nil {
@@ -91,13 +247,277 @@ func (s *Secret) InfoList() ([]dto.SecretInfo, error) {
 	}
 
 	list, err := s.client.InfoList(token)
-	if err != nil {
+	if err == nil {
+		return list, nil
+	}
+	if !isNetworkError(err) {
+		return nil, err
+	}
+
+	keys, kErr := loadKeyring(s.storage)
+	if kErr != nil {
+		return nil, err
+	}
+	v, vErr := s.loadVault(keys)
+	if vErr != nil {
 		return nil, err
 	}
+
+	list = make([]dto.SecretInfo, 0, len(v.Secrets))
+	for _, secret := range v.Secrets {
+		list = append(list, secretInfo(secret))
+	}
+	slices.SortFunc(list, func(a, b dto.SecretInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
 	return list, nil
 }
 
-func encryptData(masterKey, payload []byte) (dto.EncryptedData, error) {
+// Usage получает с сервера занятый пользователем объем хранилища и его квоты.
+func (s *Secret) Usage() (dto.Usage, error) {
+	token, err := s.storage.Token()
+	if err != nil {
+		return dto.Usage{}, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	return s.client.Usage(token)
+}
+
+// Sync синхронизирует локальную копию секретов с сервером:
+// отправляет изменения, сделанные без связи с сервером,
+// и получает секреты, измененные на сервере после последней синхронизации.
+func (s *Secret) Sync() (SyncResult, error) {
+	var result SyncResult
+
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := s.storage.Token()
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return result, err
+	}
+
+	// Сохраняем копию даже при ошибке, чтобы не отправлять повторно уже отправленные изменения
+	err = s.push(v, token, &result)
+	if err == nil {
+		err = s.pull(v, token, &result)
+	}
+	if saveErr := s.saveVault(keys, v); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	return result, err
+}
+
+// push отправляет на сервер изменения, сделанные без связи с сервером.
+// Изменения, отклоненные сервером, откладываются, и отправка продолжается,
+// прерывается она только ошибками связи и авторизации.
+func (s *Secret) push(v *vault, token string, result *SyncResult) error {
+	// Отклоненные ранее секреты отправляются повторно после новых: квота могла освободиться
+	rejected := v.Rejected
+	v.Rejected = nil
+	restore := func() {
+		v.Rejected = append(v.Rejected, rejected...)
+	}
+
+	for len(v.Created) > 0 {
+		if err := s.pushCreated(v, v.Created[0], token, result); err != nil {
+			restore()
+			return err
+		}
+		v.Created = v.Created[1:]
+	}
+	for len(rejected) > 0 {
+		if err := s.pushCreated(v, rejected[0].Secret, token, result); err != nil {
+			restore()
+			return err
+		}
+		rejected = rejected[1:]
+	}
+
+	for id, secret := range v.Updated {
+		err := s.client.Update(id, secret, token)
+		switch {
+		case err == nil:
+			result.Pushed++
+		case errors.Is(err, httpClient.ErrSecretConflict) || errors.Is(err, httpClient.ErrSecretNotFound):
+			// Секрет изменили или удалили на другом клиенте, сохраняем изменение до разрешения конфликта
+			v.Conflicts[id] = conflict{DataType: v.Secrets[id].DataType, Local: secret}
+			result.Conflicts = append(result.Conflicts, id)
+		case isRejected(err):
+			// Изменение остается в локальной копии до следующей синхронизации
+			result.Rejected = append(result.Rejected, RejectedChange{ID: id, Name: secret.Name, Reason: err.Error()})
+			continue
+		default:
+			return err
+		}
+		delete(v.Updated, id)
+	}
+	slices.Sort(result.Conflicts)
+	slices.SortStableFunc(result.Rejected, func(a, b RejectedChange) int { return cmp.Compare(a.ID, b.ID) })
+
+	for len(v.Deleted) > 0 {
+		err := s.client.Delete(v.Deleted[0], token)
+		if err != nil && !errors.Is(err, httpClient.ErrSecretNotFound) {
+			return err
+		}
+		v.Deleted = v.Deleted[1:]
+		result.Pushed++
+	}
+
+	return nil
+}
+
+// pushCreated отправляет на сервер секрет, созданный без связи с сервером.
+// Если сервер отклонил секрет, откладывает его в v.Rejected и возвращает nil.
+func (s *Secret) pushCreated(v *vault, secret dto.SecretRequest, token string, result *SyncResult) error {
+	err := s.client.Upload(secret, token)
+	if err == nil {
+		result.Pushed++
+		return nil
+	}
+	if !isRejected(err) {
+		return err
+	}
+
+	v.Rejected = append(v.Rejected, rejectedSecret{Secret: secret, Reason: err.Error()})
+	result.Rejected = append(result.Rejected, RejectedChange{Name: secret.Name, Reason: err.Error()})
+	return nil
+}
+
+// isRejected проверяет, что сервер отклонил изменение из-за превышения квоты или неверных данных,
+// а не из-за ошибки связи или авторизации.
+func isRejected(err error) bool {
+	return errors.Is(err, httpClient.ErrQuotaExceeded) || errors.Is(err, httpClient.ErrSecretInvalid)
+}
+
+// pull получает с сервера секреты, измененные после последней синхронизации,
+// и удаляет из локальной копии секреты, удаленные на сервере.
+func (s *Secret) pull(v *vault, token string, result *SyncResult) error {
+	changes, err := s.client.Changes(v.Revision, token)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[uint64]bool, len(changes.Changed))
+	for _, info := range changes.Changed {
+		changed[info.ID] = true
+
+		resp, err := s.client.Retrieve(info.ID, token)
+		if err != nil {
+			// Секрет удалили после получения ленты, запись об удалении придет при следующей синхронизации
+			if errors.Is(err, httpClient.ErrSecretNotFound) {
+				continue
+			}
+			return err
+		}
+		v.Secrets[info.ID] = resp
+		result.Pulled++
+	}
+
+	for _, id := range changes.Deleted {
+		if _, ok := v.Secrets[id]; ok {
+			delete(v.Secrets, id)
+			result.Removed++
+		}
+	}
+
+	// При первой синхронизации лента содержит все секреты пользователя,
+	// остальные секреты в локальной копии считаем удаленными
+	if v.Revision == 0 {
+		for id := range v.Secrets {
+			if !changed[id] {
+				delete(v.Secrets, id)
+				result.Removed++
+			}
+		}
+	}
+
+	v.Revision = changes.Revision
+	return nil
+}
+
+// current получает секрет id с сервера, а если сервер недоступен - из локальной копии.
+func (s *Secret) current(id uint64, keys keyring, token string) (dto.SecretResponse, error) {
+	resp, err := s.client.Retrieve(id, token)
+	if !isNetworkError(err) {
+		return resp, err
+	}
+
+	v, vErr := s.loadVault(keys)
+	if vErr != nil {
+		return resp, err
+	}
+	cached, ok := v.Secrets[id]
+	if !ok {
+		return resp, err
+	}
+
+	return cached, nil
+}
+
+// secretBinding кодирует UID, тип, название и метаданные секрета, к которым привязываются
+// его данные как associated data AES-GCM. Поэтому данные, перенесенные сервером
+// в другой секрет, или измененные тип, название и метаданные обнаруживаются при расшифровке.
+// Пустой uid у секретов, созданных до его появления.
+func secretBinding(uid, dataType, name string, meta []dto.MetaData) []byte {
+	b := []byte(secretBindingContext)
+	if uid != "" {
+		b = appendBindingField([]byte(secretBindingContextUID), uid)
+	}
+	b = appendBindingField(b, dataType)
+	b = appendBindingField(b, name)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(meta)))
+	for _, m := range meta {
+		b = appendBindingField(b, m.Name)
+		b = appendBindingField(b, m.Value)
+	}
+	return b
+}
+
+// responseBinding возвращает associated data для данных секрета из ответа сервера.
+func responseBinding(resp dto.SecretResponse) []byte {
+	return secretBinding(resp.UID, resp.DataType, resp.Name, resp.Meta)
+}
+
+// streamBinding возвращает ad потока частей данных секрета, см. crypto.NewStreamCipher.
+// Части секретов, созданных до появления UID, не привязаны к описанию секрета.
+func streamBinding(uid, dataType, name string, meta []dto.MetaData) []byte {
+	if uid == "" {
+		return nil
+	}
+	return secretBinding(uid, dataType, name, meta)
+}
+
+// newSecretUID генерирует UID секрета, случайный UUID версии 4.
+func newSecretUID() (string, error) {
+	b, err := crypto.GenerateRandomBytes(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret uid: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// appendBindingField дописывает к b строку s с ее длиной, чтобы границы полей были однозначны.
+func appendBindingField(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// encryptData шифрует payload новым ключом DEK, а его ключом аккаунта.
+// Данные привязываются к описанию секрета binding, см. secretBinding.
+func encryptData(keys keyring, binding, payload []byte) (dto.EncryptedData, error) {
 	var result dto.EncryptedData
 
 	key, err := crypto.GenerateRandomBytes(32)
@@ -105,39 +525,85 @@ func encryptData(masterKey, payload []byte) (dto.EncryptedData, error) {
 		return result, fmt.Errorf("failed to generate DEK: %w", err)
 	}
 
-	result.Data, err = crypto.EncryptAES(key, payload)
+	result.Data, err = crypto.EncryptAESWithAD(key, payload, binding)
 	if err != nil {
 		return result, fmt.Errorf("failed to encrypt payload: %w", err)
 	}
 
-	encryptedKey, err := crypto.EncryptAES(masterKey, key)
+	result.Key, result.Version, err = keys.wrap(key)
 	if err != nil {
-		return result, fmt.Errorf("failed to encrypt DEK: %w", err)
+		return result, err
 	}
-	result.Key = base64.RawStdEncoding.EncodeToString(encryptedKey)
 
 	return result, nil
 }
 
-func deryptData(masterKey []byte, data *dto.EncryptedData) ([]byte, error) {
+// decryptResponse расшифровывает данные секрета из ответа сервера, см. deryptData.
+func decryptResponse(keys keyring, resp dto.SecretResponse) ([]byte, error) {
+	return deryptData(keys, responseBinding(resp), resp.UID != "", &resp.EncrData)
+}
+
+// deryptData расшифровывает данные, ключ DEK расшифровывается мастер ключом
+// или ключом аккаунта в зависимости от версии схемы ключей.
+// Если данные привязаны к другому описанию секрета, чем binding, возвращает ErrSecretTampered.
+// Данные секрета с UID (bound) всегда привязаны, поэтому непривязанные данные тоже
+// считаются подменой. Данные секретов без UID могли быть зашифрованы
+// до появления привязки, такие данные расшифровываются без проверки.
+func deryptData(keys keyring, binding []byte, bound bool, data *dto.EncryptedData) ([]byte, error) {
 	if data == nil {
 		return nil, fmt.Errorf("the server returned invalid data: EncryptedData is nil")
 	}
 
-	encryptedKey, err := base64.RawStdEncoding.DecodeString(data.Key)
+	key, err := keys.unwrap(data.Version, data.Key)
 	if err != nil {
-		return nil, fmt.Errorf("the server returned invalid data: bad key")
+		return nil, err
 	}
 
-	key, err := crypto.DecryptAES(masterKey, encryptedKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt DEK (invalid master key?): %w", err)
+	decrypt := crypto.DecryptAESWithAD
+	if bound {
+		decrypt = crypto.DecryptAESWithADStrict
 	}
 
-	decryptedData, err := crypto.DecryptAES(key, data.Data)
+	decryptedData, err := decrypt(key, data.Data, binding)
 	if err != nil {
+		if errors.Is(err, crypto.ErrEnvelopeUnbound) {
+			return nil, ErrSecretTampered
+		}
+		// Ключ DEK подошел, значит данные целы, но привязаны к другому секрету
+		if h, hErr := crypto.ParseHeader(data.Data); hErr == nil && h.Version == crypto.EnvelopeVersion2 {
+			return nil, ErrSecretTampered
+		}
 		return nil, fmt.Errorf("failed to decrypt data: %w", err)
 	}
 
 	return decryptedData, nil
 }
+
+// decryptKey расшифровывает ключом masterKey ключ, закодированный base64.
+func decryptKey(masterKey []byte, encodedKey string) ([]byte, error) {
+	encryptedKey, err := base64.RawStdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("the server returned invalid data: bad key")
+	}
+
+	key, err := crypto.DecryptAES(masterKey, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt DEK (invalid master key?): %w", err)
+	}
+
+	return key, nil
+}
+
+func secretInfo(resp dto.SecretResponse) dto.SecretInfo {
+	return dto.SecretInfo{
+		ID:       resp.ID,
+		DataType: resp.DataType,
+		Name:     resp.Name,
+		Meta:     resp.Meta,
+		Created:  resp.Created,
+		Updated:  resp.Updated,
+		Revision: resp.Revision,
+		Version:  resp.Version,
+		Chunks:   resp.Chunks,
+	}
+}
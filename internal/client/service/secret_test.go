@@ -15,6 +15,9 @@ import (
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 )
 
+// testSecretUID UID секрета в тестах
+const testSecretUID = "3f2b8c1e-5a4d-4e6f-9b7a-0c1d2e3f4a5b"
+
 func TestSecret_Upload(t *testing.T) {
 	rawSecret := dto.SecretRequest{Name: "test", DataType: dto.SecretTypeText}
 	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
@@ -24,6 +27,8 @@ func TestSecret_Upload(t *testing.T) {
 	require.Nil(t, err, "Decoding salt from base64")
 	masterKey, err := crypto.DeriveKey([]byte("password13"), salt)
 	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
 
 	tests := []struct {
 		name    string
@@ -42,6 +47,8 @@ func TestSecret_Upload(t *testing.T) {
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
 					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return(token, nil)
 				return storage
@@ -51,7 +58,16 @@ func TestSecret_Upload(t *testing.T) {
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
 					Upload(gomock.All(), token).
-					Return(nil)
+					DoAndReturn(func(secret dto.SecretRequest, token string) error {
+						require.Nil(t, dto.ValidateSecretUID(secret.UID), "Generated UID")
+						require.NotEmpty(t, secret.UID, "Generated UID")
+						binding := secretBinding(secret.UID, secret.DataType, secret.Name, secret.Meta)
+						keys := keyring{master: masterKey, account: accountKey}
+						decrypted, err := deryptData(keys, binding, true, &secret.EncrData)
+						require.Nil(t, err, "Decrypt uploaded data")
+						assert.Equal(t, "data to crypt", string(decrypted), "Uploaded data")
+						return nil
+					})
 				return client
 			},
 		},
@@ -73,14 +89,16 @@ func TestSecret_Upload(t *testing.T) {
 			wantErr: "authorization failed: any error",
 		},
 		{
-			name: "bad_master_key",
+			name: "bad_account_key",
 			dto:  rawSecret,
 			data: []byte("data to crypt"),
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
-					Key().Return([]byte{}, nil)
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return([]byte{}, nil)
 				return storage
 			},
 			cSetup: func(t *testing.T) Client {
@@ -99,6 +117,8 @@ func TestSecret_Upload(t *testing.T) {
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
 					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return("", fmt.Errorf("any error"))
 				return storage
@@ -136,10 +156,15 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 	require.Nil(t, err, "Decoding salt from base64")
 	masterKey, err := crypto.DeriveKey([]byte("password13"), salt)
 	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
 
 	secret := []byte("some secret text")
-	ecnrData, err := encryptData(masterKey, secret)
+	keys := keyring{master: masterKey, account: accountKey}
+	ecnrData, err := encryptData(keys, secretBinding(testSecretUID, dto.SecretTypeText, "note", nil), secret)
 	require.Nil(t, err, "Data ecryption")
+	legacyData := testLegacyData(t, masterKey, secret)
+	remote := dto.SecretResponse{ID: 13, UID: testSecretUID, DataType: dto.SecretTypeText, Name: "note", EncrData: ecnrData}
 
 	type want struct {
 		secret []byte
@@ -162,6 +187,206 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
 					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(remote, nil)
+				return client
+			},
+			want: want{
+				secret: secret,
+				info:   dto.SecretInfo{ID: 13, DataType: dto.SecretTypeText, Name: "note"},
+			},
+		},
+		{
+			// сервер отдал данные другого секрета того же пользователя
+			name:     "swapped_data",
+			secretID: 14,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(14), token).
+					Return(dto.SecretResponse{ID: 14, DataType: dto.SecretTypeText, Name: "bank", EncrData: ecnrData}, nil)
+				return client
+			},
+			want: want{
+				err: ErrSecretTampered,
+			},
+		},
+		{
+			name:     "changed_type",
+			secretID: 13,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				changed := remote
+				changed.DataType = dto.SecretTypeCredentials
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(changed, nil)
+				return client
+			},
+			want: want{
+				err: ErrSecretTampered,
+			},
+		},
+		{
+			name:     "changed_meta",
+			secretID: 13,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				changed := remote
+				changed.Meta = []dto.MetaData{{Name: "url", Value: "https://example.com"}}
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(changed, nil)
+				return client
+			},
+			want: want{
+				err: ErrSecretTampered,
+			},
+		},
+		{
+			// сервер отдал данные другого секрета с тем же описанием
+			name:     "swapped_same_description",
+			secretID: 14,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				changed := remote
+				changed.ID = 14
+				changed.UID = "9c0e7a52-6d1b-4f38-a2e4-5b7c8d9e0f12"
+				client.EXPECT().
+					Retrieve(uint64(14), token).
+					Return(changed, nil)
+				return client
+			},
+			want: want{
+				err: ErrSecretTampered,
+			},
+		},
+		{
+			// сервер убрал UID, чтобы данные расшифровывались как у старого секрета
+			name:     "stripped_uid",
+			secretID: 13,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				changed := remote
+				changed.UID = ""
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(changed, nil)
+				return client
+			},
+			want: want{
+				err: ErrSecretTampered,
+			},
+		},
+		{
+			// сервер подменил данные секрета с UID данными без привязки
+			name:     "unbound_data",
+			secretID: 13,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				changed := remote
+				changed.EncrData = legacyData
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(changed, nil)
+				return client
+			},
+			want: want{
+				err: ErrSecretTampered,
+			},
+		},
+		{
+			name:     "success_legacy_key",
+			secretID: 13,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return(token, nil)
 				return storage
@@ -171,7 +396,7 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
 					Retrieve(uint64(13), token).
-					Return(dto.SecretResponse{EncrData: ecnrData}, nil)
+					Return(dto.SecretResponse{EncrData: legacyData}, nil)
 				return client
 			},
 			want: want{
@@ -179,6 +404,32 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 				info:   dto.SecretInfo{},
 			},
 		},
+		{
+			name:     "success_chunked",
+			secretID: 13,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(dto.SecretResponse{ID: 13, EncrData: dto.EncryptedData{Key: ecnrData.Key}, Chunks: 3}, nil)
+				return client
+			},
+			want: want{
+				info: dto.SecretInfo{ID: 13, Chunks: 3},
+			},
+		},
 		{
 			name:     "without_token",
 			secretID: 13,
@@ -187,6 +438,8 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
 					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return("", fmt.Errorf("any error"))
 				return storage
@@ -218,13 +471,15 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 			},
 		},
 		{
-			name:     "bad_master_key",
+			name:     "bad_account_key",
 			secretID: 13,
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
-					Key().Return([]byte{}, nil)
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return([]byte{}, nil)
 				storage.EXPECT().
 					Token().Return(token, nil)
 				return storage
@@ -234,7 +489,7 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
 					Retrieve(uint64(13), token).
-					Return(dto.SecretResponse{EncrData: ecnrData}, nil)
+					Return(remote, nil)
 				return client
 			},
 			want: want{
@@ -248,7 +503,9 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
-					Key().Return([]byte{}, nil)
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return(token, nil)
 				return storage
@@ -285,27 +542,72 @@ func TestSecret_GetSecretAndInfo(t *testing.T) {
 	}
 }
 
-func TestSecret_InfoList(t *testing.T) {
+func TestSecret_Update(t *testing.T) {
+	rawSecret := dto.SecretUpdateRequest{Name: "test", Version: 3}
+	data := []byte("data to crypt")
 	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
 		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
-	infoList := []dto.SecretInfo{{}}
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	accountKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Account key creation")
+	keys := keyring{master: masterKey, account: accountKey}
 
-	type want struct {
-		list []dto.SecretInfo
-		err  error
+	// текущая версия секрета на сервере до переименования
+	oldData, err := encryptData(keys, secretBinding(testSecretUID, dto.SecretTypeText, "old", nil), []byte("old data"))
+	require.Nil(t, err, "Encrypt current data")
+	current := dto.SecretResponse{
+		ID:       13,
+		UID:      testSecretUID,
+		DataType: dto.SecretTypeText,
+		Name:     "old",
+		Version:  3,
+		EncrData: oldData,
+	}
+	// данные привязаны к новому названию
+	binding := secretBinding(testSecretUID, dto.SecretTypeText, "test", nil)
+
+	// секрет, созданный до появления UID
+	legacyData, err := encryptData(keys, secretBinding("", dto.SecretTypeText, "test", nil), []byte("legacy data"))
+	require.Nil(t, err, "Encrypt legacy data")
+	legacy := dto.SecretResponse{ID: 13, DataType: dto.SecretTypeText, Name: "test", Version: 3, EncrData: legacyData}
+
+	// файл, загруженный по частям
+	streamKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Stream key creation")
+	streamDEK, streamKeyVersion, err := keys.wrap(streamKey)
+	require.Nil(t, err, "Wrap stream key")
+	file := dto.SecretResponse{
+		ID:       13,
+		UID:      testSecretUID,
+		DataType: dto.SecretTypeFile,
+		Name:     "old",
+		Version:  3,
+		EncrData: dto.EncryptedData{Key: streamDEK, Version: streamKeyVersion},
+		Chunks:   2,
 	}
+	fileCipher, err := crypto.NewStreamCipher(streamKey, streamBinding(testSecretUID, dto.SecretTypeFile, "old", nil))
+	require.Nil(t, err, "Stream cipher creation")
+	fileChunks := [][]byte{fileCipher.Seal(0, false, []byte("first")), fileCipher.Seal(1, true, []byte("last"))}
 
 	tests := []struct {
-		name   string
-		sSetup func(t *testing.T) Storage
-		cSetup func(t *testing.T) Client
-		want   want
+		name string
+		// nil - меняются только название и метаданные
+		data    []byte
+		sSetup  func(t *testing.T) Storage
+		cSetup  func(t *testing.T) Client
+		wantErr error
 	}{
 		{
 			name: "success",
+			data: data,
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return(token, nil)
 				return storage
@@ -314,37 +616,63 @@ func TestSecret_InfoList(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					InfoList(token).
-					Return(infoList, nil)
+					Retrieve(uint64(13), token).
+					Return(current, nil)
+				client.EXPECT().
+					Update(uint64(13), gomock.All(), token).
+					DoAndReturn(func(id uint64, secret dto.SecretUpdateRequest, token string) error {
+						assert.Equal(t, uint64(3), secret.Version, "Version passed to server")
+						assert.Equal(t, testSecretUID, secret.UID, "UID passed to server")
+						assert.Equal(t, dto.KeyVersionAccount, secret.EncrData.Version, "Key version")
+						decrypted, err := deryptData(keyring{account: accountKey}, binding, true, &secret.EncrData)
+						require.Nil(t, err, "Decrypt updated data")
+						assert.Equal(t, data, decrypted, "Updated data")
+						return nil
+					})
 				return client
 			},
-			want: want{
-				list: infoList,
-			},
 		},
 		{
-			name: "without_token",
+			name: "rename_rebinds_data",
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
 				storage.EXPECT().
-					Token().Return("", fmt.Errorf("any error"))
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
 				return storage
 			},
 			cSetup: func(t *testing.T) Client {
 				ctrl := gomock.NewController(t)
-				return mocks.NewMockClient(ctrl)
-			},
-			want: want{
-				err: ErrAuthorizationFailed,
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(current, nil)
+				client.EXPECT().
+					Update(uint64(13), gomock.All(), token).
+					DoAndReturn(func(id uint64, secret dto.SecretUpdateRequest, token string) error {
+						_, err := deryptData(keys, responseBinding(current), true, &secret.EncrData)
+						assert.ErrorIs(t, err, ErrSecretTampered, "Data bound to old name")
+						decrypted, err := deryptData(keys, binding, true, &secret.EncrData)
+						require.Nil(t, err, "Decrypt rebound data")
+						assert.Equal(t, "old data", string(decrypted), "Rebound data")
+						return nil
+					})
+				return client
 			},
 		},
-
 		{
-			name: "client_err",
+			name: "legacy_secret_gets_uid",
 			sSetup: func(t *testing.T) Storage {
 				ctrl := gomock.NewController(t)
 				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
 				storage.EXPECT().
 					Token().Return(token, nil)
 				return storage
@@ -353,29 +681,517 @@ func TestSecret_InfoList(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				client := mocks.NewMockClient(ctrl)
 				client.EXPECT().
-					InfoList(token).
-					Return(nil, httpClient.ErrSecretInfoListFailed)
+					Retrieve(uint64(13), token).
+					Return(legacy, nil)
+				client.EXPECT().
+					Update(uint64(13), gomock.All(), token).
+					DoAndReturn(func(id uint64, secret dto.SecretUpdateRequest, token string) error {
+						require.Nil(t, dto.ValidateSecretUID(secret.UID), "UID passed to server")
+						require.NotEmpty(t, secret.UID, "UID passed to server")
+						uidBinding := secretBinding(secret.UID, dto.SecretTypeText, "test", nil)
+						decrypted, err := deryptData(keys, uidBinding, true, &secret.EncrData)
+						require.Nil(t, err, "Decrypt rebound data")
+						assert.Equal(t, "legacy data", string(decrypted), "Rebound data")
+						return nil
+					})
 				return client
 			},
-			want: want{
-				err: httpClient.ErrSecretInfoListFailed,
-			},
 		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			client := test.cSetup(t)
-			storage := test.sSetup(t)
-
-			secretService := NewSecret(client, storage)
-			list, err := secretService.InfoList()
-
-			assert.ErrorIs(t, err, test.want.err, "Get secret and info error")
-			if err != nil {
-				return
-			}
-			assert.Equal(t, test.want.list, list, "Get secret list")
+		{
+			name: "rename_rebinds_chunks",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				var cipher *crypto.StreamCipher
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(file, nil)
+				client.EXPECT().
+					CreateUpload(gomock.All(), token).
+					DoAndReturn(func(upload dto.UploadRequest, token string) (dto.UploadResponse, error) {
+						assert.Equal(t, uint64(13), upload.SecretID, "Replaced secret")
+						assert.Equal(t, uint64(3), upload.Version, "Version passed to server")
+						assert.Equal(t, testSecretUID, upload.UID, "UID passed to server")
+						assert.Equal(t, "test", upload.Name, "New name")
+						key, err := keys.unwrap(upload.KeyVersion, upload.Key)
+						require.Nil(t, err, "Unwrap new stream key")
+						assert.NotEqual(t, streamKey, key, "New stream key")
+						cipher, err = crypto.NewStreamCipher(key, streamBinding(testSecretUID, dto.SecretTypeFile, "test", nil))
+						require.Nil(t, err, "Stream cipher creation")
+						return dto.UploadResponse{ID: "upload"}, nil
+					})
+				for n, chunk := range fileChunks {
+					client.EXPECT().
+						RetrieveChunk(uint64(13), uint32(n), token).
+						Return(chunk, nil)
+				}
+				client.EXPECT().
+					UploadChunk("upload", gomock.All(), gomock.All(), token).
+					DoAndReturn(func(id string, n uint32, chunk []byte, token string) error {
+						_, err := cipher.Open(uint64(n), n == 1, chunk)
+						assert.Nil(t, err, "Chunk bound to new name")
+						return nil
+					}).
+					Times(2)
+				client.EXPECT().
+					CommitUpload("upload", dto.UploadCommitRequest{Chunks: 2}, token).
+					Return(nil)
+				return client
+			},
+		},
+		{
+			name: "same_description_without_data",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				same := current
+				same.Name = "test"
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(same, nil)
+				client.EXPECT().
+					Update(uint64(13), rawSecret, token).
+					Return(nil)
+				return client
+			},
+		},
+		{
+			name: "tampered_current",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				tampered := current
+				tampered.Name = "bank"
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(tampered, nil)
+				return client
+			},
+			wantErr: ErrSecretTampered,
+		},
+		{
+			name: "without_master_key",
+			data: data,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(nil, fmt.Errorf("any error"))
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+		{
+			name: "bad_account_key",
+			data: data,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return([]byte{}, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(current, nil)
+				return client
+			},
+			wantErr: ErrSecretEncryptionFailed,
+		},
+		{
+			name: "without_token",
+			data: data,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return("", fmt.Errorf("any error"))
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+		{
+			name: "not_found",
+			data: data,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(
+						dto.SecretResponse{},
+						fmt.Errorf("%w: %w", httpClient.ErrSecretRetrieveFailed, httpClient.ErrSecretNotFound),
+					)
+				return client
+			},
+			wantErr: httpClient.ErrSecretNotFound,
+		},
+		{
+			name: "conflict",
+			data: data,
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Key().Return(masterKey, nil)
+				storage.EXPECT().
+					AccountKey().Return(accountKey, nil)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Retrieve(uint64(13), token).
+					Return(current, nil)
+				client.EXPECT().
+					Update(uint64(13), gomock.All(), token).
+					Return(fmt.Errorf("%w: %w", httpClient.ErrSecretUpdateFailed, httpClient.ErrSecretConflict))
+				return client
+			},
+			wantErr: httpClient.ErrSecretConflict,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := test.cSetup(t)
+			storage := test.sSetup(t)
+
+			secretService := NewSecret(client, storage)
+			err := secretService.Update(13, rawSecret, test.data)
+
+			assert.ErrorIs(t, err, test.wantErr, "Update error")
+		})
+	}
+}
+
+func TestSecret_Delete(t *testing.T) {
+	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
+		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
+
+	tests := []struct {
+		name    string
+		sSetup  func(t *testing.T) Storage
+		cSetup  func(t *testing.T) Client
+		wantErr error
+	}{
+		{
+			name: "success",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Delete(uint64(13), token).
+					Return(nil)
+				return client
+			},
+		},
+		{
+			name: "without_token",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return("", fmt.Errorf("any error"))
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			wantErr: ErrAuthorizationFailed,
+		},
+		{
+			name: "client_err",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Delete(uint64(13), token).
+					Return(httpClient.ErrSecretDeleteFailed)
+				return client
+			},
+			wantErr: httpClient.ErrSecretDeleteFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := test.cSetup(t)
+			storage := test.sSetup(t)
+
+			secretService := NewSecret(client, storage)
+			err := secretService.Delete(13)
+
+			assert.ErrorIs(t, err, test.wantErr, "Delete secret error")
+		})
+	}
+}
+
+func TestSecret_InfoList(t *testing.T) {
+	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
+		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
+	infoList := []dto.SecretInfo{{}}
+
+	type want struct {
+		list []dto.SecretInfo
+		err  error
+	}
+
+	tests := []struct {
+		name   string
+		sSetup func(t *testing.T) Storage
+		cSetup func(t *testing.T) Client
+		want   want
+	}{
+		{
+			name: "success",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					InfoList(token).
+					Return(infoList, nil)
+				return client
+			},
+			want: want{
+				list: infoList,
+			},
+		},
+		{
+			name: "without_token",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return("", fmt.Errorf("any error"))
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			want: want{
+				err: ErrAuthorizationFailed,
+			},
+		},
+
+		{
+			name: "client_err",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					InfoList(token).
+					Return(nil, httpClient.ErrSecretInfoListFailed)
+				return client
+			},
+			want: want{
+				err: httpClient.ErrSecretInfoListFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := test.cSetup(t)
+			storage := test.sSetup(t)
+
+			secretService := NewSecret(client, storage)
+			list, err := secretService.InfoList()
+
+			assert.ErrorIs(t, err, test.want.err, "Get secret and info error")
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.want.list, list, "Get secret list")
+		})
+	}
+}
+
+func TestSecret_Usage(t *testing.T) {
+	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
+		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
+	usage := dto.Usage{Bytes: 2048, Secrets: 3, MaxBytes: 1 << 20, MaxSecrets: 100}
+
+	type want struct {
+		usage dto.Usage
+		err   error
+	}
+
+	tests := []struct {
+		name   string
+		sSetup func(t *testing.T) Storage
+		cSetup func(t *testing.T) Client
+		want   want
+	}{
+		{
+			name: "success",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Usage(token).
+					Return(usage, nil)
+				return client
+			},
+			want: want{
+				usage: usage,
+			},
+		},
+		{
+			name: "without_token",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return("", fmt.Errorf("any error"))
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockClient(ctrl)
+			},
+			want: want{
+				err: ErrAuthorizationFailed,
+			},
+		},
+		{
+			name: "client_err",
+			sSetup: func(t *testing.T) Storage {
+				ctrl := gomock.NewController(t)
+				storage := mocks.NewMockStorage(ctrl)
+				storage.EXPECT().
+					Token().Return(token, nil)
+				return storage
+			},
+			cSetup: func(t *testing.T) Client {
+				ctrl := gomock.NewController(t)
+				client := mocks.NewMockClient(ctrl)
+				client.EXPECT().
+					Usage(token).
+					Return(dto.Usage{}, httpClient.ErrUsageFailed)
+				return client
+			},
+			want: want{
+				err: httpClient.ErrUsageFailed,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := test.cSetup(t)
+			storage := test.sSetup(t)
+
+			secretService := NewSecret(client, storage)
+			got, err := secretService.Usage()
+
+			assert.ErrorIs(t, err, test.want.err, "Usage error")
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.want.usage, got, "Usage")
 		})
 	}
 }
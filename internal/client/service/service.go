@@ -3,28 +3,95 @@ package service
 
 import "github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 
-// Storage хранилище данных для токена авторизации и мастер ключа.
+// Storage хранилище данных для токенов авторизации, мастер ключа, ключа аккаунта
+// и локальной копии секретов.
 type Storage interface {
 	// PutToken сохранение токена
 	PutToken(token string) error
 	// Token получение токена
 	Token() (string, error)
+	// PutRefreshToken сохранение refresh токена
+	PutRefreshToken(token string) error
+	// RefreshToken получение refresh токена
+	RefreshToken() (string, error)
 	// PutKey сохранение ключа
 	PutKey(key []byte) error
 	// Key получение ключа
 	Key() ([]byte, error)
+	// PutAccountKey сохранение ключа аккаунта
+	PutAccountKey(key []byte) error
+	// AccountKey получение ключа аккаунта
+	AccountKey() ([]byte, error)
+	// PutVault сохранение зашифрованной локальной копии секретов
+	PutVault(vault []byte) error
+	// Vault получение зашифрованной локальной копии секретов,
+	// если локальная копия еще не создана, возвращает nil
+	Vault() ([]byte, error)
+	// SetVaultAside переименовывает локальную копию секретов, которую не удалось расшифровать,
+	// чтобы ее не перезаписала новая копия, и возвращает путь к ней
+	SetVaultAside() (string, error)
+	// Wipe удаляет токены, ключи и локальную копию секретов
+	Wipe() error
 }
 
 // Client клиент для взаимодействия с сервером
 type Client interface {
 	// Register регистрирует пользователя в системе
 	Register(cr dto.Credentials) (dto.AuthResponse, error)
+	// PreLogin получает с сервера параметры мастер ключа пользователя для вычисления ключа аутентификации
+	PreLogin(data dto.PreLoginRequest) (dto.PreLoginResponse, error)
 	// Login осуществляет вход пользователя в систему
 	Login(cr dto.Credentials) (dto.AuthResponse, error)
+	// Logout завершает на сервере текущую сессию пользователя
+	Logout(token string) error
+	// Sessions получает с сервера список действующих сессий пользователя
+	Sessions(token string) ([]dto.Session, error)
+	// RevokeSession завершает на сервере сессию пользователя по id
+	RevokeSession(id string, token string) error
+	// TwoFactorSetup получает с сервера новый TOTP секрет
+	TwoFactorSetup(token string) (dto.TOTPSetup, error)
+	// TwoFactorEnable включает двухфакторную аутентификацию и получает коды восстановления
+	TwoFactorEnable(code string, token string) (dto.RecoveryCodes, error)
+	// TwoFactorDisable выключает двухфакторную аутентификацию
+	TwoFactorDisable(code string, token string) error
 	// Upload coхраняет секрет на сервере
 	Upload(data dto.SecretRequest, token string) error
 	// Retrieve получает секрет пользователя с ервера
 	Retrieve(id uint64, token string) (dto.SecretResponse, error)
+	// Update изменяет секрет пользователя на сервере
+	Update(id uint64, data dto.SecretUpdateRequest, token string) error
+	// Delete удаляет секрет пользователя на сервере
+	Delete(id uint64, token string) error
 	// InfoList получает информацию о всех секретах пользователя с сервера.
 	InfoList(token string) ([]dto.SecretInfo, error)
+	// Usage получает с сервера занятый пользователем объем хранилища и его квоты.
+	Usage(token string) (dto.Usage, error)
+	// Changes получает с сервера ленту изменений секретов пользователя после ревизии since.
+	Changes(since uint64, token string) (dto.SecretChanges, error)
+	// CreateUpload создает на сервере сессию загрузки секрета по частям
+	CreateUpload(data dto.UploadRequest, token string) (dto.UploadResponse, error)
+	// UploadChunk отправляет на сервер зашифрованную часть n загрузки uploadID
+	UploadChunk(uploadID string, n uint32, data []byte, token string) error
+	// UploadStatus получает с сервера состояние загрузки uploadID
+	UploadStatus(uploadID string, token string) (dto.UploadStatus, error)
+	// CommitUpload завершает загрузку uploadID
+	CommitUpload(uploadID string, data dto.UploadCommitRequest, token string) error
+	// SecretKeys получает с сервера зашифрованные ключи данных всех секретов пользователя
+	SecretKeys(token string) ([]dto.SecretKey, error)
+	// PasswordParams получает с сервера параметры мастер ключа текущего пользователя
+	PasswordParams(token string) (dto.PreLoginResponse, error)
+	// ChangePassword меняет пароль пользователя и ключи данных его секретов
+	ChangePassword(data dto.PasswordChangeRequest, token string) error
+	// SetAccountKey сохраняет на сервере ключ аккаунта и перешифрованные им ключи данных секретов
+	SetAccountKey(data dto.AccountKeyRequest, token string) error
+	// SetupRecovery сохраняет на сервере ключи восстановления доступа
+	SetupRecovery(data dto.RecoverySetupRequest, token string) error
+	// Recovery получает с сервера ключи восстановления доступа
+	Recovery(token string) (dto.RecoveryKey, error)
+	// RecoveryStart получает с сервера ключи для восстановления доступа
+	RecoveryStart(data dto.RecoveryStartRequest) (dto.RecoveryStartResponse, error)
+	// RecoveryComplete сбрасывает пароль по ключу восстановления и выполняет вход
+	RecoveryComplete(data dto.RecoveryRequest) (dto.AuthResponse, error)
+	// RetrieveChunk получает с сервера зашифрованную часть n данных секрета
+	RetrieveChunk(id uint64, n uint32, token string) ([]byte, error)
 }
@@ -0,0 +1,461 @@
+// Пакет service содержит сервисный слой клиентской части приложения
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// transferRetries сколько раз повторять передачу части при обрыве связи
+const transferRetries = 3
+
+// ProgressFunc получает количество переданных частей done из total.
+type ProgressFunc func(done, total uint32)
+
+// pendingUpload незавершенная загрузка файла. Хранится в локальной копии,
+// чтобы после обрыва связи продолжить загрузку с последней полученной сервером части.
+type pendingUpload struct {
+	ID      string            `json:"id"`
+	Request dto.UploadRequest `json:"request"`
+	// Размер и время изменения файла, по ним проверяется, что файл не изменился
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// UploadFile отправляет на сервер данные файла по частям, не загружая их целиком в память.
+// Принимает частино заполненный dto.SecretRequest, EncrData не используется.
+// Если предыдущая загрузка этого файла прервалась, продолжает ее.
+// Без связи с сервером не работает, локальная копия хранит только информацию о таких секретах.
+func (s *Secret) UploadFile(secret dto.SecretRequest, file *os.File, progress ProgressFunc) error {
+	return s.uploadStream(
+		dto.UploadRequest{UID: secret.UID, DataType: secret.DataType, Name: secret.Name, Meta: secret.Meta},
+		file,
+		progress,
+	)
+}
+
+// UpdateFile заменяет данные секрета id данными файла, отправляя их по частям.
+// Принимает частино заполненный dto.SecretUpdateRequest, EncrData не используется.
+// Если секрет был изменен другим клиентом, возвращает ошибку, содержащую ErrSecretConflict.
+func (s *Secret) UpdateFile(id uint64, secret dto.SecretUpdateRequest, file *os.File, progress ProgressFunc) error {
+	return s.uploadStream(
+		dto.UploadRequest{
+			SecretID: id,
+			Version:  secret.Version,
+			DataType: dto.SecretTypeFile,
+			Name:     secret.Name,
+			Meta:     secret.Meta,
+		},
+		file,
+		progress,
+	)
+}
+
+// DownloadFile получает данные секрета id с сервера, расшифровывает и пишет их в file.
+// Данные, загруженные по частям, получает и расшифровывает по одной части.
+// Если file уже содержит начало данных (прерванное скачивание), продолжает с первой неполной части.
+func (s *Secret) DownloadFile(id uint64, file *os.File, progress ProgressFunc) error {
+	if progress == nil {
+		progress = func(done, total uint32) {}
+	}
+
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := s.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	var resp dto.SecretResponse
+	err = s.retry(func() (err error) {
+		resp, err = s.client.Retrieve(id, token)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.Chunks == 0 {
+		data, err := decryptResponse(keys, resp)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+		}
+		if err := file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if _, err := file.WriteAt(data, 0); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		progress(1, 1)
+		return nil
+	}
+
+	key, err := keys.unwrap(resp.EncrData.Version, resp.EncrData.Key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+	}
+	cipher, err := crypto.NewStreamCipher(key, streamBinding(resp.UID, resp.DataType, resp.Name, resp.Meta))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+	}
+
+	// Все части, кроме последней, полного размера, поэтому по размеру файла
+	// определяем количество уже скачанных частей и отбрасываем неполную
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	next := uint32(min(stat.Size()/crypto.StreamChunkSize, int64(resp.Chunks)))
+	if next == resp.Chunks {
+		next--
+	}
+	offset := int64(next) * crypto.StreamChunkSize
+	if err := file.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	progress(next, resp.Chunks)
+
+	for n := next; n < resp.Chunks; n++ {
+		var chunk []byte
+		err := s.retry(func() (err error) {
+			chunk, err = s.client.RetrieveChunk(id, n, token)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		data, err := cipher.Open(uint64(n), n == resp.Chunks-1, chunk)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+		}
+
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		progress(n+1, resp.Chunks)
+	}
+
+	return nil
+}
+
+// uploadStream шифрует данные файла блоками crypto.StreamChunkSize
+// и отправляет их на сервер в рамках одной сессии загрузки.
+// Сессия сохраняется в локальной копии до завершения, поэтому прерванная
+// загрузка того же файла продолжается с части, на которой остановилась.
+func (s *Secret) uploadStream(upload dto.UploadRequest, file *os.File, progress ProgressFunc) error {
+	if progress == nil {
+		progress = func(done, total uint32) {}
+	}
+
+	keys, err := loadKeyring(s.storage)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	token, err := s.storage.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if stat.Size() > dto.UploadMaxChunks*crypto.StreamChunkSize {
+		return fmt.Errorf("%w: max %d bytes", ErrFileTooLarge, int64(dto.UploadMaxChunks*crypto.StreamChunkSize))
+	}
+	path, err := filepath.Abs(file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to get absolute file path: %w", err)
+	}
+	pendingKey := fmt.Sprintf("%d:%s", upload.SecretID, path)
+
+	// Заменяемый секрет сохраняет UID, секрету без UID он присваивается
+	if upload.SecretID != 0 {
+		var current dto.SecretResponse
+		err = s.retry(func() (err error) {
+			current, err = s.client.Retrieve(upload.SecretID, token)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		upload.UID = current.UID
+	}
+	if upload.UID == "" {
+		if upload.UID, err = newSecretUID(); err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+		}
+	}
+
+	pending, status, err := s.findUpload(keys, token, pendingKey, upload, stat)
+	if err != nil {
+		return err
+	}
+	if status.Committed {
+		// Загрузка завершилась, но ответ сервера не дошел
+		return s.forgetUpload(keys, pendingKey)
+	}
+	if pending == nil {
+		pending, err = s.createUpload(keys, token, pendingKey, upload, stat)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Части привязываются к описанию секрета из сессии, с которым ее создали
+	request := pending.Request
+	key, err := keys.unwrap(request.KeyVersion, request.Key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+	cipher, err := crypto.NewStreamCipher(key, streamBinding(request.UID, request.DataType, request.Name, request.Meta))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+
+	chunks := chunkCount(stat.Size())
+	next := min(status.Received, chunks)
+	if _, err := file.Seek(int64(next)*crypto.StreamChunkSize, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	progress(next, chunks)
+
+	buf := make([]byte, crypto.StreamChunkSize)
+	for n := next; n < chunks; n++ {
+		size := min(crypto.StreamChunkSize, stat.Size()-int64(n)*crypto.StreamChunkSize)
+		if _, err := io.ReadFull(file, buf[:size]); err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		chunk := cipher.Seal(uint64(n), n == chunks-1, buf[:size])
+		err := s.retry(func() error {
+			return s.client.UploadChunk(pending.ID, n, chunk, token)
+		})
+		if err != nil {
+			return err
+		}
+		progress(n+1, chunks)
+	}
+
+	err = s.retry(func() error {
+		return s.client.CommitUpload(pending.ID, dto.UploadCommitRequest{Chunks: chunks}, token)
+	})
+	// При обрыве связи загрузку можно будет продолжить, иначе начинать заново
+	if err != nil && isNetworkError(err) {
+		return err
+	}
+	if fErr := s.forgetUpload(keys, pendingKey); fErr != nil && err == nil {
+		err = fErr
+	}
+
+	return err
+}
+
+// rebindStream перешифровывает данные секрета current, загруженного по частям,
+// для нового описания secret и UID uid. Части получаются, расшифровываются
+// и отправляются по одной в новой сессии загрузки с новым ключом DEK,
+// потому что один ключ используется только для одного потока.
+func (s *Secret) rebindStream(
+	id uint64,
+	current dto.SecretResponse,
+	secret dto.SecretUpdateRequest,
+	uid string,
+	keys keyring,
+	token string,
+) error {
+	oldKey, err := keys.unwrap(current.EncrData.Version, current.EncrData.Key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+	}
+	from, err := crypto.NewStreamCipher(oldKey, streamBinding(current.UID, current.DataType, current.Name, current.Meta))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+	}
+
+	key, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	if err != nil {
+		return fmt.Errorf("%w: failed to generate DEK: %w", ErrSecretEncryptionFailed, err)
+	}
+	upload := dto.UploadRequest{
+		SecretID: id,
+		Version:  secret.Version,
+		UID:      uid,
+		DataType: current.DataType,
+		Name:     secret.Name,
+		Meta:     secret.Meta,
+	}
+	upload.Key, upload.KeyVersion, err = keys.wrap(key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+	to, err := crypto.NewStreamCipher(key, streamBinding(uid, upload.DataType, upload.Name, upload.Meta))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+
+	var session dto.UploadResponse
+	err = s.retry(func() (err error) {
+		session, err = s.client.CreateUpload(upload, token)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for n := uint32(0); n < current.Chunks; n++ {
+		var chunk []byte
+		err := s.retry(func() (err error) {
+			chunk, err = s.client.RetrieveChunk(id, n, token)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		last := n == current.Chunks-1
+		data, err := from.Open(uint64(n), last, chunk)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSecretDecryptionFailed, err)
+		}
+
+		chunk = to.Seal(uint64(n), last, data)
+		err = s.retry(func() error {
+			return s.client.UploadChunk(session.ID, n, chunk, token)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.retry(func() error {
+		return s.client.CommitUpload(session.ID, dto.UploadCommitRequest{Chunks: current.Chunks}, token)
+	})
+}
+
+// findUpload ищет в локальной копии незавершенную загрузку того же файла с теми же параметрами
+// и запрашивает ее состояние на сервере. Если загрузки нет или сервер ее не нашел, возвращает nil.
+func (s *Secret) findUpload(
+	keys keyring,
+	token, pendingKey string,
+	upload dto.UploadRequest,
+	stat os.FileInfo,
+) (*pendingUpload, dto.UploadStatus, error) {
+	var status dto.UploadStatus
+
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return nil, status, err
+	}
+
+	pending, ok := v.Uploads[pendingKey]
+	if !ok || !pending.matches(upload, stat) {
+		return nil, status, nil
+	}
+
+	err = s.retry(func() (err error) {
+		status, err = s.client.UploadStatus(pending.ID, token)
+		return err
+	})
+	if errors.Is(err, httpClient.ErrSecretNotFound) {
+		return nil, dto.UploadStatus{}, nil
+	}
+	if err != nil {
+		return nil, status, err
+	}
+
+	return &pending, status, nil
+}
+
+// createUpload генерирует ключ DEK, создает на сервере сессию загрузки
+// и сохраняет ее в локальной копии.
+func (s *Secret) createUpload(
+	keys keyring,
+	token, pendingKey string,
+	upload dto.UploadRequest,
+	stat os.FileInfo,
+) (*pendingUpload, error) {
+	key, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to generate DEK: %w", ErrSecretEncryptionFailed, err)
+	}
+	upload.Key, upload.KeyVersion, err = keys.wrap(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecretEncryptionFailed, err)
+	}
+
+	var session dto.UploadResponse
+	err = s.retry(func() (err error) {
+		session, err = s.client.CreateUpload(upload, token)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending := pendingUpload{ID: session.ID, Request: upload, Size: stat.Size(), ModTime: stat.ModTime()}
+
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return nil, err
+	}
+	v.Uploads[pendingKey] = pending
+	if err := s.saveVault(keys, v); err != nil {
+		return nil, err
+	}
+
+	return &pending, nil
+}
+
+// forgetUpload удаляет незавершенную загрузку из локальной копии.
+func (s *Secret) forgetUpload(keys keyring, pendingKey string) error {
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return err
+	}
+
+	delete(v.Uploads, pendingKey)
+	return s.saveVault(keys, v)
+}
+
+// retry выполняет передачу, повторяя ее при обрыве связи.
+func (s *Secret) retry(transfer func() error) error {
+	err := transfer()
+	for i := 0; i < transferRetries && isNetworkError(err); i++ {
+		time.Sleep(s.retryDelay)
+		err = transfer()
+	}
+	return err
+}
+
+// matches проверяет, что загрузка начата для того же файла с теми же параметрами.
+func (p pendingUpload) matches(upload dto.UploadRequest, stat os.FileInfo) bool {
+	return p.Size == stat.Size() &&
+		p.ModTime.Equal(stat.ModTime()) &&
+		p.Request.Name == upload.Name &&
+		p.Request.DataType == upload.DataType &&
+		p.Request.Version == upload.Version &&
+		slices.Equal(p.Request.Meta, upload.Meta)
+}
+
+// chunkCount возвращает количество частей для данных размером size, пустые данные - одна пустая часть.
+func chunkCount(size int64) uint32 {
+	if size == 0 {
+		return 1
+	}
+	return uint32((size + crypto.StreamChunkSize - 1) / crypto.StreamChunkSize)
+}
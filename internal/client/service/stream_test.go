@@ -0,0 +1,454 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// testServer имитирует хранение загрузок на сервере для мока клиента.
+type testServer struct {
+	request dto.UploadRequest
+	chunks  [][]byte
+	// Номер части, на которой имитируется обрыв связи, -1 без обрыва
+	failOn int
+}
+
+// client создает мок клиента, который сохраняет загруженные части
+// и отдает их как данные секрета id.
+func (ts *testServer) client(t *testing.T, id uint64) *mocks.MockClient {
+	client := mocks.NewMockClient(gomock.NewController(t))
+	client.EXPECT().CreateUpload(gomock.Any(), testToken).
+		DoAndReturn(func(req dto.UploadRequest, _ string) (dto.UploadResponse, error) {
+			require.NoError(t, req.Validate(), "Upload request validation")
+			ts.request, ts.chunks = req, nil
+			return dto.UploadResponse{ID: "upload-id"}, nil
+		}).AnyTimes()
+	client.EXPECT().UploadStatus("upload-id", testToken).
+		DoAndReturn(func(string, string) (dto.UploadStatus, error) {
+			return dto.UploadStatus{ID: "upload-id", Received: uint32(len(ts.chunks))}, nil
+		}).AnyTimes()
+	client.EXPECT().UploadChunk("upload-id", gomock.Any(), gomock.Any(), testToken).
+		DoAndReturn(func(_ string, n uint32, data []byte, _ string) error {
+			if int(n) == ts.failOn {
+				return testNetworkError
+			}
+			require.Equal(t, len(ts.chunks), int(n), "Chunk number")
+			ts.chunks = append(ts.chunks, bytes.Clone(data))
+			return nil
+		}).AnyTimes()
+	client.EXPECT().CommitUpload("upload-id", gomock.Any(), testToken).
+		DoAndReturn(func(_ string, commit dto.UploadCommitRequest, _ string) error {
+			assert.Equal(t, len(ts.chunks), int(commit.Chunks), "Commited chunks")
+			return nil
+		}).AnyTimes()
+	client.EXPECT().Retrieve(id, testToken).
+		DoAndReturn(func(uint64, string) (dto.SecretResponse, error) {
+			return dto.SecretResponse{
+				ID:       id,
+				UID:      ts.request.UID,
+				DataType: ts.request.DataType,
+				Name:     ts.request.Name,
+				Meta:     ts.request.Meta,
+				EncrData: dto.EncryptedData{Key: ts.request.Key, Version: ts.request.KeyVersion},
+				Chunks:   uint32(len(ts.chunks)),
+			}, nil
+		}).AnyTimes()
+	client.EXPECT().RetrieveChunk(id, gomock.Any(), testToken).
+		DoAndReturn(func(_ uint64, n uint32, _ string) ([]byte, error) {
+			return ts.chunks[n], nil
+		}).AnyTimes()
+
+	return client
+}
+
+// testFile создает файл с данными размером size и открывает его.
+func testFile(t *testing.T, size int) (*os.File, []byte) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	require.Nil(t, os.WriteFile(path, data, 0600), "File creating")
+	file, err := os.Open(path)
+	require.Nil(t, err, "File opening")
+	t.Cleanup(func() { file.Close() })
+
+	return file, data
+}
+
+// testOutFile создает файл для скачивания с начальным содержимым content.
+func testOutFile(t *testing.T, content []byte) *os.File {
+	path := filepath.Join(t.TempDir(), "file.bin.part")
+	require.Nil(t, os.WriteFile(path, content, 0600), "File creating")
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	require.Nil(t, err, "File opening")
+	t.Cleanup(func() { file.Close() })
+
+	return file
+}
+
+// readFile читает все содержимое файла.
+func readFile(t *testing.T, file *os.File) []byte {
+	data, err := os.ReadFile(file.Name())
+	require.Nil(t, err, "File reading")
+	return data
+}
+
+func TestSecret_UploadFile(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "one_chunk", size: 100},
+		{name: "exact_chunks", size: 2 * crypto.StreamChunkSize},
+		{name: "partial_last_chunk", size: 2*crypto.StreamChunkSize + 13},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, data := testFile(t, test.size)
+			storage, saved := testVaultStorage(t, masterKey, nil)
+			ts := &testServer{failOn: -1}
+			service := NewSecret(ts.client(t, 13), storage)
+
+			var progress []uint32
+			err := service.UploadFile(
+				dto.SecretRequest{DataType: dto.SecretTypeFile, Name: "file", Meta: []dto.MetaData{}},
+				file,
+				func(done, total uint32) {
+					assert.Equal(t, chunkCount(int64(test.size)), total, "Total chunks")
+					progress = append(progress, done)
+				},
+			)
+			require.Nil(t, err, "Upload error")
+			assert.Equal(t, dto.KeyVersionAccount, ts.request.KeyVersion, "DEK must be wrapped by account key")
+			assert.NotEmpty(t, ts.request.UID, "Generated UID")
+			assert.Equal(t, int(chunkCount(int64(test.size)))+1, len(progress), "Progress calls")
+			assert.Empty(t, saved().Uploads, "Finished upload must be removed from vault")
+
+			out := testOutFile(t, nil)
+			require.Nil(t, service.DownloadFile(13, out, nil), "Download error")
+			assert.True(t, bytes.Equal(data, readFile(t, out)), "Downloaded data")
+		})
+	}
+}
+
+func TestSecret_UploadFile_Resume(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	secret := dto.SecretRequest{DataType: dto.SecretTypeFile, Name: "file", Meta: []dto.MetaData{}}
+
+	t.Run("interrupted", func(t *testing.T) {
+		file, data := testFile(t, 2*crypto.StreamChunkSize+13)
+		storage, saved := testVaultStorage(t, masterKey, nil)
+		ts := &testServer{failOn: 1}
+		service := NewSecret(ts.client(t, 13), storage)
+		service.retryDelay = 0
+
+		err := service.UploadFile(secret, file, nil)
+		assert.ErrorIs(t, err, httpClient.ErrSecretSendFailed, "Interrupted upload error")
+		require.Len(t, saved().Uploads, 1, "Interrupted upload must stay in vault")
+		require.Len(t, ts.chunks, 1, "Chunks received before interruption")
+		key := ts.request.Key
+
+		// Связь восстановилась, загрузка продолжается со второй части тем же ключом
+		ts.failOn = -1
+		var first uint32
+		err = service.UploadFile(secret, file, func(done, total uint32) {
+			if first == 0 {
+				first = done
+			}
+		})
+		require.Nil(t, err, "Resumed upload error")
+		assert.Equal(t, uint32(1), first, "Resumed from chunk")
+		assert.Equal(t, key, ts.request.Key, "Upload must not be recreated")
+		assert.Empty(t, saved().Uploads, "Finished upload must be removed from vault")
+
+		out := testOutFile(t, nil)
+		require.Nil(t, service.DownloadFile(13, out, nil), "Download error")
+		assert.True(t, bytes.Equal(data, readFile(t, out)), "Downloaded data")
+	})
+
+	t.Run("file_changed", func(t *testing.T) {
+		file, _ := testFile(t, 100)
+		stat, err := file.Stat()
+		require.Nil(t, err, "File info")
+		path, err := filepath.Abs(file.Name())
+		require.Nil(t, err, "File path")
+
+		storage, saved := testVaultStorage(t, masterKey, &vault{Uploads: map[string]pendingUpload{
+			"0:" + path: {ID: "old-upload-id", Request: dto.UploadRequest{Name: "file"}, Size: stat.Size() + 1},
+		}})
+		ts := &testServer{failOn: -1}
+		service := NewSecret(ts.client(t, 13), storage)
+
+		require.Nil(t, service.UploadFile(secret, file, nil), "Upload error")
+		assert.Len(t, ts.chunks, 1, "Uploaded chunks")
+		assert.Empty(t, saved().Uploads, "Finished upload must be removed from vault")
+	})
+
+	t.Run("already_committed", func(t *testing.T) {
+		file, _ := testFile(t, 100)
+		stat, err := file.Stat()
+		require.Nil(t, err, "File info")
+		path, err := filepath.Abs(file.Name())
+		require.Nil(t, err, "File path")
+
+		storage, saved := testVaultStorage(t, masterKey, &vault{Uploads: map[string]pendingUpload{
+			"0:" + path: {
+				ID:      "upload-id",
+				Request: dto.UploadRequest{DataType: dto.SecretTypeFile, Name: "file", Meta: []dto.MetaData{}},
+				Size:    stat.Size(),
+				ModTime: stat.ModTime(),
+			},
+		}})
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().UploadStatus("upload-id", testToken).
+			Return(dto.UploadStatus{ID: "upload-id", Received: 1, Committed: true}, nil)
+
+		require.Nil(t, NewSecret(client, storage).UploadFile(secret, file, nil), "Upload error")
+		assert.Empty(t, saved().Uploads, "Finished upload must be removed from vault")
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		file, _ := testFile(t, 100)
+		storage, saved := testVaultStorage(t, masterKey, nil)
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{ID: 13, UID: testSecretUID}, nil)
+		client.EXPECT().CreateUpload(gomock.Any(), testToken).
+			DoAndReturn(func(req dto.UploadRequest, _ string) (dto.UploadResponse, error) {
+				assert.Equal(t, testSecretUID, req.UID, "Replaced secret keeps UID")
+				return dto.UploadResponse{ID: "upload-id"}, nil
+			})
+		client.EXPECT().UploadChunk("upload-id", uint32(0), gomock.Any(), testToken).Return(nil)
+		client.EXPECT().CommitUpload("upload-id", dto.UploadCommitRequest{Chunks: 1}, testToken).
+			Return(fmt.Errorf("%w: %w", httpClient.ErrUploadFailed, httpClient.ErrSecretConflict))
+
+		err := NewSecret(client, storage).UpdateFile(13, dto.SecretUpdateRequest{Name: "file", Version: 3}, file, nil)
+		assert.ErrorIs(t, err, httpClient.ErrSecretConflict, "Update error")
+		assert.Empty(t, saved().Uploads, "Rejected upload must be removed from vault")
+	})
+}
+
+func TestSecret_UpdateFile(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+
+	file, data := testFile(t, 100)
+	storage, _ := testVaultStorage(t, masterKey, nil)
+	ts := &testServer{failOn: -1}
+	client := ts.client(t, 13)
+	service := NewSecret(client, storage)
+
+	err = service.UpdateFile(13, dto.SecretUpdateRequest{Name: "file", Meta: []dto.MetaData{}, Version: 3}, file, nil)
+	require.Nil(t, err, "Update error")
+
+	out := testOutFile(t, nil)
+	require.Nil(t, service.DownloadFile(13, out, nil), "Download error")
+	assert.True(t, bytes.Equal(data, readFile(t, out)), "Downloaded data")
+}
+
+func TestSecret_DownloadFile(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+
+	key, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "DEK creation")
+	encryptedKey, err := crypto.EncryptAES(masterKey, key)
+	require.Nil(t, err, "DEK encryption")
+	cipher, err := crypto.NewStreamCipher(key, nil)
+	require.Nil(t, err, "Stream cipher creation")
+
+	// ключ DEK зашифрован мастер ключом, как до перехода на ключ аккаунта
+	chunked := dto.SecretResponse{
+		ID:       13,
+		EncrData: dto.EncryptedData{Key: base64.RawStdEncoding.EncodeToString(encryptedKey)},
+		Chunks:   2,
+	}
+	firstData := bytes.Repeat([]byte("a"), crypto.StreamChunkSize)
+	first := cipher.Seal(0, false, firstData)
+	second := cipher.Seal(1, true, []byte("second"))
+	wholeData := append(bytes.Clone(firstData), []byte("second")...)
+
+	encrData, err := encryptData(
+		keyring{master: masterKey, account: testAccountKey},
+		secretBinding("", dto.SecretTypeFile, "file", nil),
+		[]byte("whole data"),
+	)
+	require.Nil(t, err, "Data encryption")
+
+	// части секрета с UID привязаны к его описанию
+	boundKey, boundKeyVersion, err := keyring{master: masterKey, account: testAccountKey}.wrap(key)
+	require.Nil(t, err, "DEK wrapping")
+	bound := dto.SecretResponse{
+		ID:       13,
+		UID:      testSecretUID,
+		DataType: dto.SecretTypeFile,
+		Name:     "file",
+		EncrData: dto.EncryptedData{Key: boundKey, Version: boundKeyVersion},
+		Chunks:   2,
+	}
+	boundCipher, err := crypto.NewStreamCipher(key, streamBinding(bound.UID, bound.DataType, bound.Name, bound.Meta))
+	require.Nil(t, err, "Stream cipher creation")
+	boundFirst := boundCipher.Seal(0, false, firstData)
+	boundSecond := boundCipher.Seal(1, true, []byte("second"))
+
+	tests := []struct {
+		name     string
+		content  []byte
+		cSetup   func(t *testing.T) Client
+		wantData []byte
+		wantErr  error
+	}{
+		{
+			name: "chunked",
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(chunked, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).Return(first, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(1), testToken).Return(second, nil)
+				return client
+			},
+			wantData: wholeData,
+		},
+		{
+			name: "bound_chunks",
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(bound, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).Return(boundFirst, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(1), testToken).Return(boundSecond, nil)
+				return client
+			},
+			wantData: wholeData,
+		},
+		{
+			// сервер переименовал секрет, не перешифровав части
+			name: "renamed_bound_chunks",
+			cSetup: func(t *testing.T) Client {
+				renamed := bound
+				renamed.Name = "other"
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(renamed, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).Return(boundFirst, nil)
+				return client
+			},
+			wantErr: ErrSecretDecryptionFailed,
+		},
+		{
+			// сервер убрал UID, чтобы части расшифровывались без привязки
+			name: "stripped_uid",
+			cSetup: func(t *testing.T) Client {
+				stripped := bound
+				stripped.UID = ""
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(stripped, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).Return(boundFirst, nil)
+				return client
+			},
+			wantErr: ErrSecretDecryptionFailed,
+		},
+		{
+			name:    "resume",
+			content: append(bytes.Clone(firstData), []byte("sec")...),
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(chunked, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(1), testToken).Return(second, nil)
+				return client
+			},
+			wantData: wholeData,
+		},
+		{
+			name:    "resume_after_network_error",
+			content: firstData,
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(chunked, nil)
+				gomock.InOrder(
+					client.EXPECT().RetrieveChunk(uint64(13), uint32(1), testToken).Return(nil, testNetworkError),
+					client.EXPECT().RetrieveChunk(uint64(13), uint32(1), testToken).Return(second, nil),
+				)
+				return client
+			},
+			wantData: wholeData,
+		},
+		{
+			name:    "not_chunked",
+			content: []byte("stale content"),
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).
+					Return(dto.SecretResponse{ID: 13, DataType: dto.SecretTypeFile, Name: "file", EncrData: encrData}, nil)
+				return client
+			},
+			wantData: []byte("whole data"),
+		},
+		{
+			name: "reordered_chunks",
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(chunked, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).Return(second, nil)
+				return client
+			},
+			wantErr: ErrSecretDecryptionFailed,
+		},
+		{
+			name: "truncated",
+			cSetup: func(t *testing.T) Client {
+				truncated := chunked
+				truncated.Chunks = 1
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(truncated, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).Return(first, nil)
+				return client
+			},
+			wantErr: ErrSecretDecryptionFailed,
+		},
+		{
+			name: "chunk_not_found",
+			cSetup: func(t *testing.T) Client {
+				client := mocks.NewMockClient(gomock.NewController(t))
+				client.EXPECT().Retrieve(uint64(13), testToken).Return(chunked, nil)
+				client.EXPECT().RetrieveChunk(uint64(13), uint32(0), testToken).
+					Return(nil, fmt.Errorf("%w: %w", httpClient.ErrChunkRetrieveFailed, httpClient.ErrSecretNotFound))
+				return client
+			},
+			wantErr: httpClient.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage, _ := testVaultStorage(t, masterKey, nil)
+			service := NewSecret(test.cSetup(t), storage)
+			service.retryDelay = 0
+
+			out := testOutFile(t, test.content)
+			err := service.DownloadFile(13, out, nil)
+			assert.ErrorIs(t, err, test.wantErr, "Download error")
+			if err == nil {
+				assert.True(t, bytes.Equal(test.wantData, readFile(t, out)), "Downloaded data")
+			}
+		})
+	}
+}
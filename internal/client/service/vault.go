@@ -0,0 +1,138 @@
+// Пакет service содержит сервисный слой клиентской части приложения
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+var (
+	ErrSavedLocally       = errors.New("server is unavailable, the change is saved locally and will be sent on sync")
+	ErrVaultUndecryptable = errors.New("local copy can not be decrypted with the current key")
+)
+
+// vault локальная копия секретов пользователя и изменения,
+// сделанные без связи с сервером. Хранится зашифрованной ключом аккаунта,
+// поэтому не перешифровывается при смене пароля, в том числе на другом устройстве.
+type vault struct {
+	// Ревизия сервера на момент последней синхронизации
+	Revision uint64 `json:"revision"`
+	// Зашифрованные секреты, полученные с сервера
+	Secrets map[uint64]dto.SecretResponse `json:"secrets"`
+	// Секреты, созданные без связи с сервером
+	Created []dto.SecretRequest `json:"created"`
+	// Секреты, созданные без связи с сервером, которые сервер отклонил
+	Rejected []rejectedSecret `json:"rejected"`
+	// Секреты, измененные без связи с сервером
+	Updated map[uint64]dto.SecretUpdateRequest `json:"updated"`
+	// Секреты, удаленные без связи с сервером
+	Deleted []uint64 `json:"deleted"`
+	// Локальные изменения, конфликтующие с изменениями на другом клиенте
+	Conflicts map[uint64]conflict `json:"conflicts"`
+	// Незавершенные загрузки файлов по частям
+	Uploads map[string]pendingUpload `json:"uploads"`
+}
+
+// rejectedSecret секрет, созданный без связи с сервером и отклоненный сервером,
+// он отправляется повторно при каждой синхронизации.
+type rejectedSecret struct {
+	Secret dto.SecretRequest `json:"secret"`
+	// Причина, по которой сервер отклонил секрет
+	Reason string `json:"reason"`
+}
+
+// loadVault загружает и расшифровывает локальную копию секретов. Если копии нет, возвращает пустую копию.
+// Копия, сохраненная до перехода на ключ аккаунта, расшифровывается мастер ключом
+// и при следующем сохранении шифруется ключом аккаунта. Если копия зашифрована другим ключом
+// (был вход другого пользователя), она откладывается, и возвращается ErrVaultUndecryptable.
+func (s *Secret) loadVault(keys keyring) (*vault, error) {
+	v := &vault{}
+
+	data, err := s.storage.Vault()
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		plainData, err := crypto.DecryptAES(keys.account, data)
+		if err != nil {
+			plainData, err = crypto.DecryptAES(keys.master, data)
+		}
+		if err != nil {
+			return nil, s.setVaultAside()
+		}
+		if err := json.Unmarshal(plainData, v); err != nil {
+			return nil, fmt.Errorf("failed to decode vault: %w", err)
+		}
+	}
+
+	if v.Secrets == nil {
+		v.Secrets = make(map[uint64]dto.SecretResponse)
+	}
+	if v.Updated == nil {
+		v.Updated = make(map[uint64]dto.SecretUpdateRequest)
+	}
+	if v.Conflicts == nil {
+		v.Conflicts = make(map[uint64]conflict)
+	}
+	if v.Uploads == nil {
+		v.Uploads = make(map[string]pendingUpload)
+	}
+
+	return v, nil
+}
+
+// setVaultAside откладывает локальную копию, которую не удалось расшифровать, и возвращает
+// ErrVaultUndecryptable с путем к ней. В копии могут быть не отправленные на сервер изменения,
+// поэтому она не перезаписывается: ее можно расшифровать прежним ключом, а следующая
+// синхронизация начнет новую копию.
+func (s *Secret) setVaultAside() error {
+	path, err := s.storage.SetVaultAside()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrVaultUndecryptable, err)
+	}
+	return fmt.Errorf("%w: it is kept in %s, run sync to download secrets again", ErrVaultUndecryptable, path)
+}
+
+// saveVault шифрует ключом аккаунта и сохраняет локальную копию секретов.
+func (s *Secret) saveVault(keys keyring, v *vault) error {
+	plainData, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %w", err)
+	}
+
+	data, err := crypto.EncryptAES(keys.account, plainData)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt vault: %w", err)
+	}
+
+	return s.storage.PutVault(data)
+}
+
+// saveLocally вносит изменение в локальную копию секретов для отправки при синхронизации.
+// В случае успеха возвращает ErrSavedLocally.
+func (s *Secret) saveLocally(keys keyring, change func(v *vault)) error {
+	v, err := s.loadVault(keys)
+	if err != nil {
+		return err
+	}
+
+	change(v)
+
+	if err := s.saveVault(keys, v); err != nil {
+		return err
+	}
+
+	return ErrSavedLocally
+}
+
+// isNetworkError проверяет, вызвана ли ошибка недоступностью сервера.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
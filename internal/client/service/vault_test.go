@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpClient "github.com/EshkinKot1980/GophKeeper/internal/client/http"
+	"github.com/EshkinKot1980/GophKeeper/internal/client/service/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+const testToken = "token"
+
+// testAccountKey ключ аккаунта для моков хранилища.
+var testAccountKey = bytes.Repeat([]byte("a"), crypto.KeyLen)
+
+// testNetworkError имитирует ошибку недоступности сервера так, как ее возвращает http клиент.
+var testNetworkError = fmt.Errorf(
+	"%w: %w",
+	httpClient.ErrSecretSendFailed,
+	&url.Error{Op: "Post", URL: "https://localhost/api/secret", Err: errors.New("connection refused")},
+)
+
+// testVaultStorage создает мок хранилища, который держит локальную копию секретов,
+// зашифрованную testAccountKey, в памяти.
+func testVaultStorage(t *testing.T, masterKey []byte, v *vault) (*mocks.MockStorage, func() *vault) {
+	var data []byte
+	if v != nil {
+		plainData, err := json.Marshal(v)
+		require.Nil(t, err, "Encode vault")
+		data, err = crypto.EncryptAES(testAccountKey, plainData)
+		require.Nil(t, err, "Encrypt vault")
+	}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockStorage(ctrl)
+	storage.EXPECT().Key().Return(masterKey, nil).AnyTimes()
+	storage.EXPECT().AccountKey().Return(testAccountKey, nil).AnyTimes()
+	storage.EXPECT().Token().Return(testToken, nil).AnyTimes()
+	storage.EXPECT().Vault().DoAndReturn(func() ([]byte, error) { return data, nil }).AnyTimes()
+	storage.EXPECT().PutVault(gomock.Any()).DoAndReturn(func(vault []byte) error {
+		data = vault
+		return nil
+	}).AnyTimes()
+
+	saved := func() *vault {
+		plainData, err := crypto.DecryptAES(testAccountKey, data)
+		require.Nil(t, err, "Decrypt saved vault")
+		var v vault
+		require.Nil(t, json.Unmarshal(plainData, &v), "Decode saved vault")
+		return &v
+	}
+
+	return storage, saved
+}
+
+func TestSecret_Offline(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+	keys := keyring{master: masterKey, account: testAccountKey}
+	encrData, err := encryptData(keys, secretBinding(testSecretUID, dto.SecretTypeText, "cached", nil), []byte("cached data"))
+	require.Nil(t, err, "Encrypt cached data")
+	cached := dto.SecretResponse{
+		ID:       13,
+		UID:      testSecretUID,
+		Name:     "cached",
+		DataType: dto.SecretTypeText,
+		EncrData: encrData,
+	}
+
+	t.Run("upload", func(t *testing.T) {
+		storage, saved := testVaultStorage(t, masterKey, nil)
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Upload(gomock.All(), testToken).Return(testNetworkError)
+
+		err := NewSecret(client, storage).Upload(dto.SecretRequest{Name: "new"}, []byte("data"))
+		assert.ErrorIs(t, err, ErrSavedLocally, "Upload error")
+
+		v := saved()
+		require.Len(t, v.Created, 1, "Created secrets in vault")
+		assert.Equal(t, "new", v.Created[0].Name, "Created secret name")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		storage, saved := testVaultStorage(t, masterKey, &vault{Secrets: map[uint64]dto.SecretResponse{13: cached}})
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{}, testNetworkError)
+		client.EXPECT().Update(uint64(13), gomock.All(), testToken).Return(testNetworkError)
+
+		err := NewSecret(client, storage).Update(13, dto.SecretUpdateRequest{Name: "changed"}, []byte("data"))
+		assert.ErrorIs(t, err, ErrSavedLocally, "Update error")
+
+		v := saved()
+		assert.Equal(t, "changed", v.Updated[13].Name, "Updated secret in vault")
+		assert.Equal(t, "changed", v.Secrets[13].Name, "Cached secret name")
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		storage, saved := testVaultStorage(t, masterKey, &vault{Secrets: map[uint64]dto.SecretResponse{13: cached}})
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{}, testNetworkError)
+		client.EXPECT().Update(uint64(13), gomock.All(), testToken).Return(testNetworkError)
+
+		err := NewSecret(client, storage).Update(13, dto.SecretUpdateRequest{Name: "changed"}, nil)
+		assert.ErrorIs(t, err, ErrSavedLocally, "Update error")
+
+		// данные из локальной копии перешифрованы для нового названия
+		v := saved()
+		updated := v.Updated[13]
+		assert.Equal(t, testSecretUID, updated.UID, "Updated secret UID")
+		data, err := deryptData(keys, responseBinding(v.Secrets[13]), true, &updated.EncrData)
+		require.Nil(t, err, "Decrypt rebound data")
+		assert.Equal(t, "cached data", string(data), "Rebound data")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		storage, saved := testVaultStorage(t, masterKey, &vault{Secrets: map[uint64]dto.SecretResponse{13: cached}})
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Delete(uint64(13), testToken).Return(testNetworkError)
+
+		err := NewSecret(client, storage).Delete(13)
+		assert.ErrorIs(t, err, ErrSavedLocally, "Delete error")
+
+		v := saved()
+		assert.Equal(t, []uint64{13}, v.Deleted, "Deleted secrets in vault")
+		assert.Empty(t, v.Secrets, "Cached secrets")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		storage, _ := testVaultStorage(t, masterKey, &vault{Secrets: map[uint64]dto.SecretResponse{13: cached}})
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{}, testNetworkError)
+
+		data, info, err := NewSecret(client, storage).GetSecretAndInfo(13)
+		require.Nil(t, err, "Get secret error")
+		assert.Equal(t, []byte("cached data"), data, "Cached secret data")
+		assert.Equal(t, "cached", info.Name, "Cached secret name")
+	})
+
+	t.Run("get_not_cached", func(t *testing.T) {
+		storage, _ := testVaultStorage(t, masterKey, nil)
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Retrieve(uint64(13), testToken).Return(dto.SecretResponse{}, testNetworkError)
+
+		_, _, err := NewSecret(client, storage).GetSecretAndInfo(13)
+		assert.ErrorIs(t, err, httpClient.ErrSecretSendFailed, "Get secret error")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		storage, _ := testVaultStorage(t, masterKey, &vault{
+			Secrets: map[uint64]dto.SecretResponse{14: {ID: 14}, 13: cached},
+		})
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().InfoList(testToken).Return(nil, testNetworkError)
+
+		list, err := NewSecret(client, storage).InfoList()
+		require.Nil(t, err, "List error")
+		assert.Equal(t, []dto.SecretInfo{secretInfo(cached), {ID: 14}}, list, "Cached secret list")
+	})
+}
+
+func TestSecret_Sync(t *testing.T) {
+	masterKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Master key creation")
+
+	newVault := func() *vault {
+		return &vault{
+			Revision: 40,
+			Secrets: map[uint64]dto.SecretResponse{
+				3: {ID: 3, Name: "unchanged", Revision: 30},
+				4: {ID: 4, Name: "removed on server", Revision: 31},
+				5: {ID: 5, Name: "changed offline", Revision: 32},
+			},
+			Created: []dto.SecretRequest{{Name: "created offline"}},
+			Updated: map[uint64]dto.SecretUpdateRequest{5: {Name: "changed offline", Version: 2}},
+			Deleted: []uint64{7},
+		}
+	}
+
+	t.Run("master_key_vault", func(t *testing.T) {
+		// копия, сохраненная до перехода на ключ аккаунта, перешифровывается им
+		plainData, err := json.Marshal(&vault{Revision: 40})
+		require.Nil(t, err, "Encode vault")
+		data, err := crypto.EncryptAES(masterKey, plainData)
+		require.Nil(t, err, "Encrypt vault")
+
+		ctrl := gomock.NewController(t)
+		storage := mocks.NewMockStorage(ctrl)
+		storage.EXPECT().Key().Return(masterKey, nil)
+		storage.EXPECT().AccountKey().Return(testAccountKey, nil)
+		storage.EXPECT().Token().Return(testToken, nil)
+		storage.EXPECT().Vault().Return(data, nil)
+		storage.EXPECT().PutVault(gomock.Any()).DoAndReturn(func(vault []byte) error {
+			data = vault
+			return nil
+		})
+		client := mocks.NewMockClient(ctrl)
+		client.EXPECT().Changes(uint64(40), testToken).Return(dto.SecretChanges{Revision: 41}, nil)
+
+		_, err = NewSecret(client, storage).Sync()
+		require.Nil(t, err, "Sync error")
+		plainData, err = crypto.DecryptAES(testAccountKey, data)
+		require.Nil(t, err, "Decrypt vault with account key")
+		var v vault
+		require.Nil(t, json.Unmarshal(plainData, &v), "Decode saved vault")
+		assert.Equal(t, uint64(41), v.Revision, "Revision")
+	})
+
+	t.Run("undecryptable_vault", func(t *testing.T) {
+		// копия зашифрована чужим ключом, например после входа другого пользователя
+		oldKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+		require.Nil(t, err, "Old master key creation")
+		plainData, err := json.Marshal(newVault())
+		require.Nil(t, err, "Encode vault")
+		data, err := crypto.EncryptAES(oldKey, plainData)
+		require.Nil(t, err, "Encrypt vault")
+
+		ctrl := gomock.NewController(t)
+		storage := mocks.NewMockStorage(ctrl)
+		storage.EXPECT().Key().Return(masterKey, nil)
+		storage.EXPECT().AccountKey().Return(testAccountKey, nil)
+		storage.EXPECT().Token().Return(testToken, nil)
+		storage.EXPECT().Vault().Return(data, nil)
+		// копия откладывается и не перезаписывается
+		storage.EXPECT().SetVaultAside().Return("/home/user/.gophkeeper/cache/vault.20261018T100000", nil)
+
+		_, err = NewSecret(mocks.NewMockClient(ctrl), storage).Sync()
+		assert.ErrorIs(t, err, ErrVaultUndecryptable, "Sync error")
+		assert.ErrorContains(t, err, "vault.20261018T100000", "Path of vault set aside")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		storage, saved := testVaultStorage(t, masterKey, newVault())
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Upload(dto.SecretRequest{Name: "created offline"}, testToken).Return(nil)
+		client.EXPECT().Update(uint64(5), gomock.All(), testToken).
+			Return(fmt.Errorf("%w: %w", httpClient.ErrSecretUpdateFailed, httpClient.ErrSecretConflict))
+		client.EXPECT().Delete(uint64(7), testToken).
+			Return(fmt.Errorf("%w: %w", httpClient.ErrSecretDeleteFailed, httpClient.ErrSecretNotFound))
+		client.EXPECT().Changes(uint64(40), testToken).Return(dto.SecretChanges{
+			Revision: 45,
+			Changed:  []dto.SecretInfo{{ID: 5, Revision: 41}, {ID: 8, Revision: 42}, {ID: 9, Revision: 43}},
+			Deleted:  []uint64{4, 7},
+		}, nil)
+		client.EXPECT().Retrieve(uint64(5), testToken).
+			Return(dto.SecretResponse{ID: 5, Name: "changed on server", Revision: 41}, nil)
+		client.EXPECT().Retrieve(uint64(8), testToken).
+			Return(dto.SecretResponse{ID: 8, Name: "created offline", Revision: 42}, nil)
+		client.EXPECT().Retrieve(uint64(9), testToken).
+			Return(dto.SecretResponse{}, fmt.Errorf("%w: %w", httpClient.ErrSecretRetrieveFailed, httpClient.ErrSecretNotFound))
+
+		result, err := NewSecret(client, storage).Sync()
+		require.Nil(t, err, "Sync error")
+		assert.Equal(t, SyncResult{Pushed: 2, Pulled: 2, Removed: 1, Conflicts: []uint64{5}}, result, "Sync result")
+
+		v := saved()
+		assert.Equal(t, uint64(45), v.Revision, "Last sync revision")
+		assert.Empty(t, v.Created, "Created secrets in vault")
+		assert.Empty(t, v.Updated, "Updated secrets in vault")
+		assert.Empty(t, v.Deleted, "Deleted secrets in vault")
+		assert.Equal(t, map[uint64]conflict{
+			5: {Local: dto.SecretUpdateRequest{Name: "changed offline", Version: 2}},
+		}, v.Conflicts, "Conflicts in vault")
+		assert.Equal(t, map[uint64]dto.SecretResponse{
+			3: {ID: 3, Name: "unchanged", Revision: 30},
+			5: {ID: 5, Name: "changed on server", Revision: 41},
+			8: {ID: 8, Name: "created offline", Revision: 42},
+		}, v.Secrets, "Cached secrets")
+	})
+
+	t.Run("first_sync", func(t *testing.T) {
+		v := newVault()
+		v.Revision = 0
+		v.Created, v.Updated, v.Deleted = nil, nil, nil
+		storage, saved := testVaultStorage(t, masterKey, v)
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Changes(uint64(0), testToken).Return(dto.SecretChanges{
+			Revision: 32,
+			Changed:  []dto.SecretInfo{{ID: 3, Revision: 30}, {ID: 5, Revision: 32}},
+			Deleted:  []uint64{},
+		}, nil)
+		client.EXPECT().Retrieve(uint64(3), testToken).Return(dto.SecretResponse{ID: 3, Revision: 30}, nil)
+		client.EXPECT().Retrieve(uint64(5), testToken).Return(dto.SecretResponse{ID: 5, Revision: 32}, nil)
+
+		result, err := NewSecret(client, storage).Sync()
+		require.Nil(t, err, "Sync error")
+		assert.Equal(t, SyncResult{Pulled: 2, Removed: 1}, result, "Sync result")
+
+		assert.Equal(t, map[uint64]dto.SecretResponse{
+			3: {ID: 3, Revision: 30},
+			5: {ID: 5, Revision: 32},
+		}, saved().Secrets, "Cached secrets")
+	})
+
+	t.Run("server_unavailable", func(t *testing.T) {
+		storage, saved := testVaultStorage(t, masterKey, newVault())
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Upload(gomock.All(), testToken).Return(nil)
+		client.EXPECT().Update(uint64(5), gomock.All(), testToken).Return(testNetworkError)
+
+		_, err := NewSecret(client, storage).Sync()
+		assert.ErrorIs(t, err, httpClient.ErrSecretSendFailed, "Sync error")
+
+		v := saved()
+		assert.Equal(t, uint64(40), v.Revision, "Last sync revision")
+		assert.Empty(t, v.Created, "Sent secrets must not stay in vault")
+		assert.Len(t, v.Updated, 1, "Not sent changes must stay in vault")
+		assert.Equal(t, []uint64{7}, v.Deleted, "Not sent deletions must stay in vault")
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		v := newVault()
+		v.Created = []dto.SecretRequest{{Name: "over quota"}, {Name: "invalid"}, {Name: "created offline"}}
+		v.Rejected = []rejectedSecret{{Secret: dto.SecretRequest{Name: "rejected before"}, Reason: "quota"}}
+		storage, saved := testVaultStorage(t, masterKey, v)
+		client := mocks.NewMockClient(gomock.NewController(t))
+		gomock.InOrder(
+			client.EXPECT().Upload(dto.SecretRequest{Name: "over quota"}, testToken).
+				Return(fmt.Errorf("%w: %w", httpClient.ErrSecretSendFailed, httpClient.ErrQuotaExceeded)),
+			client.EXPECT().Upload(dto.SecretRequest{Name: "invalid"}, testToken).
+				Return(fmt.Errorf("%w: %w: bad", httpClient.ErrSecretSendFailed, httpClient.ErrSecretInvalid)),
+			client.EXPECT().Upload(dto.SecretRequest{Name: "created offline"}, testToken).Return(nil),
+			client.EXPECT().Upload(dto.SecretRequest{Name: "rejected before"}, testToken).Return(nil),
+		)
+		client.EXPECT().Update(uint64(5), gomock.All(), testToken).
+			Return(fmt.Errorf("%w: %w", httpClient.ErrSecretUpdateFailed, httpClient.ErrQuotaExceeded))
+		client.EXPECT().Delete(uint64(7), testToken).Return(nil)
+		client.EXPECT().Changes(uint64(40), testToken).Return(dto.SecretChanges{Revision: 40}, nil)
+
+		result, err := NewSecret(client, storage).Sync()
+		require.Nil(t, err, "Sync error")
+		assert.Equal(t, 3, result.Pushed, "Pushed changes")
+		require.Len(t, result.Rejected, 3, "Rejected changes")
+		assert.Equal(t, "over quota", result.Rejected[0].Name, "First rejected secret")
+		assert.Contains(t, result.Rejected[0].Reason, httpClient.ErrQuotaExceeded.Error(), "Rejection reason")
+		assert.Equal(t, "invalid", result.Rejected[1].Name, "Second rejected secret")
+		assert.Equal(t, uint64(5), result.Rejected[2].ID, "Rejected update")
+
+		v = saved()
+		assert.Empty(t, v.Created, "Created secrets in vault")
+		require.Len(t, v.Rejected, 2, "Rejected secrets in vault")
+		assert.Equal(t, "over quota", v.Rejected[0].Secret.Name, "First rejected secret in vault")
+		assert.Equal(t, "invalid", v.Rejected[1].Secret.Name, "Second rejected secret in vault")
+		assert.Len(t, v.Updated, 1, "Rejected update stays in vault")
+		assert.Empty(t, v.Deleted, "Deleted secrets in vault")
+	})
+
+	t.Run("rejected_then_unavailable", func(t *testing.T) {
+		v := newVault()
+		v.Rejected = []rejectedSecret{{Secret: dto.SecretRequest{Name: "rejected before"}, Reason: "quota"}}
+		storage, saved := testVaultStorage(t, masterKey, v)
+		client := mocks.NewMockClient(gomock.NewController(t))
+		client.EXPECT().Upload(dto.SecretRequest{Name: "created offline"}, testToken).Return(testNetworkError)
+
+		_, err := NewSecret(client, storage).Sync()
+		assert.ErrorIs(t, err, httpClient.ErrSecretSendFailed, "Sync error")
+
+		v = saved()
+		assert.Len(t, v.Created, 1, "Not sent secrets must stay in vault")
+		assert.Equal(t, []rejectedSecret{{Secret: dto.SecretRequest{Name: "rejected before"}, Reason: "quota"}},
+			v.Rejected, "Rejected secrets must stay in vault")
+	})
+
+	t.Run("without_key", func(t *testing.T) {
+		storage := mocks.NewMockStorage(gomock.NewController(t))
+		storage.EXPECT().Key().Return(nil, fmt.Errorf("any error"))
+		client := mocks.NewMockClient(gomock.NewController(t))
+
+		_, err := NewSecret(client, storage).Sync()
+		assert.ErrorIs(t, err, ErrAuthorizationFailed, "Sync error")
+	})
+}
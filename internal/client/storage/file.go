@@ -2,24 +2,45 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 const (
-	parentDirName  = ".gophkeeper"
-	storageDirName = "cache"
-	tokenFileName  = "token"
-	keyFileName    = "key"
+	parentDirName        = ".gophkeeper"
+	storageDirName       = "cache"
+	tokenFileName        = "token"
+	refreshTokenFileName = "refresh_token"
+	tokenLockFileName    = "token.lock"
+	keyFileName          = "key"
+	accountKeyFileName   = "account_key"
+	vaultFileName        = "vault"
+	serverKeysDirName    = "server_keys"
+)
+
+// Параметры блокировки обновления токенов: сколько ждать блокировку, занятую другим процессом,
+// и через сколько считать ее брошенной (процесс завершился, не сняв ее)
+const (
+	tokenLockWait  = 30 * time.Second
+	tokenLockStale = time.Minute
+	tokenLockPoll  = 50 * time.Millisecond
 )
 
 // Файловое хранилище данных для токена авторизации и мастер ключа.
 // Не самое безопасное решение, в дальнейшем планирую переделать на go-keyring
 // или на хранилище в памяти, в случае если пределаю клиент на полностью интерактивный cli.
 type FileStorage struct {
-	tokenPath string
-	keyPath   string
+	tokenPath        string
+	refreshTokenPath string
+	tokenLockPath    string
+	keyPath          string
+	accountKeyPath   string
+	vaultPath        string
+	serverKeysPath   string
 }
 
 func NewFileSorage() (*FileStorage, error) {
@@ -35,8 +56,13 @@ func NewFileSorage() (*FileStorage, error) {
 	}
 
 	s := FileStorage{
-		tokenPath: filepath.Join(path, tokenFileName),
-		keyPath:   filepath.Join(path, keyFileName),
+		tokenPath:        filepath.Join(path, tokenFileName),
+		refreshTokenPath: filepath.Join(path, refreshTokenFileName),
+		tokenLockPath:    filepath.Join(path, tokenLockFileName),
+		keyPath:          filepath.Join(path, keyFileName),
+		accountKeyPath:   filepath.Join(path, accountKeyFileName),
+		vaultPath:        filepath.Join(path, vaultFileName),
+		serverKeysPath:   filepath.Join(path, serverKeysDirName),
 	}
 
 	return &s, nil
@@ -60,6 +86,51 @@ func (s *FileStorage) Token() (string, error) {
 	return string(token), nil
 }
 
+// PutRefreshToken сохранение refresh токена
+func (s *FileStorage) PutRefreshToken(token string) error {
+	err := os.WriteFile(s.refreshTokenPath, []byte(token), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write refresh token: %w", err)
+	}
+	return nil
+}
+
+// RefreshToken получение refresh токена
+func (s *FileStorage) RefreshToken() (string, error) {
+	token, err := os.ReadFile(s.refreshTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return string(token), nil
+}
+
+// LockTokens блокирует обновление токенов другими процессами клиента и возвращает функцию,
+// снимающую блокировку. Блокировка - файл рядом с токенами, созданный с O_EXCL.
+// Если блокировку держит другой процесс, ждет ее до tokenLockWait.
+func (s *FileStorage) LockTokens() (func(), error) {
+	deadline := time.Now().Add(tokenLockWait)
+	for {
+		file, err := os.OpenFile(s.tokenLockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { _ = os.Remove(s.tokenLockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock tokens: %w", err)
+		}
+
+		// Блокировку, брошенную завершившимся процессом, снимаем
+		if info, err := os.Stat(s.tokenLockPath); err == nil && time.Since(info.ModTime()) > tokenLockStale {
+			_ = os.Remove(s.tokenLockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock tokens: %s is held by another process", s.tokenLockPath)
+		}
+		time.Sleep(tokenLockPoll)
+	}
+}
+
 // PutKey сохранение ключа
 func (s *FileStorage) PutKey(key []byte) error {
 	err := os.WriteFile(s.keyPath, key, 0600)
@@ -77,3 +148,112 @@ func (s *FileStorage) Key() ([]byte, error) {
 	}
 	return key, nil
 }
+
+// PutAccountKey сохранение ключа аккаунта
+func (s *FileStorage) PutAccountKey(key []byte) error {
+	err := os.WriteFile(s.accountKeyPath, key, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write account key: %w", err)
+	}
+	return nil
+}
+
+// AccountKey получение ключа аккаунта
+func (s *FileStorage) AccountKey() ([]byte, error) {
+	key, err := os.ReadFile(s.accountKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account key: %w", err)
+	}
+	return key, nil
+}
+
+// PutVault сохранение зашифрованной локальной копии секретов
+func (s *FileStorage) PutVault(vault []byte) error {
+	// Пишем во временный файл и переименовываем его,
+	// чтобы сбой во время записи не испортил предыдущую копию
+	tmpPath := s.vaultPath + ".tmp"
+	err := os.WriteFile(tmpPath, vault, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+
+	err = os.Rename(tmpPath, s.vaultPath)
+	if err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
+
+// Vault получение зашифрованной локальной копии секретов.
+// Если локальная копия еще не создана, возвращает nil.
+func (s *FileStorage) Vault() ([]byte, error) {
+	vault, err := os.ReadFile(s.vaultPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get vault: %w", err)
+	}
+	return vault, nil
+}
+
+// SetVaultAside переименовывает локальную копию секретов, добавляя к имени время, и возвращает
+// путь к ней. Отложенная копия не перезаписывается новой и не удаляется Wipe.
+func (s *FileStorage) SetVaultAside() (string, error) {
+	path := s.vaultPath + "." + time.Now().UTC().Format("20060102T150405.000000000")
+	if err := os.Rename(s.vaultPath, path); err != nil {
+		return "", fmt.Errorf("failed to set vault aside: %w", err)
+	}
+	return path, nil
+}
+
+// PutServerKey сохранение закрепленного публичного ключа сервера addr
+func (s *FileStorage) PutServerKey(addr string, key []byte) error {
+	if err := os.MkdirAll(s.serverKeysPath, 0700); err != nil {
+		return fmt.Errorf("failed to write server key: %w", err)
+	}
+
+	err := os.WriteFile(s.serverKeyPath(addr), key, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write server key: %w", err)
+	}
+	return nil
+}
+
+// ServerKey получение закрепленного публичного ключа сервера addr.
+// Если ключ еще не закреплен, возвращает nil.
+func (s *FileStorage) ServerKey(addr string) ([]byte, error) {
+	key, err := os.ReadFile(s.serverKeyPath(addr))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get server key: %w", err)
+	}
+	return key, nil
+}
+
+// serverKeyPath путь к файлу ключа сервера, символы адреса,
+// недопустимые в имени файла, заменяются на "_"
+func (s *FileStorage) serverKeyPath(addr string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, addr)
+	return filepath.Join(s.serverKeysPath, name)
+}
+
+// Wipe удаляет токены, мастер ключ, ключ аккаунта и локальную копию секретов.
+// Закрепленные ключи серверов не удаляются. Отсутствующие файлы ошибкой не считаются.
+func (s *FileStorage) Wipe() error {
+	paths := []string{s.tokenPath, s.refreshTokenPath, s.keyPath, s.accountKeyPath, s.vaultPath}
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to wipe storage: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,6 +87,83 @@ func Test_Token(t *testing.T) {
 	}
 }
 
+func Test_PutRefreshToken(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+	tokenPath := filepath.Join(homeDir, parentDirName, storageDirName, refreshTokenFileName)
+	badTokenPath := filepath.Join(homeDir, "not_exist", refreshTokenFileName)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "success", path: tokenPath},
+		{
+			name: "fail",
+			path: badTokenPath,
+			wantErr: "failed to write refresh token: open " +
+				badTokenPath + ": no such file or directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage.refreshTokenPath = test.path
+
+			err := storage.PutRefreshToken("refresh_token_string")
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Put refresh token error")
+		})
+	}
+}
+
+func Test_RefreshToken(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+	tokenPath := filepath.Join(homeDir, parentDirName, storageDirName, refreshTokenFileName)
+	badTokenPath := filepath.Join(homeDir, "not_exist", refreshTokenFileName)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	err = storage.PutRefreshToken("refresh_token_string")
+	require.Nil(t, err, "Set refresh token value")
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "success", path: tokenPath, want: "refresh_token_string"},
+		{
+			name: "fail",
+			path: badTokenPath,
+			wantErr: "failed to get refresh token: open " +
+				badTokenPath + ": no such file or directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage.refreshTokenPath = test.path
+
+			got, err := storage.RefreshToken()
+			assert.Equal(t, test.want, got, "Get refresh token")
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Get refresh token error")
+		})
+	}
+}
+
 func Test_PutKey(t *testing.T) {
 	homeDir := testSetupEHomeDir(t)
 	keyPath := filepath.Join(homeDir, parentDirName, storageDirName, keyFileName)
@@ -162,6 +241,237 @@ func Test_Key(t *testing.T) {
 	}
 }
 
+func Test_AccountKey(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+	badKeyPath := filepath.Join(homeDir, "not_exist", accountKeyFileName)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	err = storage.PutAccountKey([]byte("account_key_data"))
+	require.Nil(t, err, "Set account key value")
+
+	got, err := storage.AccountKey()
+	require.Nil(t, err, "Get account key")
+	assert.Equal(t, []byte("account_key_data"), got, "Get account key")
+
+	// ключ аккаунта хранится отдельно от мастер ключа
+	_, err = storage.Key()
+	assert.NotNil(t, err, "Key without master key")
+
+	storage.accountKeyPath = badKeyPath
+	err = storage.PutAccountKey([]byte("account_key_data"))
+	assert.EqualError(
+		t,
+		err,
+		"failed to write account key: open "+badKeyPath+": no such file or directory",
+		"Put account key error",
+	)
+}
+
+func Test_PutVault(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+	vaultPath := filepath.Join(homeDir, parentDirName, storageDirName, vaultFileName)
+	badVaultPath := filepath.Join(homeDir, "not_exist", vaultFileName)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "success", path: vaultPath},
+		{
+			name: "fail",
+			path: badVaultPath,
+			wantErr: "failed to write vault: open " +
+				badVaultPath + ".tmp: no such file or directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage.vaultPath = test.path
+
+			err := storage.PutVault([]byte("vault_data"))
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Put vault error")
+		})
+	}
+}
+
+func Test_Vault(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+	vaultPath := filepath.Join(homeDir, parentDirName, storageDirName, vaultFileName)
+	notExistVaultPath := filepath.Join(homeDir, "not_exist", vaultFileName)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	err = storage.PutVault([]byte("vault_data"))
+	require.Nil(t, err, "Set vault value")
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []byte
+		wantErr string
+	}{
+		{name: "success", path: vaultPath, want: []byte("vault_data")},
+		{name: "not_exist", path: notExistVaultPath},
+		{
+			name:    "fail",
+			path:    homeDir,
+			wantErr: "failed to get vault: read " + homeDir + ": is a directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage.vaultPath = test.path
+
+			got, err := storage.Vault()
+			assert.Equal(t, test.want, got, "Get vault")
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			assert.Equal(t, test.wantErr, gotErr, "Get vault error")
+		})
+	}
+}
+
+func Test_SetVaultAside(t *testing.T) {
+	testSetupEHomeDir(t)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+	require.Nil(t, storage.PutVault([]byte("vault_data")), "Set vault value")
+
+	path, err := storage.SetVaultAside()
+	require.Nil(t, err, "Set vault aside")
+	data, err := os.ReadFile(path)
+	require.Nil(t, err, "Read vault set aside")
+	assert.Equal(t, []byte("vault_data"), data, "Vault set aside")
+
+	// новая копия и Wipe не трогают отложенную
+	got, err := storage.Vault()
+	require.Nil(t, err, "Get vault")
+	assert.Nil(t, got, "Vault after set aside")
+	require.Nil(t, storage.PutVault([]byte("new_vault_data")), "Set new vault value")
+	require.Nil(t, storage.Wipe(), "Wipe storage")
+	_, err = os.Stat(path)
+	assert.Nil(t, err, "Vault set aside after wipe")
+
+	_, err = storage.SetVaultAside()
+	assert.ErrorContains(t, err, "failed to set vault aside", "Set absent vault aside")
+}
+
+func Test_Wipe(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	require.Nil(t, storage.PutToken("token_string"), "Set token value")
+	require.Nil(t, storage.PutRefreshToken("refresh_token_string"), "Set refresh token value")
+	require.Nil(t, storage.PutKey([]byte("key_data")), "Set key value")
+	require.Nil(t, storage.PutAccountKey([]byte("account_key_data")), "Set account key value")
+
+	// vault еще не создан, отсутствующий файл не ошибка
+	err = storage.Wipe()
+	require.Nil(t, err, "Wipe storage")
+
+	_, err = storage.Token()
+	assert.NotNil(t, err, "Token after wipe")
+	_, err = storage.RefreshToken()
+	assert.NotNil(t, err, "Refresh token after wipe")
+	_, err = storage.Key()
+	assert.NotNil(t, err, "Key after wipe")
+	_, err = storage.AccountKey()
+	assert.NotNil(t, err, "Account key after wipe")
+
+	// непустую директорию удалить нельзя
+	storage.keyPath = homeDir
+	err = storage.Wipe()
+	assert.ErrorContains(t, err, "failed to wipe storage", "Wipe storage error")
+}
+
+func Test_ServerKey(t *testing.T) {
+	homeDir := testSetupEHomeDir(t)
+
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	got, err := storage.ServerKey("https://localhost:8443/api")
+	require.Nil(t, err, "Get not pinned server key")
+	assert.Nil(t, got, "Get not pinned server key")
+
+	err = storage.PutServerKey("https://localhost:8443/api", []byte("server_key_data"))
+	require.Nil(t, err, "Set server key value")
+
+	got, err = storage.ServerKey("https://localhost:8443/api")
+	require.Nil(t, err, "Get server key")
+	assert.Equal(t, []byte("server_key_data"), got, "Get server key")
+
+	// ключи разных серверов хранятся отдельно
+	got, err = storage.ServerKey("https://example.com:8443/api")
+	require.Nil(t, err, "Get other server key")
+	assert.Nil(t, got, "Get other server key")
+
+	// ключ сервера переживает выход из аккаунта
+	require.Nil(t, storage.Wipe(), "Wipe storage")
+	got, err = storage.ServerKey("https://localhost:8443/api")
+	require.Nil(t, err, "Get server key after wipe")
+	assert.Equal(t, []byte("server_key_data"), got, "Get server key after wipe")
+
+	storage.serverKeysPath = filepath.Join(homeDir, parentDirName, storageDirName, keyFileName)
+	require.Nil(t, storage.PutKey([]byte("key_data")), "Set key value")
+	err = storage.PutServerKey("https://localhost:8443/api", []byte("server_key_data"))
+	assert.ErrorContains(t, err, "failed to write server key", "Put server key error")
+}
+
+func Test_LockTokens(t *testing.T) {
+	testSetupEHomeDir(t)
+	storage, err := NewFileSorage()
+	require.Nil(t, err, "Create file storage")
+
+	unlock, err := storage.LockTokens()
+	require.Nil(t, err, "Lock tokens")
+
+	// второй процесс ждет, пока первый снимет блокировку
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := storage.LockTokens()
+		assert.Nil(t, err, "Lock tokens after unlock")
+		close(locked)
+		unlock()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("Lock acquired while held")
+	case <-time.After(5 * tokenLockPoll):
+	}
+	unlock()
+	<-locked
+
+	// брошенная блокировка снимается
+	require.Nil(t, os.WriteFile(storage.tokenLockPath, nil, 0600), "Create stale lock")
+	old := time.Now().Add(-2 * tokenLockStale)
+	require.Nil(t, os.Chtimes(storage.tokenLockPath, old, old), "Age lock")
+	unlock, err = storage.LockTokens()
+	require.Nil(t, err, "Lock tokens over stale lock")
+	unlock()
+	_, err = os.Stat(storage.tokenLockPath)
+	assert.ErrorIs(t, err, os.ErrNotExist, "Lock file removed")
+}
+
 func testSetupEHomeDir(t *testing.T) string {
 	tmpDir := t.TempDir()
 	// Linux / macOS (XDG)
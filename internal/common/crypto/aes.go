@@ -9,38 +9,108 @@ import (
 
 // EncryptAES шифрует plainText ключом key используя AES-256-GCM.
 // Принимает key длиной 32 байта и plainData данные для шифрования.
-// Возвращает []byte в формате [Nonce (12 байт) + EncryptedData + Tag (16 байт)]
+// Возвращает []byte в формате конверта [Header + Nonce (12 байт) + EncryptedData + Tag (16 байт)]
 func EncryptAES(key, plainData []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
+	return sealAES(key, plainData, newHeader(nil), nil)
+}
+
+// EncryptAESWithKDF шифрует plainText ключом key, вычисленным из пароля с параметрами params,
+// и записывает параметры в заголовок конверта.
+func EncryptAESWithKDF(key, plainData []byte, params KDFParams) ([]byte, error) {
+	return sealAES(key, plainData, newHeader(&params), nil)
+}
+
+// EncryptAESWithAD шифрует plainText ключом key и аутентифицирует вместе с ним
+// associated data ad, которые не сохраняются в результате.
+// Расшифровать результат можно только DecryptAESWithAD с теми же ad.
+func EncryptAESWithAD(key, plainData, ad []byte) ([]byte, error) {
+	h := newHeader(nil)
+	h.Version = EnvelopeVersion2
+	return sealAES(key, plainData, h, ad)
+}
+
+// DecryptAES расшифровывает data ключом key
+// Ожидает конверт, созданный EncryptAES, или данные старого формата без заголовка:
+// [Nonce (12 байт) + Ciphertext + Tag]
+func DecryptAES(key, data []byte) ([]byte, error) {
+	return DecryptAESWithAD(key, data, nil)
+}
+
+// DecryptAESWithAD расшифровывает data ключом key, проверяя associated data ad
+// для конверта, созданного EncryptAESWithAD. Конверты первой версии и данные
+// старого формата расшифровываются без ad.
+func DecryptAESWithAD(key, data, ad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+
+	if h, n, err := parseHeader(data); err == nil {
+		decryptedData, err := openAES(gcm, data[n:], associatedData(h, data[:n], ad))
+		if err == nil {
+			return decryptedData, nil
+		}
+	}
+
+	// Начало nonce данных старого формата может совпасть с заголовком,
+	// поэтому при неудаче они расшифровываются целиком
+	decryptedData, err := openAES(gcm, data, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
-	nonce, err := GenerateRandomBytes(gcm.NonceSize())
+	return decryptedData, nil
+}
+
+// DecryptAESWithADStrict расшифровывает data ключом key, проверяя associated data ad,
+// и принимает только конверт, созданный EncryptAESWithAD. Для конвертов первой версии
+// и данных старого формата возвращает ErrEnvelopeUnbound, поэтому привязанные данные
+// нельзя подменить данными, зашифрованными без привязки.
+func DecryptAESWithADStrict(key, data, ad []byte) ([]byte, error) {
+	h, n, err := parseHeader(data)
+	if err != nil || h.Version != EnvelopeVersion2 {
+		return nil, ErrEnvelopeUnbound
+	}
+
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		return nil, err
 	}
 
-	return gcm.Seal(nonce, nonce, plainData, nil), nil
+	return openAES(gcm, data[n:], associatedData(h, data[:n], ad))
 }
 
-// DecryptAES расшифровывает data ключом key
-// Ожидает формат данных: [Nonce (12 байт) + Ciphertext + Tag]
-func DecryptAES(key, data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
+// sealAES шифрует plainData и дописывает результат к заголовку h,
+// заголовок и ad для конверта второй версии аутентифицируются вместе с данными.
+func sealAES(key, plainData []byte, h Header, ad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	nonce, err := GenerateRandomBytes(gcm.NonceSize())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
+	header := h.marshal()
+	result := append(header, nonce...)
+	return gcm.Seal(result, nonce, plainData, associatedData(h, header, ad)), nil
+}
+
+// associatedData возвращает associated data AES-GCM для конверта с заголовком h,
+// закодированным в header.
+func associatedData(h Header, header, ad []byte) []byte {
+	if h.Version != EnvelopeVersion2 {
+		return header
+	}
+
+	result := make([]byte, 0, len(header)+len(ad))
+	return append(append(result, header...), ad...)
+}
+
+// openAES расшифровывает [Nonce + Ciphertext + Tag] с associated data ad.
+func openAES(gcm cipher.AEAD, data, ad []byte) ([]byte, error) {
 	nonceSize := gcm.NonceSize()
 	if len(data) < nonceSize {
 		return nil, errors.New("ciphertext too short")
@@ -48,10 +118,25 @@ func DecryptAES(key, data []byte) ([]byte, error) {
 
 	nonce, ecryptedData := data[:nonceSize], data[nonceSize:]
 
-	decryptedData, err := gcm.Open(nil, nonce, ecryptedData, nil)
+	decryptedData, err := gcm.Open(nil, nonce, ecryptedData, ad)
 	if err != nil {
 		return nil, fmt.Errorf("decryption failed: %w", err)
 	}
 
 	return decryptedData, nil
 }
+
+// newGCM создает AES-GCM для ключа key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return gcm, nil
+}
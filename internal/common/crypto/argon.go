@@ -16,18 +16,64 @@ const (
 	SaltLen      = 16        // Рекомендуемая длина соли (16 байт)
 )
 
+// Допустимые границы параметров Argon2id, получаемых от сервера
+const (
+	ArgonMinTime    = 1
+	ArgonMaxTime    = 16
+	ArgonMinMemory  = 16 * 1024   // 16 MB
+	ArgonMaxMemory  = 1024 * 1024 // 1 GB
+	ArgonMaxThreads = 64
+)
+
+// KDFParams параметры Argon2id, с которыми из пароля вычисляется ключ.
+// Хранятся у пользователя, чтобы их можно было усилить, не теряя доступ к старым данным.
+type KDFParams struct {
+	Time    uint32
+	Memory  uint32 // в КБ
+	Threads uint8
+}
+
+// DefaultKDFParams возвращает текущие рекомендуемые параметры Argon2id.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{Time: ArgonTime, Memory: ArgonMemory, Threads: ArgonThreads}
+}
+
+// Validate проверяет, что параметры в допустимых границах: слабые параметры
+// облегчают перебор пароля, а слишком большие позволяют исчерпать память клиента.
+func (p KDFParams) Validate() error {
+	if p.Time < ArgonMinTime || p.Time > ArgonMaxTime {
+		return fmt.Errorf("kdf time must be from %d to %d", ArgonMinTime, ArgonMaxTime)
+	}
+	if p.Memory < ArgonMinMemory || p.Memory > ArgonMaxMemory {
+		return fmt.Errorf("kdf memory must be from %d to %d KB", ArgonMinMemory, ArgonMaxMemory)
+	}
+	if p.Threads == 0 || p.Threads > ArgonMaxThreads {
+		return fmt.Errorf("kdf threads must be from 1 to %d", ArgonMaxThreads)
+	}
+	return nil
+}
+
 // DeriveKey генерирует криптостойкий ключ длиной 32 байта из пароля и соли
-// используя алгоритм Argon2id
+// используя алгоритм Argon2id c параметрами по умолчанию
 //
 // Используется для получения:
 // - Master_Key (из пароля и EncrSalt)
 // - Auth_Key (из пароля и AuthSalt)
 func DeriveKey(password, salt []byte) ([]byte, error) {
+	return DeriveKeyWithParams(password, salt, DefaultKDFParams())
+}
+
+// DeriveKeyWithParams генерирует ключ длиной 32 байта из пароля и соли
+// используя алгоритм Argon2id с параметрами params.
+func DeriveKeyWithParams(password, salt []byte, params KDFParams) ([]byte, error) {
 	if len(salt) == 0 {
 		return nil, fmt.Errorf("salt cannot be empty")
 	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
 
-	key := argon2.IDKey(password, salt, ArgonTime, ArgonMemory, ArgonThreads, KeyLen)
+	key := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, KeyLen)
 
 	return key, nil
 }
@@ -81,3 +81,51 @@ func BenchmarkDeriveKey(b *testing.B) {
 		_, _ = DeriveKey(password, salt)
 	}
 }
+
+func TestDeriveKeyWithParams(t *testing.T) {
+	password := []byte("password")
+	salt := []byte("random_salt_16_b")
+
+	key1, err := DeriveKeyWithParams(password, salt, DefaultKDFParams())
+	if err != nil {
+		t.Fatalf("DeriveKeyWithParams failed: %v", err)
+	}
+	key2, _ := DeriveKey(password, salt)
+	if !bytes.Equal(key1, key2) {
+		t.Error("DeriveKey must use default params")
+	}
+
+	stronger := DefaultKDFParams()
+	stronger.Time++
+	key3, err := DeriveKeyWithParams(password, salt, stronger)
+	if err != nil {
+		t.Fatalf("DeriveKeyWithParams failed: %v", err)
+	}
+	if bytes.Equal(key1, key3) {
+		t.Error("DeriveKeyWithParams produced same keys for different params")
+	}
+}
+
+func TestKDFParams_Validate(t *testing.T) {
+	tests := map[string]KDFParams{
+		"zero":         {},
+		"weak_time":    {Time: 0, Memory: ArgonMemory, Threads: ArgonThreads},
+		"weak_memory":  {Time: ArgonTime, Memory: 1024, Threads: ArgonThreads},
+		"huge_memory":  {Time: ArgonTime, Memory: 4 * 1024 * 1024, Threads: ArgonThreads},
+		"huge_time":    {Time: 1000, Memory: ArgonMemory, Threads: ArgonThreads},
+		"no_threads":   {Time: ArgonTime, Memory: ArgonMemory},
+		"many_threads": {Time: ArgonTime, Memory: ArgonMemory, Threads: 255},
+	}
+
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := params.Validate(); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	if err := DefaultKDFParams().Validate(); err != nil {
+		t.Errorf("Default params must be valid: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Метка HKDF для ключа аутентификации, выводимого из мастер ключа
+const loginAuthInfo = "gophkeeper login auth"
+
+// DeriveAuthKey выводит из мастер ключа ключ аутентификации, который клиент
+// отправляет на сервер вместо пароля. По ключу аутентификации нельзя вычислить
+// мастер ключ, поэтому сервер не может расшифровать данные пользователя.
+func DeriveAuthKey(masterKey []byte) ([]byte, error) {
+	if len(masterKey) != KeyLen {
+		return nil, fmt.Errorf("master key must be %d bytes", KeyLen)
+	}
+
+	authKey := make([]byte, KeyLen)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, []byte(loginAuthInfo)), authKey); err != nil {
+		return nil, err
+	}
+
+	return authKey, nil
+}
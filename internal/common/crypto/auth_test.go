@@ -0,0 +1,36 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveAuthKey(t *testing.T) {
+	masterKey, _ := GenerateRandomBytes(KeyLen)
+
+	authKey, err := DeriveAuthKey(masterKey)
+	if err != nil {
+		t.Fatalf("DeriveAuthKey failed: %v", err)
+	}
+	if len(authKey) != KeyLen {
+		t.Errorf("Expected auth key length %d, got %d", KeyLen, len(authKey))
+	}
+	if bytes.Equal(authKey, masterKey) {
+		t.Errorf("Auth key must differ from master key")
+	}
+
+	again, _ := DeriveAuthKey(masterKey)
+	if !bytes.Equal(authKey, again) {
+		t.Errorf("DeriveAuthKey must be deterministic")
+	}
+
+	// Ключ аутентификации не совпадает с ключами, выведенными из ключа восстановления
+	wrapKey, recoveryAuthKey, _ := DeriveRecoveryKeys(masterKey)
+	if bytes.Equal(authKey, wrapKey) || bytes.Equal(authKey, recoveryAuthKey) {
+		t.Errorf("Auth key must differ from recovery keys")
+	}
+
+	if _, err := DeriveAuthKey(masterKey[:16]); err == nil {
+		t.Errorf("Expected error for short master key")
+	}
+}
@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Формат конверта зашифрованных данных:
+// [Version (1 байт)][Cipher (1 байт)][KDF (1 байт)][параметры KDF (9 байт), если KDF = KDFArgon2id]
+// [Nonce (12 байт)][EncryptedData + Tag (16 байт)].
+// Заголовок аутентифицируется как associated data AES-GCM, поэтому подмена версии,
+// алгоритма или параметров KDF обнаруживается при расшифровке.
+const (
+	EnvelopeVersion1 uint8 = 1
+	// Кроме заголовка аутентифицируются associated data вызывающего, см. EncryptAESWithAD
+	EnvelopeVersion2 uint8 = 2
+
+	CipherAES256GCM uint8 = 1
+
+	// Ключ шифрования случайный
+	KDFNone uint8 = 0
+	// Ключ шифрования вычислен из пароля алгоритмом Argon2id
+	KDFArgon2id uint8 = 1
+
+	envelopeHeaderLen = 3
+	kdfParamsLen      = 9
+)
+
+var (
+	ErrInvalidEnvelope = errors.New("invalid envelope header")
+	// Данные зашифрованы без associated data, см. DecryptAESWithADStrict
+	ErrEnvelopeUnbound = errors.New("envelope is not bound to associated data")
+)
+
+// Header заголовок конверта.
+type Header struct {
+	Version uint8
+	Cipher  uint8
+	// Параметры Argon2id, которыми из пароля вычислен ключ шифрования, nil для случайного ключа
+	KDF *KDFParams
+}
+
+// ParseHeader читает заголовок конверта. Для данных старого формата без заголовка
+// или неизвестной версии возвращает ErrInvalidEnvelope.
+func ParseHeader(data []byte) (Header, error) {
+	h, _, err := parseHeader(data)
+	return h, err
+}
+
+// newHeader создает заголовок текущей версии.
+func newHeader(kdf *KDFParams) Header {
+	return Header{Version: EnvelopeVersion1, Cipher: CipherAES256GCM, KDF: kdf}
+}
+
+// marshal кодирует заголовок.
+func (h Header) marshal() []byte {
+	if h.KDF == nil {
+		return []byte{h.Version, h.Cipher, KDFNone}
+	}
+
+	b := make([]byte, envelopeHeaderLen+kdfParamsLen)
+	b[0], b[1], b[2] = h.Version, h.Cipher, KDFArgon2id
+	binary.BigEndian.PutUint32(b[3:], h.KDF.Time)
+	binary.BigEndian.PutUint32(b[7:], h.KDF.Memory)
+	b[11] = h.KDF.Threads
+	return b
+}
+
+// parseHeader читает заголовок и возвращает его длину.
+func parseHeader(data []byte) (Header, int, error) {
+	var h Header
+
+	if len(data) < envelopeHeaderLen {
+		return h, 0, ErrInvalidEnvelope
+	}
+	h.Version, h.Cipher = data[0], data[1]
+	if (h.Version != EnvelopeVersion1 && h.Version != EnvelopeVersion2) || h.Cipher != CipherAES256GCM {
+		return h, 0, ErrInvalidEnvelope
+	}
+
+	switch data[2] {
+	case KDFNone:
+		return h, envelopeHeaderLen, nil
+	case KDFArgon2id:
+		if len(data) < envelopeHeaderLen+kdfParamsLen {
+			return h, 0, ErrInvalidEnvelope
+		}
+		h.KDF = &KDFParams{
+			Time:    binary.BigEndian.Uint32(data[3:]),
+			Memory:  binary.BigEndian.Uint32(data[7:]),
+			Threads: data[11],
+		}
+		return h, envelopeHeaderLen + kdfParamsLen, nil
+	default:
+		return h, 0, ErrInvalidEnvelope
+	}
+}
@@ -0,0 +1,189 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"testing"
+)
+
+func TestEncryptAES_Envelope(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+
+	encrypted, err := EncryptAES(key, []byte("data"))
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+
+	h, err := ParseHeader(encrypted)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if h.Version != EnvelopeVersion1 || h.Cipher != CipherAES256GCM || h.KDF != nil {
+		t.Errorf("Unexpected header: %+v", h)
+	}
+}
+
+func TestEncryptAESWithKDF(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+	params := KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}
+
+	encrypted, err := EncryptAESWithKDF(key, []byte("data"), params)
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+
+	h, err := ParseHeader(encrypted)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if h.KDF == nil || *h.KDF != params {
+		t.Errorf("KDF params: got %+v, want %+v", h.KDF, params)
+	}
+
+	decrypted, err := DecryptAES(key, encrypted)
+	if err != nil {
+		t.Fatalf("Decryption failed: %v", err)
+	}
+	if !bytes.Equal([]byte("data"), decrypted) {
+		t.Errorf("Decrypted data: got %q", decrypted)
+	}
+}
+
+func TestDecryptAES_TamperedHeader(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+	encrypted, _ := EncryptAESWithKDF(key, []byte("data"), DefaultKDFParams())
+
+	// Понижение параметров KDF в заголовке
+	encrypted[4] ^= 0x01
+
+	if _, err := DecryptAES(key, encrypted); err == nil {
+		t.Error("Expected error for tampered header, got nil")
+	}
+}
+
+func TestEncryptAESWithAD(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+	ad := []byte("associated data")
+
+	encrypted, err := EncryptAESWithAD(key, []byte("data"), ad)
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+
+	h, err := ParseHeader(encrypted)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if h.Version != EnvelopeVersion2 {
+		t.Errorf("Envelope version: got %d, want %d", h.Version, EnvelopeVersion2)
+	}
+
+	decrypted, err := DecryptAESWithAD(key, encrypted, ad)
+	if err != nil {
+		t.Fatalf("Decryption failed: %v", err)
+	}
+	if !bytes.Equal([]byte("data"), decrypted) {
+		t.Errorf("Decrypted data: got %q", decrypted)
+	}
+
+	if _, err := DecryptAESWithAD(key, encrypted, []byte("other data")); err == nil {
+		t.Error("Expected error for wrong associated data, got nil")
+	}
+	if _, err := DecryptAES(key, encrypted); err == nil {
+		t.Error("Expected error for missing associated data, got nil")
+	}
+
+	// Понижение версии конверта, чтобы associated data не проверялись
+	encrypted[0] = EnvelopeVersion1
+	if _, err := DecryptAESWithAD(key, encrypted, ad); err == nil {
+		t.Error("Expected error for downgraded envelope, got nil")
+	}
+}
+
+func TestDecryptAESWithAD_Version1(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+	encrypted, _ := EncryptAES(key, []byte("data"))
+
+	// Конверты первой версии расшифровываются без associated data
+	decrypted, err := DecryptAESWithAD(key, encrypted, []byte("associated data"))
+	if err != nil {
+		t.Fatalf("Decryption failed: %v", err)
+	}
+	if !bytes.Equal([]byte("data"), decrypted) {
+		t.Errorf("Decrypted data: got %q", decrypted)
+	}
+}
+
+func TestDecryptAESWithADStrict(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+	ad := []byte("associated data")
+
+	bound, _ := EncryptAESWithAD(key, []byte("data"), ad)
+	decrypted, err := DecryptAESWithADStrict(key, bound, ad)
+	if err != nil {
+		t.Fatalf("Decryption failed: %v", err)
+	}
+	if !bytes.Equal([]byte("data"), decrypted) {
+		t.Errorf("Decrypted data: got %q", decrypted)
+	}
+	if _, err := DecryptAESWithADStrict(key, bound, []byte("other data")); err == nil {
+		t.Error("Expected error for wrong associated data, got nil")
+	}
+
+	// Конверт первой версии и данные старого формата не привязаны к associated data
+	version1, _ := EncryptAES(key, []byte("data"))
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	nonce, _ := GenerateRandomBytes(gcm.NonceSize())
+	legacy := gcm.Seal(nonce, nonce, []byte("data"), nil)
+
+	for name, data := range map[string][]byte{"version1": version1, "legacy": legacy} {
+		if _, err := DecryptAESWithADStrict(key, data, ad); !errors.Is(err, ErrEnvelopeUnbound) {
+			t.Errorf("%s: got error %v, want %v", name, err, ErrEnvelopeUnbound)
+		}
+	}
+}
+
+func TestDecryptAES_Legacy(t *testing.T) {
+	key, _ := GenerateRandomBytes(32)
+	text := []byte("legacy data")
+
+	// Данные старого формата без заголовка: [Nonce + Ciphertext + Tag]
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := []byte{EnvelopeVersion1, CipherAES256GCM, KDFNone, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	legacy := gcm.Seal(bytes.Clone(nonce), nonce, text, nil)
+
+	if _, err := ParseHeader(legacy[:2]); !errors.Is(err, ErrInvalidEnvelope) {
+		t.Errorf("Expected ErrInvalidEnvelope for short data, got %v", err)
+	}
+
+	// Nonce совпадает с заголовком, но данные все равно расшифровываются
+	decrypted, err := DecryptAES(key, legacy)
+	if err != nil {
+		t.Fatalf("Decryption of legacy data failed: %v", err)
+	}
+	if !bytes.Equal(text, decrypted) {
+		t.Errorf("Decrypted data: got %q, want %q", decrypted, text)
+	}
+}
+
+func TestParseHeader_Invalid(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":           {},
+		"unknown_version": {3, CipherAES256GCM, KDFNone},
+		"unknown_cipher":  {EnvelopeVersion1, 2, KDFNone},
+		"unknown_kdf":     {EnvelopeVersion1, CipherAES256GCM, 2},
+		"short_kdf":       {EnvelopeVersion1, CipherAES256GCM, KDFArgon2id, 0, 0, 0, 3},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseHeader(data); !errors.Is(err, ErrInvalidEnvelope) {
+				t.Errorf("Expected ErrInvalidEnvelope, got %v", err)
+			}
+		})
+	}
+}
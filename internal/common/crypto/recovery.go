@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Параметры ключа восстановления
+const (
+	RecoveryKeyLen      = 32 // Длина ключа восстановления (256 бит)
+	recoveryChecksumLen = 2  // Длина контрольной суммы в печатном коде
+	recoveryGroupLen    = 5  // Количество символов в группе печатного кода
+)
+
+// Метки HKDF для ключей, выводимых из ключа восстановления
+const (
+	recoveryWrapInfo = "gophkeeper recovery wrap"
+	recoveryAuthInfo = "gophkeeper recovery auth"
+)
+
+var ErrInvalidRecoveryKey = errors.New("invalid recovery key")
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryKey генерирует случайный ключ восстановления длиной 256 бит
+func GenerateRecoveryKey() ([]byte, error) {
+	return GenerateRandomBytes(RecoveryKeyLen)
+}
+
+// EncodeRecoveryKey кодирует ключ восстановления в печатный код:
+// base32 с контрольной суммой, группами по 5 символов через дефис
+func EncodeRecoveryKey(key []byte) string {
+	sum := sha256.Sum256(key)
+	data := append(bytes.Clone(key), sum[:recoveryChecksumLen]...)
+	code := recoveryEncoding.EncodeToString(data)
+
+	groups := make([]string, 0, len(code)/recoveryGroupLen+1)
+	for len(code) > recoveryGroupLen {
+		groups = append(groups, code[:recoveryGroupLen])
+		code = code[recoveryGroupLen:]
+	}
+	groups = append(groups, code)
+
+	return strings.Join(groups, "-")
+}
+
+// DecodeRecoveryKey разбирает печатный код ключа восстановления,
+// регистр, пробелы и дефисы игнорируются, контрольная сумма проверяется
+func DecodeRecoveryKey(code string) ([]byte, error) {
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+
+	data, err := recoveryEncoding.DecodeString(code)
+	if err != nil || len(data) != RecoveryKeyLen+recoveryChecksumLen {
+		return nil, ErrInvalidRecoveryKey
+	}
+
+	key := data[:RecoveryKeyLen]
+	sum := sha256.Sum256(key)
+	if !bytes.Equal(sum[:recoveryChecksumLen], data[RecoveryKeyLen:]) {
+		return nil, ErrInvalidRecoveryKey
+	}
+
+	return key, nil
+}
+
+// DeriveRecoveryKeys выводит из ключа восстановления два независимых ключа:
+// wrapKey для шифрования мастер ключа и authKey для подтверждения владения
+// ключом восстановления на сервере (сервер хранит только хэш authKey)
+func DeriveRecoveryKeys(key []byte) (wrapKey, authKey []byte, err error) {
+	if len(key) != RecoveryKeyLen {
+		return nil, nil, ErrInvalidRecoveryKey
+	}
+
+	wrapKey = make([]byte, KeyLen)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(recoveryWrapInfo)), wrapKey); err != nil {
+		return nil, nil, err
+	}
+
+	authKey = make([]byte, KeyLen)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(recoveryAuthInfo)), authKey); err != nil {
+		return nil, nil, err
+	}
+
+	return wrapKey, authKey, nil
+}
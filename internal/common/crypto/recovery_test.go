@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryKey_EncodeDecode(t *testing.T) {
+	key, err := GenerateRecoveryKey()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryKey failed: %v", err)
+	}
+
+	code := EncodeRecoveryKey(key)
+
+	decoded, err := DecodeRecoveryKey(code)
+	if err != nil {
+		t.Fatalf("DecodeRecoveryKey failed: %v", err)
+	}
+	if !bytes.Equal(key, decoded) {
+		t.Errorf("Decoded key does not match original.\nGot: %x\nWant: %x", decoded, key)
+	}
+
+	// Регистр, пробелы и дефисы не имеют значения
+	relaxed := strings.ToLower(strings.ReplaceAll(code, "-", " "))
+	decoded, err = DecodeRecoveryKey(relaxed)
+	if err != nil {
+		t.Fatalf("DecodeRecoveryKey failed for relaxed code: %v", err)
+	}
+	if !bytes.Equal(key, decoded) {
+		t.Errorf("Decoded relaxed key does not match original")
+	}
+}
+
+func TestDecodeRecoveryKey_Invalid(t *testing.T) {
+	key, _ := GenerateRecoveryKey()
+	code := EncodeRecoveryKey(key)
+
+	// Опечатка в одном символе должна обнаруживаться контрольной суммой
+	typo := []byte(code)
+	if typo[0] == 'A' {
+		typo[0] = 'B'
+	} else {
+		typo[0] = 'A'
+	}
+
+	tests := []string{
+		"",
+		"not a code",
+		string(typo),
+		code[:len(code)-5],
+		code + "-AAAAA",
+	}
+
+	for _, tc := range tests {
+		if _, err := DecodeRecoveryKey(tc); err != ErrInvalidRecoveryKey {
+			t.Errorf("DecodeRecoveryKey(%q) error = %v, want %v", tc, err, ErrInvalidRecoveryKey)
+		}
+	}
+}
+
+func TestDeriveRecoveryKeys(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, RecoveryKeyLen)
+
+	wrap1, auth1, err := DeriveRecoveryKeys(key)
+	if err != nil {
+		t.Fatalf("DeriveRecoveryKeys failed: %v", err)
+	}
+	wrap2, auth2, _ := DeriveRecoveryKeys(key)
+
+	if !bytes.Equal(wrap1, wrap2) || !bytes.Equal(auth1, auth2) {
+		t.Error("DeriveRecoveryKeys is not deterministic")
+	}
+	if len(wrap1) != KeyLen || len(auth1) != KeyLen {
+		t.Errorf("Expected keys of length %d, got %d and %d", KeyLen, len(wrap1), len(auth1))
+	}
+	if bytes.Equal(wrap1, auth1) {
+		t.Error("Wrap and auth keys must differ")
+	}
+
+	if _, _, err := DeriveRecoveryKeys(key[:16]); err != ErrInvalidRecoveryKey {
+		t.Errorf("Expected %v for short key, got %v", ErrInvalidRecoveryKey, err)
+	}
+}
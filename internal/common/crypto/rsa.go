@@ -1,10 +1,9 @@
 package crypto
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/binary"
 	"encoding/pem"
@@ -26,6 +25,11 @@ func LoadPublicKey(fileName string) (*rsa.PublicKey, error) {
 		return nil, fmt.Errorf("failed to read public key file: %w", err)
 	}
 
+	return ParsePublicKey(data)
+}
+
+// ParsePublicKey разбирает публичный ключ rsa в формате pem.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
 	block, _ := pem.Decode(data)
 	if block == nil {
 		return nil, fmt.Errorf("failed to pem.Decode public key: %w", ErrInvalidKey)
@@ -44,6 +48,16 @@ func LoadPublicKey(fileName string) (*rsa.PublicKey, error) {
 	return publicKey, nil
 }
 
+// EncodePublicKey кодирует публичный ключ rsa в формат pem.
+func EncodePublicKey(publicKey *rsa.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
 // LoadPrivateKey загружает приватный ключ из файла в формате pem.
 func LoadPrivateKey(filename string) (*rsa.PrivateKey, error) {
 	if filename == "" {
@@ -73,98 +87,166 @@ func LoadPrivateKey(filename string) (*rsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
-// EncryptWithPublicKey шифрует данные с помощью публичного ключа.
+// Формат гибридного конверта:
+// [Version (1 байт)][Algorithm (1 байт)][длина зашифрованного ключа (2 байта)]
+// [AES ключ, зашифрованный RSA][Nonce (12 байт)][EncryptedData + Tag (16 байт)].
+// Заголовок аутентифицируется как associated data AES-GCM.
+// Данные старого формата начинаются с длины ключа uint32, поэтому первый байт у них нулевой:
+// [длина зашифрованного ключа (4 байта)][AES ключ, зашифрованный RSA PKCS#1 v1.5][Nonce][EncryptedData + Tag].
+const (
+	HybridVersion1 uint8 = 1
+
+	// AES ключ зашифрован RSA-OAEP с SHA-256
+	HybridRSAOAEPSHA256 uint8 = 1
+
+	hybridHeaderLen = 4
+	legacyHeaderLen = 4
+)
+
+// hybridOAEPLabel метка RSA-OAEP, отделяет ключи конверта от других данных,
+// зашифрованных тем же ключом RSA.
+var hybridOAEPLabel = []byte("gophkeeper hybrid envelope v1")
+
+var ErrInvalidHybrid = errors.New("invalid encrypted data")
+
+// EncryptWithPublicKey шифрует данные с помощью публичного ключа:
+// данные шифруются случайным ключом AES-256-GCM, а он - RSA-OAEP.
 func EncryptWithPublicKey(data []byte, publicKey *rsa.PublicKey) ([]byte, error) {
 	if publicKey == nil {
 		return data, nil
 	}
 
-	aesKey := make([]byte, 32)
-	if _, err := rand.Read(aesKey); err != nil {
+	aesKey, err := GenerateRandomBytes(KeyLen)
+	if err != nil {
 		return nil, fmt.Errorf("failed to generate AES key: %w", err)
 	}
 
-	block, err := aes.NewCipher(aesKey)
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, aesKey, hybridOAEPLabel)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, fmt.Errorf("failed to encrypt AES key with RSA: %w", err)
 	}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
-	}
+	header := make([]byte, hybridHeaderLen, hybridHeaderLen+len(encryptedKey))
+	header[0], header[1] = HybridVersion1, HybridRSAOAEPSHA256
+	binary.BigEndian.PutUint16(header[2:], uint16(len(encryptedKey)))
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
-		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
 	}
-
-	encryptedData := gcm.Seal(nonce, nonce, data, nil)
-
-	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, aesKey)
+	nonce, err := GenerateRandomBytes(gcm.NonceSize())
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt AES key with RSA: %w", err)
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	keySize := len(encryptedKey)
-	result := make([]byte, 4+keySize+len(encryptedData))
-
-	binary.BigEndian.PutUint32(result[0:4], uint32(keySize))
+	result := append(append(header, encryptedKey...), nonce...)
+	return gcm.Seal(result, nonce, data, header), nil
+}
 
-	copy(result[4:4+keySize], encryptedKey)
-	copy(result[4+keySize:], encryptedData)
+// DecryptWithPrivateKey расшифровывает данные с помощью приватного ключа.
+// Принимает конверт, созданный EncryptWithPublicKey, и данные старого формата с ключом,
+// зашифрованным RSA PKCS#1 v1.5.
+func DecryptWithPrivateKey(data []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
+	return decryptHybrid(data, privateKey, true)
+}
 
-	return result, nil
+// DecryptWithPrivateKeyOAEP расшифровывает только конверт HybridVersion1 с ключом,
+// зашифрованным RSA-OAEP. Данные старого формата отклоняются, поэтому функцию можно
+// применять к данным из сети, не давая оракула дополнения PKCS#1 v1.5.
+func DecryptWithPrivateKeyOAEP(data []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
+	return decryptHybrid(data, privateKey, false)
 }
 
-// DecryptWithPrivateKey расшифровывает данные с помощью приватного ключа
-func DecryptWithPrivateKey(data []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
+// decryptHybrid расшифровывает гибридный конверт, данные старого формата - только при allowLegacy.
+func decryptHybrid(data []byte, privateKey *rsa.PrivateKey, allowLegacy bool) ([]byte, error) {
 	if privateKey == nil {
 		return data, nil
 	}
 
-	if len(data) < 4 {
-		return nil, fmt.Errorf("invalid encrypted data: too short for key size header")
+	h, err := parseHybrid(data, privateKey.Size())
+	if err != nil {
+		return nil, err
 	}
-
-	keySize := int(binary.BigEndian.Uint32(data[0:4]))
-
-	if len(data) < 4+keySize {
-		return nil, fmt.Errorf("encrypted data too short, need %d bytes for key, have %d", 4+keySize, len(data))
+	if h.legacy && !allowLegacy {
+		return nil, fmt.Errorf("%w: legacy PKCS#1 v1.5 format is not accepted", ErrInvalidHybrid)
 	}
 
-	encryptedKey := data[4 : 4+keySize]
-	encryptedData := data[4+keySize:]
-
-	aesKey, err := rsa.DecryptPKCS1v15(rand.Reader, privateKey, encryptedKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+	aesKey := make([]byte, KeyLen)
+	if h.legacy {
+		// Ключ остается случайным при неверном дополнении, чтобы ошибка дополнения
+		// была неотличима от ошибки расшифровки данных
+		if _, err := rand.Read(aesKey); err != nil {
+			return nil, fmt.Errorf("failed to generate AES key: %w", err)
+		}
+		if err := rsa.DecryptPKCS1v15SessionKey(rand.Reader, privateKey, h.encryptedKey, aesKey); err != nil {
+			return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+		}
+	} else {
+		aesKey, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, h.encryptedKey, hybridOAEPLabel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+		}
 	}
 
-	block, err := aes.NewCipher(aesKey)
+	gcm, err := newGCM(aesKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, err
 	}
-
-	gcm, err := cipher.NewGCM(block)
+	decryptedData, err := openAES(gcm, h.encryptedData, h.header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
 	}
 
-	nonceSize := gcm.NonceSize()
+	return decryptedData, nil
+}
+
+// hybrid разобранный гибридный конверт.
+type hybrid struct {
+	// Заголовок, аутентифицируемый AES-GCM, nil для старого формата
+	header        []byte
+	encryptedKey  []byte
+	encryptedData []byte
+	legacy        bool
+}
+
+// parseHybrid разбирает гибридный конверт или данные старого формата.
+// Длина зашифрованного ключа должна совпадать с размером ключа RSA keySize,
+// поэтому длина из данных не используется для выделения памяти и не выходит за их границы.
+func parseHybrid(data []byte, keySize int) (hybrid, error) {
+	var h hybrid
 
-	if len(encryptedData) < nonceSize {
-		return nil, fmt.Errorf("invalid encrypted data: missing nonce")
+	if len(data) < hybridHeaderLen {
+		return h, fmt.Errorf("%w: too short for header", ErrInvalidHybrid)
 	}
 
-	nonce, ciphertext := encryptedData[:nonceSize], encryptedData[nonceSize:]
+	var headerLen int
+	var encryptedKeyLen uint32
+	switch data[0] {
+	case 0:
+		h.legacy = true
+		headerLen = legacyHeaderLen
+		encryptedKeyLen = binary.BigEndian.Uint32(data)
+	case HybridVersion1:
+		if data[1] != HybridRSAOAEPSHA256 {
+			return h, fmt.Errorf("%w: unsupported algorithm %d", ErrInvalidHybrid, data[1])
+		}
+		headerLen = hybridHeaderLen
+		encryptedKeyLen = uint32(binary.BigEndian.Uint16(data[2:]))
+		h.header = data[:headerLen]
+	default:
+		return h, fmt.Errorf("%w: unsupported version %d", ErrInvalidHybrid, data[0])
+	}
 
-	decryptedData, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	if uint64(encryptedKeyLen) != uint64(keySize) {
+		return h, fmt.Errorf("%w: key size %d does not match RSA key size %d", ErrInvalidHybrid, encryptedKeyLen, keySize)
+	}
+	if len(data)-headerLen < keySize {
+		return h, fmt.Errorf("%w: too short for key", ErrInvalidHybrid)
 	}
 
-	return decryptedData, nil
+	h.encryptedKey = data[headerLen : headerLen+keySize]
+	h.encryptedData = data[headerLen+keySize:]
+	return h, nil
 }
 
 // GenerateKeyPair создает пару ключей rsa для тестов.
@@ -1,10 +1,15 @@
 package crypto
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,6 +26,7 @@ func TestRSA(t *testing.T) {
 
 	encryptedData, err := EncryptWithPublicKey(data, publicKey)
 	require.Nil(t, err, "Encrypt data")
+	assert.Equal(t, []byte{HybridVersion1, HybridRSAOAEPSHA256}, encryptedData[:2], "Envelope header")
 
 	privateKey, err := LoadPrivateKey(privateKeyPath)
 	require.Nil(t, err, "Load private key from file")
@@ -30,3 +36,154 @@ func TestRSA(t *testing.T) {
 
 	require.Equal(t, data, decryptedData, "Check data equal")
 }
+
+func TestDecryptWithPrivateKey_Legacy(t *testing.T) {
+	privateKey := testPrivateKey(t)
+	data := []byte("Some test data")
+
+	decryptedData, err := DecryptWithPrivateKey(testLegacyHybrid(t, data, &privateKey.PublicKey), privateKey)
+	require.Nil(t, err, "Decrypt legacy data")
+	assert.Equal(t, data, decryptedData, "Legacy data")
+}
+
+func TestDecryptWithPrivateKeyOAEP(t *testing.T) {
+	privateKey := testPrivateKey(t)
+	data := []byte("Some test data")
+
+	encrypted, err := EncryptWithPublicKey(data, &privateKey.PublicKey)
+	require.Nil(t, err, "Encrypt data")
+	decryptedData, err := DecryptWithPrivateKeyOAEP(encrypted, privateKey)
+	require.Nil(t, err, "Decrypt data")
+	assert.Equal(t, data, decryptedData, "Decrypted data")
+
+	_, err = DecryptWithPrivateKeyOAEP(testLegacyHybrid(t, data, &privateKey.PublicKey), privateKey)
+	assert.ErrorIs(t, err, ErrInvalidHybrid, "Decrypt legacy data")
+}
+
+func TestDecryptWithPrivateKey_Invalid(t *testing.T) {
+	privateKey := testPrivateKey(t)
+	encrypted, err := EncryptWithPublicKey([]byte("Some test data"), &privateKey.PublicKey)
+	require.Nil(t, err, "Encrypt data")
+	legacy := testLegacyHybrid(t, []byte("Some test data"), &privateKey.PublicKey)
+
+	tamper := func(data []byte, i int, b byte) []byte {
+		data = bytes.Clone(data)
+		data[i] = b
+		return data
+	}
+	hugeKey := bytes.Clone(legacy)
+	binary.BigEndian.PutUint32(hugeKey, 0xffffffff)
+
+	tests := map[string][]byte{
+		"empty":               {},
+		"short_header":        {HybridVersion1, HybridRSAOAEPSHA256},
+		"unknown_version":     tamper(encrypted, 0, 2),
+		"unknown_algorithm":   tamper(encrypted, 1, 2),
+		"wrong_key_size":      tamper(encrypted, 3, encrypted[3]+1),
+		"truncated_key":       encrypted[:hybridHeaderLen+privateKey.Size()-1],
+		"tampered_key":        tamper(encrypted, hybridHeaderLen, encrypted[hybridHeaderLen]^0xff),
+		"tampered_data":       tamper(encrypted, len(encrypted)-1, encrypted[len(encrypted)-1]^0xff),
+		"legacy_huge_key":     hugeKey,
+		"legacy_tampered_key": tamper(legacy, legacyHeaderLen, legacy[legacyHeaderLen]^0xff),
+		// Конверт, выданный за данные старого формата, не расшифровывается PKCS#1 v1.5
+		"downgraded": append([]byte{0, 0}, encrypted[2:]...),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecryptWithPrivateKey(data, privateKey)
+			assert.NotNil(t, err, "Decrypt error")
+		})
+	}
+}
+
+func FuzzParseHybrid(f *testing.F) {
+	privateKey := testPrivateKey(f)
+	encrypted, err := EncryptWithPublicKey([]byte("Some test data"), &privateKey.PublicKey)
+	require.Nil(f, err, "Encrypt data")
+
+	f.Add(encrypted)
+	f.Add(testLegacyHybrid(f, []byte("Some test data"), &privateKey.PublicKey))
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte{0, 0, 0x02, 0, 1, 2, 3})
+	f.Add([]byte{HybridVersion1, HybridRSAOAEPSHA256, 0xff, 0xff})
+
+	keySize := privateKey.Size()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		h, err := parseHybrid(data, keySize)
+		if err != nil {
+			return
+		}
+
+		if len(h.encryptedKey) != keySize {
+			t.Errorf("Encrypted key length: got %d, want %d", len(h.encryptedKey), keySize)
+		}
+		if h.legacy == (h.header != nil) {
+			t.Errorf("Header for legacy=%v: %v", h.legacy, h.header)
+		}
+		headerLen := legacyHeaderLen
+		if !h.legacy {
+			headerLen = len(h.header)
+		}
+		if headerLen+len(h.encryptedKey)+len(h.encryptedData) != len(data) {
+			t.Errorf("Parsed parts do not cover data of length %d", len(data))
+		}
+	})
+}
+
+func FuzzDecryptWithPrivateKey(f *testing.F) {
+	privateKey := testPrivateKey(f)
+	encrypted, err := EncryptWithPublicKey([]byte("Some test data"), &privateKey.PublicKey)
+	require.Nil(f, err, "Encrypt data")
+
+	f.Add(encrypted)
+	f.Add(testLegacyHybrid(f, []byte("Some test data"), &privateKey.PublicKey))
+	f.Add([]byte{0, 0, 0x02, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Не должен паниковать, а расшифроваться могут только данные, зашифрованные этим ключом
+		decrypted, err := DecryptWithPrivateKey(data, privateKey)
+		if err == nil && !bytes.Equal(decrypted, []byte("Some test data")) {
+			t.Errorf("Decrypted unexpected data %q", decrypted)
+		}
+	})
+}
+
+// testPrivateKey загружает приватный ключ из testdata.
+func testPrivateKey(t testing.TB) *rsa.PrivateKey {
+	privateKey, err := LoadPrivateKey(filepath.Join("testdata", "private-key.test.pem"))
+	require.Nil(t, err, "Load private key from file")
+	return privateKey
+}
+
+// testLegacyHybrid шифрует данные в старом формате: ключ AES зашифрован RSA PKCS#1 v1.5,
+// перед ним записана его длина uint32.
+func testLegacyHybrid(t testing.TB, data []byte, publicKey *rsa.PublicKey) []byte {
+	aesKey, err := GenerateRandomBytes(KeyLen)
+	require.Nil(t, err, "Generate AES key")
+	gcm, err := newGCM(aesKey)
+	require.Nil(t, err, "Create GCM")
+	nonce, err := GenerateRandomBytes(gcm.NonceSize())
+	require.Nil(t, err, "Generate nonce")
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, aesKey)
+	require.Nil(t, err, "Encrypt AES key")
+
+	result := binary.BigEndian.AppendUint32(nil, uint32(len(encryptedKey)))
+	result = append(append(result, encryptedKey...), nonce...)
+	return gcm.Seal(result, nonce, data, nil)
+}
+
+func TestEncodePublicKey(t *testing.T) {
+	privateKey := testPrivateKey(t)
+
+	encoded, err := EncodePublicKey(&privateKey.PublicKey)
+	require.Nil(t, err, "Encode public key")
+
+	publicKey, err := ParsePublicKey(encoded)
+	require.Nil(t, err, "Parse public key")
+	assert.True(t, privateKey.PublicKey.Equal(publicKey), "Parsed public key")
+
+	_, err = ParsePublicKey([]byte("not a key"))
+	assert.ErrorIs(t, err, ErrInvalidKey, "Parse invalid key")
+}
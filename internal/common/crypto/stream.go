@@ -0,0 +1,79 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+)
+
+// Параметры потокового шифрования
+const (
+	// StreamChunkSize размер открытых данных в одном блоке (1 MB)
+	StreamChunkSize = 1 << 20
+	// StreamChunkOverhead на сколько зашифрованный блок больше открытого (тег GCM)
+	StreamChunkOverhead = 16
+)
+
+// StreamCipher шифрует и расшифровывает поток данных блоками AES-256-GCM.
+// Nonce блока формируется из его порядкового номера, номер блока и признак
+// последнего блока аутентифицируются как дополнительные данные вместе с ad потока,
+// поэтому блоки нельзя переставить, повторить, отбросить с конца потока
+// или перенести в поток с другими ad.
+// Один ключ должен использоваться только для одного потока.
+type StreamCipher struct {
+	gcm cipher.AEAD
+	ad  []byte
+}
+
+// NewStreamCipher создает StreamCipher, принимает key длиной 32 байта
+// и ad потока, которые аутентифицируются с каждым блоком, nil - без ad.
+func NewStreamCipher(key, ad []byte) (*StreamCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &StreamCipher{gcm: gcm, ad: ad}, nil
+}
+
+// Seal шифрует блок с номером index, last - признак последнего блока потока.
+// Возвращает []byte в формате [EncryptedData + Tag (16 байт)]
+func (c *StreamCipher) Seal(index uint64, last bool, plainData []byte) []byte {
+	nonce, ad := c.nonceAndAD(index, last)
+	return c.gcm.Seal(nil, nonce, plainData, ad)
+}
+
+// Open расшифровывает блок с номером index, last - признак последнего блока потока.
+func (c *StreamCipher) Open(index uint64, last bool, data []byte) ([]byte, error) {
+	nonce, ad := c.nonceAndAD(index, last)
+
+	decryptedData, err := c.gcm.Open(nil, nonce, data, ad)
+	if err != nil {
+		return nil, fmt.Errorf("decryption of chunk %d failed: %w", index, err)
+	}
+
+	return decryptedData, nil
+}
+
+// nonceAndAD возвращает nonce [0 (4 байта) + index (8 байт)]
+// и дополнительные данные [index (8 байт) + last (1 байт) + ad потока] для блока.
+func (c *StreamCipher) nonceAndAD(index uint64, last bool) ([]byte, []byte) {
+	nonce := make([]byte, c.gcm.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], index)
+
+	ad := binary.BigEndian.AppendUint64(nil, index)
+	if last {
+		ad = append(ad, 1)
+	} else {
+		ad = append(ad, 0)
+	}
+	ad = append(ad, c.ad...)
+
+	return nonce, ad
+}
@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStreamCipher_SealOpen(t *testing.T) {
+	key, err := GenerateRandomBytes(KeyLen)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	c, err := NewStreamCipher(key, []byte("stream"))
+	if err != nil {
+		t.Fatalf("Failed to create stream cipher: %v", err)
+	}
+
+	chunks := [][]byte{[]byte("first chunk"), []byte("second chunk"), {}}
+	sealed := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		sealed[i] = c.Seal(uint64(i), i == len(chunks)-1, chunk)
+		if len(sealed[i]) != len(chunk)+StreamChunkOverhead {
+			t.Errorf("Chunk %d: unexpected sealed size %d", i, len(sealed[i]))
+		}
+	}
+
+	for i, data := range sealed {
+		opened, err := c.Open(uint64(i), i == len(chunks)-1, data)
+		if err != nil {
+			t.Fatalf("Chunk %d: open failed: %v", i, err)
+		}
+		if !bytes.Equal(chunks[i], opened) {
+			t.Errorf("Chunk %d: got %q, want %q", i, opened, chunks[i])
+		}
+	}
+}
+
+func TestStreamCipher_Open_Tampered(t *testing.T) {
+	key, _ := GenerateRandomBytes(KeyLen)
+	otherKey, _ := GenerateRandomBytes(KeyLen)
+	c, _ := NewStreamCipher(key, []byte("stream"))
+	other, _ := NewStreamCipher(otherKey, []byte("stream"))
+	otherAD, _ := NewStreamCipher(key, []byte("other stream"))
+	unbound, _ := NewStreamCipher(key, nil)
+
+	first := c.Seal(0, false, []byte("first chunk"))
+	last := c.Seal(1, true, []byte("last chunk"))
+
+	tests := []struct {
+		name  string
+		c     *StreamCipher
+		index uint64
+		last  bool
+		data  []byte
+	}{
+		{name: "reordered", c: c, index: 1, last: false, data: first},
+		{name: "truncated", c: c, index: 0, last: true, data: first},
+		{name: "extended", c: c, index: 1, last: false, data: last},
+		{name: "wrong_key", c: other, index: 0, last: false, data: first},
+		{name: "other_stream", c: otherAD, index: 0, last: false, data: first},
+		{name: "unbound", c: unbound, index: 0, last: false, data: first},
+		{name: "modified", c: c, index: 0, last: false, data: append([]byte{first[0] ^ 0xFF}, first[1:]...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.c.Open(test.index, test.last, test.data); err == nil {
+				t.Error("Expected error for tampered stream, got nil")
+			}
+		})
+	}
+}
+
+func TestNewStreamCipher_KeySize(t *testing.T) {
+	if _, err := NewStreamCipher([]byte("shortkey"), nil); err == nil {
+		t.Error("Expected error for invalid key size, got nil")
+	}
+}
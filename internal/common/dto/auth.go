@@ -1,39 +1,250 @@
 package dto
 
 import (
+	"encoding/base64"
 	"fmt"
 	"unicode"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
 )
 
 const (
 	CredentialsLoginMaxLen    = 64
 	CredentialsLoginMinLen    = 3
 	CredentialsPasswordMinLen = 8
+	CredentialsDeviceMaxLen   = 64
+)
+
+// Длина ключа аутентификации, выведенного из мастер ключа
+const AuthKeyLen = 32
+
+// Версии схемы аутентификации: что сервер хранит для проверки входа.
+const (
+	// Хэш пароля, клиент передает пароль
+	AuthVersionPassword uint8 = 0
+	// Хэш ключа аутентификации, выведенного из мастер ключа, пароль сервер не получает
+	AuthVersionKey uint8 = 1
 )
 
+// EncryptedContentType тип тела запроса, зашифрованного публичным ключом сервера
+// (crypto.EncryptWithPublicKey), внутри зашифрован JSON.
+const EncryptedContentType = "application/vnd.gophkeeper.encrypted"
+
 type AuthResponse struct {
 	// токен для авторизации (JWT)
 	Token string `json:"token"`
+	// токен для получения нового токена авторизации
+	RefreshToken string `json:"refresh_token"`
 	// Соль для создания мастер из пароля ключа закодированная base64
 	EncrSalt string `json:"encr_salt"`
+	// Параметры Argon2id для создания мастер ключа,
+	// если не переданы, используются параметры по умолчанию
+	KDF *KDFParams `json:"kdf,omitempty"`
+	// Ключ аккаунта, зашифрованный мастер ключом, закодированный base64,
+	// пустой, если аккаунт еще не переведен на ключ аккаунта
+	AccountKey string `json:"account_key,omitempty"`
+}
+
+// KDFParams параметры Argon2id, с которыми из пароля вычисляется мастер ключ.
+type KDFParams struct {
+	Time uint32 `json:"time"`
+	// Объем памяти в КБ
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// Validate проверяет, что параметры в допустимых границах.
+func (p KDFParams) Validate() error {
+	return crypto.KDFParams(p).Validate()
+}
+
+// PreLoginRequest структура запроса параметров для вычисления мастер ключа перед входом.
+type PreLoginRequest struct {
+	Login string `json:"login"`
+}
+
+// PreLoginResponse параметры, по которым клиент вычисляет мастер ключ
+// и ключ аутентификации до входа в систему.
+type PreLoginResponse struct {
+	// Соль для создания мастер ключа, закодированная base64
+	EncrSalt string `json:"encr_salt"`
+	// Параметры Argon2id для создания мастер ключа
+	KDF *KDFParams `json:"kdf"`
+	// Версия схемы аутентификации, AuthVersionPassword или AuthVersionKey
+	AuthVersion uint8 `json:"auth_version,omitempty"`
+}
+
+// PublicKeyResponse структура ответа с публичным ключом сервера
+// для шифрования тел запросов регистрации и логина.
+type PublicKeyResponse struct {
+	// Публичный ключ rsa в формате pem
+	Key string `json:"key"`
+}
+
+// RefreshRequest структура запроса новой пары токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse структура ответа с новой парой токенов
+type TokenResponse struct {
+	// токен для авторизации (JWT)
+	Token string `json:"token"`
+	// новый refresh токен, переданный в запросе больше не действует
+	RefreshToken string `json:"refresh_token"`
 }
 
 type Credentials struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login string `json:"login"`
+	// Пароль передается на сервер только при входе в аккаунт,
+	// который еще не переведен на ключ аутентификации (AuthVersionPassword)
+	Password string `json:"password,omitempty"`
+	// Ключ аутентификации, выведенный из мастер ключа, закодированный base64
+	AuthKey string `json:"auth_key,omitempty"`
+	// Соль мастер ключа, закодированная base64, и параметры Argon2id,
+	// с которыми он вычислен, передаются только при регистрации
+	EncrSalt string     `json:"encr_salt,omitempty"`
+	KDF      *KDFParams `json:"kdf,omitempty"`
+	// Название устройства для списка сессий, длинное название обрезается
+	Device string `json:"device,omitempty"`
+	// Код двухфакторной аутентификации или код восстановления,
+	// нужен только при входе, если двухфакторная аутентификация включена
+	OTP string `json:"otp,omitempty"`
+	// Код приглашения, нужен только при регистрации, если сервер регистрирует по приглашениям
+	Invite string `json:"invite,omitempty"`
+}
+
+// PasswordChangeRequest структура запроса смены пароля.
+// Ключи данных перешифровываются на клиенте, сервер только заменяет их.
+type PasswordChangeRequest struct {
+	// Текущий ключ аутентификации, закодированный base64
+	AuthKey string `json:"auth_key,omitempty"`
+	// Текущий пароль, передается только для аккаунта с AuthVersionPassword
+	Password string `json:"password,omitempty"`
+	// Ключ аутентификации, выведенный из нового мастер ключа, закодированный base64
+	NewAuthKey string `json:"new_auth_key"`
+	// Новая соль для создания мастер ключа закодированная base64
+	EncrSalt string `json:"encr_salt"`
+	// Параметры Argon2id, с которыми вычислен новый мастер ключ,
+	// если не переданы, сохраняются параметры по умолчанию
+	KDF *KDFParams `json:"kdf,omitempty"`
+	// Ключ аккаунта, зашифрованный новым мастер ключом, передается,
+	// если аккаунт переведен на ключ аккаунта
+	AccountKey string `json:"account_key,omitempty"`
+	// Ключи данных всех секретов пользователя, зашифрованные новым мастер ключом.
+	// Если передан AccountKey, только ключи старого формата, перешифрованные ключом аккаунта
+	Keys []SecretKey `json:"keys"`
+	// Ключи восстановления, перешифрованные новым мастер ключом,
+	// если не переданы, восстановление доступа отключается.
+	// Не передаются вместе с AccountKey: ключ аккаунта при смене пароля не меняется
+	Recovery *RecoveryKey `json:"recovery,omitempty"`
+}
+
+// AccountKeyRequest структура запроса перевода аккаунта на ключ аккаунта.
+// Ключи данных перешифровываются на клиенте, сервер только заменяет их.
+type AccountKeyRequest struct {
+	// Ключ аккаунта, зашифрованный мастер ключом, закодированный base64
+	AccountKey string `json:"account_key"`
+	// Ключи данных всех секретов пользователя, зашифрованные ключом аккаунта
+	Keys []SecretKey `json:"keys"`
+	// Ключи восстановления, перешифрованные для ключа аккаунта,
+	// если не переданы, восстановление доступа отключается
+	Recovery *RecoveryKey `json:"recovery,omitempty"`
+}
+
+// Validate проверяет запрос перевода на ключ аккаунта, используется на сервере.
+func (a AccountKeyRequest) Validate() error {
+	if err := ValidateSecretKey(a.AccountKey); err != nil {
+		return fmt.Errorf("account key: %w", err)
+	}
+	if err := validateSecretKeys(a.Keys); err != nil {
+		return err
+	}
+	if a.Recovery != nil {
+		return a.Recovery.Validate(false)
+	}
+	return nil
+}
+
+// Validate проверяет запрос смены пароля, используется на сервере.
+func (p PasswordChangeRequest) Validate() error {
+	if err := ValidateAuthKey(p.NewAuthKey); err != nil {
+		return err
+	}
+
+	if err := validateMasterKeyParams(p.EncrSalt, p.KDF); err != nil {
+		return err
+	}
+
+	if p.AccountKey != "" {
+		if err := ValidateSecretKey(p.AccountKey); err != nil {
+			return fmt.Errorf("account key: %w", err)
+		}
+		if p.Recovery != nil {
+			return fmt.Errorf("recovery keys are not changed with account key")
+		}
+	}
+
+	if err := validateSecretKeys(p.Keys); err != nil {
+		return err
+	}
+
+	if p.Recovery != nil {
+		return p.Recovery.Validate(false)
+	}
+
+	return nil
+}
+
+// validateSecretKeys проверяет ключи данных секретов и отсутствие повторов.
+func validateSecretKeys(keys []SecretKey) error {
+	ids := make(map[uint64]struct{}, len(keys))
+	for _, k := range keys {
+		if err := ValidateSecretKey(k.Key); err != nil {
+			return fmt.Errorf("secret %d: %w", k.ID, err)
+		}
+		if _, ok := ids[k.ID]; ok {
+			return fmt.Errorf("duplicate key for secret %d", k.ID)
+		}
+		ids[k.ID] = struct{}{}
+	}
+	return nil
 }
 
 // Validate проверяет учетные данные пользователя при регистрации,
-// используется на сервере
+// используется на сервере. Пароль сервер не получает, его проверяет клиент
 func (cr Credentials) Validate() error {
 	if err := cr.ValidateLogin(); err != nil {
 		return err
 	}
 
-	if err := cr.ValidatePassword(); err != nil {
+	if err := ValidateAuthKey(cr.AuthKey); err != nil {
 		return err
 	}
 
+	return validateMasterKeyParams(cr.EncrSalt, cr.KDF)
+}
+
+// ValidateAuthKey проверяет ключ аутентификации, выведенный из мастер ключа.
+func ValidateAuthKey(key string) error {
+	b, err := base64.RawStdEncoding.DecodeString(key)
+	if err != nil || len(b) != AuthKeyLen {
+		return fmt.Errorf("auth key must be %d bytes base64 encoded", AuthKeyLen)
+	}
+	return nil
+}
+
+// validateMasterKeyParams проверяет соль и параметры Argon2id мастер ключа,
+// параметры могут быть не переданы.
+func validateMasterKeyParams(encrSalt string, kdf *KDFParams) error {
+	salt, err := base64.RawStdEncoding.DecodeString(encrSalt)
+	if err != nil || len(salt) == 0 {
+		return fmt.Errorf("encryption salt must be base64 encoded")
+	}
+
+	if kdf != nil {
+		return kdf.Validate()
+	}
 	return nil
 }
 
@@ -6,37 +6,59 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// testAuthKey ключ аутентификации длиной AuthKeyLen в base64
+const testAuthKey = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8"
+
 func TestCredentials_Validate(t *testing.T) {
 	tests := []struct {
-		name     string
-		login    string
-		password string
-		wantErr  string
+		name    string
+		cr      Credentials
+		wantErr string
 	}{
 		{
-			name:     "succes",
-			login:    "test13",
-			password: "password13",
+			name: "succes",
+			cr:   Credentials{Login: "test13", AuthKey: testAuthKey, EncrSalt: "c2FsdA"},
 		},
 		{
-			name:     "invalid_login",
-			login:    "t1",
-			password: "password13",
-			wantErr:  "login too short (min 3 chars)",
+			name: "with_kdf_params",
+			cr: Credentials{
+				Login:    "test13",
+				AuthKey:  testAuthKey,
+				EncrSalt: "c2FsdA",
+				KDF:      &KDFParams{Time: 4, Memory: 128 * 1024, Threads: 4},
+			},
 		},
 		{
-			name:     "invalid_password",
-			login:    "test13",
-			password: "p1",
-			wantErr:  "password too short (min 8 chars)",
+			name:    "invalid_login",
+			cr:      Credentials{Login: "t1", AuthKey: testAuthKey, EncrSalt: "c2FsdA"},
+			wantErr: "login too short (min 3 chars)",
+		},
+		{
+			name:    "password_instead_of_auth_key",
+			cr:      Credentials{Login: "test13", Password: "password13", EncrSalt: "c2FsdA"},
+			wantErr: "auth key must be 32 bytes base64 encoded",
+		},
+		{
+			name:    "empty_salt",
+			cr:      Credentials{Login: "test13", AuthKey: testAuthKey},
+			wantErr: "encryption salt must be base64 encoded",
+		},
+		{
+			name: "weak_kdf_params",
+			cr: Credentials{
+				Login:    "test13",
+				AuthKey:  testAuthKey,
+				EncrSalt: "c2FsdA",
+				KDF:      &KDFParams{Time: 1, Memory: 1024, Threads: 1},
+			},
+			wantErr: "kdf memory must be from 16384 to 1048576 KB",
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			var gotErr string
-			cr := Credentials{Login: test.login, Password: test.password}
 
-			err := cr.Validate()
+			err := test.cr.Validate()
 			if err != nil {
 				gotErr = err.Error()
 			}
@@ -133,3 +155,157 @@ func TestCredentials_ValidatePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordChangeRequest_Validate(t *testing.T) {
+	keys := []SecretKey{{ID: 1, Key: "a2V5MQ"}, {ID: 2, Key: "a2V5Mg"}}
+
+	tests := []struct {
+		name    string
+		req     PasswordChangeRequest
+		wantErr string
+	}{
+		{
+			name: "succes",
+			req:  PasswordChangeRequest{AuthKey: testAuthKey, NewAuthKey: testAuthKey, EncrSalt: "c2FsdA", Keys: keys},
+		},
+		{
+			name: "without_secrets",
+			req:  PasswordChangeRequest{AuthKey: testAuthKey, NewAuthKey: testAuthKey, EncrSalt: "c2FsdA"},
+		},
+		{
+			name:    "invalid_auth_key",
+			req:     PasswordChangeRequest{AuthKey: testAuthKey, NewAuthKey: "c2hvcnQ", EncrSalt: "c2FsdA", Keys: keys},
+			wantErr: "auth key must be 32 bytes base64 encoded",
+		},
+		{
+			name:    "invalid_salt",
+			req:     PasswordChangeRequest{AuthKey: testAuthKey, NewAuthKey: testAuthKey, EncrSalt: "c2FsdA==", Keys: keys},
+			wantErr: "encryption salt must be base64 encoded",
+		},
+		{
+			name:    "empty_salt",
+			req:     PasswordChangeRequest{AuthKey: testAuthKey, NewAuthKey: testAuthKey, Keys: keys},
+			wantErr: "encryption salt must be base64 encoded",
+		},
+		{
+			name: "empty_key",
+			req: PasswordChangeRequest{
+				AuthKey:    testAuthKey,
+				NewAuthKey: testAuthKey,
+				EncrSalt:   "c2FsdA",
+				Keys:       []SecretKey{{ID: 1}},
+			},
+			wantErr: "secret 1: key can not be empty",
+		},
+		{
+			name: "duplicate_key",
+			req: PasswordChangeRequest{
+				AuthKey:    testAuthKey,
+				NewAuthKey: testAuthKey,
+				EncrSalt:   "c2FsdA",
+				Keys:       append(keys, SecretKey{ID: 1, Key: "a2V5Mw"}),
+			},
+			wantErr: "duplicate key for secret 1",
+		},
+		{
+			name: "with_account_key",
+			req: PasswordChangeRequest{
+				AuthKey:    testAuthKey,
+				NewAuthKey: testAuthKey,
+				EncrSalt:   "c2FsdA",
+				AccountKey: "YWNjb3VudA",
+			},
+		},
+		{
+			name: "with_kdf_params",
+			req: PasswordChangeRequest{
+				AuthKey:    testAuthKey,
+				NewAuthKey: testAuthKey,
+				EncrSalt:   "c2FsdA",
+				KDF:        &KDFParams{Time: 4, Memory: 128 * 1024, Threads: 4},
+			},
+		},
+		{
+			name: "weak_kdf_params",
+			req: PasswordChangeRequest{
+				AuthKey:    testAuthKey,
+				NewAuthKey: testAuthKey,
+				EncrSalt:   "c2FsdA",
+				KDF:        &KDFParams{Time: 1, Memory: 1024, Threads: 1},
+			},
+			wantErr: "kdf memory must be from 16384 to 1048576 KB",
+		},
+		{
+			name: "account_key_with_recovery",
+			req: PasswordChangeRequest{
+				AuthKey:    testAuthKey,
+				NewAuthKey: testAuthKey,
+				EncrSalt:   "c2FsdA",
+				AccountKey: "YWNjb3VudA",
+				Recovery:   &RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"},
+			},
+			wantErr: "recovery keys are not changed with account key",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+
+			err := test.req.Validate()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
+
+func TestAccountKeyRequest_Validate(t *testing.T) {
+	keys := []SecretKey{{ID: 1, Key: "a2V5MQ", Version: KeyVersionAccount}}
+
+	tests := []struct {
+		name    string
+		req     AccountKeyRequest
+		wantErr string
+	}{
+		{
+			name: "succes",
+			req:  AccountKeyRequest{AccountKey: "YWNjb3VudA", Keys: keys},
+		},
+		{
+			name: "with_recovery",
+			req: AccountKeyRequest{
+				AccountKey: "YWNjb3VudA",
+				Recovery:   &RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"},
+			},
+		},
+		{
+			name:    "empty_account_key",
+			req:     AccountKeyRequest{Keys: keys},
+			wantErr: "account key: key can not be empty",
+		},
+		{
+			name:    "duplicate_key",
+			req:     AccountKeyRequest{AccountKey: "YWNjb3VudA", Keys: append(keys, keys...)},
+			wantErr: "duplicate key for secret 1",
+		},
+		{
+			name:    "invalid_recovery",
+			req:     AccountKeyRequest{AccountKey: "YWNjb3VudA", Recovery: &RecoveryKey{RecoveryKey: "cms"}},
+			wantErr: "master key: key can not be empty",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+
+			err := test.req.Validate()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	CardNumberMinLen = 12
+	CardNumberMaxLen = 19
+	CardHolderMaxLen = 64
+	// Формат срока действия карты MM/YY
+	CardExpiryLayout = "01/06"
+)
+
+// Card данные банковской карты.
+type Card struct {
+	Number string `json:"number"`
+	Holder string `json:"holder"`
+	// Срок действия карты в формате MM/YY
+	Expiry string `json:"expiry"`
+	CVV    string `json:"cvv"`
+}
+
+// Validate проверяет данные банковской карты, используется на клиенте.
+func (c Card) Validate() error {
+	if err := c.ValidateNumber(); err != nil {
+		return err
+	}
+
+	if err := c.ValidateHolder(); err != nil {
+		return err
+	}
+
+	if err := c.ValidateExpiry(time.Now()); err != nil {
+		return err
+	}
+
+	if err := c.ValidateCVV(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateNumber проверяет длину номера карты и контрольную сумму по алгоритму Луна.
+func (c Card) ValidateNumber() error {
+	number := c.NormalizedNumber()
+
+	if len(number) < CardNumberMinLen || len(number) > CardNumberMaxLen {
+		return fmt.Errorf(
+			"card number must contain from %d to %d digits",
+			CardNumberMinLen,
+			CardNumberMaxLen,
+		)
+	}
+
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("card number must contain only digits")
+		}
+	}
+
+	if !luhnValid(number) {
+		return fmt.Errorf("invalid card number checksum")
+	}
+
+	return nil
+}
+
+// ValidateHolder проверяет имя держателя карты.
+func (c Card) ValidateHolder() error {
+	if strings.TrimSpace(c.Holder) == "" {
+		return fmt.Errorf("card holder can not be empty")
+	}
+
+	if len([]rune(c.Holder)) > CardHolderMaxLen {
+		return fmt.Errorf("card holder too long (max %d chars)", CardHolderMaxLen)
+	}
+
+	return nil
+}
+
+// ValidateExpiry проверяет формат срока действия карты и то,
+// что на момент now срок действия не истек.
+func (c Card) ValidateExpiry(now time.Time) error {
+	expiry, err := time.Parse(CardExpiryLayout, c.Expiry)
+	if err != nil {
+		return fmt.Errorf("card expiry must be in MM/YY format")
+	}
+	// time.Parse относит годы 69-99 к XX веку, для карт это всегда XXI век
+	if expiry.Year() < 2000 {
+		expiry = expiry.AddDate(100, 0, 0)
+	}
+
+	// Карта действительна до конца указанного месяца
+	if !now.Before(expiry.AddDate(0, 1, 0)) {
+		return fmt.Errorf("card expired")
+	}
+
+	return nil
+}
+
+// ValidateCVV проверяет код CVV/CVC карты.
+func (c Card) ValidateCVV() error {
+	if len(c.CVV) < 3 || len(c.CVV) > 4 {
+		return fmt.Errorf("card CVV must contain 3 or 4 digits")
+	}
+
+	for _, r := range c.CVV {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("card CVV must contain 3 or 4 digits")
+		}
+	}
+
+	return nil
+}
+
+// NormalizedNumber возвращает номер карты без пробелов и дефисов.
+func (c Card) NormalizedNumber() string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(c.Number)
+}
+
+// MaskedNumber возвращает номер карты, в котором видны только последние 4 цифры.
+func (c Card) MaskedNumber() string {
+	number := c.NormalizedNumber()
+	if len(number) <= 4 {
+		return number
+	}
+
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
+
+// luhnValid проверяет контрольную сумму номера по алгоритму Луна.
+// Ожидает строку, состоящую только из цифр.
+func luhnValid(number string) bool {
+	var sum int
+	double := false
+
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
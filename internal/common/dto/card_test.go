@@ -0,0 +1,159 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCard_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		card    Card
+		wantErr string
+	}{
+		{
+			name: "succes",
+			card: Card{Number: "4111 1111 1111 1111", Holder: "IVAN IVANOV", Expiry: "12/99", CVV: "123"},
+		},
+		{
+			name:    "invalid_number",
+			card:    Card{Number: "4111 1111 1111 1112", Holder: "IVAN IVANOV", Expiry: "12/99", CVV: "123"},
+			wantErr: "invalid card number checksum",
+		},
+		{
+			name:    "empty_holder",
+			card:    Card{Number: "4111 1111 1111 1111", Holder: " ", Expiry: "12/99", CVV: "123"},
+			wantErr: "card holder can not be empty",
+		},
+		{
+			name:    "expired",
+			card:    Card{Number: "4111 1111 1111 1111", Holder: "IVAN IVANOV", Expiry: "01/01", CVV: "123"},
+			wantErr: "card expired",
+		},
+		{
+			name:    "invalid_cvv",
+			card:    Card{Number: "4111 1111 1111 1111", Holder: "IVAN IVANOV", Expiry: "12/99", CVV: "12a"},
+			wantErr: "card CVV must contain 3 or 4 digits",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+
+			err := test.card.Validate()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
+
+func TestCard_ValidateNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  string
+		wantErr string
+	}{
+		{
+			name:   "succes_visa",
+			number: "4111111111111111",
+		},
+		{
+			name:   "succes_with_separators",
+			number: "5555-5555-5555-4444",
+		},
+		{
+			name:   "succes_amex",
+			number: "378282246310005",
+		},
+		{
+			name:    "too_short",
+			number:  "41111111111",
+			wantErr: "card number must contain from 12 to 19 digits",
+		},
+		{
+			name:    "too_long",
+			number:  "41111111111111111111",
+			wantErr: "card number must contain from 12 to 19 digits",
+		},
+		{
+			name:    "not_digits",
+			number:  "4111a11111111111",
+			wantErr: "card number must contain only digits",
+		},
+		{
+			name:    "bad_checksum",
+			number:  "4111111111111112",
+			wantErr: "invalid card number checksum",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+			card := Card{Number: test.number}
+
+			err := card.ValidateNumber()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
+
+func TestCard_ValidateExpiry(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expiry  string
+		wantErr string
+	}{
+		{
+			name:   "succes",
+			expiry: "04/27",
+		},
+		{
+			name:   "current_month",
+			expiry: "03/26",
+		},
+		{
+			name:    "previous_month",
+			expiry:  "02/26",
+			wantErr: "card expired",
+		},
+		{
+			name:    "invalid_month",
+			expiry:  "13/26",
+			wantErr: "card expiry must be in MM/YY format",
+		},
+		{
+			name:    "invalid_format",
+			expiry:  "2026-03",
+			wantErr: "card expiry must be in MM/YY format",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+			card := Card{Expiry: test.expiry}
+
+			err := card.ValidateExpiry(now)
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
+
+func TestCard_MaskedNumber(t *testing.T) {
+	card := Card{Number: "4111 1111 1111 1234"}
+	assert.Equal(t, "************1234", card.MaskedNumber(), "Masked number")
+}
@@ -0,0 +1,180 @@
+package dto
+
+import (
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+)
+
+const (
+	OTPTypeTOTP = "totp"
+	OTPTypeHOTP = "hotp"
+	// Параметры по умолчанию, их используют почти все сервисы
+	OTPDefaultAlgorithm = "SHA1"
+	OTPDefaultDigits    = 6
+	OTPDefaultPeriod    = 30
+	OTPDigitsMin        = 6
+	OTPDigitsMax        = 8
+	// Схема URI, в котором сервисы передают секрет для приложения аутентификатора
+	OTPURIScheme = "otpauth"
+)
+
+// OTP параметры генератора одноразовых кодов TOTP (RFC 6238) или HOTP (RFC 4226).
+type OTP struct {
+	Type string `json:"type"`
+	// Секрет в кодировке base32
+	Secret  string `json:"secret"`
+	Issuer  string `json:"issuer,omitempty"`
+	Account string `json:"account,omitempty"`
+	// Хэш функция: SHA1, SHA256 или SHA512
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	// Длительность шага TOTP в секундах
+	Period uint64 `json:"period,omitempty"`
+	// Счетчик HOTP для следующего кода
+	Counter uint64 `json:"counter,omitempty"`
+}
+
+// NewTOTP создает TOTP генератор с параметрами по умолчанию для секрета в base32.
+func NewTOTP(secret string) OTP {
+	return OTP{
+		Type:      OTPTypeTOTP,
+		Secret:    normalizeOTPSecret(secret),
+		Algorithm: OTPDefaultAlgorithm,
+		Digits:    OTPDefaultDigits,
+		Period:    OTPDefaultPeriod,
+	}
+}
+
+// ParseOTPURI разбирает URI вида otpauth://totp/Issuer:account?secret=...&issuer=...
+// Незаданные параметры получают значения по умолчанию.
+func ParseOTPURI(uri string) (OTP, error) {
+	var o OTP
+
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != OTPURIScheme {
+		return o, fmt.Errorf("invalid otpauth URI")
+	}
+
+	o = NewTOTP(u.Query().Get("secret"))
+	o.Type = strings.ToLower(u.Host)
+
+	// метка имеет вид "Issuer:account" или "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		o.Issuer, o.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		o.Account = strings.TrimSpace(label)
+	}
+
+	q := u.Query()
+	if issuer := q.Get("issuer"); issuer != "" {
+		o.Issuer = issuer
+	}
+	if algorithm := q.Get("algorithm"); algorithm != "" {
+		o.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := q.Get("digits"); digits != "" {
+		if o.Digits, err = strconv.Atoi(digits); err != nil {
+			return o, fmt.Errorf("invalid otp digits")
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		if o.Period, err = strconv.ParseUint(period, 10, 64); err != nil {
+			return o, fmt.Errorf("invalid otp period")
+		}
+	}
+	if counter := q.Get("counter"); counter != "" {
+		if o.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+			return o, fmt.Errorf("invalid otp counter")
+		}
+	}
+	if o.Type == OTPTypeHOTP {
+		o.Period = 0
+	}
+
+	return o, o.Validate()
+}
+
+// Validate проверяет параметры генератора, используется на клиенте.
+func (o OTP) Validate() error {
+	if o.Type != OTPTypeTOTP && o.Type != OTPTypeHOTP {
+		return fmt.Errorf("otp type must be %s or %s", OTPTypeTOTP, OTPTypeHOTP)
+	}
+
+	if o.Secret == "" {
+		return fmt.Errorf("otp secret can not be empty")
+	}
+	if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(o.Secret); err != nil {
+		return fmt.Errorf("otp secret must be base32 encoded")
+	}
+
+	if _, err := o.algorithm(); err != nil {
+		return err
+	}
+
+	if o.Digits < OTPDigitsMin || o.Digits > OTPDigitsMax {
+		return fmt.Errorf("otp digits must be from %d to %d", OTPDigitsMin, OTPDigitsMax)
+	}
+
+	if o.Type == OTPTypeTOTP && o.Period == 0 {
+		return fmt.Errorf("totp period must be positive")
+	}
+
+	return nil
+}
+
+// Code возвращает код на момент now, для HOTP код зависит только от счетчика.
+func (o OTP) Code(now time.Time) (string, error) {
+	algorithm, err := o.algorithm()
+	if err != nil {
+		return "", err
+	}
+
+	counter := o.Counter
+	if o.Type == OTPTypeTOTP {
+		counter = uint64(now.Unix()) / o.Period
+	}
+
+	return hotp.GenerateCodeCustom(o.Secret, counter, hotp.ValidateOpts{
+		Digits:    otp.Digits(o.Digits),
+		Algorithm: algorithm,
+	})
+}
+
+// Remaining возвращает, сколько еще действует код TOTP, полученный в момент now.
+func (o OTP) Remaining(now time.Time) time.Duration {
+	if o.Type != OTPTypeTOTP || o.Period == 0 {
+		return 0
+	}
+
+	period := int64(o.Period)
+	return time.Duration(period-now.Unix()%period) * time.Second
+}
+
+func (o OTP) algorithm() (otp.Algorithm, error) {
+	switch o.Algorithm {
+	case "SHA1":
+		return otp.AlgorithmSHA1, nil
+	case "SHA256":
+		return otp.AlgorithmSHA256, nil
+	case "SHA512":
+		return otp.AlgorithmSHA512, nil
+	}
+
+	return 0, fmt.Errorf("otp algorithm must be SHA1, SHA256 or SHA512")
+}
+
+// normalizeOTPSecret приводит секрет к base32 без пробелов и выравнивания,
+// сервисы часто показывают его группами символов в нижнем регистре.
+func normalizeOTPSecret(secret string) string {
+	secret = strings.ToUpper(secret)
+	secret = strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret)
+	return secret
+}
@@ -0,0 +1,147 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Секрет "12345678901234567890" из приложений RFC 4226 и RFC 6238
+const testOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestParseOTPURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    OTP
+		wantErr string
+	}{
+		{
+			name: "totp_defaults",
+			uri:  "otpauth://totp/Example:alice@example.com?secret=gezd%20gnbv&issuer=Example",
+			want: OTP{
+				Type:      OTPTypeTOTP,
+				Secret:    "GEZDGNBV",
+				Issuer:    "Example",
+				Account:   "alice@example.com",
+				Algorithm: OTPDefaultAlgorithm,
+				Digits:    OTPDefaultDigits,
+				Period:    OTPDefaultPeriod,
+			},
+		},
+		{
+			name: "totp_custom",
+			uri:  "otpauth://totp/alice?secret=" + testOTPSecret + "&algorithm=sha256&digits=8&period=60",
+			want: OTP{
+				Type:      OTPTypeTOTP,
+				Secret:    testOTPSecret,
+				Account:   "alice",
+				Algorithm: "SHA256",
+				Digits:    8,
+				Period:    60,
+			},
+		},
+		{
+			name: "hotp",
+			uri:  "otpauth://hotp/Example:alice?secret=" + testOTPSecret + "&counter=5",
+			want: OTP{
+				Type:      OTPTypeHOTP,
+				Secret:    testOTPSecret,
+				Issuer:    "Example",
+				Account:   "alice",
+				Algorithm: OTPDefaultAlgorithm,
+				Digits:    OTPDefaultDigits,
+				Counter:   5,
+			},
+		},
+		{
+			name:    "invalid_scheme",
+			uri:     "https://example.com/?secret=" + testOTPSecret,
+			wantErr: "invalid otpauth URI",
+		},
+		{
+			name:    "invalid_type",
+			uri:     "otpauth://motp/alice?secret=" + testOTPSecret,
+			wantErr: "otp type must be totp or hotp",
+		},
+		{
+			name:    "empty_secret",
+			uri:     "otpauth://totp/alice",
+			wantErr: "otp secret can not be empty",
+		},
+		{
+			name:    "invalid_secret",
+			uri:     "otpauth://totp/alice?secret=18",
+			wantErr: "otp secret must be base32 encoded",
+		},
+		{
+			name:    "invalid_algorithm",
+			uri:     "otpauth://totp/alice?secret=" + testOTPSecret + "&algorithm=MD5",
+			wantErr: "otp algorithm must be SHA1, SHA256 or SHA512",
+		},
+		{
+			name:    "invalid_digits",
+			uri:     "otpauth://totp/alice?secret=" + testOTPSecret + "&digits=4",
+			wantErr: "otp digits must be from 6 to 8",
+		},
+		{
+			name:    "invalid_period",
+			uri:     "otpauth://totp/alice?secret=" + testOTPSecret + "&period=0",
+			wantErr: "totp period must be positive",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseOTPURI(test.uri)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestOTP_Code(t *testing.T) {
+	tests := []struct {
+		name          string
+		otp           OTP
+		now           time.Time
+		wantCode      string
+		wantRemaining time.Duration
+	}{
+		{
+			name:          "totp_rfc6238",
+			otp:           OTP{Type: OTPTypeTOTP, Secret: testOTPSecret, Algorithm: "SHA1", Digits: 8, Period: 30},
+			now:           time.Unix(59, 0),
+			wantCode:      "94287082",
+			wantRemaining: time.Second,
+		},
+		{
+			name:          "totp_defaults",
+			otp:           NewTOTP(testOTPSecret),
+			now:           time.Unix(1111111109, 0),
+			wantCode:      "081804",
+			wantRemaining: time.Second,
+		},
+		{
+			name:     "hotp_rfc4226",
+			otp:      OTP{Type: OTPTypeHOTP, Secret: testOTPSecret, Algorithm: "SHA1", Digits: 6, Counter: 1},
+			now:      time.Unix(59, 0),
+			wantCode: "287082",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := test.otp.Code(test.now)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantCode, code)
+			assert.Equal(t, test.wantRemaining, test.otp.Remaining(test.now))
+		})
+	}
+}
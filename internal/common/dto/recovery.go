@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Длина ключа аутентификации, выведенного из ключа восстановления
+const RecoveryAuthKeyLen = 32
+
+// RecoveryKey ключи восстановления доступа, хранящиеся на сервере.
+type RecoveryKey struct {
+	// Мастер ключ, а после перевода аккаунта ключ аккаунта, зашифрованный ключом восстановления, base64
+	MasterKey string `json:"master_key"`
+	// Ключ восстановления, зашифрованный тем же ключом, base64,
+	// нужен, чтобы перешифровать мастер ключ при смене пароля или переводе на ключ аккаунта
+	RecoveryKey string `json:"recovery_key"`
+	// Ключ аутентификации, выведенный из ключа восстановления, base64,
+	// передается только при настройке, сервер хранит его хэш
+	AuthKey string `json:"auth_key,omitempty"`
+}
+
+// Validate проверяет зашифрованные ключи, используется на сервере.
+// Если withAuthKey, проверяется и ключ аутентификации.
+func (k RecoveryKey) Validate(withAuthKey bool) error {
+	if err := ValidateSecretKey(k.MasterKey); err != nil {
+		return fmt.Errorf("master key: %w", err)
+	}
+	if err := ValidateSecretKey(k.RecoveryKey); err != nil {
+		return fmt.Errorf("recovery key: %w", err)
+	}
+	if withAuthKey {
+		return ValidateRecoveryAuthKey(k.AuthKey)
+	}
+	return nil
+}
+
+// RecoverySetupRequest структура запроса настройки ключа восстановления.
+// Настройка требует текущих учетных данных, одной сессии для нее недостаточно.
+type RecoverySetupRequest struct {
+	// Текущий ключ аутентификации, закодированный base64
+	AuthKey string `json:"auth_key,omitempty"`
+	// Текущий пароль, передается только для аккаунта с AuthVersionPassword
+	Password string `json:"password,omitempty"`
+	// Ключи восстановления с ключом аутентификации, выведенным из ключа восстановления
+	Recovery RecoveryKey `json:"recovery"`
+}
+
+// RecoveryStartRequest структура запроса ключей для восстановления доступа.
+type RecoveryStartRequest struct {
+	Login string `json:"login"`
+	// Ключ аутентификации, выведенный из ключа восстановления, base64
+	AuthKey string `json:"auth_key"`
+}
+
+// RecoveryStartResponse ключи для восстановления доступа.
+// Если аккаунт переведен на ключ аккаунта, передается только AccountKey,
+// иначе мастер ключ и ключи данных всех секретов.
+type RecoveryStartResponse struct {
+	// Мастер ключ, зашифрованный ключом восстановления, base64
+	MasterKey string `json:"master_key,omitempty"`
+	// Ключ аккаунта, зашифрованный ключом восстановления, base64
+	AccountKey string `json:"account_key,omitempty"`
+	// Ключи данных всех секретов пользователя, зашифрованные мастер ключом
+	Keys []SecretKey `json:"keys"`
+}
+
+// RecoveryRequest структура запроса сброса пароля по ключу восстановления.
+// Ключи данных перешифровываются на клиенте, сервер только заменяет их.
+type RecoveryRequest struct {
+	Login string `json:"login"`
+	// Ключ аутентификации, выведенный из ключа восстановления, base64
+	AuthKey string `json:"auth_key"`
+	// Ключ аутентификации, выведенный из нового мастер ключа, закодированный base64
+	NewAuthKey string `json:"new_auth_key"`
+	// Новая соль для создания мастер ключа закодированная base64
+	EncrSalt string `json:"encr_salt"`
+	// Параметры Argon2id, с которыми вычислен новый мастер ключ
+	KDF *KDFParams `json:"kdf,omitempty"`
+	// Ключ аккаунта, зашифрованный новым мастер ключом, если аккаунт переведен на ключ аккаунта,
+	// тогда ключи данных и ключи восстановления не меняются
+	AccountKey string `json:"account_key,omitempty"`
+	// Ключи данных всех секретов пользователя, зашифрованные новым мастер ключом
+	Keys []SecretKey `json:"keys"`
+	// Новый мастер ключ, зашифрованный ключом восстановления, и ключ восстановления,
+	// зашифрованный новым мастер ключом
+	Recovery *RecoveryKey `json:"recovery,omitempty"`
+	// Название устройства для новой сессии
+	Device string `json:"device,omitempty"`
+	// Код двухфакторной аутентификации, если она включена
+	OTP string `json:"otp,omitempty"`
+}
+
+// Validate проверяет запрос сброса пароля, используется на сервере.
+func (r RecoveryRequest) Validate() error {
+	change := PasswordChangeRequest{
+		NewAuthKey: r.NewAuthKey,
+		EncrSalt:   r.EncrSalt,
+		KDF:        r.KDF,
+		AccountKey: r.AccountKey,
+		Keys:       r.Keys,
+		Recovery:   r.Recovery,
+	}
+	if err := change.Validate(); err != nil {
+		return err
+	}
+	if r.AccountKey == "" && r.Recovery == nil {
+		return fmt.Errorf("recovery keys can not be empty")
+	}
+	return nil
+}
+
+// ValidateRecoveryAuthKey проверяет ключ аутентификации, выведенный из ключа восстановления.
+func ValidateRecoveryAuthKey(key string) error {
+	b, err := base64.RawStdEncoding.DecodeString(key)
+	if err != nil || len(b) != RecoveryAuthKeyLen {
+		return fmt.Errorf("auth key must be %d bytes base64 encoded", RecoveryAuthKeyLen)
+	}
+	return nil
+}
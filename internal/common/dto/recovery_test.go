@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryKey_Validate(t *testing.T) {
+	authKey := "QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVphYmNkZWY"
+
+	tests := []struct {
+		name        string
+		key         RecoveryKey
+		withAuthKey bool
+		wantErr     string
+	}{
+		{
+			name:        "succes",
+			key:         RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms", AuthKey: authKey},
+			withAuthKey: true,
+		},
+		{
+			name: "without_auth_key",
+			key:  RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"},
+		},
+		{
+			name:    "empty_master_key",
+			key:     RecoveryKey{RecoveryKey: "cms"},
+			wantErr: "master key: key can not be empty",
+		},
+		{
+			name:    "invalid_recovery_key",
+			key:     RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms=="},
+			wantErr: "recovery key: key must be base64 encoded without padding",
+		},
+		{
+			name:        "short_auth_key",
+			key:         RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms", AuthKey: "YXV0aA"},
+			withAuthKey: true,
+			wantErr:     "auth key must be 32 bytes base64 encoded",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+
+			err := test.key.Validate(test.withAuthKey)
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
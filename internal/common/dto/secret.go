@@ -1,38 +1,124 @@
 package dto
 
-import "time"
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 const (
 	SecretTypeCredentials = "credentials"
 	SecretTypeCard        = "card"
 	SecretTypeFile        = "file"
 	SecretTypeText        = "text"
+	SecretTypeOTP         = "otp"
 	SecretNameMaxLen      = 64
+	// Ограничения метаданных, значением может быть путь к файлу
+	SecretMetaMaxCount    = 16
+	SecretMetaNameMaxLen  = 64
+	SecretMetaValueMaxLen = 4096
+	// Максимальная длина зашифрованного ключа в base64, ограничена размером колонки в БД
+	SecretKeyMaxLen = 128
+	// Максимальный размер зашифрованных данных, переданных одним запросом,
+	// большие файлы передаются по частям
+	SecretDataMaxSize = 16 << 20
+)
+
+// Версии схемы ключей: каким ключом зашифрован ключ данных секрета.
+const (
+	// Ключ данных зашифрован мастер ключом, вычисленным из пароля
+	KeyVersionMaster uint8 = 0
+	// Ключ данных зашифрован ключом аккаунта, который зашифрован мастер ключом
+	KeyVersionAccount uint8 = 1
 )
 
 // SecretSupportedTypes доступные типы секретов.
 var SecretSupportedTypes = []string{
 	SecretTypeCredentials,
+	SecretTypeCard,
 	SecretTypeFile,
+	SecretTypeText,
+	SecretTypeOTP,
 }
 
 // SecretRequest струкура запроса.
 type SecretRequest struct {
+	// Идентификатор секрета, сгенерированный клиентом (UUID), к нему привязаны данные
+	UID      string        `json:"uid,omitempty"`
 	DataType string        `json:"data_type"`
 	Name     string        `json:"name"`
 	Meta     []MetaData    `json:"meta"`
 	EncrData EncryptedData `json:"data"`
 }
 
-// SecretRequest струкура запроса.
+// Validate проверяет запрос на создание секрета, используется на сервере.
+func (s SecretRequest) Validate() error {
+	if err := ValidateSecretUID(s.UID); err != nil {
+		return err
+	}
+	if err := ValidateSecretType(s.DataType); err != nil {
+		return err
+	}
+	if err := ValidateSecretName(s.Name); err != nil {
+		return err
+	}
+	if err := ValidateSecretMeta(s.Meta); err != nil {
+		return err
+	}
+	return s.EncrData.Validate()
+}
+
+// SecretUpdateRequest струкура запроса на изменение секрета.
+// Тип секрета изменить нельзя.
+type SecretUpdateRequest struct {
+	Name     string        `json:"name"`
+	Meta     []MetaData    `json:"meta"`
+	EncrData EncryptedData `json:"data"`
+	// Версия секрета, известная клиенту.
+	// Если на сервере версия секрета другая, изменение будет отклонено.
+	Version uint64 `json:"version"`
+	// UID, к которому привязаны новые данные, передается вместе с ними.
+	// Присваивается секрету, созданному до появления UID, у остальных должен совпадать
+	// с UID секрета, иначе изменение будет отклонено.
+	UID string `json:"uid,omitempty"`
+}
+
+// Validate проверяет запрос на изменение секрета, используется на сервере.
+// Данные могут отсутствовать, тогда меняются только название и метаданные.
+func (s SecretUpdateRequest) Validate() error {
+	if err := ValidateSecretUID(s.UID); err != nil {
+		return err
+	}
+	if err := ValidateSecretName(s.Name); err != nil {
+		return err
+	}
+	if err := ValidateSecretMeta(s.Meta); err != nil {
+		return err
+	}
+	if s.EncrData.Key == "" && len(s.EncrData.Data) == 0 {
+		return nil
+	}
+	return s.EncrData.Validate()
+}
+
+// SecretResponse струкура ответа.
 type SecretResponse struct {
-	ID       uint64        `json:"id"`
+	ID uint64 `json:"id"`
+	// Идентификатор, сгенерированный клиентом, пустой у секретов, созданных до его появления
+	UID      string        `json:"uid,omitempty"`
 	DataType string        `json:"data_type"`
 	Name     string        `json:"name"`
 	Meta     []MetaData    `json:"meta"`
 	EncrData EncryptedData `json:"data"`
 	Created  time.Time     `json:"created"`
 	Updated  time.Time     `json:"updated"`
+	Revision uint64        `json:"revision"`
+	Version  uint64        `json:"version"`
+	// Количество частей, на которые разбиты данные загруженного по частям секрета,
+	// 0 если данные переданы в EncrData
+	Chunks uint32 `json:"chunks"`
 }
 
 type SecretInfo struct {
@@ -41,6 +127,20 @@ type SecretInfo struct {
 	Name     string     `json:"name"`
 	Meta     []MetaData `json:"meta"`
 	Created  time.Time  `json:"created"`
+	Updated  time.Time  `json:"updated"`
+	Revision uint64     `json:"revision"`
+	Version  uint64     `json:"version"`
+	Chunks   uint32     `json:"chunks"`
+}
+
+// SecretChanges струкура ответа ленты изменений.
+type SecretChanges struct {
+	// Ревизия, с которой нужно запрашивать следующие изменения
+	Revision uint64 `json:"revision"`
+	// Секреты, созданные или измененные после запрошенной ревизии
+	Changed []SecretInfo `json:"changed"`
+	// ID секретов, удаленных после запрошенной ревизии
+	Deleted []uint64 `json:"deleted"`
 }
 
 // MetaData метаданные секрата.
@@ -49,10 +149,125 @@ type MetaData struct {
 	Value string `json:"value"`
 }
 
+// SecretKey зашифрованный ключ данных секрета.
+type SecretKey struct {
+	ID  uint64 `json:"id"`
+	Key string `json:"key"`
+	// Версия схемы ключей, KeyVersionMaster или KeyVersionAccount
+	Version uint8 `json:"version,omitempty"`
+}
+
 // EncryptedData зашифраванные данные секрета.
 type EncryptedData struct {
-	// Ключ ифрования зашифрованный мастер ключом, закодированн base64
+	// Ключ ифрования зашифрованный мастер ключом или ключом аккаунта, закодированн base64
 	Key string `json:"key"`
 	// Зашифрованные бинарные данные
 	Data []byte `json:"data"`
+	// Версия схемы ключей, KeyVersionMaster или KeyVersionAccount
+	Version uint8 `json:"version,omitempty"`
+}
+
+// Validate проверяет, что ключ закодирован base64 и помещается в БД, версия схемы ключей известна,
+// а данные не пустые и не превышают SecretDataMaxSize.
+func (e EncryptedData) Validate() error {
+	if err := ValidateSecretKey(e.Key); err != nil {
+		return err
+	}
+	if err := ValidateKeyVersion(e.Version); err != nil {
+		return err
+	}
+	if len(e.Data) == 0 {
+		return fmt.Errorf("data can not be empty")
+	}
+	if len(e.Data) > SecretDataMaxSize {
+		return fmt.Errorf("data too large (max %d bytes)", SecretDataMaxSize)
+	}
+	return nil
+}
+
+// ValidateSecretType проверяет, что тип секрета поддерживается.
+func ValidateSecretType(dataType string) error {
+	if !slices.Contains(SecretSupportedTypes, dataType) {
+		return fmt.Errorf(
+			"unsupported data type %q (supported: %s)",
+			dataType,
+			strings.Join(SecretSupportedTypes, ", "),
+		)
+	}
+	return nil
+}
+
+// ValidateSecretUID проверяет, что uid пустой или записан как UUID.
+func ValidateSecretUID(uid string) error {
+	if uid == "" {
+		return nil
+	}
+	if len(uid) != 36 {
+		return fmt.Errorf("uid must be a UUID")
+	}
+	for i, c := range uid {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return fmt.Errorf("uid must be a UUID")
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return fmt.Errorf("uid must be a UUID")
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateSecretName проверяет название секрета.
+func ValidateSecretName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name can not be empty")
+	}
+	if len([]rune(name)) > SecretNameMaxLen {
+		return fmt.Errorf("name too long (max %d chars)", SecretNameMaxLen)
+	}
+	return nil
+}
+
+// ValidateSecretMeta проверяет количество метаданных и длину их названий и значений.
+func ValidateSecretMeta(meta []MetaData) error {
+	if len(meta) > SecretMetaMaxCount {
+		return fmt.Errorf("too many metadata entries (max %d)", SecretMetaMaxCount)
+	}
+	for _, m := range meta {
+		if strings.TrimSpace(m.Name) == "" {
+			return fmt.Errorf("metadata name can not be empty")
+		}
+		if len([]rune(m.Name)) > SecretMetaNameMaxLen {
+			return fmt.Errorf("metadata name too long (max %d chars)", SecretMetaNameMaxLen)
+		}
+		if len([]rune(m.Value)) > SecretMetaValueMaxLen {
+			return fmt.Errorf("metadata value too long (max %d chars)", SecretMetaValueMaxLen)
+		}
+	}
+	return nil
+}
+
+// ValidateKeyVersion проверяет, что версия схемы ключей известна.
+func ValidateKeyVersion(version uint8) error {
+	if version != KeyVersionMaster && version != KeyVersionAccount {
+		return fmt.Errorf("unsupported key version %d", version)
+	}
+	return nil
+}
+
+// ValidateSecretKey проверяет зашифрованный ключ шифрования данных.
+func ValidateSecretKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key can not be empty")
+	}
+	if len(key) > SecretKeyMaxLen {
+		return fmt.Errorf("key too long (max %d chars)", SecretKeyMaxLen)
+	}
+	if _, err := base64.RawStdEncoding.DecodeString(key); err != nil {
+		return fmt.Errorf("key must be base64 encoded without padding")
+	}
+	return nil
 }
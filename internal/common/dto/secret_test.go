@@ -0,0 +1,221 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretRequest_Validate(t *testing.T) {
+	valid := SecretRequest{
+		DataType: SecretTypeText,
+		Name:     "name",
+		Meta:     []MetaData{{Name: "file_name", Value: "notes.txt"}},
+		EncrData: EncryptedData{Key: "a2V5", Data: []byte("data")},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *SecretRequest)
+		wantErr string
+	}{
+		{
+			name:   "success",
+			modify: func(s *SecretRequest) {},
+		},
+		{
+			name:   "with_uid",
+			modify: func(s *SecretRequest) { s.UID = "3f2b8c1e-5a4d-4e6f-9b7a-0c1d2e3f4a5b" },
+		},
+		{
+			name:    "invalid_uid",
+			modify:  func(s *SecretRequest) { s.UID = "3F2B8C1E5A4D4E6F9B7A0C1D2E3F4A5B" },
+			wantErr: "uid must be a UUID",
+		},
+		{
+			name:    "unsupported_type",
+			modify:  func(s *SecretRequest) { s.DataType = "photo" },
+			wantErr: `unsupported data type "photo" (supported: credentials, card, file, text, otp)`,
+		},
+		{
+			name:    "empty_name",
+			modify:  func(s *SecretRequest) { s.Name = " " },
+			wantErr: "name can not be empty",
+		},
+		{
+			name:    "name_too_long",
+			modify:  func(s *SecretRequest) { s.Name = strings.Repeat("я", SecretNameMaxLen+1) },
+			wantErr: "name too long (max 64 chars)",
+		},
+		{
+			name: "max_name_in_runes",
+			modify: func(s *SecretRequest) {
+				s.Name = strings.Repeat("я", SecretNameMaxLen)
+			},
+		},
+		{
+			name: "too_many_meta",
+			modify: func(s *SecretRequest) {
+				s.Meta = make([]MetaData, SecretMetaMaxCount+1)
+			},
+			wantErr: "too many metadata entries (max 16)",
+		},
+		{
+			name:    "empty_meta_name",
+			modify:  func(s *SecretRequest) { s.Meta = []MetaData{{Value: "value"}} },
+			wantErr: "metadata name can not be empty",
+		},
+		{
+			name: "meta_value_too_long",
+			modify: func(s *SecretRequest) {
+				s.Meta = []MetaData{{Name: "path", Value: strings.Repeat("a", SecretMetaValueMaxLen+1)}}
+			},
+			wantErr: "metadata value too long (max 4096 chars)",
+		},
+		{
+			name:    "empty_key",
+			modify:  func(s *SecretRequest) { s.EncrData.Key = "" },
+			wantErr: "key can not be empty",
+		},
+		{
+			name:    "key_too_long",
+			modify:  func(s *SecretRequest) { s.EncrData.Key = strings.Repeat("a", SecretKeyMaxLen+1) },
+			wantErr: "key too long (max 128 chars)",
+		},
+		{
+			name:    "key_not_base64",
+			modify:  func(s *SecretRequest) { s.EncrData.Key = "a2V5==" },
+			wantErr: "key must be base64 encoded without padding",
+		},
+		{
+			name:    "empty_data",
+			modify:  func(s *SecretRequest) { s.EncrData.Data = nil },
+			wantErr: "data can not be empty",
+		},
+		{
+			name:    "unsupported_key_version",
+			modify:  func(s *SecretRequest) { s.EncrData.Version = 2 },
+			wantErr: "unsupported key version 2",
+		},
+		{
+			name:    "data_too_large",
+			modify:  func(s *SecretRequest) { s.EncrData.Data = make([]byte, SecretDataMaxSize+1) },
+			wantErr: "data too large (max 16777216 bytes)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+			secret := valid
+			test.modify(&secret)
+
+			err := secret.Validate()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
+
+func TestSecretUpdateRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  SecretUpdateRequest
+		wantErr string
+	}{
+		{
+			name:   "without_data",
+			secret: SecretUpdateRequest{Name: "name", Version: 3},
+		},
+		{
+			name:   "with_data",
+			secret: SecretUpdateRequest{Name: "name", EncrData: EncryptedData{Key: "a2V5", Data: []byte("data")}},
+		},
+		{
+			name:    "data_without_key",
+			secret:  SecretUpdateRequest{Name: "name", EncrData: EncryptedData{Data: []byte("data")}},
+			wantErr: "key can not be empty",
+		},
+		{
+			name:    "empty_name",
+			secret:  SecretUpdateRequest{},
+			wantErr: "name can not be empty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+
+			err := test.secret.Validate()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
+
+func TestUploadRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		upload  UploadRequest
+		wantErr string
+	}{
+		{
+			name:   "success",
+			upload: UploadRequest{SecretID: 13, Version: 3, DataType: SecretTypeFile, Name: "name", Key: "a2V5"},
+		},
+		{
+			name: "account_key_version",
+			upload: UploadRequest{
+				DataType:   SecretTypeFile,
+				Name:       "name",
+				Key:        "a2V5",
+				KeyVersion: KeyVersionAccount,
+			},
+		},
+		{
+			name: "with_uid",
+			upload: UploadRequest{
+				UID:      "3f2b8c1e-5a4d-4e6f-9b7a-0c1d2e3f4a5b",
+				DataType: SecretTypeFile,
+				Name:     "name",
+				Key:      "a2V5",
+			},
+		},
+		{
+			name:    "invalid_uid",
+			upload:  UploadRequest{UID: "uid", DataType: SecretTypeFile, Name: "name", Key: "a2V5"},
+			wantErr: "uid must be a UUID",
+		},
+		{
+			name:    "without_type",
+			upload:  UploadRequest{SecretID: 13, Version: 3, Name: "name", Key: "a2V5"},
+			wantErr: `unsupported data type "" (supported: credentials, card, file, text, otp)`,
+		},
+		{
+			name:    "without_key",
+			upload:  UploadRequest{DataType: SecretTypeFile, Name: "name"},
+			wantErr: "key can not be empty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotErr string
+
+			err := test.upload.Validate()
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			assert.Equal(t, test.wantErr, gotErr, "Validation error")
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// Session структура ответа с информацией о сессии пользователя
+type Session struct {
+	ID       string    `json:"id"`
+	Device   string    `json:"device"`
+	IP       string    `json:"ip"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"last_seen"`
+	// Сессия, из которой выполнен запрос
+	Current bool `json:"current"`
+}
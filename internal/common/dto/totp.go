@@ -0,0 +1,22 @@
+package dto
+
+// TOTPSetup структура ответа с новым TOTP секретом,
+// секрет начинает проверяться при входе только после подтверждения кодом.
+type TOTPSetup struct {
+	// Секрет в кодировке base32 для ручного ввода в приложение аутентификатор
+	Secret string `json:"secret"`
+	// URI otpauth:// для QR кода
+	URI string `json:"uri"`
+}
+
+// TOTPCode структура запроса с кодом двухфакторной аутентификации
+type TOTPCode struct {
+	// Код из приложения аутентификатора или код восстановления
+	Code string `json:"code"`
+}
+
+// RecoveryCodes структура ответа с одноразовыми кодами восстановления,
+// сервер хранит только их хэши, поэтому показать их повторно нельзя.
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
@@ -0,0 +1,64 @@
+package dto
+
+// UploadMaxChunks максимальное количество частей одной загрузки,
+// с частями по 1 MB это файлы до 1 TB
+const UploadMaxChunks = 1 << 20
+
+// UploadRequest струкура запроса на создание сессии загрузки секрета по частям.
+type UploadRequest struct {
+	// ID секрета, данные которого заменяются, 0 для нового секрета
+	SecretID uint64 `json:"secret_id,omitempty"`
+	// Версия заменяемого секрета, известная клиенту
+	Version uint64 `json:"version,omitempty"`
+	// Идентификатор секрета, сгенерированный клиентом (UUID), к нему привязаны части данных.
+	// Заменяемому секрету присваивается, только если у него еще нет UID.
+	UID      string     `json:"uid,omitempty"`
+	DataType string     `json:"data_type"`
+	Name     string     `json:"name"`
+	Meta     []MetaData `json:"meta"`
+	// Ключ шифрования зашифрованный мастер ключом или ключом аккаунта, закодированн base64
+	Key string `json:"key"`
+	// Версия схемы ключей, KeyVersionMaster или KeyVersionAccount
+	KeyVersion uint8 `json:"key_version,omitempty"`
+}
+
+// Validate проверяет запрос на создание сессии загрузки, используется на сервере.
+func (u UploadRequest) Validate() error {
+	if err := ValidateSecretUID(u.UID); err != nil {
+		return err
+	}
+	if err := ValidateSecretType(u.DataType); err != nil {
+		return err
+	}
+	if err := ValidateSecretName(u.Name); err != nil {
+		return err
+	}
+	if err := ValidateSecretMeta(u.Meta); err != nil {
+		return err
+	}
+	if err := ValidateSecretKey(u.Key); err != nil {
+		return err
+	}
+	return ValidateKeyVersion(u.KeyVersion)
+}
+
+// UploadResponse струкура ответа на создание сессии загрузки.
+type UploadResponse struct {
+	ID string `json:"id"`
+}
+
+// UploadCommitRequest струкура запроса на завершение загрузки.
+type UploadCommitRequest struct {
+	// Количество загруженных частей
+	Chunks uint32 `json:"chunks"`
+}
+
+// UploadStatus струкура ответа на запрос состояния загрузки.
+type UploadStatus struct {
+	ID string `json:"id"`
+	// Количество частей, полученных подряд начиная с первой,
+	// загрузку нужно продолжать с части с этим номером
+	Received uint32 `json:"received"`
+	// Загрузка завершена, секрет сохранен
+	Committed bool `json:"committed"`
+}
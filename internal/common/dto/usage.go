@@ -0,0 +1,11 @@
+package dto
+
+// Usage струкура ответа с использованием хранилища пользователем.
+type Usage struct {
+	// Размер зашифрованных данных всех секретов в байтах
+	Bytes   int64 `json:"bytes"`
+	Secrets int64 `json:"secrets"`
+	// Квота пользователя, 0 - без ограничения
+	MaxBytes   int64 `json:"max_bytes"`
+	MaxSecrets int64 `json:"max_secrets"`
+}
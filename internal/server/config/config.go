@@ -4,7 +4,9 @@ package config
 import (
 	"flag"
 	"fmt"
+	"net/netip"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -28,16 +30,79 @@ type Config struct {
 	JWTpriv string `yaml:"jwt_priv" env:"JWT_PRIV" env-default:"rsa/jwt-priv.pem"`
 	// Путь к публичному ключу для JWT
 	JWTpub string `yaml:"jwt_pub" env:"JWT_PUB" env-default:"rsa/jwt-pub.pem"`
+	// Путь к приватному ключу для расшифровки тел запросов регистрации и логина,
+	// если не задан, шифрование тел запросов не поддерживается
+	PayloadPriv string `yaml:"payload_priv" env:"PAYLOAD_PRIV"`
 	// Время истечения годности токена
-	TokenTTL time.Duration `yaml:"token_ttl" env:"TOKEN_TTL" env-default:"24h"`
+	TokenTTL time.Duration `yaml:"token_ttl" env:"TOKEN_TTL" env-default:"15m"`
+	// Время истечения годности refresh токена
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" env-default:"720h"`
+	// Ограничение частоты запросов регистрации, входа и восстановления доступа
+	// в минуту с одного IP и входа с одним логином, 0 - без ограничения
+	AuthRateIP    int `yaml:"auth_rate_ip" env:"AUTH_RATE_IP" env-default:"30"`
+	AuthRateLogin int `yaml:"auth_rate_login" env:"AUTH_RATE_LOGIN" env-default:"10"`
+	// Максимальное количество IP и логинов, для которых хранятся счетчики ограничения частоты,
+	// сверх него новый счетчик вытесняет восстановившийся или использует общий
+	AuthRateMaxKeys int `yaml:"auth_rate_max_keys" env:"AUTH_RATE_MAX_KEYS" env-default:"100000"`
+	// Период удаления восстановившихся счетчиков IP и логинов
+	AuthRateGCInterval time.Duration `yaml:"auth_rate_gc_interval" env:"AUTH_RATE_GC_INTERVAL" env-default:"1m"`
+	// Сети доверенных прокси, для запросов от них IP клиента берется из X-Forwarded-For
+	TrustedProxies []netip.Prefix
+	// Количество неудачных попыток входа подряд, после которого логин блокируется, 0 - без блокировки
+	LoginLockThreshold int `yaml:"login_lock_threshold" env:"LOGIN_LOCK_THRESHOLD" env-default:"5"`
+	// Время первой блокировки логина, каждая следующая неудачная попытка удваивает его до LoginLockMax
+	LoginLockBase time.Duration `yaml:"login_lock_base" env:"LOGIN_LOCK_BASE" env-default:"30s"`
+	LoginLockMax  time.Duration `yaml:"login_lock_max" env:"LOGIN_LOCK_MAX" env-default:"1h"`
+	// Счетчик неудачных попыток начинается заново, если попыток не было это время
+	LoginLockReset time.Duration `yaml:"login_lock_reset" env:"LOGIN_LOCK_RESET" env-default:"24h"`
+	// Период удаления неудачных попыток входа старше LoginLockReset
+	LoginLockGCInterval time.Duration `yaml:"login_lock_gc_interval" env:"LOGIN_LOCK_GC_INTERVAL" env-default:"1h"`
+	// Период обновления распределения пользователей по схеме аутентификации и параметрам
+	// мастер ключа, по которому выбираются ответы PreLogin для несуществующих логинов
+	AuthProfilesInterval time.Duration `yaml:"auth_profiles_interval" env:"AUTH_PROFILES_INTERVAL" env-default:"10m"`
+	// Максимум одновременных вычислений Argon2id на сервере, 0 - по количеству CPU
+	HashConcurrency int `yaml:"hash_concurrency" env:"HASH_CONCURRENCY" env-default:"0"`
+	// Секретный ключ кодов приглашения, если задан, регистрация возможна только по приглашению
+	InviteKey string `yaml:"invite_key" env:"INVITE_KEY"`
+	// Логин, для которого нужно вывести код приглашения и завершить работу (флаг -invite)
+	InviteLogin string
 	// Максимальный размер тела запроса для регистрации и логина в систему в байтах
 	AuthBodyMaxSize int64
+	// Максимальный размер тела запроса для создания и изменения секрета в байтах
+	SecretBodyMaxSize int64
+	// Квота пользователя: суммарный размер данных секретов в байтах и количество секретов, 0 - без ограничения
+	QuotaBytes   int64
+	QuotaSecrets int64 `yaml:"quota_secrets" env:"QUOTA_SECRETS" env-default:"10000"`
+	// Хранилище данных файлов: "fs" - локальный каталог, "s3" - S3-совместимое хранилище
+	BlobStore string `yaml:"blob_store" env:"BLOB_STORE" env-default:"fs"`
+	// Каталог хранилища "fs"
+	BlobDir string `yaml:"blob_dir" env:"BLOB_DIR" env-default:"blobs"`
+	// Параметры хранилища "s3"
+	S3Endpoint  string `yaml:"s3_endpoint" env:"S3_ENDPOINT" env-default:"http://localhost:9000"`
+	S3Bucket    string `yaml:"s3_bucket" env:"S3_BUCKET" env-default:"gophkeeper"`
+	S3Region    string `yaml:"s3_region" env:"S3_REGION" env-default:"us-east-1"`
+	S3AccessKey string `yaml:"s3_access_key" env:"S3_ACCESS_KEY"`
+	S3SecretKey string `yaml:"s3_secret_key" env:"S3_SECRET_KEY"`
+	// Данные файлов больше этого размера в байтах хранятся в хранилище, а не в БД
+	BlobThreshold int64
+	// Период запуска сборщика мусора хранилища
+	BlobGCInterval time.Duration `yaml:"blob_gc_interval" env:"BLOB_GC_INTERVAL" env-default:"1h"`
+	// Объекты хранилища моложе этого возраста сборщик мусора не удаляет
+	BlobGCGrace time.Duration `yaml:"blob_gc_grace" env:"BLOB_GC_GRACE" env-default:"1h"`
+	// Незавершенные загрузки по частям старше этого времени удаляются сборщиком мусора
+	UploadTTL time.Duration `yaml:"upload_ttl" env:"UPLOAD_TTL" env-default:"24h"`
 }
 
 // Промежуточная конфигурация, служит для преобразования пользовательского ввода типа 10MB
 // в реальное занчение конфига
 type rawConfig struct {
 	AuthBodyMaxSize string `yaml:"auth_body_max_size" env:"AUTH_BODY_MAX_SIZE" env-default:"4KB"`
+	BlobThreshold   string `yaml:"blob_threshold" env:"BLOB_THRESHOLD" env-default:"64KB"`
+	// Размер тела включает данные секрета в base64, поэтому больше dto.SecretDataMaxSize
+	SecretBodyMaxSize string `yaml:"secret_body_max_size" env:"SECRET_BODY_MAX_SIZE" env-default:"24MB"`
+	QuotaBytes        string `yaml:"quota_bytes" env:"QUOTA_BYTES" env-default:"10GB"`
+	// Адреса и сети доверенных прокси через запятую, например "10.0.0.0/8,192.0.2.1"
+	TrustedProxies string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
 }
 
 // Загружает конфигурацию из файла, переменных среды и флагов (в порядке приоритета).
@@ -51,8 +116,9 @@ func Load() (*Config, error) {
 		flagC = flag.String("c", "", "config file path")
 		flagA = flag.String("a", "", "address to serve https")
 		flagD = flag.String("d", "", "database dsn")
-		flagT = flag.String("t", "24h", "token ttl in 15h04m05s format")
+		flagT = flag.String("t", "15m", "token ttl in 15h04m05s format")
 		flagS = flag.String("s", "4KB", "max auth body size")
+		flagI = flag.String("invite", "", "print invite code for login and exit")
 	)
 
 	err := flag.CommandLine.Parse(os.Args[1:])
@@ -97,6 +163,8 @@ func Load() (*Config, error) {
 			}
 		case "s":
 			rawCfg.AuthBodyMaxSize = *flagS
+		case "invite":
+			cfg.InviteLogin = *flagI
 		}
 	})
 
@@ -107,5 +175,64 @@ func Load() (*Config, error) {
 	}
 	cfg.AuthBodyMaxSize = int64(b)
 
+	b, err = bytesize.Parse(rawCfg.SecretBodyMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SecretBodyMaxSize")
+	}
+	cfg.SecretBodyMaxSize = int64(b)
+
+	b, err = bytesize.Parse(rawCfg.QuotaBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse QuotaBytes")
+	}
+	cfg.QuotaBytes = int64(b)
+
+	b, err = bytesize.Parse(rawCfg.BlobThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse BlobThreshold")
+	}
+	cfg.BlobThreshold = int64(b)
+
+	cfg.TrustedProxies, err = parsePrefixes(rawCfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TrustedProxies: %w", err)
+	}
+
+	if cfg.InviteLogin != "" && cfg.InviteKey == "" {
+		return nil, fmt.Errorf("invite key is not set")
+	}
+
+	if cfg.BlobStore != "fs" && cfg.BlobStore != "s3" {
+		return nil, fmt.Errorf("unknown blob store %q", cfg.BlobStore)
+	}
+
 	return cfg, nil
 }
+
+// parsePrefixes разбирает список адресов и сетей через запятую, адрес считается сетью из одного адреса.
+func parsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
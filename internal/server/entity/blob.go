@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+// Blob объект в хранилище зашифрованных данных.
+type Blob struct {
+	Key      string
+	Modified time.Time
+}
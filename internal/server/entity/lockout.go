@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"math"
+	"time"
+)
+
+// LoginAttempt неудачные попытки входа по логину, в том числе несуществующему, из сети Network.
+type LoginAttempt struct {
+	Login       string     `db:"login"`
+	Network     string     `db:"network"`
+	Failures    int        `db:"failures"`
+	LockedUntil *time.Time `db:"locked_until"`
+	Updated     time.Time  `db:"updated_at"`
+}
+
+// LockoutPolicy правила блокировки входа после неудачных попыток.
+type LockoutPolicy struct {
+	// Количество неудачных попыток подряд, после которого логин блокируется
+	Threshold int
+	// Время первой блокировки, каждая следующая неудачная попытка удваивает его
+	Base time.Duration
+	// Максимальное время блокировки
+	Max time.Duration
+	// Счетчик попыток начинается заново, если неудачных попыток не было это время
+	Reset time.Duration
+}
+
+// LockFor возвращает время блокировки после failures неудачных попыток подряд, 0 - без блокировки.
+func (p LockoutPolicy) LockFor(failures int) time.Duration {
+	if p.Threshold <= 0 || p.Base <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	d := p.Base
+	for i := p.Threshold; i < failures && d <= math.MaxInt64/2; i++ {
+		d *= 2
+		if p.Max > 0 && d >= p.Max {
+			return p.Max
+		}
+	}
+	if p.Max > 0 && d > p.Max {
+		return p.Max
+	}
+
+	return d
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutPolicy_LockFor(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 3, Base: time.Second, Max: time.Minute}
+
+	tests := []struct {
+		name     string
+		policy   LockoutPolicy
+		failures int
+		want     time.Duration
+	}{
+		{name: "below_threshold", policy: policy, failures: 2, want: 0},
+		{name: "threshold", policy: policy, failures: 3, want: time.Second},
+		{name: "doubles", policy: policy, failures: 5, want: 4 * time.Second},
+		{name: "capped", policy: policy, failures: 100, want: time.Minute},
+		{name: "disabled", policy: LockoutPolicy{}, failures: 100, want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.policy.LockFor(test.failures))
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package entity
+
+// RecoveryKey ключи восстановления доступа пользователя.
+type RecoveryKey struct {
+	UserID string `db:"user_id"`
+	// Мастер ключ, а после перевода аккаунта ключ аккаунта, зашифрованный ключом восстановления, base64
+	MasterKey string `db:"master_key"`
+	// Ключ восстановления, зашифрованный тем же ключом, base64
+	RecoveryKey string `db:"recovery_key"`
+	// Хэш ключа аутентификации, выведенного из ключа восстановления
+	AuthHash string `db:"auth_hash"`
+}
@@ -3,15 +3,24 @@ package entity
 import "time"
 
 type Secret struct {
-	ID            uint64    `db:"id"`
-	UserID        string    `db:"user_id"`
-	DataType      string    `db:"data_type"`
-	Name          string    `db:"name"`
-	MetaData      string    `db:"meta_data"`
-	EncryptedData []byte    `db:"encrypted_data"`
-	EncryptedKey  string    `db:"encrypted_key"`
-	Created       time.Time `db:"created_at"`
-	Updated       time.Time `db:"updated_at"`
+	ID     uint64 `db:"id"`
+	UserID string `db:"user_id"`
+	// Идентификатор, сгенерированный клиентом, пустой у секретов, созданных до его появления
+	UID           string `db:"uid"`
+	DataType      string `db:"data_type"`
+	Name          string `db:"name"`
+	MetaData      string `db:"meta_data"`
+	EncryptedData []byte `db:"encrypted_data"`
+	EncryptedKey  string `db:"encrypted_key"`
+	// Версия схемы ключей: каким ключом зашифрован EncryptedKey
+	KeyVersion uint8     `db:"key_version"`
+	Created    time.Time `db:"created_at"`
+	Updated    time.Time `db:"updated_at"`
+	Revision   uint64    `db:"revision"`
+	Version    uint64    `db:"version"`
+	Chunks     uint32    `db:"chunks"`
+	// Ключ данных в хранилище вне БД, пустой, если данные в EncryptedData
+	BlobKey string `db:"blob_key"`
 }
 
 type SecretInfo struct {
@@ -21,4 +30,19 @@ type SecretInfo struct {
 	MetaData string    `db:"meta_data"`
 	Created  time.Time `db:"created_at"`
 	Updated  time.Time `db:"updated_at"`
+	Revision uint64    `db:"revision"`
+	Version  uint64    `db:"version"`
+	Chunks   uint32    `db:"chunks"`
+}
+
+// SecretTombstone запись об удаленном секрете.
+type SecretTombstone struct {
+	SecretID uint64 `db:"secret_id"`
+	Revision uint64 `db:"revision"`
+}
+
+// SecretChanges измененные и удаленные секреты пользователя, прочитанные из одного снимка БД.
+type SecretChanges struct {
+	Changed []SecretInfo
+	Deleted []SecretTombstone
 }
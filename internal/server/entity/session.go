@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// Session сессия пользователя, создается при входе в систему, ее ID содержится в токене авторизации.
+type Session struct {
+	ID       string    `db:"id"`
+	UserID   string    `db:"user_id"`
+	Device   string    `db:"device"`
+	IP       string    `db:"ip"`
+	Created  time.Time `db:"created_at"`
+	LastSeen time.Time `db:"last_seen_at"`
+	Expires  time.Time `db:"expires_at"`
+}
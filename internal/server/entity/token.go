@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// RefreshToken токен для выпуска новых токенов доступа, в БД хранится только его хэш.
+type RefreshToken struct {
+	UserID string `db:"user_id"`
+	Hash   string `db:"hash"`
+	// Сессия, для которой выпущен токен
+	SessionID string    `db:"session_id"`
+	Expires   time.Time `db:"expires_at"`
+}
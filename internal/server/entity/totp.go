@@ -0,0 +1,12 @@
+package entity
+
+// TOTP секрет двухфакторной аутентификации пользователя.
+type TOTP struct {
+	UserID string `db:"user_id"`
+	// Секрет в кодировке base32
+	Secret string `db:"secret"`
+	// Секрет подтвержден кодом и проверяется при входе
+	Enabled bool `db:"enabled"`
+	// Временной шаг последнего принятого кода
+	LastStep int64 `db:"last_step"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+type Upload struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+	// Секрет, данные которого заменяются, 0 для нового секрета
+	SecretID     uint64 `db:"secret_id"`
+	Version      uint64 `db:"version"`
+	UID          string `db:"uid"`
+	DataType     string `db:"data_type"`
+	Name         string `db:"name"`
+	MetaData     string `db:"meta_data"`
+	EncryptedKey string `db:"encrypted_key"`
+	KeyVersion   uint8  `db:"key_version"`
+}
+
+// UploadStatus состояние загрузки секрета по частям.
+type UploadStatus struct {
+	// Количество частей, полученных подряд начиная с первой
+	Received  uint32 `db:"received"`
+	Committed bool   `db:"committed"`
+}
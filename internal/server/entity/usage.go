@@ -0,0 +1,25 @@
+package entity
+
+// Usage использование хранилища пользователем.
+type Usage struct {
+	Bytes   int64 `db:"used_bytes"`
+	Secrets int64 `db:"secret_count"`
+}
+
+// Quota ограничения хранилища пользователя, 0 - без ограничения.
+type Quota struct {
+	MaxBytes   int64
+	MaxSecrets int64
+}
+
+// Allows проверяет, что после добавления bytes байт и secrets секретов использование не превысит ограничений.
+// Уменьшение использования разрешено всегда.
+func (q Quota) Allows(u Usage, bytes, secrets int64) bool {
+	if q.MaxBytes > 0 && bytes > 0 && u.Bytes+bytes > q.MaxBytes {
+		return false
+	}
+	if q.MaxSecrets > 0 && secrets > 0 && u.Secrets+secrets > q.MaxSecrets {
+		return false
+	}
+	return true
+}
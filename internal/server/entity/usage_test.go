@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota_Allows(t *testing.T) {
+	quota := Quota{MaxBytes: 100, MaxSecrets: 2}
+	usage := Usage{Bytes: 90, Secrets: 2}
+
+	tests := []struct {
+		name    string
+		quota   Quota
+		bytes   int64
+		secrets int64
+		want    bool
+	}{
+		{name: "fits", quota: quota, bytes: 10, want: true},
+		{name: "too_many_bytes", quota: quota, bytes: 11, want: false},
+		{name: "too_many_secrets", quota: quota, secrets: 1, want: false},
+		{name: "decrease_over_quota", quota: Quota{MaxBytes: 50, MaxSecrets: 1}, bytes: -10, want: true},
+		{name: "unlimited", quota: Quota{}, bytes: 1 << 40, secrets: 1000, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.quota.Allows(usage, test.bytes, test.secrets))
+		})
+	}
+}
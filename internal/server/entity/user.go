@@ -3,10 +3,84 @@ package entity
 import "time"
 
 type User struct {
-	ID       string    `db:"id"`
-	Login    string    `db:"login"`
-	Hash     string    `db:"hash"`
-	AuthSalt string    `db:"auth_salt"`
-	EncrSalt string    `db:"encr_salt"`
-	Created  time.Time `db:"created_at"`
+	ID       string `db:"id"`
+	Login    string `db:"login"`
+	Hash     string `db:"hash"`
+	AuthSalt string `db:"auth_salt"`
+	EncrSalt string `db:"encr_salt"`
+	// Версия схемы аутентификации, dto.AuthVersionPassword или dto.AuthVersionKey:
+	// хэш пароля или хэш ключа аутентификации хранится в Hash
+	AuthVersion uint8 `db:"auth_version"`
+	// Параметры Argon2id для вычисления мастер ключа на клиенте
+	KDF KDFParams
+	// Ключ аккаунта, зашифрованный мастер ключом,
+	// пустой, если аккаунт еще не переведен на ключ аккаунта
+	AccountKey string    `db:"account_key"`
+	Created    time.Time `db:"created_at"`
+}
+
+// KDFParams параметры Argon2id, с которыми клиент вычисляет мастер ключ из пароля.
+type KDFParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// AuthProfile версия схемы аутентификации и параметры мастер ключа, общие для Count пользователей.
+// По распределению профилей выбираются ответы PreLogin для несуществующих логинов.
+type AuthProfile struct {
+	AuthVersion uint8
+	KDF         KDFParams
+	Count       uint64
+}
+
+// SecretKey зашифрованный ключ данных секрета.
+type SecretKey struct {
+	SecretID     uint64 `db:"id"`
+	EncryptedKey string `db:"encrypted_key"`
+	Version      uint8  `db:"key_version"`
+}
+
+// PasswordChange новые хэш пароля и соли пользователя
+// и ключи данных всех его секретов, зашифрованные новым мастер ключом.
+type PasswordChange struct {
+	UserID string
+	// Хэш текущего пароля, пароль меняется, только если его не изменили параллельно
+	OldHash string
+	// Хэш ключа аутентификации, выведенного из нового мастер ключа
+	Hash     string
+	AuthSalt string
+	EncrSalt string
+	// Параметры Argon2id, с которыми вычислен новый мастер ключ
+	KDF KDFParams
+	// Сессия, в которой меняется пароль, остальные сессии пользователя завершаются,
+	// если сессия не указана, завершаются все сессии
+	SessionID string
+	// Ключ аккаунта, зашифрованный новым мастер ключом, если аккаунт переведен на ключ аккаунта,
+	// тогда Keys содержат только ключи старого формата, перешифрованные ключом аккаунта
+	AccountKey string
+	Keys       []SecretKey
+	// Ключи восстановления, перешифрованные новым мастер ключом,
+	// если не указаны, ключи восстановления удаляются
+	Recovery *RecoveryKey
+}
+
+// AuthKeyChange перевод аккаунта с хэша пароля на хэш ключа аутентификации при входе.
+type AuthKeyChange struct {
+	UserID string
+	// Хэш пароля, аккаунт переводится, только если пароль не изменили параллельно
+	OldHash  string
+	Hash     string
+	AuthSalt string
+}
+
+// AccountKeyChange перевод аккаунта на ключ аккаунта: ключ аккаунта, зашифрованный мастер ключом,
+// и ключи данных всех секретов пользователя, перешифрованные ключом аккаунта.
+type AccountKeyChange struct {
+	UserID     string
+	AccountKey string
+	Keys       []SecretKey
+	// Ключи восстановления, перешифрованные для ключа аккаунта,
+	// если не указаны, ключи восстановления удаляются
+	Recovery *RecoveryKey
 }
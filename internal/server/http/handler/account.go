@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type AccountService interface {
+	// SetAccountKey переводит аккаунт текущего пользователя на ключ аккаунта.
+	SetAccountKey(ctx context.Context, req *dto.AccountKeyRequest) error
+}
+
+// Account обработчик запроса перевода аккаунта на ключ аккаунта
+type Account struct {
+	service     AccountService
+	logger      Logger
+	bodyMaxSize int64
+}
+
+// NewAccount создает обработчик перевода на ключ аккаунта, запрос содержит ключи всех секретов
+// пользователя, как и запрос смены пароля.
+func NewAccount(srv AccountService, l Logger, bodyMaxSize int64) *Account {
+	return &Account{service: srv, logger: l, bodyMaxSize: bodyMaxSize}
+}
+
+// SetKey сохраняет ключ аккаунта и перешифрованные им ключи данных секретов.
+// Если ключ аккаунта уже сохранен или ключи не совпадают с секретами пользователя, отдает 409.
+func (h *Account) SetKey(w http.ResponseWriter, r *http.Request) {
+	var req dto.AccountKeyRequest
+
+	if !decodeJSON(w, r, h.bodyMaxSize, &req) {
+		return
+	}
+
+	err := h.service.SetAccountKey(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, srvErrors.ErrAccountKeyInvalidRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, srvErrors.ErrAccountKeyExists), errors.Is(err, srvErrors.ErrPasswordKeysMismatch):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+func TestAccount_SetKey(t *testing.T) {
+	request := dto.AccountKeyRequest{
+		AccountKey: "YWNjb3VudA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ", Version: dto.KeyVersionAccount}},
+	}
+	reqBody, err := json.Marshal(request)
+	require.Nil(t, err, "account key request json encoding")
+
+	tests := []struct {
+		name    string
+		reqBody []byte
+		err     error
+		call    bool
+		want    handlerWant
+	}{
+		{
+			name:    "success",
+			reqBody: reqBody,
+			call:    true,
+			want:    handlerWant{code: http.StatusNoContent},
+		},
+		{
+			name:    "invalid_json",
+			reqBody: []byte("{"),
+			want:    handlerWant{code: http.StatusBadRequest, body: "invalid request format"},
+		},
+		{
+			name:    "invalid_request",
+			reqBody: reqBody,
+			call:    true,
+			err:     errors.ErrAccountKeyInvalidRequest,
+			want:    handlerWant{code: http.StatusBadRequest, body: errors.ErrAccountKeyInvalidRequest.Error()},
+		},
+		{
+			name:    "already_set",
+			reqBody: reqBody,
+			call:    true,
+			err:     errors.ErrAccountKeyExists,
+			want:    handlerWant{code: http.StatusConflict, body: errors.ErrAccountKeyExists.Error()},
+		},
+		{
+			name:    "keys_mismatch",
+			reqBody: reqBody,
+			call:    true,
+			err:     errors.ErrPasswordKeysMismatch,
+			want:    handlerWant{code: http.StatusConflict, body: errors.ErrPasswordKeysMismatch.Error()},
+		},
+		{
+			name:    "server_error",
+			reqBody: reqBody,
+			call:    true,
+			err:     errors.ErrUnexpected,
+			want:    handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	logger := mocks.NewMockLogger(gomock.NewController(t))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := mocks.NewMockAccountService(gomock.NewController(t))
+			if test.call {
+				service.EXPECT().SetAccountKey(gomock.Any(), &request).Return(test.err)
+			}
+			handler := NewAccount(service, logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPut, "/account-key", bytes.NewReader(test.reqBody))
+			w := httptest.NewRecorder()
+			handler.SetKey(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
@@ -11,13 +11,17 @@ import (
 )
 
 type AuthService interface {
-	// Register регистрирует пользователя по логину и паролю.
+	// Register регистрирует пользователя по логину и ключу аутентификации.
 	Register(ctx context.Context, c dto.Credentials) (dto.AuthResponse, error)
-	// Login выполняет вход пользователя в систему по логину с паролем.
+	// PreLogin возвращает параметры мастер ключа пользователя для вычисления ключа аутентификации.
+	PreLogin(ctx context.Context, req dto.PreLoginRequest) (dto.PreLoginResponse, error)
+	// Login выполняет вход пользователя в систему по логину с ключом аутентификации.
 	Login(ctx context.Context, c dto.Credentials) (dto.AuthResponse, error)
+	// Refresh выпускает новую пару токенов по refresh токену.
+	Refresh(ctx context.Context, refreshToken string) (dto.TokenResponse, error)
 }
 
-// Auth обработчик запросов регистрации и логина
+// Auth обработчик запросов регистрации, логина и обновления токенов
 type Auth struct {
 	service     AuthService
 	logger      Logger
@@ -28,9 +32,11 @@ func NewAuth(srv AuthService, l Logger, bodyMaxSize int64) *Auth {
 	return &Auth{service: srv, logger: l, bodyMaxSize: bodyMaxSize}
 }
 
-// Register регистрирует пользователя по логину и паролю.
+// Register регистрирует пользователя по логину и ключу аутентификации,
+// соль и параметры мастер ключа вычисляет клиент.
 // В случае успеха, возвращает JSON, содержащий токен (JWT)
-// и соль для создания мастер ключа, закодированную base64
+// и соль для создания мастер ключа, закодированную base64.
+// Если сервер регистрирует по приглашениям, без верного кода приглашения отвечает 403.
 func (h *Auth) Register(w http.ResponseWriter, r *http.Request) {
 	var credentials dto.Credentials
 
@@ -47,6 +53,8 @@ func (h *Auth) Register(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		case errors.Is(err, srvErrors.ErrAuthUserAlreadyExists):
 			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, srvErrors.ErrAuthInvalidInvite):
+			http.Error(w, err.Error(), http.StatusForbidden)
 		default:
 			http.Error(w, statusText500, http.StatusInternalServerError)
 		}
@@ -57,7 +65,29 @@ func (h *Auth) Register(w http.ResponseWriter, r *http.Request) {
 	newJSONwriter(w, h.logger).write(resp, "register response", http.StatusOK)
 }
 
-// Login вход пользователя в систему по логину с паролем.
+// PreLogin отдает соль, параметры Argon2id мастер ключа и версию схемы аутентификации по логину.
+// Для несуществующего логина ответ не отличается от ответа для существующего.
+func (h *Auth) PreLogin(w http.ResponseWriter, r *http.Request) {
+	var req dto.PreLoginRequest
+
+	if !decodeJSON(w, r, h.bodyMaxSize, &req) {
+		return
+	}
+
+	resp, err := h.service.PreLogin(r.Context(), req)
+	if err != nil {
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(resp, "prelogin response", http.StatusOK)
+}
+
+// Login вход пользователя в систему по логину с ключом аутентификации
+// (для аккаунтов, еще не переведенных на него, — с паролем).
+// Если включена двухфакторная аутентификация, без кода отвечает 401 с текстом «2fa required».
+// Если вход по логину заблокирован после неудачных попыток или сервер перегружен,
+// отвечает 429 с заголовком Retry-After.
 // В случае успеха, возвращает JSON, содержащий токен (JWT)
 // и соль для создания мастер ключа, закодированную base64
 func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
@@ -71,9 +101,15 @@ func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.service.Login(r.Context(), credentials)
 	if err != nil {
-		if errors.Is(err, srvErrors.ErrAuthInvalidCredentials) {
+		if writeRetry(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, srvErrors.ErrAuthInvalidCredentials):
 			http.Error(w, "", http.StatusUnauthorized)
-		} else {
+		case errors.Is(err, srvErrors.ErrAuthOTPRequired), errors.Is(err, srvErrors.ErrAuthInvalidOTP):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
 			http.Error(w, statusText500, http.StatusInternalServerError)
 		}
 		return
@@ -81,3 +117,27 @@ func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
 
 	newJSONwriter(w, h.logger).write(resp, "register response", http.StatusOK)
 }
+
+// Refresh выпускает новую пару токенов по refresh токену, переданный токен при этом отзывается.
+// В случае успеха, возвращает JSON, содержащий новые токен (JWT) и refresh токен
+func (h *Auth) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshRequest
+
+	body := http.MaxBytesReader(w, r.Body, h.bodyMaxSize)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrAuthInvalidToken) {
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(resp, "refresh response", http.StatusOK)
+}
@@ -2,15 +2,18 @@ package handler
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
@@ -21,7 +24,7 @@ func TestAuth_Register(t *testing.T) {
 	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
 		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
 	salt := "duBXKxwaWXfhgXBQrrwdtQ"
-	successBody := `{"token":"` + token + `","encr_salt":"` + salt + `"}`
+	successBody := `{"token":"` + token + `","refresh_token":"refresh","encr_salt":"` + salt + `"}`
 
 	errLoginTooLong := fmt.Errorf(
 		"%w: login too long, max %d characters",
@@ -54,7 +57,7 @@ func TestAuth_Register(t *testing.T) {
 				service := mocks.NewMockAuthService(ctrl)
 				service.EXPECT().
 					Register(gomock.All(), dto.Credentials{Login: "testLogin", Password: "t1estP5assword"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: salt}, nil)
+					Return(dto.AuthResponse{Token: token, RefreshToken: "refresh", EncrSalt: salt}, nil)
 				return service
 			},
 			want: want{
@@ -150,6 +153,22 @@ func TestAuth_Register(t *testing.T) {
 				body: "user already exists",
 			},
 		},
+		{
+			name: "negative_invalid_invite",
+			body: `{"login":"testLogin", "password":"t1estP5assword", "invite":"abcd"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Register(gomock.All(), dto.Credentials{Login: "testLogin", Password: "t1estP5assword", Invite: "abcd"}).
+					Return(dto.AuthResponse{}, errors.ErrAuthInvalidInvite)
+				return service
+			},
+			want: want{
+				code: http.StatusForbidden,
+				body: "invalid invite code",
+			},
+		},
 		{
 			name: "negative_server_error",
 			body: `{"login":"testLogin", "password":"t1estP5assword"}`,
@@ -202,7 +221,7 @@ func TestAuth_Login(t *testing.T) {
 	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
 		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
 	salt := "duBXKxwaWXfhgXBQrrwdtQ"
-	successBody := `{"token":"` + token + `","encr_salt":"` + salt + `"}`
+	successBody := `{"token":"` + token + `","refresh_token":"refresh","encr_salt":"` + salt + `"}`
 
 	var longPassword strings.Builder
 	for range 100 {
@@ -211,8 +230,9 @@ func TestAuth_Login(t *testing.T) {
 	tooLargeBody := `{"login":"testLogin", "password":"` + longPassword.String() + `"}`
 
 	type want struct {
-		code int
-		body string
+		code       int
+		body       string
+		retryAfter string
 	}
 
 	tests := []struct {
@@ -223,13 +243,13 @@ func TestAuth_Login(t *testing.T) {
 	}{
 		{
 			name: "success",
-			body: `{"login":"testLogin", "password":"t1estP5assword"}`,
+			body: `{"login":"testLogin", "auth_key":"b2xkS2V5"}`,
 			setup: func(t *testing.T) AuthService {
 				ctrl := gomock.NewController(t)
 				service := mocks.NewMockAuthService(ctrl)
 				service.EXPECT().
-					Login(gomock.All(), dto.Credentials{Login: "testLogin", Password: "t1estP5assword"}).
-					Return(dto.AuthResponse{Token: token, EncrSalt: salt}, nil)
+					Login(gomock.All(), dto.Credentials{Login: "testLogin", AuthKey: "b2xkS2V5"}).
+					Return(dto.AuthResponse{Token: token, RefreshToken: "refresh", EncrSalt: salt}, nil)
 				return service
 			},
 			want: want{
@@ -237,6 +257,23 @@ func TestAuth_Login(t *testing.T) {
 				body: successBody,
 			},
 		},
+		{
+			name: "negative_too_many_attempts",
+			body: `{"login":"testLogin", "auth_key":"b2xkS2V5"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Login(gomock.All(), dto.Credentials{Login: "testLogin", AuthKey: "b2xkS2V5"}).
+					Return(dto.AuthResponse{}, &errors.RetryError{Err: errors.ErrAuthTooManyAttempts, RetryAfter: 1500 * time.Millisecond})
+				return service
+			},
+			want: want{
+				code:       http.StatusTooManyRequests,
+				body:       errors.ErrAuthTooManyAttempts.Error(),
+				retryAfter: "2",
+			},
+		},
 		{
 			name: "negative_bad_json",
 			body: `not valid jsson`,
@@ -277,6 +314,38 @@ func TestAuth_Login(t *testing.T) {
 				body: "",
 			},
 		},
+		{
+			name: "negative_2fa_required",
+			body: `{"login":"testLogin", "password":"t1estP5assword"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Login(gomock.All(), dto.Credentials{Login: "testLogin", Password: "t1estP5assword"}).
+					Return(dto.AuthResponse{}, errors.ErrAuthOTPRequired)
+				return service
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				body: "2fa required",
+			},
+		},
+		{
+			name: "negative_invalid_2fa_code",
+			body: `{"login":"testLogin", "password":"t1estP5assword", "otp":"123456"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Login(gomock.All(), dto.Credentials{Login: "testLogin", Password: "t1estP5assword", OTP: "123456"}).
+					Return(dto.AuthResponse{}, errors.ErrAuthInvalidOTP)
+				return service
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				body: "invalid 2fa code",
+			},
+		},
 		{
 			name: "negative_server_error",
 			body: `{"login":"testLogin", "password":"t1estP5assword"}`,
@@ -313,6 +382,182 @@ func TestAuth_Login(t *testing.T) {
 			res := w.Result()
 			defer res.Body.Close()
 
+			assert.Equal(t, test.want.code, res.StatusCode, "Response status code")
+			assert.Equal(t, test.want.retryAfter, res.Header.Get("Retry-After"), "Retry-After header")
+
+			resBody, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := strings.TrimSuffix(string(resBody), "\n")
+			assert.Equal(t, test.want.body, body, "Response body")
+		})
+	}
+}
+
+func TestAuth_PreLogin(t *testing.T) {
+	resp := dto.PreLoginResponse{
+		EncrSalt:    "c2FsdA",
+		KDF:         &dto.KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4},
+		AuthVersion: dto.AuthVersionKey,
+	}
+	respBody, err := json.Marshal(resp)
+	require.Nil(t, err, "prelogin response json encoding")
+
+	tests := []struct {
+		name  string
+		body  string
+		setup func(t *testing.T) AuthService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			body: `{"login":"testLogin"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					PreLogin(gomock.All(), dto.PreLoginRequest{Login: "testLogin"}).
+					Return(resp, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "negative_bad_json",
+			body: `not valid json`,
+			setup: func(t *testing.T) AuthService {
+				return mocks.NewMockAuthService(gomock.NewController(t))
+			},
+			want: handlerWant{code: http.StatusBadRequest, body: "invalid request format"},
+		},
+		{
+			name: "negative_server_error",
+			body: `{"login":"testLogin"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					PreLogin(gomock.All(), dto.PreLoginRequest{Login: "testLogin"}).
+					Return(dto.PreLoginResponse{}, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewAuth(test.setup(t), logger, 100)
+
+			r := httptest.NewRequest(http.MethodPost, "/prelogin", bytes.NewBufferString(test.body))
+			w := httptest.NewRecorder()
+			handler.PreLogin(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestAuth_Refresh(t *testing.T) {
+	token := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" +
+		".eyJleHAiOjE3NTg0NTk0OTMsImp0aSI6IjEifQ._mX-s6U9_iq4YhnQ5HOYbJAz7P8ly8BD_BufPYx2Kms"
+	successBody := `{"token":"` + token + `","refresh_token":"new_refresh"}`
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		setup func(t *testing.T) AuthService
+		want  want
+	}{
+		{
+			name: "success",
+			body: `{"refresh_token":"refresh"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Refresh(gomock.All(), "refresh").
+					Return(dto.TokenResponse{Token: token, RefreshToken: "new_refresh"}, nil)
+				return service
+			},
+			want: want{
+				code: http.StatusOK,
+				body: successBody,
+			},
+		},
+		{
+			name: "negative_bad_json",
+			body: `not valid jsson`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockAuthService(ctrl)
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				body: "invalid request format",
+			},
+		},
+		{
+			name: "negative_invalid_token",
+			body: `{"refresh_token":"refresh"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Refresh(gomock.All(), "refresh").
+					Return(dto.TokenResponse{}, errors.ErrAuthInvalidToken)
+				return service
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				body: "invalid refresh token",
+			},
+		},
+		{
+			name: "negative_server_error",
+			body: `{"refresh_token":"refresh"}`,
+			setup: func(t *testing.T) AuthService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockAuthService(ctrl)
+				service.EXPECT().
+					Refresh(gomock.All(), "refresh").
+					Return(dto.TokenResponse{}, errors.ErrUnexpected)
+				return service
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+	bodyMaxSize := int64(100)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := test.setup(t)
+			handler := NewAuth(service, logger, bodyMaxSize)
+
+			reqBody := []byte(test.body)
+			r := httptest.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBuffer(reqBody))
+			r.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			handler.Refresh(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
 			assert.Equal(t, test.want.code, res.StatusCode, "Response status code")
 
 			resBody, err := io.ReadAll(res.Body)
@@ -3,7 +3,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
 )
 
 const statusText500 = "oops, something went wrong"
@@ -37,3 +42,34 @@ func (jw *jsonWriter) write(value any, valueName string, stasusCode int) {
 		jw.logger.Error("failed to write body", err)
 	}
 }
+
+// decodeJSON читает из тела запроса не больше maxSize байт и декодирует JSON в value.
+// При ошибке отдает 413, если тело больше maxSize, иначе 400, и возвращает false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, maxSize int64, value any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSize)).Decode(value)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+	} else {
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+	}
+	return false
+}
+
+// writeRetry отдает 429 с заголовком Retry-After в секундах и возвращает true,
+// если err содержит *srvErrors.RetryError.
+func writeRetry(w http.ResponseWriter, err error) bool {
+	var retry *srvErrors.RetryError
+	if !errors.As(err, &retry) {
+		return false
+	}
+
+	seconds := int(math.Ceil(retry.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(w, retry.Error(), http.StatusTooManyRequests)
+	return true
+}
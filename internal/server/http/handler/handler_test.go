@@ -23,7 +23,7 @@ func Test_jsonWriter_write(t *testing.T) {
 		body   string
 	}
 
-	validLSON := `{"token":"TokenString","encr_salt":"EncryptionSaltString"}`
+	validLSON := `{"token":"TokenString","refresh_token":"RefreshTokenString","encr_salt":"EncryptionSaltString"}`
 
 	tests := []struct {
 		name       string
@@ -42,8 +42,9 @@ func Test_jsonWriter_write(t *testing.T) {
 				return mocks.NewMockLogger(ctrl)
 			},
 			value: dto.AuthResponse{
-				Token:    "TokenString",
-				EncrSalt: "EncryptionSaltString",
+				Token:        "TokenString",
+				RefreshToken: "RefreshTokenString",
+				EncrSalt:     "EncryptionSaltString",
 			},
 			valueName:  "AuthResponse",
 			stasusCode: http.StatusOK,
@@ -83,8 +84,9 @@ func Test_jsonWriter_write(t *testing.T) {
 				return logger
 			},
 			value: dto.AuthResponse{
-				Token:    "TokenString",
-				EncrSalt: "EncryptionSaltString",
+				Token:        "TokenString",
+				RefreshToken: "RefreshTokenString",
+				EncrSalt:     "EncryptionSaltString",
 			},
 			valueName:  "AuthResponse",
 			stasusCode: http.StatusOK,
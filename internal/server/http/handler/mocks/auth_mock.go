@@ -50,6 +50,36 @@ func (mr *MockAuthServiceMockRecorder) Login(ctx, c interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), ctx, c)
 }
 
+// PreLogin mocks base method.
+func (m *MockAuthService) PreLogin(ctx context.Context, req dto.PreLoginRequest) (dto.PreLoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreLogin", ctx, req)
+	ret0, _ := ret[0].(dto.PreLoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreLogin indicates an expected call of PreLogin.
+func (mr *MockAuthServiceMockRecorder) PreLogin(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreLogin", reflect.TypeOf((*MockAuthService)(nil).PreLogin), ctx, req)
+}
+
+// Refresh mocks base method.
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string) (dto.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken)
+	ret0, _ := ret[0].(dto.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockAuthServiceMockRecorder) Refresh(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthService)(nil).Refresh), ctx, refreshToken)
+}
+
 // Register mocks base method.
 func (m *MockAuthService) Register(ctx context.Context, c dto.Credentials) (dto.AuthResponse, error) {
 	m.ctrl.T.Helper()
@@ -35,6 +35,50 @@ func (m *MockSecretService) EXPECT() *MockSecretServiceMockRecorder {
 	return m.recorder
 }
 
+// Changes mocks base method.
+func (m *MockSecretService) Changes(ctx context.Context, since uint64) (dto.SecretChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changes", ctx, since)
+	ret0, _ := ret[0].(dto.SecretChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Changes indicates an expected call of Changes.
+func (mr *MockSecretServiceMockRecorder) Changes(ctx, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changes", reflect.TypeOf((*MockSecretService)(nil).Changes), ctx, since)
+}
+
+// Chunk mocks base method.
+func (m *MockSecretService) Chunk(ctx context.Context, secretID uint64, n uint32) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Chunk", ctx, secretID, n)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Chunk indicates an expected call of Chunk.
+func (mr *MockSecretServiceMockRecorder) Chunk(ctx, secretID, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Chunk", reflect.TypeOf((*MockSecretService)(nil).Chunk), ctx, secretID, n)
+}
+
+// Delete mocks base method.
+func (m *MockSecretService) Delete(ctx context.Context, secretID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, secretID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSecretServiceMockRecorder) Delete(ctx, secretID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSecretService)(nil).Delete), ctx, secretID)
+}
+
 // InfoList mocks base method.
 func (m *MockSecretService) InfoList(ctx context.Context) ([]dto.SecretInfo, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +94,21 @@ func (mr *MockSecretServiceMockRecorder) InfoList(ctx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InfoList", reflect.TypeOf((*MockSecretService)(nil).InfoList), ctx)
 }
 
+// Keys mocks base method.
+func (m *MockSecretService) Keys(ctx context.Context) ([]dto.SecretKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", ctx)
+	ret0, _ := ret[0].([]dto.SecretKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockSecretServiceMockRecorder) Keys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockSecretService)(nil).Keys), ctx)
+}
+
 // Save mocks base method.
 func (m *MockSecretService) Save(ctx context.Context, secret *dto.SecretRequest) error {
 	m.ctrl.T.Helper()
@@ -78,3 +137,32 @@ func (mr *MockSecretServiceMockRecorder) Secret(ctx, secretID interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Secret", reflect.TypeOf((*MockSecretService)(nil).Secret), ctx, secretID)
 }
+
+// Update mocks base method.
+func (m *MockSecretService) Update(ctx context.Context, secretID uint64, secret *dto.SecretUpdateRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, secretID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSecretServiceMockRecorder) Update(ctx, secretID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSecretService)(nil).Update), ctx, secretID, secret)
+}
+
+// Usage mocks base method.
+func (m *MockSecretService) Usage(ctx context.Context) (dto.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx)
+	ret0, _ := ret[0].(dto.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockSecretServiceMockRecorder) Usage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockSecretService)(nil).Usage), ctx)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type PasswordService interface {
+	// PasswordParams возвращает параметры мастер ключа текущего пользователя.
+	PasswordParams(ctx context.Context) (dto.PreLoginResponse, error)
+	// ChangePassword меняет пароль текущего пользователя и ключи данных его секретов.
+	ChangePassword(ctx context.Context, req *dto.PasswordChangeRequest) error
+}
+
+// Password обработчик запроса смены пароля
+type Password struct {
+	service     PasswordService
+	logger      Logger
+	bodyMaxSize int64
+}
+
+// NewPassword создает обработчик смены пароля, запрос содержит ключи всех секретов пользователя,
+// поэтому bodyMaxSize должен быть больше, чем для остальных запросов аутентификации.
+func NewPassword(srv PasswordService, l Logger, bodyMaxSize int64) *Password {
+	return &Password{service: srv, logger: l, bodyMaxSize: bodyMaxSize}
+}
+
+// Params отдает соль, параметры Argon2id мастер ключа и версию схемы аутентификации
+// текущего пользователя, по ним клиент вычисляет текущий ключ аутентификации.
+func (h *Password) Params(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.PasswordParams(r.Context())
+	if err != nil {
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(resp, "password params response", http.StatusOK)
+}
+
+// Change меняет пароль пользователя.
+// Если текущий ключ аутентификации или пароль не подходит, отдает 403, если ключи не совпадают с секретами пользователя, отдает 409.
+func (h *Password) Change(w http.ResponseWriter, r *http.Request) {
+	var req dto.PasswordChangeRequest
+
+	if !decodeJSON(w, r, h.bodyMaxSize, &req) {
+		return
+	}
+
+	err := h.service.ChangePassword(r.Context(), &req)
+	if err != nil {
+		if writeRetry(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, srvErrors.ErrAuthInvalidCredentials):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, srvErrors.ErrPasswordInvalidRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, srvErrors.ErrPasswordKeysMismatch):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
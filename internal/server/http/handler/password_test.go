@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+func TestPassword_Change(t *testing.T) {
+	request := dto.PasswordChangeRequest{
+		AuthKey:    "b2xkS2V5",
+		NewAuthKey: "bmV3S2V5",
+		EncrSalt:   "c2FsdA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}},
+	}
+	reqBody, err := json.Marshal(request)
+	require.Nil(t, err, "password change request json encoding")
+
+	tests := []struct {
+		name    string
+		reqBody []byte
+		setup   func(t *testing.T) PasswordService
+		want    handlerWant
+	}{
+		{
+			name:    "success",
+			reqBody: reqBody,
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().ChangePassword(gomock.All(), &request).Return(nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusNoContent},
+		},
+		{
+			name:    "invalid_json",
+			reqBody: []byte("{"),
+			setup: func(t *testing.T) PasswordService {
+				return mocks.NewMockPasswordService(gomock.NewController(t))
+			},
+			want: handlerWant{code: http.StatusBadRequest, body: "invalid request format"},
+		},
+		{
+			name:    "too_large",
+			reqBody: bytes.Repeat([]byte(" "), 2048),
+			setup: func(t *testing.T) PasswordService {
+				return mocks.NewMockPasswordService(gomock.NewController(t))
+			},
+			want: handlerWant{code: http.StatusRequestEntityTooLarge, body: "request body too large"},
+		},
+		{
+			name:    "invalid_password",
+			reqBody: reqBody,
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().ChangePassword(gomock.All(), &request).Return(errors.ErrAuthInvalidCredentials)
+				return service
+			},
+			want: handlerWant{code: http.StatusForbidden, body: errors.ErrAuthInvalidCredentials.Error()},
+		},
+		{
+			name:    "invalid_request",
+			reqBody: reqBody,
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().
+					ChangePassword(gomock.All(), &request).
+					Return(fmt.Errorf("%w: invalid auth key length", errors.ErrPasswordInvalidRequest))
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: errors.ErrPasswordInvalidRequest.Error() + ": invalid auth key length",
+			},
+		},
+		{
+			name:    "keys_mismatch",
+			reqBody: reqBody,
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().ChangePassword(gomock.All(), &request).Return(errors.ErrPasswordKeysMismatch)
+				return service
+			},
+			want: handlerWant{code: http.StatusConflict, body: errors.ErrPasswordKeysMismatch.Error()},
+		},
+		{
+			name:    "server_error",
+			reqBody: reqBody,
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().ChangePassword(gomock.All(), &request).Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewPassword(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPost, "/password", bytes.NewReader(test.reqBody))
+			w := httptest.NewRecorder()
+			handler.Change(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestPassword_Params(t *testing.T) {
+	resp := dto.PreLoginResponse{
+		EncrSalt:    "c2FsdA",
+		KDF:         &dto.KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4},
+		AuthVersion: dto.AuthVersionKey,
+	}
+	respBody, err := json.Marshal(resp)
+	require.Nil(t, err, "password params response json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) PasswordService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().PasswordParams(gomock.All()).Return(resp, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) PasswordService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockPasswordService(ctrl)
+				service.EXPECT().PasswordParams(gomock.All()).Return(dto.PreLoginResponse{}, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewPassword(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodGet, "/password", nil)
+			w := httptest.NewRecorder()
+			handler.Params(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"crypto/rsa"
+	"net/http"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// PublicKey обработчик запроса публичного ключа сервера,
+// которым клиент шифрует тела запросов регистрации и логина.
+type PublicKey struct {
+	key    *rsa.PublicKey
+	logger Logger
+}
+
+// NewPublicKey создает обработчик, key может быть nil,
+// если шифрование тел запросов не поддерживается.
+func NewPublicKey(key *rsa.PublicKey, l Logger) *PublicKey {
+	return &PublicKey{key: key, logger: l}
+}
+
+// Get возвращает JSON с публичным ключом в формате pem или 404, если ключ не задан.
+func (p *PublicKey) Get(w http.ResponseWriter, r *http.Request) {
+	if p.key == nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	key, err := crypto.EncodePublicKey(p.key)
+	if err != nil {
+		p.logger.Error("failed to encode payload public key", err)
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, p.logger).write(dto.PublicKeyResponse{Key: string(key)}, "public key", http.StatusOK)
+}
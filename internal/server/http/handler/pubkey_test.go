@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+)
+
+func TestPublicKey_Get(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Generate key")
+	pemKey, err := crypto.EncodePublicKey(&privateKey.PublicKey)
+	require.Nil(t, err, "Encode public key")
+	respBody, err := json.Marshal(dto.PublicKeyResponse{Key: string(pemKey)})
+	require.Nil(t, err, "public key json encoding")
+
+	tests := []struct {
+		name string
+		key  *rsa.PublicKey
+		want handlerWant
+	}{
+		{
+			name: "success",
+			key:  &privateKey.PublicKey,
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "not_configured",
+			key:  nil,
+			want: handlerWant{code: http.StatusNotFound, body: ""},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewPublicKey(test.key, logger)
+
+			r := httptest.NewRequest(http.MethodGet, "/pubkey", nil)
+			w := httptest.NewRecorder()
+			handler.Get(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
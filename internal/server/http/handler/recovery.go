@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type RecoveryService interface {
+	// Setup проверяет текущие учетные данные и сохраняет ключи восстановления текущего пользователя.
+	Setup(ctx context.Context, req *dto.RecoverySetupRequest) error
+	// Get возвращает ключи восстановления текущего пользователя.
+	Get(ctx context.Context) (dto.RecoveryKey, error)
+	// Start возвращает ключи для восстановления доступа.
+	Start(ctx context.Context, req dto.RecoveryStartRequest) (dto.RecoveryStartResponse, error)
+	// Complete сбрасывает пароль по ключу восстановления и выполняет вход.
+	Complete(ctx context.Context, req dto.RecoveryRequest) (dto.AuthResponse, error)
+}
+
+// Recovery обработчик запросов восстановления доступа по ключу восстановления
+type Recovery struct {
+	service     RecoveryService
+	logger      Logger
+	bodyMaxSize int64
+}
+
+// NewRecovery создает обработчик восстановления доступа, запрос сброса пароля
+// содержит ключи всех секретов пользователя, как и запрос смены пароля.
+func NewRecovery(srv RecoveryService, l Logger, bodyMaxSize int64) *Recovery {
+	return &Recovery{service: srv, logger: l, bodyMaxSize: bodyMaxSize}
+}
+
+// Setup сохраняет ключи восстановления текущего пользователя.
+// Если текущий ключ аутентификации или пароль не подходит, отдает 403.
+func (h *Recovery) Setup(w http.ResponseWriter, r *http.Request) {
+	var req dto.RecoverySetupRequest
+
+	if !decodeJSON(w, r, h.bodyMaxSize, &req) {
+		return
+	}
+
+	err := h.service.Setup(r.Context(), &req)
+	if err != nil {
+		if writeRetry(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, srvErrors.ErrAuthInvalidCredentials):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, srvErrors.ErrRecoveryInvalidRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get отдает ключи восстановления текущего пользователя, если восстановление не настроено, отдает 404.
+func (h *Recovery) Get(w http.ResponseWriter, r *http.Request) {
+	key, err := h.service.Get(r.Context())
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrRecoveryNotSetUp) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(key, "recovery key", http.StatusOK)
+}
+
+// Start отдает зашифрованный мастер ключ и ключи данных секретов пользователя.
+// Если логин или ключ восстановления не подходят, отдает 403.
+func (h *Recovery) Start(w http.ResponseWriter, r *http.Request) {
+	var req dto.RecoveryStartRequest
+
+	if !decodeJSON(w, r, h.bodyMaxSize, &req) {
+		return
+	}
+
+	resp, err := h.service.Start(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrRecoveryInvalidKey) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(resp, "recovery start response", http.StatusOK)
+}
+
+// Complete сбрасывает пароль по ключу восстановления.
+// Если логин или ключ восстановления не подходят, отдает 403, если ключи не совпадают
+// с секретами пользователя, отдает 409, без кода двухфакторной аутентификации отдает 401,
+// как и при входе. В случае успеха, возвращает JSON, содержащий токены и новую соль.
+func (h *Recovery) Complete(w http.ResponseWriter, r *http.Request) {
+	var req dto.RecoveryRequest
+
+	if !decodeJSON(w, r, h.bodyMaxSize, &req) {
+		return
+	}
+
+	resp, err := h.service.Complete(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, srvErrors.ErrRecoveryInvalidKey):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, srvErrors.ErrAuthOTPRequired), errors.Is(err, srvErrors.ErrAuthInvalidOTP):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, srvErrors.ErrPasswordInvalidRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, srvErrors.ErrPasswordKeysMismatch):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(resp, "recovery response", http.StatusOK)
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+func TestRecovery_Setup(t *testing.T) {
+	req := &dto.RecoverySetupRequest{
+		AuthKey:  "a2V5",
+		Recovery: dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms", AuthKey: "YXV0aA"},
+	}
+	reqBody, err := json.Marshal(req)
+	require.Nil(t, err, "recovery setup request json encoding")
+
+	tests := []struct {
+		name    string
+		reqBody []byte
+		setup   func(t *testing.T) RecoveryService
+		want    handlerWant
+	}{
+		{
+			name:    "success",
+			reqBody: reqBody,
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Setup(gomock.Any(), req).Return(nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusNoContent},
+		},
+		{
+			name:    "invalid_json",
+			reqBody: []byte("{"),
+			setup: func(t *testing.T) RecoveryService {
+				return mocks.NewMockRecoveryService(gomock.NewController(t))
+			},
+			want: handlerWant{code: http.StatusBadRequest, body: "invalid request format"},
+		},
+		{
+			name:    "invalid_key",
+			reqBody: reqBody,
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Setup(gomock.Any(), req).Return(errors.ErrRecoveryInvalidRequest)
+				return service
+			},
+			want: handlerWant{code: http.StatusBadRequest, body: errors.ErrRecoveryInvalidRequest.Error()},
+		},
+		{
+			name:    "invalid_credentials",
+			reqBody: reqBody,
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Setup(gomock.Any(), req).Return(errors.ErrAuthInvalidCredentials)
+				return service
+			},
+			want: handlerWant{code: http.StatusForbidden, body: errors.ErrAuthInvalidCredentials.Error()},
+		},
+		{
+			name:    "server_error",
+			reqBody: reqBody,
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Setup(gomock.Any(), req).Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	logger := mocks.NewMockLogger(gomock.NewController(t))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewRecovery(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPut, "/recovery", bytes.NewReader(test.reqBody))
+			w := httptest.NewRecorder()
+			handler.Setup(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestRecovery_Get(t *testing.T) {
+	key := dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"}
+	respBody, err := json.Marshal(key)
+	require.Nil(t, err, "recovery key json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) RecoveryService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Get(gomock.Any()).Return(key, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "not_set_up",
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Get(gomock.Any()).Return(dto.RecoveryKey{}, errors.ErrRecoveryNotSetUp)
+				return service
+			},
+			want: handlerWant{code: http.StatusNotFound, body: errors.ErrRecoveryNotSetUp.Error()},
+		},
+	}
+
+	logger := mocks.NewMockLogger(gomock.NewController(t))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewRecovery(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodGet, "/recovery", nil)
+			w := httptest.NewRecorder()
+			handler.Get(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestRecovery_Start(t *testing.T) {
+	req := dto.RecoveryStartRequest{Login: "user", AuthKey: "YXV0aA"}
+	reqBody, err := json.Marshal(req)
+	require.Nil(t, err, "recovery start request json encoding")
+	resp := dto.RecoveryStartResponse{MasterKey: "bWs", Keys: []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}}}
+	respBody, err := json.Marshal(resp)
+	require.Nil(t, err, "recovery start response json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) RecoveryService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Start(gomock.Any(), req).Return(resp, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "invalid_key",
+			setup: func(t *testing.T) RecoveryService {
+				service := mocks.NewMockRecoveryService(gomock.NewController(t))
+				service.EXPECT().Start(gomock.Any(), req).Return(dto.RecoveryStartResponse{}, errors.ErrRecoveryInvalidKey)
+				return service
+			},
+			want: handlerWant{code: http.StatusForbidden, body: errors.ErrRecoveryInvalidKey.Error()},
+		},
+	}
+
+	logger := mocks.NewMockLogger(gomock.NewController(t))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewRecovery(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPost, "/recovery/start", bytes.NewReader(reqBody))
+			w := httptest.NewRecorder()
+			handler.Start(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestRecovery_Complete(t *testing.T) {
+	req := dto.RecoveryRequest{
+		Login:      "user",
+		AuthKey:    "YXV0aA",
+		NewAuthKey: "bmV3S2V5",
+		EncrSalt:   "c2FsdA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}},
+		Recovery:   &dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"},
+	}
+	reqBody, err := json.Marshal(req)
+	require.Nil(t, err, "recovery request json encoding")
+	resp := dto.AuthResponse{Token: "token", RefreshToken: "refresh", EncrSalt: "c2FsdA"}
+	respBody, err := json.Marshal(resp)
+	require.Nil(t, err, "auth response json encoding")
+
+	tests := []struct {
+		name string
+		err  error
+		want handlerWant
+	}{
+		{
+			name: "success",
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "invalid_key",
+			err:  errors.ErrRecoveryInvalidKey,
+			want: handlerWant{code: http.StatusForbidden, body: errors.ErrRecoveryInvalidKey.Error()},
+		},
+		{
+			name: "otp_required",
+			err:  errors.ErrAuthOTPRequired,
+			want: handlerWant{code: http.StatusUnauthorized, body: errors.ErrAuthOTPRequired.Error()},
+		},
+		{
+			name: "invalid_request",
+			err:  errors.ErrPasswordInvalidRequest,
+			want: handlerWant{code: http.StatusBadRequest, body: errors.ErrPasswordInvalidRequest.Error()},
+		},
+		{
+			name: "keys_mismatch",
+			err:  errors.ErrPasswordKeysMismatch,
+			want: handlerWant{code: http.StatusConflict, body: errors.ErrPasswordKeysMismatch.Error()},
+		},
+		{
+			name: "server_error",
+			err:  errors.ErrUnexpected,
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	logger := mocks.NewMockLogger(gomock.NewController(t))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := mocks.NewMockRecoveryService(gomock.NewController(t))
+			if test.err != nil {
+				service.EXPECT().Complete(gomock.Any(), req).Return(dto.AuthResponse{}, test.err)
+			} else {
+				service.EXPECT().Complete(gomock.Any(), req).Return(resp, nil)
+			}
+			handler := NewRecovery(service, logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPost, "/recovery/complete", bytes.NewReader(reqBody))
+			w := httptest.NewRecorder()
+			handler.Complete(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
@@ -3,7 +3,6 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -17,34 +16,51 @@ type SecretService interface {
 	Save(ctx context.Context, secret *dto.SecretRequest) error
 	// Secret возвращает секрет по secretID, если он принадлежит текущему пользователю.
 	Secret(ctx context.Context, secretID uint64) (dto.SecretResponse, error)
+	// Update изменяет секрет по secretID, если он принадлежит текущему пользователю.
+	Update(ctx context.Context, secretID uint64, secret *dto.SecretUpdateRequest) error
+	// Delete удаляет секрет по secretID, если он принадлежит текущему пользователю.
+	Delete(ctx context.Context, secretID uint64) error
+	// Chunk возвращает часть n данных секрета по secretID, если он принадлежит текущему пользователю.
+	Chunk(ctx context.Context, secretID uint64, n uint32) ([]byte, error)
 	// InfoList возвращает информаци о всех секретах пользователя.
 	InfoList(ctx context.Context) ([]dto.SecretInfo, error)
+	// Changes возвращает информацию о секретах пользователя, созданных, измененных
+	// или удаленных после ревизии since.
+	Changes(ctx context.Context, since uint64) (dto.SecretChanges, error)
+	// Usage возвращает использование хранилища текущим пользователем и его квоту.
+	Usage(ctx context.Context) (dto.Usage, error)
+	// Keys возвращает зашифрованные ключи данных всех секретов текущего пользователя.
+	Keys(ctx context.Context) ([]dto.SecretKey, error)
 }
 
 // Secret обработчик запросов загрузки и отдачи секретов пользователя
 type Secret struct {
-	service SecretService
-	logger  Logger
+	service     SecretService
+	logger      Logger
+	bodyMaxSize int64
 }
 
-func NewSecret(srv SecretService, l Logger) *Secret {
-	return &Secret{service: srv, logger: l}
+func NewSecret(srv SecretService, l Logger, bodyMaxSize int64) *Secret {
+	return &Secret{service: srv, logger: l, bodyMaxSize: bodyMaxSize}
 }
 
-// Upload загружает данные секрета
+// Upload загружает данные секрета.
+// Если тело запроса больше bodyMaxSize, отдает 413, если секрет не помещается в квоту, отдает 403.
 func (s *Secret) Upload(w http.ResponseWriter, r *http.Request) {
 	var secret dto.SecretRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
-		http.Error(w, "invalid request format", http.StatusBadRequest)
+	if !decodeJSON(w, r, s.bodyMaxSize, &secret) {
 		return
 	}
 
 	err := s.service.Save(r.Context(), &secret)
 	if err != nil {
-		if errors.Is(err, srvErrors.ErrSecretInvalidData) {
+		switch {
+		case errors.Is(err, srvErrors.ErrSecretInvalidData):
 			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
+		case errors.Is(err, srvErrors.ErrQuotaExceeded):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
 			http.Error(w, statusText500, http.StatusInternalServerError)
 		}
 		return
@@ -73,8 +89,98 @@ func (s *Secret) Get(w http.ResponseWriter, r *http.Request) {
 	newJSONwriter(w, s.logger).write(secret, "secret", http.StatusOK)
 }
 
-// InfoList возвращает информацию о всех секретах пользователя.
+// Chunk отдает часть данных секрета, загруженного по частям, id секрета и номер части берет из пути.
+func (s *Secret) Chunk(w http.ResponseWriter, r *http.Request) {
+	secretID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid secret id", http.StatusBadRequest)
+		return
+	}
+	n, err := strconv.ParseUint(r.PathValue("n"), 10, 32)
+	if err != nil || n >= dto.UploadMaxChunks {
+		http.Error(w, "invalid chunk number", http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.service.Chunk(r.Context(), secretID, uint32(n))
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrSecretNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(data); err != nil {
+		s.logger.Error("failed to write body", err)
+	}
+}
+
+// Update изменяет секрет по id, который берет из пути.
+// Если секрет был изменен другим клиентом, отдает 409,
+// если тело запроса больше bodyMaxSize - 413, если данные не помещаются в квоту - 403.
+func (s *Secret) Update(w http.ResponseWriter, r *http.Request) {
+	secretID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid secret id", http.StatusBadRequest)
+		return
+	}
+
+	var secret dto.SecretUpdateRequest
+	if !decodeJSON(w, r, s.bodyMaxSize, &secret) {
+		return
+	}
+
+	err = s.service.Update(r.Context(), secretID, &secret)
+	if err != nil {
+		switch {
+		case errors.Is(err, srvErrors.ErrSecretInvalidData):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, srvErrors.ErrSecretNotFound):
+			http.Error(w, "", http.StatusNotFound)
+		case errors.Is(err, srvErrors.ErrSecretConflict):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, srvErrors.ErrQuotaExceeded):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// Delete удаляет секрет по id, который берет из пути.
+func (s *Secret) Delete(w http.ResponseWriter, r *http.Request) {
+	secretID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid secret id", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.Delete(r.Context(), secretID)
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrSecretNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List возвращает информацию о всех секретах пользователя.
+// Если передан параметр since, возвращает ленту изменений после этой ревизии.
 func (s *Secret) List(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("since") {
+		s.changes(w, r)
+		return
+	}
+
 	list, err := s.service.InfoList(r.Context())
 	if err != nil {
 		http.Error(w, statusText500, http.StatusInternalServerError)
@@ -83,3 +189,42 @@ func (s *Secret) List(w http.ResponseWriter, r *http.Request) {
 
 	newJSONwriter(w, s.logger).write(list, "secret info list", http.StatusOK)
 }
+
+// Keys возвращает JSON с зашифрованными ключами данных всех секретов пользователя.
+func (s *Secret) Keys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.service.Keys(r.Context())
+	if err != nil {
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, s.logger).write(keys, "secret keys", http.StatusOK)
+}
+
+// changes возвращает ленту изменений секретов пользователя после ревизии из параметра since.
+func (s *Secret) changes(w http.ResponseWriter, r *http.Request) {
+	since, err := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := s.service.Changes(r.Context(), since)
+	if err != nil {
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, s.logger).write(changes, "secret changes", http.StatusOK)
+}
+
+// Usage возвращает JSON с использованием хранилища пользователем и его квотой.
+func (s *Secret) Usage(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.service.Usage(r.Context())
+	if err != nil {
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, s.logger).write(usage, "usage", http.StatusOK)
+}
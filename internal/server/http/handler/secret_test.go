@@ -3,6 +3,7 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -18,6 +19,8 @@ import (
 	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
 )
 
+const testBodyMaxSize = 1024
+
 func TestAuth_Upload(t *testing.T) {
 	secret := dto.SecretRequest{}
 	reqBody, err := json.Marshal(secret)
@@ -69,12 +72,40 @@ func TestAuth_Upload(t *testing.T) {
 				service := mocks.NewMockSecretService(ctrl)
 				service.EXPECT().
 					Save(gomock.All(), &secret).
-					Return(errors.ErrSecretInvalidData)
+					Return(fmt.Errorf("%w: name can not be empty", errors.ErrSecretInvalidData))
 				return service
 			},
 			want: want{
 				code: http.StatusBadRequest,
-				body: "invalid secret data",
+				body: "invalid secret data: name can not be empty",
+			},
+		},
+		{
+			name: "body_too_large",
+			body: []byte(`{"name":"` + strings.Repeat("a", testBodyMaxSize) + `"}`),
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				code: http.StatusRequestEntityTooLarge,
+				body: "request body too large",
+			},
+		},
+		{
+			name: "quota_exceeded",
+			body: reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Save(gomock.All(), &secret).
+					Return(errors.ErrQuotaExceeded)
+				return service
+			},
+			want: want{
+				code: http.StatusForbidden,
+				body: errors.ErrQuotaExceeded.Error(),
 			},
 		},
 		{
@@ -101,7 +132,7 @@ func TestAuth_Upload(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			service := test.setup(t)
-			handler := NewSecret(service, logger)
+			handler := NewSecret(service, logger, testBodyMaxSize)
 
 			r := httptest.NewRequest(http.MethodPost, "/secret", bytes.NewBuffer(test.body))
 			r.Header.Set("Content-Type", "application/json")
@@ -206,7 +237,7 @@ func TestAuth_Get(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			service := test.setup(t)
-			handler := NewSecret(service, logger)
+			handler := NewSecret(service, logger, testBodyMaxSize)
 
 			r := httptest.NewRequest(http.MethodGet, "/secret/"+test.secretID, nil)
 			r.SetPathValue("id", test.secretID)
@@ -228,6 +259,293 @@ func TestAuth_Get(t *testing.T) {
 	}
 }
 
+func TestSecret_Update(t *testing.T) {
+	secret := dto.SecretUpdateRequest{}
+	reqBody, err := json.Marshal(secret)
+	require.Nil(t, err, "Secret json encoding")
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := []struct {
+		name     string
+		secretID string
+		body     []byte
+		setup    func(t *testing.T) SecretService
+		want     want
+	}{
+		{
+			name:     "succes",
+			secretID: "13",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Update(gomock.All(), uint64(13), &secret).
+					Return(nil)
+				return service
+			},
+			want: want{
+				code: http.StatusOK,
+			},
+		},
+		{
+			name:     "bad_secret_id",
+			secretID: "bad_id",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				body: "invalid secret id",
+			},
+		},
+		{
+			name:     "ivalid_request_format",
+			secretID: "13",
+			body:     []byte("invalid json"),
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				body: "invalid request format",
+			},
+		},
+		{
+			name:     "ivalid_secret_data",
+			secretID: "13",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Update(gomock.All(), uint64(13), &secret).
+					Return(errors.ErrSecretInvalidData)
+				return service
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				body: "invalid secret data",
+			},
+		},
+		{
+			name:     "secret_not_found",
+			secretID: "13",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Update(gomock.All(), uint64(13), &secret).
+					Return(errors.ErrSecretNotFound)
+				return service
+			},
+			want: want{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "conflict",
+			secretID: "13",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Update(gomock.All(), uint64(13), &secret).
+					Return(errors.ErrSecretConflict)
+				return service
+			},
+			want: want{
+				code: http.StatusConflict,
+				body: "secret was modified by another client",
+			},
+		},
+		{
+			name:     "body_too_large",
+			secretID: "13",
+			body:     []byte(`{"name":"` + strings.Repeat("a", testBodyMaxSize) + `"}`),
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				code: http.StatusRequestEntityTooLarge,
+				body: "request body too large",
+			},
+		},
+		{
+			name:     "quota_exceeded",
+			secretID: "13",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Update(gomock.All(), uint64(13), &secret).
+					Return(errors.ErrQuotaExceeded)
+				return service
+			},
+			want: want{
+				code: http.StatusForbidden,
+				body: "storage quota exceeded",
+			},
+		},
+		{
+			name:     "server_error",
+			secretID: "13",
+			body:     reqBody,
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Update(gomock.All(), uint64(13), &secret).
+					Return(errors.ErrUnexpected)
+				return service
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := test.setup(t)
+			handler := NewSecret(service, logger, testBodyMaxSize)
+
+			r := httptest.NewRequest(http.MethodPut, "/secret/"+test.secretID, bytes.NewBuffer(test.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.SetPathValue("id", test.secretID)
+
+			w := httptest.NewRecorder()
+			handler.Update(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, test.want.code, res.StatusCode, "Response status code")
+
+			resBody, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := strings.TrimSuffix(string(resBody), "\n")
+			assert.Equal(t, test.want.body, body, "Response body")
+		})
+	}
+}
+
+func TestSecret_Delete(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := []struct {
+		name     string
+		secretID string
+		setup    func(t *testing.T) SecretService
+		want     want
+	}{
+		{
+			name:     "succes",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Delete(gomock.All(), uint64(13)).
+					Return(nil)
+				return service
+			},
+			want: want{
+				code: http.StatusNoContent,
+			},
+		},
+		{
+			name:     "bad_secret_id",
+			secretID: "bad_id",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				body: "invalid secret id",
+			},
+		},
+		{
+			name:     "secret_not_found",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Delete(gomock.All(), uint64(13)).
+					Return(errors.ErrSecretNotFound)
+				return service
+			},
+			want: want{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "server_error",
+			secretID: "13",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Delete(gomock.All(), uint64(13)).
+					Return(errors.ErrUnexpected)
+				return service
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := test.setup(t)
+			handler := NewSecret(service, logger, testBodyMaxSize)
+
+			r := httptest.NewRequest(http.MethodDelete, "/secret/"+test.secretID, nil)
+			r.SetPathValue("id", test.secretID)
+
+			w := httptest.NewRecorder()
+			handler.Delete(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, test.want.code, res.StatusCode, "Response status code")
+
+			resBody, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := strings.TrimSuffix(string(resBody), "\n")
+			assert.Equal(t, test.want.body, body, "Response body")
+		})
+	}
+}
+
 func TestAuth_List(t *testing.T) {
 	list := []dto.SecretInfo{{}}
 	respBody, err := json.Marshal(list)
@@ -281,7 +599,7 @@ func TestAuth_List(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			service := test.setup(t)
-			handler := NewSecret(service, logger)
+			handler := NewSecret(service, logger, testBodyMaxSize)
 
 			r := httptest.NewRequest(http.MethodGet, "/secret", nil)
 
@@ -301,3 +619,307 @@ func TestAuth_List(t *testing.T) {
 		})
 	}
 }
+
+func TestSecret_Changes(t *testing.T) {
+	changes := dto.SecretChanges{Revision: 42, Changed: []dto.SecretInfo{{ID: 13, Revision: 42}}, Deleted: []uint64{7}}
+	respBody, err := json.Marshal(changes)
+	require.Nil(t, err, "secret changes json encoding")
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := []struct {
+		name  string
+		since string
+		setup func(t *testing.T) SecretService
+		want  want
+	}{
+		{
+			name:  "succes",
+			since: "40",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Changes(gomock.All(), uint64(40)).
+					Return(changes, nil)
+				return service
+			},
+			want: want{
+				code: http.StatusOK,
+				body: string(respBody),
+			},
+		},
+		{
+			name:  "bad_revision",
+			since: "yesterday",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				body: "invalid revision",
+			},
+		},
+		{
+			name:  "server_error",
+			since: "0",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Changes(gomock.All(), uint64(0)).
+					Return(dto.SecretChanges{}, errors.ErrUnexpected)
+				return service
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := test.setup(t)
+			handler := NewSecret(service, logger, testBodyMaxSize)
+
+			r := httptest.NewRequest(http.MethodGet, "/secret?since="+test.since, nil)
+
+			w := httptest.NewRecorder()
+			handler.List(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, test.want.code, res.StatusCode, "Response status code")
+
+			resBody, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := strings.TrimSuffix(string(resBody), "\n")
+			assert.Equal(t, test.want.body, body, "Response body")
+		})
+	}
+}
+
+func TestSecret_Chunk(t *testing.T) {
+	data := []byte("chunk data")
+
+	tests := []struct {
+		name     string
+		secretID string
+		n        string
+		setup    func(t *testing.T) SecretService
+		want     handlerWant
+	}{
+		{
+			name:     "succes",
+			secretID: "13",
+			n:        "2",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Chunk(gomock.All(), uint64(13), uint32(2)).
+					Return(data, nil)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusOK,
+				body: string(data),
+			},
+		},
+		{
+			name:     "bad_secret_id",
+			secretID: "bad_id",
+			n:        "2",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid secret id",
+			},
+		},
+		{
+			name:     "bad_chunk_number",
+			secretID: "13",
+			n:        "first",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid chunk number",
+			},
+		},
+		{
+			name:     "chunk_number_too_large",
+			secretID: "13",
+			n:        "1048576",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid chunk number",
+			},
+		},
+		{
+			name:     "not_found",
+			secretID: "13",
+			n:        "2",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Chunk(gomock.All(), gomock.All(), gomock.All()).
+					Return(nil, errors.ErrSecretNotFound)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "server_error",
+			secretID: "13",
+			n:        "2",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().
+					Chunk(gomock.All(), gomock.All(), gomock.All()).
+					Return(nil, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewSecret(test.setup(t), logger, testBodyMaxSize)
+
+			r := httptest.NewRequest(http.MethodGet, "/secret/"+test.secretID+"/chunk/"+test.n, nil)
+			r.SetPathValue("id", test.secretID)
+			r.SetPathValue("n", test.n)
+			w := httptest.NewRecorder()
+			handler.Chunk(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestSecret_Usage(t *testing.T) {
+	usage := dto.Usage{Bytes: 2048, Secrets: 3, MaxBytes: 4096, MaxSecrets: 10}
+	respBody, err := json.Marshal(usage)
+	require.Nil(t, err, "Usage json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SecretService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().Usage(gomock.All()).Return(usage, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().Usage(gomock.All()).Return(dto.Usage{}, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewSecret(test.setup(t), logger, testBodyMaxSize)
+
+			r := httptest.NewRequest(http.MethodGet, "/usage", nil)
+			w := httptest.NewRecorder()
+			handler.Usage(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestSecret_Keys(t *testing.T) {
+	keys := []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}, {ID: 3, Key: "a2V5Mw"}}
+	respBody, err := json.Marshal(keys)
+	require.Nil(t, err, "secret keys json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SecretService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().Keys(gomock.All()).Return(keys, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) SecretService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSecretService(ctrl)
+				service.EXPECT().Keys(gomock.All()).Return(nil, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewSecret(test.setup(t), logger, testBodyMaxSize)
+
+			r := httptest.NewRequest(http.MethodGet, "/secret/keys", nil)
+			w := httptest.NewRecorder()
+			handler.Keys(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
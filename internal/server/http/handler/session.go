@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type SessionService interface {
+	// List возвращает действующие сессии текущего пользователя.
+	List(ctx context.Context) ([]dto.Session, error)
+	// Revoke завершает сессию текущего пользователя по id.
+	Revoke(ctx context.Context, id string) error
+	// Logout завершает сессию, из которой выполнен запрос.
+	Logout(ctx context.Context) error
+}
+
+// Session обработчик запросов для работы с сессиями пользователя
+type Session struct {
+	service SessionService
+	logger  Logger
+}
+
+func NewSession(srv SessionService, l Logger) *Session {
+	return &Session{service: srv, logger: l}
+}
+
+// List возвращает JSON со списком действующих сессий пользователя.
+func (s *Session) List(w http.ResponseWriter, r *http.Request) {
+	list, err := s.service.List(r.Context())
+	if err != nil {
+		http.Error(w, statusText500, http.StatusInternalServerError)
+		return
+	}
+
+	newJSONwriter(w, s.logger).write(list, "session list", http.StatusOK)
+}
+
+// Revoke завершает сессию по id, который берет из пути.
+func (s *Session) Revoke(w http.ResponseWriter, r *http.Request) {
+	err := s.service.Revoke(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrSessionNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Logout завершает сессию, из которой выполнен запрос.
+func (s *Session) Logout(w http.ResponseWriter, r *http.Request) {
+	err := s.service.Logout(r.Context())
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrSessionNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
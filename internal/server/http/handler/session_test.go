@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+func TestSession_List(t *testing.T) {
+	seen := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	list := []dto.Session{{ID: "session-id", Device: "laptop", IP: "192.0.2.1", Created: seen, LastSeen: seen, Current: true}}
+	respBody, err := json.Marshal(list)
+	require.Nil(t, err, "session list json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SessionService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					List(gomock.All()).
+					Return(list, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					List(gomock.All()).
+					Return(nil, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewSession(test.setup(t), logger)
+
+			r := httptest.NewRequest(http.MethodGet, "/session", nil)
+			w := httptest.NewRecorder()
+			handler.List(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestSession_Revoke(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SessionService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					Revoke(gomock.All(), "session-id").
+					Return(nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusNoContent},
+		},
+		{
+			name: "not_found",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					Revoke(gomock.All(), "session-id").
+					Return(errors.ErrSessionNotFound)
+				return service
+			},
+			want: handlerWant{code: http.StatusNotFound},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					Revoke(gomock.All(), "session-id").
+					Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewSession(test.setup(t), logger)
+
+			r := httptest.NewRequest(http.MethodDelete, "/session/session-id", nil)
+			r.SetPathValue("id", "session-id")
+			w := httptest.NewRecorder()
+			handler.Revoke(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestSession_Logout(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) SessionService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					Logout(gomock.All()).
+					Return(nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusNoContent},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) SessionService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockSessionService(ctrl)
+				service.EXPECT().
+					Logout(gomock.All()).
+					Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewSession(test.setup(t), logger)
+
+			r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			w := httptest.NewRecorder()
+			handler.Logout(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type TwoFactorService interface {
+	// Setup генерирует новый TOTP секрет для текущего пользователя.
+	Setup(ctx context.Context) (dto.TOTPSetup, error)
+	// Enable включает двухфакторную аутентификацию и возвращает коды восстановления.
+	Enable(ctx context.Context, code string) (dto.RecoveryCodes, error)
+	// Disable выключает двухфакторную аутентификацию.
+	Disable(ctx context.Context, code string) error
+}
+
+// TwoFactor обработчик запросов настройки двухфакторной аутентификации
+type TwoFactor struct {
+	service     TwoFactorService
+	logger      Logger
+	bodyMaxSize int64
+}
+
+func NewTwoFactor(srv TwoFactorService, l Logger, bodyMaxSize int64) *TwoFactor {
+	return &TwoFactor{service: srv, logger: l, bodyMaxSize: bodyMaxSize}
+}
+
+// Setup возвращает JSON с новым TOTP секретом и otpauth URI для QR кода.
+func (h *TwoFactor) Setup(w http.ResponseWriter, r *http.Request) {
+	setup, err := h.service.Setup(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(setup, "totp setup", http.StatusOK)
+}
+
+// Enable включает двухфакторную аутентификацию по коду из приложения,
+// в случае успеха возвращает JSON с кодами восстановления.
+func (h *TwoFactor) Enable(w http.ResponseWriter, r *http.Request) {
+	code, ok := h.decodeCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.Enable(r.Context(), code)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	newJSONwriter(w, h.logger).write(codes, "recovery codes", http.StatusOK)
+}
+
+// Disable выключает двухфакторную аутентификацию по коду из приложения или коду восстановления.
+func (h *TwoFactor) Disable(w http.ResponseWriter, r *http.Request) {
+	code, ok := h.decodeCode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Disable(r.Context(), code); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TwoFactor) decodeCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req dto.TOTPCode
+
+	body := http.MaxBytesReader(w, r.Body, h.bodyMaxSize)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return "", false
+	}
+
+	return req.Code, true
+}
+
+func (h *TwoFactor) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, srvErrors.ErrAuthInvalidOTP):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, srvErrors.ErrTOTPAlreadyEnabled),
+		errors.Is(err, srvErrors.ErrTOTPNotSetUp),
+		errors.Is(err, srvErrors.ErrTOTPNotEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, statusText500, http.StatusInternalServerError)
+	}
+}
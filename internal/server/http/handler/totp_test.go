@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+func TestTwoFactor_Setup(t *testing.T) {
+	setup := dto.TOTPSetup{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/GophKeeper:alice?issuer=GophKeeper&secret=JBSWY3DPEHPK3PXP",
+	}
+	respBody, err := json.Marshal(setup)
+	require.Nil(t, err, "totp setup json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) TwoFactorService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Setup(gomock.All()).Return(setup, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "already_enabled",
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Setup(gomock.All()).Return(dto.TOTPSetup{}, errors.ErrTOTPAlreadyEnabled)
+				return service
+			},
+			want: handlerWant{code: http.StatusConflict, body: errors.ErrTOTPAlreadyEnabled.Error()},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Setup(gomock.All()).Return(dto.TOTPSetup{}, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewTwoFactor(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPost, "/2fa/setup", nil)
+			w := httptest.NewRecorder()
+			handler.Setup(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestTwoFactor_Enable(t *testing.T) {
+	codes := dto.RecoveryCodes{Codes: []string{"abcde-fghij", "klmno-pqrst"}}
+	respBody, err := json.Marshal(codes)
+	require.Nil(t, err, "recovery codes json encoding")
+
+	tests := []struct {
+		name  string
+		body  string
+		setup func(t *testing.T) TwoFactorService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			body: `{"code":"123456"}`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Enable(gomock.All(), "123456").Return(codes, nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusOK, body: string(respBody)},
+		},
+		{
+			name: "bad_json",
+			body: `not json`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockTwoFactorService(ctrl)
+			},
+			want: handlerWant{code: http.StatusBadRequest, body: "invalid request format"},
+		},
+		{
+			name: "invalid_code",
+			body: `{"code":"123456"}`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Enable(gomock.All(), "123456").Return(dto.RecoveryCodes{}, errors.ErrAuthInvalidOTP)
+				return service
+			},
+			want: handlerWant{code: http.StatusBadRequest, body: errors.ErrAuthInvalidOTP.Error()},
+		},
+		{
+			name: "not_set_up",
+			body: `{"code":"123456"}`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Enable(gomock.All(), "123456").Return(dto.RecoveryCodes{}, errors.ErrTOTPNotSetUp)
+				return service
+			},
+			want: handlerWant{code: http.StatusConflict, body: errors.ErrTOTPNotSetUp.Error()},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewTwoFactor(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPost, "/2fa/enable", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			handler.Enable(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestTwoFactor_Disable(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		setup func(t *testing.T) TwoFactorService
+		want  handlerWant
+	}{
+		{
+			name: "success",
+			body: `{"code":"abcde-fghij"}`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Disable(gomock.All(), "abcde-fghij").Return(nil)
+				return service
+			},
+			want: handlerWant{code: http.StatusNoContent},
+		},
+		{
+			name: "not_enabled",
+			body: `{"code":"123456"}`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Disable(gomock.All(), "123456").Return(errors.ErrTOTPNotEnabled)
+				return service
+			},
+			want: handlerWant{code: http.StatusConflict, body: errors.ErrTOTPNotEnabled.Error()},
+		},
+		{
+			name: "server_error",
+			body: `{"code":"123456"}`,
+			setup: func(t *testing.T) TwoFactorService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockTwoFactorService(ctrl)
+				service.EXPECT().Disable(gomock.All(), "123456").Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{code: http.StatusInternalServerError, body: statusText500},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewTwoFactor(test.setup(t), logger, 1024)
+
+			r := httptest.NewRequest(http.MethodPost, "/2fa/disable", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			handler.Disable(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
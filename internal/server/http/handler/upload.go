@@ -0,0 +1,146 @@
+// Пакет handler содержит обработчики http запросов
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+// ChunkMaxSize максимальный размер зашифрованной части данных в байтах
+const ChunkMaxSize = crypto.StreamChunkSize + crypto.StreamChunkOverhead
+
+// UploadBodyMaxSize максимальный размер тела запросов создания и завершения загрузки в байтах:
+// в них только название, метаданные и ключ секрета, данные передаются частями
+const UploadBodyMaxSize = 1 << 20
+
+type UploadService interface {
+	// Create создает сессию загрузки секрета по частям.
+	Create(ctx context.Context, upload *dto.UploadRequest) (dto.UploadResponse, error)
+	// PutChunk сохраняет часть n загрузки uploadID.
+	PutChunk(ctx context.Context, uploadID string, n uint32, data []byte) error
+	// Status возвращает состояние загрузки uploadID.
+	Status(ctx context.Context, uploadID string) (dto.UploadStatus, error)
+	// Commit завершает загрузку uploadID.
+	Commit(ctx context.Context, uploadID string, commit *dto.UploadCommitRequest) error
+}
+
+// Upload обработчик запросов загрузки секретов по частям
+type Upload struct {
+	service UploadService
+	logger  Logger
+}
+
+func NewUpload(srv UploadService, l Logger) *Upload {
+	return &Upload{service: srv, logger: l}
+}
+
+// Create создает сессию загрузки, возвращает JSON с ее ID.
+func (u *Upload) Create(w http.ResponseWriter, r *http.Request) {
+	var upload dto.UploadRequest
+
+	if !decodeJSON(w, r, UploadBodyMaxSize, &upload) {
+		return
+	}
+
+	resp, err := u.service.Create(r.Context(), &upload)
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrSecretInvalidData) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newJSONwriter(w, u.logger).write(resp, "upload response", http.StatusCreated)
+}
+
+// PutChunk сохраняет часть загрузки, id загрузки и номер части берет из пути,
+// номер части должен быть меньше dto.UploadMaxChunks.
+// Тело запроса - зашифрованные данные части размером не больше ChunkMaxSize.
+// Если части незавершенных загрузок не помещаются в квоту, отдает 403.
+func (u *Upload) PutChunk(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.ParseUint(r.PathValue("n"), 10, 32)
+	if err != nil || n >= dto.UploadMaxChunks {
+		http.Error(w, "invalid chunk number", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ChunkMaxSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "chunk too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "failed to read chunk", http.StatusBadRequest)
+		}
+		return
+	}
+
+	err = u.service.PutChunk(r.Context(), r.PathValue("id"), uint32(n), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, srvErrors.ErrUploadNotFound):
+			http.Error(w, "", http.StatusNotFound)
+		case errors.Is(err, srvErrors.ErrQuotaExceeded):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Status отдает JSON с состоянием загрузки, id загрузки берет из пути.
+// По нему клиент определяет, с какой части продолжить прерванную загрузку.
+func (u *Upload) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := u.service.Status(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, srvErrors.ErrUploadNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	newJSONwriter(w, u.logger).write(status, "upload status", http.StatusOK)
+}
+
+// Commit завершает загрузку, id загрузки берет из пути.
+// Если заменяемый секрет был изменен другим клиентом, отдает 409, если данные не помещаются в квоту - 403.
+func (u *Upload) Commit(w http.ResponseWriter, r *http.Request) {
+	var commit dto.UploadCommitRequest
+
+	if !decodeJSON(w, r, UploadBodyMaxSize, &commit) {
+		return
+	}
+
+	err := u.service.Commit(r.Context(), r.PathValue("id"), &commit)
+	if err != nil {
+		switch {
+		case errors.Is(err, srvErrors.ErrUploadNotFound):
+			http.Error(w, "", http.StatusNotFound)
+		case errors.Is(err, srvErrors.ErrUploadIncomplete), errors.Is(err, srvErrors.ErrSecretInvalidData):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, srvErrors.ErrSecretConflict):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, srvErrors.ErrQuotaExceeded):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, statusText500, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,520 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/http/handler/mocks"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type handlerWant struct {
+	code int
+	body string
+}
+
+// checkResponse сравнивает код и тело ответа с ожидаемыми.
+func checkResponse(t *testing.T, w *httptest.ResponseRecorder, want handlerWant) {
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, want.code, res.StatusCode, "Response status code")
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := strings.TrimSuffix(string(resBody), "\n")
+	assert.Equal(t, want.body, body, "Response body")
+}
+
+func TestUpload_Create(t *testing.T) {
+	request := dto.UploadRequest{DataType: dto.SecretTypeFile, Name: "name", Key: "key"}
+	reqBody, err := json.Marshal(request)
+	require.Nil(t, err, "upload request json encoding")
+	respBody, err := json.Marshal(dto.UploadResponse{ID: "upload-id"})
+	require.Nil(t, err, "upload response json encoding")
+
+	tests := []struct {
+		name    string
+		reqBody []byte
+		setup   func(t *testing.T) UploadService
+		want    handlerWant
+	}{
+		{
+			name:    "succes",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Create(gomock.All(), &request).
+					Return(dto.UploadResponse{ID: "upload-id"}, nil)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusCreated,
+				body: string(respBody),
+			},
+		},
+		{
+			name:    "bad_json",
+			reqBody: []byte("{"),
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid request format",
+			},
+		},
+		{
+			name:    "body_too_large",
+			reqBody: []byte(`{"name":"` + strings.Repeat("a", UploadBodyMaxSize) + `"}`),
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusRequestEntityTooLarge,
+				body: "request body too large",
+			},
+		},
+		{
+			name:    "invalid_data",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Create(gomock.All(), gomock.All()).
+					Return(dto.UploadResponse{}, errors.ErrSecretInvalidData)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: errors.ErrSecretInvalidData.Error(),
+			},
+		},
+		{
+			name:    "server_error",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Create(gomock.All(), gomock.All()).
+					Return(dto.UploadResponse{}, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewUpload(test.setup(t), logger)
+
+			r := httptest.NewRequest(http.MethodPost, "/secret/upload", bytes.NewReader(test.reqBody))
+			w := httptest.NewRecorder()
+			handler.Create(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestUpload_PutChunk(t *testing.T) {
+	data := []byte("chunk data")
+
+	tests := []struct {
+		name  string
+		n     string
+		body  []byte
+		setup func(t *testing.T) UploadService
+		want  handlerWant
+	}{
+		{
+			name: "succes",
+			n:    "2",
+			body: data,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					PutChunk(gomock.All(), "upload-id", uint32(2), data).
+					Return(nil)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusNoContent,
+			},
+		},
+		{
+			name: "bad_chunk_number",
+			n:    "-1",
+			body: data,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid chunk number",
+			},
+		},
+		{
+			name: "chunk_number_too_large",
+			n:    "4294967295",
+			body: data,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid chunk number",
+			},
+		},
+		{
+			name: "chunk_too_large",
+			n:    "2",
+			body: make([]byte, ChunkMaxSize+1),
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusRequestEntityTooLarge,
+				body: "chunk too large",
+			},
+		},
+		{
+			name: "upload_not_found",
+			n:    "2",
+			body: data,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					PutChunk(gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrUploadNotFound)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name: "quota_exceeded",
+			n:    "2",
+			body: data,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					PutChunk(gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrQuotaExceeded)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusForbidden,
+				body: errors.ErrQuotaExceeded.Error(),
+			},
+		},
+		{
+			name: "server_error",
+			n:    "2",
+			body: data,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					PutChunk(gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewUpload(test.setup(t), logger)
+
+			r := httptest.NewRequest(
+				http.MethodPut,
+				"/secret/upload/upload-id/chunk/"+test.n,
+				bytes.NewReader(test.body),
+			)
+			r.SetPathValue("id", "upload-id")
+			r.SetPathValue("n", test.n)
+			w := httptest.NewRecorder()
+			handler.PutChunk(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestUpload_Status(t *testing.T) {
+	respBody, err := json.Marshal(dto.UploadStatus{ID: "upload-id", Received: 5})
+	require.Nil(t, err, "upload status json encoding")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) UploadService
+		want  handlerWant
+	}{
+		{
+			name: "succes",
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Status(gomock.All(), "upload-id").
+					Return(dto.UploadStatus{ID: "upload-id", Received: 5}, nil)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusOK,
+				body: string(respBody),
+			},
+		},
+		{
+			name: "upload_not_found",
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Status(gomock.All(), gomock.All()).
+					Return(dto.UploadStatus{}, errors.ErrUploadNotFound)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name: "server_error",
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Status(gomock.All(), gomock.All()).
+					Return(dto.UploadStatus{}, errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewUpload(test.setup(t), logger)
+
+			r := httptest.NewRequest(http.MethodGet, "/secret/upload/upload-id", nil)
+			r.SetPathValue("id", "upload-id")
+			w := httptest.NewRecorder()
+			handler.Status(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
+
+func TestUpload_Commit(t *testing.T) {
+	reqBody, err := json.Marshal(dto.UploadCommitRequest{Chunks: 3})
+	require.Nil(t, err, "commit request json encoding")
+
+	tests := []struct {
+		name    string
+		reqBody []byte
+		setup   func(t *testing.T) UploadService
+		want    handlerWant
+	}{
+		{
+			name:    "succes",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), "upload-id", &dto.UploadCommitRequest{Chunks: 3}).
+					Return(nil)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusNoContent,
+			},
+		},
+		{
+			name:    "bad_json",
+			reqBody: []byte("{"),
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: "invalid request format",
+			},
+		},
+		{
+			name:    "body_too_large",
+			reqBody: []byte(`{"name":"` + strings.Repeat("a", UploadBodyMaxSize) + `"}`),
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadService(ctrl)
+			},
+			want: handlerWant{
+				code: http.StatusRequestEntityTooLarge,
+				body: "request body too large",
+			},
+		},
+		{
+			name:    "upload_not_found",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrUploadNotFound)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name:    "incomplete",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrUploadIncomplete)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: errors.ErrUploadIncomplete.Error(),
+			},
+		},
+		{
+			name:    "conflict",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrSecretConflict)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusConflict,
+				body: errors.ErrSecretConflict.Error(),
+			},
+		},
+		{
+			name:    "uid_mismatch",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrSecretInvalidData)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusBadRequest,
+				body: errors.ErrSecretInvalidData.Error(),
+			},
+		},
+		{
+			name:    "quota_exceeded",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrQuotaExceeded)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusForbidden,
+				body: errors.ErrQuotaExceeded.Error(),
+			},
+		},
+		{
+			name:    "server_error",
+			reqBody: reqBody,
+			setup: func(t *testing.T) UploadService {
+				ctrl := gomock.NewController(t)
+				service := mocks.NewMockUploadService(ctrl)
+				service.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All()).
+					Return(errors.ErrUnexpected)
+				return service
+			},
+			want: handlerWant{
+				code: http.StatusInternalServerError,
+				body: statusText500,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	logger := mocks.NewMockLogger(ctrl)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewUpload(test.setup(t), logger)
+
+			r := httptest.NewRequest(
+				http.MethodPost,
+				"/secret/upload/upload-id/commit",
+				bytes.NewReader(test.reqBody),
+			)
+			r.SetPathValue("id", "upload-id")
+			w := httptest.NewRecorder()
+			handler.Commit(w, r)
+
+			checkResponse(t, w, test.want)
+		})
+	}
+}
@@ -12,8 +12,8 @@ import (
 )
 
 type AuthService interface {
-	// User отдает пользователя по токену
-	User(ctx context.Context, token string) (entity.User, error)
+	// Session отдает действующую сессию пользователя по токену
+	Session(ctx context.Context, token string) (entity.Session, error)
 }
 
 type Authorizer struct {
@@ -33,7 +33,7 @@ func (a *Authorizer) Authorize(next http.Handler) http.Handler {
 		}
 
 		token := strings.TrimPrefix(header, "Bearer ")
-		user, err := a.service.User(r.Context(), token)
+		session, err := a.service.Session(r.Context(), token)
 		if err != nil {
 			if errors.Is(err, srvErrors.ErrAuthTokenExpired) {
 				http.Error(w, "token expired", http.StatusUnauthorized)
@@ -43,7 +43,8 @@ func (a *Authorizer) Authorize(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := srvContext.SetUserID(r.Context(), user.ID)
+		ctx := srvContext.SetUserID(r.Context(), session.UserID)
+		ctx = srvContext.SetSessionID(ctx, session.ID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 
@@ -22,9 +22,10 @@ func TestAuthorizer_Authorize(t *testing.T) {
 	authHeader := "Bearer " + token
 
 	type want struct {
-		code   int
-		body   string
-		userID string
+		code      int
+		body      string
+		userID    string
+		sessionID string
 	}
 
 	tests := []struct {
@@ -40,14 +41,15 @@ func TestAuthorizer_Authorize(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				service := mocks.NewMockAuthService(ctrl)
 				service.EXPECT().
-					User(gomock.All(), token).
-					Return(entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil)
+					Session(gomock.All(), token).
+					Return(entity.Session{ID: "5b1c3f0e-7d0a-4c7e-9a43-3f1d2b8e6a10", UserID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil)
 				return service
 			},
 			want: want{
-				code:   http.StatusOK,
-				body:   "",
-				userID: "d7d81ca8-8b0b-496e-abbd-fd522245c975",
+				code:      http.StatusOK,
+				body:      "",
+				userID:    "d7d81ca8-8b0b-496e-abbd-fd522245c975",
+				sessionID: "5b1c3f0e-7d0a-4c7e-9a43-3f1d2b8e6a10",
 			},
 		},
 		{
@@ -69,8 +71,8 @@ func TestAuthorizer_Authorize(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				service := mocks.NewMockAuthService(ctrl)
 				service.EXPECT().
-					User(gomock.All(), token).
-					Return(entity.User{}, errors.ErrAuthTokenExpired)
+					Session(gomock.All(), token).
+					Return(entity.Session{}, errors.ErrAuthTokenExpired)
 				return service
 			},
 			want: want{
@@ -85,8 +87,8 @@ func TestAuthorizer_Authorize(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				service := mocks.NewMockAuthService(ctrl)
 				service.EXPECT().
-					User(gomock.All(), token).
-					Return(entity.User{}, errors.ErrAuthInvalidToken)
+					Session(gomock.All(), token).
+					Return(entity.Session{}, errors.ErrAuthInvalidToken)
 				return service
 			},
 			want: want{
@@ -107,6 +109,9 @@ func TestAuthorizer_Authorize(t *testing.T) {
 				userID, err := srvContext.UserID(r.Context())
 				assert.Nil(t, err, "Get user ID from context")
 				assert.Equal(t, test.want.userID, userID, "Handler get userID")
+				sessionID, err := srvContext.SessionID(r.Context())
+				assert.Nil(t, err, "Get session ID from context")
+				assert.Equal(t, test.want.sessionID, sessionID, "Handler get sessionID")
 				w.WriteHeader(http.StatusOK)
 			})
 
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+)
+
+// ClientIP сохраняет в контексте запроса IP клиента для ограничения частоты запросов
+// и списка сессий.
+type ClientIP struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIP создает middleware определения IP клиента, trusted - сети доверенных прокси.
+// Если адрес соединения не из доверенной сети, используется он: заголовкам вроде
+// X-Forwarded-For доверять нельзя, так как клиент может их подделать.
+func NewClientIP(trusted []netip.Prefix) *ClientIP {
+	return &ClientIP{trusted: trusted}
+}
+
+// Resolve определяет IP клиента. Для запросов от доверенных прокси X-Forwarded-For
+// просматривается справа налево и берется первый адрес не из доверенной сети,
+// адреса левее него мог подставить клиент.
+func (c *ClientIP) Resolve(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := srvContext.SetClientIP(r.Context(), c.clientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func (c *ClientIP) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || !c.isTrusted(addr) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// дальше цепочка недостоверна, клиентом считается последний проверенный адрес
+			break
+		}
+		addr = hop.Unmap()
+		if !c.isTrusted(addr) {
+			break
+		}
+	}
+
+	return addr.String()
+}
+
+func (c *ClientIP) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+)
+
+func TestClientIP_Resolve(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8:ffff::/48"),
+	}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "ipv4", remoteAddr: "192.0.2.1:54321", want: "192.0.2.1"},
+		{name: "ipv6", remoteAddr: "[2001:db8::1]:54321", want: "2001:db8::1"},
+		{name: "without_port", remoteAddr: "192.0.2.1", want: "192.0.2.1"},
+		{
+			name:       "no_trusted_proxies",
+			remoteAddr: "10.0.0.1:54321",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "untrusted_remote",
+			trusted:    trusted,
+			remoteAddr: "192.0.2.1:54321",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "trusted_proxy",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:54321",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed_hops",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:54321",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7, 10.0.0.2"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "several_headers",
+			trusted:    trusted,
+			remoteAddr: "[2001:db8:ffff::1]:54321",
+			forwarded:  []string{"198.51.100.1", "203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "invalid_hop",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:54321",
+			forwarded:  []string{"203.0.113.7, garbage, 10.0.0.2"},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "all_hops_trusted",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:54321",
+			forwarded:  []string{"10.0.0.3, 10.0.0.2"},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "without_header",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:54321",
+			want:       "10.0.0.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, v := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = srvContext.ClientIP(r.Context())
+			})
+
+			NewClientIP(test.trusted).Resolve(next).ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, test.want, got, "Client IP")
+		})
+	}
+}
@@ -35,17 +35,17 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 	return m.recorder
 }
 
-// User mocks base method.
-func (m *MockAuthService) User(ctx context.Context, token string) (entity.User, error) {
+// Session mocks base method.
+func (m *MockAuthService) Session(ctx context.Context, token string) (entity.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "User", ctx, token)
-	ret0, _ := ret[0].(entity.User)
+	ret := m.ctrl.Call(m, "Session", ctx, token)
+	ret0, _ := ret[0].(entity.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// User indicates an expected call of User.
-func (mr *MockAuthServiceMockRecorder) User(ctx, token interface{}) *gomock.Call {
+// Session indicates an expected call of Session.
+func (mr *MockAuthServiceMockRecorder) Session(ctx, token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockAuthService)(nil).User), ctx, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Session", reflect.TypeOf((*MockAuthService)(nil).Session), ctx, token)
 }
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+// payloadOverhead запас на заголовок конверта, nonce и тег AES-GCM сверх ключа RSA.
+const payloadOverhead = 64
+
+// PayloadDecryptor расшифровывает тела запросов, зашифрованные публичным ключом сервера.
+type PayloadDecryptor struct {
+	key     *rsa.PrivateKey
+	maxSize int64
+}
+
+// NewPayloadDecryptor создает middleware расшифровки, maxSize - максимальный размер
+// расшифрованного тела, key может быть nil, если шифрование тел не поддерживается.
+func NewPayloadDecryptor(key *rsa.PrivateKey, maxSize int64) *PayloadDecryptor {
+	return &PayloadDecryptor{key: key, maxSize: maxSize}
+}
+
+// Decrypt заменяет зашифрованное тело запроса расшифрованным JSON,
+// запросы с другим типом содержимого передаются дальше без изменений.
+func (p *PayloadDecryptor) Decrypt(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != dto.EncryptedContentType {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if p.key == nil {
+			http.Error(w, "encrypted payload not supported", http.StatusBadRequest)
+			return
+		}
+
+		limit := p.maxSize + int64(p.key.Size()) + payloadOverhead
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			} else {
+				http.Error(w, "invalid request format", http.StatusBadRequest)
+			}
+			return
+		}
+
+		// Старый формат с PKCS#1 v1.5 не принимается, чтобы не давать оракул дополнения
+		body, err := crypto.DecryptWithPrivateKeyOAEP(data, p.key)
+		if err != nil {
+			http.Error(w, "invalid encrypted payload", http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
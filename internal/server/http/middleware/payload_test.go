@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+)
+
+func TestPayloadDecryptor_Decrypt(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Generate key")
+
+	payload := []byte(`{"login":"user","password":"password"}`)
+	encrypted, err := crypto.EncryptWithPublicKey(payload, &privateKey.PublicKey)
+	require.Nil(t, err, "Encrypt payload")
+
+	tampered := bytes.Clone(encrypted)
+	tampered[len(tampered)-1] ^= 1
+
+	large, err := crypto.EncryptWithPublicKey(bytes.Repeat([]byte("a"), 200), &privateKey.PublicKey)
+	require.Nil(t, err, "Encrypt large payload")
+
+	// конверт старого формата с ключом, зашифрованным RSA PKCS#1 v1.5
+	aesKey, err := crypto.GenerateRandomBytes(crypto.KeyLen)
+	require.Nil(t, err, "Generate AES key")
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, &privateKey.PublicKey, aesKey)
+	require.Nil(t, err, "Encrypt AES key")
+	block, err := aes.NewCipher(aesKey)
+	require.Nil(t, err, "Create AES cipher")
+	gcm, err := cipher.NewGCM(block)
+	require.Nil(t, err, "Create GCM")
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := binary.BigEndian.AppendUint32(nil, uint32(len(encryptedKey)))
+	legacy = gcm.Seal(append(append(legacy, encryptedKey...), nonce...), nonce, payload, nil)
+	legacyPlain, err := crypto.DecryptWithPrivateKey(legacy, privateKey)
+	require.Nil(t, err, "Decrypt legacy envelope")
+	require.Equal(t, payload, legacyPlain, "Legacy envelope payload")
+
+	type want struct {
+		code        int
+		body        []byte
+		contentType string
+	}
+
+	tests := []struct {
+		name        string
+		key         *rsa.PrivateKey
+		contentType string
+		body        []byte
+		want        want
+	}{
+		{
+			name:        "plain",
+			key:         privateKey,
+			contentType: "application/json",
+			body:        payload,
+			want:        want{code: http.StatusOK, body: payload, contentType: "application/json"},
+		},
+		{
+			name:        "encrypted",
+			key:         privateKey,
+			contentType: dto.EncryptedContentType,
+			body:        encrypted,
+			want:        want{code: http.StatusOK, body: payload, contentType: "application/json"},
+		},
+		{
+			name:        "tampered",
+			key:         privateKey,
+			contentType: dto.EncryptedContentType,
+			body:        tampered,
+			want:        want{code: http.StatusBadRequest},
+		},
+		{
+			name:        "legacy_pkcs1v15",
+			key:         privateKey,
+			contentType: dto.EncryptedContentType,
+			body:        legacy,
+			want:        want{code: http.StatusBadRequest},
+		},
+		{
+			name:        "too_large",
+			key:         privateKey,
+			contentType: dto.EncryptedContentType,
+			body:        large,
+			want:        want{code: http.StatusRequestEntityTooLarge},
+		},
+		{
+			name:        "not_supported",
+			key:         nil,
+			contentType: dto.EncryptedContentType,
+			body:        encrypted,
+			want:        want{code: http.StatusBadRequest},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+			r.Header.Set("Content-Type", test.contentType)
+			w := httptest.NewRecorder()
+
+			var (
+				called      bool
+				body        []byte
+				contentType string
+			)
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				body, _ = io.ReadAll(r.Body)
+				contentType = r.Header.Get("Content-Type")
+			})
+
+			NewPayloadDecryptor(test.key, 100).Decrypt(next).ServeHTTP(w, r)
+
+			assert.Equal(t, test.want.code, w.Code, "Response status code")
+			assert.Equal(t, test.want.code == http.StatusOK, called, "Next handler called")
+			if called {
+				assert.Equal(t, test.want.body, body, "Request body")
+				assert.Equal(t, test.want.contentType, contentType, "Request content type")
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+)
+
+// RateLimiter ограничивает частоту запросов по ключу алгоритмом token bucket:
+// у каждого ключа есть корзина на perMinute запросов, которая равномерно наполняется за минуту.
+// Состояние хранится в памяти процесса. Ключи выбирает клиент, поэтому количество корзин
+// ограничено. Вытесняются только наполнившиеся корзины, они не отличаются от новых, поэтому
+// вытеснение не сбрасывает ограничение ключа. Если наполнившихся корзин нет, новые ключи
+// делят одну общую корзину overflow. Наполнившиеся корзины также удаляет Run.
+type RateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	overflow *bucket
+	// время, раньше которого ни одна корзина не наполнится
+	nextEvict time.Time
+	// емкость корзины и скорость ее наполнения в запросах в секунду
+	burst   float64
+	rate    float64
+	maxKeys int
+	maxSize int64
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	// время последнего запроса
+	updated time.Time
+}
+
+// NewRateLimiter создает ограничитель на perMinute запросов в минуту с одного ключа,
+// хранящий не больше maxKeys корзин, maxSize - сколько байт тела читать для поиска логина.
+// При perMinute <= 0 возвращает nil, и middleware ограничителя пропускают все запросы.
+func NewRateLimiter(perMinute, maxKeys int, maxSize int64) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		buckets: make(map[string]*bucket),
+		// нулевое время последнего запроса - корзина полная
+		overflow: &bucket{tokens: float64(perMinute)},
+		burst:    float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		maxKeys:  max(maxKeys, 1),
+		maxSize:  maxSize,
+		now:      time.Now,
+	}
+}
+
+// Run удаляет наполнившиеся корзины с периодом interval до отмены ctx.
+// Для выключенного ограничителя (nil) сразу возвращается.
+func (l *RateLimiter) Run(ctx context.Context, interval time.Duration) {
+	if l == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.evictIdle()
+		}
+	}
+}
+
+// ByIP ограничивает частоту запросов с одного IP клиента,
+// IP берется из контекста, поэтому должен использоваться после ClientIP.
+func (l *RateLimiter) ByIP(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(w, "ip:"+srvContext.ClientIP(r.Context())) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// ByLogin ограничивает частоту запросов с одним логином из JSON тела запроса,
+// тело возвращается в запрос без изменений. Для зашифрованных тел должен
+// использоваться после расшифровки. Запросы без логина передаются дальше,
+// их отклонит обработчик.
+func (l *RateLimiter) ByLogin(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(io.LimitReader(r.Body, l.maxSize))
+		if err != nil {
+			http.Error(w, "invalid request format", http.StatusBadRequest)
+			return
+		}
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+
+		var body struct {
+			Login string `json:"login"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			login := strings.TrimSpace(body.Login)
+			if login != "" && !l.allow(w, "login:"+login) {
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// allow забирает запрос из корзины ключа. Если корзина пуста,
+// отвечает 429 с заголовком Retry-After и возвращает false.
+func (l *RateLimiter) allow(w http.ResponseWriter, key string) bool {
+	wait := l.take(key)
+	if wait == 0 {
+		return true
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+	return false
+}
+
+// take забирает запрос из корзины ключа и возвращает 0 или время до появления запроса в корзине.
+func (l *RateLimiter) take(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.maxKeys && !now.Before(l.nextEvict) {
+			l.evictFull(now)
+		}
+		if len(l.buckets) < l.maxKeys {
+			b = &bucket{tokens: l.burst, updated: now}
+			l.buckets[key] = b
+		} else {
+			b = l.overflow
+		}
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// evictIdle удаляет корзины ключей, которые успели наполниться без запросов.
+func (l *RateLimiter) evictIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evictFull(l.now())
+}
+
+// evictFull удаляет наполнившиеся к now корзины: они не отличаются от новых.
+// Запоминает, когда наполнится следующая корзина, чтобы при потоке новых ключей
+// не обходить все корзины на каждый запрос.
+func (l *RateLimiter) evictFull(now time.Time) {
+	var next time.Time
+	for key, b := range l.buckets {
+		full := b.updated.Add(time.Duration((l.burst - b.tokens) / l.rate * float64(time.Second)))
+		if !now.Before(full) {
+			delete(l.buckets, key)
+			continue
+		}
+		if next.IsZero() || full.Before(next) {
+			next = full
+		}
+	}
+	l.nextEvict = next
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
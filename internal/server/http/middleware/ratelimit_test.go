@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+)
+
+func TestRateLimiter_ByIP(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 10, 1024)
+	limiter.now = func() time.Time { return now }
+	handler := limiter.ByIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r = r.WithContext(srvContext.SetClientIP(r.Context(), ip))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1").Code, "First request")
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1").Code, "Second request")
+
+	w := request("192.0.2.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "Request over limit")
+	assert.Equal(t, "30", w.Header().Get("Retry-After"), "Retry-After header")
+
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.2").Code, "Request from another IP")
+
+	// за 30 секунд корзина наполняется на один запрос
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1").Code, "Request after refill")
+	assert.Equal(t, http.StatusTooManyRequests, request("192.0.2.1").Code, "Request over limit after refill")
+}
+
+func TestRateLimiter_ByLogin(t *testing.T) {
+	limiter := NewRateLimiter(1, 10, 1024)
+
+	var got string
+	handler := limiter.ByLogin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.Nil(t, err, "Read body")
+		got = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{name: "first_request", body: `{"login":"user","auth_key":"a2V5"}`, wantCode: http.StatusNoContent},
+		{name: "same_login", body: `{"login":" user ","auth_key":"a2V5"}`, wantCode: http.StatusTooManyRequests},
+		{name: "another_login", body: `{"login":"other","auth_key":"a2V5"}`, wantCode: http.StatusNoContent},
+		{name: "without_login", body: `{"auth_key":"a2V5"}`, wantCode: http.StatusNoContent},
+		{name: "invalid_json", body: `not json`, wantCode: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got = ""
+			r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, test.wantCode, w.Code, "Response status code")
+			if test.wantCode == http.StatusNoContent {
+				assert.Equal(t, test.body, got, "Body passed to handler")
+			}
+		})
+	}
+}
+
+func TestRateLimiter_disabled(t *testing.T) {
+	limiter := NewRateLimiter(0, 10, 1024)
+	assert.Nil(t, limiter, "Disabled limiter")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	for range 10 {
+		w := httptest.NewRecorder()
+		limiter.ByIP(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code, "Response status code")
+	}
+}
+
+func TestRateLimiter_evictIdle(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 10, 1024)
+	limiter.now = func() time.Time { return now }
+
+	limiter.take("ip:192.0.2.1")
+	now = now.Add(30 * time.Second)
+	limiter.take("ip:192.0.2.2")
+	limiter.take("ip:192.0.2.2")
+
+	now = now.Add(30 * time.Second)
+	limiter.evictIdle()
+	assert.NotContains(t, limiter.buckets, "ip:192.0.2.1", "Full bucket")
+	assert.Contains(t, limiter.buckets, "ip:192.0.2.2", "Refilling bucket")
+}
+
+func TestRateLimiter_maxKeys(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 3, 1024)
+	limiter.now = func() time.Time { return now }
+
+	// корзина, на которой клиент уже ограничен
+	limiter.take("login:victim")
+	limiter.take("login:victim")
+	require.NotZero(t, limiter.take("login:victim"), "Throttled bucket")
+
+	for i := range 100 {
+		limiter.take(fmt.Sprintf("login:spoofed%d", i))
+		assert.LessOrEqual(t, len(limiter.buckets), 3, "Buckets count")
+	}
+	assert.Contains(t, limiter.buckets, "login:victim", "Throttled bucket kept")
+	assert.NotZero(t, limiter.take("login:victim"), "Still throttled")
+	// новые ключи сверх лимита делят общую корзину
+	assert.NotZero(t, limiter.take("login:new"), "Overflow bucket exhausted")
+	assert.NotContains(t, limiter.buckets, "login:new", "Overflow key")
+
+	// наполнившиеся корзины вытесняются новыми ключами
+	now = now.Add(time.Minute)
+	assert.Zero(t, limiter.take("login:new"), "Full buckets evicted")
+	assert.Contains(t, limiter.buckets, "login:new", "New bucket")
+}
+
+func TestRateLimiter_Run(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 10, 1024)
+	limiter.now = func() time.Time { return now }
+	limiter.take("ip:192.0.2.1")
+	now = now.Add(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		limiter.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return len(limiter.buckets) == 0
+	}, time.Second, time.Millisecond, "Idle bucket evicted")
+
+	cancel()
+	<-done
+
+	var disabled *RateLimiter
+	disabled.Run(ctx, time.Millisecond)
+}
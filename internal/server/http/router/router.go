@@ -1,6 +1,7 @@
 package router
 
 import (
+	"crypto/rsa"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -12,6 +13,8 @@ import (
 
 type AuthService interface {
 	handler.AuthService
+	handler.PasswordService
+	handler.AccountService
 	middleware.AuthService
 }
 
@@ -22,31 +25,108 @@ type Logger interface {
 
 type SecretService = handler.SecretService
 
-// NewRouter инициализирует хендлеры и создает роутер *chiMux
-func NewRouter(cfg *config.Config, l Logger, a AuthService, s SecretService) http.Handler {
+type UploadService = handler.UploadService
+
+type SessionService = handler.SessionService
+
+type TwoFactorService = handler.TwoFactorService
+
+type RecoveryService = handler.RecoveryService
+
+// NewRouter инициализирует хендлеры и создает роутер *chiMux,
+// payloadKey - ключ для расшифровки тел запросов с учетными данными или ключом восстановления,
+// может быть nil,
+// ipLimit и loginLimit - ограничители частоты запросов с IP и входа в логин, могут быть nil
+func NewRouter(
+	cfg *config.Config,
+	l Logger,
+	a AuthService,
+	s SecretService,
+	u UploadService,
+	ss SessionService,
+	f TwoFactorService,
+	rs RecoveryService,
+	payloadKey *rsa.PrivateKey,
+	ipLimit *middleware.RateLimiter,
+	loginLimit *middleware.RateLimiter,
+) http.Handler {
 	authorizer := middleware.NewAuthorizer(a)
 	logger := middleware.NewLogger(l)
 	authHandler := handler.NewAuth(a, l, cfg.AuthBodyMaxSize)
-	secretHandler := handler.NewSecret(s, l)
+	passwordHandler := handler.NewPassword(a, l, cfg.SecretBodyMaxSize)
+	accountHandler := handler.NewAccount(a, l, cfg.SecretBodyMaxSize)
+	secretHandler := handler.NewSecret(s, l, cfg.SecretBodyMaxSize)
+	uploadHandler := handler.NewUpload(u, l)
+	sessionHandler := handler.NewSession(ss, l)
+	twoFactorHandler := handler.NewTwoFactor(f, l, cfg.AuthBodyMaxSize)
+	recoveryHandler := handler.NewRecovery(rs, l, cfg.SecretBodyMaxSize)
+	clientIP := middleware.NewClientIP(cfg.TrustedProxies)
+	payload := middleware.NewPayloadDecryptor(payloadKey, cfg.AuthBodyMaxSize)
+	// Смена пароля и восстановление передают вместе с учетными данными ключи секретов
+	keysPayload := middleware.NewPayloadDecryptor(payloadKey, cfg.SecretBodyMaxSize)
+	var payloadPublicKey *rsa.PublicKey
+	if payloadKey != nil {
+		payloadPublicKey = &payloadKey.PublicKey
+	}
+	publicKeyHandler := handler.NewPublicKey(payloadPublicKey, l)
 
 	router := chi.NewRouter()
 
 	router.Route("/api", func(r chi.Router) {
 		r.Use(logger.Log)
+		r.Use(clientIP.Resolve)
+		r.Get("/pubkey", publicKeyHandler.Get)
+		r.With(ipLimit.ByIP).Post("/prelogin", authHandler.PreLogin)
 		r.Route("/register", func(r chi.Router) {
-			r.Post("/", authHandler.Register)
+			r.With(ipLimit.ByIP, payload.Decrypt).Post("/", authHandler.Register)
 		})
 		r.Route("/login", func(r chi.Router) {
-			r.Post("/", authHandler.Login)
+			r.With(ipLimit.ByIP, payload.Decrypt, loginLimit.ByLogin).Post("/", authHandler.Login)
+		})
+		r.Route("/token", func(r chi.Router) {
+			r.Post("/refresh", authHandler.Refresh)
 		})
+		r.With(ipLimit.ByIP, payload.Decrypt).Post("/recovery/start", recoveryHandler.Start)
+		r.With(ipLimit.ByIP, keysPayload.Decrypt).Post("/recovery/complete", recoveryHandler.Complete)
 
 		r.Group(func(r chi.Router) {
 			r.Use(authorizer.Authorize)
 
+			r.Get("/usage", secretHandler.Usage)
+			r.Post("/logout", sessionHandler.Logout)
+			r.Get("/password", passwordHandler.Params)
+			r.With(keysPayload.Decrypt).Post("/password", passwordHandler.Change)
+			// Ключ аккаунта и ключи секретов передаются зашифрованными, учетных данных в запросе нет
+			r.Put("/account-key", accountHandler.SetKey)
+			r.Get("/recovery", recoveryHandler.Get)
+			r.With(keysPayload.Decrypt).Put("/recovery", recoveryHandler.Setup)
+
+			r.Route("/session", func(r chi.Router) {
+				r.Get("/", sessionHandler.List)
+				r.Delete("/{id}", sessionHandler.Revoke)
+			})
+
+			r.Route("/2fa", func(r chi.Router) {
+				r.Post("/setup", twoFactorHandler.Setup)
+				r.Post("/enable", twoFactorHandler.Enable)
+				r.Post("/disable", twoFactorHandler.Disable)
+			})
+
 			r.Route("/secret", func(r chi.Router) {
 				r.Post("/", secretHandler.Upload)
 				r.Get("/{id}", secretHandler.Get)
+				r.Get("/{id}/chunk/{n}", secretHandler.Chunk)
+				r.Put("/{id}", secretHandler.Update)
+				r.Delete("/{id}", secretHandler.Delete)
 				r.Get("/", secretHandler.List)
+				r.Get("/keys", secretHandler.Keys)
+
+				r.Route("/upload", func(r chi.Router) {
+					r.Post("/", uploadHandler.Create)
+					r.Get("/{id}", uploadHandler.Status)
+					r.Put("/{id}/chunk/{n}", uploadHandler.PutChunk)
+					r.Post("/{id}/commit", uploadHandler.Commit)
+				})
 			})
 		})
 	})
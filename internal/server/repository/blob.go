@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+// Префиксы ключей объектов в хранилище данных
+const (
+	BlobPrefixSecret = "secrets/"
+	BlobPrefixChunk  = "chunks/"
+)
+
+// BlobStore хранилище зашифрованных данных секретов вне БД.
+type BlobStore interface {
+	// Put сохраняет данные по ключу key, заменяя существующие.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get возвращает данные по ключу key или errors.ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete удаляет данные по ключу key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob репозиторий ссылок на объекты хранилища данных.
+type Blob struct {
+	pool *pgxpool.Pool
+}
+
+func NewBlob(db *pg.DB) *Blob {
+	return &Blob{pool: db.Pool()}
+}
+
+// Unreferenced возвращает ключи из keys, на которые не ссылается ни один секрет или часть загрузки.
+func (b *Blob) Unreferenced(ctx context.Context, keys []string) ([]string, error) {
+	query := `
+	SELECT k FROM unnest($1::text[]) AS k 
+		WHERE NOT EXISTS (SELECT 1 FROM secrets WHERE blob_key = k) 
+			AND NOT EXISTS (SELECT 1 FROM secret_chunks WHERE blob_key = k)`
+
+	rows, err := b.pool.Query(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select blob keys: %w", err)
+	}
+
+	list, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse selected blob keys: %w", err)
+	}
+
+	return list, nil
+}
+
+// ExpireUploads удаляет незавершенные загрузки, созданные раньше, чем ttl назад, вместе с их частями.
+// После этого объекты частей перестают быть нужными и их удаляет сборщик мусора.
+// Возвращает количество удаленных загрузок.
+func (b *Blob) ExpireUploads(ctx context.Context, ttl time.Duration) (int64, error) {
+	query := `
+	DELETE FROM secret_uploads 
+		WHERE committed_at IS NULL AND created_at < NOW() - make_interval(secs => $1)`
+
+	tag, err := b.pool.Exec(ctx, query, ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete from secret_uploads: %w", errors.Trasform(err))
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// blobOffloader решает, какие данные секретов хранить в BlobStore, а какие в БД.
+type blobOffloader struct {
+	blobs BlobStore
+	// Данные файлов больше threshold байт хранятся в BlobStore
+	threshold int64
+}
+
+// offload сохраняет в хранилище данные файла больше порога и возвращает ключ объекта.
+// Для остальных данных возвращает nil, такие данные хранятся в БД.
+func (o blobOffloader) offload(ctx context.Context, dataType string, data []byte) (*string, error) {
+	if dataType != dto.SecretTypeFile || int64(len(data)) <= o.threshold {
+		return nil, nil
+	}
+
+	key, err := newBlobKey(BlobPrefixSecret)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.blobs.Put(ctx, key, data); err != nil {
+		return nil, fmt.Errorf("failed to put secret data to blob store: %w", err)
+	}
+
+	return &key, nil
+}
+
+// discard удаляет объект, ссылку на который не удалось сохранить в БД.
+// Ошибка удаления не возвращается, оставшийся объект удалит сборщик мусора.
+func (o blobOffloader) discard(ctx context.Context, key *string) {
+	if key != nil {
+		_ = o.blobs.Delete(ctx, *key)
+	}
+}
+
+// newBlobKey генерирует случайный ключ объекта с префиксом prefix.
+func newBlobKey(prefix string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate blob key: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// newChunkBlobKey генерирует ключ объекта части n загрузки uploadID.
+// Ключ случайный, поэтому повторная загрузка части не перезаписывает данные, на которые уже есть ссылка.
+func newChunkBlobKey(uploadID string, n uint32) (string, error) {
+	return newBlobKey(fmt.Sprintf("%s%s/%d/", BlobPrefixChunk, uploadID, n))
+}
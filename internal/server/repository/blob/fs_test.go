@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileStore(filepath.Join(dir, "blobs"))
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "secrets/a1", []byte("first")))
+	require.NoError(t, store.Put(ctx, "chunks/u1/0", []byte("chunk")))
+	require.NoError(t, store.Put(ctx, "secrets/a1", []byte("replaced")))
+
+	data, err := store.Get(ctx, "secrets/a1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("replaced"), data)
+
+	list, err := store.List(ctx, "secrets/")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "secrets/a1", list[0].Key)
+	assert.False(t, list[0].Modified.IsZero())
+
+	list, err = store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	require.NoError(t, store.Delete(ctx, "secrets/a1"))
+	require.NoError(t, store.Delete(ctx, "secrets/a1"))
+	_, err = store.Get(ctx, "secrets/a1")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+
+	// Временные файлы не остаются в каталоге
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs", "secrets"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFileStore_InvalidKey(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../outside", "a/../../b", "/abs", "a//b", `a\b`} {
+		assert.Error(t, store.Put(ctx, key, []byte("data")), key)
+		_, err := store.Get(ctx, key)
+		assert.Error(t, err, key)
+		assert.Error(t, store.Delete(ctx, key), key)
+	}
+}
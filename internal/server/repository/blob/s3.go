@@ -0,0 +1,267 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+)
+
+const (
+	s3Service    = "s3"
+	amzDateFmt   = "20060102T150405Z"
+	amzDayFmt    = "20060102"
+	signAlgoritm = "AWS4-HMAC-SHA256"
+)
+
+// S3Config параметры подключения к S3-совместимому хранилищу.
+type S3Config struct {
+	// Адрес хранилища, например "http://localhost:9000"
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store хранит данные в бакете S3-совместимого хранилища (AWS S3, MinIO).
+// Использует адресацию бакета в пути и подпись запросов AWS Signature V4.
+type S3Store struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Store(cfg S3Config) *S3Store {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &S3Store{cfg: cfg, client: &http.Client{Timeout: time.Minute}, now: time.Now}
+}
+
+// Put сохраняет данные по ключу key, заменяя существующие.
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to put blob: %w", responseError(resp))
+	}
+
+	return nil
+}
+
+// Get возвращает данные по ключу key или errors.ErrNotFound.
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get blob: %w", responseError(resp))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+
+	return data, nil
+}
+
+// Delete удаляет данные по ключу key, отсутствие данных ошибкой не считается.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent &&
+		resp.StatusCode != http.StatusOK &&
+		resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete blob: %w", responseError(resp))
+	}
+
+	return nil
+}
+
+// listResult ответ ListObjectsV2.
+type listResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List возвращает все объекты бакета, ключи которых начинаются с prefix.
+func (s *S3Store) List(ctx context.Context, prefix string) ([]entity.Blob, error) {
+	var (
+		list  []entity.Blob
+		token string
+	)
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		page, err := s.listPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			list = append(list, entity.Blob{Key: obj.Key, Modified: obj.LastModified})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return list, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// listPage запрашивает одну страницу списка объектов.
+func (s *S3Store) listPage(ctx context.Context, query url.Values) (listResult, error) {
+	var page listResult
+
+	resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+	if err != nil {
+		return page, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("failed to list blobs: %w", responseError(resp))
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("failed to decode blob list: %w", err)
+	}
+
+	return page, nil
+}
+
+// do отправляет подписанный запрос к объекту key бакета, пустой key - запрос к самому бакету.
+func (s *S3Store) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.cfg.Bucket
+	if key != "" {
+		if !validKey(key) {
+			return nil, fmt.Errorf("invalid blob key %q", key)
+		}
+		path += "/" + key
+	}
+
+	u, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	u.Path = path
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	s.sign(req, hex.EncodeToString(payloadHash[:]), s.now().UTC())
+
+	return s.client.Do(req)
+}
+
+// sign добавляет к запросу подпись AWS Signature V4.
+// Подписываются заголовок Host и все заголовки X-Amz-*.
+func (s *S3Store) sign(req *http.Request, payloadHash string, t time.Time) {
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFmt))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(amzDayFmt), s.cfg.Region, s3Service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signAlgoritm,
+		t.Format(amzDateFmt),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), t.Format(amzDayFmt))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgoritm, s.cfg.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalQuery кодирует параметры запроса так, как требует подпись: по порядку имен, пробел как %20.
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// responseError возвращает ошибку с кодом и текстом ответа хранилища.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+// testDB подключается к тестовой БД из TEST_DATABASE_DSN и применяет миграции,
+// без TEST_DATABASE_DSN тест пропускается.
+func testDB(t *testing.T) *pg.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	// миграции ищутся относительно корня репозитория
+	t.Chdir("../../..")
+	db, err := pg.NewDB(context.Background(), dsn)
+	require.Nil(t, err, "Connect to test DB")
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// testUser создает пользователя со случайным логином и возвращает его ID.
+func testUser(t *testing.T, db *pg.DB) string {
+	b, err := crypto.GenerateRandomBytes(8)
+	require.Nil(t, err, "Generate login")
+
+	user, err := NewUser(db).Create(context.Background(), entity.User{
+		Login:       "test-" + hex.EncodeToString(b),
+		Hash:        "hash",
+		AuthSalt:    "salt",
+		AuthVersion: dto.AuthVersionKey,
+		EncrSalt:    "salt",
+		KDF:         entity.KDFParams(crypto.DefaultKDFParams()),
+	})
+	require.Nil(t, err, "Create user")
+
+	return user.ID
+}
+
+// testSecret секрет пользователя userID с данными в БД.
+func testSecret(userID, name string) entity.Secret {
+	return entity.Secret{
+		UserID:        userID,
+		DataType:      dto.SecretTypeText,
+		Name:          name,
+		MetaData:      "[]",
+		EncryptedData: []byte("data"),
+		EncryptedKey:  "key",
+	}
+}
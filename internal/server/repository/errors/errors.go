@@ -16,6 +16,10 @@ var (
 	ErrDuplicateKey  = errors.New("duplicate key")
 	ErrNotFound      = errors.New("not found")
 	ErrNoRowsUpdated = errors.New("no rows updated")
+	ErrIncomplete    = errors.New("incomplete data")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrRevoked       = errors.New("revoked")
+	ErrUIDMismatch   = errors.New("uid mismatch")
 )
 
 func Trasform(err error) error {
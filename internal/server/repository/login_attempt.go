@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+type LoginAttempt struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginAttempt(db *pg.DB) *LoginAttempt {
+	return &LoginAttempt{pool: db.Pool()}
+}
+
+// Attempt учитывает попытку входа по логину из сети network до проверки учетных данных.
+// Проверка блокировки и учет попытки выполняются в одной транзакции, поэтому параллельные
+// попытки не проходят проверку раньше, чем учтены предыдущие.
+// Если вход заблокирован позже now, попытка не учитывается и возвращается время окончания
+// блокировки. Иначе счетчик попыток увеличивается (и начинается заново, если предыдущая
+// попытка была раньше, чем p.Reset назад), при достижении порога сразу ставится блокировка
+// на p.LockFor и возвращается нулевое время. Успешный вход сбрасывает счетчик через Reset.
+func (l *LoginAttempt) Attempt(
+	ctx context.Context,
+	login, network string,
+	now time.Time,
+	p entity.LockoutPolicy,
+) (time.Time, error) {
+	tx, err := l.pool.Begin(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Пустая запись нужна, чтобы первую попытку тоже упорядочила блокировка строки
+	query := `
+	INSERT INTO login_attempts (login, network) VALUES ($1, $2)
+		ON CONFLICT (login, network) DO NOTHING`
+	if _, err := tx.Exec(ctx, query, login, network); err != nil {
+		return time.Time{}, fmt.Errorf("failed to insert to login_attempts: %w", errors.Trasform(err))
+	}
+
+	query = `
+	SELECT login, network, failures, locked_until, updated_at 
+		FROM login_attempts 
+		WHERE login = $1 AND network = $2 
+		FOR UPDATE`
+	rows, err := tx.Query(ctx, query, login, network)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to select from login_attempts: %w", err)
+	}
+	attempt, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.LoginAttempt])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to select from login_attempts: %w", errors.Trasform(err))
+	}
+
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		return *attempt.LockedUntil, nil
+	}
+
+	failures := attempt.Failures + 1
+	if attempt.Updated.Before(now.Add(-p.Reset)) {
+		failures = 1
+	}
+	var lockedUntil *time.Time
+	if d := p.LockFor(failures); d > 0 {
+		until := now.Add(d)
+		lockedUntil = &until
+	}
+
+	query = `
+	UPDATE login_attempts SET failures = $3, locked_until = $4, updated_at = $5 
+		WHERE login = $1 AND network = $2`
+	if _, err := tx.Exec(ctx, query, login, network, failures, lockedUntil, now); err != nil {
+		return time.Time{}, fmt.Errorf("failed to update login_attempts: %w", errors.Trasform(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return time.Time{}, nil
+}
+
+// Reset удаляет попытки входа по логину из сети network после успешного входа.
+func (l *LoginAttempt) Reset(ctx context.Context, login, network string) error {
+	query := `DELETE FROM login_attempts WHERE login = $1 AND network = $2`
+
+	_, err := l.pool.Exec(ctx, query, login, network)
+	if err != nil {
+		return fmt.Errorf("failed to delete from login_attempts: %w", errors.Trasform(err))
+	}
+
+	return nil
+}
+
+// Purge удаляет неудачные попытки входа, после которых прошло больше reset, если логин
+// уже не заблокирован: такие записи не влияют на вход. Возвращает количество удаленных записей.
+func (l *LoginAttempt) Purge(ctx context.Context, reset time.Duration) (int64, error) {
+	query := `
+	DELETE FROM login_attempts
+		WHERE updated_at < NOW() - make_interval(secs => $1)
+			AND (locked_until IS NULL OR locked_until < NOW())`
+
+	tag, err := l.pool.Exec(ctx, query, reset.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete from login_attempts: %w", errors.Trasform(err))
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+)
+
+func testLogin(t *testing.T) string {
+	t.Helper()
+	b, err := crypto.GenerateRandomBytes(8)
+	require.Nil(t, err, "Generate login")
+	return "test-" + hex.EncodeToString(b)
+}
+
+func countAttempts(t *testing.T, attempts *LoginAttempt, login string) int {
+	t.Helper()
+	var n int
+	err := attempts.pool.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM login_attempts WHERE login = $1`, login,
+	).Scan(&n)
+	require.Nil(t, err, "Count attempts")
+	return n
+}
+
+func TestLoginAttempt_Attempt(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	attempts := NewLoginAttempt(db)
+	policy := entity.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: time.Hour, Reset: 24 * time.Hour}
+	now := time.Now().Truncate(time.Microsecond)
+
+	login := testLogin(t)
+	t.Cleanup(func() {
+		_, _ = db.Pool().Exec(ctx, `DELETE FROM login_attempts WHERE login = $1`, login)
+	})
+
+	for i := 1; i < policy.Threshold; i++ {
+		until, err := attempts.Attempt(ctx, login, "192.0.2.0/24", now, policy)
+		require.Nil(t, err, "Attempt %d", i)
+		assert.True(t, until.IsZero(), "Attempt %d is not locked", i)
+	}
+	// попытка, достигшая порога, проходит и сразу блокирует следующие
+	until, err := attempts.Attempt(ctx, login, "192.0.2.0/24", now, policy)
+	require.Nil(t, err, "Threshold attempt")
+	assert.True(t, until.IsZero(), "Threshold attempt is not locked")
+
+	until, err = attempts.Attempt(ctx, login, "192.0.2.0/24", now, policy)
+	require.Nil(t, err, "Locked attempt")
+	assert.True(t, until.Equal(now.Add(time.Minute)), "Locked until")
+
+	// вход из другой сети не заблокирован
+	until, err = attempts.Attempt(ctx, login, "198.51.100.0/24", now, policy)
+	require.Nil(t, err, "Other network attempt")
+	assert.True(t, until.IsZero(), "Other network is not locked")
+
+	// после окончания блокировки следующая попытка удваивает ее
+	later := now.Add(2 * time.Minute)
+	until, err = attempts.Attempt(ctx, login, "192.0.2.0/24", later, policy)
+	require.Nil(t, err, "Attempt after lock")
+	assert.True(t, until.IsZero(), "Attempt after lock is not locked")
+	until, err = attempts.Attempt(ctx, login, "192.0.2.0/24", later, policy)
+	require.Nil(t, err, "Locked again")
+	assert.True(t, until.Equal(later.Add(2*time.Minute)), "Doubled lock")
+
+	require.Nil(t, attempts.Reset(ctx, login, "192.0.2.0/24"), "Reset network")
+	assert.Equal(t, 1, countAttempts(t, attempts, login), "Other network attempts kept")
+}
+
+func TestLoginAttempt_AttemptParallel(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	attempts := NewLoginAttempt(db)
+	policy := entity.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: time.Hour, Reset: 24 * time.Hour}
+	now := time.Now()
+
+	login := testLogin(t)
+	t.Cleanup(func() {
+		_, _ = db.Pool().Exec(ctx, `DELETE FROM login_attempts WHERE login = $1`, login)
+	})
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			until, err := attempts.Attempt(ctx, login, "", now, policy)
+			assert.Nil(t, err, "Attempt")
+			if err == nil && until.IsZero() {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, policy.Threshold, allowed, "Allowed parallel attempts")
+}
+
+func TestLoginAttempt_Purge(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	attempts := NewLoginAttempt(db)
+	policy := entity.LockoutPolicy{Threshold: 1, Base: time.Hour, Reset: time.Hour}
+
+	old, locked, recent := testLogin(t), testLogin(t), testLogin(t)
+	for _, l := range []string{old, recent} {
+		_, err := attempts.Attempt(ctx, l, "", time.Now(), entity.LockoutPolicy{Reset: time.Hour})
+		require.Nil(t, err, "Record attempt")
+	}
+	_, err := attempts.Attempt(ctx, locked, "", time.Now(), policy)
+	require.Nil(t, err, "Lock login")
+	_, err = db.Pool().Exec(ctx,
+		`UPDATE login_attempts SET updated_at = NOW() - INTERVAL '2 days' WHERE login = ANY($1)`,
+		[]string{old, locked},
+	)
+	require.Nil(t, err, "Age attempts")
+
+	deleted, err := attempts.Purge(ctx, 24*time.Hour)
+	require.Nil(t, err, "Purge attempts")
+	assert.GreaterOrEqual(t, deleted, int64(1), "Deleted attempts")
+
+	assert.Equal(t, 0, countAttempts(t, attempts, old), "Old attempt")
+	assert.Equal(t, 1, countAttempts(t, attempts, locked), "Locked login attempt")
+	assert.Equal(t, 1, countAttempts(t, attempts, recent), "Recent attempt")
+
+	require.Nil(t, attempts.Reset(ctx, locked, ""), "Cleanup locked login")
+	require.Nil(t, attempts.Reset(ctx, recent, ""), "Cleanup recent login")
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+type Recovery struct {
+	pool *pgxpool.Pool
+}
+
+func NewRecovery(db *pg.DB) *Recovery {
+	return &Recovery{pool: db.Pool()}
+}
+
+// Get возвращает ключи восстановления пользователя.
+// Если пользователь не настраивал восстановление доступа, возвращает errors.ErrNotFound.
+func (r *Recovery) Get(ctx context.Context, userID string) (entity.RecoveryKey, error) {
+	query := `SELECT user_id, master_key, recovery_key, auth_hash FROM user_recovery_keys WHERE user_id = $1`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return entity.RecoveryKey{}, fmt.Errorf("failed to select from user_recovery_keys: %w", err)
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.RecoveryKey])
+	if err != nil {
+		return key, errors.Trasform(err)
+	}
+
+	return key, nil
+}
+
+// Save сохраняет ключи восстановления, заменяя предыдущие.
+func (r *Recovery) Save(ctx context.Context, key entity.RecoveryKey) error {
+	query := `
+	INSERT INTO user_recovery_keys (user_id, master_key, recovery_key, auth_hash) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET master_key = EXCLUDED.master_key, recovery_key = EXCLUDED.recovery_key,
+			auth_hash = EXCLUDED.auth_hash, created_at = NOW()`
+
+	_, err := r.pool.Exec(ctx, query, key.UserID, key.MasterKey, key.RecoveryKey, key.AuthHash)
+	if err != nil {
+		return fmt.Errorf("failed to insert to user_recovery_keys: %w", errors.Trasform(err))
+	}
+
+	return nil
+}
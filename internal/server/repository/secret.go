@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -14,22 +15,48 @@ import (
 
 type Secret struct {
 	pool *pgxpool.Pool
+	blobOffloader
 }
 
-func NewSecret(db *pg.DB) *Secret {
-	return &Secret{pool: db.Pool()}
+// NewSecret создает репозиторий секретов, данные файлов больше threshold байт
+// хранятся в blobs, в БД остается только ключ объекта.
+func NewSecret(db *pg.DB, blobs BlobStore, threshold int64) *Secret {
+	return &Secret{pool: db.Pool(), blobOffloader: blobOffloader{blobs: blobs, threshold: threshold}}
 }
 
 // Create создает пользовательский секрет в БД.
-func (s *Secret) Create(ctx context.Context, secret entity.Secret) error {
+// Возвращает errors.ErrQuotaExceeded, если секрет не помещается в квоту пользователя.
+func (s *Secret) Create(ctx context.Context, secret entity.Secret, quota entity.Quota) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	size := int64(len(secret.EncryptedData))
+	usage, err := lockUsage(ctx, tx, secret.UserID)
+	if err != nil {
+		return err
+	}
+	if !quota.Allows(usage, size, 1) {
+		return errors.ErrQuotaExceeded
+	}
+
+	blobKey, err := s.offload(ctx, secret.DataType, secret.EncryptedData)
+	if err != nil {
+		return err
+	}
+	if blobKey != nil {
+		secret.EncryptedData = nil
+	}
+
 	query := `
 	INSERT INTO secrets
-			(user_id, data_type, name, meta_data, encrypted_data, encrypted_key) 
+			(user_id, data_type, name, meta_data, encrypted_data, encrypted_key, key_version, blob_key, size, uid) 
 		VALUES
-			($1, $2, $3, $4, $5, $6) 
-		RETURNING id, created_at, updated_at`
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::UUID)`
 
-	_, err := s.pool.Exec(
+	_, err = tx.Exec(
 		ctx,
 		query,
 		secret.UserID,
@@ -38,22 +65,33 @@ func (s *Secret) Create(ctx context.Context, secret entity.Secret) error {
 		secret.MetaData,
 		secret.EncryptedData,
 		secret.EncryptedKey,
+		secret.KeyVersion,
+		blobKey,
+		size,
+		secret.UID,
 	)
-
 	if err != nil {
+		s.discard(ctx, blobKey)
 		return fmt.Errorf("failed to insert to secrets: %w", errors.Trasform(err))
 	}
 
+	if err = tx.Commit(ctx); err != nil {
+		s.discard(ctx, blobKey)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
 // GetForUser возвращает пользовательский секрет по secretID и userID.
+// Данные, хранящиеся вне БД, получает из хранилища.
 func (s *Secret) GetForUser(ctx context.Context, secretID uint64, userID string) (entity.Secret, error) {
 	var secret entity.Secret
 
 	query := `
 		SELECT 
-			id, user_id, data_type, name, meta_data, encrypted_data, encrypted_key, created_at, updated_at 
+			id, user_id, data_type, name, meta_data, encrypted_data, encrypted_key, key_version, created_at, updated_at, revision, version, chunks, 
+			COALESCE(blob_key, '') AS blob_key, COALESCE(uid::TEXT, '') AS uid 
 		FROM secrets 
 		WHERE id = $1 AND user_id = $2`
 	rows, err := s.pool.Query(ctx, query, secretID, userID)
@@ -66,12 +104,259 @@ func (s *Secret) GetForUser(ctx context.Context, secretID uint64, userID string)
 		return secret, errors.Trasform(err)
 	}
 
+	if secret.BlobKey != "" {
+		secret.EncryptedData, err = s.blobs.Get(ctx, secret.BlobKey)
+		if err != nil {
+			return secret, fmt.Errorf("failed to get secret data from blob store: %w", err)
+		}
+	}
+
 	return secret, nil
 }
 
+// UpdateForUser изменяет пользовательский секрет, если его версия совпадает с secret.Version,
+// и увеличивает версию. Если secret.EncryptedKey пустой, данные секрета не меняются.
+// Возвращает errors.ErrNotFound, если секрет не найден или принадлежит другому пользователю,
+// errors.ErrNoRowsUpdated, если секрет уже изменили,
+// errors.ErrUIDMismatch, если secret.UID не пустой и отличается от UID секрета,
+// и errors.ErrQuotaExceeded, если новые данные не помещаются в квоту пользователя.
+// Старые данные из хранилища не удаляются, их удалит сборщик мусора.
+func (s *Secret) UpdateForUser(ctx context.Context, secret entity.Secret, quota entity.Quota) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	usage, err := lockUsage(ctx, tx, secret.UserID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		uploadID *string
+		dataType string
+		size     int64
+		uid      string
+	)
+	query := `
+	SELECT upload_id, data_type, size, COALESCE(uid::TEXT, '') 
+		FROM secrets 
+		WHERE id = $1 AND user_id = $2 AND version = $3 
+		FOR UPDATE`
+	err = tx.QueryRow(ctx, query, secret.ID, secret.UserID, secret.Version).Scan(&uploadID, &dataType, &size, &uid)
+	if err == pgx.ErrNoRows {
+		return s.updateFailed(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to select from secrets: %w", errors.Trasform(err))
+	}
+	if !sameUID(uid, secret.UID) {
+		return errors.ErrUIDMismatch
+	}
+
+	if secret.EncryptedKey != "" && !quota.Allows(usage, int64(len(secret.EncryptedData))-size, 0) {
+		return errors.ErrQuotaExceeded
+	}
+
+	if secret.EncryptedKey == "" {
+		query = `
+		UPDATE secrets 
+			SET name = $1, meta_data = $2, updated_at = NOW(),
+				revision = nextval('secret_revision_seq'), version = version + 1
+			WHERE id = $3`
+		_, err = tx.Exec(ctx, query, secret.Name, secret.MetaData, secret.ID)
+	} else {
+		err = s.replaceData(ctx, tx, secret, dataType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update secrets: %w", errors.Trasform(err))
+	}
+
+	// Данные секрета заменены, части старых данных больше не нужны
+	if secret.EncryptedKey != "" && uploadID != nil {
+		if _, err = tx.Exec(ctx, `DELETE FROM secret_uploads WHERE id = $1`, *uploadID); err != nil {
+			return fmt.Errorf("failed to delete from secret_uploads: %w", errors.Trasform(err))
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// replaceData заменяет данные секрета, данные файлов больше порога сохраняет в хранилище.
+func (s *Secret) replaceData(ctx context.Context, tx pgx.Tx, secret entity.Secret, dataType string) error {
+	size := len(secret.EncryptedData)
+	blobKey, err := s.offload(ctx, dataType, secret.EncryptedData)
+	if err != nil {
+		return err
+	}
+	if blobKey != nil {
+		secret.EncryptedData = nil
+	}
+
+	query := `
+	UPDATE secrets 
+		SET name = $1, meta_data = $2, encrypted_data = $3, encrypted_key = $4, key_version = $5,
+			blob_key = $6, size = $7, upload_id = NULL, chunks = 0, updated_at = NOW(),
+			uid = COALESCE(uid, NULLIF($9, '')::UUID),
+			revision = nextval('secret_revision_seq'), version = version + 1
+		WHERE id = $8`
+	_, err = tx.Exec(
+		ctx,
+		query,
+		secret.Name,
+		secret.MetaData,
+		secret.EncryptedData,
+		secret.EncryptedKey,
+		secret.KeyVersion,
+		blobKey,
+		size,
+		secret.ID,
+		secret.UID,
+	)
+	if err != nil {
+		s.discard(ctx, blobKey)
+		return err
+	}
+
+	return nil
+}
+
+// sameUID проверяет, что UID из запроса не противоречит UID секрета: пустой UID
+// запроса ничего не меняет, а UID присваивается только секрету без UID.
+func sameUID(stored, requested string) bool {
+	return stored == "" || requested == "" || strings.EqualFold(stored, requested)
+}
+
+// updateFailed выясняет, почему секрет не удалось изменить:
+// возвращает errors.ErrNotFound, если секрета нет, и errors.ErrNoRowsUpdated, если версия не совпала.
+func (s *Secret) updateFailed(ctx context.Context, secret entity.Secret) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM secrets WHERE id = $1 AND user_id = $2)`
+	err := s.pool.QueryRow(ctx, query, secret.ID, secret.UserID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to select from secrets: %w", errors.Trasform(err))
+	}
+
+	if !exists {
+		return errors.ErrNotFound
+	}
+
+	return errors.ErrNoRowsUpdated
+}
+
+// DeleteForUser удаляет пользовательский секрет по secretID и userID вместе с его частями
+// и оставляет запись об удалении.
+// Возвращает errors.ErrNotFound, если секрет не найден или принадлежит другому пользователю.
+func (s *Secret) DeleteForUser(ctx context.Context, secretID uint64, userID string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = lockUsage(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	query := `
+	WITH deleted AS (
+		DELETE FROM secrets WHERE id = $1 AND user_id = $2 RETURNING id, user_id, upload_id
+	), uploads AS (
+		DELETE FROM secret_uploads WHERE id IN (SELECT upload_id FROM deleted)
+	)
+	INSERT INTO secret_tombstones (secret_id, user_id)
+		SELECT id, user_id FROM deleted`
+
+	tag, err := tx.Exec(ctx, query, secretID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete from secrets: %w", errors.Trasform(err))
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetChunkForUser возвращает часть n данных пользовательского секрета по secretID и userID.
+// Возвращает errors.ErrNotFound, если секрет или часть не найдены.
+func (s *Secret) GetChunkForUser(ctx context.Context, secretID uint64, userID string, n uint32) ([]byte, error) {
+	var (
+		data    []byte
+		blobKey *string
+	)
+
+	query := `
+	SELECT c.data, c.blob_key 
+		FROM secrets s 
+		JOIN secret_chunks c ON c.upload_id = s.upload_id 
+		WHERE s.id = $1 AND s.user_id = $2 AND c.n = $3`
+	err := s.pool.QueryRow(ctx, query, secretID, userID, n).Scan(&data, &blobKey)
+	if err != nil {
+		return nil, errors.Trasform(err)
+	}
+
+	if blobKey != nil {
+		data, err = s.blobs.Get(ctx, *blobKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunk from blob store: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// UsageByUser возвращает использование хранилища пользователем.
+func (s *Secret) UsageByUser(ctx context.Context, userID string) (entity.Usage, error) {
+	var usage entity.Usage
+
+	query := `SELECT used_bytes, secret_count FROM users WHERE id = $1`
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return usage, fmt.Errorf("failed to select from users: %w", err)
+	}
+
+	usage, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Usage])
+	if err != nil {
+		return usage, errors.Trasform(err)
+	}
+
+	return usage, nil
+}
+
+// GetKeysByUser возвращает зашифрованные ключи данных всех секретов пользователя.
+func (s *Secret) GetKeysByUser(ctx context.Context, userID string) ([]entity.SecretKey, error) {
+	query := `SELECT id, encrypted_key, key_version FROM secrets WHERE user_id = $1 ORDER BY id`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select from secrets: %w", err)
+	}
+
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.SecretKey])
+	if err != nil {
+		return list, fmt.Errorf("failed to parse selected keys: %w", err)
+	}
+
+	return list, nil
+}
+
 // GetAllUnencryptedByUser возвращает не зашифрованные данные для всех записей пользователя
 func (s *Secret) GetAllUnencryptedByUser(ctx context.Context, userID string) ([]entity.SecretInfo, error) {
-	query := `SELECT id, data_type, name, meta_data, created_at, updated_at FROM secrets WHERE user_id = $1`
+	query := `
+	SELECT id, data_type, name, meta_data, created_at, updated_at, revision, version, chunks 
+		FROM secrets 
+		WHERE user_id = $1`
 
 	rows, err := s.pool.Query(ctx, query, userID)
 	if err != nil {
@@ -85,3 +370,57 @@ func (s *Secret) GetAllUnencryptedByUser(ctx context.Context, userID string) ([]
 
 	return list, nil
 }
+
+// GetChangesByUser возвращает не зашифрованные данные записей пользователя, созданных или измененных
+// после ревизии since, и записи об удаленных после нее секретах в порядке возрастания ревизии.
+// Оба списка читаются в одной транзакции REPEATABLE READ, поэтому изменение, закоммиченное
+// между запросами, не попадет в один список без другого. Ревизии изменений секретов пользователя
+// выдаются под блокировкой lockUsage, поэтому в снимке нет пропусков: изменение с меньшей ревизией
+// закоммичено раньше изменения с большей.
+func (s *Secret) GetChangesByUser(ctx context.Context, userID string, since uint64) (entity.SecretChanges, error) {
+	var changes entity.SecretChanges
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return changes, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	SELECT id, data_type, name, meta_data, created_at, updated_at, revision, version, chunks 
+		FROM secrets 
+		WHERE user_id = $1 AND revision > $2 
+		ORDER BY revision`
+
+	rows, err := tx.Query(ctx, query, userID, since)
+	if err != nil {
+		return changes, fmt.Errorf("failed to select from secrets: %w", err)
+	}
+
+	changes.Changed, err = pgx.CollectRows(rows, pgx.RowToStructByName[entity.SecretInfo])
+	if err != nil {
+		return changes, fmt.Errorf("failed to parse selected secrets: %w", err)
+	}
+
+	query = `
+	SELECT secret_id, revision 
+		FROM secret_tombstones 
+		WHERE user_id = $1 AND revision > $2 
+		ORDER BY revision`
+
+	rows, err = tx.Query(ctx, query, userID, since)
+	if err != nil {
+		return changes, fmt.Errorf("failed to select from secret_tombstones: %w", err)
+	}
+
+	changes.Deleted, err = pgx.CollectRows(rows, pgx.RowToStructByName[entity.SecretTombstone])
+	if err != nil {
+		return changes, fmt.Errorf("failed to parse selected tombstones: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return changes, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+)
+
+func TestSecret_GetChangesByUser(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, db)
+	secrets := NewSecret(db, nil, 0)
+
+	// Первое изменение получило ревизию и держит блокировку пользователя, но еще не закоммичено
+	tx, err := db.Pool().Begin(ctx)
+	require.Nil(t, err, "Begin transaction")
+	defer tx.Rollback(ctx)
+	_, err = lockUsage(ctx, tx, userID)
+	require.Nil(t, err, "Lock usage")
+	var first uint64
+	query := `
+	INSERT INTO secrets (user_id, data_type, name, meta_data, encrypted_data, encrypted_key, key_version, size)
+		VALUES ($1, 'text', 'first', '[]', 'data', 'key', 0, 4) RETURNING revision`
+	require.Nil(t, tx.QueryRow(ctx, query, userID).Scan(&first), "Insert first secret")
+
+	// Второе изменение ждет блокировку и получает ревизию после коммита первого
+	done := make(chan error, 1)
+	go func() {
+		done <- secrets.Create(ctx, testSecret(userID, "second"), entity.Quota{})
+	}()
+
+	changes, err := secrets.GetChangesByUser(ctx, userID, 0)
+	require.Nil(t, err, "Get changes before commit")
+	assert.Empty(t, changes.Changed, "Changes before commit")
+
+	select {
+	case err := <-done:
+		t.Fatalf("second change did not wait for the first one: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	require.Nil(t, tx.Commit(ctx), "Commit first change")
+	require.Nil(t, <-done, "Create second secret")
+
+	changes, err = secrets.GetChangesByUser(ctx, userID, 0)
+	require.Nil(t, err, "Get changes after commit")
+	require.Len(t, changes.Changed, 2, "Changes after commit")
+	assert.Equal(t, "first", changes.Changed[0].Name, "First change")
+	assert.Equal(t, first, changes.Changed[0].Revision, "First change revision")
+	assert.Equal(t, "second", changes.Changed[1].Name, "Second change")
+	assert.Greater(t, changes.Changed[1].Revision, first, "Second change revision")
+
+	// Удаление попадает в ленту после курсора, выданного клиенту
+	cursor := changes.Changed[1].Revision
+	require.Nil(t, secrets.DeleteForUser(ctx, changes.Changed[0].ID, userID), "Delete first secret")
+	changes, err = secrets.GetChangesByUser(ctx, userID, cursor)
+	require.Nil(t, err, "Get changes after delete")
+	assert.Empty(t, changes.Changed, "Changes after delete")
+	require.Len(t, changes.Deleted, 1, "Deleted after delete")
+	assert.Greater(t, changes.Deleted[0].Revision, cursor, "Deletion revision")
+}
+
+func TestSecret_UID(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, db)
+	secrets := NewSecret(db, nil, 0)
+	uid := "3f2b8c1e-5a4d-4e6f-9b7a-0c1d2e3f4a5b"
+
+	withUID := testSecret(userID, "with uid")
+	withUID.UID = uid
+	require.Nil(t, secrets.Create(ctx, withUID, entity.Quota{}), "Create secret with UID")
+	require.Nil(t, secrets.Create(ctx, testSecret(userID, "legacy"), entity.Quota{}), "Create legacy secret")
+
+	changes, err := secrets.GetChangesByUser(ctx, userID, 0)
+	require.Nil(t, err, "Get changes")
+	require.Len(t, changes.Changed, 2, "Created secrets")
+
+	secret, err := secrets.GetForUser(ctx, changes.Changed[0].ID, userID)
+	require.Nil(t, err, "Get secret with UID")
+	assert.Equal(t, uid, secret.UID, "Secret UID")
+
+	legacy, err := secrets.GetForUser(ctx, changes.Changed[1].ID, userID)
+	require.Nil(t, err, "Get legacy secret")
+	assert.Empty(t, legacy.UID, "Legacy secret UID")
+
+	// UID присваивается вместе с новыми данными секрету без UID
+	legacy.UID = "9c0e7a52-6d1b-4f38-a2e4-5b7c8d9e0f12"
+	require.Nil(t, secrets.UpdateForUser(ctx, legacy, entity.Quota{}), "Update legacy secret")
+	legacy, err = secrets.GetForUser(ctx, legacy.ID, userID)
+	require.Nil(t, err, "Get updated legacy secret")
+	assert.Equal(t, "9c0e7a52-6d1b-4f38-a2e4-5b7c8d9e0f12", legacy.UID, "Assigned UID")
+
+	// данные с другим UID отклоняются, UID секрета не меняется
+	other := secret
+	other.UID = "9c0e7a52-6d1b-4f38-a2e4-5b7c8d9e0f12"
+	err = secrets.UpdateForUser(ctx, other, entity.Quota{})
+	assert.ErrorIs(t, err, errors.ErrUIDMismatch, "Update secret with other UID")
+	secret, err = secrets.GetForUser(ctx, secret.ID, userID)
+	require.Nil(t, err, "Get secret")
+	assert.Equal(t, uid, secret.UID, "Kept UID")
+	assert.Equal(t, other.Version, secret.Version, "Kept version")
+
+	// тот же UID и пустой UID не мешают изменению
+	secret.UID = strings.ToUpper(uid)
+	require.Nil(t, secrets.UpdateForUser(ctx, secret, entity.Quota{}), "Update secret with same UID")
+	secret, err = secrets.GetForUser(ctx, secret.ID, userID)
+	require.Nil(t, err, "Get updated secret")
+	secret.UID = ""
+	require.Nil(t, secrets.UpdateForUser(ctx, secret, entity.Quota{}), "Update secret without UID")
+}
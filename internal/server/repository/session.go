@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+type Session struct {
+	pool *pgxpool.Pool
+}
+
+func NewSession(db *pg.DB) *Session {
+	return &Session{pool: db.Pool()}
+}
+
+// Create создает сессию и удаляет истекшие сессии пользователя.
+func (s *Session) Create(ctx context.Context, session entity.Session) (entity.Session, error) {
+	query := `DELETE FROM sessions WHERE user_id = $1 AND expires_at < NOW()`
+	if _, err := s.pool.Exec(ctx, query, session.UserID); err != nil {
+		return session, fmt.Errorf("failed to delete from sessions: %w", errors.Trasform(err))
+	}
+
+	query = `
+	INSERT INTO sessions (user_id, device, ip, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, last_seen_at`
+
+	row := s.pool.QueryRow(ctx, query, session.UserID, session.Device, session.IP, session.Expires)
+	err := row.Scan(&session.ID, &session.Created, &session.LastSeen)
+	if err != nil {
+		return session, fmt.Errorf("failed to insert to sessions: %w", errors.Trasform(err))
+	}
+
+	return session, nil
+}
+
+// Touch возвращает действующую сессию по id и отмечает время последнего запроса и IP клиента.
+// Время обновляется не чаще раза в минуту, чтобы не писать в БД на каждый запрос.
+// Если сессия удалена или истекла, возвращает errors.ErrNotFound.
+func (s *Session) Touch(ctx context.Context, id string, ip string) (entity.Session, error) {
+	if !validUUID(id) {
+		return entity.Session{}, errors.ErrNotFound
+	}
+
+	query := `
+	WITH touched AS (
+		UPDATE sessions SET last_seen_at = NOW(), ip = $2
+			WHERE id = $1 AND (last_seen_at < NOW() - INTERVAL '1 minute' OR ip <> $2)
+	)
+	SELECT id, user_id, device, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE id = $1 AND expires_at > NOW()`
+
+	rows, err := s.pool.Query(ctx, query, id, ip)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("failed to select from sessions: %w", err)
+	}
+
+	session, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Session])
+	if err != nil {
+		return session, errors.Trasform(err)
+	}
+
+	return session, nil
+}
+
+// ListByUser возвращает действующие сессии пользователя, начиная с последней активной.
+func (s *Session) ListByUser(ctx context.Context, userID string) ([]entity.Session, error) {
+	query := `
+	SELECT id, user_id, device, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select from sessions: %w", err)
+	}
+
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Session])
+	if err != nil {
+		return list, fmt.Errorf("failed to parse selected sessions: %w", err)
+	}
+
+	return list, nil
+}
+
+// DeleteForUser удаляет сессию пользователя вместе с ее refresh токенами.
+// Если сессия не найдена, возвращает errors.ErrNotFound.
+func (s *Session) DeleteForUser(ctx context.Context, id string, userID string) error {
+	if !validUUID(id) {
+		return errors.ErrNotFound
+	}
+
+	query := `DELETE FROM sessions WHERE id = $1 AND user_id = $2`
+	tag, err := s.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete from sessions: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
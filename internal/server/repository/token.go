@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+type RefreshToken struct {
+	pool *pgxpool.Pool
+}
+
+func NewRefreshToken(db *pg.DB) *RefreshToken {
+	return &RefreshToken{pool: db.Pool()}
+}
+
+// Create сохраняет первый токен сессии и удаляет истекшие токены пользователя.
+func (t *RefreshToken) Create(ctx context.Context, token entity.RefreshToken) error {
+	query := `DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < NOW()`
+	if _, err := t.pool.Exec(ctx, query, token.UserID); err != nil {
+		return fmt.Errorf("failed to delete from refresh_tokens: %w", errors.Trasform(err))
+	}
+
+	query = `INSERT INTO refresh_tokens (user_id, hash, session_id, expires_at) VALUES ($1, $2, $3, $4)`
+	_, err := t.pool.Exec(ctx, query, token.UserID, token.Hash, token.SessionID, token.Expires)
+	if err != nil {
+		return fmt.Errorf("failed to insert to refresh_tokens: %w", errors.Trasform(err))
+	}
+
+	return nil
+}
+
+// Rotate отзывает действующий токен с хэшем hash, сохраняет вместо него next в той же сессии
+// и продлевает сессию до истечения next. Возвращает next с заполненными пользователем и сессией.
+// Если токен не найден или истек, возвращает errors.ErrNotFound.
+// Повторное использование отозванного токена означает, что он украден,
+// поэтому сессия удаляется вместе со всеми ее токенами и возвращается errors.ErrRevoked.
+func (t *RefreshToken) Rotate(ctx context.Context, hash string, next entity.RefreshToken) (entity.RefreshToken, error) {
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return next, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var revoked, expired bool
+	query := `
+	SELECT user_id, session_id, revoked_at IS NOT NULL, expires_at < NOW()
+		FROM refresh_tokens WHERE hash = $1 FOR UPDATE`
+
+	err = tx.QueryRow(ctx, query, hash).Scan(&next.UserID, &next.SessionID, &revoked, &expired)
+	if err != nil {
+		return next, fmt.Errorf("failed to select from refresh_tokens: %w", errors.Trasform(err))
+	}
+
+	if revoked {
+		query = `DELETE FROM sessions WHERE id = $1`
+		if _, err = tx.Exec(ctx, query, next.SessionID); err != nil {
+			return next, fmt.Errorf("failed to delete from sessions: %w", errors.Trasform(err))
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return next, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return next, errors.ErrRevoked
+	}
+	if expired {
+		return next, errors.ErrNotFound
+	}
+
+	query = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE hash = $1`
+	if _, err = tx.Exec(ctx, query, hash); err != nil {
+		return next, fmt.Errorf("failed to update refresh_tokens: %w", errors.Trasform(err))
+	}
+
+	query = `INSERT INTO refresh_tokens (user_id, hash, session_id, expires_at) VALUES ($1, $2, $3, $4)`
+	_, err = tx.Exec(ctx, query, next.UserID, next.Hash, next.SessionID, next.Expires)
+	if err != nil {
+		return next, fmt.Errorf("failed to insert to refresh_tokens: %w", errors.Trasform(err))
+	}
+
+	query = `UPDATE sessions SET expires_at = $2 WHERE id = $1`
+	if _, err = tx.Exec(ctx, query, next.SessionID, next.Expires); err != nil {
+		return next, fmt.Errorf("failed to update sessions: %w", errors.Trasform(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return next, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return next, nil
+}
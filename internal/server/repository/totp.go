@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+type TOTP struct {
+	pool *pgxpool.Pool
+}
+
+func NewTOTP(db *pg.DB) *TOTP {
+	return &TOTP{pool: db.Pool()}
+}
+
+// Get возвращает TOTP секрет пользователя.
+// Если пользователь не настраивал двухфакторную аутентификацию, возвращает errors.ErrNotFound.
+func (t *TOTP) Get(ctx context.Context, userID string) (entity.TOTP, error) {
+	query := `SELECT user_id, secret, enabled, last_step FROM user_totp WHERE user_id = $1`
+
+	rows, err := t.pool.Query(ctx, query, userID)
+	if err != nil {
+		return entity.TOTP{}, fmt.Errorf("failed to select from user_totp: %w", err)
+	}
+
+	totp, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.TOTP])
+	if err != nil {
+		return totp, errors.Trasform(err)
+	}
+
+	return totp, nil
+}
+
+// Save сохраняет неподтвержденный секрет, заменяя предыдущий неподтвержденный.
+// Если двухфакторная аутентификация уже включена, возвращает errors.ErrNoRowsUpdated.
+func (t *TOTP) Save(ctx context.Context, totp entity.TOTP) error {
+	query := `
+	INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_step = 0, created_at = NOW()
+		WHERE user_totp.enabled = FALSE`
+
+	tag, err := t.pool.Exec(ctx, query, totp.UserID, totp.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to insert to user_totp: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNoRowsUpdated
+	}
+
+	return nil
+}
+
+// Enable включает двухфакторную аутентификацию, отмечает шаг step использованным
+// и заменяет коды восстановления хэшами codeHashes.
+// Если секрет не найден, уже подтвержден или код шага step уже использован,
+// возвращает errors.ErrNoRowsUpdated.
+func (t *TOTP) Enable(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE user_totp SET enabled = TRUE, last_step = $2
+		WHERE user_id = $1 AND enabled = FALSE AND last_step < $2`
+
+	tag, err := tx.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to update user_totp: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNoRowsUpdated
+	}
+
+	query = `DELETE FROM recovery_codes WHERE user_id = $1`
+	if _, err = tx.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete from recovery_codes: %w", errors.Trasform(err))
+	}
+
+	query = `INSERT INTO recovery_codes (user_id, hash) SELECT $1, UNNEST($2::VARCHAR[])`
+	if _, err = tx.Exec(ctx, query, userID, codeHashes); err != nil {
+		return fmt.Errorf("failed to insert to recovery_codes: %w", errors.Trasform(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseStep отмечает шаг step использованным, чтобы код нельзя было предъявить повторно.
+// Если код этого шага или более позднего уже принят, возвращает errors.ErrNoRowsUpdated.
+func (t *TOTP) UseStep(ctx context.Context, userID string, step int64) error {
+	query := `UPDATE user_totp SET last_step = $2 WHERE user_id = $1 AND enabled = TRUE AND last_step < $2`
+
+	tag, err := t.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to update user_totp: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNoRowsUpdated
+	}
+
+	return nil
+}
+
+// UseRecoveryCode отмечает код восстановления с хэшем hash использованным.
+// Если код не найден или уже использован, возвращает errors.ErrNotFound.
+func (t *TOTP) UseRecoveryCode(ctx context.Context, userID string, hash string) error {
+	query := `
+	UPDATE recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND hash = $2 AND used_at IS NULL`
+
+	tag, err := t.pool.Exec(ctx, query, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to update recovery_codes: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete выключает двухфакторную аутентификацию, удаляя секрет и коды восстановления.
+func (t *TOTP) Delete(ctx context.Context, userID string) error {
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM recovery_codes WHERE user_id = $1`
+	if _, err = tx.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete from recovery_codes: %w", errors.Trasform(err))
+	}
+
+	query = `DELETE FROM user_totp WHERE user_id = $1`
+	if _, err = tx.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete from user_totp: %w", errors.Trasform(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
+)
+
+type Upload struct {
+	pool  *pgxpool.Pool
+	blobs BlobStore
+}
+
+// NewUpload создает репозиторий загрузок по частям, части хранятся в blobs.
+func NewUpload(db *pg.DB, blobs BlobStore) *Upload {
+	return &Upload{pool: db.Pool(), blobs: blobs}
+}
+
+// Create создает сессию загрузки секрета по частям, возвращает ее ID.
+func (u *Upload) Create(ctx context.Context, upload entity.Upload) (string, error) {
+	var id string
+
+	query := `
+	INSERT INTO secret_uploads
+			(user_id, secret_id, version, data_type, name, meta_data, encrypted_key, key_version, uid) 
+		VALUES
+			($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8, NULLIF($9, '')::UUID) 
+		RETURNING id`
+
+	err := u.pool.QueryRow(
+		ctx,
+		query,
+		upload.UserID,
+		upload.SecretID,
+		upload.Version,
+		upload.DataType,
+		upload.Name,
+		upload.MetaData,
+		upload.EncryptedKey,
+		upload.KeyVersion,
+		upload.UID,
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert to secret_uploads: %w", errors.Trasform(err))
+	}
+
+	return id, nil
+}
+
+// PutChunk сохраняет часть n незавершенной загрузки пользователя, повторная загрузка части заменяет ее.
+// Если часть n следующая после полученных подряд, увеличивает счетчик полученных частей.
+// Данные части сохраняются в хранилище, в БД остается ключ объекта.
+// Части всех незавершенных загрузок пользователя учитываются в quota вместе с данными секретов.
+// Возвращает errors.ErrNotFound, если загрузка не найдена или уже завершена,
+// и errors.ErrQuotaExceeded, если часть не помещается в квоту пользователя.
+func (u *Upload) PutChunk(ctx context.Context, uploadID, userID string, n uint32, data []byte, quota entity.Quota) error {
+	if !validUUID(uploadID) {
+		return errors.ErrNotFound
+	}
+
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокировка использования пользователя упорядочивает проверки квоты его параллельных запросов
+	usage, err := lockUsage(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	var pending int64
+	query := `
+		SELECT COALESCE(SUM(c.size), 0) 
+		FROM secret_chunks c JOIN secret_uploads u ON u.id = c.upload_id 
+		WHERE u.user_id = $1 AND u.committed_at IS NULL AND NOT (c.upload_id = $2 AND c.n = $3)`
+	if err = tx.QueryRow(ctx, query, userID, uploadID, n).Scan(&pending); err != nil {
+		return fmt.Errorf("failed to select from secret_chunks: %w", err)
+	}
+	if !quota.Allows(usage, pending+int64(len(data)), 0) {
+		return errors.ErrQuotaExceeded
+	}
+
+	// Объект сохраняется до записи в БД, чтобы не появилось ссылки на несуществующие данные
+	blobKey, err := newChunkBlobKey(uploadID, n)
+	if err != nil {
+		return err
+	}
+	if err := u.blobs.Put(ctx, blobKey, data); err != nil {
+		return fmt.Errorf("failed to put chunk to blob store: %w", err)
+	}
+
+	query = `
+	WITH upload AS (
+		UPDATE secret_uploads 
+			SET received = CASE WHEN received = $3 THEN received + 1 ELSE received END 
+			WHERE id = $1 AND user_id = $2 AND committed_at IS NULL 
+			RETURNING id
+	)
+	INSERT INTO secret_chunks (upload_id, n, blob_key, size) 
+		SELECT id, $3, $4, $5 FROM upload 
+		ON CONFLICT (upload_id, n) DO UPDATE SET data = NULL, blob_key = EXCLUDED.blob_key, size = EXCLUDED.size`
+
+	tag, err := tx.Exec(ctx, query, uploadID, userID, n, blobKey, len(data))
+	if err == nil && tag.RowsAffected() == 0 {
+		err = errors.ErrNotFound
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		// Ошибка удаления не важна, оставшийся объект удалит сборщик мусора
+		_ = u.blobs.Delete(ctx, blobKey)
+		return fmt.Errorf("failed to insert to secret_chunks: %w", errors.Trasform(err))
+	}
+
+	return nil
+}
+
+// Status возвращает состояние загрузки пользователя.
+// Возвращает errors.ErrNotFound, если загрузка не найдена.
+func (u *Upload) Status(ctx context.Context, uploadID, userID string) (entity.UploadStatus, error) {
+	var status entity.UploadStatus
+
+	if !validUUID(uploadID) {
+		return status, errors.ErrNotFound
+	}
+
+	query := `
+		SELECT received, committed_at IS NOT NULL AS committed 
+		FROM secret_uploads 
+		WHERE id = $1 AND user_id = $2`
+	rows, err := u.pool.Query(ctx, query, uploadID, userID)
+	if err != nil {
+		return status, fmt.Errorf("failed to select from secret_uploads: %w", err)
+	}
+
+	status, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.UploadStatus])
+	if err != nil {
+		return status, errors.Trasform(err)
+	}
+
+	return status, nil
+}
+
+// Commit завершает загрузку пользователя из chunks частей: создает новый секрет
+// или заменяет данные существующего, если его версия совпадает с версией в загрузке.
+// Возвращает errors.ErrNotFound, если загрузка или заменяемый секрет не найдены,
+// errors.ErrIncomplete, если загружены не все части,
+// errors.ErrNoRowsUpdated, если заменяемый секрет уже изменили,
+// errors.ErrUIDMismatch, если UID загрузки не пустой и отличается от UID секрета,
+// и errors.ErrQuotaExceeded, если данные не помещаются в квоту пользователя.
+func (u *Upload) Commit(ctx context.Context, uploadID, userID string, chunks uint32, quota entity.Quota) error {
+	if !validUUID(uploadID) {
+		return errors.ErrNotFound
+	}
+
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	usage, err := lockUsage(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT 
+			id, user_id, COALESCE(secret_id, 0) AS secret_id, COALESCE(version, 0) AS version, 
+			data_type, name, meta_data, encrypted_key, key_version, COALESCE(uid::TEXT, '') AS uid 
+		FROM secret_uploads 
+		WHERE id = $1 AND user_id = $2 AND committed_at IS NULL 
+		FOR UPDATE`
+	rows, err := tx.Query(ctx, query, uploadID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to select from secret_uploads: %w", err)
+	}
+	upload, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Upload])
+	if err != nil {
+		return errors.Trasform(err)
+	}
+
+	// Части нумеруются с 0, значит должны быть загружены все части от 0 до chunks-1
+	var count, last, size int64
+	query = `SELECT COUNT(*), COALESCE(MAX(n), -1), COALESCE(SUM(size), 0) FROM secret_chunks WHERE upload_id = $1`
+	if err = tx.QueryRow(ctx, query, uploadID).Scan(&count, &last, &size); err != nil {
+		return fmt.Errorf("failed to select from secret_chunks: %w", err)
+	}
+	if chunks == 0 || count != int64(chunks) || last != int64(chunks)-1 {
+		return errors.ErrIncomplete
+	}
+
+	if upload.SecretID == 0 {
+		if !quota.Allows(usage, size, 1) {
+			return errors.ErrQuotaExceeded
+		}
+		err = u.createSecret(ctx, tx, upload, chunks, size)
+	} else {
+		err = u.replaceSecretData(ctx, tx, upload, chunks, size, usage, quota)
+	}
+	if err != nil {
+		return err
+	}
+
+	query = `UPDATE secret_uploads SET committed_at = NOW() WHERE id = $1`
+	if _, err = tx.Exec(ctx, query, uploadID); err != nil {
+		return fmt.Errorf("failed to update secret_uploads: %w", errors.Trasform(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// createSecret создает секрет, данные которого хранятся в частях загрузки.
+func (u *Upload) createSecret(ctx context.Context, tx pgx.Tx, upload entity.Upload, chunks uint32, size int64) error {
+	query := `
+	INSERT INTO secrets
+			(user_id, data_type, name, meta_data, encrypted_key, key_version, upload_id, chunks, size, uid) 
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::UUID)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		upload.UserID,
+		upload.DataType,
+		upload.Name,
+		upload.MetaData,
+		upload.EncryptedKey,
+		upload.KeyVersion,
+		upload.ID,
+		chunks,
+		size,
+		upload.UID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert to secrets: %w", errors.Trasform(err))
+	}
+
+	return nil
+}
+
+// replaceSecretData заменяет данные секрета частями загрузки и удаляет старые части.
+func (u *Upload) replaceSecretData(
+	ctx context.Context,
+	tx pgx.Tx,
+	upload entity.Upload,
+	chunks uint32,
+	size int64,
+	usage entity.Usage,
+	quota entity.Quota,
+) error {
+	var (
+		version     uint64
+		oldUploadID *string
+		oldSize     int64
+		uid         string
+	)
+
+	query := `
+	SELECT version, upload_id, size, COALESCE(uid::TEXT, '') 
+		FROM secrets 
+		WHERE id = $1 AND user_id = $2 
+		FOR UPDATE`
+	err := tx.QueryRow(ctx, query, upload.SecretID, upload.UserID).Scan(&version, &oldUploadID, &oldSize, &uid)
+	if err != nil {
+		return errors.Trasform(err)
+	}
+	if version != upload.Version {
+		return errors.ErrNoRowsUpdated
+	}
+	if !sameUID(uid, upload.UID) {
+		return errors.ErrUIDMismatch
+	}
+	if !quota.Allows(usage, size-oldSize, 0) {
+		return errors.ErrQuotaExceeded
+	}
+
+	query = `
+	UPDATE secrets 
+		SET name = $1, meta_data = $2, encrypted_data = NULL, encrypted_key = $3, key_version = $4,
+			blob_key = NULL, upload_id = $5, chunks = $6, size = $7, updated_at = NOW(),
+			uid = COALESCE(uid, NULLIF($9, '')::UUID),
+			revision = nextval('secret_revision_seq'), version = version + 1
+		WHERE id = $8`
+	_, err = tx.Exec(
+		ctx,
+		query,
+		upload.Name,
+		upload.MetaData,
+		upload.EncryptedKey,
+		upload.KeyVersion,
+		upload.ID,
+		chunks,
+		size,
+		upload.SecretID,
+		upload.UID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update secrets: %w", errors.Trasform(err))
+	}
+
+	if oldUploadID != nil {
+		if _, err = tx.Exec(ctx, `DELETE FROM secret_uploads WHERE id = $1`, *oldUploadID); err != nil {
+			return fmt.Errorf("failed to delete from secret_uploads: %w", errors.Trasform(err))
+		}
+	}
+
+	return nil
+}
+
+// validUUID проверяет, что id является UUID, иначе запрос к БД завершится ошибкой приведения типа.
+func validUUID(id string) bool {
+	var uuid pgtype.UUID
+	return uuid.Scan(id) == nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/blob"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+)
+
+// testUpload создает незавершенную загрузку файла пользователя userID и возвращает ее ID.
+func testUpload(t *testing.T, uploads *Upload, userID string) string {
+	id, err := uploads.Create(context.Background(), entity.Upload{
+		UserID:       userID,
+		DataType:     dto.SecretTypeFile,
+		Name:         "file",
+		MetaData:     "[]",
+		EncryptedKey: "key",
+		KeyVersion:   dto.KeyVersionAccount,
+	})
+	require.Nil(t, err, "Create upload")
+	return id
+}
+
+func TestUpload_PutChunkQuota(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, db)
+	store, err := blob.NewFileStore(t.TempDir())
+	require.Nil(t, err, "Create blob store")
+	uploads := NewUpload(db, store)
+	quota := entity.Quota{MaxBytes: 10}
+
+	first := testUpload(t, uploads, userID)
+	second := testUpload(t, uploads, userID)
+	require.Nil(t, uploads.PutChunk(ctx, first, userID, 0, []byte("123456"), quota), "Put first chunk")
+
+	// части незавершенных загрузок учитываются в квоте
+	err = uploads.PutChunk(ctx, second, userID, 0, []byte("123456"), quota)
+	assert.ErrorIs(t, err, errors.ErrQuotaExceeded, "Chunk over quota")
+
+	// повторная передача части заменяет ее размер, а не добавляется к нему
+	require.Nil(t, uploads.PutChunk(ctx, first, userID, 0, []byte("1234567890"), quota), "Replace chunk")
+	require.Nil(t, uploads.PutChunk(ctx, second, userID, 0, nil, quota), "Put empty chunk")
+}
+
+func TestBlob_ExpireUploads(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, db)
+	store, err := blob.NewFileStore(t.TempDir())
+	require.Nil(t, err, "Create blob store")
+	uploads := NewUpload(db, store)
+
+	stale := testUpload(t, uploads, userID)
+	fresh := testUpload(t, uploads, userID)
+	require.Nil(t, uploads.PutChunk(ctx, stale, userID, 0, []byte("chunk"), entity.Quota{}), "Put chunk")
+	_, err = db.Pool().Exec(ctx, `UPDATE secret_uploads SET created_at = NOW() - INTERVAL '2 days' WHERE id = $1`, stale)
+	require.Nil(t, err, "Age upload")
+
+	deleted, err := NewBlob(db).ExpireUploads(ctx, 24*time.Hour)
+	require.Nil(t, err, "Expire uploads")
+	assert.GreaterOrEqual(t, deleted, int64(1), "Expired uploads")
+
+	_, err = uploads.Status(ctx, stale, userID)
+	assert.ErrorIs(t, err, errors.ErrNotFound, "Stale upload")
+	_, err = uploads.Status(ctx, fresh, userID)
+	assert.Nil(t, err, "Fresh upload")
+}
+
+func TestUpload_CommitUIDMismatch(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, db)
+	store, err := blob.NewFileStore(t.TempDir())
+	require.Nil(t, err, "Create blob store")
+	uploads := NewUpload(db, store)
+	secrets := NewSecret(db, nil, 0)
+
+	stored := testSecret(userID, "with uid")
+	stored.UID = "3f2b8c1e-5a4d-4e6f-9b7a-0c1d2e3f4a5b"
+	require.Nil(t, secrets.Create(ctx, stored, entity.Quota{}), "Create secret")
+	changes, err := secrets.GetChangesByUser(ctx, userID, 0)
+	require.Nil(t, err, "Get changes")
+	require.Len(t, changes.Changed, 1, "Created secret")
+	secret, err := secrets.GetForUser(ctx, changes.Changed[0].ID, userID)
+	require.Nil(t, err, "Get secret")
+
+	id, err := uploads.Create(ctx, entity.Upload{
+		UserID:       userID,
+		SecretID:     secret.ID,
+		Version:      secret.Version,
+		UID:          "9c0e7a52-6d1b-4f38-a2e4-5b7c8d9e0f12",
+		DataType:     secret.DataType,
+		Name:         secret.Name,
+		MetaData:     "[]",
+		EncryptedKey: "key",
+		KeyVersion:   dto.KeyVersionAccount,
+	})
+	require.Nil(t, err, "Create upload")
+	require.Nil(t, uploads.PutChunk(ctx, id, userID, 0, []byte("chunk"), entity.Quota{}), "Put chunk")
+
+	err = uploads.Commit(ctx, id, userID, 1, entity.Quota{})
+	assert.ErrorIs(t, err, errors.ErrUIDMismatch, "Commit with other UID")
+
+	kept, err := secrets.GetForUser(ctx, secret.ID, userID)
+	require.Nil(t, err, "Get secret after commit")
+	assert.Equal(t, stored.UID, kept.UID, "Kept UID")
+	assert.Equal(t, secret.Version, kept.Version, "Kept version")
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+)
+
+// lockUsage блокирует до конца транзакции и возвращает счетчики использования хранилища пользователем.
+// Счетчики обновляет триггер на secrets. Транзакции, изменяющие секреты, блокируют счетчики первыми,
+// поэтому проверка квоты и изменение не пересекаются с другими изменениями секретов пользователя.
+// Ревизии секретов берутся из secret_revision_seq после блокировки, поэтому изменения секретов
+// пользователя коммитятся в порядке возрастания ревизий, и лента изменений их не пропускает.
+func lockUsage(ctx context.Context, tx pgx.Tx, userID string) (entity.Usage, error) {
+	var usage entity.Usage
+
+	query := `SELECT used_bytes, secret_count FROM users WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRow(ctx, query, userID).Scan(&usage.Bytes, &usage.Secrets)
+	if err != nil {
+		return usage, fmt.Errorf("failed to select from users: %w", errors.Trasform(err))
+	}
+
+	return usage, nil
+}
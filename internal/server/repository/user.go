@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/repository/pg"
@@ -21,10 +23,25 @@ func NewUser(db *pg.DB) *User {
 
 func (u *User) GetByID(ctx context.Context, id string) (entity.User, error) {
 	var user entity.User
-	query := `SELECT id, login, hash, auth_salt, encr_salt, created_at FROM users WHERE id = $1`
+	query := `
+	SELECT id, login, hash, auth_salt, auth_version, encr_salt, encr_kdf_time, encr_kdf_memory, encr_kdf_threads,
+		COALESCE(account_key, ''), created_at
+		FROM users WHERE id = $1`
 	row := u.pool.QueryRow(ctx, query, id)
 
-	err := row.Scan(&user.ID, &user.Login, &user.Hash, &user.AuthSalt, &user.EncrSalt, &user.Created)
+	err := row.Scan(
+		&user.ID,
+		&user.Login,
+		&user.Hash,
+		&user.AuthSalt,
+		&user.AuthVersion,
+		&user.EncrSalt,
+		&user.KDF.Time,
+		&user.KDF.Memory,
+		&user.KDF.Threads,
+		&user.AccountKey,
+		&user.Created,
+	)
 	if err != nil {
 		return entity.User{}, errors.Trasform(err)
 	}
@@ -34,10 +51,25 @@ func (u *User) GetByID(ctx context.Context, id string) (entity.User, error) {
 
 func (u *User) FindByLogin(ctx context.Context, login string) (entity.User, error) {
 	var user entity.User
-	query := `SELECT id, login, hash, auth_salt, encr_salt, created_at FROM users WHERE login = $1`
+	query := `
+	SELECT id, login, hash, auth_salt, auth_version, encr_salt, encr_kdf_time, encr_kdf_memory, encr_kdf_threads,
+		COALESCE(account_key, ''), created_at
+		FROM users WHERE login = $1`
 	row := u.pool.QueryRow(ctx, query, login)
 
-	err := row.Scan(&user.ID, &user.Login, &user.Hash, &user.AuthSalt, &user.EncrSalt, &user.Created)
+	err := row.Scan(
+		&user.ID,
+		&user.Login,
+		&user.Hash,
+		&user.AuthSalt,
+		&user.AuthVersion,
+		&user.EncrSalt,
+		&user.KDF.Time,
+		&user.KDF.Memory,
+		&user.KDF.Threads,
+		&user.AccountKey,
+		&user.Created,
+	)
 	if err != nil {
 		return entity.User{}, errors.Trasform(err)
 	}
@@ -45,9 +77,47 @@ func (u *User) FindByLogin(ctx context.Context, login string) (entity.User, erro
 	return user, nil
 }
 
+// AuthProfiles возвращает число пользователей с каждой версией схемы аутентификации
+// и параметрами мастер ключа, в постоянном порядке.
+func (u *User) AuthProfiles(ctx context.Context) ([]entity.AuthProfile, error) {
+	query := `
+	SELECT auth_version, encr_kdf_time, encr_kdf_memory, encr_kdf_threads, count(*)
+		FROM users
+		GROUP BY auth_version, encr_kdf_time, encr_kdf_memory, encr_kdf_threads
+		ORDER BY auth_version, encr_kdf_time, encr_kdf_memory, encr_kdf_threads`
+	rows, err := u.pool.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Trasform(err)
+	}
+
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.AuthProfile, error) {
+		var p entity.AuthProfile
+		err := row.Scan(&p.AuthVersion, &p.KDF.Time, &p.KDF.Memory, &p.KDF.Threads, &p.Count)
+		return p, err
+	})
+	if err != nil {
+		return nil, errors.Trasform(err)
+	}
+
+	return list, nil
+}
+
 func (u *User) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	query := `INSERT INTO users (login, hash, auth_salt, encr_salt) VALUES($1, $2, $3, $4) RETURNING id, created_at`
-	row := u.pool.QueryRow(ctx, query, user.Login, user.Hash, user.AuthSalt, user.EncrSalt)
+	query := `
+	INSERT INTO users (login, hash, auth_salt, auth_version, encr_salt, encr_kdf_time, encr_kdf_memory, encr_kdf_threads)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+	row := u.pool.QueryRow(
+		ctx,
+		query,
+		user.Login,
+		user.Hash,
+		user.AuthSalt,
+		int16(user.AuthVersion),
+		user.EncrSalt,
+		user.KDF.Time,
+		user.KDF.Memory,
+		user.KDF.Threads,
+	)
 
 	err := row.Scan(&user.ID, &user.Created)
 	if err != nil {
@@ -56,3 +126,196 @@ func (u *User) Create(ctx context.Context, user entity.User) (entity.User, error
 
 	return user, nil
 }
+
+// ChangePassword в одной транзакции заменяет хэш ключа аутентификации, соли и параметры KDF пользователя и ключи данных его секретов,
+// удаляет незавершенные загрузки, ключи которых зашифрованы старым мастер ключом,
+// и завершает остальные сессии. Если аккаунт не переведен на ключ аккаунта, change.Keys должны
+// содержать ключи всех секретов, а ключи восстановления заменяются или удаляются. Иначе заменяется
+// ключ аккаунта и переданные ключи, ключи восстановления открывают ключ аккаунта и не меняются.
+// Возвращает errors.ErrNoRowsUpdated, если пароль уже изменили или аккаунт перевели на ключ аккаунта,
+// и errors.ErrIncomplete, если change.Keys не совпадают с секретами пользователя.
+func (u *User) ChangePassword(ctx context.Context, change entity.PasswordChange) error {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Строка пользователя блокируется до конца транзакции,
+	// поэтому секреты пользователя не могут быть созданы или удалены параллельно
+	query := `
+	UPDATE users SET hash = $2, auth_salt = $3, encr_salt = $4, account_key = COALESCE(NULLIF($6, ''), account_key),
+		encr_kdf_time = $7, encr_kdf_memory = $8, encr_kdf_threads = $9, auth_version = $10
+		WHERE id = $1 AND hash = $5 AND (account_key IS NULL) = ($6 = '')`
+	tag, err := tx.Exec(
+		ctx,
+		query,
+		change.UserID,
+		change.Hash,
+		change.AuthSalt,
+		change.EncrSalt,
+		change.OldHash,
+		change.AccountKey,
+		change.KDF.Time,
+		change.KDF.Memory,
+		change.KDF.Threads,
+		int16(dto.AuthVersionKey),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update users: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNoRowsUpdated
+	}
+
+	if err = replaceKeys(ctx, tx, change.UserID, change.Keys, change.AccountKey == ""); err != nil {
+		return err
+	}
+
+	// Части незавершенных загрузок удалит сборщик мусора хранилища
+	query = `DELETE FROM secret_uploads WHERE user_id = $1 AND committed_at IS NULL AND key_version = 0`
+	if _, err = tx.Exec(ctx, query, change.UserID); err != nil {
+		return fmt.Errorf("failed to delete from secret_uploads: %w", errors.Trasform(err))
+	}
+
+	// Ключи восстановления, зашифрованные старым мастер ключом, больше не действуют
+	if change.AccountKey == "" {
+		if err = replaceRecovery(ctx, tx, change.UserID, change.Recovery); err != nil {
+			return err
+		}
+	}
+
+	// Refresh токены сессий удаляются каскадно
+	query = `DELETE FROM sessions WHERE user_id = $1 AND id IS DISTINCT FROM NULLIF($2, '')::UUID`
+	if _, err = tx.Exec(ctx, query, change.UserID, change.SessionID); err != nil {
+		return fmt.Errorf("failed to delete from sessions: %w", errors.Trasform(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetAuthKey заменяет хэш пароля пользователя хэшем ключа аутентификации.
+// Возвращает errors.ErrNoRowsUpdated, если пароль уже изменили или аккаунт уже переведен.
+func (u *User) SetAuthKey(ctx context.Context, change entity.AuthKeyChange) error {
+	query := `
+	UPDATE users SET hash = $2, auth_salt = $3, auth_version = $5
+		WHERE id = $1 AND hash = $4 AND auth_version = $6`
+	tag, err := u.pool.Exec(
+		ctx,
+		query,
+		change.UserID,
+		change.Hash,
+		change.AuthSalt,
+		change.OldHash,
+		int16(dto.AuthVersionKey),
+		int16(dto.AuthVersionPassword),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update users: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNoRowsUpdated
+	}
+
+	return nil
+}
+
+// SetAccountKey в одной транзакции сохраняет ключ аккаунта пользователя, заменяет ключи данных
+// всех его секретов и ключи восстановления. Незавершенные загрузки остаются: мастер ключ не меняется.
+// Возвращает errors.ErrNoRowsUpdated, если ключ аккаунта уже сохранен,
+// и errors.ErrIncomplete, если change.Keys не совпадают с секретами пользователя.
+func (u *User) SetAccountKey(ctx context.Context, change entity.AccountKeyChange) error {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE users SET account_key = $2 WHERE id = $1 AND account_key IS NULL`
+	tag, err := tx.Exec(ctx, query, change.UserID, change.AccountKey)
+	if err != nil {
+		return fmt.Errorf("failed to update users: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrNoRowsUpdated
+	}
+
+	if err = replaceKeys(ctx, tx, change.UserID, change.Keys, true); err != nil {
+		return err
+	}
+
+	if err = replaceRecovery(ctx, tx, change.UserID, change.Recovery); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// replaceKeys заменяет ключи данных секретов пользователя и их версии.
+// Секреты получают новую ревизию и версию, поэтому клиенты получат новые ключи при синхронизации,
+// а изменения, отправленные со старым ключом, будут отклонены как конфликт.
+// Строка пользователя должна быть заблокирована в транзакции tx.
+// Если all, keys должны содержать ключи всех секретов пользователя,
+// иначе возвращает errors.ErrIncomplete, как и для ключей несуществующих секретов.
+func replaceKeys(ctx context.Context, tx pgx.Tx, userID string, keys []entity.SecretKey, all bool) error {
+	ids := make([]int64, 0, len(keys))
+	encryptedKeys := make([]string, 0, len(keys))
+	versions := make([]int16, 0, len(keys))
+	for _, k := range keys {
+		ids = append(ids, int64(k.SecretID))
+		encryptedKeys = append(encryptedKeys, k.EncryptedKey)
+		versions = append(versions, int16(k.Version))
+	}
+
+	if all {
+		var count int64
+		query := `SELECT COUNT(*) FROM secrets WHERE user_id = $1`
+		if err := tx.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to select from secrets: %w", err)
+		}
+		if count != int64(len(keys)) {
+			return errors.ErrIncomplete
+		}
+	}
+
+	query := `
+	UPDATE secrets s SET encrypted_key = k.encrypted_key, key_version = k.key_version,
+			revision = nextval('secret_revision_seq'), version = s.version + 1
+		FROM UNNEST($2::BIGINT[], $3::VARCHAR[], $4::SMALLINT[]) AS k(id, encrypted_key, key_version)
+		WHERE s.id = k.id AND s.user_id = $1`
+	tag, err := tx.Exec(ctx, query, userID, ids, encryptedKeys, versions)
+	if err != nil {
+		return fmt.Errorf("failed to update secrets: %w", errors.Trasform(err))
+	}
+	if tag.RowsAffected() != int64(len(keys)) {
+		return errors.ErrIncomplete
+	}
+
+	return nil
+}
+
+// replaceRecovery заменяет ключи восстановления пользователя, если recovery не указаны, удаляет их.
+func replaceRecovery(ctx context.Context, tx pgx.Tx, userID string, recovery *entity.RecoveryKey) error {
+	var err error
+
+	if recovery != nil {
+		query := `UPDATE user_recovery_keys SET master_key = $2, recovery_key = $3 WHERE user_id = $1`
+		_, err = tx.Exec(ctx, query, userID, recovery.MasterKey, recovery.RecoveryKey)
+	} else {
+		query := `DELETE FROM user_recovery_keys WHERE user_id = $1`
+		_, err = tx.Exec(ctx, query, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user_recovery_keys: %w", errors.Trasform(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+)
+
+func TestUser_AuthProfiles(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	users := NewUser(db)
+
+	// параметры, которых нет у других пользователей тестовой БД
+	b, err := crypto.GenerateRandomBytes(2)
+	require.Nil(t, err, "Generate kdf time")
+	kdf := entity.KDFParams{Time: 1000 + uint32(b[0])<<8 + uint32(b[1]), Memory: 64 * 1024, Threads: 1}
+	for range 2 {
+		login, err := crypto.GenerateRandomBytes(8)
+		require.Nil(t, err, "Generate login")
+		_, err = users.Create(ctx, entity.User{
+			Login:       "test-" + hex.EncodeToString(login),
+			Hash:        "hash",
+			AuthSalt:    "salt",
+			AuthVersion: dto.AuthVersionKey,
+			EncrSalt:    "salt",
+			KDF:         kdf,
+		})
+		require.Nil(t, err, "Create user")
+	}
+
+	profiles, err := users.AuthProfiles(ctx)
+	require.Nil(t, err, "Get auth profiles")
+	assert.Contains(t, profiles, entity.AuthProfile{AuthVersion: dto.AuthVersionKey, KDF: kdf, Count: 2}, "Profile of created users")
+}
+
+func TestUser_SetAccountKey(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userID := testUser(t, db)
+	secrets := NewSecret(db, nil, 0)
+
+	require.Nil(t, secrets.Create(ctx, testSecret(userID, "secret"), entity.Quota{}), "Create secret")
+	changes, err := secrets.GetChangesByUser(ctx, userID, 0)
+	require.Nil(t, err, "Get changes")
+	require.Len(t, changes.Changed, 1, "Created secrets")
+	before := changes.Changed[0]
+
+	err = NewUser(db).SetAccountKey(ctx, entity.AccountKeyChange{
+		UserID:     userID,
+		AccountKey: "account key",
+		Keys:       []entity.SecretKey{{SecretID: before.ID, EncryptedKey: "new key", Version: dto.KeyVersionAccount}},
+	})
+	require.Nil(t, err, "Set account key")
+
+	// секрет с перешифрованным ключом попадает в ленту изменений и получает новую версию
+	changes, err = secrets.GetChangesByUser(ctx, userID, before.Revision)
+	require.Nil(t, err, "Get changes after key replacement")
+	require.Len(t, changes.Changed, 1, "Changes after key replacement")
+	assert.Greater(t, changes.Changed[0].Revision, before.Revision, "Revision after key replacement")
+	assert.Equal(t, before.Version+1, changes.Changed[0].Version, "Version after key replacement")
+
+	secret, err := secrets.GetForUser(ctx, before.ID, userID)
+	require.Nil(t, err, "Get secret")
+	assert.Equal(t, "new key", secret.EncryptedKey, "Replaced key")
+}
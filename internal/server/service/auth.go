@@ -3,12 +3,19 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/subtle"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,6 +24,7 @@ import (
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
 	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
 	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
 )
 
@@ -24,6 +32,23 @@ type UserRepository interface {
 	Create(ctx context.Context, user entity.User) (entity.User, error)
 	FindByLogin(ctx context.Context, login string) (entity.User, error)
 	GetByID(ctx context.Context, id string) (entity.User, error)
+	// SetAuthKey переводит аккаунт с хэша пароля на хэш ключа аутентификации.
+	SetAuthKey(ctx context.Context, change entity.AuthKeyChange) error
+	// ChangePassword заменяет хэш пароля, соли и ключи данных секретов пользователя.
+	ChangePassword(ctx context.Context, change entity.PasswordChange) error
+	// SetAccountKey сохраняет ключ аккаунта и заменяет ключи данных секретов пользователя.
+	SetAccountKey(ctx context.Context, change entity.AccountKeyChange) error
+	// AuthProfiles возвращает распределение пользователей по версии схемы аутентификации
+	// и параметрам мастер ключа.
+	AuthProfiles(ctx context.Context) ([]entity.AuthProfile, error)
+}
+
+// Длина refresh токена в байтах
+const refreshTokenLen = 32
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token entity.RefreshToken) error
+	Rotate(ctx context.Context, hash string, next entity.RefreshToken) (entity.RefreshToken, error)
 }
 
 type Logger interface {
@@ -33,28 +58,122 @@ type Logger interface {
 // Auth сервис для регистрации аутентификации и авторизации
 type Auth struct {
 	repository UserRepository
+	sessions   SessionRepository
+	tokens     RefreshTokenRepository
+	totp       TOTPRepository
 	logger     Logger
 	pub        *rsa.PublicKey
 	priv       *rsa.PrivateKey
 	tokenTTL   time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+	// Ключ для вычисления соли несуществующих пользователей в PreLogin
+	preLoginKey []byte
+	// Пользователь, на котором проверяются учетные данные при входе с несуществующим логином
+	fakeUser entity.User
+	// Распределение пользователей по версии схемы аутентификации и параметрам мастер ключа,
+	// по нему выбираются ответы PreLogin для несуществующих логинов
+	profilesMu sync.RWMutex
+	profiles   []entity.AuthProfile
+	// Функции проверки учетных данных
+	hashes authHashes
+	// Неудачные попытки входа и правила блокировки, без репозитория блокировка выключена
+	attempts LoginAttemptRepository
+	lockout  entity.LockoutPolicy
+	// Слоты для одновременных вычислений Argon2id
+	hashSlots chan struct{}
+	// Ключ кодов приглашения, без ключа регистрация открыта для всех
+	inviteKey []byte
 }
 
 func NewAuth(
 	r UserRepository,
+	s SessionRepository,
+	t RefreshTokenRepository,
+	f TOTPRepository,
 	l Logger,
 	jwtPub *rsa.PublicKey,
 	jwtPriv *rsa.PrivateKey,
 	tokenTTL time.Duration,
+	refreshTTL time.Duration,
 ) *Auth {
-	return &Auth{repository: r, logger: l, pub: jwtPub, priv: jwtPriv, tokenTTL: tokenTTL}
+	return &Auth{
+		repository:  r,
+		sessions:    s,
+		tokens:      t,
+		totp:        f,
+		logger:      l,
+		pub:         jwtPub,
+		priv:        jwtPriv,
+		tokenTTL:    tokenTTL,
+		refreshTTL:  refreshTTL,
+		now:         time.Now,
+		preLoginKey: newPreLoginKey(jwtPriv),
+		fakeUser:    newFakeUser(),
+		hashes:      defaultAuthHashes(),
+		hashSlots:   make(chan struct{}, runtime.NumCPU()),
+	}
+}
+
+// authHashes функции хэширования и сравнения, которыми проверяются учетные данные.
+// Несуществующие логины проверяются теми же функциями, что и существующие,
+// в тестах функции подменяются счетчиками.
+type authHashes struct {
+	deriveKey    func(password, salt []byte) ([]byte, error)
+	hashKey      func(authKey string, salt []byte) string
+	hashRecovery func(authKey string) string
+	compare      func(x, y []byte) int
+}
+
+func defaultAuthHashes() authHashes {
+	return authHashes{
+		deriveKey:    crypto.DeriveKey,
+		hashKey:      hashAuthKey,
+		hashRecovery: hashRecoveryAuthKey,
+		compare:      subtle.ConstantTimeCompare,
+	}
+}
+
+// newFakeUser пользователь со случайными солью и хэшем, ни пароль, ни ключ аутентификации
+// к хэшу подобрать нельзя. Соль аутентификации клиенту не отдается, поэтому одного такого
+// пользователя достаточно для всех несуществующих логинов, версия схемы аутентификации
+// для каждого логина берется из fakeProfile.
+func newFakeUser() entity.User {
+	authSalt, _ := crypto.GenerateRandomBytes(crypto.SaltLen)
+	hash, _ := crypto.GenerateRandomBytes(sha256.Size)
+
+	return entity.User{
+		Hash:        base64.RawStdEncoding.EncodeToString(hash),
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		AuthVersion: dto.AuthVersionKey,
+	}
+}
+
+// newPreLoginKey выводит ключ для соли несуществующих пользователей из приватного ключа JWT,
+// чтобы соль не менялась после перезапуска сервера. Без ключа JWT используется случайный ключ.
+func newPreLoginKey(priv *rsa.PrivateKey) []byte {
+	if priv == nil {
+		key, _ := crypto.GenerateRandomBytes(sha256.Size)
+		return key
+	}
+
+	mac := hmac.New(sha256.New, x509.MarshalPKCS1PrivateKey(priv))
+	mac.Write([]byte("gophkeeper prelogin salt"))
+	return mac.Sum(nil)
 }
 
-// Register регистрация пользователя по логину с паролем
+// Register регистрация пользователя по логину и ключу аутентификации.
+// Соль и параметры Argon2id мастер ключа выбирает клиент, пароль сервер не получает.
+// Если задан ключ приглашений, без верного для логина кода приглашения возвращает
+// srvErrors.ErrAuthInvalidInvite, не проверяя, занят ли логин.
 func (a *Auth) Register(ctx context.Context, c dto.Credentials) (resp dto.AuthResponse, err error) {
 	cr := trimCredentials(c)
 	if err := cr.Validate(); err != nil {
 		return resp, fmt.Errorf("%w: %w", srvErrors.ErrAuthInvalidCredentials, err)
 	}
+	if a.inviteKey != nil && !validInvite(a.inviteKey, cr.Login, cr.Invite) {
+		return resp, srvErrors.ErrAuthInvalidInvite
+	}
 
 	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
 	if err != nil {
@@ -62,24 +181,14 @@ func (a *Auth) Register(ctx context.Context, c dto.Credentials) (resp dto.AuthRe
 		return resp, srvErrors.ErrUnexpected
 	}
 
-	hash, err := crypto.DeriveKey([]byte(c.Password), authSalt)
-	if err != nil {
-		a.logger.Error("failed to hash password", err)
-		return resp, srvErrors.ErrUnexpected
-	}
-
-	encrSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
-	if err != nil {
-		a.logger.Error("failed to generate encriptin salt", err)
-		return resp, srvErrors.ErrUnexpected
-	}
-	base64EcrSalt := base64.RawStdEncoding.EncodeToString(encrSalt)
-
+	kdf := kdfParams(cr.KDF)
 	user := entity.User{
-		Login:    c.Login,
-		Hash:     base64.RawStdEncoding.EncodeToString(hash),
-		AuthSalt: base64.RawStdEncoding.EncodeToString(authSalt),
-		EncrSalt: base64EcrSalt,
+		Login:       cr.Login,
+		Hash:        hashAuthKey(cr.AuthKey, authSalt),
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		AuthVersion: dto.AuthVersionKey,
+		EncrSalt:    cr.EncrSalt,
+		KDF:         kdf,
 	}
 	user, err = a.repository.Create(ctx, user)
 
@@ -93,65 +202,520 @@ func (a *Auth) Register(ctx context.Context, c dto.Credentials) (resp dto.AuthRe
 		}
 	}
 
-	token, err := a.generateToken(user)
+	resp.Token, resp.RefreshToken, err = a.issueTokens(ctx, user, cr.Device)
 	if err != nil {
 		return resp, err
 	}
 
-	resp.Token = token
-	resp.EncrSalt = base64EcrSalt
+	resp.EncrSalt = cr.EncrSalt
+	resp.KDF = kdfResponse(kdf)
 	return resp, nil
 }
 
-// Login вход пользователя в систему по логину с паролем
+// PreLogin возвращает соль и параметры Argon2id мастер ключа пользователя и версию схемы
+// аутентификации, чтобы клиент вычислил ключ аутентификации до входа. Для несуществующего
+// логина возвращает постоянную для этого логина соль и выбранные по логину параметры
+// и версию из распределения существующих пользователей, чтобы по ответу нельзя было
+// определить, существует ли пользователь.
+func (a *Auth) PreLogin(ctx context.Context, req dto.PreLoginRequest) (dto.PreLoginResponse, error) {
+	login := strings.TrimSpace(req.Login)
+
+	user, err := a.repository.FindByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return a.fakePreLogin(login), nil
+		}
+		a.logger.Error("failed to find user", err)
+		return dto.PreLoginResponse{}, srvErrors.ErrUnexpected
+	}
+
+	return preLoginResponse(user), nil
+}
+
+// PasswordParams возвращает соль и параметры Argon2id мастер ключа текущего пользователя
+// и версию схемы аутентификации, клиенту они нужны для подтверждения пароля при его смене.
+func (a *Auth) PasswordParams(ctx context.Context) (dto.PreLoginResponse, error) {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		a.logger.Error("failed to get user id", err)
+		return dto.PreLoginResponse{}, srvErrors.ErrUnexpected
+	}
+
+	user, err := a.repository.GetByID(ctx, userID)
+	if err != nil {
+		a.logger.Error("failed to get user", err)
+		return dto.PreLoginResponse{}, srvErrors.ErrUnexpected
+	}
+
+	return preLoginResponse(user), nil
+}
+
+// fakePreLogin параметры для несуществующего пользователя: соль и профиль вычисляются из логина,
+// поэтому повторные запросы получают тот же ответ, как и для существующего пользователя.
+func (a *Auth) fakePreLogin(login string) dto.PreLoginResponse {
+	salt, profile := a.fakeProfile(login)
+
+	return dto.PreLoginResponse{
+		EncrSalt:    base64.RawStdEncoding.EncodeToString(salt),
+		KDF:         kdfResponse(profile.KDF),
+		AuthVersion: profile.AuthVersion,
+	}
+}
+
+// fakeProfile соль мастер ключа и профиль несуществующего логина. Профиль выбирается по HMAC логина
+// с вероятностью, равной доле пользователей с ним, поэтому версия схемы аутентификации и параметры
+// Argon2id распределены так же, как у существующих пользователей. Пока распределение
+// не загружено, используются ключ аутентификации и параметры по умолчанию.
+func (a *Auth) fakeProfile(login string) ([]byte, entity.AuthProfile) {
+	mac := hmac.New(sha256.New, a.preLoginKey)
+	mac.Write([]byte(login))
+	sum := mac.Sum(nil)
+	salt := sum[:crypto.SaltLen]
+
+	a.profilesMu.RLock()
+	defer a.profilesMu.RUnlock()
+
+	var total uint64
+	for _, p := range a.profiles {
+		total += p.Count
+	}
+	if total > 0 {
+		n := binary.BigEndian.Uint64(sum[crypto.SaltLen:]) % total
+		for _, p := range a.profiles {
+			if n < p.Count {
+				return salt, p
+			}
+			n -= p.Count
+		}
+	}
+
+	return salt, entity.AuthProfile{AuthVersion: dto.AuthVersionKey, KDF: kdfParams(nil)}
+}
+
+// RefreshProfiles загружает распределение пользователей по версии схемы аутентификации
+// и параметрам мастер ключа для ответов несуществующим логинам.
+func (a *Auth) RefreshProfiles(ctx context.Context) error {
+	profiles, err := a.repository.AuthProfiles(ctx)
+	if err != nil {
+		a.logger.Error("failed to get auth profiles", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	a.profilesMu.Lock()
+	a.profiles = profiles
+	a.profilesMu.Unlock()
+	return nil
+}
+
+// RunProfiles запускает RefreshProfiles сразу и затем каждые interval, пока не отменен ctx.
+func (a *Auth) RunProfiles(ctx context.Context, interval time.Duration) {
+	// Ошибки уже записаны в лог, до следующей попытки остается прежнее распределение
+	_ = a.RefreshProfiles(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = a.RefreshProfiles(ctx)
+		}
+	}
+}
+
+func preLoginResponse(user entity.User) dto.PreLoginResponse {
+	return dto.PreLoginResponse{
+		EncrSalt:    user.EncrSalt,
+		KDF:         kdfResponse(user.KDF),
+		AuthVersion: user.AuthVersion,
+	}
+}
+
+// Login вход пользователя в систему по логину и ключу аутентификации. Для аккаунта,
+// который еще не переведен на ключ аутентификации, проверяется пароль, и если передан
+// ключ аутентификации, аккаунт переводится на него.
+// Если у пользователя включена двухфакторная аутентификация,
+// без кода возвращает srvErrors.ErrAuthOTPRequired.
 func (a *Auth) Login(ctx context.Context, c dto.Credentials) (resp dto.AuthResponse, err error) {
 	cr := trimCredentials(c)
+	// Такой логин нельзя зарегистрировать, а в login_attempts он не помещается
+	if err := cr.ValidateLogin(); err != nil {
+		return resp, fmt.Errorf("%w: %w", srvErrors.ErrAuthInvalidCredentials, err)
+	}
+
+	if err := a.checkLockout(ctx, cr.Login); err != nil {
+		return resp, err
+	}
+
+	user, err := a.authenticate(ctx, cr, c.Password)
+	if err != nil {
+		return resp, err
+	}
+	a.resetFailures(ctx, cr.Login)
+
+	if user.AuthVersion == dto.AuthVersionPassword && cr.AuthKey != "" {
+		a.upgradeAuthKey(ctx, user, cr.AuthKey)
+	}
+
+	resp.Token, resp.RefreshToken, err = a.issueTokens(ctx, user, cr.Device)
+	if err != nil {
+		return resp, err
+	}
 
+	resp.EncrSalt = user.EncrSalt
+	resp.KDF = kdfResponse(user.KDF)
+	resp.AccountKey = user.AccountKey
+	return resp, nil
+}
+
+// authenticate находит пользователя по логину и проверяет его учетные данные и код
+// двухфакторной аутентификации, password передается без обрезки пробелов.
+// Для несуществующего логина учетные данные проверяются на a.fakeUser по той схеме,
+// которую PreLogin сообщает для этого логина, то есть теми же функциями и с той же
+// работой, что у существующего пользователя, чтобы по времени ответа нельзя было
+// определить, существует ли пользователь.
+func (a *Auth) authenticate(ctx context.Context, cr dto.Credentials, password string) (entity.User, error) {
 	user, err := a.repository.FindByLogin(ctx, cr.Login)
 	if err != nil {
 		if errors.Is(err, repErrors.ErrNotFound) {
-			return resp, srvErrors.ErrAuthInvalidCredentials
-		} else {
-			a.logger.Error("failed to find user", err)
-			return resp, srvErrors.ErrUnexpected
+			// результат не важен, вход для несуществующего логина всегда неудачный
+			fake := a.fakeUser
+			_, profile := a.fakeProfile(cr.Login)
+			fake.AuthVersion = profile.AuthVersion
+			_ = a.checkCredentials(ctx, fake, password, cr.AuthKey)
+			return entity.User{}, srvErrors.ErrAuthInvalidCredentials
+		}
+		a.logger.Error("failed to find user", err)
+		return user, srvErrors.ErrUnexpected
+	}
+
+	if err := a.checkCredentials(ctx, user, password, cr.AuthKey); err != nil {
+		return user, err
+	}
+
+	if err := a.checkSecondFactor(ctx, user.ID, cr.OTP); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// ChangePassword меняет пароль текущего пользователя. Ключ аккаунта или, если аккаунт
+// еще не переведен на ключ аккаунта, ключи данных секретов перешифрованы на клиенте
+// новым мастер ключом, они заменяются вместе с хэшем пароля и солями в одной транзакции.
+// Остальные сессии пользователя завершаются. Если req.Keys не совпадают с секретами пользователя
+// или req.AccountKey не соответствует аккаунту, возвращает srvErrors.ErrPasswordKeysMismatch.
+func (a *Auth) ChangePassword(ctx context.Context, req *dto.PasswordChangeRequest) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		a.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	sessionID, err := srvContext.SessionID(ctx)
+	if err != nil {
+		a.logger.Error("failed to get session id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	user, err := a.repository.GetByID(ctx, userID)
+	if err != nil {
+		a.logger.Error("failed to get user", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	if err := a.checkCredentials(ctx, user, req.Password, req.AuthKey); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", srvErrors.ErrPasswordInvalidRequest, err)
+	}
+
+	// Клиент должен перешифровать ключи по схеме, по которой они хранятся
+	if (user.AccountKey == "") != (req.AccountKey == "") {
+		return srvErrors.ErrPasswordKeysMismatch
+	}
+
+	change, err := a.newPasswordChange(user, req.NewAuthKey, req.EncrSalt, req.KDF, req.AccountKey, req.Keys)
+	if err != nil {
+		return err
+	}
+	change.SessionID = sessionID
+	if req.Recovery != nil {
+		change.Recovery = &entity.RecoveryKey{
+			UserID:      userID,
+			MasterKey:   req.Recovery.MasterKey,
+			RecoveryKey: req.Recovery.RecoveryKey,
+		}
+	}
+
+	err = a.repository.ChangePassword(ctx, change)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNoRowsUpdated):
+			// пароль изменили или аккаунт перевели на ключ аккаунта параллельно,
+			// текущий пароль или ключи уже не подходят
+			return srvErrors.ErrAuthInvalidCredentials
+		case errors.Is(err, repErrors.ErrIncomplete):
+			return srvErrors.ErrPasswordKeysMismatch
+		default:
+			a.logger.Error("failed to change password", err)
+			return srvErrors.ErrUnexpected
 		}
 	}
 
+	return nil
+}
+
+// newPasswordChange хэширует новый ключ аутентификации с новой солью и собирает смену пароля пользователя.
+// Если передан ключ аккаунта, ключи данных перешифрованы им, иначе новым мастер ключом.
+func (a *Auth) newPasswordChange(
+	user entity.User,
+	authKey string,
+	encrSalt string,
+	kdf *dto.KDFParams,
+	accountKey string,
+	keys []dto.SecretKey,
+) (entity.PasswordChange, error) {
+	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	if err != nil {
+		a.logger.Error("failed to generate auth salt", err)
+		return entity.PasswordChange{}, srvErrors.ErrUnexpected
+	}
+
+	change := entity.PasswordChange{
+		UserID:     user.ID,
+		OldHash:    user.Hash,
+		Hash:       hashAuthKey(authKey, authSalt),
+		AuthSalt:   base64.RawStdEncoding.EncodeToString(authSalt),
+		EncrSalt:   encrSalt,
+		KDF:        kdfParams(kdf),
+		AccountKey: accountKey,
+		Keys:       newSecretKeys(keys, accountKey != ""),
+	}
+
+	return change, nil
+}
+
+// SetAccountKey переводит аккаунт текущего пользователя на ключ аккаунта: сохраняет ключ аккаунта,
+// зашифрованный мастер ключом, и ключи данных всех секретов, перешифрованные на клиенте ключом аккаунта,
+// в одной транзакции. Если ключ аккаунта уже сохранен, возвращает srvErrors.ErrAccountKeyExists,
+// если req.Keys не совпадают с секретами пользователя, возвращает srvErrors.ErrPasswordKeysMismatch.
+func (a *Auth) SetAccountKey(ctx context.Context, req *dto.AccountKeyRequest) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		a.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", srvErrors.ErrAccountKeyInvalidRequest, err)
+	}
+
+	change := entity.AccountKeyChange{
+		UserID:     userID,
+		AccountKey: req.AccountKey,
+		Keys:       newSecretKeys(req.Keys, true),
+	}
+	if req.Recovery != nil {
+		change.Recovery = &entity.RecoveryKey{
+			UserID:      userID,
+			MasterKey:   req.Recovery.MasterKey,
+			RecoveryKey: req.Recovery.RecoveryKey,
+		}
+	}
+
+	err = a.repository.SetAccountKey(ctx, change)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNoRowsUpdated):
+			return srvErrors.ErrAccountKeyExists
+		case errors.Is(err, repErrors.ErrIncomplete):
+			return srvErrors.ErrPasswordKeysMismatch
+		default:
+			a.logger.Error("failed to set account key", err)
+			return srvErrors.ErrUnexpected
+		}
+	}
+
+	return nil
+}
+
+// kdfParams возвращает параметры Argon2id из запроса или, если клиент их не передал,
+// параметры по умолчанию, с которыми вычисляли мастер ключ клиенты до их появления.
+func kdfParams(p *dto.KDFParams) entity.KDFParams {
+	if p == nil {
+		return entity.KDFParams(crypto.DefaultKDFParams())
+	}
+	return entity.KDFParams(*p)
+}
+
+// kdfResponse преобразует параметры Argon2id пользователя для ответа.
+func kdfResponse(p entity.KDFParams) *dto.KDFParams {
+	resp := dto.KDFParams(p)
+	return &resp
+}
+
+// newSecretKeys преобразует ключи данных из запроса, версия схемы ключей определяется тем,
+// зашифрованы ли они ключом аккаунта.
+func newSecretKeys(keys []dto.SecretKey, withAccountKey bool) []entity.SecretKey {
+	version := dto.KeyVersionMaster
+	if withAccountKey {
+		version = dto.KeyVersionAccount
+	}
+
+	list := make([]entity.SecretKey, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, entity.SecretKey{SecretID: k.ID, EncryptedKey: k.Key, Version: version})
+	}
+
+	return list
+}
+
+// checkCredentials сверяет с хэшем пользователя ключ аутентификации
+// или, если аккаунт еще не переведен на ключ аутентификации, пароль.
+func (a *Auth) checkCredentials(ctx context.Context, user entity.User, password, authKey string) error {
+	if user.AuthVersion == dto.AuthVersionPassword {
+		return a.checkPassword(ctx, user, password)
+	}
+
 	authSalt, err := base64.RawStdEncoding.DecodeString(user.AuthSalt)
 	if err != nil {
 		a.logger.Error("failed decode user auth salt", err)
-		return resp, srvErrors.ErrUnexpected
+		return srvErrors.ErrUnexpected
+	}
+
+	if a.hashes.compare([]byte(user.Hash), []byte(a.hashes.hashKey(authKey, authSalt))) != 1 {
+		return srvErrors.ErrAuthInvalidCredentials
+	}
+
+	return nil
+}
+
+// upgradeAuthKey переводит аккаунт с проверенным паролем на ключ аутентификации.
+// Вход от этого не зависит, поэтому ошибки только логируются.
+func (a *Auth) upgradeAuthKey(ctx context.Context, user entity.User, authKey string) {
+	if dto.ValidateAuthKey(authKey) != nil {
+		return
+	}
+
+	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	if err != nil {
+		a.logger.Error("failed to generate auth salt", err)
+		return
+	}
+
+	err = a.repository.SetAuthKey(ctx, entity.AuthKeyChange{
+		UserID:   user.ID,
+		OldHash:  user.Hash,
+		Hash:     hashAuthKey(authKey, authSalt),
+		AuthSalt: base64.RawStdEncoding.EncodeToString(authSalt),
+	})
+	// пароль изменили параллельно, аккаунт переведет следующий вход
+	if err != nil && !errors.Is(err, repErrors.ErrNoRowsUpdated) {
+		a.logger.Error("failed to set auth key", err)
+	}
+}
+
+// hashAuthKey хэш ключа аутентификации для хранения в БД. Ключ выведен из мастер ключа
+// медленным Argon2id на клиенте, поэтому повторно медленно хэшировать его не нужно.
+func hashAuthKey(authKey string, authSalt []byte) string {
+	h := sha256.New()
+	h.Write(authSalt)
+	h.Write([]byte(authKey))
+	return base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// checkPassword сверяет пароль с хэшем пользователя.
+// Вычисление Argon2id выполняется в одном из ограниченного числа слотов.
+func (a *Auth) checkPassword(ctx context.Context, user entity.User, password string) error {
+	authSalt, err := base64.RawStdEncoding.DecodeString(user.AuthSalt)
+	if err != nil {
+		a.logger.Error("failed decode user auth salt", err)
+		return srvErrors.ErrUnexpected
 	}
 
 	userHash, err := base64.RawStdEncoding.DecodeString(user.Hash)
 	if err != nil {
 		a.logger.Error("failed decode user hash", err)
-		return resp, srvErrors.ErrUnexpected
+		return srvErrors.ErrUnexpected
 	}
 
-	calculatedHash, err := crypto.DeriveKey([]byte(c.Password), authSalt)
+	release, err := a.acquireHash(ctx)
+	if err != nil {
+		return err
+	}
+	calculatedHash, err := a.hashes.deriveKey([]byte(password), authSalt)
+	release()
 	if err != nil {
 		a.logger.Error("failed to hash password", err)
-		return resp, srvErrors.ErrUnexpected
+		return srvErrors.ErrUnexpected
+	}
+
+	if a.hashes.compare(userHash, calculatedHash) != 1 {
+		return srvErrors.ErrAuthInvalidCredentials
+	}
+
+	return nil
+}
+
+// checkSecondFactor проверяет код двухфакторной аутентификации,
+// если она включена у пользователя.
+func (a *Auth) checkSecondFactor(ctx context.Context, userID string, code string) error {
+	t, err := a.totp.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return nil
+		}
+		a.logger.Error("failed to get totp secret", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	if !t.Enabled {
+		return nil
 	}
+	if code == "" {
+		return srvErrors.ErrAuthOTPRequired
+	}
+
+	return verifySecondFactor(ctx, a.totp, a.logger, t, code, a.now())
+}
 
-	if subtle.ConstantTimeCompare(userHash, calculatedHash) != 1 {
-		return resp, srvErrors.ErrAuthInvalidCredentials
+// Refresh выпускает новую пару токенов по refresh токену, переданный токен при этом отзывается.
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (resp dto.TokenResponse, err error) {
+	next, nextToken, err := a.newRefreshToken("")
+	if err != nil {
+		return resp, err
+	}
+
+	next, err = a.tokens.Rotate(ctx, hashRefreshToken(refreshToken), next)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNotFound), errors.Is(err, repErrors.ErrRevoked):
+			return resp, srvErrors.ErrAuthInvalidToken
+		default:
+			a.logger.Error("failed to rotate refresh token", err)
+			return resp, srvErrors.ErrUnexpected
+		}
 	}
 
-	token, err := a.generateToken(user)
+	token, err := a.generateToken(next.UserID, next.SessionID)
 	if err != nil {
 		return resp, err
 	}
 
 	resp.Token = token
-	resp.EncrSalt = user.EncrSalt
+	resp.RefreshToken = nextToken
 	return resp, nil
 }
 
-// User получение пользователя по JWT
-func (a *Auth) User(ctx context.Context, token string) (entity.User, error) {
-	var user entity.User
+// Session получение действующей сессии пользователя по JWT.
+// Отмечает время последнего запроса в сессии и IP клиента из контекста.
+func (a *Auth) Session(ctx context.Context, token string) (entity.Session, error) {
+	var session entity.Session
 
 	jt, err := jwt.Parse(
 		token,
@@ -167,47 +731,57 @@ func (a *Auth) User(ctx context.Context, token string) (entity.User, error) {
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return user, srvErrors.ErrAuthTokenExpired
+			return session, srvErrors.ErrAuthTokenExpired
 		}
-		return user, srvErrors.ErrAuthInvalidToken
+		return session, srvErrors.ErrAuthInvalidToken
 	}
 
-	userID, err := parseID(jt)
+	sessionID, userID, err := parseClaims(jt)
 	if err != nil {
-		return user, srvErrors.ErrAuthInvalidToken
+		return session, srvErrors.ErrAuthInvalidToken
 	}
 
-	user, err = a.repository.GetByID(ctx, userID)
+	session, err = a.sessions.Touch(ctx, sessionID, srvContext.ClientIP(ctx))
 	if err != nil {
 		if !errors.Is(err, repErrors.ErrNotFound) {
-			a.logger.Error("failed to find user by id", err)
+			a.logger.Error("failed to find session", err)
 		}
-		return user, srvErrors.ErrAuthInvalidToken
+		return entity.Session{}, srvErrors.ErrAuthInvalidToken
+	}
+	if session.UserID != userID {
+		return entity.Session{}, srvErrors.ErrAuthInvalidToken
 	}
 
-	return user, nil
+	return session, nil
 }
 
-func parseID(token *jwt.Token) (string, error) {
+// parseClaims возвращает ID сессии и ID пользователя из токена.
+func parseClaims(token *jwt.Token) (sessionID string, userID string, err error) {
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", srvErrors.ErrAuthInvalidToken
+		return "", "", srvErrors.ErrAuthInvalidToken
 	}
 
-	id, ok := claims["jti"].(string)
-	if !ok {
-		return "", srvErrors.ErrAuthInvalidToken
+	sessionID, ok = claims["jti"].(string)
+	if !ok || sessionID == "" {
+		return "", "", srvErrors.ErrAuthInvalidToken
 	}
 
-	return id, nil
+	userID, ok = claims["sub"].(string)
+	if !ok || userID == "" {
+		return "", "", srvErrors.ErrAuthInvalidToken
+	}
+
+	return sessionID, userID, nil
 }
 
-func (a *Auth) generateToken(u entity.User) (string, error) {
+func (a *Auth) generateToken(userID, sessionID string) (string, error) {
 	token := jwt.NewWithClaims(
 		jwt.SigningMethodRS256,
 		jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.tokenTTL)),
-			ID:        u.ID,
+			ID:        sessionID,
+			Subject:   userID,
 		},
 	)
 
@@ -220,9 +794,79 @@ func (a *Auth) generateToken(u entity.User) (string, error) {
 	return tokenStr, nil
 }
 
+// issueTokens создает сессию пользователя на устройстве device,
+// выпускает для нее токен авторизации и refresh токен.
+func (a *Auth) issueTokens(ctx context.Context, u entity.User, device string) (string, string, error) {
+	refresh, refreshToken, err := a.newRefreshToken(u.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	session, err := a.sessions.Create(ctx, entity.Session{
+		UserID:  u.ID,
+		Device:  device,
+		IP:      srvContext.ClientIP(ctx),
+		Expires: refresh.Expires,
+	})
+	if err != nil {
+		a.logger.Error("failed to create session", err)
+		return "", "", srvErrors.ErrUnexpected
+	}
+
+	token, err := a.generateToken(u.ID, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refresh.SessionID = session.ID
+	if err := a.tokens.Create(ctx, refresh); err != nil {
+		a.logger.Error("failed to create refresh token", err)
+		return "", "", srvErrors.ErrUnexpected
+	}
+
+	return token, refreshToken, nil
+}
+
+// newRefreshToken генерирует случайный refresh токен,
+// возвращает его сущность для хранения в БД и сам токен для клиента.
+func (a *Auth) newRefreshToken(userID string) (entity.RefreshToken, string, error) {
+	b, err := crypto.GenerateRandomBytes(refreshTokenLen)
+	if err != nil {
+		a.logger.Error("failed to generate refresh token", err)
+		return entity.RefreshToken{}, "", srvErrors.ErrUnexpected
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	refresh := entity.RefreshToken{
+		UserID:  userID,
+		Hash:    hashRefreshToken(token),
+		Expires: time.Now().Add(a.refreshTTL),
+	}
+
+	return refresh, token, nil
+}
+
+// hashRefreshToken хэш refresh токена для хранения в БД, токен случайный,
+// поэтому соль и медленное хэширование не нужны.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func trimCredentials(c dto.Credentials) dto.Credentials {
+	device := []rune(strings.TrimSpace(c.Device))
+	if len(device) > dto.CredentialsDeviceMaxLen {
+		device = device[:dto.CredentialsDeviceMaxLen]
+	}
+
 	return dto.Credentials{
 		Login:    strings.TrimSpace(c.Login),
 		Password: strings.TrimSpace(c.Password),
+		AuthKey:  strings.TrimSpace(c.AuthKey),
+		EncrSalt: c.EncrSalt,
+		KDF:      c.KDF,
+		Device:   string(device),
+		OTP:      strings.TrimSpace(c.OTP),
+		Invite:   strings.TrimSpace(c.Invite),
 	}
 }
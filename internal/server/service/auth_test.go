@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"crypto/rsa"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -17,14 +19,15 @@ import (
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
 	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
 	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
 )
 
 func TestAuth_Register(t *testing.T) {
-	goodCredentials := dto.Credentials{Login: "testLogin", Password: "t1estP5assword"}
+	goodCredentials := dto.Credentials{Login: "testLogin", AuthKey: testAuthKey, EncrSalt: "c2FsdA"}
 
-	tooLongLoginCr := dto.Credentials{Login: "l", Password: "t1estP5assword"}
+	tooLongLoginCr := dto.Credentials{Login: "l", AuthKey: testAuthKey, EncrSalt: "c2FsdA"}
 	for range dto.CredentialsLoginMaxLen {
 		tooLongLoginCr.Login += "l"
 	}
@@ -49,7 +52,15 @@ func TestAuth_Register(t *testing.T) {
 				repository := mocks.NewMockUserRepository(ctrl)
 				repository.EXPECT().
 					Create(gomock.All(), gomock.All()).
-					Return(entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil)
+					DoAndReturn(func(_ context.Context, user entity.User) (entity.User, error) {
+						// сервер хранит хэш ключа аутентификации и соль мастер ключа от клиента
+						salt, err := base64.RawStdEncoding.DecodeString(user.AuthSalt)
+						require.Nil(t, err, "Decode auth salt")
+						assert.Equal(t, hashAuthKey(testAuthKey, salt), user.Hash, "Auth key hash")
+						assert.Equal(t, dto.AuthVersionKey, user.AuthVersion, "Auth version")
+						assert.Equal(t, "c2FsdA", user.EncrSalt, "Encryption salt")
+						return entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil
+					})
 				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
@@ -63,7 +74,7 @@ func TestAuth_Register(t *testing.T) {
 		},
 		{
 			name:        "negative_empty_login",
-			credentials: dto.Credentials{Login: "", Password: "t1estP5assword"},
+			credentials: dto.Credentials{Login: "", AuthKey: testAuthKey, EncrSalt: "c2FsdA"},
 			rSetup: func(t *testing.T) UserRepository {
 				ctrl := gomock.NewController(t)
 				return mocks.NewMockUserRepository(ctrl)
@@ -77,8 +88,8 @@ func TestAuth_Register(t *testing.T) {
 			},
 		},
 		{
-			name:        "negative_empty_password",
-			credentials: dto.Credentials{Login: "testLogin", Password: ""},
+			name:        "negative_password_instead_of_auth_key",
+			credentials: dto.Credentials{Login: "testLogin", Password: "t1estP5assword", EncrSalt: "c2FsdA"},
 			rSetup: func(t *testing.T) UserRepository {
 				ctrl := gomock.NewController(t)
 				return mocks.NewMockUserRepository(ctrl)
@@ -159,7 +170,7 @@ func TestAuth_Register(t *testing.T) {
 			ctx := context.Background()
 			tokenTTL := time.Hour
 
-			authService := NewAuth(repository, logger, pub, priv, tokenTTL)
+			authService := NewAuth(repository, testSessions(t), testRefreshTokens(t), testTOTP(t), logger, pub, priv, tokenTTL, tokenTTL)
 			resp, err := authService.Register(ctx, test.credentials)
 
 			assert.ErrorIs(t, err, test.want.err, "Register user error")
@@ -179,27 +190,45 @@ func TestAuth_Register(t *testing.T) {
 				},
 			)
 			require.Nil(t, err, "Parse token")
-			userID, err := parseID(jt)
-			require.Nil(t, err, "Get ID from token")
+			sessionID, userID, err := parseClaims(jt)
+			require.Nil(t, err, "Get IDs from token")
 			assert.Equal(t, test.want.userID, userID, "Registered userID form token")
+			assert.Equal(t, testSessionID, sessionID, "SessionID form token")
+			assert.NotEmpty(t, resp.RefreshToken, "Refresh token")
 
-			t.Log(resp.EncrSalt)
-			salt, err := base64.RawStdEncoding.DecodeString(resp.EncrSalt)
-			require.Nil(t, err, "Decode salt")
-			assert.Equal(t, crypto.SaltLen, len(salt), "Registered userID form token")
+			assert.Equal(t, "c2FsdA", resp.EncrSalt, "Encryption salt")
+			assert.Equal(t, &dto.KDFParams{Time: crypto.ArgonTime, Memory: crypto.ArgonMemory, Threads: crypto.ArgonThreads}, resp.KDF, "KDF params")
 		})
 	}
 }
 
 func TestAuth_Login(t *testing.T) {
-	goodCredentials := dto.Credentials{Login: "testLogin", Password: "t1estP5assword"}
+	userID := "d7d81ca8-8b0b-496e-abbd-fd522245c975"
+	kdf := entity.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}
 
 	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
 	require.Nil(t, err, "Generate auth salt for entity")
+	user := entity.User{
+		ID:          userID,
+		Hash:        hashAuthKey(testAuthKey, authSalt),
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		AuthVersion: dto.AuthVersionKey,
+		KDF:         kdf,
+	}
+
+	// аккаунт, еще не переведенный на ключ аутентификации
 	hash, err := crypto.DeriveKey([]byte("t1estP5assword"), authSalt)
 	require.Nil(t, err, "Generate hash for entity")
+	legacyUser := entity.User{
+		ID:          userID,
+		Hash:        base64.RawStdEncoding.EncodeToString(hash),
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		AuthVersion: dto.AuthVersionPassword,
+		KDF:         kdf,
+	}
 
-	badPasswordCredentials := dto.Credentials{Login: "testLogin", Password: "badPassword"}
+	goodCredentials := dto.Credentials{Login: "testLogin", AuthKey: testAuthKey}
+	legacyCredentials := dto.Credentials{Login: "testLogin", Password: "t1estP5assword", AuthKey: testAuthKey}
 
 	type want struct {
 		userID string
@@ -221,11 +250,7 @@ func TestAuth_Login(t *testing.T) {
 				repository := mocks.NewMockUserRepository(ctrl)
 				repository.EXPECT().
 					FindByLogin(gomock.All(), goodCredentials.Login).
-					Return(entity.User{
-						ID:       "d7d81ca8-8b0b-496e-abbd-fd522245c975",
-						Hash:     base64.RawStdEncoding.EncodeToString(hash),
-						AuthSalt: base64.RawStdEncoding.EncodeToString(authSalt),
-					}, nil)
+					Return(user, nil)
 				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
@@ -233,7 +258,83 @@ func TestAuth_Login(t *testing.T) {
 				return mocks.NewMockLogger(ctrl)
 			},
 			want: want{
-				userID: "d7d81ca8-8b0b-496e-abbd-fd522245c975",
+				userID: userID,
+				err:    nil,
+			},
+		},
+		{
+			name:        "succes_legacy_password_upgrade",
+			credentials: legacyCredentials,
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().
+					FindByLogin(gomock.All(), legacyCredentials.Login).
+					Return(legacyUser, nil)
+				repository.EXPECT().
+					SetAuthKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.AuthKeyChange) error {
+						assert.Equal(t, userID, change.UserID, "User ID")
+						assert.Equal(t, legacyUser.Hash, change.OldHash, "Old hash")
+						salt, err := base64.RawStdEncoding.DecodeString(change.AuthSalt)
+						require.Nil(t, err, "Decode auth salt")
+						assert.Equal(t, hashAuthKey(testAuthKey, salt), change.Hash, "Auth key hash")
+						return nil
+					})
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				userID: userID,
+				err:    nil,
+			},
+		},
+		{
+			name:        "succes_legacy_password_without_auth_key",
+			credentials: dto.Credentials{Login: "testLogin", Password: "t1estP5assword"},
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().
+					FindByLogin(gomock.All(), "testLogin").
+					Return(legacyUser, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				userID: userID,
+				err:    nil,
+			},
+		},
+		{
+			name:        "succes_legacy_upgrade_failed",
+			credentials: legacyCredentials,
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().
+					FindByLogin(gomock.All(), legacyCredentials.Login).
+					Return(legacyUser, nil)
+				repository.EXPECT().
+					SetAuthKey(gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("any error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to set auth key", gomock.All())
+				return logger
+			},
+			want: want{
+				userID: userID,
 				err:    nil,
 			},
 		},
@@ -256,19 +357,54 @@ func TestAuth_Login(t *testing.T) {
 				err: srvErrors.ErrAuthInvalidCredentials,
 			},
 		},
+		{
+			name:        "negative_bad_auth_key",
+			credentials: dto.Credentials{Login: "testLogin", AuthKey: testNewAuthKey},
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().
+					FindByLogin(gomock.All(), "testLogin").
+					Return(user, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				err: srvErrors.ErrAuthInvalidCredentials,
+			},
+		},
+		{
+			// после перевода на ключ аутентификации вход по паролю невозможен
+			name:        "negative_password_for_upgraded_account",
+			credentials: dto.Credentials{Login: "testLogin", Password: "t1estP5assword"},
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().
+					FindByLogin(gomock.All(), "testLogin").
+					Return(user, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				err: srvErrors.ErrAuthInvalidCredentials,
+			},
+		},
 		{
 			name:        "negative_bad_password",
-			credentials: badPasswordCredentials,
+			credentials: dto.Credentials{Login: "testLogin", Password: "badPassword", AuthKey: testAuthKey},
 			rSetup: func(t *testing.T) UserRepository {
 				ctrl := gomock.NewController(t)
 				repository := mocks.NewMockUserRepository(ctrl)
 				repository.EXPECT().
-					FindByLogin(gomock.All(), badPasswordCredentials.Login).
-					Return(entity.User{
-						ID:       "d7d81ca8-8b0b-496e-abbd-fd522245c975",
-						Hash:     base64.RawStdEncoding.EncodeToString(hash),
-						AuthSalt: base64.RawStdEncoding.EncodeToString(authSalt),
-					}, nil)
+					FindByLogin(gomock.All(), "testLogin").
+					Return(legacyUser, nil)
 				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
@@ -313,7 +449,7 @@ func TestAuth_Login(t *testing.T) {
 			ctx := context.Background()
 			tokenTTL := time.Hour
 
-			authService := NewAuth(repository, logger, pub, priv, tokenTTL)
+			authService := NewAuth(repository, testSessions(t), testRefreshTokens(t), testTOTP(t), logger, pub, priv, tokenTTL, tokenTTL)
 			resp, err := authService.Login(ctx, test.credentials)
 
 			assert.ErrorIs(t, err, test.want.err, "Login user error")
@@ -333,47 +469,621 @@ func TestAuth_Login(t *testing.T) {
 				},
 			)
 			require.Nil(t, err, "Parse token")
-			userID, err := parseID(jt)
-			require.Nil(t, err, "Get ID from token")
+			sessionID, userID, err := parseClaims(jt)
+			require.Nil(t, err, "Get IDs from token")
 			assert.Equal(t, test.want.userID, userID, "Logged in userID form token")
+			assert.Equal(t, testSessionID, sessionID, "SessionID form token")
+			assert.NotEmpty(t, resp.RefreshToken, "Refresh token")
+			assert.Equal(t, &dto.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}, resp.KDF, "KDF params")
+		})
+	}
+}
+
+// hashCalls число вызовов функций проверки учетных данных.
+type hashCalls struct {
+	deriveKey, hashKey, hashRecovery, compare int
+}
+
+// countHashes подменяет функции проверки учетных данных сервиса a счетчиками.
+// Argon2id заменяется быстрым SHA-256, работа при этом считается по вызовам.
+func countHashes(a *Auth) *hashCalls {
+	calls := &hashCalls{}
+	h := a.hashes
+	a.hashes = authHashes{
+		deriveKey: func(password, salt []byte) ([]byte, error) {
+			calls.deriveKey++
+			h := sha256.New()
+			h.Write(salt)
+			h.Write(password)
+			return h.Sum(nil), nil
+		},
+		hashKey: func(authKey string, salt []byte) string {
+			calls.hashKey++
+			return h.hashKey(authKey, salt)
+		},
+		hashRecovery: func(authKey string) string {
+			calls.hashRecovery++
+			return h.hashRecovery(authKey)
+		},
+		compare: func(x, y []byte) int {
+			calls.compare++
+			return h.compare(x, y)
+		},
+	}
+	return calls
+}
+
+func TestAuth_LoginHashCalls(t *testing.T) {
+	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	require.Nil(t, err, "Generate auth salt for entity")
+	hash, err := crypto.GenerateRandomBytes(sha256.Size)
+	require.Nil(t, err, "Generate hash for entity")
+
+	tests := []struct {
+		name        string
+		authVersion uint8
+		wantCalls   hashCalls
+	}{
+		{
+			name:        "auth_key",
+			authVersion: dto.AuthVersionKey,
+			wantCalls:   hashCalls{hashKey: 1, compare: 1},
+		},
+		{
+			name:        "password",
+			authVersion: dto.AuthVersionPassword,
+			wantCalls:   hashCalls{deriveKey: 1, compare: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := entity.User{
+				ID:          "d7d81ca8-8b0b-496e-abbd-fd522245c975",
+				Login:       "testLogin",
+				Hash:        base64.RawStdEncoding.EncodeToString(hash),
+				AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+				AuthVersion: test.authVersion,
+			}
+
+			ctrl := gomock.NewController(t)
+			repository := mocks.NewMockUserRepository(ctrl)
+			repository.EXPECT().FindByLogin(gomock.Any(), "testLogin").Return(user, nil).AnyTimes()
+			repository.EXPECT().FindByLogin(gomock.Any(), "unknownLogin").Return(entity.User{}, repErrors.ErrNotFound).AnyTimes()
+			repository.EXPECT().
+				AuthProfiles(gomock.Any()).
+				Return([]entity.AuthProfile{{AuthVersion: test.authVersion, KDF: kdfParams(nil), Count: 1}}, nil)
+
+			authService := NewAuth(repository, nil, nil, nil, mocks.NewMockLogger(ctrl), nil, nil, time.Hour, time.Hour)
+			require.Nil(t, authService.RefreshProfiles(context.Background()), "Refresh profiles")
+			calls := countHashes(authService)
+
+			// PreLogin сообщает для несуществующего логина ту же схему, что и у пользователя
+			pre, err := authService.PreLogin(context.Background(), dto.PreLoginRequest{Login: "unknownLogin"})
+			require.Nil(t, err, "PreLogin error")
+			assert.Equal(t, test.authVersion, pre.AuthVersion, "Fake auth version")
+
+			cr := dto.Credentials{Password: "t1estP5assword", AuthKey: testAuthKey}
+			login := func(login string) hashCalls {
+				*calls = hashCalls{}
+				cr.Login = login
+				_, err := authService.Login(context.Background(), cr)
+				require.ErrorIs(t, err, srvErrors.ErrAuthInvalidCredentials, "Login error")
+				return *calls
+			}
+
+			existing := login("testLogin")
+			assert.Equal(t, test.wantCalls, existing, "Existing user hash calls")
+			assert.Equal(t, existing, login("unknownLogin"), "Unknown user hash calls")
+		})
+	}
+}
+
+func Test_newFakeUser(t *testing.T) {
+	user := newFakeUser()
+	assert.Equal(t, dto.AuthVersionKey, user.AuthVersion, "Fake user auth version")
+	assert.NotEqual(t, user, newFakeUser(), "Fake users must be random")
+
+	salt, err := base64.RawStdEncoding.DecodeString(user.AuthSalt)
+	require.Nil(t, err, "Decode fake auth salt")
+	assert.Len(t, salt, crypto.SaltLen, "Fake auth salt length")
+
+	ctrl := gomock.NewController(t)
+	authService := NewAuth(nil, nil, nil, nil, mocks.NewMockLogger(ctrl), nil, nil, time.Hour, time.Hour)
+	err = authService.checkCredentials(context.Background(), user, "", testAuthKey)
+	assert.ErrorIs(t, err, srvErrors.ErrAuthInvalidCredentials, "Check fake user credentials")
+
+	// несуществующий логин с версией пароля проверяется тем же пользователем
+	user.AuthVersion = dto.AuthVersionPassword
+	err = authService.checkCredentials(context.Background(), user, "t1estP5assword", testAuthKey)
+	assert.ErrorIs(t, err, srvErrors.ErrAuthInvalidCredentials, "Check fake user password")
+}
+
+func TestAuth_RefreshProfiles(t *testing.T) {
+	kdf := entity.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}
+	profiles := []entity.AuthProfile{
+		{AuthVersion: dto.AuthVersionPassword, KDF: kdfParams(nil), Count: 1},
+		{AuthVersion: dto.AuthVersionKey, KDF: kdfParams(nil), Count: 2},
+		{AuthVersion: dto.AuthVersionKey, KDF: kdf, Count: 1},
+	}
+
+	t.Run("distribution", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockUserRepository(ctrl)
+		repository.EXPECT().AuthProfiles(gomock.Any()).Return(profiles, nil)
+		authService := NewAuth(repository, nil, nil, nil, mocks.NewMockLogger(ctrl), nil, nil, time.Hour, time.Hour)
+		require.Nil(t, authService.RefreshProfiles(context.Background()), "Refresh profiles")
+
+		// профили выбираются по логину с долей, равной доле пользователей с ними
+		const logins = 4000
+		counts := make([]int, len(profiles))
+		for i := range logins {
+			login := fmt.Sprintf("login%d", i)
+			_, profile := authService.fakeProfile(login)
+			_, again := authService.fakeProfile(login)
+			require.Equal(t, profile, again, "Same login, same profile")
+			idx := slices.Index(profiles, profile)
+			require.NotEqual(t, -1, idx, "Profile from distribution")
+			counts[idx]++
+		}
+		for i, p := range profiles {
+			want := logins * int(p.Count) / 4
+			assert.InDelta(t, want, counts[i], float64(want)/5, "Profile %d share", i)
+		}
+	})
+
+	t.Run("negative_repository_error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockUserRepository(ctrl)
+		repository.EXPECT().AuthProfiles(gomock.Any()).Return(nil, fmt.Errorf("any error"))
+		logger := mocks.NewMockLogger(ctrl)
+		logger.EXPECT().Error("failed to get auth profiles", gomock.Any())
+		authService := NewAuth(repository, nil, nil, nil, logger, nil, nil, time.Hour, time.Hour)
+
+		err := authService.RefreshProfiles(context.Background())
+		assert.ErrorIs(t, err, srvErrors.ErrUnexpected, "Refresh profiles error")
+		// без распределения используются ключ аутентификации и параметры по умолчанию
+		_, profile := authService.fakeProfile("unknown")
+		assert.Equal(t, entity.AuthProfile{AuthVersion: dto.AuthVersionKey, KDF: kdfParams(nil)}, profile, "Default profile")
+	})
+}
+
+func TestAuth_PreLogin(t *testing.T) {
+	kdf := entity.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}
+	user := entity.User{
+		ID:          "d7d81ca8-8b0b-496e-abbd-fd522245c975",
+		EncrSalt:    "c2FsdA",
+		KDF:         kdf,
+		AuthVersion: dto.AuthVersionPassword,
+	}
+
+	priv, pub, err := crypto.GenerateKeyPair()
+	require.Nil(t, err, "Generate rsa key pair")
+
+	newService := func(t *testing.T, repository UserRepository, logger Logger) *Auth {
+		return NewAuth(repository, testSessions(t), testRefreshTokens(t), testTOTP(t), logger, pub, priv, time.Hour, time.Hour)
+	}
+
+	t.Run("succes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockUserRepository(ctrl)
+		repository.EXPECT().FindByLogin(gomock.Any(), "testLogin").Return(user, nil)
+
+		resp, err := newService(t, repository, mocks.NewMockLogger(ctrl)).
+			PreLogin(context.Background(), dto.PreLoginRequest{Login: " testLogin "})
+		require.Nil(t, err, "PreLogin error")
+		assert.Equal(t, "c2FsdA", resp.EncrSalt, "Encryption salt")
+		assert.Equal(t, &dto.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}, resp.KDF, "KDF params")
+		assert.Equal(t, dto.AuthVersionPassword, resp.AuthVersion, "Auth version")
+	})
+
+	t.Run("unknown_user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockUserRepository(ctrl)
+		repository.EXPECT().
+			FindByLogin(gomock.Any(), gomock.Any()).
+			Return(entity.User{}, repErrors.ErrNotFound).
+			Times(3)
+		authService := newService(t, repository, mocks.NewMockLogger(ctrl))
+		ctx := context.Background()
+
+		first, err := authService.PreLogin(ctx, dto.PreLoginRequest{Login: "unknown"})
+		require.Nil(t, err, "PreLogin error")
+		second, err := authService.PreLogin(ctx, dto.PreLoginRequest{Login: "unknown"})
+		require.Nil(t, err, "PreLogin error")
+		other, err := authService.PreLogin(ctx, dto.PreLoginRequest{Login: "another"})
+		require.Nil(t, err, "PreLogin error")
+
+		// ответ для несуществующего логина не отличается от ответа для существующего
+		salt, err := base64.RawStdEncoding.DecodeString(first.EncrSalt)
+		require.Nil(t, err, "Decode salt")
+		assert.Equal(t, crypto.SaltLen, len(salt), "Salt length")
+		assert.Equal(t, dto.AuthVersionKey, first.AuthVersion, "Auth version")
+		assert.NotNil(t, first.KDF, "KDF params")
+		assert.Equal(t, first, second, "Same login, same response")
+		assert.NotEqual(t, first.EncrSalt, other.EncrSalt, "Different logins, different salts")
+	})
+
+	t.Run("negative_unexpected_repository_error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mocks.NewMockUserRepository(ctrl)
+		repository.EXPECT().
+			FindByLogin(gomock.Any(), "testLogin").
+			Return(entity.User{}, fmt.Errorf("any error"))
+		logger := mocks.NewMockLogger(ctrl)
+		logger.EXPECT().Error("failed to find user", gomock.Any())
+
+		_, err := newService(t, repository, logger).
+			PreLogin(context.Background(), dto.PreLoginRequest{Login: "testLogin"})
+		assert.ErrorIs(t, err, srvErrors.ErrUnexpected, "PreLogin error")
+	})
+}
+
+func TestAuth_ChangePassword(t *testing.T) {
+	userID := "d7d81ca8-8b0b-496e-abbd-fd522245c975"
+	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	require.Nil(t, err, "Generate auth salt for entity")
+
+	user := entity.User{
+		ID:          userID,
+		Hash:        hashAuthKey(testAuthKey, authSalt),
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		AuthVersion: dto.AuthVersionKey,
+	}
+	goodRequest := dto.PasswordChangeRequest{
+		AuthKey:    testAuthKey,
+		NewAuthKey: testNewAuthKey,
+		EncrSalt:   "c2FsdA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}, {ID: 2, Key: "a2V5Mg"}},
+	}
+
+	// аккаунт, еще не переведенный на ключ аутентификации, подтверждает смену паролем
+	hash, err := crypto.DeriveKey([]byte("t1estP5assword"), authSalt)
+	require.Nil(t, err, "Generate hash for entity")
+	legacyUser := entity.User{
+		ID:       userID,
+		Hash:     base64.RawStdEncoding.EncodeToString(hash),
+		AuthSalt: base64.RawStdEncoding.EncodeToString(authSalt),
+	}
+
+	// checkChange проверяет, что новый хэш вычислен из нового ключа аутентификации с новой солью
+	checkChange := func(t *testing.T, change entity.PasswordChange) {
+		assert.Equal(t, userID, change.UserID, "User ID")
+		assert.Equal(t, user.Hash, change.OldHash, "Old hash")
+		assert.Equal(t, testSessionID, change.SessionID, "Session ID")
+		assert.Nil(t, change.Recovery, "Recovery key")
+		assert.Equal(t, goodRequest.EncrSalt, change.EncrSalt, "Encryption salt")
+		// клиент без параметров KDF вычисляет мастер ключ с параметрами по умолчанию
+		assert.Equal(t, entity.KDFParams(crypto.DefaultKDFParams()), change.KDF, "KDF params")
+		assert.Equal(
+			t,
+			[]entity.SecretKey{{SecretID: 1, EncryptedKey: "a2V5MQ"}, {SecretID: 2, EncryptedKey: "a2V5Mg"}},
+			change.Keys,
+			"Secret keys",
+		)
+
+		salt, err := base64.RawStdEncoding.DecodeString(change.AuthSalt)
+		require.Nil(t, err, "Decode auth salt")
+		assert.Equal(t, hashAuthKey(goodRequest.NewAuthKey, salt), change.Hash, "New hash")
+	}
+
+	tests := []struct {
+		name    string
+		req     dto.PasswordChangeRequest
+		rSetup  func(t *testing.T) UserRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "succes",
+			req:  goodRequest,
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+				repository.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.PasswordChange) error {
+						checkChange(t, change)
+						return nil
+					})
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			name: "succes_legacy_password",
+			req: dto.PasswordChangeRequest{
+				Password:   "t1estP5assword",
+				NewAuthKey: goodRequest.NewAuthKey,
+				EncrSalt:   goodRequest.EncrSalt,
+				Keys:       goodRequest.Keys,
+			},
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(legacyUser, nil)
+				repository.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.PasswordChange) error {
+						assert.Equal(t, legacyUser.Hash, change.OldHash, "Old hash")
+						salt, err := base64.RawStdEncoding.DecodeString(change.AuthSalt)
+						require.Nil(t, err, "Decode auth salt")
+						assert.Equal(t, hashAuthKey(goodRequest.NewAuthKey, salt), change.Hash, "New hash")
+						return nil
+					})
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			name: "succes_with_account_key",
+			req: dto.PasswordChangeRequest{
+				AuthKey:    goodRequest.AuthKey,
+				NewAuthKey: goodRequest.NewAuthKey,
+				EncrSalt:   goodRequest.EncrSalt,
+				KDF:        &dto.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2},
+				AccountKey: "bmV3",
+				Keys:       []dto.SecretKey{{ID: 2, Key: "a2V5Mg"}},
+			},
+			rSetup: func(t *testing.T) UserRepository {
+				migrated := user
+				migrated.AccountKey = "b2xk"
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(migrated, nil)
+				repository.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.PasswordChange) error {
+						assert.Equal(t, "bmV3", change.AccountKey, "Account key")
+						assert.Equal(t, entity.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2}, change.KDF, "KDF params")
+						// ключи старого формата переводятся на ключ аккаунта
+						assert.Equal(
+							t,
+							[]entity.SecretKey{{SecretID: 2, EncryptedKey: "a2V5Mg", Version: dto.KeyVersionAccount}},
+							change.Keys,
+							"Secret keys",
+						)
+						return nil
+					})
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			name: "negative_account_key_required",
+			req:  goodRequest,
+			rSetup: func(t *testing.T) UserRepository {
+				migrated := user
+				migrated.AccountKey = "b2xk"
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(migrated, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrPasswordKeysMismatch,
+		},
+		{
+			name: "negative_bad_auth_key",
+			req: dto.PasswordChangeRequest{
+				AuthKey:    testNewAuthKey,
+				NewAuthKey: goodRequest.NewAuthKey,
+				EncrSalt:   goodRequest.EncrSalt,
+			},
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			name: "negative_invalid_new_auth_key",
+			req: dto.PasswordChangeRequest{
+				AuthKey:    goodRequest.AuthKey,
+				NewAuthKey: "c2hvcnQ",
+				EncrSalt:   goodRequest.EncrSalt,
+			},
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrPasswordInvalidRequest,
+		},
+		{
+			name: "negative_keys_mismatch",
+			req:  goodRequest,
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+				repository.EXPECT().ChangePassword(gomock.Any(), gomock.Any()).Return(repErrors.ErrIncomplete)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrPasswordKeysMismatch,
+		},
+		{
+			name: "negative_password_changed_concurrently",
+			req:  goodRequest,
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+				repository.EXPECT().ChangePassword(gomock.Any(), gomock.Any()).Return(repErrors.ErrNoRowsUpdated)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			name: "negative_unexpected_repository_error",
+			req:  goodRequest,
+			rSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUserRepository(ctrl)
+				repository.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+				repository.EXPECT().ChangePassword(gomock.Any(), gomock.Any()).Return(fmt.Errorf("any error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error("failed to change password", gomock.Any())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := srvContext.SetUserID(context.Background(), userID)
+			ctx = srvContext.SetSessionID(ctx, testSessionID)
+
+			authService := NewAuth(
+				test.rSetup(t), testSessions(t), testRefreshTokens(t), testTOTP(t),
+				test.lSetup(t), nil, nil, time.Hour, time.Hour,
+			)
+			err := authService.ChangePassword(ctx, &test.req)
+			assert.ErrorIs(t, err, test.wantErr, "Change password error")
 		})
 	}
 }
 
-func TestAuth_User(t *testing.T) {
+func TestAuth_SetAccountKey(t *testing.T) {
+	userID := "d7d81ca8-8b0b-496e-abbd-fd522245c975"
+	goodRequest := dto.AccountKeyRequest{
+		AccountKey: "YWNjb3VudA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}},
+		Recovery:   &dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms"},
+	}
+
+	tests := []struct {
+		name    string
+		req     dto.AccountKeyRequest
+		repErr  error
+		call    bool
+		wantErr error
+	}{
+		{
+			name: "succes",
+			req:  goodRequest,
+			call: true,
+		},
+		{
+			name:    "negative_invalid_request",
+			req:     dto.AccountKeyRequest{Keys: goodRequest.Keys},
+			wantErr: srvErrors.ErrAccountKeyInvalidRequest,
+		},
+		{
+			name:    "negative_already_set",
+			req:     goodRequest,
+			call:    true,
+			repErr:  repErrors.ErrNoRowsUpdated,
+			wantErr: srvErrors.ErrAccountKeyExists,
+		},
+		{
+			name:    "negative_keys_mismatch",
+			req:     goodRequest,
+			call:    true,
+			repErr:  repErrors.ErrIncomplete,
+			wantErr: srvErrors.ErrPasswordKeysMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := srvContext.SetUserID(context.Background(), userID)
+
+			repository := mocks.NewMockUserRepository(gomock.NewController(t))
+			if test.call {
+				repository.EXPECT().
+					SetAccountKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.AccountKeyChange) error {
+						assert.Equal(
+							t,
+							entity.AccountKeyChange{
+								UserID:     userID,
+								AccountKey: "YWNjb3VudA",
+								Keys:       []entity.SecretKey{{SecretID: 1, EncryptedKey: "a2V5MQ", Version: dto.KeyVersionAccount}},
+								Recovery:   &entity.RecoveryKey{UserID: userID, MasterKey: "bWs", RecoveryKey: "cms"},
+							},
+							change,
+							"Account key change",
+						)
+						return test.repErr
+					})
+			}
+
+			authService := NewAuth(
+				repository, testSessions(t), testRefreshTokens(t), testTOTP(t),
+				mocks.NewMockLogger(gomock.NewController(t)), nil, nil, time.Hour, time.Hour,
+			)
+			err := authService.SetAccountKey(ctx, &test.req)
+			assert.ErrorIs(t, err, test.wantErr, "Set account key error")
+		})
+	}
+}
+
+func TestAuth_Session(t *testing.T) {
 	priv, pub, err := crypto.GenerateKeyPair()
 	require.Nil(t, err, "Generate rsa key pair")
 	badPriv, _, err := crypto.GenerateKeyPair()
 	require.Nil(t, err, "Generate rsa key pair")
 
 	userID := "d7d81ca8-8b0b-496e-abbd-fd522245c975"
+	session := entity.Session{ID: testSessionID, UserID: userID}
 
-	goodToken := testGenerateToken(t, userID, priv, false)
-	expiredToken := testGenerateToken(t, userID, priv, true)
-	badSignedToken := testGenerateToken(t, userID, badPriv, false)
-	badIDtoken := testGenerateToken(t, "", priv, false)
+	goodToken := testGenerateToken(t, testSessionID, userID, priv, false)
+	expiredToken := testGenerateToken(t, testSessionID, userID, priv, true)
+	badSignedToken := testGenerateToken(t, testSessionID, userID, badPriv, false)
+	badIDtoken := testGenerateToken(t, "", userID, priv, false)
+	withoutUserToken := testGenerateToken(t, testSessionID, "", priv, false)
 
 	type want struct {
-		user entity.User
-		err  error
+		session entity.Session
+		err     error
 	}
 
 	tests := []struct {
 		name   string
 		token  string
-		rSetup func(t *testing.T) UserRepository
+		sSetup func(t *testing.T) SessionRepository
 		lSetup func(t *testing.T) Logger
 		want   want
 	}{
 		{
 			name:  "success",
 			token: goodToken,
-			rSetup: func(t *testing.T) UserRepository {
+			sSetup: func(t *testing.T) SessionRepository {
 				ctrl := gomock.NewController(t)
-				repository := mocks.NewMockUserRepository(ctrl)
+				repository := mocks.NewMockSessionRepository(ctrl)
 				repository.EXPECT().
-					GetByID(gomock.All(), "d7d81ca8-8b0b-496e-abbd-fd522245c975").
-					Return(entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil)
+					Touch(gomock.All(), testSessionID, "192.0.2.1").
+					Return(session, nil)
 				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
@@ -381,100 +1091,306 @@ func TestAuth_User(t *testing.T) {
 				return mocks.NewMockLogger(ctrl)
 			},
 			want: want{
-				user: entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"},
-				err:  nil,
+				session: session,
 			},
 		},
 		{
 			name:  "negative_token_expired",
 			token: expiredToken,
-			rSetup: func(t *testing.T) UserRepository {
+			sSetup: func(t *testing.T) SessionRepository {
 				ctrl := gomock.NewController(t)
-				return mocks.NewMockUserRepository(ctrl)
+				return mocks.NewMockSessionRepository(ctrl)
 			},
 			lSetup: func(t *testing.T) Logger {
 				ctrl := gomock.NewController(t)
 				return mocks.NewMockLogger(ctrl)
 			},
 			want: want{
-				user: entity.User{},
-				err:  srvErrors.ErrAuthTokenExpired,
+				err: srvErrors.ErrAuthTokenExpired,
 			},
 		},
 		{
 			name:  "negative_bad_signed_token",
 			token: badSignedToken,
-			rSetup: func(t *testing.T) UserRepository {
+			sSetup: func(t *testing.T) SessionRepository {
 				ctrl := gomock.NewController(t)
-				return mocks.NewMockUserRepository(ctrl)
+				return mocks.NewMockSessionRepository(ctrl)
 			},
 			lSetup: func(t *testing.T) Logger {
 				ctrl := gomock.NewController(t)
 				return mocks.NewMockLogger(ctrl)
 			},
 			want: want{
-				user: entity.User{},
-				err:  srvErrors.ErrAuthInvalidToken,
+				err: srvErrors.ErrAuthInvalidToken,
 			},
 		},
 		{
 			name:  "negative_token_without_id",
 			token: badIDtoken,
-			rSetup: func(t *testing.T) UserRepository {
+			sSetup: func(t *testing.T) SessionRepository {
 				ctrl := gomock.NewController(t)
-				return mocks.NewMockUserRepository(ctrl)
+				return mocks.NewMockSessionRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				err: srvErrors.ErrAuthInvalidToken,
+			},
+		},
+		{
+			name:  "negative_token_without_user",
+			token: withoutUserToken,
+			sSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSessionRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				err: srvErrors.ErrAuthInvalidToken,
+			},
+		},
+		{
+			name:  "negative_session_revoked",
+			token: goodToken,
+			sSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					Touch(gomock.All(), testSessionID, "192.0.2.1").
+					Return(entity.Session{}, repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				err: srvErrors.ErrAuthInvalidToken,
+			},
+		},
+		{
+			name:  "negative_session_of_another_user",
+			token: goodToken,
+			sSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					Touch(gomock.All(), testSessionID, "192.0.2.1").
+					Return(entity.Session{ID: testSessionID, UserID: "another"}, nil)
+				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
 				ctrl := gomock.NewController(t)
 				return mocks.NewMockLogger(ctrl)
 			},
 			want: want{
-				user: entity.User{},
-				err:  srvErrors.ErrAuthInvalidToken,
+				err: srvErrors.ErrAuthInvalidToken,
 			},
 		},
 		{
 			name:  "negative_unexpected_repository_error",
 			token: goodToken,
-			rSetup: func(t *testing.T) UserRepository {
+			sSetup: func(t *testing.T) SessionRepository {
 				ctrl := gomock.NewController(t)
-				repository := mocks.NewMockUserRepository(ctrl)
+				repository := mocks.NewMockSessionRepository(ctrl)
 				repository.EXPECT().
-					GetByID(gomock.All(), userID).
-					Return(entity.User{}, fmt.Errorf("any error"))
+					Touch(gomock.All(), testSessionID, "192.0.2.1").
+					Return(entity.Session{}, fmt.Errorf("any error"))
 				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
 				ctrl := gomock.NewController(t)
 				logger := mocks.NewMockLogger(ctrl)
 				logger.EXPECT().
-					Error("failed to find user by id", gomock.All())
+					Error("failed to find session", gomock.All())
 				return logger
 			},
 			want: want{
-				user: entity.User{},
-				err:  srvErrors.ErrAuthInvalidToken,
+				err: srvErrors.ErrAuthInvalidToken,
 			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			repository := test.rSetup(t)
+			ctrl := gomock.NewController(t)
+			repository := mocks.NewMockUserRepository(ctrl)
+			tokens := mocks.NewMockRefreshTokenRepository(ctrl)
 			logger := test.lSetup(t)
-			ctx := context.Background()
+			ctx := srvContext.SetClientIP(context.Background(), "192.0.2.1")
 			tokenTTL := time.Hour
 
-			authService := NewAuth(repository, logger, pub, priv, tokenTTL)
-			user, err := authService.User(ctx, test.token)
+			authService := NewAuth(repository, test.sSetup(t), tokens, testTOTP(t), logger, pub, priv, tokenTTL, tokenTTL)
+			got, err := authService.Session(ctx, test.token)
 
-			assert.Equal(t, test.want.user, user, "Get user entity")
-			assert.ErrorIs(t, err, test.want.err, "Get user error")
+			assert.Equal(t, test.want.session, got, "Get session entity")
+			assert.ErrorIs(t, err, test.want.err, "Get session error")
 		})
 	}
 }
 
-func testGenerateToken(t *testing.T, id string, jwtPriv *rsa.PrivateKey, expired bool) string {
+func TestAuth_Refresh(t *testing.T) {
+	priv, pub, err := crypto.GenerateKeyPair()
+	require.Nil(t, err, "Generate rsa key pair")
+
+	userID := "d7d81ca8-8b0b-496e-abbd-fd522245c975"
+	refreshToken := "refresh"
+
+	tests := []struct {
+		name    string
+		tSetup  func(t *testing.T) RefreshTokenRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			tSetup: func(t *testing.T) RefreshTokenRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockRefreshTokenRepository(ctrl)
+				repository.EXPECT().
+					Rotate(gomock.Any(), hashRefreshToken(refreshToken), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, next entity.RefreshToken) (entity.RefreshToken, error) {
+						assert.Empty(t, next.UserID, "Next token user")
+						assert.NotEqual(t, hashRefreshToken(refreshToken), next.Hash, "Next token hash")
+						next.UserID = userID
+						next.SessionID = testSessionID
+						return next, nil
+					})
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "negative_not_found",
+			tSetup: func(t *testing.T) RefreshTokenRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockRefreshTokenRepository(ctrl)
+				repository.EXPECT().
+					Rotate(gomock.Any(), hashRefreshToken(refreshToken), gomock.Any()).
+					Return(entity.RefreshToken{}, repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrAuthInvalidToken,
+		},
+		{
+			name: "negative_reused",
+			tSetup: func(t *testing.T) RefreshTokenRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockRefreshTokenRepository(ctrl)
+				repository.EXPECT().
+					Rotate(gomock.Any(), hashRefreshToken(refreshToken), gomock.Any()).
+					Return(entity.RefreshToken{UserID: userID}, repErrors.ErrRevoked)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrAuthInvalidToken,
+		},
+		{
+			name: "negative_unexpected_repository_error",
+			tSetup: func(t *testing.T) RefreshTokenRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockRefreshTokenRepository(ctrl)
+				repository.EXPECT().
+					Rotate(gomock.Any(), hashRefreshToken(refreshToken), gomock.Any()).
+					Return(entity.RefreshToken{}, fmt.Errorf("any error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to rotate refresh token", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mocks.NewMockUserRepository(ctrl)
+			logger := test.lSetup(t)
+
+			sessions := mocks.NewMockSessionRepository(ctrl)
+			authService := NewAuth(repository, sessions, test.tSetup(t), testTOTP(t), logger, pub, priv, time.Hour, time.Hour)
+			resp, err := authService.Refresh(context.Background(), refreshToken)
+
+			assert.ErrorIs(t, err, test.wantErr, "Refresh error")
+			if err != nil {
+				return
+			}
+
+			jt, err := jwt.Parse(resp.Token, func(t *jwt.Token) (any, error) { return pub, nil })
+			require.Nil(t, err, "Parse token")
+			sessionID, gotID, err := parseClaims(jt)
+			require.Nil(t, err, "Get IDs from token")
+			assert.Equal(t, userID, gotID, "UserID form token")
+			assert.Equal(t, testSessionID, sessionID, "SessionID form token")
+			assert.NotEmpty(t, resp.RefreshToken, "Refresh token")
+			assert.NotEqual(t, refreshToken, resp.RefreshToken, "Rotated refresh token")
+		})
+	}
+}
+
+const testSessionID = "5b1c3f0e-7d0a-4c7e-9a43-3f1d2b8e6a10"
+
+// Ключи аутентификации длиной dto.AuthKeyLen в base64
+const (
+	testAuthKey    = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8"
+	testNewAuthKey = "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8"
+)
+
+func testSessions(t *testing.T) SessionRepository {
+	ctrl := gomock.NewController(t)
+	repository := mocks.NewMockSessionRepository(ctrl)
+	repository.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, session entity.Session) (entity.Session, error) {
+			session.ID = testSessionID
+			return session, nil
+		}).
+		AnyTimes()
+	return repository
+}
+
+func testRefreshTokens(t *testing.T) RefreshTokenRepository {
+	ctrl := gomock.NewController(t)
+	repository := mocks.NewMockRefreshTokenRepository(ctrl)
+	repository.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil).
+		AnyTimes()
+	return repository
+}
+
+// testTOTP репозиторий пользователей без двухфакторной аутентификации
+func testTOTP(t *testing.T) TOTPRepository {
+	ctrl := gomock.NewController(t)
+	repository := mocks.NewMockTOTPRepository(ctrl)
+	repository.EXPECT().
+		Get(gomock.Any(), gomock.Any()).
+		Return(entity.TOTP{}, repErrors.ErrNotFound).
+		AnyTimes()
+	return repository
+}
+
+func testGenerateToken(t *testing.T, sessionID, userID string, jwtPriv *rsa.PrivateKey, expired bool) string {
 	expires := time.Now().Add((-1) * time.Hour)
 	if !expired {
 		expires = expires.Add(25 * time.Hour)
@@ -484,7 +1400,8 @@ func testGenerateToken(t *testing.T, id string, jwtPriv *rsa.PrivateKey, expired
 		jwt.SigningMethodRS256,
 		jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expires),
-			ID:        id,
+			ID:        sessionID,
+			Subject:   userID,
 		},
 	)
 
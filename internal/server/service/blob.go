@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+// blobBatchSize сколько ключей проверять в БД одним запросом
+const blobBatchSize = 1000
+
+type BlobRepository interface {
+	// Unreferenced возвращает ключи из keys, на которые нет ссылок в БД.
+	Unreferenced(ctx context.Context, keys []string) ([]string, error)
+	// ExpireUploads удаляет незавершенные загрузки старше ttl и возвращает их количество.
+	ExpireUploads(ctx context.Context, ttl time.Duration) (int64, error)
+}
+
+type BlobStore interface {
+	// List возвращает все объекты хранилища, ключи которых начинаются с prefix.
+	List(ctx context.Context, prefix string) ([]entity.Blob, error)
+	// Delete удаляет данные по ключу key.
+	Delete(ctx context.Context, key string) error
+}
+
+// BlobCollector сборщик мусора хранилища данных секретов:
+// удаляет объекты, оставшиеся после изменения и удаления секретов, повторной передачи частей
+// и брошенных загрузок.
+type BlobCollector struct {
+	logger     Logger
+	repository BlobRepository
+	store      BlobStore
+	// Объекты моложе grace не удаляются: ссылка на только что сохраненный объект
+	// появляется в БД после его записи в хранилище
+	grace time.Duration
+	// Незавершенные загрузки старше uploadTTL удаляются вместе с частями
+	uploadTTL time.Duration
+	now       func() time.Time
+}
+
+func NewBlobCollector(l Logger, r BlobRepository, s BlobStore, grace, uploadTTL time.Duration) *BlobCollector {
+	return &BlobCollector{logger: l, repository: r, store: s, grace: grace, uploadTTL: uploadTTL, now: time.Now}
+}
+
+// Collect удаляет незавершенные загрузки старше uploadTTL, затем объекты хранилища старше grace,
+// на которые нет ссылок в БД. Возвращает количество удаленных объектов.
+func (c *BlobCollector) Collect(ctx context.Context) (int, error) {
+	if _, err := c.repository.ExpireUploads(ctx, c.uploadTTL); err != nil {
+		c.logger.Error("failed to expire uploads", err)
+		return 0, srvErrors.ErrUnexpected
+	}
+
+	blobs, err := c.store.List(ctx, "")
+	if err != nil {
+		c.logger.Error("failed to list blobs", err)
+		return 0, srvErrors.ErrUnexpected
+	}
+
+	deadline := c.now().Add(-c.grace)
+	keys := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		if b.Modified.Before(deadline) {
+			keys = append(keys, b.Key)
+		}
+	}
+
+	deleted := 0
+	for start := 0; start < len(keys); start += blobBatchSize {
+		batch := keys[start:min(start+blobBatchSize, len(keys))]
+
+		orphans, err := c.repository.Unreferenced(ctx, batch)
+		if err != nil {
+			c.logger.Error("failed to find unreferenced blobs", err)
+			return deleted, srvErrors.ErrUnexpected
+		}
+
+		for _, key := range orphans {
+			if err := c.store.Delete(ctx, key); err != nil {
+				c.logger.Error("failed to delete blob "+key, err)
+				continue
+			}
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Run запускает Collect каждые interval, пока не отменен ctx.
+func (c *BlobCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Ошибки уже записаны в лог, следующая попытка через interval
+			_, _ = c.Collect(ctx)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+func TestBlobCollector_Collect(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	grace := time.Hour
+	uploadTTL := 24 * time.Hour
+	blobs := []entity.Blob{
+		{Key: "secrets/old-orphan", Modified: now.Add(-2 * time.Hour)},
+		{Key: "secrets/old-used", Modified: now.Add(-2 * time.Hour)},
+		{Key: "chunks/u1/0/new", Modified: now.Add(-time.Minute)},
+		{Key: "chunks/u1/1/old", Modified: now.Add(-3 * time.Hour)},
+	}
+	oldKeys := []string{"secrets/old-orphan", "secrets/old-used", "chunks/u1/1/old"}
+
+	type want struct {
+		deleted int
+		err     error
+	}
+
+	tests := []struct {
+		name   string
+		rSetup func(t *testing.T) BlobRepository
+		sSetup func(t *testing.T) BlobStore
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			rSetup: func(t *testing.T) BlobRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockBlobRepository(ctrl)
+				repository.EXPECT().ExpireUploads(gomock.Any(), uploadTTL).Return(int64(1), nil)
+				repository.EXPECT().
+					Unreferenced(gomock.Any(), oldKeys).
+					Return([]string{"secrets/old-orphan", "chunks/u1/1/old"}, nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) BlobStore {
+				ctrl := gomock.NewController(t)
+				store := mocks.NewMockBlobStore(ctrl)
+				store.EXPECT().List(gomock.Any(), "").Return(blobs, nil)
+				store.EXPECT().Delete(gomock.Any(), "secrets/old-orphan").Return(nil)
+				store.EXPECT().Delete(gomock.Any(), "chunks/u1/1/old").Return(nil)
+				return store
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{deleted: 2},
+		},
+		{
+			name: "nothing_old",
+			rSetup: func(t *testing.T) BlobRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockBlobRepository(ctrl)
+				repository.EXPECT().ExpireUploads(gomock.Any(), uploadTTL).Return(int64(1), nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) BlobStore {
+				ctrl := gomock.NewController(t)
+				store := mocks.NewMockBlobStore(ctrl)
+				store.EXPECT().List(gomock.Any(), "").Return(blobs[2:3], nil)
+				return store
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{deleted: 0},
+		},
+		{
+			name: "delete_failed",
+			rSetup: func(t *testing.T) BlobRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockBlobRepository(ctrl)
+				repository.EXPECT().ExpireUploads(gomock.Any(), uploadTTL).Return(int64(1), nil)
+				repository.EXPECT().
+					Unreferenced(gomock.Any(), oldKeys).
+					Return([]string{"secrets/old-orphan", "chunks/u1/1/old"}, nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) BlobStore {
+				ctrl := gomock.NewController(t)
+				store := mocks.NewMockBlobStore(ctrl)
+				store.EXPECT().List(gomock.Any(), "").Return(blobs, nil)
+				store.EXPECT().Delete(gomock.Any(), "secrets/old-orphan").Return(fmt.Errorf("store error"))
+				store.EXPECT().Delete(gomock.Any(), "chunks/u1/1/old").Return(nil)
+				return store
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any())
+				return logger
+			},
+			want: want{deleted: 1},
+		},
+		{
+			name: "list_failed",
+			rSetup: func(t *testing.T) BlobRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockBlobRepository(ctrl)
+				repository.EXPECT().ExpireUploads(gomock.Any(), uploadTTL).Return(int64(1), nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) BlobStore {
+				ctrl := gomock.NewController(t)
+				store := mocks.NewMockBlobStore(ctrl)
+				store.EXPECT().List(gomock.Any(), "").Return(nil, fmt.Errorf("store error"))
+				return store
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+		{
+			name: "repository_failed",
+			rSetup: func(t *testing.T) BlobRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockBlobRepository(ctrl)
+				repository.EXPECT().ExpireUploads(gomock.Any(), uploadTTL).Return(int64(1), nil)
+				repository.EXPECT().
+					Unreferenced(gomock.Any(), oldKeys).
+					Return(nil, fmt.Errorf("db error"))
+				return repository
+			},
+			sSetup: func(t *testing.T) BlobStore {
+				ctrl := gomock.NewController(t)
+				store := mocks.NewMockBlobStore(ctrl)
+				store.EXPECT().List(gomock.Any(), "").Return(blobs, nil)
+				return store
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+		{
+			name: "expire_failed",
+			rSetup: func(t *testing.T) BlobRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockBlobRepository(ctrl)
+				repository.EXPECT().
+					ExpireUploads(gomock.Any(), uploadTTL).
+					Return(int64(0), fmt.Errorf("db error"))
+				return repository
+			},
+			sSetup: func(t *testing.T) BlobStore {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockBlobStore(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error("failed to expire uploads", gomock.Any())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collector := NewBlobCollector(test.lSetup(t), test.rSetup(t), test.sSetup(t), grace, uploadTTL)
+			collector.now = func() time.Time { return now }
+
+			deleted, err := collector.Collect(context.Background())
+			assert.Equal(t, test.want.deleted, deleted)
+			assert.ErrorIs(t, err, test.want.err)
+		})
+	}
+}
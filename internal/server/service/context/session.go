@@ -0,0 +1,33 @@
+package context
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	keySessionID сontextKey = "sessionID"
+	keyClientIP  сontextKey = "clientIP"
+)
+
+func SetSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, keySessionID, sessionID)
+}
+
+func SessionID(ctx context.Context) (string, error) {
+	sessionID, ok := ctx.Value(keySessionID).(string)
+	if !ok {
+		return "", fmt.Errorf("failed to get string value for %s key", keySessionID)
+	}
+	return sessionID, nil
+}
+
+func SetClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, keyClientIP, ip)
+}
+
+// ClientIP возвращает IP клиента или пустую строку, если он не известен.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(keyClientIP).(string)
+	return ip
+}
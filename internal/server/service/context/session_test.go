@@ -0,0 +1,52 @@
+package context
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionID(t *testing.T) {
+	sessionID := "5b1c3f0e-7d0a-4c7e-9a43-3f1d2b8e6a10"
+
+	type want struct {
+		sessionID string
+		hasErr    bool
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want want
+	}{
+		{
+			name: "success",
+			ctx:  SetSessionID(context.Background(), sessionID),
+			want: want{sessionID: sessionID},
+		},
+		{
+			name: "without_key",
+			ctx:  context.Background(),
+			want: want{hasErr: true},
+		},
+		{
+			name: "bad_type",
+			ctx:  context.WithValue(context.Background(), keySessionID, 13),
+			want: want{hasErr: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotSessionID, err := SessionID(test.ctx)
+			gorErr := err != nil
+			assert.Equal(t, test.want.sessionID, gotSessionID, "SessionID value comparation")
+			assert.Equal(t, test.want.hasErr, gorErr, "Error presence comparation")
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	assert.Equal(t, "192.0.2.1", ClientIP(SetClientIP(context.Background(), "192.0.2.1")), "Client IP")
+	assert.Equal(t, "", ClientIP(context.Background()), "Unknown client IP")
+}
@@ -1,13 +1,50 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrUnexpected             = errors.New("unexpected error")
-	ErrAuthUserAlreadyExists  = errors.New("user already exists")
-	ErrAuthInvalidCredentials = errors.New("invalid credentials")
-	ErrAuthInvalidToken       = errors.New("invalid token")
-	ErrAuthTokenExpired       = errors.New("token expired")
-	ErrSecretInvalidData      = errors.New("invalid secret data")
-	ErrSecretNotFound         = errors.New("secret not found")
+	ErrUnexpected               = errors.New("unexpected error")
+	ErrAuthUserAlreadyExists    = errors.New("user already exists")
+	ErrAuthInvalidCredentials   = errors.New("invalid credentials")
+	ErrAuthInvalidToken         = errors.New("invalid token")
+	ErrAuthTokenExpired         = errors.New("token expired")
+	ErrAuthOTPRequired          = errors.New("2fa required")
+	ErrAuthInvalidOTP           = errors.New("invalid 2fa code")
+	ErrAuthTooManyAttempts      = errors.New("too many failed login attempts")
+	ErrAuthBusy                 = errors.New("too many concurrent login attempts")
+	ErrAuthInvalidInvite        = errors.New("invalid invite code")
+	ErrTOTPAlreadyEnabled       = errors.New("2fa already enabled")
+	ErrTOTPNotSetUp             = errors.New("2fa setup not started")
+	ErrTOTPNotEnabled           = errors.New("2fa not enabled")
+	ErrSecretInvalidData        = errors.New("invalid secret data")
+	ErrSecretNotFound           = errors.New("secret not found")
+	ErrSecretConflict           = errors.New("secret was modified by another client")
+	ErrUploadNotFound           = errors.New("upload not found")
+	ErrUploadIncomplete         = errors.New("not all chunks are uploaded")
+	ErrQuotaExceeded            = errors.New("storage quota exceeded")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrPasswordInvalidRequest   = errors.New("invalid password change request")
+	ErrPasswordKeysMismatch     = errors.New("secret keys do not match stored secrets")
+	ErrRecoveryInvalidRequest   = errors.New("invalid recovery key data")
+	ErrRecoveryNotSetUp         = errors.New("recovery key not set up")
+	ErrRecoveryInvalidKey       = errors.New("invalid login or recovery key")
+	ErrAccountKeyInvalidRequest = errors.New("invalid account key request")
+	ErrAccountKeyExists         = errors.New("account key already set")
 )
+
+// RetryError ошибка запроса, который можно повторить через RetryAfter.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
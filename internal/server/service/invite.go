@@ -0,0 +1,43 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+)
+
+// Длина кода приглашения в байтах до кодирования base32
+const inviteCodeLen = 10
+
+// SetInviteKey включает регистрацию только по кодам приглашения, выведенным из key.
+// Код привязан к логину, поэтому по одному коду нельзя проверить, заняты ли другие логины.
+// При пустом key регистрация открыта для всех.
+func (a *Auth) SetInviteKey(key string) {
+	if key == "" {
+		a.inviteKey = nil
+		return
+	}
+	a.inviteKey = []byte(key)
+}
+
+// InviteCode код приглашения для регистрации пользователя с логином login
+// вида `xxxx-xxxx-xxxx-xxxx`. Код не хранится на сервере, он вычисляется из ключа и логина.
+func InviteCode(key, login string) string {
+	code := inviteCode([]byte(key), strings.TrimSpace(login))
+	return strings.ToLower(strings.Join([]string{code[0:4], code[4:8], code[8:12], code[12:16]}, "-"))
+}
+
+// inviteCode код приглашения без разделителей в верхнем регистре.
+func inviteCode(key []byte, login string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gophkeeper invite\x00" + login))
+	return base32.StdEncoding.EncodeToString(mac.Sum(nil)[:inviteCodeLen])
+}
+
+// validInvite сверяет код приглашения с логином за постоянное время,
+// регистр и разделители в коде не учитываются.
+func validInvite(key []byte, login, code string) bool {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hmac.Equal([]byte(code), []byte(inviteCode(key, login)))
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+func Test_validInvite(t *testing.T) {
+	key := "invite key"
+	code := InviteCode(key, "testLogin")
+	require.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, code, "Invite code format")
+	assert.Equal(t, code, InviteCode(key, " testLogin "), "Invite code for login with spaces")
+
+	tests := []struct {
+		name  string
+		login string
+		code  string
+		want  bool
+	}{
+		{
+			name:  "valid",
+			login: "testLogin",
+			code:  code,
+			want:  true,
+		},
+		{
+			name:  "valid_upper_case_without_dashes",
+			login: "testLogin",
+			code:  strings.ToUpper(strings.ReplaceAll(code, "-", "")),
+			want:  true,
+		},
+		{
+			name:  "other_login",
+			login: "otherLogin",
+			code:  code,
+		},
+		{
+			name:  "other_key",
+			login: "testLogin",
+			code:  InviteCode("other key", "testLogin"),
+		},
+		{
+			name:  "empty",
+			login: "testLogin",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, validInvite([]byte(key), test.login, test.code), "Valid invite")
+		})
+	}
+}
+
+func TestAuth_RegisterInvite(t *testing.T) {
+	key := "invite key"
+	credentials := dto.Credentials{Login: "testLogin", AuthKey: testAuthKey, EncrSalt: "c2FsdA"}
+
+	tests := []struct {
+		name    string
+		key     string
+		invite  string
+		rSetup  func(t *testing.T) UserRepository
+		wantErr error
+	}{
+		{
+			name:   "valid_invite",
+			key:    key,
+			invite: InviteCode(key, "testLogin"),
+			rSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().
+					Create(gomock.All(), gomock.All()).
+					Return(entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil)
+				return repository
+			},
+		},
+		{
+			name: "open_registration",
+			rSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().
+					Create(gomock.All(), gomock.All()).
+					Return(entity.User{ID: "d7d81ca8-8b0b-496e-abbd-fd522245c975"}, nil)
+				return repository
+			},
+		},
+		{
+			// логин не проверяется, поэтому ответ не зависит от того, занят ли он
+			name: "negative_no_invite",
+			key:  key,
+			rSetup: func(t *testing.T) UserRepository {
+				return mocks.NewMockUserRepository(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidInvite,
+		},
+		{
+			name:   "negative_invite_for_other_login",
+			key:    key,
+			invite: InviteCode(key, "otherLogin"),
+			rSetup: func(t *testing.T) UserRepository {
+				return mocks.NewMockUserRepository(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidInvite,
+		},
+	}
+
+	priv, pub, err := crypto.GenerateKeyPair()
+	require.Nil(t, err, "Generate rsa key pair")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions, tokens := SessionRepository(nil), RefreshTokenRepository(nil)
+			if test.wantErr == nil {
+				sessions, tokens = testSessions(t), testRefreshTokens(t)
+			}
+			logger := mocks.NewMockLogger(gomock.NewController(t))
+			authService := NewAuth(test.rSetup(t), sessions, tokens, nil, logger, pub, priv, time.Hour, time.Hour)
+			authService.SetInviteKey(test.key)
+
+			cr := credentials
+			cr.Invite = test.invite
+			_, err := authService.Register(context.Background(), cr)
+			assert.ErrorIs(t, err, test.wantErr, "Register user error")
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type LoginAttemptRepository interface {
+	// Attempt учитывает попытку входа по логину из сети network и блокирует вход по правилам p.
+	// Если вход уже заблокирован, попытка не учитывается и возвращается время окончания блокировки.
+	Attempt(ctx context.Context, login, network string, now time.Time, p entity.LockoutPolicy) (time.Time, error)
+	// Reset удаляет попытки входа по логину из сети network.
+	Reset(ctx context.Context, login, network string) error
+	// Purge удаляет устаревшие неудачные попытки входа и возвращает их количество.
+	Purge(ctx context.Context, reset time.Duration) (int64, error)
+}
+
+// Длина префикса сети клиента, по которой считаются попытки входа: подбор пароля
+// из одной сети не блокирует вход владельцу логина из других сетей
+const (
+	lockoutPrefixV4 = 24
+	lockoutPrefixV6 = 64
+)
+
+// Время ожидания свободного слота для вычисления Argon2id,
+// после которого клиенту предлагается повторить запрос
+const (
+	hashWait       = 5 * time.Second
+	hashRetryAfter = time.Second
+)
+
+// SetLockout включает блокировку входа по логину после неудачных попыток по правилам p.
+// Попытки считаются отдельно для каждой сети клиента и учитываются и для несуществующих
+// логинов, чтобы блокировка не выдавала их.
+func (a *Auth) SetLockout(r LoginAttemptRepository, p entity.LockoutPolicy) {
+	a.attempts = r
+	a.lockout = p
+}
+
+// SetHashLimit ограничивает количество одновременных вычислений Argon2id на сервере,
+// каждое занимает 64MB памяти. При n <= 0 ограничение не меняется.
+func (a *Auth) SetHashLimit(n int) {
+	if n > 0 {
+		a.hashSlots = make(chan struct{}, n)
+	}
+}
+
+// checkLockout учитывает попытку входа по логину из сети клиента до проверки учетных данных
+// и возвращает srvErrors.RetryError, если вход заблокирован. Попытка считается неудачной,
+// пока успешный вход не сбросит счетчик, поэтому параллельные попытки не обходят блокировку.
+func (a *Auth) checkLockout(ctx context.Context, login string) error {
+	if a.attempts == nil {
+		return nil
+	}
+
+	now := a.now()
+	lockedUntil, err := a.attempts.Attempt(ctx, login, clientNetwork(ctx), now, a.lockout)
+	if err != nil {
+		a.logger.Error("failed to record login attempt", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	if wait := lockedUntil.Sub(now); wait > 0 {
+		return &srvErrors.RetryError{Err: srvErrors.ErrAuthTooManyAttempts, RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// resetFailures сбрасывает счетчик попыток входа из сети клиента после успешного входа.
+func (a *Auth) resetFailures(ctx context.Context, login string) {
+	if a.attempts == nil {
+		return
+	}
+
+	if err := a.attempts.Reset(ctx, login, clientNetwork(ctx)); err != nil {
+		a.logger.Error("failed to reset login attempts", err)
+	}
+}
+
+// clientNetwork возвращает сеть клиента, по которой считаются попытки входа,
+// или пустую строку, если IP клиента не известен.
+func clientNetwork(ctx context.Context) string {
+	addr, err := netip.ParseAddr(srvContext.ClientIP(ctx))
+	if err != nil {
+		return ""
+	}
+
+	addr = addr.Unmap()
+	bits := lockoutPrefixV6
+	if addr.Is4() {
+		bits = lockoutPrefixV4
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+
+	return prefix.String()
+}
+
+// PurgeLoginAttempts удаляет неудачные попытки входа старше a.lockout.Reset,
+// иначе таблица растет от попыток входа в несуществующие логины.
+// Возвращает количество удаленных записей.
+func (a *Auth) PurgeLoginAttempts(ctx context.Context) (int64, error) {
+	if a.attempts == nil {
+		return 0, nil
+	}
+
+	deleted, err := a.attempts.Purge(ctx, a.lockout.Reset)
+	if err != nil {
+		a.logger.Error("failed to purge login attempts", err)
+		return 0, srvErrors.ErrUnexpected
+	}
+
+	return deleted, nil
+}
+
+// RunLoginAttemptsGC запускает PurgeLoginAttempts каждые interval, пока не отменен ctx.
+func (a *Auth) RunLoginAttemptsGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Ошибки уже записаны в лог, следующая попытка через interval
+			_, _ = a.PurgeLoginAttempts(ctx)
+		}
+	}
+}
+
+// acquireHash занимает слот для вычисления Argon2id и возвращает функцию его освобождения.
+// Если свободного слота нет дольше hashWait, возвращает srvErrors.RetryError.
+func (a *Auth) acquireHash(ctx context.Context) (func(), error) {
+	if a.hashSlots == nil {
+		return func() {}, nil
+	}
+
+	timer := time.NewTimer(hashWait)
+	defer timer.Stop()
+
+	select {
+	case a.hashSlots <- struct{}{}:
+		return func() { <-a.hashSlots }, nil
+	case <-timer.C:
+		return nil, &srvErrors.RetryError{Err: srvErrors.ErrAuthBusy, RetryAfter: hashRetryAfter}
+	case <-ctx.Done():
+		return nil, &srvErrors.RetryError{Err: srvErrors.ErrAuthBusy, RetryAfter: hashRetryAfter}
+	}
+}
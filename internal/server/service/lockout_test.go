@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+func TestAuth_LoginLockout(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	policy := entity.LockoutPolicy{Threshold: 3, Base: 30 * time.Second, Max: time.Hour, Reset: 24 * time.Hour}
+
+	authSalt, err := crypto.GenerateRandomBytes(crypto.SaltLen)
+	require.Nil(t, err, "Generate auth salt for entity")
+	user := entity.User{
+		ID:          "d7d81ca8-8b0b-496e-abbd-fd522245c975",
+		Hash:        hashAuthKey(testAuthKey, authSalt),
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		AuthVersion: dto.AuthVersionKey,
+	}
+	goodCredentials := dto.Credentials{Login: "testLogin", AuthKey: testAuthKey}
+	badCredentials := dto.Credentials{Login: "testLogin", AuthKey: testNewAuthKey}
+	network := "192.0.2.0/24"
+	lockedUntil := now.Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		credentials dto.Credentials
+		rSetup      func(t *testing.T) UserRepository
+		aSetup      func(t *testing.T) LoginAttemptRepository
+		lSetup      func(t *testing.T) Logger
+		wantErr     error
+		wantRetry   time.Duration
+	}{
+		{
+			name:        "succes_resets_failures",
+			credentials: goodCredentials,
+			rSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), "testLogin").Return(user, nil)
+				return repository
+			},
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().Attempt(gomock.Any(), "testLogin", network, now, policy).Return(time.Time{}, nil)
+				attempts.EXPECT().Reset(gomock.Any(), "testLogin", network).Return(nil)
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			name:        "negative_locked",
+			credentials: goodCredentials,
+			rSetup: func(t *testing.T) UserRepository {
+				return mocks.NewMockUserRepository(gomock.NewController(t))
+			},
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().Attempt(gomock.Any(), "testLogin", network, now, policy).Return(lockedUntil, nil)
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr:   srvErrors.ErrAuthTooManyAttempts,
+			wantRetry: time.Minute,
+		},
+		{
+			// попытка уже учтена до проверки, неудача не сбрасывает счетчик
+			name:        "negative_failure_counted",
+			credentials: badCredentials,
+			rSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), "testLogin").Return(user, nil)
+				return repository
+			},
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().Attempt(gomock.Any(), "testLogin", network, now, policy).Return(time.Time{}, nil)
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			// попытки для несуществующего логина учитываются так же, как для существующего
+			name:        "negative_unknown_user_counted",
+			credentials: dto.Credentials{Login: "unknown", AuthKey: testAuthKey},
+			rSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), "unknown").Return(entity.User{}, repErrors.ErrNotFound)
+				return repository
+			},
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().Attempt(gomock.Any(), "unknown", network, now, policy).Return(time.Time{}, nil)
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			// такой логин не зарегистрировать, попытка не учитывается
+			name:        "negative_login_too_long",
+			credentials: dto.Credentials{Login: strings.Repeat("a", dto.CredentialsLoginMaxLen+1), AuthKey: testAuthKey},
+			rSetup: func(t *testing.T) UserRepository {
+				return mocks.NewMockUserRepository(gomock.NewController(t))
+			},
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				return mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			name:        "negative_attempt_error",
+			credentials: goodCredentials,
+			rSetup: func(t *testing.T) UserRepository {
+				return mocks.NewMockUserRepository(gomock.NewController(t))
+			},
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().
+					Attempt(gomock.Any(), "testLogin", network, now, policy).
+					Return(time.Time{}, fmt.Errorf("any error"))
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				logger := mocks.NewMockLogger(gomock.NewController(t))
+				logger.EXPECT().Error("failed to record login attempt", gomock.Any())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	priv, pub, err := crypto.GenerateKeyPair()
+	require.Nil(t, err, "Generate rsa key pair")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService := NewAuth(
+				test.rSetup(t), testSessions(t), testRefreshTokens(t), testTOTP(t), test.lSetup(t),
+				pub, priv, time.Hour, time.Hour,
+			)
+			authService.SetLockout(test.aSetup(t), policy)
+			authService.now = func() time.Time { return now }
+
+			ctx := srvContext.SetClientIP(context.Background(), "192.0.2.1")
+			_, err := authService.Login(ctx, test.credentials)
+			assert.ErrorIs(t, err, test.wantErr, "Login error")
+
+			var retry *srvErrors.RetryError
+			if test.wantRetry > 0 {
+				require.ErrorAs(t, err, &retry, "Retry error")
+				assert.Equal(t, test.wantRetry, retry.RetryAfter, "Retry after")
+			} else {
+				assert.False(t, errors.As(err, &retry), "Unexpected retry error")
+			}
+		})
+	}
+}
+
+func Test_clientNetwork(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{name: "ipv4", ip: "192.0.2.77", want: "192.0.2.0/24"},
+		{name: "ipv6", ip: "2001:db8:1:2:3:4:5:6", want: "2001:db8:1:2::/64"},
+		{name: "ipv4_mapped", ip: "::ffff:192.0.2.77", want: "192.0.2.0/24"},
+		{name: "unknown", ip: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := srvContext.SetClientIP(context.Background(), test.ip)
+			assert.Equal(t, test.want, clientNetwork(ctx), "Client network")
+		})
+	}
+}
+
+func TestAuth_PurgeLoginAttempts(t *testing.T) {
+	policy := entity.LockoutPolicy{Threshold: 3, Base: 30 * time.Second, Max: time.Hour, Reset: 24 * time.Hour}
+
+	type want struct {
+		deleted int64
+		err     error
+	}
+
+	tests := []struct {
+		name   string
+		aSetup func(t *testing.T) LoginAttemptRepository
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().Purge(gomock.Any(), policy.Reset).Return(int64(3), nil)
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			want: want{deleted: 3},
+		},
+		{
+			name: "negative_repository_error",
+			aSetup: func(t *testing.T) LoginAttemptRepository {
+				attempts := mocks.NewMockLoginAttemptRepository(gomock.NewController(t))
+				attempts.EXPECT().Purge(gomock.Any(), policy.Reset).Return(int64(0), fmt.Errorf("any error"))
+				return attempts
+			},
+			lSetup: func(t *testing.T) Logger {
+				logger := mocks.NewMockLogger(gomock.NewController(t))
+				logger.EXPECT().Error("failed to purge login attempts", gomock.Any())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService := NewAuth(nil, nil, nil, nil, test.lSetup(t), nil, nil, time.Hour, time.Hour)
+			authService.SetLockout(test.aSetup(t), policy)
+
+			deleted, err := authService.PurgeLoginAttempts(context.Background())
+			assert.ErrorIs(t, err, test.want.err, "Purge error")
+			assert.Equal(t, test.want.deleted, deleted, "Deleted attempts")
+		})
+	}
+
+	t.Run("lockout_disabled", func(t *testing.T) {
+		authService := NewAuth(nil, nil, nil, nil, nil, nil, nil, time.Hour, time.Hour)
+		deleted, err := authService.PurgeLoginAttempts(context.Background())
+		assert.Nil(t, err, "Purge error")
+		assert.Zero(t, deleted, "Deleted attempts")
+	})
+}
+
+func TestAuth_acquireHash(t *testing.T) {
+	authService := NewAuth(nil, nil, nil, nil, nil, nil, nil, time.Hour, time.Hour)
+	authService.SetHashLimit(1)
+
+	release, err := authService.acquireHash(context.Background())
+	require.Nil(t, err, "Acquire free slot")
+
+	// все слоты заняты, запрос отменен до освобождения слота
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = authService.acquireHash(ctx)
+	assert.ErrorIs(t, err, srvErrors.ErrAuthBusy, "Acquire busy slot")
+	var retry *srvErrors.RetryError
+	require.ErrorAs(t, err, &retry, "Retry error")
+	assert.Equal(t, hashRetryAfter, retry.RetryAfter, "Retry after")
+
+	release()
+	release, err = authService.acquireHash(context.Background())
+	require.Nil(t, err, "Acquire released slot")
+	release()
+}
@@ -36,17 +36,31 @@ func (m *MockSecretRepository) EXPECT() *MockSecretRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockSecretRepository) Create(ctx context.Context, secret entity.Secret) error {
+func (m *MockSecretRepository) Create(ctx context.Context, secret entity.Secret, quota entity.Quota) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, secret)
+	ret := m.ctrl.Call(m, "Create", ctx, secret, quota)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockSecretRepositoryMockRecorder) Create(ctx, secret interface{}) *gomock.Call {
+func (mr *MockSecretRepositoryMockRecorder) Create(ctx, secret, quota interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSecretRepository)(nil).Create), ctx, secret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSecretRepository)(nil).Create), ctx, secret, quota)
+}
+
+// DeleteForUser mocks base method.
+func (m *MockSecretRepository) DeleteForUser(ctx context.Context, secretID uint64, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteForUser", ctx, secretID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteForUser indicates an expected call of DeleteForUser.
+func (mr *MockSecretRepositoryMockRecorder) DeleteForUser(ctx, secretID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteForUser", reflect.TypeOf((*MockSecretRepository)(nil).DeleteForUser), ctx, secretID, userID)
 }
 
 // GetAllUnencryptedByUser mocks base method.
@@ -64,6 +78,36 @@ func (mr *MockSecretRepositoryMockRecorder) GetAllUnencryptedByUser(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUnencryptedByUser", reflect.TypeOf((*MockSecretRepository)(nil).GetAllUnencryptedByUser), ctx, userID)
 }
 
+// GetChangesByUser mocks base method.
+func (m *MockSecretRepository) GetChangesByUser(ctx context.Context, userID string, since uint64) (entity.SecretChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangesByUser", ctx, userID, since)
+	ret0, _ := ret[0].(entity.SecretChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangesByUser indicates an expected call of GetChangesByUser.
+func (mr *MockSecretRepositoryMockRecorder) GetChangesByUser(ctx, userID, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangesByUser", reflect.TypeOf((*MockSecretRepository)(nil).GetChangesByUser), ctx, userID, since)
+}
+
+// GetChunkForUser mocks base method.
+func (m *MockSecretRepository) GetChunkForUser(ctx context.Context, secretID uint64, userID string, n uint32) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChunkForUser", ctx, secretID, userID, n)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChunkForUser indicates an expected call of GetChunkForUser.
+func (mr *MockSecretRepositoryMockRecorder) GetChunkForUser(ctx, secretID, userID, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChunkForUser", reflect.TypeOf((*MockSecretRepository)(nil).GetChunkForUser), ctx, secretID, userID, n)
+}
+
 // GetForUser mocks base method.
 func (m *MockSecretRepository) GetForUser(ctx context.Context, secretID uint64, userID string) (entity.Secret, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +122,47 @@ func (mr *MockSecretRepositoryMockRecorder) GetForUser(ctx, secretID, userID int
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUser", reflect.TypeOf((*MockSecretRepository)(nil).GetForUser), ctx, secretID, userID)
 }
+
+// GetKeysByUser mocks base method.
+func (m *MockSecretRepository) GetKeysByUser(ctx context.Context, userID string) ([]entity.SecretKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeysByUser", ctx, userID)
+	ret0, _ := ret[0].([]entity.SecretKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeysByUser indicates an expected call of GetKeysByUser.
+func (mr *MockSecretRepositoryMockRecorder) GetKeysByUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysByUser", reflect.TypeOf((*MockSecretRepository)(nil).GetKeysByUser), ctx, userID)
+}
+
+// UpdateForUser mocks base method.
+func (m *MockSecretRepository) UpdateForUser(ctx context.Context, secret entity.Secret, quota entity.Quota) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateForUser", ctx, secret, quota)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateForUser indicates an expected call of UpdateForUser.
+func (mr *MockSecretRepositoryMockRecorder) UpdateForUser(ctx, secret, quota interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateForUser", reflect.TypeOf((*MockSecretRepository)(nil).UpdateForUser), ctx, secret, quota)
+}
+
+// UsageByUser mocks base method.
+func (m *MockSecretRepository) UsageByUser(ctx context.Context, userID string) (entity.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsageByUser", ctx, userID)
+	ret0, _ := ret[0].(entity.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsageByUser indicates an expected call of UsageByUser.
+func (mr *MockSecretRepositoryMockRecorder) UsageByUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsageByUser", reflect.TypeOf((*MockSecretRepository)(nil).UsageByUser), ctx, userID)
+}
@@ -35,6 +35,35 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AuthProfiles mocks base method.
+func (m *MockUserRepository) AuthProfiles(ctx context.Context) ([]entity.AuthProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthProfiles", ctx)
+	ret0, _ := ret[0].([]entity.AuthProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthProfiles indicates an expected call of AuthProfiles.
+func (mr *MockUserRepositoryMockRecorder) AuthProfiles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthProfiles", reflect.TypeOf((*MockUserRepository)(nil).AuthProfiles), ctx)
+}
+
+// ChangePassword mocks base method.
+func (m *MockUserRepository) ChangePassword(ctx context.Context, change entity.PasswordChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserRepositoryMockRecorder) ChangePassword(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserRepository)(nil).ChangePassword), ctx, change)
+}
+
 // Create mocks base method.
 func (m *MockUserRepository) Create(ctx context.Context, user entity.User) (entity.User, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +108,31 @@ func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
 }
+
+// SetAccountKey mocks base method.
+func (m *MockUserRepository) SetAccountKey(ctx context.Context, change entity.AccountKeyChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountKey", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccountKey indicates an expected call of SetAccountKey.
+func (mr *MockUserRepositoryMockRecorder) SetAccountKey(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountKey", reflect.TypeOf((*MockUserRepository)(nil).SetAccountKey), ctx, change)
+}
+
+// SetAuthKey mocks base method.
+func (m *MockUserRepository) SetAuthKey(ctx context.Context, change entity.AuthKeyChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAuthKey", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAuthKey indicates an expected call of SetAuthKey.
+func (mr *MockUserRepositoryMockRecorder) SetAuthKey(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAuthKey", reflect.TypeOf((*MockUserRepository)(nil).SetAuthKey), ctx, change)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type RecoveryRepository interface {
+	// Get возвращает ключи восстановления пользователя.
+	Get(ctx context.Context, userID string) (entity.RecoveryKey, error)
+	// Save сохраняет ключи восстановления, заменяя предыдущие.
+	Save(ctx context.Context, key entity.RecoveryKey) error
+}
+
+// Recovery сервис восстановления доступа по ключу восстановления.
+// Ключ восстановления не покидает клиента, сервер хранит зашифрованный им мастер ключ
+// и хэш выведенного из него ключа аутентификации.
+type Recovery struct {
+	auth       *Auth
+	repository RecoveryRepository
+	secrets    SecretRepository
+	// Ключи восстановления, с которыми сверяется ключ аутентификации,
+	// если логина нет или восстановление не настроено
+	fakeKey entity.RecoveryKey
+}
+
+// NewRecovery создает сервис восстановления доступа, вход после сброса пароля выполняет сервис a.
+func NewRecovery(a *Auth, r RecoveryRepository, s SecretRepository) *Recovery {
+	return &Recovery{auth: a, repository: r, secrets: s, fakeKey: newFakeRecoveryKey()}
+}
+
+// newFakeRecoveryKey ключи восстановления со случайным хэшем, ключ аутентификации к нему подобрать нельзя.
+func newFakeRecoveryKey() entity.RecoveryKey {
+	hash, _ := crypto.GenerateRandomBytes(sha256.Size)
+	return entity.RecoveryKey{AuthHash: hex.EncodeToString(hash)}
+}
+
+// Setup сохраняет ключи восстановления текущего пользователя, заменяя предыдущие.
+// Ключ восстановления дает доступ к аккаунту, поэтому, как и при смене пароля,
+// нужен текущий ключ аутентификации или пароль, одной сессии недостаточно.
+func (v *Recovery) Setup(ctx context.Context, req *dto.RecoverySetupRequest) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		v.auth.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	user, err := v.auth.repository.GetByID(ctx, userID)
+	if err != nil {
+		v.auth.logger.Error("failed to get user", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	if err := v.auth.checkCredentials(ctx, user, req.Password, req.AuthKey); err != nil {
+		return err
+	}
+
+	key := req.Recovery
+	if err := key.Validate(true); err != nil {
+		return fmt.Errorf("%w: %w", srvErrors.ErrRecoveryInvalidRequest, err)
+	}
+
+	err = v.repository.Save(ctx, entity.RecoveryKey{
+		UserID:      userID,
+		MasterKey:   key.MasterKey,
+		RecoveryKey: key.RecoveryKey,
+		AuthHash:    hashRecoveryAuthKey(key.AuthKey),
+	})
+	if err != nil {
+		v.auth.logger.Error("failed to save recovery key", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// Get возвращает ключи восстановления текущего пользователя без ключа аутентификации.
+// Если восстановление не настроено, возвращает srvErrors.ErrRecoveryNotSetUp.
+func (v *Recovery) Get(ctx context.Context) (dto.RecoveryKey, error) {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		v.auth.logger.Error("failed to get user id", err)
+		return dto.RecoveryKey{}, srvErrors.ErrUnexpected
+	}
+
+	key, err := v.repository.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return dto.RecoveryKey{}, srvErrors.ErrRecoveryNotSetUp
+		}
+		v.auth.logger.Error("failed to get recovery key", err)
+		return dto.RecoveryKey{}, srvErrors.ErrUnexpected
+	}
+
+	return dto.RecoveryKey{MasterKey: key.MasterKey, RecoveryKey: key.RecoveryKey}, nil
+}
+
+// Start возвращает зашифрованный ключом восстановления ключ аккаунта, а если аккаунт
+// еще не переведен на ключ аккаунта, мастер ключ и ключи данных секретов пользователя
+// для перешифровки на клиенте. Если логин или ключ аутентификации не подходят,
+// возвращает srvErrors.ErrRecoveryInvalidKey.
+func (v *Recovery) Start(ctx context.Context, req dto.RecoveryStartRequest) (dto.RecoveryStartResponse, error) {
+	var resp dto.RecoveryStartResponse
+
+	user, key, err := v.verify(ctx, req.Login, req.AuthKey)
+	if err != nil {
+		return resp, err
+	}
+
+	// Ключи данных зашифрованы ключом аккаунта и при сбросе пароля не меняются
+	if user.AccountKey != "" {
+		resp.AccountKey = key.MasterKey
+		resp.Keys = []dto.SecretKey{}
+		return resp, nil
+	}
+
+	keys, err := v.secrets.GetKeysByUser(ctx, user.ID)
+	if err != nil {
+		v.auth.logger.Error("failed to get secret keys for user", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	resp.MasterKey = key.MasterKey
+	resp.Keys = make([]dto.SecretKey, 0, len(keys))
+	for _, k := range keys {
+		resp.Keys = append(resp.Keys, dto.SecretKey{ID: k.SecretID, Key: k.EncryptedKey, Version: k.Version})
+	}
+
+	return resp, nil
+}
+
+// Complete сбрасывает пароль пользователя по ключу восстановления: заменяет хэш ключа аутентификации,
+// соли и ключ аккаунта, а если аккаунт еще не переведен на ключ аккаунта, ключи данных
+// и ключи восстановления в одной транзакции, завершает все сессии пользователя
+// и выполняет вход на устройстве клиента.
+// Если включена двухфакторная аутентификация, требует ее код, как и при входе.
+func (v *Recovery) Complete(ctx context.Context, req dto.RecoveryRequest) (resp dto.AuthResponse, err error) {
+	user, _, err := v.verify(ctx, req.Login, req.AuthKey)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := req.Validate(); err != nil {
+		return resp, fmt.Errorf("%w: %w", srvErrors.ErrPasswordInvalidRequest, err)
+	}
+
+	if (user.AccountKey == "") != (req.AccountKey == "") {
+		return resp, srvErrors.ErrPasswordKeysMismatch
+	}
+
+	if err := v.auth.checkSecondFactor(ctx, user.ID, strings.TrimSpace(req.OTP)); err != nil {
+		return resp, err
+	}
+
+	change, err := v.auth.newPasswordChange(user, req.NewAuthKey, req.EncrSalt, req.KDF, req.AccountKey, req.Keys)
+	if err != nil {
+		return resp, err
+	}
+	if req.Recovery != nil {
+		change.Recovery = &entity.RecoveryKey{
+			UserID:      user.ID,
+			MasterKey:   req.Recovery.MasterKey,
+			RecoveryKey: req.Recovery.RecoveryKey,
+		}
+	}
+
+	err = v.auth.repository.ChangePassword(ctx, change)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNoRowsUpdated), errors.Is(err, repErrors.ErrIncomplete):
+			// пароль или секреты изменили параллельно, ключи нужно получить заново
+			return resp, srvErrors.ErrPasswordKeysMismatch
+		default:
+			v.auth.logger.Error("failed to reset password", err)
+			return resp, srvErrors.ErrUnexpected
+		}
+	}
+
+	device := trimCredentials(dto.Credentials{Device: req.Device}).Device
+	resp.Token, resp.RefreshToken, err = v.auth.issueTokens(ctx, user, device)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.EncrSalt = req.EncrSalt
+	resp.KDF = kdfResponse(change.KDF)
+	resp.AccountKey = req.AccountKey
+	return resp, nil
+}
+
+// verify находит пользователя по логину и сверяет ключ аутентификации с хэшем из его ключей восстановления.
+// Если логина нет или восстановление не настроено, ключ сверяется с v.fakeKey теми же функциями,
+// и возвращается та же ошибка, что и для неверного ключа.
+func (v *Recovery) verify(ctx context.Context, login, authKey string) (entity.User, entity.RecoveryKey, error) {
+	found := true
+	user, err := v.auth.repository.FindByLogin(ctx, strings.TrimSpace(login))
+	if err != nil {
+		if !errors.Is(err, repErrors.ErrNotFound) {
+			v.auth.logger.Error("failed to find user", err)
+			return user, entity.RecoveryKey{}, srvErrors.ErrUnexpected
+		}
+		found = false
+	}
+
+	key := v.fakeKey
+	if found {
+		key, err = v.repository.Get(ctx, user.ID)
+		if err != nil {
+			if !errors.Is(err, repErrors.ErrNotFound) {
+				v.auth.logger.Error("failed to get recovery key", err)
+				return user, key, srvErrors.ErrUnexpected
+			}
+			key, found = v.fakeKey, false
+		}
+	}
+
+	hashes := v.auth.hashes
+	if hashes.compare([]byte(key.AuthHash), []byte(hashes.hashRecovery(authKey))) != 1 || !found {
+		return user, entity.RecoveryKey{}, srvErrors.ErrRecoveryInvalidKey
+	}
+
+	return user, key, nil
+}
+
+// hashRecoveryAuthKey хэш ключа аутентификации для хранения в БД, ключ выведен
+// из случайного ключа восстановления, поэтому соль и медленное хэширование не нужны.
+func hashRecoveryAuthKey(authKey string) string {
+	sum := sha256.Sum256([]byte(authKey))
+	return hex.EncodeToString(sum[:])
+}
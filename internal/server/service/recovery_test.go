@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+const (
+	testRecoveryUserID  = "d7d81ca8-8b0b-496e-abbd-fd522245c975"
+	testRecoveryLogin   = "testLogin"
+	testRecoveryAuthKey = "QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVphYmNkZWY"
+)
+
+func TestRecovery_Setup(t *testing.T) {
+	authSalt := []byte("auth salt")
+	user := entity.User{
+		ID:          testRecoveryUserID,
+		Login:       testRecoveryLogin,
+		AuthSalt:    base64.RawStdEncoding.EncodeToString(authSalt),
+		Hash:        hashAuthKey(testAuthKey, authSalt),
+		AuthVersion: dto.AuthVersionKey,
+	}
+	goodKey := dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms", AuthKey: testRecoveryAuthKey}
+
+	tests := []struct {
+		name    string
+		req     dto.RecoverySetupRequest
+		rSetup  func(t *testing.T) RecoveryRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "succes",
+			req:  dto.RecoverySetupRequest{AuthKey: testAuthKey, Recovery: goodKey},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockRecoveryRepository(ctrl)
+				repository.EXPECT().Save(gomock.Any(), entity.RecoveryKey{
+					UserID:      testRecoveryUserID,
+					MasterKey:   goodKey.MasterKey,
+					RecoveryKey: goodKey.RecoveryKey,
+					AuthHash:    hashRecoveryAuthKey(testRecoveryAuthKey),
+				}).Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			// сессии без текущего ключа аутентификации недостаточно
+			name: "negative_without_credentials",
+			req:  dto.RecoverySetupRequest{Recovery: goodKey},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				return mocks.NewMockRecoveryRepository(gomock.NewController(t))
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			name: "negative_wrong_auth_key",
+			req:  dto.RecoverySetupRequest{AuthKey: testNewAuthKey, Recovery: goodKey},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				return mocks.NewMockRecoveryRepository(gomock.NewController(t))
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidCredentials,
+		},
+		{
+			name: "negative_short_auth_key",
+			req: dto.RecoverySetupRequest{
+				AuthKey:  testAuthKey,
+				Recovery: dto.RecoveryKey{MasterKey: "bWs", RecoveryKey: "cms", AuthKey: "a2V5"},
+			},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				return mocks.NewMockRecoveryRepository(gomock.NewController(t))
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrRecoveryInvalidRequest,
+		},
+		{
+			name: "negative_repository_error",
+			req:  dto.RecoverySetupRequest{AuthKey: testAuthKey, Recovery: goodKey},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockRecoveryRepository(ctrl)
+				repository.EXPECT().Save(gomock.Any(), gomock.Any()).Return(fmt.Errorf("any error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error("failed to save recovery key", gomock.Any())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users := mocks.NewMockUserRepository(gomock.NewController(t))
+			users.EXPECT().GetByID(gomock.Any(), testRecoveryUserID).Return(user, nil)
+
+			ctx := srvContext.SetUserID(context.Background(), testRecoveryUserID)
+			auth := NewAuth(users, nil, nil, nil, test.lSetup(t), nil, nil, time.Hour, time.Hour)
+			recovery := NewRecovery(auth, test.rSetup(t), nil)
+
+			err := recovery.Setup(ctx, &test.req)
+			assert.ErrorIs(t, err, test.wantErr, "Setup error")
+		})
+	}
+}
+
+func TestRecovery_Start(t *testing.T) {
+	user := entity.User{ID: testRecoveryUserID, Login: testRecoveryLogin}
+	stored := entity.RecoveryKey{
+		UserID:      testRecoveryUserID,
+		MasterKey:   "bWs",
+		RecoveryKey: "cms",
+		AuthHash:    hashRecoveryAuthKey(testRecoveryAuthKey),
+	}
+
+	type want struct {
+		resp dto.RecoveryStartResponse
+		err  error
+	}
+
+	tests := []struct {
+		name   string
+		req    dto.RecoveryStartRequest
+		uSetup func(t *testing.T) UserRepository
+		rSetup func(t *testing.T) RecoveryRepository
+		sSetup func(t *testing.T) SecretRepository
+		want   want
+	}{
+		{
+			name: "succes",
+			req:  dto.RecoveryStartRequest{Login: testRecoveryLogin, AuthKey: testRecoveryAuthKey},
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				return repository
+			},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				repository := mocks.NewMockRecoveryRepository(gomock.NewController(t))
+				repository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(stored, nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) SecretRepository {
+				repository := mocks.NewMockSecretRepository(gomock.NewController(t))
+				repository.EXPECT().
+					GetKeysByUser(gomock.Any(), testRecoveryUserID).
+					Return([]entity.SecretKey{{SecretID: 1, EncryptedKey: "a2V5MQ"}}, nil)
+				return repository
+			},
+			want: want{
+				resp: dto.RecoveryStartResponse{MasterKey: "bWs", Keys: []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}}},
+			},
+		},
+		{
+			name: "succes_with_account_key",
+			req:  dto.RecoveryStartRequest{Login: testRecoveryLogin, AuthKey: testRecoveryAuthKey},
+			uSetup: func(t *testing.T) UserRepository {
+				migrated := user
+				migrated.AccountKey = "YWs"
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(migrated, nil)
+				return repository
+			},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				repository := mocks.NewMockRecoveryRepository(gomock.NewController(t))
+				repository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(stored, nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) SecretRepository {
+				return mocks.NewMockSecretRepository(gomock.NewController(t))
+			},
+			want: want{
+				resp: dto.RecoveryStartResponse{AccountKey: "bWs", Keys: []dto.SecretKey{}},
+			},
+		},
+		{
+			name: "negative_unknown_login",
+			req:  dto.RecoveryStartRequest{Login: "unknown", AuthKey: testRecoveryAuthKey},
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), "unknown").Return(entity.User{}, repErrors.ErrNotFound)
+				return repository
+			},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				return mocks.NewMockRecoveryRepository(gomock.NewController(t))
+			},
+			sSetup: func(t *testing.T) SecretRepository {
+				return mocks.NewMockSecretRepository(gomock.NewController(t))
+			},
+			want: want{err: srvErrors.ErrRecoveryInvalidKey},
+		},
+		{
+			name: "negative_not_set_up",
+			req:  dto.RecoveryStartRequest{Login: testRecoveryLogin, AuthKey: testRecoveryAuthKey},
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				return repository
+			},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				repository := mocks.NewMockRecoveryRepository(gomock.NewController(t))
+				repository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(entity.RecoveryKey{}, repErrors.ErrNotFound)
+				return repository
+			},
+			sSetup: func(t *testing.T) SecretRepository {
+				return mocks.NewMockSecretRepository(gomock.NewController(t))
+			},
+			want: want{err: srvErrors.ErrRecoveryInvalidKey},
+		},
+		{
+			name: "negative_wrong_auth_key",
+			req:  dto.RecoveryStartRequest{Login: testRecoveryLogin, AuthKey: strings.ToLower(testRecoveryAuthKey)},
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				return repository
+			},
+			rSetup: func(t *testing.T) RecoveryRepository {
+				repository := mocks.NewMockRecoveryRepository(gomock.NewController(t))
+				repository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(stored, nil)
+				return repository
+			},
+			sSetup: func(t *testing.T) SecretRepository {
+				return mocks.NewMockSecretRepository(gomock.NewController(t))
+			},
+			want: want{err: srvErrors.ErrRecoveryInvalidKey},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger := mocks.NewMockLogger(gomock.NewController(t))
+			auth := NewAuth(test.uSetup(t), nil, nil, nil, logger, nil, nil, time.Hour, time.Hour)
+			recovery := NewRecovery(auth, test.rSetup(t), test.sSetup(t))
+
+			resp, err := recovery.Start(context.Background(), test.req)
+			assert.ErrorIs(t, err, test.want.err, "Start error")
+			assert.Equal(t, test.want.resp, resp, "Start response")
+		})
+	}
+}
+
+func TestRecovery_verifyHashCalls(t *testing.T) {
+	user := entity.User{ID: testRecoveryUserID, Login: testRecoveryLogin}
+	stored := entity.RecoveryKey{UserID: testRecoveryUserID, AuthHash: hashRecoveryAuthKey(testRecoveryAuthKey)}
+
+	tests := []struct {
+		name   string
+		login  string
+		uSetup func(repository *mocks.MockUserRepository)
+		rSetup func(repository *mocks.MockRecoveryRepository)
+	}{
+		{
+			name:  "wrong_key",
+			login: testRecoveryLogin,
+			uSetup: func(repository *mocks.MockUserRepository) {
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+			},
+			rSetup: func(repository *mocks.MockRecoveryRepository) {
+				repository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(stored, nil)
+			},
+		},
+		{
+			name:  "recovery_not_set_up",
+			login: testRecoveryLogin,
+			uSetup: func(repository *mocks.MockUserRepository) {
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+			},
+			rSetup: func(repository *mocks.MockRecoveryRepository) {
+				repository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(entity.RecoveryKey{}, repErrors.ErrNotFound)
+			},
+		},
+		{
+			name:  "unknown_login",
+			login: "unknownLogin",
+			uSetup: func(repository *mocks.MockUserRepository) {
+				repository.EXPECT().FindByLogin(gomock.Any(), "unknownLogin").Return(entity.User{}, repErrors.ErrNotFound)
+			},
+			rSetup: func(repository *mocks.MockRecoveryRepository) {},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			users := mocks.NewMockUserRepository(ctrl)
+			test.uSetup(users)
+			keys := mocks.NewMockRecoveryRepository(ctrl)
+			test.rSetup(keys)
+
+			auth := NewAuth(users, nil, nil, nil, mocks.NewMockLogger(ctrl), nil, nil, time.Hour, time.Hour)
+			calls := countHashes(auth)
+			recovery := NewRecovery(auth, keys, nil)
+
+			// ключ не подходит ни к сохраненному хэшу, ни к фиктивному, сверка одна и та же
+			_, err := recovery.Start(context.Background(), dto.RecoveryStartRequest{Login: test.login, AuthKey: testAuthKey})
+			assert.ErrorIs(t, err, srvErrors.ErrRecoveryInvalidKey, "Start error")
+			assert.Equal(t, hashCalls{hashRecovery: 1, compare: 1}, *calls, "Hash calls")
+		})
+	}
+}
+
+func TestRecovery_Complete(t *testing.T) {
+	priv, pub, err := crypto.GenerateKeyPair()
+	require.Nil(t, err, "Generate rsa key pair")
+
+	user := entity.User{ID: testRecoveryUserID, Login: testRecoveryLogin, Hash: "b2xkSGFzaA"}
+	stored := entity.RecoveryKey{UserID: testRecoveryUserID, AuthHash: hashRecoveryAuthKey(testRecoveryAuthKey)}
+	goodRequest := dto.RecoveryRequest{
+		Login:      testRecoveryLogin,
+		AuthKey:    testRecoveryAuthKey,
+		NewAuthKey: testNewAuthKey,
+		EncrSalt:   "c2FsdA",
+		Keys:       []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}},
+		Recovery:   &dto.RecoveryKey{MasterKey: "bmV3TWs", RecoveryKey: "bmV3Ums"},
+		Device:     "laptop",
+	}
+
+	// checkChange проверяет, что завершаются все сессии и заменяются ключи восстановления
+	checkChange := func(t *testing.T, change entity.PasswordChange) {
+		assert.Equal(t, testRecoveryUserID, change.UserID, "User ID")
+		assert.Equal(t, user.Hash, change.OldHash, "Old hash")
+		assert.Empty(t, change.SessionID, "Session ID")
+		assert.Equal(t, goodRequest.EncrSalt, change.EncrSalt, "Encryption salt")
+		assert.Equal(t, []entity.SecretKey{{SecretID: 1, EncryptedKey: "a2V5MQ"}}, change.Keys, "Secret keys")
+		assert.Equal(
+			t,
+			&entity.RecoveryKey{UserID: testRecoveryUserID, MasterKey: "bmV3TWs", RecoveryKey: "bmV3Ums"},
+			change.Recovery,
+			"Recovery key",
+		)
+
+		salt, err := base64.RawStdEncoding.DecodeString(change.AuthSalt)
+		require.Nil(t, err, "Decode auth salt")
+		assert.Equal(t, hashAuthKey(goodRequest.NewAuthKey, salt), change.Hash, "New hash")
+	}
+
+	tests := []struct {
+		name    string
+		req     dto.RecoveryRequest
+		uSetup  func(t *testing.T) UserRepository
+		fSetup  func(t *testing.T) TOTPRepository
+		wantErr error
+	}{
+		{
+			name: "succes",
+			req:  goodRequest,
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				repository.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.PasswordChange) error {
+						checkChange(t, change)
+						return nil
+					})
+				return repository
+			},
+			fSetup: testTOTP,
+		},
+		{
+			name: "succes_with_account_key",
+			req: dto.RecoveryRequest{
+				Login:      testRecoveryLogin,
+				AuthKey:    testRecoveryAuthKey,
+				NewAuthKey: goodRequest.NewAuthKey,
+				EncrSalt:   goodRequest.EncrSalt,
+				KDF:        &dto.KDFParams{Time: 4, Memory: 128 * 1024, Threads: 2},
+				AccountKey: "bmV3QWs",
+			},
+			uSetup: func(t *testing.T) UserRepository {
+				migrated := user
+				migrated.AccountKey = "YWs"
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(migrated, nil)
+				repository.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, change entity.PasswordChange) error {
+						// ключи данных и ключи восстановления открываются ключом аккаунта и не меняются
+						assert.Equal(t, "bmV3QWs", change.AccountKey, "Account key")
+						assert.Empty(t, change.Keys, "Secret keys")
+						assert.Nil(t, change.Recovery, "Recovery key")
+						assert.Empty(t, change.SessionID, "Session ID")
+						return nil
+					})
+				return repository
+			},
+			fSetup: testTOTP,
+		},
+		{
+			name: "negative_account_key_required",
+			req:  goodRequest,
+			uSetup: func(t *testing.T) UserRepository {
+				migrated := user
+				migrated.AccountKey = "YWs"
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(migrated, nil)
+				return repository
+			},
+			fSetup: func(t *testing.T) TOTPRepository {
+				return mocks.NewMockTOTPRepository(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrPasswordKeysMismatch,
+		},
+		{
+			name: "negative_wrong_auth_key",
+			req: dto.RecoveryRequest{
+				Login:   testRecoveryLogin,
+				AuthKey: strings.ToLower(testRecoveryAuthKey),
+			},
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				return repository
+			},
+			fSetup: func(t *testing.T) TOTPRepository {
+				return mocks.NewMockTOTPRepository(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrRecoveryInvalidKey,
+		},
+		{
+			name: "negative_invalid_new_auth_key",
+			req: dto.RecoveryRequest{
+				Login:      testRecoveryLogin,
+				AuthKey:    testRecoveryAuthKey,
+				NewAuthKey: "c2hvcnQ",
+				EncrSalt:   goodRequest.EncrSalt,
+				Recovery:   goodRequest.Recovery,
+			},
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				return repository
+			},
+			fSetup: func(t *testing.T) TOTPRepository {
+				return mocks.NewMockTOTPRepository(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrPasswordInvalidRequest,
+		},
+		{
+			name: "negative_otp_required",
+			req:  goodRequest,
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				return repository
+			},
+			fSetup: func(t *testing.T) TOTPRepository {
+				repository := mocks.NewMockTOTPRepository(gomock.NewController(t))
+				repository.EXPECT().
+					Get(gomock.Any(), testRecoveryUserID).
+					Return(entity.TOTP{UserID: testRecoveryUserID, Enabled: true}, nil)
+				return repository
+			},
+			wantErr: srvErrors.ErrAuthOTPRequired,
+		},
+		{
+			name: "negative_keys_mismatch",
+			req:  goodRequest,
+			uSetup: func(t *testing.T) UserRepository {
+				repository := mocks.NewMockUserRepository(gomock.NewController(t))
+				repository.EXPECT().FindByLogin(gomock.Any(), testRecoveryLogin).Return(user, nil)
+				repository.EXPECT().ChangePassword(gomock.Any(), gomock.Any()).Return(repErrors.ErrIncomplete)
+				return repository
+			},
+			fSetup:  testTOTP,
+			wantErr: srvErrors.ErrPasswordKeysMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rRepository := mocks.NewMockRecoveryRepository(gomock.NewController(t))
+			rRepository.EXPECT().Get(gomock.Any(), testRecoveryUserID).Return(stored, nil).AnyTimes()
+			logger := mocks.NewMockLogger(gomock.NewController(t))
+
+			auth := NewAuth(
+				test.uSetup(t), testSessions(t), testRefreshTokens(t), test.fSetup(t),
+				logger, pub, priv, time.Hour, time.Hour,
+			)
+			recovery := NewRecovery(auth, rRepository, nil)
+
+			resp, err := recovery.Complete(context.Background(), test.req)
+			assert.ErrorIs(t, err, test.wantErr, "Complete error")
+			if test.wantErr == nil {
+				assert.NotEmpty(t, resp.Token, "Token")
+				assert.NotEmpty(t, resp.RefreshToken, "Refresh token")
+				assert.Equal(t, test.req.EncrSalt, resp.EncrSalt, "Encryption salt")
+				assert.Equal(t, test.req.AccountKey, resp.AccountKey, "Account key")
+				if test.req.KDF != nil {
+					assert.Equal(t, test.req.KDF, resp.KDF, "KDF params")
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
 	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
@@ -14,25 +15,43 @@ import (
 )
 
 type SecretRepository interface {
-	// Create создает пользовательский секрет в БД.
-	Create(ctx context.Context, secret entity.Secret) error
+	// Create создает пользовательский секрет в БД, если он помещается в quota.
+	Create(ctx context.Context, secret entity.Secret, quota entity.Quota) error
 	// GetForUser возвращает пользовательский секрет по secretID и userID.
 	GetForUser(ctx context.Context, secretID uint64, userID string) (entity.Secret, error)
+	// UpdateForUser изменяет пользовательский секрет, если его версия совпадает с secret.Version
+	// и новые данные помещаются в quota.
+	UpdateForUser(ctx context.Context, secret entity.Secret, quota entity.Quota) error
+	// DeleteForUser удаляет пользовательский секрет по secretID и userID.
+	DeleteForUser(ctx context.Context, secretID uint64, userID string) error
+	// GetChunkForUser возвращает часть n данных пользовательского секрета по secretID и userID.
+	GetChunkForUser(ctx context.Context, secretID uint64, userID string, n uint32) ([]byte, error)
+	// UsageByUser возвращает использование хранилища пользователем.
+	UsageByUser(ctx context.Context, userID string) (entity.Usage, error)
+	// GetKeysByUser возвращает зашифрованные ключи данных всех секретов пользователя.
+	GetKeysByUser(ctx context.Context, userID string) ([]entity.SecretKey, error)
 	// GetAlluUnencryptedByUser возвращает не зашифрованные данные для всех записей пользователя
 	GetAllUnencryptedByUser(ctx context.Context, userID string) ([]entity.SecretInfo, error)
+	// GetChangesByUser возвращает не зашифрованные данные записей пользователя, созданных
+	// или измененных после ревизии since, и записи об удаленных после нее секретах из одного снимка БД.
+	GetChangesByUser(ctx context.Context, userID string, since uint64) (entity.SecretChanges, error)
 }
 
 // Secret сервис загрузки и отдачи секретов пользователя
 type Secret struct {
 	logger     Logger
 	repository SecretRepository
+	quota      entity.Quota
 }
 
-func NewSecret(l Logger, s SecretRepository) *Secret {
-	return &Secret{logger: l, repository: s}
+// NewSecret создает сервис секретов, размер и количество секретов пользователя ограничены quota.
+func NewSecret(l Logger, s SecretRepository, quota entity.Quota) *Secret {
+	return &Secret{logger: l, repository: s, quota: quota}
 }
 
-// Save сохраняет секрет на сервере
+// Save сохраняет секрет на сервере.
+// Если запрос не прошел проверку, возвращает ошибку, содержащую srvErrors.ErrSecretInvalidData,
+// если секрет не помещается в квоту пользователя, возвращает srvErrors.ErrQuotaExceeded.
 func (s *Secret) Save(ctx context.Context, secret *dto.SecretRequest) error {
 	userID, err := srvContext.UserID(ctx)
 	if err != nil {
@@ -40,6 +59,10 @@ func (s *Secret) Save(ctx context.Context, secret *dto.SecretRequest) error {
 		return srvErrors.ErrUnexpected
 	}
 
+	if err := secret.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", srvErrors.ErrSecretInvalidData, err)
+	}
+
 	meta, err := json.Marshal(secret.Meta)
 	if err != nil {
 		s.logger.Error("failed encode secret metadata to json", err)
@@ -48,14 +71,19 @@ func (s *Secret) Save(ctx context.Context, secret *dto.SecretRequest) error {
 
 	enity := entity.Secret{
 		UserID:        userID,
+		UID:           secret.UID,
 		DataType:      secret.DataType,
 		Name:          secret.Name,
 		MetaData:      string(meta),
 		EncryptedKey:  secret.EncrData.Key,
+		KeyVersion:    secret.EncrData.Version,
 		EncryptedData: secret.EncrData.Data,
 	}
 
-	err = s.repository.Create(ctx, enity)
+	err = s.repository.Create(ctx, enity, s.quota)
+	if errors.Is(err, repErrors.ErrQuotaExceeded) {
+		return srvErrors.ErrQuotaExceeded
+	}
 	if err != nil {
 		s.logger.Error("failed create secret", err)
 		return srvErrors.ErrUnexpected
@@ -90,18 +118,140 @@ func (s *Secret) Secret(ctx context.Context, secretID uint64) (dto.SecretRespons
 
 	return dto.SecretResponse{
 		ID:       entity.ID,
+		UID:      entity.UID,
 		DataType: entity.DataType,
 		Name:     entity.Name,
 		Meta:     meta,
 		Created:  entity.Created,
 		Updated:  entity.Updated,
+		Revision: entity.Revision,
+		Version:  entity.Version,
+		Chunks:   entity.Chunks,
 		EncrData: dto.EncryptedData{
-			Key:  entity.EncryptedKey,
-			Data: entity.EncryptedData,
+			Key:     entity.EncryptedKey,
+			Data:    entity.EncryptedData,
+			Version: entity.KeyVersion,
 		},
 	}, nil
 }
 
+// Update изменяет секрет по secretID, если он принадлежит текущему пользователю.
+// Если данные секрета не переданы, меняются только название и метаданные.
+// Если версия секрета на сервере отличается от secret.Version, возвращает srvErrors.ErrSecretConflict.
+// Если запрос не прошел проверку или UID в нем отличается от UID секрета, возвращает ошибку,
+// содержащую srvErrors.ErrSecretInvalidData, если новые данные не помещаются в квоту
+// пользователя, возвращает srvErrors.ErrQuotaExceeded.
+func (s *Secret) Update(ctx context.Context, secretID uint64, secret *dto.SecretUpdateRequest) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	if err := secret.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", srvErrors.ErrSecretInvalidData, err)
+	}
+
+	meta, err := json.Marshal(secret.Meta)
+	if err != nil {
+		s.logger.Error("failed encode secret metadata to json", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	enity := entity.Secret{
+		ID:            secretID,
+		UserID:        userID,
+		UID:           secret.UID,
+		Name:          secret.Name,
+		MetaData:      string(meta),
+		EncryptedKey:  secret.EncrData.Key,
+		KeyVersion:    secret.EncrData.Version,
+		EncryptedData: secret.EncrData.Data,
+		Version:       secret.Version,
+	}
+
+	err = s.repository.UpdateForUser(ctx, enity, s.quota)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNotFound):
+			return srvErrors.ErrSecretNotFound
+		case errors.Is(err, repErrors.ErrNoRowsUpdated):
+			return srvErrors.ErrSecretConflict
+		case errors.Is(err, repErrors.ErrUIDMismatch):
+			return fmt.Errorf("%w: uid does not match the secret", srvErrors.ErrSecretInvalidData)
+		case errors.Is(err, repErrors.ErrQuotaExceeded):
+			return srvErrors.ErrQuotaExceeded
+		default:
+			s.logger.Error("failed to update secret for user", err)
+			return srvErrors.ErrUnexpected
+		}
+	}
+
+	return nil
+}
+
+// Chunk возвращает часть n данных секрета по secretID, если он принадлежит текущему пользователю.
+func (s *Secret) Chunk(ctx context.Context, secretID uint64, n uint32) ([]byte, error) {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+
+	data, err := s.repository.GetChunkForUser(ctx, secretID, userID, n)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return nil, srvErrors.ErrSecretNotFound
+		}
+		s.logger.Error("failed to get secret chunk for user", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+
+	return data, nil
+}
+
+// Delete удаляет секрет по secretID, если он принадлежит текущему пользователю.
+func (s *Secret) Delete(ctx context.Context, secretID uint64) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	err = s.repository.DeleteForUser(ctx, secretID, userID)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return srvErrors.ErrSecretNotFound
+		}
+		s.logger.Error("failed to delete secret for user", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// Usage возвращает использование хранилища текущим пользователем и его квоту.
+func (s *Secret) Usage(ctx context.Context) (dto.Usage, error) {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return dto.Usage{}, srvErrors.ErrUnexpected
+	}
+
+	usage, err := s.repository.UsageByUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get usage for user", err)
+		return dto.Usage{}, srvErrors.ErrUnexpected
+	}
+
+	return dto.Usage{
+		Bytes:      usage.Bytes,
+		Secrets:    usage.Secrets,
+		MaxBytes:   s.quota.MaxBytes,
+		MaxSecrets: s.quota.MaxSecrets,
+	}, nil
+}
+
 // InfoList возвращает информацию о всех секретах пользователя.
 func (s *Secret) InfoList(ctx context.Context) ([]dto.SecretInfo, error) {
 	userID, err := srvContext.UserID(ctx)
@@ -116,13 +266,80 @@ func (s *Secret) InfoList(ctx context.Context) ([]dto.SecretInfo, error) {
 		return nil, srvErrors.ErrUnexpected
 	}
 
-	var list []dto.SecretInfo
+	list, err := infoList(secrets)
+	if err != nil {
+		s.logger.Error("failed to unmarhal metadata", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+
+	return list, nil
+}
+
+// Keys возвращает зашифрованные ключи данных всех секретов текущего пользователя,
+// клиент перешифровывает их при смене пароля.
+func (s *Secret) Keys(ctx context.Context) ([]dto.SecretKey, error) {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+
+	keys, err := s.repository.GetKeysByUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get secret keys for user", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+
+	list := make([]dto.SecretKey, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, dto.SecretKey{ID: k.SecretID, Key: k.EncryptedKey, Version: k.Version})
+	}
+
+	return list, nil
+}
+
+// Changes возвращает информацию о секретах пользователя, созданных или измененных
+// после ревизии since, и ID секретов, удаленных после нее.
+func (s *Secret) Changes(ctx context.Context, since uint64) (dto.SecretChanges, error) {
+	changes := dto.SecretChanges{Revision: since, Deleted: []uint64{}}
+
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return changes, srvErrors.ErrUnexpected
+	}
+
+	// Оба списка из одного снимка, иначе курсор может пропустить изменение между запросами
+	found, err := s.repository.GetChangesByUser(ctx, userID, since)
+	if err != nil {
+		s.logger.Error("failed to get secret changes for user", err)
+		return changes, srvErrors.ErrUnexpected
+	}
+
+	changes.Changed, err = infoList(found.Changed)
+	if err != nil {
+		s.logger.Error("failed to unmarhal metadata", err)
+		return changes, srvErrors.ErrUnexpected
+	}
+
+	for _, secret := range found.Changed {
+		changes.Revision = max(changes.Revision, secret.Revision)
+	}
+	for _, tombstone := range found.Deleted {
+		changes.Deleted = append(changes.Deleted, tombstone.SecretID)
+		changes.Revision = max(changes.Revision, tombstone.Revision)
+	}
+
+	return changes, nil
+}
+
+// infoList преобразует данные секретов из БД в информацию для клиента.
+func infoList(secrets []entity.SecretInfo) ([]dto.SecretInfo, error) {
+	list := make([]dto.SecretInfo, 0, len(secrets))
 	for _, secret := range secrets {
 		var meta []dto.MetaData
-		err = json.Unmarshal([]byte(secret.MetaData), &meta)
-		if err != nil {
-			s.logger.Error("failed to unmarhal metadata", err)
-			return nil, srvErrors.ErrUnexpected
+		if err := json.Unmarshal([]byte(secret.MetaData), &meta); err != nil {
+			return nil, err
 		}
 
 		list = append(
@@ -133,6 +350,10 @@ func (s *Secret) InfoList(ctx context.Context) ([]dto.SecretInfo, error) {
 				Name:     secret.Name,
 				Meta:     meta,
 				Created:  secret.Created,
+				Updated:  secret.Updated,
+				Revision: secret.Revision,
+				Version:  secret.Version,
+				Chunks:   secret.Chunks,
 			},
 		)
 	}
@@ -17,10 +17,22 @@ import (
 	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
 )
 
+var testQuota = entity.Quota{MaxBytes: 1 << 20, MaxSecrets: 100}
+
+const testSecretUID = "3f2b8c1e-5a4d-4e6f-9b7a-0c1d2e3f4a5b"
+
 func TestSecret_Save(t *testing.T) {
 	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
 	goodCtx := srvContext.SetUserID(context.Background(), userID)
-	requestDTO := dto.SecretRequest{}
+	requestDTO := dto.SecretRequest{
+		UID:      testSecretUID,
+		DataType: dto.SecretTypeText,
+		Name:     "name",
+		Meta:     []dto.MetaData{},
+		EncrData: dto.EncryptedData{Key: "a2V5", Data: []byte("data")},
+	}
+	invalidDTO := requestDTO
+	invalidDTO.DataType = "unknown"
 
 	tests := []struct {
 		name    string
@@ -38,7 +50,7 @@ func TestSecret_Save(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repository := mocks.NewMockSecretRepository(ctrl)
 				repository.EXPECT().
-					Create(gomock.All(), gomock.All()).
+					Create(gomock.All(), gomock.All(), testQuota).
 					Return(nil)
 				return repository
 			},
@@ -64,6 +76,38 @@ func TestSecret_Save(t *testing.T) {
 			},
 			wantErr: srvErrors.ErrUnexpected,
 		},
+		{
+			name:   "invalid_data",
+			ctx:    goodCtx,
+			secret: &invalidDTO,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretInvalidData,
+		},
+		{
+			name:   "quota_exceeded",
+			ctx:    goodCtx,
+			secret: &requestDTO,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					Create(gomock.All(), gomock.All(), testQuota).
+					Return(repErrors.ErrQuotaExceeded)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrQuotaExceeded,
+		},
 		{
 			name:   "repository_error",
 			ctx:    goodCtx,
@@ -72,7 +116,7 @@ func TestSecret_Save(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repository := mocks.NewMockSecretRepository(ctrl)
 				repository.EXPECT().
-					Create(gomock.All(), gomock.All()).
+					Create(gomock.All(), gomock.All(), testQuota).
 					Return(fmt.Errorf("repositoryerror"))
 				return repository
 			},
@@ -91,7 +135,7 @@ func TestSecret_Save(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			repository := test.rSetup(t)
 			logger := test.lSetup(t)
-			secretService := NewSecret(logger, repository)
+			secretService := NewSecret(logger, repository, testQuota)
 			err := secretService.Save(test.ctx, test.secret)
 			assert.ErrorIs(t, err, test.wantErr, "Save secret error")
 		})
@@ -124,7 +168,7 @@ func TestSecret_Secret(t *testing.T) {
 				repository := mocks.NewMockSecretRepository(ctrl)
 				repository.EXPECT().
 					GetForUser(gomock.All(), gomock.All(), gomock.All()).
-					Return(entity.Secret{MetaData: "[]"}, nil)
+					Return(entity.Secret{UID: testSecretUID, MetaData: "[]"}, nil)
 				return repository
 			},
 			lSetup: func(t *testing.T) Logger {
@@ -132,7 +176,7 @@ func TestSecret_Secret(t *testing.T) {
 				return mocks.NewMockLogger(ctrl)
 			},
 			want: want{
-				secret: dto.SecretResponse{Meta: []dto.MetaData{}},
+				secret: dto.SecretResponse{UID: testSecretUID, Meta: []dto.MetaData{}},
 			},
 		},
 		{
@@ -226,7 +270,7 @@ func TestSecret_Secret(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			repository := test.rSetup(t)
 			logger := test.lSetup(t)
-			secretService := NewSecret(logger, repository)
+			secretService := NewSecret(logger, repository, testQuota)
 			secret, err := secretService.Secret(test.ctx, test.secretID)
 			assert.ErrorIs(t, err, test.want.err, "Retrieve secret error")
 			if err == nil {
@@ -236,6 +280,275 @@ func TestSecret_Secret(t *testing.T) {
 	}
 }
 
+func TestSecret_Update(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+	requestDTO := dto.SecretUpdateRequest{Name: "name", Meta: []dto.MetaData{}, Version: 3}
+	wantEntity := entity.Secret{
+		ID:       13,
+		UserID:   userID,
+		Name:     "name",
+		MetaData: "[]",
+		Version:  3,
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		secret  *dto.SecretUpdateRequest
+		rSetup  func(t *testing.T) SecretRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UpdateForUser(gomock.All(), wantEntity, testQuota).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+		{
+			name: "secret_not_found",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UpdateForUser(gomock.All(), gomock.All(), testQuota).
+					Return(repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretNotFound,
+		},
+		{
+			name:   "key_without_data",
+			ctx:    goodCtx,
+			secret: &dto.SecretUpdateRequest{Name: "name", EncrData: dto.EncryptedData{Key: "a2V5"}},
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretInvalidData,
+		},
+		{
+			name: "conflict",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UpdateForUser(gomock.All(), gomock.All(), testQuota).
+					Return(repErrors.ErrNoRowsUpdated)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretConflict,
+		},
+		{
+			name: "uid_mismatch",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UpdateForUser(gomock.All(), gomock.All(), testQuota).
+					Return(repErrors.ErrUIDMismatch)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretInvalidData,
+		},
+		{
+			name: "quota_exceeded",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UpdateForUser(gomock.All(), gomock.All(), testQuota).
+					Return(repErrors.ErrQuotaExceeded)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrQuotaExceeded,
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UpdateForUser(gomock.All(), gomock.All(), testQuota).
+					Return(fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to update secret for user", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			secretService := NewSecret(logger, repository, testQuota)
+			secret := test.secret
+			if secret == nil {
+				secret = &requestDTO
+			}
+			err := secretService.Update(test.ctx, 13, secret)
+			assert.ErrorIs(t, err, test.wantErr, "Update secret error")
+		})
+	}
+}
+
+func TestSecret_Delete(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		secretID uint64
+		rSetup   func(t *testing.T) SecretRepository
+		lSetup   func(t *testing.T) Logger
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			ctx:      goodCtx,
+			secretID: 13,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), uint64(13), userID).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name:     "without_user",
+			ctx:      context.TODO(),
+			secretID: 13,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+		{
+			name:     "secret_not_found",
+			ctx:      goodCtx,
+			secretID: 13,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), uint64(13), userID).
+					Return(repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretNotFound,
+		},
+		{
+			name:     "repository_error",
+			ctx:      goodCtx,
+			secretID: 13,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), uint64(13), userID).
+					Return(fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to delete secret for user", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			secretService := NewSecret(logger, repository, testQuota)
+			err := secretService.Delete(test.ctx, test.secretID)
+			assert.ErrorIs(t, err, test.wantErr, "Delete secret error")
+		})
+	}
+}
+
 func TestSecret_InfoList(t *testing.T) {
 	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
 	goodCtx := srvContext.SetUserID(context.Background(), userID)
@@ -339,7 +652,7 @@ func TestSecret_InfoList(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			repository := test.rSetup(t)
 			logger := test.lSetup(t)
-			secretService := NewSecret(logger, repository)
+			secretService := NewSecret(logger, repository, testQuota)
 
 			list, err := secretService.InfoList(test.ctx)
 			assert.ErrorIs(t, err, test.want.err, "Retrieve secret error")
@@ -349,3 +662,411 @@ func TestSecret_InfoList(t *testing.T) {
 		})
 	}
 }
+
+func TestSecret_Changes(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	type want struct {
+		changes dto.SecretChanges
+		err     error
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		rSetup func(t *testing.T) SecretRepository
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChangesByUser(gomock.All(), userID, uint64(40)).
+					Return(entity.SecretChanges{
+						Changed: []entity.SecretInfo{{ID: 13, MetaData: "[]", Revision: 41}},
+						Deleted: []entity.SecretTombstone{{SecretID: 7, Revision: 43}},
+					}, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				changes: dto.SecretChanges{
+					Revision: 43,
+					Changed:  []dto.SecretInfo{{ID: 13, Meta: []dto.MetaData{}, Revision: 41}},
+					Deleted:  []uint64{7},
+				},
+			},
+		},
+		{
+			name: "no_changes",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChangesByUser(gomock.All(), userID, uint64(40)).
+					Return(entity.SecretChanges{Changed: []entity.SecretInfo{}, Deleted: []entity.SecretTombstone{}}, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				changes: dto.SecretChanges{Revision: 40, Changed: []dto.SecretInfo{}, Deleted: []uint64{}},
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChangesByUser(gomock.All(), gomock.All(), gomock.All()).
+					Return(entity.SecretChanges{}, fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get secret changes for user", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+		{
+			name: "bad_meta_data",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChangesByUser(gomock.All(), gomock.All(), gomock.All()).
+					Return(entity.SecretChanges{Changed: []entity.SecretInfo{{MetaData: ""}}}, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to unmarhal metadata", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			secretService := NewSecret(logger, repository, testQuota)
+
+			changes, err := secretService.Changes(test.ctx, 40)
+			assert.ErrorIs(t, err, test.want.err, "Changes error")
+			if err == nil {
+				assert.Equal(t, test.want.changes, changes, "Secret changes")
+			}
+		})
+	}
+}
+
+func TestSecret_Chunk(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+	data := []byte("chunk data")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rSetup  func(t *testing.T) SecretRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChunkForUser(gomock.All(), uint64(13), userID, uint32(2)).
+					Return(data, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+		{
+			name: "chunk_not_found",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChunkForUser(gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(nil, repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretNotFound,
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetChunkForUser(gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(nil, fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get secret chunk for user", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			secretService := NewSecret(logger, repository, testQuota)
+
+			got, err := secretService.Chunk(test.ctx, 13, 2)
+			assert.ErrorIs(t, err, test.wantErr, "Get chunk error")
+			if err == nil {
+				assert.Equal(t, data, got, "Chunk data")
+			}
+		})
+	}
+}
+
+func TestSecret_Usage(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	type want struct {
+		usage dto.Usage
+		err   error
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		rSetup func(t *testing.T) SecretRepository
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UsageByUser(gomock.All(), userID).
+					Return(entity.Usage{Bytes: 2048, Secrets: 3}, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				usage: dto.Usage{Bytes: 2048, Secrets: 3, MaxBytes: testQuota.MaxBytes, MaxSecrets: testQuota.MaxSecrets},
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					UsageByUser(gomock.All(), userID).
+					Return(entity.Usage{}, fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get usage for user", gomock.All())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			secretService := NewSecret(logger, repository, testQuota)
+			usage, err := secretService.Usage(test.ctx)
+			assert.Equal(t, test.want.usage, usage, "Usage")
+			assert.ErrorIs(t, err, test.want.err, "Usage error")
+		})
+	}
+}
+
+func TestSecret_Keys(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	type want struct {
+		keys []dto.SecretKey
+		err  error
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		rSetup func(t *testing.T) SecretRepository
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetKeysByUser(gomock.All(), userID).
+					Return([]entity.SecretKey{{SecretID: 1, EncryptedKey: "a2V5MQ"}, {SecretID: 3, EncryptedKey: "a2V5Mw"}}, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				keys: []dto.SecretKey{{ID: 1, Key: "a2V5MQ"}, {ID: 3, Key: "a2V5Mw"}},
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSecretRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SecretRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSecretRepository(ctrl)
+				repository.EXPECT().
+					GetKeysByUser(gomock.All(), userID).
+					Return(nil, fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get secret keys for user", gomock.All())
+				return logger
+			},
+			want: want{err: srvErrors.ErrUnexpected},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			secretService := NewSecret(logger, repository, testQuota)
+			keys, err := secretService.Keys(test.ctx)
+			assert.Equal(t, test.want.keys, keys, "Keys")
+			assert.ErrorIs(t, err, test.want.err, "Keys error")
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type SessionRepository interface {
+	// Create создает сессию пользователя.
+	Create(ctx context.Context, session entity.Session) (entity.Session, error)
+	// Touch возвращает действующую сессию по id и отмечает время последнего запроса и IP клиента.
+	Touch(ctx context.Context, id string, ip string) (entity.Session, error)
+	// ListByUser возвращает действующие сессии пользователя.
+	ListByUser(ctx context.Context, userID string) ([]entity.Session, error)
+	// DeleteForUser удаляет сессию пользователя вместе с ее refresh токенами.
+	DeleteForUser(ctx context.Context, id string, userID string) error
+}
+
+// Session сервис для работы с сессиями пользователя
+type Session struct {
+	logger     Logger
+	repository SessionRepository
+}
+
+func NewSession(l Logger, r SessionRepository) *Session {
+	return &Session{logger: l, repository: r}
+}
+
+// List возвращает действующие сессии текущего пользователя.
+func (s *Session) List(ctx context.Context) ([]dto.Session, error) {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+	// ID текущей сессии нужен только для пометки в списке
+	current, _ := srvContext.SessionID(ctx)
+
+	sessions, err := s.repository.ListByUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get sessions for user", err)
+		return nil, srvErrors.ErrUnexpected
+	}
+
+	list := make([]dto.Session, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, dto.Session{
+			ID:       session.ID,
+			Device:   session.Device,
+			IP:       session.IP,
+			Created:  session.Created,
+			LastSeen: session.LastSeen,
+			Current:  session.ID == current,
+		})
+	}
+
+	return list, nil
+}
+
+// Revoke завершает сессию текущего пользователя по id,
+// токены этой сессии перестают действовать.
+func (s *Session) Revoke(ctx context.Context, id string) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	err = s.repository.DeleteForUser(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return srvErrors.ErrSessionNotFound
+		}
+		s.logger.Error("failed to delete session for user", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// Logout завершает сессию, из которой выполнен запрос.
+func (s *Session) Logout(ctx context.Context) error {
+	sessionID, err := srvContext.SessionID(ctx)
+	if err != nil {
+		s.logger.Error("failed to get session id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return s.Revoke(ctx, sessionID)
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+func TestSession_List(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	otherID := "0f9d1b7e-2c4b-4f8a-8e57-6a1c2d3e4f50"
+	goodCtx := srvContext.SetSessionID(srvContext.SetUserID(context.Background(), userID), testSessionID)
+	seen := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	sessions := []entity.Session{
+		{ID: testSessionID, UserID: userID, Device: "laptop", IP: "192.0.2.1", Created: seen, LastSeen: seen},
+		{ID: otherID, UserID: userID, Device: "phone", IP: "192.0.2.2", Created: seen, LastSeen: seen},
+	}
+
+	type want struct {
+		list []dto.Session
+		err  error
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		rSetup func(t *testing.T) SessionRepository
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					ListByUser(gomock.All(), userID).
+					Return(sessions, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				list: []dto.Session{
+					{ID: testSessionID, Device: "laptop", IP: "192.0.2.1", Created: seen, LastSeen: seen, Current: true},
+					{ID: otherID, Device: "phone", IP: "192.0.2.2", Created: seen, LastSeen: seen},
+				},
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.Background(),
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSessionRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					ListByUser(gomock.All(), userID).
+					Return(nil, fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get sessions for user", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessionService := NewSession(test.lSetup(t), test.rSetup(t))
+			list, err := sessionService.List(test.ctx)
+			assert.ErrorIs(t, err, test.want.err, "List sessions error")
+			assert.Equal(t, test.want.list, list, "Sessions")
+		})
+	}
+}
+
+func TestSession_Revoke(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rSetup  func(t *testing.T) SessionRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), testSessionID, userID).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.Background(),
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSessionRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+		{
+			name: "not_found",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), testSessionID, userID).
+					Return(repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSessionNotFound,
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), testSessionID, userID).
+					Return(fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to delete session for user", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessionService := NewSession(test.lSetup(t), test.rSetup(t))
+			err := sessionService.Revoke(test.ctx, testSessionID)
+			assert.ErrorIs(t, err, test.wantErr, "Revoke session error")
+		})
+	}
+}
+
+func TestSession_Logout(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rSetup  func(t *testing.T) SessionRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  srvContext.SetSessionID(srvContext.SetUserID(context.Background(), userID), testSessionID),
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockSessionRepository(ctrl)
+				repository.EXPECT().
+					DeleteForUser(gomock.All(), testSessionID, userID).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "without_session",
+			ctx:  srvContext.SetUserID(context.Background(), userID),
+			rSetup: func(t *testing.T) SessionRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockSessionRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get session id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessionService := NewSession(test.lSetup(t), test.rSetup(t))
+			err := sessionService.Logout(test.ctx)
+			assert.ErrorIs(t, err, test.wantErr, "Logout error")
+		})
+	}
+}
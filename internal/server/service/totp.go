@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/crypto"
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+const (
+	totpIssuer = "GophKeeper"
+	// Длительность шага TOTP в секундах
+	totpPeriod = 30
+	// Допустимое расхождение часов клиента и сервера в шагах
+	totpSkew = 1
+	// Количество кодов восстановления
+	recoveryCodesCount = 10
+	// Длина кода восстановления в байтах, 6 байт дают 10 символов base32
+	recoveryCodeLen = 6
+)
+
+type TOTPRepository interface {
+	// Get возвращает TOTP секрет пользователя.
+	Get(ctx context.Context, userID string) (entity.TOTP, error)
+	// Save сохраняет неподтвержденный секрет.
+	Save(ctx context.Context, totp entity.TOTP) error
+	// Enable включает двухфакторную аутентификацию и заменяет коды восстановления.
+	Enable(ctx context.Context, userID string, step int64, codeHashes []string) error
+	// UseStep отмечает шаг step использованным.
+	UseStep(ctx context.Context, userID string, step int64) error
+	// UseRecoveryCode отмечает код восстановления использованным.
+	UseRecoveryCode(ctx context.Context, userID string, hash string) error
+	// Delete выключает двухфакторную аутентификацию.
+	Delete(ctx context.Context, userID string) error
+}
+
+// TwoFactor сервис для настройки двухфакторной аутентификации
+type TwoFactor struct {
+	logger     Logger
+	users      UserRepository
+	repository TOTPRepository
+	now        func() time.Time
+}
+
+func NewTwoFactor(l Logger, u UserRepository, r TOTPRepository) *TwoFactor {
+	return &TwoFactor{logger: l, users: u, repository: r, now: time.Now}
+}
+
+// Setup генерирует новый TOTP секрет для текущего пользователя.
+// Секрет начинает проверяться при входе только после подтверждения кодом в Enable.
+func (f *TwoFactor) Setup(ctx context.Context) (dto.TOTPSetup, error) {
+	var setup dto.TOTPSetup
+
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		f.logger.Error("failed to get user id", err)
+		return setup, srvErrors.ErrUnexpected
+	}
+
+	user, err := f.users.GetByID(ctx, userID)
+	if err != nil {
+		f.logger.Error("failed to get user", err)
+		return setup, srvErrors.ErrUnexpected
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Login,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		f.logger.Error("failed to generate totp secret", err)
+		return setup, srvErrors.ErrUnexpected
+	}
+
+	err = f.repository.Save(ctx, entity.TOTP{UserID: userID, Secret: key.Secret()})
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNoRowsUpdated) {
+			return setup, srvErrors.ErrTOTPAlreadyEnabled
+		}
+		f.logger.Error("failed to save totp secret", err)
+		return setup, srvErrors.ErrUnexpected
+	}
+
+	setup.Secret = key.Secret()
+	setup.URI = key.URL()
+	return setup, nil
+}
+
+// Enable включает двухфакторную аутентификацию, если code подходит к секрету из Setup.
+// Возвращает коды восстановления, которые больше нигде не показываются.
+func (f *TwoFactor) Enable(ctx context.Context, code string) (dto.RecoveryCodes, error) {
+	var resp dto.RecoveryCodes
+
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		f.logger.Error("failed to get user id", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	t, err := f.repository.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return resp, srvErrors.ErrTOTPNotSetUp
+		}
+		f.logger.Error("failed to get totp secret", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+	if t.Enabled {
+		return resp, srvErrors.ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := totpStep(t.Secret, code, f.now())
+	if !ok {
+		return resp, srvErrors.ErrAuthInvalidOTP
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		f.logger.Error("failed to generate recovery codes", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	err = f.repository.Enable(ctx, userID, step, hashes)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNoRowsUpdated) {
+			return resp, srvErrors.ErrAuthInvalidOTP
+		}
+		f.logger.Error("failed to enable totp", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	resp.Codes = codes
+	return resp, nil
+}
+
+// Disable выключает двухфакторную аутентификацию, code - код из приложения или код восстановления.
+func (f *TwoFactor) Disable(ctx context.Context, code string) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		f.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	t, err := f.repository.Get(ctx, userID)
+	if err != nil && !errors.Is(err, repErrors.ErrNotFound) {
+		f.logger.Error("failed to get totp secret", err)
+		return srvErrors.ErrUnexpected
+	}
+	if !t.Enabled {
+		return srvErrors.ErrTOTPNotEnabled
+	}
+
+	err = verifySecondFactor(ctx, f.repository, f.logger, t, code, f.now())
+	if err != nil {
+		return err
+	}
+
+	if err := f.repository.Delete(ctx, userID); err != nil {
+		f.logger.Error("failed to delete totp secret", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// verifySecondFactor проверяет код из приложения или код восстановления
+// и отмечает его использованным, чтобы его нельзя было предъявить повторно.
+func verifySecondFactor(
+	ctx context.Context,
+	r TOTPRepository,
+	l Logger,
+	t entity.TOTP,
+	code string,
+	now time.Time,
+) error {
+	if step, ok := totpStep(t.Secret, code, now); ok {
+		err := r.UseStep(ctx, t.UserID, step)
+		if err != nil {
+			if errors.Is(err, repErrors.ErrNoRowsUpdated) {
+				return srvErrors.ErrAuthInvalidOTP
+			}
+			l.Error("failed to use totp step", err)
+			return srvErrors.ErrUnexpected
+		}
+		return nil
+	}
+
+	err := r.UseRecoveryCode(ctx, t.UserID, hashRecoveryCode(code))
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return srvErrors.ErrAuthInvalidOTP
+		}
+		l.Error("failed to use recovery code", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// totpStep возвращает шаг, для которого code верен в момент now
+// с учетом допустимого расхождения часов.
+func totpStep(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	opts := hotp.ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	current := now.Unix() / totpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		ok, err := hotp.ValidateCustom(code, uint64(step), secret, opts)
+		if err == nil && ok {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// newRecoveryCodes генерирует коды восстановления вида xxxxx-xxxxx и их хэши для хранения в БД.
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	for range recoveryCodesCount {
+		b, err := crypto.GenerateRandomBytes(recoveryCodeLen)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s := strings.ToLower(encoding.EncodeToString(b))
+		code := s[:5] + "-" + s[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode хэш кода восстановления для хранения в БД,
+// регистр, пробелы и дефисы при вводе не важны.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// testNow фиксированное время для проверки кодов, шаг testStep
+var testNow = time.Unix(1760700015, 0)
+
+const testStep = 1760700015 / totpPeriod
+
+func Test_totpStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current_step", code: testTOTPCode(t, testStep), wantStep: testStep, wantOK: true},
+		{name: "previous_step", code: testTOTPCode(t, testStep-1), wantStep: testStep - 1, wantOK: true},
+		{name: "next_step", code: " " + testTOTPCode(t, testStep+1) + " ", wantStep: testStep + 1, wantOK: true},
+		{name: "too_old", code: testTOTPCode(t, testStep-2)},
+		{name: "too_new", code: testTOTPCode(t, testStep+2)},
+		{name: "not_a_code", code: "abcdef"},
+		{name: "empty", code: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, ok := totpStep(testTOTPSecret, test.code, testNow)
+			assert.Equal(t, test.wantOK, ok, "Code is valid")
+			assert.Equal(t, test.wantStep, step, "Code step")
+		})
+	}
+}
+
+func Test_newRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.Nil(t, err, "Generate recovery codes")
+	require.Len(t, codes, recoveryCodesCount, "Recovery codes count")
+	require.Len(t, hashes, recoveryCodesCount, "Recovery code hashes count")
+
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	for i, code := range codes {
+		assert.Regexp(t, format, code, "Recovery code format")
+		assert.Equal(t, hashes[i], hashRecoveryCode(code), "Recovery code hash")
+		// ввод без дефиса и в верхнем регистре дает тот же хэш
+		input := strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+		assert.Equal(t, hashes[i], hashRecoveryCode(input), "Recovery code input hash")
+	}
+}
+
+func TestTwoFactor_Setup(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		uSetup  func(t *testing.T) UserRepository
+		rSetup  func(t *testing.T) TOTPRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			uSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				users := mocks.NewMockUserRepository(ctrl)
+				users.EXPECT().GetByID(gomock.All(), userID).Return(entity.User{ID: userID, Login: "alice"}, nil)
+				return users
+			},
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Save(gomock.All(), gomock.All()).Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			name: "already_enabled",
+			ctx:  goodCtx,
+			uSetup: func(t *testing.T) UserRepository {
+				ctrl := gomock.NewController(t)
+				users := mocks.NewMockUserRepository(ctrl)
+				users.EXPECT().GetByID(gomock.All(), userID).Return(entity.User{ID: userID, Login: "alice"}, nil)
+				return users
+			},
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Save(gomock.All(), gomock.All()).Return(repErrors.ErrNoRowsUpdated)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrTOTPAlreadyEnabled,
+		},
+		{
+			name: "without_user",
+			ctx:  context.Background(),
+			uSetup: func(t *testing.T) UserRepository {
+				return mocks.NewMockUserRepository(gomock.NewController(t))
+			},
+			rSetup: func(t *testing.T) TOTPRepository {
+				return mocks.NewMockTOTPRepository(gomock.NewController(t))
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := NewTwoFactor(test.lSetup(t), test.uSetup(t), test.rSetup(t))
+			setup, err := service.Setup(test.ctx)
+
+			assert.ErrorIs(t, err, test.wantErr, "Setup error")
+			if err != nil {
+				return
+			}
+
+			key, err := otp.NewKeyFromURL(setup.URI)
+			require.Nil(t, err, "Parse otpauth URI")
+			assert.Equal(t, setup.Secret, key.Secret(), "Secret in URI")
+			assert.Equal(t, totpIssuer, key.Issuer(), "Issuer in URI")
+			assert.Equal(t, "alice", key.AccountName(), "Account in URI")
+		})
+	}
+}
+
+func TestTwoFactor_Enable(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+	pending := entity.TOTP{UserID: userID, Secret: testTOTPSecret}
+	enabled := entity.TOTP{UserID: userID, Secret: testTOTPSecret, Enabled: true}
+
+	tests := []struct {
+		name    string
+		code    string
+		rSetup  func(t *testing.T) TOTPRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(pending, nil)
+				repository.EXPECT().
+					Enable(gomock.All(), userID, int64(testStep), gomock.Len(recoveryCodesCount)).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+		},
+		{
+			name: "not_set_up",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(entity.TOTP{}, repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrTOTPNotSetUp,
+		},
+		{
+			name: "already_enabled",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrTOTPAlreadyEnabled,
+		},
+		{
+			name: "invalid_code",
+			code: testTOTPCode(t, testStep-5),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(pending, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				return mocks.NewMockLogger(gomock.NewController(t))
+			},
+			wantErr: srvErrors.ErrAuthInvalidOTP,
+		},
+		{
+			name: "repository_error",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(pending, nil)
+				repository.EXPECT().
+					Enable(gomock.All(), userID, int64(testStep), gomock.Any()).
+					Return(fmt.Errorf("any error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error("failed to enable totp", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users := mocks.NewMockUserRepository(gomock.NewController(t))
+			service := NewTwoFactor(test.lSetup(t), users, test.rSetup(t))
+			service.now = func() time.Time { return testNow }
+
+			resp, err := service.Enable(goodCtx, test.code)
+
+			assert.ErrorIs(t, err, test.wantErr, "Enable error")
+			if err == nil {
+				assert.Len(t, resp.Codes, recoveryCodesCount, "Recovery codes")
+			}
+		})
+	}
+}
+
+func TestTwoFactor_Disable(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+	enabled := entity.TOTP{UserID: userID, Secret: testTOTPSecret, Enabled: true}
+
+	tests := []struct {
+		name    string
+		code    string
+		rSetup  func(t *testing.T) TOTPRepository
+		wantErr error
+	}{
+		{
+			name: "success_totp",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				repository.EXPECT().UseStep(gomock.All(), userID, int64(testStep)).Return(nil)
+				repository.EXPECT().Delete(gomock.All(), userID).Return(nil)
+				return repository
+			},
+		},
+		{
+			name: "success_recovery_code",
+			code: "abcde-fghij",
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				repository.EXPECT().UseRecoveryCode(gomock.All(), userID, hashRecoveryCode("abcdefghij")).Return(nil)
+				repository.EXPECT().Delete(gomock.All(), userID).Return(nil)
+				return repository
+			},
+		},
+		{
+			name: "not_enabled",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(entity.TOTP{}, repErrors.ErrNotFound)
+				return repository
+			},
+			wantErr: srvErrors.ErrTOTPNotEnabled,
+		},
+		{
+			name: "invalid_code",
+			code: "000000",
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				repository.EXPECT().
+					UseRecoveryCode(gomock.All(), userID, gomock.Any()).
+					Return(repErrors.ErrNotFound)
+				return repository
+			},
+			wantErr: srvErrors.ErrAuthInvalidOTP,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			users := mocks.NewMockUserRepository(ctrl)
+			logger := mocks.NewMockLogger(ctrl)
+			service := NewTwoFactor(logger, users, test.rSetup(t))
+			service.now = func() time.Time { return testNow }
+
+			err := service.Disable(goodCtx, test.code)
+			assert.ErrorIs(t, err, test.wantErr, "Disable error")
+		})
+	}
+}
+
+func TestAuth_checkSecondFactor(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	enabled := entity.TOTP{UserID: userID, Secret: testTOTPSecret, Enabled: true}
+
+	tests := []struct {
+		name    string
+		code    string
+		rSetup  func(t *testing.T) TOTPRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "2fa_not_set_up",
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(entity.TOTP{}, repErrors.ErrNotFound)
+				return repository
+			},
+		},
+		{
+			name: "2fa_not_confirmed",
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().
+					Get(gomock.All(), userID).
+					Return(entity.TOTP{UserID: userID, Secret: testTOTPSecret}, nil)
+				return repository
+			},
+		},
+		{
+			name: "code_required",
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				return repository
+			},
+			wantErr: srvErrors.ErrAuthOTPRequired,
+		},
+		{
+			name: "valid_code",
+			code: testTOTPCode(t, testStep-1),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				repository.EXPECT().UseStep(gomock.All(), userID, int64(testStep-1)).Return(nil)
+				return repository
+			},
+		},
+		{
+			name: "replayed_code",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(enabled, nil)
+				repository.EXPECT().
+					UseStep(gomock.All(), userID, int64(testStep)).
+					Return(repErrors.ErrNoRowsUpdated)
+				return repository
+			},
+			wantErr: srvErrors.ErrAuthInvalidOTP,
+		},
+		{
+			name: "repository_error",
+			code: testTOTPCode(t, testStep),
+			rSetup: func(t *testing.T) TOTPRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockTOTPRepository(ctrl)
+				repository.EXPECT().Get(gomock.All(), userID).Return(entity.TOTP{}, fmt.Errorf("any error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().Error("failed to get totp secret", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logger Logger = mocks.NewMockLogger(gomock.NewController(t))
+			if test.lSetup != nil {
+				logger = test.lSetup(t)
+			}
+
+			authService := &Auth{totp: test.rSetup(t), logger: logger}
+			authService.now = func() time.Time { return testNow }
+
+			err := authService.checkSecondFactor(context.Background(), userID, test.code)
+			assert.ErrorIs(t, err, test.wantErr, "Check second factor error")
+		})
+	}
+}
+
+func testTOTPCode(t *testing.T, step int64) string {
+	opts := hotp.ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	code, err := hotp.GenerateCodeCustom(testTOTPSecret, uint64(step), opts)
+	require.Nil(t, err, "Generate TOTP code")
+	return code
+}
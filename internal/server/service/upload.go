@@ -0,0 +1,161 @@
+// Пакет service содержит сервисный слой серверной части приложения
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+)
+
+type UploadRepository interface {
+	// Create создает сессию загрузки секрета по частям, возвращает ее ID.
+	Create(ctx context.Context, upload entity.Upload) (string, error)
+	// PutChunk сохраняет часть n незавершенной загрузки пользователя,
+	// если части незавершенных загрузок помещаются в quota.
+	PutChunk(ctx context.Context, uploadID, userID string, n uint32, data []byte, quota entity.Quota) error
+	// Status возвращает состояние загрузки пользователя.
+	Status(ctx context.Context, uploadID, userID string) (entity.UploadStatus, error)
+	// Commit завершает загрузку пользователя из chunks частей, если данные помещаются в quota.
+	Commit(ctx context.Context, uploadID, userID string, chunks uint32, quota entity.Quota) error
+}
+
+// Upload сервис загрузки секретов по частям
+type Upload struct {
+	logger     Logger
+	repository UploadRepository
+	quota      entity.Quota
+}
+
+// NewUpload создает сервис загрузки по частям, размер и количество секретов пользователя ограничены quota.
+func NewUpload(l Logger, r UploadRepository, quota entity.Quota) *Upload {
+	return &Upload{logger: l, repository: r, quota: quota}
+}
+
+// Create создает сессию загрузки секрета по частям.
+// Если upload.SecretID не 0, после завершения загрузки будут заменены данные этого секрета.
+// Если запрос не прошел проверку, возвращает ошибку, содержащую srvErrors.ErrSecretInvalidData.
+func (u *Upload) Create(ctx context.Context, upload *dto.UploadRequest) (dto.UploadResponse, error) {
+	var resp dto.UploadResponse
+
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		u.logger.Error("failed to get user id", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	if err := upload.Validate(); err != nil {
+		return resp, fmt.Errorf("%w: %w", srvErrors.ErrSecretInvalidData, err)
+	}
+
+	meta, err := json.Marshal(upload.Meta)
+	if err != nil {
+		u.logger.Error("failed encode secret metadata to json", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	resp.ID, err = u.repository.Create(ctx, entity.Upload{
+		UserID:       userID,
+		SecretID:     upload.SecretID,
+		Version:      upload.Version,
+		UID:          upload.UID,
+		DataType:     upload.DataType,
+		Name:         upload.Name,
+		MetaData:     string(meta),
+		EncryptedKey: upload.Key,
+		KeyVersion:   upload.KeyVersion,
+	})
+	if err != nil {
+		u.logger.Error("failed to create upload", err)
+		return resp, srvErrors.ErrUnexpected
+	}
+
+	return resp, nil
+}
+
+// PutChunk сохраняет часть n загрузки uploadID, если загрузка принадлежит текущему пользователю.
+// Если части незавершенных загрузок не помещаются в квоту пользователя, возвращает srvErrors.ErrQuotaExceeded.
+func (u *Upload) PutChunk(ctx context.Context, uploadID string, n uint32, data []byte) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		u.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	err = u.repository.PutChunk(ctx, uploadID, userID, n, data, u.quota)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNotFound):
+			return srvErrors.ErrUploadNotFound
+		case errors.Is(err, repErrors.ErrQuotaExceeded):
+			return srvErrors.ErrQuotaExceeded
+		}
+		u.logger.Error("failed to put upload chunk", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// Status возвращает состояние загрузки uploadID, если загрузка принадлежит текущему пользователю.
+func (u *Upload) Status(ctx context.Context, uploadID string) (dto.UploadStatus, error) {
+	status := dto.UploadStatus{ID: uploadID}
+
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		u.logger.Error("failed to get user id", err)
+		return status, srvErrors.ErrUnexpected
+	}
+
+	entity, err := u.repository.Status(ctx, uploadID, userID)
+	if err != nil {
+		if errors.Is(err, repErrors.ErrNotFound) {
+			return status, srvErrors.ErrUploadNotFound
+		}
+		u.logger.Error("failed to get upload status", err)
+		return status, srvErrors.ErrUnexpected
+	}
+
+	status.Received = entity.Received
+	status.Committed = entity.Committed
+	return status, nil
+}
+
+// Commit завершает загрузку uploadID, если она принадлежит текущему пользователю.
+// Если заменяемый секрет уже изменили, возвращает srvErrors.ErrSecretConflict,
+// если UID загрузки отличается от UID секрета, возвращает ошибку, содержащую srvErrors.ErrSecretInvalidData,
+// если данные не помещаются в квоту пользователя, возвращает srvErrors.ErrQuotaExceeded.
+func (u *Upload) Commit(ctx context.Context, uploadID string, commit *dto.UploadCommitRequest) error {
+	userID, err := srvContext.UserID(ctx)
+	if err != nil {
+		u.logger.Error("failed to get user id", err)
+		return srvErrors.ErrUnexpected
+	}
+
+	err = u.repository.Commit(ctx, uploadID, userID, commit.Chunks, u.quota)
+	if err != nil {
+		switch {
+		case errors.Is(err, repErrors.ErrNotFound):
+			return srvErrors.ErrUploadNotFound
+		case errors.Is(err, repErrors.ErrIncomplete):
+			return srvErrors.ErrUploadIncomplete
+		case errors.Is(err, repErrors.ErrNoRowsUpdated):
+			return srvErrors.ErrSecretConflict
+		case errors.Is(err, repErrors.ErrUIDMismatch):
+			return fmt.Errorf("%w: uid does not match the secret", srvErrors.ErrSecretInvalidData)
+		case errors.Is(err, repErrors.ErrQuotaExceeded):
+			return srvErrors.ErrQuotaExceeded
+		default:
+			u.logger.Error("failed to commit upload", err)
+			return srvErrors.ErrUnexpected
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/EshkinKot1980/GophKeeper/internal/common/dto"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/entity"
+
+	repErrors "github.com/EshkinKot1980/GophKeeper/internal/server/repository/errors"
+	srvContext "github.com/EshkinKot1980/GophKeeper/internal/server/service/context"
+	srvErrors "github.com/EshkinKot1980/GophKeeper/internal/server/service/errors"
+	"github.com/EshkinKot1980/GophKeeper/internal/server/service/mocks"
+)
+
+func TestUpload_Create(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+	request := dto.UploadRequest{
+		SecretID: 13,
+		Version:  3,
+		DataType: dto.SecretTypeFile,
+		Name:     "name",
+		Meta:     []dto.MetaData{},
+		Key:      "key",
+	}
+
+	type want struct {
+		resp dto.UploadResponse
+		err  error
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		request dto.UploadRequest
+		rSetup  func(t *testing.T) UploadRepository
+		lSetup  func(t *testing.T) Logger
+		want    want
+	}{
+		{
+			name:    "success",
+			ctx:     goodCtx,
+			request: request,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Create(gomock.All(), entity.Upload{
+						UserID:       userID,
+						SecretID:     13,
+						Version:      3,
+						DataType:     dto.SecretTypeFile,
+						Name:         "name",
+						MetaData:     "[]",
+						EncryptedKey: "key",
+					}).
+					Return("upload-id", nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				resp: dto.UploadResponse{ID: "upload-id"},
+			},
+		},
+		{
+			name:    "without_key",
+			ctx:     goodCtx,
+			request: dto.UploadRequest{Name: "name"},
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				err: srvErrors.ErrSecretInvalidData,
+			},
+		},
+		{
+			name:    "without_user",
+			ctx:     context.TODO(),
+			request: request,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+		{
+			name:    "repository_error",
+			ctx:     goodCtx,
+			request: request,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Create(gomock.All(), gomock.All()).
+					Return("", fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to create upload", gomock.All())
+				return logger
+			},
+			want: want{
+				err: srvErrors.ErrUnexpected,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			uploadService := NewUpload(logger, repository, testQuota)
+
+			resp, err := uploadService.Create(test.ctx, &test.request)
+			assert.ErrorIs(t, err, test.want.err, "Create upload error")
+			if err == nil {
+				assert.Equal(t, test.want.resp, resp, "Upload response")
+			}
+		})
+	}
+}
+
+func TestUpload_PutChunk(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+	data := []byte("chunk data")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rSetup  func(t *testing.T) UploadRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					PutChunk(gomock.All(), "upload-id", userID, uint32(2), data, testQuota).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+		{
+			name: "upload_not_found",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					PutChunk(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrUploadNotFound,
+		},
+		{
+			name: "quota_exceeded",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					PutChunk(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrQuotaExceeded)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrQuotaExceeded,
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					PutChunk(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to put upload chunk", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			uploadService := NewUpload(logger, repository, testQuota)
+
+			err := uploadService.PutChunk(test.ctx, "upload-id", 2, data)
+			assert.ErrorIs(t, err, test.wantErr, "Put chunk error")
+		})
+	}
+}
+
+func TestUpload_Status(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	type want struct {
+		status dto.UploadStatus
+		err    error
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		rSetup func(t *testing.T) UploadRepository
+		lSetup func(t *testing.T) Logger
+		want   want
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Status(gomock.All(), "upload-id", userID).
+					Return(entity.UploadStatus{Received: 5}, nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				status: dto.UploadStatus{ID: "upload-id", Received: 5},
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			want: want{
+				status: dto.UploadStatus{ID: "upload-id"},
+				err:    srvErrors.ErrUnexpected,
+			},
+		},
+		{
+			name: "upload_not_found",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Status(gomock.All(), gomock.All(), gomock.All()).
+					Return(entity.UploadStatus{}, repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			want: want{
+				status: dto.UploadStatus{ID: "upload-id"},
+				err:    srvErrors.ErrUploadNotFound,
+			},
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Status(gomock.All(), gomock.All(), gomock.All()).
+					Return(entity.UploadStatus{}, fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get upload status", gomock.All())
+				return logger
+			},
+			want: want{
+				status: dto.UploadStatus{ID: "upload-id"},
+				err:    srvErrors.ErrUnexpected,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			uploadService := NewUpload(logger, repository, testQuota)
+
+			status, err := uploadService.Status(test.ctx, "upload-id")
+			assert.ErrorIs(t, err, test.want.err, "Status error")
+			assert.Equal(t, test.want.status, status, "Upload status")
+		})
+	}
+}
+
+func TestUpload_Commit(t *testing.T) {
+	userID := "1ed655b6-0738-4162-a34a-34257c0dc106"
+	goodCtx := srvContext.SetUserID(context.Background(), userID)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rSetup  func(t *testing.T) UploadRepository
+		lSetup  func(t *testing.T) Logger
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), "upload-id", userID, uint32(3), testQuota).
+					Return(nil)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+		},
+		{
+			name: "without_user",
+			ctx:  context.TODO(),
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockUploadRepository(ctrl)
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to get user id", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+		{
+			name: "upload_not_found",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrNotFound)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrUploadNotFound,
+		},
+		{
+			name: "incomplete",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrIncomplete)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrUploadIncomplete,
+		},
+		{
+			name: "conflict",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrNoRowsUpdated)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretConflict,
+		},
+		{
+			name: "uid_mismatch",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrUIDMismatch)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrSecretInvalidData,
+		},
+		{
+			name: "quota_exceeded",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(repErrors.ErrQuotaExceeded)
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				return mocks.NewMockLogger(ctrl)
+			},
+			wantErr: srvErrors.ErrQuotaExceeded,
+		},
+		{
+			name: "repository_error",
+			ctx:  goodCtx,
+			rSetup: func(t *testing.T) UploadRepository {
+				ctrl := gomock.NewController(t)
+				repository := mocks.NewMockUploadRepository(ctrl)
+				repository.EXPECT().
+					Commit(gomock.All(), gomock.All(), gomock.All(), gomock.All(), gomock.All()).
+					Return(fmt.Errorf("repository error"))
+				return repository
+			},
+			lSetup: func(t *testing.T) Logger {
+				ctrl := gomock.NewController(t)
+				logger := mocks.NewMockLogger(ctrl)
+				logger.EXPECT().
+					Error("failed to commit upload", gomock.All())
+				return logger
+			},
+			wantErr: srvErrors.ErrUnexpected,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := test.rSetup(t)
+			logger := test.lSetup(t)
+			uploadService := NewUpload(logger, repository, testQuota)
+
+			err := uploadService.Commit(test.ctx, "upload-id", &dto.UploadCommitRequest{Chunks: 3})
+			assert.ErrorIs(t, err, test.wantErr, "Commit upload error")
+		})
+	}
+}